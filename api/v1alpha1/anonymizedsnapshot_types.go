@@ -0,0 +1,105 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AnonymizedSnapshotSpec defines the desired state of AnonymizedSnapshot
+type AnonymizedSnapshotSpec struct {
+	// Source is the ManagedDatabase in the same namespace which is copied
+	Source string `json:"source"`
+
+	// Target is the ManagedDatabase in the same namespace which the copy is
+	// restored into. The copied tables are dropped and created again in it,
+	// so it must be annotated with
+	// dbaoperator.app-sre.redhat.com/snapshot-target: "true".
+	Target string `json:"target"`
+
+	// Tables limits the copy to the listed tables, every table of the
+	// source is copied when empty
+	Tables []string `json:"tables,omitempty"`
+
+	// Masks hide the values of columns as they are copied. Columns which
+	// aren't listed are copied unchanged.
+	Masks []TableMask `json:"masks,omitempty"`
+
+	// Export is run as a Job once the copy is complete, with the target's
+	// connection string in DBA_OP_CONNECTION_STRING, for example to dump
+	// the copy to object storage
+	Export *corev1.Container `json:"export,omitempty"`
+}
+
+// TableMask lists the masked columns of a table
+type TableMask struct {
+	Table string `json:"table"`
+
+	// +kubebuilder:validation:MinItems=1
+	Columns []MaskedColumn `json:"columns"`
+}
+
+// AnonymizedSnapshotStatus defines the observed state of AnonymizedSnapshot
+type AnonymizedSnapshotStatus struct {
+	// Phase is Copying while the tables are copied, Exporting while the
+	// export Job runs, and Succeeded or Failed once the snapshot is done.
+	// Finished snapshots are never taken again.
+	Phase       string       `json:"phase,omitempty"`
+	Message     string       `json:"message,omitempty"`
+	StartedAt   *metav1.Time `json:"startedAt,omitempty"`
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// SourceVersion is the schema version of the source when it was copied
+	SourceVersion string `json:"sourceVersion,omitempty"`
+
+	// Tables counts the rows copied from each table
+	Tables []SnapshotTableStatus `json:"tables,omitempty"`
+
+	// TemporaryErrorRetries counts the attempts which failed with a
+	// temporary error, the delay before the next attempt grows with it
+	TemporaryErrorRetries int `json:"temporaryErrorRetries,omitempty"`
+}
+
+// SnapshotTableStatus is the number of rows copied from a table
+type SnapshotTableStatus struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+// +kubebuilder:object:root=true
+
+// AnonymizedSnapshot is the Schema for the anonymizedsnapshots API
+type AnonymizedSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AnonymizedSnapshotSpec   `json:"spec,omitempty"`
+	Status AnonymizedSnapshotStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AnonymizedSnapshotList contains a list of AnonymizedSnapshot
+type AnonymizedSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AnonymizedSnapshot `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AnonymizedSnapshot{}, &AnonymizedSnapshotList{})
+}
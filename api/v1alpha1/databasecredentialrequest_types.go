@@ -0,0 +1,98 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ManagedDatabaseReference identifies a ManagedDatabase in any namespace
+type ManagedDatabaseReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// DatabaseCredentialRequestSpec defines the desired state of DatabaseCredentialRequest
+type DatabaseCredentialRequestSpec struct {
+	ManagedDatabase ManagedDatabaseReference `json:"managedDatabase"`
+
+	// ServiceAccountName is the service account in the requesting namespace
+	// which will consume the credentials, and is checked against the
+	// ManagedDatabase's credential request policies.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Class selects the privileges of the issued user. The masked class may
+	// only select from the ManagedDatabase's masked views. The cdc class is
+	// read-only and may also stream the binary log, for change data capture
+	// consumers such as Debezium.
+	// +kubebuilder:validation:Enum=readwrite;readonly;masked;cdc
+	Class string `json:"class,omitempty"`
+
+	// Authentication selects how the issued user authenticates. With
+	// workload-identity the database maps tokens of ServiceAccountName to the
+	// user through the ManagedDatabase's workload identity provider, and the
+	// secret only contains connection metadata. Password is the default.
+	// +kubebuilder:validation:Enum=password;workload-identity
+	Authentication string `json:"authentication,omitempty"`
+
+	// SecretName is the name of the secret which will be written to the
+	// requesting namespace, the name of the request is used when empty.
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// DatabaseCredentialRequestStatus defines the observed state of DatabaseCredentialRequest
+type DatabaseCredentialRequestStatus struct {
+	Granted    bool                   `json:"granted,omitempty"`
+	Username   string                 `json:"username,omitempty"`
+	SecretName string                 `json:"secretName,omitempty"`
+	Errors     []ManagedDatabaseError `json:"errors,omitempty"`
+
+	// Class is the grant class the credentials were provisioned with, which
+	// the credential approval policy may have changed from the requested one
+	Class string `json:"class,omitempty"`
+
+	// RequestedClass is the class which was requested when the credentials
+	// were provisioned, the grants are applied again when it changes
+	RequestedClass string `json:"requestedClass,omitempty"`
+
+	// TemporaryErrorRetries counts the reconciles in a row which failed
+	// with a temporary error, the delay before the next retry grows with it
+	TemporaryErrorRetries int `json:"temporaryErrorRetries,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DatabaseCredentialRequest is the Schema for the databasecredentialrequests API
+type DatabaseCredentialRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DatabaseCredentialRequestSpec   `json:"spec,omitempty"`
+	Status DatabaseCredentialRequestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DatabaseCredentialRequestList contains a list of DatabaseCredentialRequest
+type DatabaseCredentialRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatabaseCredentialRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DatabaseCredentialRequest{}, &DatabaseCredentialRequestList{})
+}
@@ -17,6 +17,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -34,6 +35,96 @@ type DatabaseMigrationSpec struct {
 	MigrationContainerSpec corev1.Container              `json:"migrationContainerSpec,omitempty"`
 	Scalable               bool                          `json:"scalable,omitempty"`
 	SchemaHints            []DatabaseMigrationSchemaHint `json:"schemaHints"`
+
+	// Breaking is set on migrations which drop or rename something that
+	// the previous schema version had, versions of the app which need an
+	// earlier schema version can't run once it is applied
+	Breaking bool `json:"breaking,omitempty"`
+
+	// Grants replaces the grants of the ManagedDatabase for the credentials
+	// issued for this schema version, so that the users of earlier versions
+	// don't have access to the objects which it adds
+	Grants []DatabaseGrant `json:"grants,omitempty"`
+
+	// PostMigration describes table maintenance to run once the migration
+	// has completed, none is run when empty
+	PostMigration *PostMigrationSpec `json:"postMigration,omitempty"`
+
+	// ActiveDeadline bounds how long the migration Job may run before it is
+	// aborted, there is no limit when empty
+	ActiveDeadline *metav1.Duration `json:"activeDeadline,omitempty"`
+
+	// Guard aborts the migration when it puts too much load on the database
+	// while it is running
+	Guard *MigrationGuardSpec `json:"guard,omitempty"`
+
+	// Embedded has the operator apply the migration's SQL files itself
+	// instead of running MigrationContainerSpec in a Job, which requires
+	// the embedded migration engine
+	Embedded *EmbeddedMigrationSpec `json:"embedded,omitempty"`
+
+	// Bundle is pulled from a registry and its signature verified before it
+	// is applied by the embedded runner, or mounted into the migration Job
+	Bundle *MigrationBundleSpec `json:"bundle,omitempty"`
+
+	// ImagePullSecret names a kubernetes.io/dockerconfigjson Secret with the
+	// credentials of the registry of MigrationContainerSpec's image, which
+	// the Job pulls the image with and the operator verifies its signature
+	ImagePullSecret string `json:"imagePullSecret,omitempty"`
+}
+
+// EmbeddedMigrationSpec names the SQL files of a migration which the operator
+// applies itself
+type EmbeddedMigrationSpec struct {
+	// ConfigMap contains the files, the keys ending in .sql are applied in
+	// the order of their names. The files are read from the bundle of the
+	// migration instead when it has one.
+	ConfigMap string `json:"configMap,omitempty"`
+}
+
+// MigrationBundleSpec locates the SQL files of a migration which are
+// distributed as an OCI artifact
+type MigrationBundleSpec struct {
+	// Reference is written as oci://registry/repository:tag, or
+	// oci://registry/repository@sha256:digest to pin the bundle
+	Reference string `json:"reference"`
+
+	// PullSecret names a kubernetes.io/dockerconfigjson Secret with the
+	// credentials of the registry, if it requires them
+	PullSecret string `json:"pullSecret,omitempty"`
+}
+
+// MigrationGuardSpec contains the thresholds beyond which a running
+// migration is aborted, the thresholds which are left empty aren't checked
+type MigrationGuardSpec struct {
+	// MaxBinlogGrowth is how much the binary logs may grow while the
+	// migration is running
+	MaxBinlogGrowth *resource.Quantity `json:"maxBinlogGrowth,omitempty"`
+
+	// MaxReplicaLag is how far behind the replicas may fall
+	MaxReplicaLag metav1.Duration `json:"maxReplicaLag,omitempty"`
+
+	// MaxLockWait is how long any session may wait for a metadata lock on
+	// the database's tables
+	MaxLockWait metav1.Duration `json:"maxLockWait,omitempty"`
+
+	// CheckInterval is how often the thresholds are checked, defaults to 30s
+	CheckInterval metav1.Duration `json:"checkInterval,omitempty"`
+}
+
+// PostMigrationSpec describes table maintenance which keeps the query planner
+// statistics accurate after large changes
+type PostMigrationSpec struct {
+	// AnalyzeTables lists the tables to refresh statistics for, the tables
+	// named in the schema hints are used when empty
+	AnalyzeTables []string `json:"analyzeTables,omitempty"`
+
+	// Optimize also rebuilds the tables, which reclaims the space left
+	// behind by large backfills but takes much longer
+	Optimize bool `json:"optimize,omitempty"`
+
+	// TimeLimit bounds how long the maintenance may run, defaults to 10m
+	TimeLimit metav1.Duration `json:"timeLimit,omitempty"`
 }
 
 // DatabaseMigrationStatus defines the observed state of DatabaseMigration
@@ -0,0 +1,88 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DatabaseOperationSpec defines the desired state of DatabaseOperation
+type DatabaseOperationSpec struct {
+	// ManagedDatabase is the name of the ManagedDatabase in the same
+	// namespace which the operation is run against
+	ManagedDatabase string `json:"managedDatabase"`
+
+	// Action is the one-shot action to run. rotate-credentials rotates the
+	// passwords of the database's credentials, reconcile-grants grants the
+	// migration users their privileges from the spec again, kill-sessions
+	// terminates the sessions of Username, drift-scan checks the server
+	// parameters, helper routines, masked views and character set,
+	// upgrade-check reports what would break after upgrading the server to
+	// TargetVersion, and failover-drill fails the primary over as the
+	// database's failoverDrill approves of.
+	// +kubebuilder:validation:Enum=rotate-credentials;reconcile-grants;kill-sessions;drift-scan;upgrade-check;failover-drill
+	Action string `json:"action"`
+
+	// Username is the user whose sessions are killed, it must be a user
+	// which the operator issued
+	Username string `json:"username,omitempty"`
+
+	// TargetVersion is the major version of the server which upgrade-check
+	// prepares for, such as 8.0
+	TargetVersion string `json:"targetVersion,omitempty"`
+}
+
+// DatabaseOperationStatus defines the observed state of DatabaseOperation
+type DatabaseOperationStatus struct {
+	// Phase is Running while the action runs, and Succeeded or Failed once
+	// it is done. Finished operations are never run again.
+	Phase       string       `json:"phase,omitempty"`
+	Message     string       `json:"message,omitempty"`
+	StartedAt   *metav1.Time `json:"startedAt,omitempty"`
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// TemporaryErrorRetries counts the attempts which failed with a
+	// temporary error, the delay before the next attempt grows with it
+	TemporaryErrorRetries int `json:"temporaryErrorRetries,omitempty"`
+
+	// FailoverTriggeredAt is when a failover-drill triggered its failover,
+	// which is never triggered again by the same operation
+	FailoverTriggeredAt *metav1.Time `json:"failoverTriggeredAt,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DatabaseOperation is the Schema for the databaseoperations API
+type DatabaseOperation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DatabaseOperationSpec   `json:"spec,omitempty"`
+	Status DatabaseOperationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DatabaseOperationList contains a list of DatabaseOperation
+type DatabaseOperationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatabaseOperation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DatabaseOperation{}, &DatabaseOperationList{})
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -24,30 +25,1376 @@ import (
 
 // ManagedDatabaseSpec defines the desired state of ManagedDatabase
 type ManagedDatabaseSpec struct {
-	DesiredSchemaVersion string                 `json:"desiredSchemaVersion,omitempty"`
-	Connection           DatabaseConnectionInfo `json:"connection,omitempty"`
-	MigrationEngine      string                 `json:"migrationEngine,omitempty"`
+	// ClassName references a cluster scoped ManagedDatabaseClass, whose
+	// defaults fill in every field that is left empty here
+	ClassName string `json:"className,omitempty"`
+
+	// DesiredSchemaVersion names the DatabaseMigration to migrate to, or is
+	// a sha256: digest of either the chain of migrations ending at it or
+	// the image that it is pinned to
+	DesiredSchemaVersion string `json:"desiredSchemaVersion,omitempty"`
+
+	// AppVersions lists the versions of the application which use this
+	// database and the schema version that each of them needs, the operator
+	// reports whether each of them is safe to roll out
+	AppVersions []AppVersionRequirement `json:"appVersions,omitempty"`
+
+	// BlueGreen keeps the credentials of earlier schema versions for as long
+	// as app pods which use them remain, so that the app can be rolled out
+	// while both versions are running
+	BlueGreen *BlueGreenSpec `json:"blueGreen,omitempty"`
+
+	Connection      DatabaseConnectionInfo `json:"connection,omitempty"`
+	MigrationEngine string                 `json:"migrationEngine,omitempty"`
+
+	// Grants explicitly lists the databases on the instance which credentials
+	// issued for this ManagedDatabase may access. When empty, credentials are
+	// granted read-write access to the database named in the connection DSN.
+	Grants []DatabaseGrant `json:"grants,omitempty"`
+
+	// CredentialRequestPolicies lists which namespaces and service accounts
+	// may request credentials for this database with a
+	// DatabaseCredentialRequest. Requests which don't match any policy are
+	// denied.
+	CredentialRequestPolicies []CredentialRequestPolicy `json:"credentialRequestPolicies,omitempty"`
+
+	// SecretFormat adds the database settings of a framework to the
+	// credentials Secrets, next to username and password: spring adds an
+	// application.properties key, django DATABASE_URL, sqlalchemy
+	// SQLALCHEMY_DATABASE_URI and rails database.yml. It requires a
+	// connection spec with a single host.
+	// +kubebuilder:validation:Enum=spring;django;sqlalchemy;rails
+	SecretFormat string `json:"secretFormat,omitempty"`
+
+	// AuthPlugin selects the authentication plugin that issued credentials
+	// are created with, the server default is used when empty.
+	// +kubebuilder:validation:Enum=caching_sha2_password;mysql_native_password
+	AuthPlugin string `json:"authPlugin,omitempty"`
+
+	// Galera enables support for Galera based clusters such as Percona
+	// XtraDB Cluster
+	Galera *GaleraSpec `json:"galera,omitempty"`
+
+	// GroupReplication routes all user and schema changes to the current
+	// primary of a Group Replication (InnoDB Cluster) group, which is
+	// discovered through the member the connection points at
+	GroupReplication bool `json:"groupReplication,omitempty"`
+
+	// AutoPause supports databases which pause themselves while they have
+	// no connections, such as Aurora Serverless
+	AutoPause *AutoPauseSpec `json:"autoPause,omitempty"`
+
+	// ExportSchemaSnapshots writes the DDL of the database to a ConfigMap
+	// named after the schema version whenever a new version is reached
+	ExportSchemaSnapshots bool `json:"exportSchemaSnapshots,omitempty"`
+
+	// MetadataLockGuard watches for schema changes stalled behind metadata
+	// locks while a migration is running
+	MetadataLockGuard *MetadataLockGuardSpec `json:"metadataLockGuard,omitempty"`
+
+	// WorkloadIdentity allows DatabaseCredentialRequests to be granted users
+	// which authenticate with Kubernetes service account tokens
+	WorkloadIdentity *WorkloadIdentitySpec `json:"workloadIdentity,omitempty"`
+
+	// Rotation controls whether fleet credential rotation applies to the
+	// database
+	Rotation *RotationPolicy `json:"rotation,omitempty"`
+
+	// MaintenanceWindows restricts when migrations are started and when
+	// expired rows are purged, which may happen at any time when empty
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// Paused stops every operation which would change the database or its
+	// credentials, while the schema version and status are still reported.
+	// Migration jobs which are already running are left to finish.
+	Paused bool `json:"paused,omitempty"`
+
+	// Compatibility lists the server requirements which are verified before
+	// the database is changed in any way
+	Compatibility *CompatibilitySpec `json:"compatibility,omitempty"`
+
+	// DeprovisionQuarantine is how long a user which is no longer needed is
+	// locked before it is dropped, users are dropped as soon as they are
+	// unused when it is zero
+	DeprovisionQuarantine metav1.Duration `json:"deprovisionQuarantine,omitempty"`
+
+	// PlanApproval enables plan/apply mode for the users provisioned on the
+	// database. The statements are rendered into status.pendingPlan, and are
+	// only executed once their hash is approved.
+	PlanApproval *PlanApprovalSpec `json:"planApproval,omitempty"`
+
+	// PrivilegeTightening revokes the unused privileges suggested in
+	// status.privilegeTightening, once the hash of the suggestions is
+	// approved
+	PrivilegeTightening *PrivilegeTighteningSpec `json:"privilegeTightening,omitempty"`
+
+	// Service publishes the primary of the database as a Service in the
+	// namespace, so that applications can connect through a stable name
+	Service *ServicePublication `json:"service,omitempty"`
+
+	// HelperRoutines are stored routines shipped with the operator which
+	// should be created in the database. Grant the execute class to allow
+	// migrations and applications to call them.
+	HelperRoutines *HelperRoutinesSpec `json:"helperRoutines,omitempty"`
+
+	// MaskedViews are created in the database for the masked credential
+	// class, which may only select from these views
+	MaskedViews []MaskedView `json:"maskedViews,omitempty"`
+
+	// ScheduledStatements are recurring jobs, such as retention purges and
+	// partition rotation, which the server runs itself. They are kept in
+	// sync with the spec and removed when they are removed from it.
+	ScheduledStatements []ScheduledStatement `json:"scheduledStatements,omitempty"`
+
+	// Partitioning lists the time partitioned tables which the operator
+	// adds upcoming partitions to and drops expired partitions from
+	Partitioning []PartitionedTable `json:"partitioning,omitempty"`
+
+	// Retention lists the tables whose expired rows the operator deletes,
+	// in small batches during the maintenance windows
+	Retention []RetentionRule `json:"retention,omitempty"`
+
+	// SeedData are reference tables and bootstrap rows which are applied
+	// once the database has reached a schema version. Each source is
+	// applied again only when its content changes, so it must be idempotent.
+	SeedData []SeedDataSource `json:"seedData,omitempty"`
+
+	// ConsistencyChecks are pairs of queries whose results must match, for
+	// example while an application writes to both an old and a new schema.
+	// They are run on a schedule with read-only credentials.
+	ConsistencyChecks []ConsistencyCheck `json:"consistencyChecks,omitempty"`
+
+	// IntegrityChecks list columns which reference another table without a
+	// foreign key constraint. The operator scans them in the background for
+	// rows whose referenced row doesn't exist, and only ever reads them.
+	IntegrityChecks []IntegrityCheck `json:"integrityChecks,omitempty"`
+
+	// Replicas lists the read replicas of the database. A read-only user
+	// is created on the primary, from which it replicates, and published
+	// for the replicas. Requires a typed connection spec.
+	Replicas *ReplicaSpec `json:"replicas,omitempty"`
+
+	// OperatorSession contains session variables which are set on every
+	// connection that the operator itself opens to the database
+	OperatorSession *SessionSettings `json:"operatorSession,omitempty"`
+
+	// Charset is the default character set and collation which the
+	// database must have
+	Charset *CharsetSpec `json:"charset,omitempty"`
+
+	// Parameters are global server variables which the operator keeps at
+	// the specified values, and sets again when they are changed outside of
+	// it. They apply to the whole server, not only to this database.
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// Extensions are created in the database when they don't exist yet, by
+	// backends of engines which have them such as Postgres, of which there
+	// are none yet. The ExtensionsReady condition is false with the
+	// UnsupportedEngine reason for engines which don't, without failing the
+	// reconcile.
+	Extensions []DatabaseExtension `json:"extensions,omitempty"`
+
+	// VerifyGrants logs in as each newly provisioned user and checks that
+	// it can read every granted database and can't drop tables. Requires a
+	// typed connection spec.
+	VerifyGrants bool `json:"verifyGrants,omitempty"`
+
+	// FailoverDrill approves failover drills of the database, which fail
+	// its primary over on purpose and measure how long the operator and the
+	// issued credentials take to recover. No drill is run without it.
+	FailoverDrill *FailoverDrillSpec `json:"failoverDrill,omitempty"`
+
+	// SecretConvention templates the names, labels and annotations of the
+	// Secrets generated for the database. It can't be changed once they
+	// exist.
+	SecretConvention *SecretConvention `json:"secretConvention,omitempty"`
+}
+
+// SecretConvention contains Go templates of the metadata of generated
+// Secrets. They are rendered with the .Namespace, .Database, .Labels of the
+// ManagedDatabase, .Version of credentials Secrets, and .Kind of Secret:
+// credentials, credential-request, replica or connection.
+type SecretConvention struct {
+	// NameTemplate names the credentials Secret of each schema version,
+	// defaults to {{.Database}}-{{.Version}}
+	NameTemplate string `json:"nameTemplate,omitempty"`
+
+	// Labels are added to every generated Secret, the labels which the
+	// operator selects Secrets by take precedence
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to every generated Secret
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// FailoverDrillSpec describes how the primary of a database is failed over
+// during a drill, and when drills are run
+type FailoverDrillSpec struct {
+	// Method triggers the failover: rds-cluster fails an Aurora cluster
+	// over to a reader, rds-instance reboots a Multi-AZ instance onto its
+	// standby, and orchestrator asks orchestrator for a graceful takeover
+	// of the master
+	// +kubebuilder:validation:Enum=rds-cluster;rds-instance;orchestrator
+	Method string `json:"method"`
+
+	// Target is the identifier of the RDS cluster or instance, or the alias
+	// of the orchestrator cluster. Defaults to the first cloud identifier
+	// of the connection.
+	Target string `json:"target,omitempty"`
+
+	// Schedule is a cron expression, in UTC, of when drills are run. Without
+	// it drills are only run by failover-drill DatabaseOperations.
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// SessionSettings are session variables of the operator's connections,
+// unset fields leave the server's defaults in place
+type SessionSettings struct {
+	// SQLMode is a comma separated list of SQL modes
+	// +kubebuilder:validation:Pattern=^[A-Za-z_]*(,[A-Za-z_]+)*$
+	SQLMode string `json:"sqlMode,omitempty"`
+
+	// LockWaitTimeout is how long DDL waits for metadata locks, and
+	// InnoDBLockWaitTimeout how long statements wait for row locks. Both
+	// are rounded up to whole seconds.
+	LockWaitTimeout       metav1.Duration `json:"lockWaitTimeout,omitempty"`
+	InnoDBLockWaitTimeout metav1.Duration `json:"innodbLockWaitTimeout,omitempty"`
+
+	// StatementTimeout limits how long a statement may run, which servers
+	// other than MariaDB only enforce for SELECT statements
+	StatementTimeout metav1.Duration `json:"statementTimeout,omitempty"`
+}
+
+// CharsetSpec is the character set and collation that a database must
+// default to, and what the operator does when it doesn't
+type CharsetSpec struct {
+	// +kubebuilder:validation:Pattern=^[a-z][a-z0-9_]*$
+	CharacterSet string `json:"characterSet"`
+
+	// Collation is the required collation, any collation of the character
+	// set is accepted when it is empty, and conversion uses the character
+	// set's default collation
+	// +kubebuilder:validation:Pattern=^[a-z][a-z0-9_]*$
+	Collation string `json:"collation,omitempty"`
+
+	// Policy is fail to refuse the database, warn to only report it, or
+	// convert to change the database's default during a maintenance
+	// window. Tables which already exist are not converted.
+	// +kubebuilder:validation:Enum=fail;warn;convert
+	Policy string `json:"policy,omitempty"`
+}
+
+// ReplicaSpec describes the read replicas the read-only user is published
+// for
+type ReplicaSpec struct {
+	Endpoints []ReplicaEndpoint `json:"endpoints"`
+
+	// Combined publishes a single Secret with a list of every replica host,
+	// instead of a Secret for each endpoint
+	Combined bool `json:"combined,omitempty"`
+}
+
+// ReplicaEndpoint is the address of a read replica, the rest of the
+// connection is the same as for the primary
+type ReplicaEndpoint struct {
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	Name string `json:"name"`
+
+	Host string `json:"host"`
+	Port int32  `json:"port,omitempty"`
+}
+
+// RetentionRule describes which rows of a table expire, and how quickly
+// they are deleted. The timestamp column should be indexed, since rows are
+// deleted oldest first.
+type RetentionRule struct {
+	Name  string `json:"name"`
+	Table string `json:"table"`
+
+	// TimestampColumn is the DATE, DATETIME or TIMESTAMP column which rows
+	// expire by. It is compared against the server's clock in the time zone
+	// of its sessions.
+	TimestampColumn string `json:"timestampColumn"`
+
+	// Retain is how long rows are kept
+	Retain metav1.Duration `json:"retain"`
+
+	// BatchSize is how many rows each DELETE removes, 1000 by default
+	// +kubebuilder:validation:Minimum=1
+	BatchSize int32 `json:"batchSize,omitempty"`
+
+	// Pause is how long to wait between batches, one second by default
+	Pause metav1.Duration `json:"pause,omitempty"`
+
+	// MaxReplicaLag is how far behind the replicas may fall before purges
+	// back off, 30 seconds by default
+	MaxReplicaLag metav1.Duration `json:"maxReplicaLag,omitempty"`
+}
+
+// ConsistencyCheck compares the single numeric values which two queries
+// return, such as row counts or sums, on this or other ManagedDatabases
+type ConsistencyCheck struct {
+	Name string `json:"name"`
+
+	// Schedule is a cron expression, in UTC, for when the check is run
+	Schedule string `json:"schedule"`
+
+	Left  ConsistencyQuery `json:"left"`
+	Right ConsistencyQuery `json:"right"`
+
+	// Tolerance is how far apart the values may be, either as an absolute
+	// difference such as "10" or relative to the larger value such as
+	// "0.5%". The values must be equal when empty.
+	Tolerance string `json:"tolerance,omitempty"`
+}
+
+// IntegrityCheck is a column whose values must be found in a column of
+// another table
+type IntegrityCheck struct {
+	Name string `json:"name"`
+
+	// Table and Column hold the references, which should be indexed since
+	// the scan reads them in order
+	Table  string `json:"table"`
+	Column string `json:"column"`
+
+	// ReferencedTable and ReferencedColumn are where the values must exist,
+	// usually the primary key of the referenced table
+	ReferencedTable  string `json:"referencedTable"`
+	ReferencedColumn string `json:"referencedColumn"`
+
+	// BatchSize is how many rows each scan reads, 1000 by default
+	// +kubebuilder:validation:Minimum=1
+	BatchSize int32 `json:"batchSize,omitempty"`
+
+	// Interval is how long to wait between batches, one minute by default
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+// ConsistencyQuery is one side of a consistency check
+type ConsistencyQuery struct {
+	// ManagedDatabase in the same namespace which the query is run on, this
+	// one when empty. It must have a typed connection spec.
+	ManagedDatabase string `json:"managedDatabase,omitempty"`
+
+	// CredentialsSecret contains the username and password which the query
+	// is run with, such as the Secret of a readonly DatabaseCredentialRequest
+	CredentialsSecret string `json:"credentialsSecret"`
+
+	// Query must return a single row with a single numeric column. It is
+	// run in a read-only transaction.
+	Query string `json:"query"`
+}
+
+// SeedDataSource is seed data which is read from a ConfigMap, or applied by
+// a container which is run as a Job
+type SeedDataSource struct {
+	Name string `json:"name"`
+
+	// AfterVersion is the migration which creates the tables the seed data
+	// fills, it is applied once the database has reached it. When empty it
+	// is applied as soon as the database has a schema version.
+	AfterVersion string `json:"afterVersion,omitempty"`
+
+	// ConfigMap contains the seed data in keys ending in .sql, with INSERT,
+	// REPLACE, UPDATE and DELETE statements separated by semicolons, and in
+	// keys ending in .csv, with rows which replace those with the same keys
+	// in the table the key is named after. Keys are applied in order, in a
+	// single transaction.
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// Container is run to apply the seed data, with the connection string
+	// in DBA_OP_CONNECTION_STRING as for migrations
+	Container *corev1.Container `json:"container,omitempty"`
+}
+
+// PartitionedTable describes how a table that is partitioned by time is
+// maintained. The table must already be partitioned by RANGE COLUMNS on a
+// date, or by RANGE on TO_DAYS() or UNIX_TIMESTAMP() of one.
+type PartitionedTable struct {
+	Table string `json:"table"`
+
+	// +kubebuilder:validation:Enum=daily;weekly;monthly
+	Interval string `json:"interval"`
+
+	// Ahead is how many partitions after the current one are kept ready
+	// +kubebuilder:validation:Minimum=0
+	Ahead int `json:"ahead,omitempty"`
+
+	// Retention is how long partitions are kept after their range ends,
+	// they are never dropped when it is zero
+	Retention metav1.Duration `json:"retention,omitempty"`
+}
+
+// ScheduledStatement is SQL which the server runs on a fixed interval, as a
+// MySQL EVENT. The server's event scheduler must be enabled for it to run.
+type ScheduledStatement struct {
+	// +kubebuilder:validation:Pattern=^[A-Za-z0-9_]+$
+	Name string `json:"name"`
+
+	// Every is the time between runs, in whole seconds
+	Every metav1.Duration `json:"every"`
+
+	// Statement is run with the privileges of the operator's admin user, it
+	// may be a BEGIN ... END block
+	Statement string `json:"statement"`
+
+	// Disabled keeps the statement defined without running it
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// MaskedView exposes some of the columns of a table, masking the sensitive
+// ones
+type MaskedView struct {
+	Name  string `json:"name"`
+	Table string `json:"table"`
+
+	// +kubebuilder:validation:MinItems=1
+	Columns []MaskedColumn `json:"columns"`
+}
+
+// MaskedColumn is a column of a masked view, columns of the table which
+// aren't listed are left out of the view
+type MaskedColumn struct {
+	Name string `json:"name"`
+
+	// Mask hides the value of the column, it is passed through unchanged
+	// when empty
+	// +kubebuilder:validation:Enum=redact;hash;null
+	Mask string `json:"mask,omitempty"`
+}
+
+// HelperRoutinesSpec selects the helper routines to create
+type HelperRoutinesSpec struct {
+	// +kubebuilder:validation:MinItems=1
+	Names []HelperRoutine `json:"names"`
+
+	// RepairDrift replaces routines whose definitions have been changed in
+	// the database, otherwise they are only reported in
+	// status.driftedRoutines
+	RepairDrift bool `json:"repairDrift,omitempty"`
+}
+
+// HelperRoutine is the name of a stored routine shipped with the operator
+// +kubebuilder:validation:Enum=dba_soft_delete;dba_restore_deleted
+type HelperRoutine string
+
+// ServicePublication configures the Service which points at the primary
+type ServicePublication struct {
+	// Name of the Service, defaults to the name of the ManagedDatabase
+	// followed by -db
+	Name string `json:"name,omitempty"`
+}
+
+// PlanApprovalSpec contains the approval for the pending plan
+type PlanApprovalSpec struct {
+	// ApprovedHash is copied from status.pendingPlan.hash to execute the plan
+	ApprovedHash string `json:"approvedHash,omitempty"`
+}
+
+// PrivilegeTighteningSpec contains the approval for the suggested revocations
+type PrivilegeTighteningSpec struct {
+	// ApprovedHash is copied from status.privilegeTightening.hash to revoke
+	// the suggested privileges
+	ApprovedHash string `json:"approvedHash,omitempty"`
+}
+
+// CompatibilitySpec restricts the database servers that the operator will
+// manage a database on
+type CompatibilitySpec struct {
+	// MinServerVersion is the oldest acceptable version of the server's
+	// flavor, inclusive
+	MinServerVersion string `json:"minServerVersion,omitempty"`
+
+	// MaxServerVersion is the newest acceptable version of the server's
+	// flavor, inclusive
+	MaxServerVersion string `json:"maxServerVersion,omitempty"`
+
+	RequiredFeatures []ServerFeature `json:"requiredFeatures,omitempty"`
+}
+
+// ServerFeature is an optional capability of the database server
+// +kubebuilder:validation:Enum=roles;instant-ddl;check-constraints
+type ServerFeature string
+
+// RotationPolicy controls fleet credential rotation for a database
+type RotationPolicy struct {
+	// Disabled excludes the database from fleet credential rotation
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Schedule is a cron expression, in UTC, for when the credentials are
+	// rotated, e.g. "0 3 * * 0". A database with a schedule is left out of
+	// the fleet rotation passes.
+	Schedule string `json:"schedule,omitempty"`
+
+	// Jitter delays every scheduled rotation by up to this long. The delay
+	// is derived from the database's name, so it is the same each time and
+	// databases sharing a schedule are spread over the jitter.
+	Jitter metav1.Duration `json:"jitter,omitempty"`
+}
+
+// MaintenanceWindow is a recurring span of time, in UTC
+type MaintenanceWindow struct {
+	// Days are three letter day names, e.g. Sat, the window opens every day
+	// when empty
+	Days []string `json:"days,omitempty"`
+
+	// Start is the time of day formatted as HH:MM
+	Start    string          `json:"start"`
+	Duration metav1.Duration `json:"duration"`
+}
+
+// WorkloadIdentitySpec describes the OpenID Connect identity provider which
+// the database server trusts to issue tokens for Kubernetes workloads
+type WorkloadIdentitySpec struct {
+	// IdentityProvider is the name of the provider in the server's
+	// configuration
+	IdentityProvider string `json:"identityProvider"`
+}
+
+// MetadataLockGuardSpec controls what happens when a migration's schema change
+// is queued behind a metadata lock, which in turn queues all other access to
+// the table behind the schema change.
+type MetadataLockGuardSpec struct {
+	// Policy is alert to only send a notification, pause to cancel the
+	// waiting schema change so that the migration can be retried later, or
+	// kill-blocker to kill the session holding the lock
+	// +kubebuilder:validation:Enum=alert;pause;kill-blocker
+	Policy string `json:"policy"`
+
+	// MaxWait is how long a schema change may wait before the policy is
+	// applied, defaults to 30s
+	MaxWait metav1.Duration `json:"maxWait,omitempty"`
+
+	// CheckInterval is how often lock waits are checked, defaults to 10s
+	CheckInterval metav1.Duration `json:"checkInterval,omitempty"`
+}
+
+// GaleraSpec controls how changes are applied to a Galera cluster
+type GaleraSpec struct {
+	// RollingSchemaUpgrades applies DDL one node at a time with
+	// wsrep_OSU_method=RSU instead of blocking the whole cluster, and asks
+	// migration jobs to do the same
+	RollingSchemaUpgrades bool `json:"rollingSchemaUpgrades,omitempty"`
+}
+
+// AutoPauseSpec controls how the operator connects to a database which
+// pauses itself while idle. The operator never keeps idle connections open,
+// and a database which is resuming is retried after a few seconds.
+type AutoPauseSpec struct {
+	// WakeUp waits for a paused database to resume whenever the operator
+	// connects, so that migrations, rotations and operations don't fail on
+	// a cold database
+	WakeUp bool `json:"wakeUp,omitempty"`
+
+	// ResumeTimeout is how long to wait for the database to resume, one
+	// minute by default
+	ResumeTimeout metav1.Duration `json:"resumeTimeout,omitempty"`
+}
+
+// CredentialRequestPolicy allows DatabaseCredentialRequests from the listed
+// namespaces to be granted.
+type CredentialRequestPolicy struct {
+	Namespaces []string `json:"namespaces"`
+
+	// ServiceAccounts restricts the policy to requests made on behalf of the
+	// named service accounts, any service account matches when empty.
+	ServiceAccounts []string `json:"serviceAccounts,omitempty"`
+
+	// Classes restricts which grant classes may be requested, all classes
+	// are allowed when empty.
+	Classes []string `json:"classes,omitempty"`
+}
+
+// DatabaseGrant names a database on the managed instance and the class of
+// access that issued credentials should have to it.
+type DatabaseGrant struct {
+	Database string `json:"database"`
+
+	// +kubebuilder:validation:Enum=readwrite;readonly;execute
+	Class string `json:"class,omitempty"`
+
+	// Tables restricts the grant to some of the tables in the database, for
+	// users which may only see part of a shared schema
+	Tables []TableGrant `json:"tables,omitempty"`
+}
+
+// DatabaseExtension is a server extension which the database needs
+type DatabaseExtension struct {
+	// +kubebuilder:validation:Pattern=^[A-Za-z0-9_-]+$
+	Name string `json:"name"`
+
+	// Version constrains the installed version, such as ">= 1.8", any
+	// version is accepted when empty
+	// +kubebuilder:validation:Pattern=^((>=|<=|>|<|=) *)?[0-9]+(\.[0-9]+)*$
+	Version string `json:"version,omitempty"`
+}
+
+// TableGrant scopes a grant to one table
+type TableGrant struct {
+	Name string `json:"name"`
+
+	// Columns further restricts the grant to some of the table's columns,
+	// which is only allowed for the readonly class
+	Columns []string `json:"columns,omitempty"`
 }
 
 // DatabaseConnectionInfo defines engine specific connection parameters to establish
 // a connection to the database.
 type DatabaseConnectionInfo struct {
-	Engine    string `json:"engine,omitempty"`
+	Engine string `json:"engine,omitempty"`
+
+	// DSNSecret names a secret whose dsn key contains a raw connection
+	// string. Deprecated in favor of Spec, which is validated before use.
 	DSNSecret string `json:"dsnSecret,omitempty"`
+
+	// Spec describes the connection with typed fields, from which the
+	// operator builds the DSN for the engine. Exactly one of DSNSecret and
+	// Spec must be set.
+	Spec *ConnectionSpec `json:"spec,omitempty"`
+
+	// ReaderDSNSecret names a secret whose dsn key connects to a read-only
+	// endpoint of the same database. Checks which don't change the database
+	// are run against it, falling back to the primary when it is unavailable.
+	ReaderDSNSecret string `json:"readerDsnSecret,omitempty"`
+
+	// RegionalPrimaries are the primaries of other regions which don't
+	// replicate users from this one, such as independent primaries with
+	// external replication. The users the operator issues are created,
+	// rotated, locked and deleted on each of them as well, a user is only
+	// issued once it exists on every one of them.
+	RegionalPrimaries []RegionalPrimary `json:"regionalPrimaries,omitempty"`
+
+	// CloudIdentifiers names the cloud instances and clusters serving the
+	// database, such as an RDS instance or an Aurora cluster. Failover
+	// events about them requeue the database right away. The identifiers in
+	// RDS host names of Spec are recognized without being listed.
+	CloudIdentifiers []string `json:"cloudIdentifiers,omitempty"`
+
+	// Tunnel reaches a database which is only accessible through an SSH
+	// bastion or a SOCKS proxy. The primary and the reader are both
+	// connected to through it.
+	Tunnel *ConnectionTunnel `json:"tunnel,omitempty"`
+
+	// Parameters are settings which only the engine's backend understands,
+	// for backends compiled into a fork of the operator. The mysql engine
+	// has none.
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// RegionalPrimary is the primary of another region of the database
+type RegionalPrimary struct {
+	// Name identifies the region in the status
+	Name string `json:"name"`
+
+	// DSNSecret names a secret whose dsn key connects to the primary, with
+	// the same privileges as the connection of the ManagedDatabase
+	DSNSecret string `json:"dsnSecret"`
+}
+
+// ConnectionTunnel describes the bastion or proxy which a database is reached
+// through. The operator opens a tunnel for every connection to the database,
+// and closes it when the connection is closed.
+type ConnectionTunnel struct {
+	// Type is ssh for an SSH bastion, or socks5 for a SOCKS proxy
+	// +kubebuilder:validation:Enum=ssh;socks5
+	Type string `json:"type"`
+
+	// Address is the host:port of the bastion or proxy
+	Address string `json:"address"`
+
+	// User logs in to the bastion, or to a SOCKS proxy which requires
+	// authentication
+	User string `json:"user,omitempty"`
+
+	// CredentialsSecret names a secret in the same namespace. For ssh its
+	// privateKey key holds the private key of User, and its knownHosts key
+	// the host key of the bastion, which is the only one accepted. For
+	// socks5 its password key holds the password of User.
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// ConnectionSpec describes how to connect to a database without relying on
+// engine specific DSN syntax.
+type ConnectionSpec struct {
+	// Host may be a host name or an IPv4 or IPv6 address
+	Host string `json:"host,omitempty"`
+	Port int32  `json:"port,omitempty"`
+
+	// Hosts lists the servers of a self-hosted HA setup without a proxy, in
+	// order of preference, instead of Host. Hosts without a port use Port.
+	// The server is chosen by HostSelection whenever a connection is opened.
+	Hosts []ConnectionHost `json:"hosts,omitempty"`
+
+	// HostSelection chooses between Hosts: first-available connects to the
+	// first server which answers queries, primary to the first one which
+	// isn't read only. Defaults to first-available.
+	// +kubebuilder:validation:Enum=first-available;primary
+	HostSelection string `json:"hostSelection,omitempty"`
+
+	// Socket is the path of a Unix socket to connect through instead of Host
+	// and Port, such as one shared by a database proxy sidecar. Migration
+	// jobs must mount the same socket.
+	Socket string `json:"socket,omitempty"`
+
+	// Dialer names a custom dialer compiled into the operator, or tcp4 or
+	// tcp6 to restrict Host to one address family. The DSN given to
+	// migration jobs names the same dialer.
+	Dialer string `json:"dialer,omitempty"`
+
+	Database string `json:"database"`
+
+	// CredentialsSecret names a secret with username and password keys
+	CredentialsSecret string `json:"credentialsSecret"`
+
+	// +kubebuilder:validation:Enum=disabled;preferred;required;skip-verify
+	TLS string `json:"tls,omitempty"`
+
+	// Params contains engine specific connection parameters
+	Params map[string]string `json:"params,omitempty"`
+
+	// ServerPublicKey is the PEM encoded RSA public key of the server, used
+	// to protect the password when authenticating without TLS
+	ServerPublicKey string `json:"serverPublicKey,omitempty"`
+
+	// AllowPublicKeyRetrieval permits fetching the server's public key over
+	// the connection instead, which is required for MySQL 8 users without
+	// TLS or a ServerPublicKey
+	AllowPublicKeyRetrieval bool `json:"allowPublicKeyRetrieval,omitempty"`
+}
+
+// ConnectionHost is one of the servers which a connection may be made to
+type ConnectionHost struct {
+	// Host may be a host name or an IPv4 or IPv6 address
+	Host string `json:"host"`
+	Port int32  `json:"port,omitempty"`
+}
+
+// DefaultSchemaVersionLabel is the pod label which names the schema version
+// whose credentials a pod uses
+const DefaultSchemaVersionLabel = "dbaoperator.app-sre.redhat.com/schema-version"
+
+// BlueGreenSpec selects the pods of the app whose schema versions need
+// credentials
+type BlueGreenSpec struct {
+	PodSelector map[string]string `json:"podSelector"`
+
+	// VersionLabel is the pod label containing the schema version whose
+	// credentials the pod uses, defaults to
+	// dbaoperator.app-sre.redhat.com/schema-version
+	VersionLabel string `json:"versionLabel,omitempty"`
+}
+
+// AppVersionRequirement names the schema version which a version of the
+// application needs
+type AppVersionRequirement struct {
+	Version       string `json:"version"`
+	SchemaVersion string `json:"schemaVersion"`
+}
+
+// AppVersionStatus reports whether a version of the application can be
+// rolled out against the current schema
+type AppVersionStatus struct {
+	Version       string `json:"version"`
+	SchemaVersion string `json:"schemaVersion"`
+
+	// Safe is true when the schema version is live, and none of the
+	// migrations applied since is breaking
+	Safe   bool   `json:"safe"`
+	Reason string `json:"reason,omitempty"`
 }
 
 // ManagedDatabaseError contains information about an error that occurred when
 // reconciling this ManagedDatabase, and whether the error is considered
 // temporary/transient.
 type ManagedDatabaseError struct {
+	// Code identifies the phase which failed, it doesn't change between
+	// reconciles so that repeats of the same failure can be recognized
+	Code      string `json:"code,omitempty"`
 	Message   string `json:"message,omitempty"`
 	Temporary bool   `json:"temporary,omitempty"`
+
+	// Count is the number of reconciles in a row which failed with this
+	// code, since FirstSeen
+	Count     int          `json:"count,omitempty"`
+	FirstSeen *metav1.Time `json:"firstSeen,omitempty"`
+	LastSeen  *metav1.Time `json:"lastSeen,omitempty"`
+}
+
+// ConditionReady is true when the last reconcile of the ManagedDatabase
+// completed without an error
+const ConditionReady = "Ready"
+
+// ConditionConsistent is false while any of the ManagedDatabase's
+// consistency checks found values which don't match
+const ConditionConsistent = "Consistent"
+
+// ConditionExtensionsReady is true when every extension of the
+// ManagedDatabase's spec is installed in the database
+const ConditionExtensionsReady = "ExtensionsReady"
+
+// ManagedDatabaseCondition is the latest observation of one aspect of the
+// ManagedDatabase
+type ManagedDatabaseCondition struct {
+	Type               string                 `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
 }
 
 // ManagedDatabaseStatus defines the observed state of ManagedDatabase
 type ManagedDatabaseStatus struct {
-	CurrentVersion string                 `json:"currentVersion,omitempty"`
-	Errors         []ManagedDatabaseError `json:"errors,omitempty"`
+	CurrentVersion string `json:"currentVersion,omitempty"`
+
+	// Conditions contains the Ready condition, which carries the most
+	// actionable of the errors
+	Conditions []ManagedDatabaseCondition `json:"conditions,omitempty"`
+
+	// LastErrors contains every error of the last reconcile, the ones which
+	// need to be fixed by a person come first
+	LastErrors []ManagedDatabaseError `json:"lastErrors,omitempty"`
+
+	// TemporaryErrorRetries counts the reconciles in a row which failed
+	// with a temporary error, the delay before the next retry grows with it
+	TemporaryErrorRetries int `json:"temporaryErrorRetries,omitempty"`
+
+	// AppVersions reports which of the versions in spec.appVersions can be
+	// rolled out
+	AppVersions []AppVersionStatus `json:"appVersions,omitempty"`
+
+	// SchemaSnapshot names the ConfigMap containing the DDL of the current
+	// schema version
+	SchemaSnapshot string `json:"schemaSnapshot,omitempty"`
+
+	// Paused is true while changes are suspended, either by spec.paused or
+	// because the operator is in safe-mode
+	Paused bool `json:"paused,omitempty"`
+
+	// ServerFlavor is the detected distribution of the database server, one
+	// of mysql, mariadb, tidb or aurora-mysql
+	ServerFlavor string `json:"serverFlavor,omitempty"`
+
+	// ServerVersion is the detected version of the server's flavor
+	ServerVersion string `json:"serverVersion,omitempty"`
+
+	// Instance identifies the server the database is on as hostname:port,
+	// which is what per-instance quotas are counted against
+	Instance string `json:"instance,omitempty"`
+
+	// RegionalPrimaries reports the last credential operation on each of
+	// the regional primaries of the connection
+	RegionalPrimaries []RegionalPrimaryStatus `json:"regionalPrimaries,omitempty"`
+
+	// Incompatible describes why the server doesn't meet the compatibility
+	// requirements, nothing is changed while it is set
+	Incompatible string `json:"incompatible,omitempty"`
+
+	// Quarantined lists the users which have been locked and will be dropped
+	// once the deprovision quarantine has passed
+	Quarantined []QuarantinedUser `json:"quarantined,omitempty"`
+
+	// LastLogins contains when each user issued by the operator was last
+	// seen logged in to the database, users which haven't been seen since
+	// the operator started sampling are omitted
+	LastLogins []UserLogin `json:"lastLogins,omitempty"`
+
+	// PrivilegeUsage contains when each tracked privilege of the users
+	// issued by the operator was last used, while least privilege sampling
+	// is enabled
+	PrivilegeUsage []PrivilegeUsage `json:"privilegeUsage,omitempty"`
+
+	// PrivilegeTightening lists the privileges which went unused for the
+	// whole window, they are only revoked once their hash is approved
+	PrivilegeTightening *PrivilegeTightening `json:"privilegeTightening,omitempty"`
+
+	// Consumers lists the secrets issued for the database which pods use,
+	// by the generation of the credentials that the pods were started with
+	Consumers []SecretConsumers `json:"consumers,omitempty"`
+
+	// Plan is the progress of the last admin plan executed on the database,
+	// it is used to resume the plan if the operator is interrupted
+	Plan *PlanProgress `json:"plan,omitempty"`
+
+	// Journal is the operation which was being executed on the database. It
+	// is written before the operation is dispatched, so that an operation
+	// interrupted by an operator restart can be verified by the next
+	// reconcile instead of being repeated blindly.
+	Journal *JournalEntry `json:"journal,omitempty"`
+
+	// PendingPlan is the plan waiting for approval, when plan approval is
+	// enabled
+	PendingPlan *PendingPlan `json:"pendingPlan,omitempty"`
+
+	// Primary is the address of the primary that the Service points at
+	Primary string `json:"primary,omitempty"`
+
+	// DriftedRoutines are the helper routines whose definitions no longer
+	// match the ones shipped with the operator
+	DriftedRoutines []string `json:"driftedRoutines,omitempty"`
+
+	// MaskedViews records the definition of each masked view as it was
+	// written, so that changes made outside of the operator are reverted
+	MaskedViews []MaskedViewStatus `json:"maskedViews,omitempty"`
+
+	// ScheduledStatements records the definition of each scheduled
+	// statement as it was written, so that changes made outside of the
+	// operator are reverted
+	ScheduledStatements []ScheduledStatementStatus `json:"scheduledStatements,omitempty"`
+
+	// Retention records how far each retention rule has got
+	Retention []RetentionStatus `json:"retention,omitempty"`
+
+	// SeedData records the checksum of each seed data source as it was
+	// last applied
+	SeedData []SeedDataStatus `json:"seedData,omitempty"`
+
+	// BinlogConsumers records when each change data capture user issued by
+	// a credential request was last seen streaming the binary log
+	BinlogConsumers []BinlogConsumerStatus `json:"binlogConsumers,omitempty"`
+
+	// ConsistencyChecks records the values of each consistency check when
+	// it was last run
+	ConsistencyChecks []ConsistencyCheckStatus `json:"consistencyChecks,omitempty"`
+
+	// IntegrityChecks records how far the scan of each integrity check has
+	// got, and the orphaned rows it found
+	IntegrityChecks []IntegrityCheckStatus `json:"integrityChecks,omitempty"`
+
+	// Partitioning records how far each partitioned table was from its
+	// schedule when it was last maintained
+	Partitioning []PartitionedTableStatus `json:"partitioning,omitempty"`
+
+	// Charset is the database's default character set and collation when
+	// it was last checked
+	Charset *CharsetStatus `json:"charset,omitempty"`
+
+	// AppliedParameters are the server variables as the operator last set
+	// them, and ParameterDrift the ones which had been changed outside of
+	// the operator when they were last checked
+	AppliedParameters map[string]string `json:"appliedParameters,omitempty"`
+	ParameterDrift    []string          `json:"parameterDrift,omitempty"`
+
+	// InstalledExtensions are the versions of the extensions of the spec
+	// which are installed in the database
+	InstalledExtensions map[string]string `json:"installedExtensions,omitempty"`
+
+	// ReplicaUsername is the read-only user created for the replicas, and
+	// Replicas the result of the latest health check of each of them
+	ReplicaUsername string          `json:"replicaUsername,omitempty"`
+	Replicas        []ReplicaStatus `json:"replicas,omitempty"`
+
+	// GrantVerification is the result of the latest check of the
+	// privileges of a newly provisioned user
+	GrantVerification *GrantVerificationStatus `json:"grantVerification,omitempty"`
+
+	// Rotation records the scheduled rotations of the credentials, when
+	// the rotation policy has a schedule
+	Rotation *RotationStatus `json:"rotation,omitempty"`
+
+	// FailoverDrill records the latest failover drill, and when the next
+	// scheduled one is run
+	FailoverDrill *FailoverDrillStatus `json:"failoverDrill,omitempty"`
+
+	// LeaseHolder is the cluster whose operator may change the database,
+	// when ownership leases are enabled
+	LeaseHolder string `json:"leaseHolder,omitempty"`
+
+	// ResolvedVersion is the migration that a desired schema digest refers
+	// to
+	ResolvedVersion string `json:"resolvedVersion,omitempty"`
+
+	// AppliedMigrations records the checksum of each migration once the
+	// database has reached it, migrations which are changed afterwards are
+	// refused. Migrations run by the operator also record the binary log
+	// position before and after them.
+	AppliedMigrations []AppliedMigration `json:"appliedMigrations,omitempty"`
+
+	// MigrationProgress is the latest progress reported by the running
+	// migration Job, it is cleared once no migration is running
+	MigrationProgress *MigrationProgress `json:"migrationProgress,omitempty"`
+
+	// MigrationFailure describes why the operator aborted a migration. The
+	// migration isn't retried until its DatabaseMigration is changed.
+	MigrationFailure *MigrationFailure `json:"migrationFailure,omitempty"`
+
+	// MigrationSilence is the Alertmanager silence covering the running
+	// migration, it is expired once the migration stops
+	MigrationSilence *AlertSilence `json:"migrationSilence,omitempty"`
+
+	// Performance is how long the last reconcile took, broken down by
+	// phase
+	Performance *ReconcilePerformance `json:"performance,omitempty"`
+
+	// SecretConvention is the convention which the Secrets of the database
+	// are generated with, fixed before the first of them is written
+	SecretConvention *SecretConvention `json:"secretConvention,omitempty"`
+}
+
+// MaskedViewStatus identifies the spec a masked view was written from, and
+// the definition the server stored for it
+type MaskedViewStatus struct {
+	Name     string `json:"name"`
+	SpecHash string `json:"specHash"`
+	Checksum string `json:"checksum"`
+}
+
+// ScheduledStatementStatus identifies the spec a scheduled statement was
+// written from, and the definition the server stored for it
+type ScheduledStatementStatus struct {
+	Name     string `json:"name"`
+	SpecHash string `json:"specHash"`
+	Checksum string `json:"checksum"`
+}
+
+// RetentionStatus is the progress of a retention rule
+type RetentionStatus struct {
+	Name string `json:"name"`
+
+	// RowsPurged counts the rows which the rule has deleted
+	RowsPurged int64 `json:"rowsPurged"`
+
+	// CaughtUp is true when the last batch found fewer expired rows than
+	// the batch size
+	CaughtUp bool `json:"caughtUp"`
+
+	// LastBatch is when the last batch was deleted
+	LastBatch *metav1.Time `json:"lastBatch,omitempty"`
+
+	// Backoff is how long purges are paused for because of replica lag, it
+	// doubles every time the replicas are still behind and is reset by the
+	// next batch
+	Backoff      metav1.Duration `json:"backoff,omitempty"`
+	BackoffUntil *metav1.Time    `json:"backoffUntil,omitempty"`
+}
+
+// SeedDataStatus identifies the content a seed data source was applied with
+type SeedDataStatus struct {
+	Name      string      `json:"name"`
+	Checksum  string      `json:"checksum"`
+	AppliedAt metav1.Time `json:"appliedAt"`
+}
+
+// ConsistencyCheckStatus is the outcome of the last run of a consistency
+// check. Values are kept as strings, since CRDs don't allow floats.
+type ConsistencyCheckStatus struct {
+	Name    string       `json:"name"`
+	LastRun *metav1.Time `json:"lastRun,omitempty"`
+	Left    string       `json:"left,omitempty"`
+	Right   string       `json:"right,omitempty"`
+	Passed  bool         `json:"passed,omitempty"`
+}
+
+// IntegrityCheckStatus is the progress of the current pass over the table
+// of an integrity check, and the outcome of the last complete pass
+type IntegrityCheckStatus struct {
+	Name string `json:"name"`
+
+	// Cursor is the last value of the column which was scanned, the next
+	// batch continues after it
+	Cursor   string       `json:"cursor,omitempty"`
+	LastScan *metav1.Time `json:"lastScan,omitempty"`
+
+	// Scanned and Orphaned count the rows of the current pass
+	Scanned  int64 `json:"scanned,omitempty"`
+	Orphaned int64 `json:"orphaned,omitempty"`
+
+	// LastPassOrphaned is the number of orphaned rows which the last
+	// complete pass found
+	LastPassOrphaned    int64        `json:"lastPassOrphaned,omitempty"`
+	LastPassCompletedAt *metav1.Time `json:"lastPassCompletedAt,omitempty"`
+}
+
+// BinlogConsumerStatus tracks a change data capture user. A consumer which
+// hasn't streamed for longer than the server retains binary logs is
+// abandoned, since the position it would resume from has been purged.
+type BinlogConsumerStatus struct {
+	Username       string       `json:"username"`
+	Owner          string       `json:"owner,omitempty"`
+	Streaming      bool         `json:"streaming,omitempty"`
+	LastStreamedAt *metav1.Time `json:"lastStreamedAt,omitempty"`
+	Abandoned      bool         `json:"abandoned,omitempty"`
+}
+
+// ReconcilePerformance is how long a reconcile took, Phases lists the time
+// spent in each phase in the order they were first entered
+type ReconcilePerformance struct {
+	StartedAt metav1.Time     `json:"startedAt"`
+	Duration  metav1.Duration `json:"duration"`
+	Phases    []PhaseDuration `json:"phases,omitempty"`
+}
+
+// PhaseDuration is the time which a reconcile spent in one phase
+type PhaseDuration struct {
+	Phase    string          `json:"phase"`
+	Duration metav1.Duration `json:"duration"`
+}
+
+// PartitionedTableStatus counts the partitions of a table which are ready
+// ahead of the current one, and the changes it was behind its schedule by
+type PartitionedTableStatus struct {
+	Table  string `json:"table"`
+	Ahead  int    `json:"ahead"`
+	Behind int    `json:"behind"`
+}
+
+// ReplicaStatus is the result of logging in to a replica with the read-only
+// user
+type ReplicaStatus struct {
+	Name       string      `json:"name"`
+	SecretName string      `json:"secretName"`
+	Healthy    bool        `json:"healthy"`
+	Message    string      `json:"message,omitempty"`
+	CheckedAt  metav1.Time `json:"checkedAt"`
+}
+
+// RotationStatus records when the credentials were last rotated on their
+// schedule, and when they are rotated next
+type RotationStatus struct {
+	LastRotatedAt  *metav1.Time `json:"lastRotatedAt,omitempty"`
+	NextRotationAt *metav1.Time `json:"nextRotationAt,omitempty"`
+}
+
+// FailoverDrillStatus records the failover drills of a database
+type FailoverDrillStatus struct {
+	LastDrill   *FailoverDrillResult `json:"lastDrill,omitempty"`
+	NextDrillAt *metav1.Time         `json:"nextDrillAt,omitempty"`
+}
+
+// FailoverDrillResult is the outcome of a failover drill. The recoveries are
+// measured from when the failover was triggered, and are left out when they
+// didn't happen within the timeout.
+type FailoverDrillResult struct {
+	// Operation is the name of the DatabaseOperation which ran the drill
+	Operation string      `json:"operation"`
+	Method    string      `json:"method"`
+	Target    string      `json:"target"`
+	StartedAt metav1.Time `json:"startedAt"`
+	Succeeded bool        `json:"succeeded"`
+	Message   string      `json:"message,omitempty"`
+
+	// PrimaryBefore and PrimaryAfter are the instance of the primary before
+	// and after the failover
+	PrimaryBefore string `json:"primaryBefore,omitempty"`
+	PrimaryAfter  string `json:"primaryAfter,omitempty"`
+
+	// OperatorRecovery is when the operator could query the database again
+	OperatorRecovery *metav1.Duration `json:"operatorRecovery,omitempty"`
+
+	// CredentialsRecovery is when every issued credential could log in
+	// again
+	CredentialsRecovery *metav1.Duration `json:"credentialsRecovery,omitempty"`
+}
+
+// GrantVerificationStatus records which privileges a user turned out to
+// have, Failures lists the probes which the server answered unexpectedly
+type GrantVerificationStatus struct {
+	Username  string      `json:"username"`
+	Passed    bool        `json:"passed"`
+	Probes    []string    `json:"probes,omitempty"`
+	Failures  []string    `json:"failures,omitempty"`
+	Message   string      `json:"message,omitempty"`
+	CheckedAt metav1.Time `json:"checkedAt"`
+}
+
+// CharsetStatus describes the character set of a database
+type CharsetStatus struct {
+	CharacterSet string `json:"characterSet"`
+	Collation    string `json:"collation"`
+
+	// Matches is true when the database defaults to the specified
+	// character set and collation
+	Matches bool `json:"matches"`
+
+	// MismatchedTables is the number of tables which use a different
+	// collation, they have to be converted by a migration
+	MismatchedTables int `json:"mismatchedTables,omitempty"`
+}
+
+// AppliedMigration is the checksum of a migration as it was applied
+type AppliedMigration struct {
+	Version  string `json:"version"`
+	Checksum string `json:"checksum"`
+
+	// Before is the binary log position just before the migration's Job was
+	// created, a point in time restore which stops there undoes the migration
+	Before *LogPosition `json:"before,omitempty"`
+
+	// After is the binary log position when the Job was first seen to have
+	// succeeded
+	After *LogPosition `json:"after,omitempty"`
+
+	// Image is the result of verifying the signature of the image which
+	// the migration's Job ran
+	Image *ImageVerification `json:"image,omitempty"`
+}
+
+// ImageVerification is the result of verifying the signature of a migration
+// image before its Job was launched
+type ImageVerification struct {
+	Image    string `json:"image"`
+	Verified bool   `json:"verified"`
+
+	// Digest is the digest which the signature was verified for, the Job
+	// is launched with the image pinned to it
+	Digest string `json:"digest,omitempty"`
+
+	// Signer is the ID of the key, or the keyless identity, which signed
+	// the image
+	Signer string `json:"signer,omitempty"`
+
+	// Message explains why the image didn't verify
+	Message string `json:"message,omitempty"`
+
+	VerifiedAt metav1.Time `json:"verifiedAt"`
+}
+
+// LogPosition is a position in the binary log of the database server
+type LogPosition struct {
+	File     string `json:"file"`
+	Position int64  `json:"position"`
+
+	// GTIDSet is the set of transactions written to the binary log, when the
+	// server uses GTIDs
+	GTIDSet string `json:"gtidSet,omitempty"`
+
+	CapturedAt metav1.Time `json:"capturedAt"`
+}
+
+// PendingPlan is a rendered admin plan, with secret values redacted
+type PendingPlan struct {
+	ID         string   `json:"id"`
+	Hash       string   `json:"hash"`
+	Statements []string `json:"statements"`
+}
+
+// JournalEntry describes an operation which has been dispatched but not yet
+// confirmed as complete
+type JournalEntry struct {
+	Phase     string `json:"phase"`
+	Operation string `json:"operation"`
+
+	// Target is the name of the user or Job the operation acts on
+	Target string `json:"target"`
+
+	// Token is unique to each dispatch of an operation, and is attached to
+	// the objects it creates
+	Token   string      `json:"token"`
+	Started metav1.Time `json:"started"`
+}
+
+// PlanProgress is the checkpoint of an admin plan
+type PlanProgress struct {
+	ID             string `json:"id"`
+	CompletedSteps int    `json:"completedSteps"`
+}
+
+// MigrationProgress is a progress report relayed from a migration Job
+type MigrationProgress struct {
+	// Migration is the name of the DatabaseMigration being run
+	Migration string `json:"migration"`
+
+	Step          int    `json:"step,omitempty"`
+	Total         int    `json:"total,omitempty"`
+	Statement     string `json:"statement,omitempty"`
+	RowsProcessed int64  `json:"rowsProcessed,omitempty"`
+
+	// UpdatedAt is when the report was read from the Job
+	UpdatedAt metav1.Time `json:"updatedAt"`
+}
+
+// MigrationFailure describes a migration which was aborted by the operator
+type MigrationFailure struct {
+	// Migration is the name of the DatabaseMigration which was aborted
+	Migration string `json:"migration"`
+
+	// Generation is the generation of the DatabaseMigration when it was
+	// aborted
+	Generation int64 `json:"generation"`
+
+	// Reason is one of DeadlineExceeded, BinlogGrowth, ReplicaLag or
+	// LockWait
+	Reason    string      `json:"reason"`
+	Message   string      `json:"message"`
+	AbortedAt metav1.Time `json:"abortedAt"`
+}
+
+// AlertSilence is an Alertmanager silence created by the operator
+type AlertSilence struct {
+	ID        string      `json:"id"`
+	Migration string      `json:"migration"`
+	EndsAt    metav1.Time `json:"endsAt"`
+}
+
+// SecretConsumers describes the pods which use a secret
+type SecretConsumers struct {
+	Secret      string                 `json:"secret"`
+	Generations []CredentialGeneration `json:"generations"`
+}
+
+// CredentialGeneration counts the pods which were started with one version
+// of a secret's credentials
+type CredentialGeneration struct {
+	// Checksum is the credentials-checksum annotation the secret had, it is
+	// empty when the pods are older than the checksums that were recorded
+	Checksum string `json:"checksum,omitempty"`
+
+	// Current is true for the credentials which the secret contains now
+	Current bool `json:"current,omitempty"`
+	Pods    int  `json:"pods"`
+
+	// Workloads are the controllers of the pods, as Kind/name
+	Workloads []string `json:"workloads,omitempty"`
+}
+
+// RegionalPrimaryStatus is the outcome of the last credential operation on a
+// regional primary
+type RegionalPrimaryStatus struct {
+	Name      string      `json:"name"`
+	Operation string      `json:"operation"`
+	Succeeded bool        `json:"succeeded"`
+	Error     string      `json:"error,omitempty"`
+	At        metav1.Time `json:"at"`
+}
+
+// UserLogin is the last time that a database user was seen logged in
+type UserLogin struct {
+	Username  string      `json:"username"`
+	LastLogin metav1.Time `json:"lastLogin"`
+}
+
+// PrivilegeUsage is when a privilege of a user was last used
+type PrivilegeUsage struct {
+	Username  string `json:"username"`
+	Privilege string `json:"privilege"`
+
+	// Since is when the operator started watching the privilege
+	Since    metav1.Time  `json:"since"`
+	LastUsed *metav1.Time `json:"lastUsed,omitempty"`
+}
+
+// PrivilegeTightening is the set of suggested revocations
+type PrivilegeTightening struct {
+	Hash        string                `json:"hash"`
+	Suggestions []PrivilegeSuggestion `json:"suggestions"`
+	ComputedAt  metav1.Time           `json:"computedAt"`
+}
+
+// PrivilegeSuggestion is a privilege of a user which can be revoked
+type PrivilegeSuggestion struct {
+	Username    string      `json:"username"`
+	Privilege   string      `json:"privilege"`
+	UnusedSince metav1.Time `json:"unusedSince"`
+
+	// Revoke are the grants which are revoked, in the syntax of a GRANT
+	// statement
+	Revoke []string `json:"revoke"`
+}
+
+// QuarantinedUser is a database user which is locked pending removal
+type QuarantinedUser struct {
+	Username string      `json:"username"`
+	LockedAt metav1.Time `json:"lockedAt"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,71 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ManagedDatabaseClassSpec contains the defaults applied to every
+// ManagedDatabase which references the class. Fields which are set on the
+// ManagedDatabase itself take precedence.
+type ManagedDatabaseClassSpec struct {
+	Engine          string `json:"engine,omitempty"`
+	MigrationEngine string `json:"migrationEngine,omitempty"`
+
+	// Connection contains connection defaults, such as the host and TLS
+	// mode of a shared instance. The credentials secret is looked up in the
+	// namespace of each ManagedDatabase.
+	Connection *ConnectionSpec `json:"connection,omitempty"`
+
+	// Grants are used by ManagedDatabases which don't list their own, a
+	// grant without a database applies to each ManagedDatabase's database
+	Grants []DatabaseGrant `json:"grants,omitempty"`
+
+	// +kubebuilder:validation:Enum=caching_sha2_password;mysql_native_password
+	AuthPlugin string `json:"authPlugin,omitempty"`
+
+	Rotation           *RotationPolicy     `json:"rotation,omitempty"`
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+	Compatibility      *CompatibilitySpec  `json:"compatibility,omitempty"`
+	Charset            *CharsetSpec        `json:"charset,omitempty"`
+	OperatorSession    *SessionSettings    `json:"operatorSession,omitempty"`
+	SecretConvention   *SecretConvention   `json:"secretConvention,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// ManagedDatabaseClass is the Schema for the manageddatabaseclasses API
+type ManagedDatabaseClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ManagedDatabaseClassSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ManagedDatabaseClassList contains a list of ManagedDatabaseClass
+type ManagedDatabaseClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManagedDatabaseClass `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManagedDatabaseClass{}, &ManagedDatabaseClassList{})
+}
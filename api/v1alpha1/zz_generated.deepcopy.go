@@ -20,45 +20,48 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DatabaseConnectionInfo) DeepCopyInto(out *DatabaseConnectionInfo) {
+func (in *AlertSilence) DeepCopyInto(out *AlertSilence) {
 	*out = *in
+	in.EndsAt.DeepCopyInto(&out.EndsAt)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseConnectionInfo.
-func (in *DatabaseConnectionInfo) DeepCopy() *DatabaseConnectionInfo {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertSilence.
+func (in *AlertSilence) DeepCopy() *AlertSilence {
 	if in == nil {
 		return nil
 	}
-	out := new(DatabaseConnectionInfo)
+	out := new(AlertSilence)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DatabaseMigration) DeepCopyInto(out *DatabaseMigration) {
+func (in *AnonymizedSnapshot) DeepCopyInto(out *AnonymizedSnapshot) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseMigration.
-func (in *DatabaseMigration) DeepCopy() *DatabaseMigration {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnonymizedSnapshot.
+func (in *AnonymizedSnapshot) DeepCopy() *AnonymizedSnapshot {
 	if in == nil {
 		return nil
 	}
-	out := new(DatabaseMigration)
+	out := new(AnonymizedSnapshot)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *DatabaseMigration) DeepCopyObject() runtime.Object {
+func (in *AnonymizedSnapshot) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -66,31 +69,31 @@ func (in *DatabaseMigration) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DatabaseMigrationList) DeepCopyInto(out *DatabaseMigrationList) {
+func (in *AnonymizedSnapshotList) DeepCopyInto(out *AnonymizedSnapshotList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]DatabaseMigration, len(*in))
+		*out = make([]AnonymizedSnapshot, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseMigrationList.
-func (in *DatabaseMigrationList) DeepCopy() *DatabaseMigrationList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnonymizedSnapshotList.
+func (in *AnonymizedSnapshotList) DeepCopy() *AnonymizedSnapshotList {
 	if in == nil {
 		return nil
 	}
-	out := new(DatabaseMigrationList)
+	out := new(AnonymizedSnapshotList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *DatabaseMigrationList) DeepCopyObject() runtime.Object {
+func (in *AnonymizedSnapshotList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -98,162 +101,2469 @@ func (in *DatabaseMigrationList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DatabaseMigrationSchemaHint) DeepCopyInto(out *DatabaseMigrationSchemaHint) {
+func (in *AnonymizedSnapshotSpec) DeepCopyInto(out *AnonymizedSnapshotSpec) {
 	*out = *in
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Masks != nil {
+		in, out := &in.Masks, &out.Masks
+		*out = make([]TableMask, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Export != nil {
+		in, out := &in.Export, &out.Export
+		*out = new(v1.Container)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseMigrationSchemaHint.
-func (in *DatabaseMigrationSchemaHint) DeepCopy() *DatabaseMigrationSchemaHint {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnonymizedSnapshotSpec.
+func (in *AnonymizedSnapshotSpec) DeepCopy() *AnonymizedSnapshotSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(DatabaseMigrationSchemaHint)
+	out := new(AnonymizedSnapshotSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DatabaseMigrationSpec) DeepCopyInto(out *DatabaseMigrationSpec) {
+func (in *AnonymizedSnapshotStatus) DeepCopyInto(out *AnonymizedSnapshotStatus) {
 	*out = *in
-	in.MigrationContainerSpec.DeepCopyInto(&out.MigrationContainerSpec)
-	if in.SchemaHints != nil {
-		in, out := &in.SchemaHints, &out.SchemaHints
-		*out = make([]DatabaseMigrationSchemaHint, len(*in))
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]SnapshotTableStatus, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseMigrationSpec.
-func (in *DatabaseMigrationSpec) DeepCopy() *DatabaseMigrationSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnonymizedSnapshotStatus.
+func (in *AnonymizedSnapshotStatus) DeepCopy() *AnonymizedSnapshotStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(DatabaseMigrationSpec)
+	out := new(AnonymizedSnapshotStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DatabaseMigrationStatus) DeepCopyInto(out *DatabaseMigrationStatus) {
+func (in *AppVersionRequirement) DeepCopyInto(out *AppVersionRequirement) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseMigrationStatus.
-func (in *DatabaseMigrationStatus) DeepCopy() *DatabaseMigrationStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppVersionRequirement.
+func (in *AppVersionRequirement) DeepCopy() *AppVersionRequirement {
 	if in == nil {
 		return nil
 	}
-	out := new(DatabaseMigrationStatus)
+	out := new(AppVersionRequirement)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagedDatabase) DeepCopyInto(out *ManagedDatabase) {
+func (in *AppVersionStatus) DeepCopyInto(out *AppVersionStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabase.
-func (in *ManagedDatabase) DeepCopy() *ManagedDatabase {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppVersionStatus.
+func (in *AppVersionStatus) DeepCopy() *AppVersionStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ManagedDatabase)
+	out := new(AppVersionStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ManagedDatabase) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppliedMigration) DeepCopyInto(out *AppliedMigration) {
+	*out = *in
+	if in.Before != nil {
+		in, out := &in.Before, &out.Before
+		*out = new(LogPosition)
+		(*in).DeepCopyInto(*out)
 	}
-	return nil
+	if in.After != nil {
+		in, out := &in.After, &out.After
+		*out = new(LogPosition)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(ImageVerification)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppliedMigration.
+func (in *AppliedMigration) DeepCopy() *AppliedMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(AppliedMigration)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagedDatabaseError) DeepCopyInto(out *ManagedDatabaseError) {
+func (in *AutoPauseSpec) DeepCopyInto(out *AutoPauseSpec) {
 	*out = *in
+	out.ResumeTimeout = in.ResumeTimeout
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseError.
-func (in *ManagedDatabaseError) DeepCopy() *ManagedDatabaseError {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoPauseSpec.
+func (in *AutoPauseSpec) DeepCopy() *AutoPauseSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ManagedDatabaseError)
+	out := new(AutoPauseSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagedDatabaseList) DeepCopyInto(out *ManagedDatabaseList) {
+func (in *BinlogConsumerStatus) DeepCopyInto(out *BinlogConsumerStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	out.ListMeta = in.ListMeta
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]ManagedDatabase, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
+	if in.LastStreamedAt != nil {
+		in, out := &in.LastStreamedAt, &out.LastStreamedAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BinlogConsumerStatus.
+func (in *BinlogConsumerStatus) DeepCopy() *BinlogConsumerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BinlogConsumerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreenSpec) DeepCopyInto(out *BlueGreenSpec) {
+	*out = *in
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseList.
-func (in *ManagedDatabaseList) DeepCopy() *ManagedDatabaseList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreenSpec.
+func (in *BlueGreenSpec) DeepCopy() *BlueGreenSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ManagedDatabaseList)
+	out := new(BlueGreenSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ManagedDatabaseList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CharsetSpec) DeepCopyInto(out *CharsetSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CharsetSpec.
+func (in *CharsetSpec) DeepCopy() *CharsetSpec {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(CharsetSpec)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagedDatabaseSpec) DeepCopyInto(out *ManagedDatabaseSpec) {
+func (in *CharsetStatus) DeepCopyInto(out *CharsetStatus) {
 	*out = *in
-	out.Connection = in.Connection
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseSpec.
-func (in *ManagedDatabaseSpec) DeepCopy() *ManagedDatabaseSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CharsetStatus.
+func (in *CharsetStatus) DeepCopy() *CharsetStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ManagedDatabaseSpec)
+	out := new(CharsetStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagedDatabaseStatus) DeepCopyInto(out *ManagedDatabaseStatus) {
+func (in *CompatibilitySpec) DeepCopyInto(out *CompatibilitySpec) {
 	*out = *in
-	if in.Errors != nil {
-		in, out := &in.Errors, &out.Errors
-		*out = make([]ManagedDatabaseError, len(*in))
+	if in.RequiredFeatures != nil {
+		in, out := &in.RequiredFeatures, &out.RequiredFeatures
+		*out = make([]ServerFeature, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseStatus.
-func (in *ManagedDatabaseStatus) DeepCopy() *ManagedDatabaseStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompatibilitySpec.
+func (in *CompatibilitySpec) DeepCopy() *CompatibilitySpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ManagedDatabaseStatus)
+	out := new(CompatibilitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionHost) DeepCopyInto(out *ConnectionHost) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionHost.
+func (in *ConnectionHost) DeepCopy() *ConnectionHost {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionHost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionSpec) DeepCopyInto(out *ConnectionSpec) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]ConnectionHost, len(*in))
+		copy(*out, *in)
+	}
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionSpec.
+func (in *ConnectionSpec) DeepCopy() *ConnectionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionTunnel) DeepCopyInto(out *ConnectionTunnel) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionTunnel.
+func (in *ConnectionTunnel) DeepCopy() *ConnectionTunnel {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionTunnel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsistencyCheck) DeepCopyInto(out *ConsistencyCheck) {
+	*out = *in
+	out.Left = in.Left
+	out.Right = in.Right
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistencyCheck.
+func (in *ConsistencyCheck) DeepCopy() *ConsistencyCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsistencyCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsistencyCheckStatus) DeepCopyInto(out *ConsistencyCheckStatus) {
+	*out = *in
+	if in.LastRun != nil {
+		in, out := &in.LastRun, &out.LastRun
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistencyCheckStatus.
+func (in *ConsistencyCheckStatus) DeepCopy() *ConsistencyCheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsistencyCheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsistencyQuery) DeepCopyInto(out *ConsistencyQuery) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistencyQuery.
+func (in *ConsistencyQuery) DeepCopy() *ConsistencyQuery {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsistencyQuery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialGeneration) DeepCopyInto(out *CredentialGeneration) {
+	*out = *in
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialGeneration.
+func (in *CredentialGeneration) DeepCopy() *CredentialGeneration {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialGeneration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialRequestPolicy) DeepCopyInto(out *CredentialRequestPolicy) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceAccounts != nil {
+		in, out := &in.ServiceAccounts, &out.ServiceAccounts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Classes != nil {
+		in, out := &in.Classes, &out.Classes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialRequestPolicy.
+func (in *CredentialRequestPolicy) DeepCopy() *CredentialRequestPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialRequestPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseConnectionInfo) DeepCopyInto(out *DatabaseConnectionInfo) {
+	*out = *in
+	if in.Spec != nil {
+		in, out := &in.Spec, &out.Spec
+		*out = new(ConnectionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RegionalPrimaries != nil {
+		in, out := &in.RegionalPrimaries, &out.RegionalPrimaries
+		*out = make([]RegionalPrimary, len(*in))
+		copy(*out, *in)
+	}
+	if in.CloudIdentifiers != nil {
+		in, out := &in.CloudIdentifiers, &out.CloudIdentifiers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tunnel != nil {
+		in, out := &in.Tunnel, &out.Tunnel
+		*out = new(ConnectionTunnel)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseConnectionInfo.
+func (in *DatabaseConnectionInfo) DeepCopy() *DatabaseConnectionInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseConnectionInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseCredentialRequest) DeepCopyInto(out *DatabaseCredentialRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseCredentialRequest.
+func (in *DatabaseCredentialRequest) DeepCopy() *DatabaseCredentialRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseCredentialRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatabaseCredentialRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseCredentialRequestList) DeepCopyInto(out *DatabaseCredentialRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DatabaseCredentialRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseCredentialRequestList.
+func (in *DatabaseCredentialRequestList) DeepCopy() *DatabaseCredentialRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseCredentialRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatabaseCredentialRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseCredentialRequestSpec) DeepCopyInto(out *DatabaseCredentialRequestSpec) {
+	*out = *in
+	out.ManagedDatabase = in.ManagedDatabase
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseCredentialRequestSpec.
+func (in *DatabaseCredentialRequestSpec) DeepCopy() *DatabaseCredentialRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseCredentialRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseCredentialRequestStatus) DeepCopyInto(out *DatabaseCredentialRequestStatus) {
+	*out = *in
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]ManagedDatabaseError, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseCredentialRequestStatus.
+func (in *DatabaseCredentialRequestStatus) DeepCopy() *DatabaseCredentialRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseCredentialRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseExtension) DeepCopyInto(out *DatabaseExtension) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseExtension.
+func (in *DatabaseExtension) DeepCopy() *DatabaseExtension {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseExtension)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseGrant) DeepCopyInto(out *DatabaseGrant) {
+	*out = *in
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]TableGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseGrant.
+func (in *DatabaseGrant) DeepCopy() *DatabaseGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseMigration) DeepCopyInto(out *DatabaseMigration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseMigration.
+func (in *DatabaseMigration) DeepCopy() *DatabaseMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatabaseMigration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseMigrationList) DeepCopyInto(out *DatabaseMigrationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DatabaseMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseMigrationList.
+func (in *DatabaseMigrationList) DeepCopy() *DatabaseMigrationList {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseMigrationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatabaseMigrationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseMigrationSchemaHint) DeepCopyInto(out *DatabaseMigrationSchemaHint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseMigrationSchemaHint.
+func (in *DatabaseMigrationSchemaHint) DeepCopy() *DatabaseMigrationSchemaHint {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseMigrationSchemaHint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseMigrationSpec) DeepCopyInto(out *DatabaseMigrationSpec) {
+	*out = *in
+	in.MigrationContainerSpec.DeepCopyInto(&out.MigrationContainerSpec)
+	if in.SchemaHints != nil {
+		in, out := &in.SchemaHints, &out.SchemaHints
+		*out = make([]DatabaseMigrationSchemaHint, len(*in))
+		copy(*out, *in)
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]DatabaseGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostMigration != nil {
+		in, out := &in.PostMigration, &out.PostMigration
+		*out = new(PostMigrationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ActiveDeadline != nil {
+		in, out := &in.ActiveDeadline, &out.ActiveDeadline
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Guard != nil {
+		in, out := &in.Guard, &out.Guard
+		*out = new(MigrationGuardSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Embedded != nil {
+		in, out := &in.Embedded, &out.Embedded
+		*out = new(EmbeddedMigrationSpec)
+		**out = **in
+	}
+	if in.Bundle != nil {
+		in, out := &in.Bundle, &out.Bundle
+		*out = new(MigrationBundleSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseMigrationSpec.
+func (in *DatabaseMigrationSpec) DeepCopy() *DatabaseMigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseMigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseMigrationStatus) DeepCopyInto(out *DatabaseMigrationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseMigrationStatus.
+func (in *DatabaseMigrationStatus) DeepCopy() *DatabaseMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseOperation) DeepCopyInto(out *DatabaseOperation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseOperation.
+func (in *DatabaseOperation) DeepCopy() *DatabaseOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatabaseOperation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseOperationList) DeepCopyInto(out *DatabaseOperationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DatabaseOperation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseOperationList.
+func (in *DatabaseOperationList) DeepCopy() *DatabaseOperationList {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseOperationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatabaseOperationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseOperationSpec) DeepCopyInto(out *DatabaseOperationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseOperationSpec.
+func (in *DatabaseOperationSpec) DeepCopy() *DatabaseOperationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseOperationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseOperationStatus) DeepCopyInto(out *DatabaseOperationStatus) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailoverTriggeredAt != nil {
+		in, out := &in.FailoverTriggeredAt, &out.FailoverTriggeredAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseOperationStatus.
+func (in *DatabaseOperationStatus) DeepCopy() *DatabaseOperationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseOperationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EmbeddedMigrationSpec) DeepCopyInto(out *EmbeddedMigrationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmbeddedMigrationSpec.
+func (in *EmbeddedMigrationSpec) DeepCopy() *EmbeddedMigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EmbeddedMigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailoverDrillResult) DeepCopyInto(out *FailoverDrillResult) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.OperatorRecovery != nil {
+		in, out := &in.OperatorRecovery, &out.OperatorRecovery
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.CredentialsRecovery != nil {
+		in, out := &in.CredentialsRecovery, &out.CredentialsRecovery
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailoverDrillResult.
+func (in *FailoverDrillResult) DeepCopy() *FailoverDrillResult {
+	if in == nil {
+		return nil
+	}
+	out := new(FailoverDrillResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailoverDrillSpec) DeepCopyInto(out *FailoverDrillSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailoverDrillSpec.
+func (in *FailoverDrillSpec) DeepCopy() *FailoverDrillSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FailoverDrillSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailoverDrillStatus) DeepCopyInto(out *FailoverDrillStatus) {
+	*out = *in
+	if in.LastDrill != nil {
+		in, out := &in.LastDrill, &out.LastDrill
+		*out = new(FailoverDrillResult)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NextDrillAt != nil {
+		in, out := &in.NextDrillAt, &out.NextDrillAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailoverDrillStatus.
+func (in *FailoverDrillStatus) DeepCopy() *FailoverDrillStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FailoverDrillStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GaleraSpec) DeepCopyInto(out *GaleraSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GaleraSpec.
+func (in *GaleraSpec) DeepCopy() *GaleraSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GaleraSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GrantVerificationStatus) DeepCopyInto(out *GrantVerificationStatus) {
+	*out = *in
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Failures != nil {
+		in, out := &in.Failures, &out.Failures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.CheckedAt.DeepCopyInto(&out.CheckedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GrantVerificationStatus.
+func (in *GrantVerificationStatus) DeepCopy() *GrantVerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GrantVerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelperRoutinesSpec) DeepCopyInto(out *HelperRoutinesSpec) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]HelperRoutine, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelperRoutinesSpec.
+func (in *HelperRoutinesSpec) DeepCopy() *HelperRoutinesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HelperRoutinesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageVerification) DeepCopyInto(out *ImageVerification) {
+	*out = *in
+	in.VerifiedAt.DeepCopyInto(&out.VerifiedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageVerification.
+func (in *ImageVerification) DeepCopy() *ImageVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IntegrityCheck) DeepCopyInto(out *IntegrityCheck) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntegrityCheck.
+func (in *IntegrityCheck) DeepCopy() *IntegrityCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(IntegrityCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IntegrityCheckStatus) DeepCopyInto(out *IntegrityCheckStatus) {
+	*out = *in
+	if in.LastScan != nil {
+		in, out := &in.LastScan, &out.LastScan
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastPassCompletedAt != nil {
+		in, out := &in.LastPassCompletedAt, &out.LastPassCompletedAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntegrityCheckStatus.
+func (in *IntegrityCheckStatus) DeepCopy() *IntegrityCheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IntegrityCheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JournalEntry) DeepCopyInto(out *JournalEntry) {
+	*out = *in
+	in.Started.DeepCopyInto(&out.Started)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JournalEntry.
+func (in *JournalEntry) DeepCopy() *JournalEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(JournalEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogPosition) DeepCopyInto(out *LogPosition) {
+	*out = *in
+	in.CapturedAt.DeepCopyInto(&out.CapturedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogPosition.
+func (in *LogPosition) DeepCopy() *LogPosition {
+	if in == nil {
+		return nil
+	}
+	out := new(LogPosition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDatabase) DeepCopyInto(out *ManagedDatabase) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabase.
+func (in *ManagedDatabase) DeepCopy() *ManagedDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManagedDatabase) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDatabaseClass) DeepCopyInto(out *ManagedDatabaseClass) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseClass.
+func (in *ManagedDatabaseClass) DeepCopy() *ManagedDatabaseClass {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDatabaseClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManagedDatabaseClass) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDatabaseClassList) DeepCopyInto(out *ManagedDatabaseClassList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ManagedDatabaseClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseClassList.
+func (in *ManagedDatabaseClassList) DeepCopy() *ManagedDatabaseClassList {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDatabaseClassList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManagedDatabaseClassList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDatabaseClassSpec) DeepCopyInto(out *ManagedDatabaseClassSpec) {
+	*out = *in
+	if in.Connection != nil {
+		in, out := &in.Connection, &out.Connection
+		*out = new(ConnectionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]DatabaseGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(RotationPolicy)
+		**out = **in
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Compatibility != nil {
+		in, out := &in.Compatibility, &out.Compatibility
+		*out = new(CompatibilitySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Charset != nil {
+		in, out := &in.Charset, &out.Charset
+		*out = new(CharsetSpec)
+		**out = **in
+	}
+	if in.OperatorSession != nil {
+		in, out := &in.OperatorSession, &out.OperatorSession
+		*out = new(SessionSettings)
+		**out = **in
+	}
+	if in.SecretConvention != nil {
+		in, out := &in.SecretConvention, &out.SecretConvention
+		*out = new(SecretConvention)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseClassSpec.
+func (in *ManagedDatabaseClassSpec) DeepCopy() *ManagedDatabaseClassSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDatabaseClassSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDatabaseCondition) DeepCopyInto(out *ManagedDatabaseCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseCondition.
+func (in *ManagedDatabaseCondition) DeepCopy() *ManagedDatabaseCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDatabaseCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDatabaseError) DeepCopyInto(out *ManagedDatabaseError) {
+	*out = *in
+	if in.FirstSeen != nil {
+		in, out := &in.FirstSeen, &out.FirstSeen
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastSeen != nil {
+		in, out := &in.LastSeen, &out.LastSeen
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseError.
+func (in *ManagedDatabaseError) DeepCopy() *ManagedDatabaseError {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDatabaseError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDatabaseList) DeepCopyInto(out *ManagedDatabaseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ManagedDatabase, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseList.
+func (in *ManagedDatabaseList) DeepCopy() *ManagedDatabaseList {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDatabaseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManagedDatabaseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDatabaseReference) DeepCopyInto(out *ManagedDatabaseReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseReference.
+func (in *ManagedDatabaseReference) DeepCopy() *ManagedDatabaseReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDatabaseReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDatabaseSpec) DeepCopyInto(out *ManagedDatabaseSpec) {
+	*out = *in
+	if in.AppVersions != nil {
+		in, out := &in.AppVersions, &out.AppVersions
+		*out = make([]AppVersionRequirement, len(*in))
+		copy(*out, *in)
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreenSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Connection.DeepCopyInto(&out.Connection)
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]DatabaseGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CredentialRequestPolicies != nil {
+		in, out := &in.CredentialRequestPolicies, &out.CredentialRequestPolicies
+		*out = make([]CredentialRequestPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Galera != nil {
+		in, out := &in.Galera, &out.Galera
+		*out = new(GaleraSpec)
+		**out = **in
+	}
+	if in.AutoPause != nil {
+		in, out := &in.AutoPause, &out.AutoPause
+		*out = new(AutoPauseSpec)
+		**out = **in
+	}
+	if in.MetadataLockGuard != nil {
+		in, out := &in.MetadataLockGuard, &out.MetadataLockGuard
+		*out = new(MetadataLockGuardSpec)
+		**out = **in
+	}
+	if in.WorkloadIdentity != nil {
+		in, out := &in.WorkloadIdentity, &out.WorkloadIdentity
+		*out = new(WorkloadIdentitySpec)
+		**out = **in
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(RotationPolicy)
+		**out = **in
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Compatibility != nil {
+		in, out := &in.Compatibility, &out.Compatibility
+		*out = new(CompatibilitySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	out.DeprovisionQuarantine = in.DeprovisionQuarantine
+	if in.PlanApproval != nil {
+		in, out := &in.PlanApproval, &out.PlanApproval
+		*out = new(PlanApprovalSpec)
+		**out = **in
+	}
+	if in.PrivilegeTightening != nil {
+		in, out := &in.PrivilegeTightening, &out.PrivilegeTightening
+		*out = new(PrivilegeTighteningSpec)
+		**out = **in
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServicePublication)
+		**out = **in
+	}
+	if in.HelperRoutines != nil {
+		in, out := &in.HelperRoutines, &out.HelperRoutines
+		*out = new(HelperRoutinesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaskedViews != nil {
+		in, out := &in.MaskedViews, &out.MaskedViews
+		*out = make([]MaskedView, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ScheduledStatements != nil {
+		in, out := &in.ScheduledStatements, &out.ScheduledStatements
+		*out = make([]ScheduledStatement, len(*in))
+		copy(*out, *in)
+	}
+	if in.Partitioning != nil {
+		in, out := &in.Partitioning, &out.Partitioning
+		*out = make([]PartitionedTable, len(*in))
+		copy(*out, *in)
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = make([]RetentionRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.SeedData != nil {
+		in, out := &in.SeedData, &out.SeedData
+		*out = make([]SeedDataSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConsistencyChecks != nil {
+		in, out := &in.ConsistencyChecks, &out.ConsistencyChecks
+		*out = make([]ConsistencyCheck, len(*in))
+		copy(*out, *in)
+	}
+	if in.IntegrityChecks != nil {
+		in, out := &in.IntegrityChecks, &out.IntegrityChecks
+		*out = make([]IntegrityCheck, len(*in))
+		copy(*out, *in)
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(ReplicaSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OperatorSession != nil {
+		in, out := &in.OperatorSession, &out.OperatorSession
+		*out = new(SessionSettings)
+		**out = **in
+	}
+	if in.Charset != nil {
+		in, out := &in.Charset, &out.Charset
+		*out = new(CharsetSpec)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Extensions != nil {
+		in, out := &in.Extensions, &out.Extensions
+		*out = make([]DatabaseExtension, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailoverDrill != nil {
+		in, out := &in.FailoverDrill, &out.FailoverDrill
+		*out = new(FailoverDrillSpec)
+		**out = **in
+	}
+	if in.SecretConvention != nil {
+		in, out := &in.SecretConvention, &out.SecretConvention
+		*out = new(SecretConvention)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseSpec.
+func (in *ManagedDatabaseSpec) DeepCopy() *ManagedDatabaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDatabaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDatabaseStatus) DeepCopyInto(out *ManagedDatabaseStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]ManagedDatabaseCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastErrors != nil {
+		in, out := &in.LastErrors, &out.LastErrors
+		*out = make([]ManagedDatabaseError, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AppVersions != nil {
+		in, out := &in.AppVersions, &out.AppVersions
+		*out = make([]AppVersionStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.RegionalPrimaries != nil {
+		in, out := &in.RegionalPrimaries, &out.RegionalPrimaries
+		*out = make([]RegionalPrimaryStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Quarantined != nil {
+		in, out := &in.Quarantined, &out.Quarantined
+		*out = make([]QuarantinedUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastLogins != nil {
+		in, out := &in.LastLogins, &out.LastLogins
+		*out = make([]UserLogin, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PrivilegeUsage != nil {
+		in, out := &in.PrivilegeUsage, &out.PrivilegeUsage
+		*out = make([]PrivilegeUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PrivilegeTightening != nil {
+		in, out := &in.PrivilegeTightening, &out.PrivilegeTightening
+		*out = new(PrivilegeTightening)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Consumers != nil {
+		in, out := &in.Consumers, &out.Consumers
+		*out = make([]SecretConsumers, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(PlanProgress)
+		**out = **in
+	}
+	if in.Journal != nil {
+		in, out := &in.Journal, &out.Journal
+		*out = new(JournalEntry)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingPlan != nil {
+		in, out := &in.PendingPlan, &out.PendingPlan
+		*out = new(PendingPlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DriftedRoutines != nil {
+		in, out := &in.DriftedRoutines, &out.DriftedRoutines
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaskedViews != nil {
+		in, out := &in.MaskedViews, &out.MaskedViews
+		*out = make([]MaskedViewStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.ScheduledStatements != nil {
+		in, out := &in.ScheduledStatements, &out.ScheduledStatements
+		*out = make([]ScheduledStatementStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = make([]RetentionStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SeedData != nil {
+		in, out := &in.SeedData, &out.SeedData
+		*out = make([]SeedDataStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BinlogConsumers != nil {
+		in, out := &in.BinlogConsumers, &out.BinlogConsumers
+		*out = make([]BinlogConsumerStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConsistencyChecks != nil {
+		in, out := &in.ConsistencyChecks, &out.ConsistencyChecks
+		*out = make([]ConsistencyCheckStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.IntegrityChecks != nil {
+		in, out := &in.IntegrityChecks, &out.IntegrityChecks
+		*out = make([]IntegrityCheckStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Partitioning != nil {
+		in, out := &in.Partitioning, &out.Partitioning
+		*out = make([]PartitionedTableStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Charset != nil {
+		in, out := &in.Charset, &out.Charset
+		*out = new(CharsetStatus)
+		**out = **in
+	}
+	if in.AppliedParameters != nil {
+		in, out := &in.AppliedParameters, &out.AppliedParameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ParameterDrift != nil {
+		in, out := &in.ParameterDrift, &out.ParameterDrift
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InstalledExtensions != nil {
+		in, out := &in.InstalledExtensions, &out.InstalledExtensions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = make([]ReplicaStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GrantVerification != nil {
+		in, out := &in.GrantVerification, &out.GrantVerification
+		*out = new(GrantVerificationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(RotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailoverDrill != nil {
+		in, out := &in.FailoverDrill, &out.FailoverDrill
+		*out = new(FailoverDrillStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AppliedMigrations != nil {
+		in, out := &in.AppliedMigrations, &out.AppliedMigrations
+		*out = make([]AppliedMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MigrationProgress != nil {
+		in, out := &in.MigrationProgress, &out.MigrationProgress
+		*out = new(MigrationProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MigrationFailure != nil {
+		in, out := &in.MigrationFailure, &out.MigrationFailure
+		*out = new(MigrationFailure)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MigrationSilence != nil {
+		in, out := &in.MigrationSilence, &out.MigrationSilence
+		*out = new(AlertSilence)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Performance != nil {
+		in, out := &in.Performance, &out.Performance
+		*out = new(ReconcilePerformance)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretConvention != nil {
+		in, out := &in.SecretConvention, &out.SecretConvention
+		*out = new(SecretConvention)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabaseStatus.
+func (in *ManagedDatabaseStatus) DeepCopy() *ManagedDatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaskedColumn) DeepCopyInto(out *MaskedColumn) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaskedColumn.
+func (in *MaskedColumn) DeepCopy() *MaskedColumn {
+	if in == nil {
+		return nil
+	}
+	out := new(MaskedColumn)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaskedView) DeepCopyInto(out *MaskedView) {
+	*out = *in
+	if in.Columns != nil {
+		in, out := &in.Columns, &out.Columns
+		*out = make([]MaskedColumn, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaskedView.
+func (in *MaskedView) DeepCopy() *MaskedView {
+	if in == nil {
+		return nil
+	}
+	out := new(MaskedView)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaskedViewStatus) DeepCopyInto(out *MaskedViewStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaskedViewStatus.
+func (in *MaskedViewStatus) DeepCopy() *MaskedViewStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MaskedViewStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetadataLockGuardSpec) DeepCopyInto(out *MetadataLockGuardSpec) {
+	*out = *in
+	out.MaxWait = in.MaxWait
+	out.CheckInterval = in.CheckInterval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetadataLockGuardSpec.
+func (in *MetadataLockGuardSpec) DeepCopy() *MetadataLockGuardSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetadataLockGuardSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationBundleSpec) DeepCopyInto(out *MigrationBundleSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationBundleSpec.
+func (in *MigrationBundleSpec) DeepCopy() *MigrationBundleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationBundleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationFailure) DeepCopyInto(out *MigrationFailure) {
+	*out = *in
+	in.AbortedAt.DeepCopyInto(&out.AbortedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationFailure.
+func (in *MigrationFailure) DeepCopy() *MigrationFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationGuardSpec) DeepCopyInto(out *MigrationGuardSpec) {
+	*out = *in
+	if in.MaxBinlogGrowth != nil {
+		in, out := &in.MaxBinlogGrowth, &out.MaxBinlogGrowth
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	out.MaxReplicaLag = in.MaxReplicaLag
+	out.MaxLockWait = in.MaxLockWait
+	out.CheckInterval = in.CheckInterval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationGuardSpec.
+func (in *MigrationGuardSpec) DeepCopy() *MigrationGuardSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationGuardSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationProgress) DeepCopyInto(out *MigrationProgress) {
+	*out = *in
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationProgress.
+func (in *MigrationProgress) DeepCopy() *MigrationProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PartitionedTable) DeepCopyInto(out *PartitionedTable) {
+	*out = *in
+	out.Retention = in.Retention
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PartitionedTable.
+func (in *PartitionedTable) DeepCopy() *PartitionedTable {
+	if in == nil {
+		return nil
+	}
+	out := new(PartitionedTable)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PartitionedTableStatus) DeepCopyInto(out *PartitionedTableStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PartitionedTableStatus.
+func (in *PartitionedTableStatus) DeepCopy() *PartitionedTableStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PartitionedTableStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingPlan) DeepCopyInto(out *PendingPlan) {
+	*out = *in
+	if in.Statements != nil {
+		in, out := &in.Statements, &out.Statements
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingPlan.
+func (in *PendingPlan) DeepCopy() *PendingPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PhaseDuration) DeepCopyInto(out *PhaseDuration) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PhaseDuration.
+func (in *PhaseDuration) DeepCopy() *PhaseDuration {
+	if in == nil {
+		return nil
+	}
+	out := new(PhaseDuration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlanApprovalSpec) DeepCopyInto(out *PlanApprovalSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanApprovalSpec.
+func (in *PlanApprovalSpec) DeepCopy() *PlanApprovalSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlanApprovalSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlanProgress) DeepCopyInto(out *PlanProgress) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanProgress.
+func (in *PlanProgress) DeepCopy() *PlanProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(PlanProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostMigrationSpec) DeepCopyInto(out *PostMigrationSpec) {
+	*out = *in
+	if in.AnalyzeTables != nil {
+		in, out := &in.AnalyzeTables, &out.AnalyzeTables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.TimeLimit = in.TimeLimit
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostMigrationSpec.
+func (in *PostMigrationSpec) DeepCopy() *PostMigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PostMigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrivilegeSuggestion) DeepCopyInto(out *PrivilegeSuggestion) {
+	*out = *in
+	in.UnusedSince.DeepCopyInto(&out.UnusedSince)
+	if in.Revoke != nil {
+		in, out := &in.Revoke, &out.Revoke
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrivilegeSuggestion.
+func (in *PrivilegeSuggestion) DeepCopy() *PrivilegeSuggestion {
+	if in == nil {
+		return nil
+	}
+	out := new(PrivilegeSuggestion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrivilegeTightening) DeepCopyInto(out *PrivilegeTightening) {
+	*out = *in
+	if in.Suggestions != nil {
+		in, out := &in.Suggestions, &out.Suggestions
+		*out = make([]PrivilegeSuggestion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.ComputedAt.DeepCopyInto(&out.ComputedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrivilegeTightening.
+func (in *PrivilegeTightening) DeepCopy() *PrivilegeTightening {
+	if in == nil {
+		return nil
+	}
+	out := new(PrivilegeTightening)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrivilegeTighteningSpec) DeepCopyInto(out *PrivilegeTighteningSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrivilegeTighteningSpec.
+func (in *PrivilegeTighteningSpec) DeepCopy() *PrivilegeTighteningSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PrivilegeTighteningSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrivilegeUsage) DeepCopyInto(out *PrivilegeUsage) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+	if in.LastUsed != nil {
+		in, out := &in.LastUsed, &out.LastUsed
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrivilegeUsage.
+func (in *PrivilegeUsage) DeepCopy() *PrivilegeUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(PrivilegeUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuarantinedUser) DeepCopyInto(out *QuarantinedUser) {
+	*out = *in
+	in.LockedAt.DeepCopyInto(&out.LockedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuarantinedUser.
+func (in *QuarantinedUser) DeepCopy() *QuarantinedUser {
+	if in == nil {
+		return nil
+	}
+	out := new(QuarantinedUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReconcilePerformance) DeepCopyInto(out *ReconcilePerformance) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	out.Duration = in.Duration
+	if in.Phases != nil {
+		in, out := &in.Phases, &out.Phases
+		*out = make([]PhaseDuration, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReconcilePerformance.
+func (in *ReconcilePerformance) DeepCopy() *ReconcilePerformance {
+	if in == nil {
+		return nil
+	}
+	out := new(ReconcilePerformance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegionalPrimary) DeepCopyInto(out *RegionalPrimary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegionalPrimary.
+func (in *RegionalPrimary) DeepCopy() *RegionalPrimary {
+	if in == nil {
+		return nil
+	}
+	out := new(RegionalPrimary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegionalPrimaryStatus) DeepCopyInto(out *RegionalPrimaryStatus) {
+	*out = *in
+	in.At.DeepCopyInto(&out.At)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegionalPrimaryStatus.
+func (in *RegionalPrimaryStatus) DeepCopy() *RegionalPrimaryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RegionalPrimaryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaEndpoint) DeepCopyInto(out *ReplicaEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaEndpoint.
+func (in *ReplicaEndpoint) DeepCopy() *ReplicaEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaSpec) DeepCopyInto(out *ReplicaSpec) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]ReplicaEndpoint, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaSpec.
+func (in *ReplicaSpec) DeepCopy() *ReplicaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaStatus) DeepCopyInto(out *ReplicaStatus) {
+	*out = *in
+	in.CheckedAt.DeepCopyInto(&out.CheckedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaStatus.
+func (in *ReplicaStatus) DeepCopy() *ReplicaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetentionRule) DeepCopyInto(out *RetentionRule) {
+	*out = *in
+	out.Retain = in.Retain
+	out.Pause = in.Pause
+	out.MaxReplicaLag = in.MaxReplicaLag
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetentionRule.
+func (in *RetentionRule) DeepCopy() *RetentionRule {
+	if in == nil {
+		return nil
+	}
+	out := new(RetentionRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetentionStatus) DeepCopyInto(out *RetentionStatus) {
+	*out = *in
+	if in.LastBatch != nil {
+		in, out := &in.LastBatch, &out.LastBatch
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	out.Backoff = in.Backoff
+	if in.BackoffUntil != nil {
+		in, out := &in.BackoffUntil, &out.BackoffUntil
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetentionStatus.
+func (in *RetentionStatus) DeepCopy() *RetentionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RetentionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RotationPolicy) DeepCopyInto(out *RotationPolicy) {
+	*out = *in
+	out.Jitter = in.Jitter
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RotationPolicy.
+func (in *RotationPolicy) DeepCopy() *RotationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RotationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RotationStatus) DeepCopyInto(out *RotationStatus) {
+	*out = *in
+	if in.LastRotatedAt != nil {
+		in, out := &in.LastRotatedAt, &out.LastRotatedAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NextRotationAt != nil {
+		in, out := &in.NextRotationAt, &out.NextRotationAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RotationStatus.
+func (in *RotationStatus) DeepCopy() *RotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledStatement) DeepCopyInto(out *ScheduledStatement) {
+	*out = *in
+	out.Every = in.Every
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledStatement.
+func (in *ScheduledStatement) DeepCopy() *ScheduledStatement {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledStatement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledStatementStatus) DeepCopyInto(out *ScheduledStatementStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledStatementStatus.
+func (in *ScheduledStatementStatus) DeepCopy() *ScheduledStatementStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledStatementStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretConsumers) DeepCopyInto(out *SecretConsumers) {
+	*out = *in
+	if in.Generations != nil {
+		in, out := &in.Generations, &out.Generations
+		*out = make([]CredentialGeneration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretConsumers.
+func (in *SecretConsumers) DeepCopy() *SecretConsumers {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretConsumers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretConvention) DeepCopyInto(out *SecretConvention) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretConvention.
+func (in *SecretConvention) DeepCopy() *SecretConvention {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretConvention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedDataSource) DeepCopyInto(out *SeedDataSource) {
+	*out = *in
+	if in.Container != nil {
+		in, out := &in.Container, &out.Container
+		*out = new(v1.Container)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedDataSource.
+func (in *SeedDataSource) DeepCopy() *SeedDataSource {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedDataSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedDataStatus) DeepCopyInto(out *SeedDataStatus) {
+	*out = *in
+	in.AppliedAt.DeepCopyInto(&out.AppliedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedDataStatus.
+func (in *SeedDataStatus) DeepCopy() *SeedDataStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedDataStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServicePublication) DeepCopyInto(out *ServicePublication) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicePublication.
+func (in *ServicePublication) DeepCopy() *ServicePublication {
+	if in == nil {
+		return nil
+	}
+	out := new(ServicePublication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SessionSettings) DeepCopyInto(out *SessionSettings) {
+	*out = *in
+	out.LockWaitTimeout = in.LockWaitTimeout
+	out.InnoDBLockWaitTimeout = in.InnoDBLockWaitTimeout
+	out.StatementTimeout = in.StatementTimeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SessionSettings.
+func (in *SessionSettings) DeepCopy() *SessionSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(SessionSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotTableStatus) DeepCopyInto(out *SnapshotTableStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotTableStatus.
+func (in *SnapshotTableStatus) DeepCopy() *SnapshotTableStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotTableStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TableGrant) DeepCopyInto(out *TableGrant) {
+	*out = *in
+	if in.Columns != nil {
+		in, out := &in.Columns, &out.Columns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableGrant.
+func (in *TableGrant) DeepCopy() *TableGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(TableGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TableMask) DeepCopyInto(out *TableMask) {
+	*out = *in
+	if in.Columns != nil {
+		in, out := &in.Columns, &out.Columns
+		*out = make([]MaskedColumn, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableMask.
+func (in *TableMask) DeepCopy() *TableMask {
+	if in == nil {
+		return nil
+	}
+	out := new(TableMask)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserLogin) DeepCopyInto(out *UserLogin) {
+	*out = *in
+	in.LastLogin.DeepCopyInto(&out.LastLogin)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserLogin.
+func (in *UserLogin) DeepCopy() *UserLogin {
+	if in == nil {
+		return nil
+	}
+	out := new(UserLogin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentitySpec) DeepCopyInto(out *WorkloadIdentitySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentitySpec.
+func (in *WorkloadIdentitySpec) DeepCopy() *WorkloadIdentitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentitySpec)
 	in.DeepCopyInto(out)
 	return out
 }
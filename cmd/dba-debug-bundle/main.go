@@ -0,0 +1,101 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// dba-debug-bundle downloads a support bundle from an operator running with
+// --debug, for attaching to bug reports. The bundle is a zip archive of the
+// operator's goroutines and runtime state, its recent reconciles, the
+// connection pool of each managed database, and the timings and EXPLAIN
+// output of the statements it recently sent to them. Only statement
+// templates are recorded, and errors are redacted of credentials. The
+// diagnostics address normally only listens on localhost, so it is reached
+// with:
+//
+//	kubectl port-forward <operator pod> 8082
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	var url string
+	var tokenFile string
+	var output string
+	flag.StringVar(&url, "url", "http://127.0.0.1:8082/debug/bundle", "The URL of the operator's support bundle endpoint.")
+	flag.StringVar(&tokenFile, "token-file", "", "Path to a file containing the operator's diagnostics token, if it requires one.")
+	flag.StringVar(&output, "output", "", "The file to write the bundle to, dba-operator-<time>.zip when empty.")
+	flag.Parse()
+
+	if output == "" {
+		output = fmt.Sprintf("dba-operator-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	}
+	if err := download(url, tokenFile, output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func download(url, tokenFile, output string) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("Unable to build request: %w", err)
+	}
+	if tokenFile != "" {
+		token, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return fmt.Errorf("Unable to read diagnostics token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	// Dumping every goroutine of a busy operator can take a while
+	client := http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Unable to fetch support bundle: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Unable to read support bundle: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Diagnostics endpoint returned %s", resp.Status)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return fmt.Errorf("Response was not a support bundle: %w", err)
+	}
+	var files []string
+	for _, file := range archive.File {
+		files = append(files, file.Name)
+	}
+
+	if err := ioutil.WriteFile(output, body, 0644); err != nil {
+		return fmt.Errorf("Unable to write support bundle: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s containing %s\n", output, strings.Join(files, ", "))
+	return nil
+}
@@ -0,0 +1,76 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// dba-schema-dump writes the DDL of a database to stdout or a file, in the
+// same format as the schema snapshots recorded by the operator. The DSN is
+// read from the environment so that it never appears in process listings.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/mysqladmin"
+)
+
+func main() {
+	var engine string
+	var dsnEnv string
+	var output string
+	flag.StringVar(&engine, "engine", "mysql", "The database engine to connect to.")
+	flag.StringVar(&dsnEnv, "dsn-env", "DBA_OP_CONNECTION_STRING", "The environment variable containing the connection DSN.")
+	flag.StringVar(&output, "output", "", "The file to write the schema to, stdout is used when empty.")
+	flag.Parse()
+
+	if err := dump(engine, os.Getenv(dsnEnv), output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func dump(engine, dsn, output string) error {
+	if dsn == "" {
+		return fmt.Errorf("No connection DSN was provided in the environment")
+	}
+
+	var admin dbadmin.DbAdmin
+	var err error
+	switch engine {
+	case "mysql":
+		admin, err = mysqladmin.CreateMySQLAdmin(dsn, nil, logf.NullLogger{})
+	default:
+		return fmt.Errorf("Unknown database engine: %s", engine)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to create database connection: %w", err)
+	}
+	defer admin.Close()
+
+	ddl, err := admin.ExportSchema()
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = fmt.Print(ddl)
+		return err
+	}
+	return ioutil.WriteFile(output, []byte(ddl), 0644)
+}
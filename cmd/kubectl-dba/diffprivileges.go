@@ -0,0 +1,120 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/alembic"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/embedded"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/mysqladmin"
+	"github.com/app-sre/dba-operator/pkg/privdiff"
+)
+
+// diffExitCode is returned with -exit-code when the databases differ, so
+// that a pipeline can tell drift apart from a failure to compare
+const diffExitCode = 3
+
+func diffPrivileges(args []string) error {
+	flags := flag.NewFlagSet("diff-privileges", flag.ExitOnError)
+	var engine string
+	var migrationEngine string
+	var fromName, toName string
+	var fromDSNEnv, toDSNEnv string
+	var prefixes string
+	var output string
+	var exitCode bool
+	flags.StringVar(&engine, "engine", "mysql", "The database engine of both databases.")
+	flags.StringVar(&migrationEngine, "migration-engine", "alembic", "The migration engine which records the schema version: alembic or embedded.")
+	flags.StringVar(&fromName, "from", "staging", "The name of the baseline environment.")
+	flags.StringVar(&toName, "to", "prod", "The name of the environment compared to the baseline.")
+	flags.StringVar(&fromDSNEnv, "from-dsn-env", "DBA_OP_FROM_CONNECTION_STRING", "The environment variable containing the DSN of the baseline database.")
+	flags.StringVar(&toDSNEnv, "to-dsn-env", "DBA_OP_TO_CONNECTION_STRING", "The environment variable containing the DSN of the compared database.")
+	flags.StringVar(&prefixes, "username-prefixes", "dba_,dbr_", "The comma separated prefixes of the users to compare.")
+	flags.StringVar(&output, "output", "text", "The format of the report: text or json.")
+	flags.BoolVar(&exitCode, "exit-code", false, fmt.Sprintf("Exit with status %d when the databases differ.", diffExitCode))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if output != "text" && output != "json" {
+		return fmt.Errorf("Unknown output format: %s", output)
+	}
+
+	var versions dbadmin.MigrationEngine
+	switch migrationEngine {
+	case "alembic":
+		versions = alembic.CreateMigrationEngine()
+	case "embedded":
+		versions = embedded.CreateMigrationEngine()
+	default:
+		return fmt.Errorf("Unknown migration engine: %s", migrationEngine)
+	}
+
+	usernamePrefixes := strings.Split(prefixes, ",")
+	from, err := captureEnvironment(engine, versions, fromName, os.Getenv(fromDSNEnv), usernamePrefixes)
+	if err != nil {
+		return err
+	}
+	to, err := captureEnvironment(engine, versions, toName, os.Getenv(toDSNEnv), usernamePrefixes)
+	if err != nil {
+		return err
+	}
+
+	report := privdiff.Compare(from, to)
+	if output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if exitCode && !report.Empty() {
+		os.Exit(diffExitCode)
+	}
+	return nil
+}
+
+func captureEnvironment(engine string, versions dbadmin.MigrationEngine, name, dsn string, usernamePrefixes []string) (privdiff.Snapshot, error) {
+	if dsn == "" {
+		return privdiff.Snapshot{}, fmt.Errorf("No connection DSN for %s was provided in the environment", name)
+	}
+
+	var admin dbadmin.DbAdmin
+	var err error
+	switch engine {
+	case "mysql":
+		admin, err = mysqladmin.CreateMySQLAdmin(dsn, versions, logf.NullLogger{})
+	default:
+		return privdiff.Snapshot{}, fmt.Errorf("Unknown database engine: %s", engine)
+	}
+	if err != nil {
+		return privdiff.Snapshot{}, fmt.Errorf("Unable to create database connection to %s: %w", name, err)
+	}
+	defer admin.Close()
+
+	return privdiff.Capture(name, admin, usernamePrefixes)
+}
@@ -0,0 +1,95 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kubectl-dba is a kubectl plugin for administering the databases which are
+// managed by the operator. Installed on the PATH it is run as `kubectl dba`.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/mysqladmin"
+)
+
+const usage = `Usage: kubectl dba <command> [flags]
+
+Commands:
+  bootstrap-admin  Print the statements which create the operator's admin user
+  diff-privileges  Compare the users, privileges and schema versions of two databases
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "bootstrap-admin":
+		err = bootstrapAdmin(os.Args[2:])
+	case "diff-privileges":
+		err = diffPrivileges(os.Args[2:])
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func bootstrapAdmin(args []string) error {
+	flags := flag.NewFlagSet("bootstrap-admin", flag.ExitOnError)
+	var profile string
+	var engine string
+	var flavor string
+	var username string
+	var host string
+	var database string
+	flags.StringVar(&profile, "profile", string(dbadmin.ProfileFull), "The privilege profile of the admin user: credentials-only, credentials+migrations or full.")
+	flags.StringVar(&engine, "engine", "mysql", "The database engine that the admin user is created on.")
+	flags.StringVar(&flavor, "flavor", string(dbadmin.FlavorMySQL), "The flavor of server: mysql, mariadb, aurora-mysql or tidb.")
+	flags.StringVar(&username, "username", "dba-operator", "The name of the admin user.")
+	flags.StringVar(&host, "host", "%", "The host that the admin user connects from.")
+	flags.StringVar(&database, "database", "", "The managed database that the admin user administers.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var statements []string
+	var err error
+	switch engine {
+	case "mysql":
+		statements, err = mysqladmin.BootstrapStatements(dbadmin.PrivilegeProfile(profile), dbadmin.ServerFlavor(flavor), username, host, database)
+	default:
+		return fmt.Errorf("Unknown database engine: %s", engine)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("-- Admin user for the %s profile, set its password with ALTER USER\n", profile)
+	for _, statement := range statements {
+		fmt.Println(statement + ";")
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/adminapi"
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+// RequestedByAnnotation names the caller of the admin API which created a
+// DatabaseOperation
+const RequestedByAnnotation = "dbaoperator.app-sre.redhat.com/requested-by"
+
+// adminAPIOperations are the DatabaseOperation actions which callers can
+// start, by the path they are started at
+var adminAPIOperations = map[string]struct {
+	action     string
+	permission string
+}{
+	"rotate":     {operationRotateCredentials, adminapi.ActionRotate},
+	"drift-scan": {operationDriftScan, adminapi.ActionDriftScan},
+}
+
+type adminAPIUsers struct {
+	Users []string `json:"users"`
+}
+
+type adminAPIOperation struct {
+	Namespace string                      `json:"namespace"`
+	Name      string                      `json:"name"`
+	Action    string                      `json:"action"`
+	Status    dba.DatabaseOperationStatus `json:"status"`
+}
+
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=databaseoperations,verbs=create
+
+// AdminAPIHandler serves the admin API, for tooling outside of the cluster:
+//
+//	GET  /v1/databases/<namespace>/<name>/users       lists the issued users
+//	POST /v1/databases/<namespace>/<name>/rotate      rotates the credentials
+//	POST /v1/databases/<namespace>/<name>/drift-scan  runs the drift checks
+//	GET  /v1/operations/<namespace>/<name>            reports on an operation
+//
+// Rotations and drift scans are run as DatabaseOperations, so that they are
+// serialized with everything else the operator does and recorded in the
+// cluster. Each request is authorized against the callers, and logged.
+type AdminAPIHandler struct {
+	Client  client.Client
+	Callers []adminapi.Caller
+	Log     logr.Logger
+}
+
+func (h AdminAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	caller := adminapi.Authenticate(h.Callers, r)
+	if caller == nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="dba-operator admin"`)
+		http.Error(w, "A valid bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+	if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	namespace, name := parts[1], parts[2]
+	log := h.Log.WithValues("caller", caller.Name, "method", r.Method, "path", r.URL.Path)
+
+	switch {
+	case parts[0] == "databases" && len(parts) == 4 && parts[3] == "users":
+		if !h.authorize(w, log, caller, r, http.MethodGet, namespace, adminapi.ActionRead) {
+			return
+		}
+		h.listUsers(w, r, log, namespace, name)
+	case parts[0] == "databases" && len(parts) == 4:
+		operation, ok := adminAPIOperations[parts[3]]
+		if !ok {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		if !h.authorize(w, log, caller, r, http.MethodPost, namespace, operation.permission) {
+			return
+		}
+		h.startOperation(w, r, log, caller, namespace, name, operation.action)
+	case parts[0] == "operations" && len(parts) == 3:
+		if !h.authorize(w, log, caller, r, http.MethodGet, namespace, adminapi.ActionRead) {
+			return
+		}
+		h.getOperation(w, r, namespace, name)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// authorize returns true if the caller may take the action with the method,
+// otherwise it responds with the reason
+func (h AdminAPIHandler) authorize(w http.ResponseWriter, log logr.Logger, caller *adminapi.Caller, r *http.Request, method, namespace, action string) bool {
+	if r.Method != method {
+		http.Error(w, fmt.Sprintf("Only %s is supported", method), http.StatusMethodNotAllowed)
+		return false
+	}
+	if !caller.Allows(namespace, action) {
+		log.Info("Refused admin API request", "action", action)
+		http.Error(w, fmt.Sprintf("Caller %s may not %s in namespace %s", caller.Name, action, namespace), http.StatusForbidden)
+		return false
+	}
+	log.Info("Serving admin API request", "action", action)
+	return true
+}
+
+func (h AdminAPIHandler) listUsers(w http.ResponseWriter, r *http.Request, log logr.Logger, namespace, name string) {
+	var db dba.ManagedDatabase
+	if !h.fetch(w, r, types.NamespacedName{Namespace: namespace, Name: name}, &db) {
+		return
+	}
+	if err := applyManagedDatabaseClass(r.Context(), h.Client, &db); err != nil {
+		http.Error(w, redact.String(err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	admin, err := initializeAdminConnection(r.Context(), log, nil, h.Client, db.Namespace, &db.Spec)
+	if err != nil {
+		log.Error(redact.Error(err), "Unable to connect to database")
+		http.Error(w, "Unable to connect to the database", http.StatusBadGateway)
+		return
+	}
+	defer admin.Close()
+
+	users := adminAPIUsers{Users: []string{}}
+	for _, prefix := range issuedUsernamePrefixes {
+		usernames, err := admin.ListUsernames(prefix)
+		if err != nil {
+			log.Error(redact.Error(err), "Unable to list users")
+			http.Error(w, "Unable to list the users of the database", http.StatusBadGateway)
+			return
+		}
+		users.Users = append(users.Users, usernames...)
+	}
+	sort.Strings(users.Users)
+
+	respondJSON(w, http.StatusOK, users)
+}
+
+func (h AdminAPIHandler) startOperation(w http.ResponseWriter, r *http.Request, log logr.Logger, caller *adminapi.Caller, namespace, name, action string) {
+	var db dba.ManagedDatabase
+	if !h.fetch(w, r, types.NamespacedName{Namespace: namespace, Name: name}, &db) {
+		return
+	}
+
+	operation, err := createOperation(r.Context(), h.Client, &db, action, "admin-api/"+caller.Name)
+	if err != nil {
+		log.Error(err, "Unable to create DatabaseOperation")
+		http.Error(w, "Unable to create the DatabaseOperation", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("Started operation", "operation", operation.Name, "action", action)
+	respondJSON(w, http.StatusAccepted, adminAPIOperation{Namespace: namespace, Name: operation.Name, Action: action})
+}
+
+func (h AdminAPIHandler) getOperation(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	var operation dba.DatabaseOperation
+	if !h.fetch(w, r, types.NamespacedName{Namespace: namespace, Name: name}, &operation) {
+		return
+	}
+	respondJSON(w, http.StatusOK, adminAPIOperation{
+		Namespace: namespace,
+		Name:      name,
+		Action:    operation.Spec.Action,
+		Status:    operation.Status,
+	})
+}
+
+// fetch gets the object, or responds with why it can't
+func (h AdminAPIHandler) fetch(w http.ResponseWriter, r *http.Request, key types.NamespacedName, obj runtime.Object) bool {
+	if err := h.Client.Get(r.Context(), key, obj); err != nil {
+		if apierrs.IsNotFound(err) {
+			http.Error(w, fmt.Sprintf("%s does not exist", key), http.StatusNotFound)
+			return false
+		}
+		http.Error(w, fmt.Sprintf("Unable to fetch %s", key), http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// createOperation starts a DatabaseOperation on the database on behalf of
+// the requester
+func createOperation(ctx context.Context, apiClient client.Client, db *dba.ManagedDatabase, action, requestedBy string) (*dba.DatabaseOperation, error) {
+	operation := &dba.DatabaseOperation{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    db.Namespace,
+			GenerateName: fmt.Sprintf("%s-%s-", db.Name, action),
+			Annotations:  map[string]string{RequestedByAnnotation: requestedBy},
+		},
+		Spec: dba.DatabaseOperationSpec{ManagedDatabase: db.Name, Action: action},
+	}
+	if err := apiClient.Create(ctx, operation); err != nil {
+		return nil, fmt.Errorf("Unable to create DatabaseOperation: %w", err)
+	}
+	return operation, nil
+}
+
+func respondJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
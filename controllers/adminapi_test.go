@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/adminapi"
+)
+
+const (
+	operatorToken = "operator-token-0123456789abcdefghij"
+	readerToken   = "reader-token-0123456789abcdefghijkl"
+)
+
+// generateNameClient names objects from their GenerateName, as the API
+// server would
+type generateNameClient struct {
+	client.Client
+	generated int
+}
+
+func (gc *generateNameClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOptionFunc) error {
+	if operation, ok := obj.(*dba.DatabaseOperation); ok && operation.Name == "" {
+		gc.generated++
+		operation.Name = fmt.Sprintf("%s%d", operation.GenerateName, gc.generated)
+	}
+	return gc.Client.Create(ctx, obj, opts...)
+}
+
+func testAdminAPIHandler(t *testing.T) AdminAPIHandler {
+	db := &dba.ManagedDatabase{ObjectMeta: metav1.ObjectMeta{Namespace: "quay", Name: "quayio"}}
+	operation := &dba.DatabaseOperation{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "quay", Name: "quayio-drift-scan-1"},
+		Spec:       dba.DatabaseOperationSpec{ManagedDatabase: "quayio", Action: operationDriftScan},
+	}
+	return AdminAPIHandler{
+		Client: &generateNameClient{Client: fake.NewFakeClientWithScheme(testScheme(t), db, operation)},
+		Callers: []adminapi.Caller{
+			{Name: "operator", Token: operatorToken, Namespaces: []string{"quay"}, Actions: []string{adminapi.ActionRead, adminapi.ActionRotate}},
+			{Name: "reader", Token: readerToken, Actions: []string{adminapi.ActionRead}},
+		},
+		Log: logf.NullLogger{},
+	}
+}
+
+func serveAdminAPI(handler AdminAPIHandler, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestAdminAPIAuthorization(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"no token", http.MethodPost, "/v1/databases/quay/quayio/rotate", "", http.StatusUnauthorized},
+		{"unknown token", http.MethodPost, "/v1/databases/quay/quayio/rotate", "unknown-token-0123456789abcdefghij", http.StatusUnauthorized},
+		{"wrong method", http.MethodGet, "/v1/databases/quay/quayio/rotate", operatorToken, http.StatusMethodNotAllowed},
+		{"namespace which isn't allowed", http.MethodPost, "/v1/databases/billing/quayio/rotate", operatorToken, http.StatusForbidden},
+		{"action which isn't allowed", http.MethodPost, "/v1/databases/quay/quayio/rotate", readerToken, http.StatusForbidden},
+		{"unknown action", http.MethodPost, "/v1/databases/quay/quayio/drop", operatorToken, http.StatusNotFound},
+		{"unknown path", http.MethodGet, "/v1/quay", operatorToken, http.StatusNotFound},
+		{"missing database", http.MethodPost, "/v1/databases/quay/missing/rotate", operatorToken, http.StatusNotFound},
+	} {
+		handler := testAdminAPIHandler(t)
+		if recorder := serveAdminAPI(handler, tc.method, tc.path, tc.token); recorder.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.status, recorder.Code)
+		}
+
+		var operations dba.DatabaseOperationList
+		if err := handler.Client.List(context.Background(), &operations); err != nil {
+			t.Fatal(err)
+		}
+		if len(operations.Items) != 1 {
+			t.Errorf("%s: refused requests must not start operations, got %d", tc.name, len(operations.Items))
+		}
+	}
+}
+
+func TestAdminAPIStartsOperations(t *testing.T) {
+	handler := testAdminAPIHandler(t)
+	recorder := serveAdminAPI(handler, http.MethodPost, "/v1/databases/quay/quayio/rotate", operatorToken)
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, recorder.Code, recorder.Body.String())
+	}
+
+	var started adminAPIOperation
+	if err := json.Unmarshal(recorder.Body.Bytes(), &started); err != nil {
+		t.Fatal(err)
+	}
+	var operation dba.DatabaseOperation
+	if err := handler.Client.Get(context.Background(), client.ObjectKey{Namespace: "quay", Name: started.Name}, &operation); err != nil {
+		t.Fatalf("expected the operation to be created: %v", err)
+	}
+	if operation.Spec.ManagedDatabase != "quayio" || operation.Spec.Action != operationRotateCredentials {
+		t.Errorf("unexpected operation spec %+v", operation.Spec)
+	}
+	if requestedBy := operation.Annotations[RequestedByAnnotation]; requestedBy != "admin-api/operator" {
+		t.Errorf("expected the operation to name its caller, got %q", requestedBy)
+	}
+}
+
+func TestAdminAPIGetOperation(t *testing.T) {
+	handler := testAdminAPIHandler(t)
+	recorder := serveAdminAPI(handler, http.MethodGet, "/v1/operations/quay/quayio-drift-scan-1", readerToken)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	var reported adminAPIOperation
+	if err := json.Unmarshal(recorder.Body.Bytes(), &reported); err != nil {
+		t.Fatal(err)
+	}
+	if reported.Namespace != "quay" || reported.Name != "quayio-drift-scan-1" || reported.Action != operationDriftScan {
+		t.Errorf("unexpected operation %+v", reported)
+	}
+
+	if recorder := serveAdminAPI(handler, http.MethodGet, "/v1/operations/quay/missing", readerToken); recorder.Code != http.StatusNotFound {
+		t.Errorf("expected missing operations to be %d, got %d", http.StatusNotFound, recorder.Code)
+	}
+}
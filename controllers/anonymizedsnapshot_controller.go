@@ -0,0 +1,388 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// SnapshotTargetAnnotation must be set to "true" on a ManagedDatabase before
+// snapshots are restored into it, since their tables are dropped first
+const SnapshotTargetAnnotation = "dbaoperator.app-sre.redhat.com/snapshot-target"
+
+// Phases of an AnonymizedSnapshot
+const (
+	snapshotCopying   = "Copying"
+	snapshotExporting = "Exporting"
+	snapshotSucceeded = "Succeeded"
+	snapshotFailed    = "Failed"
+)
+
+// snapshotBatchSize is how many rows are read from the source before they
+// are inserted into the target
+const snapshotBatchSize = 1000
+
+// AnonymizedSnapshotController copies the tables of a ManagedDatabase into
+// another one with the requested columns masked, and optionally runs a Job
+// to export the copy. Each snapshot is taken once.
+type AnonymizedSnapshotController struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	config      config.Provider
+	metrics     AnonymizedSnapshotControllerMetrics
+	diagnostics *diagnostics.Recorder
+
+	// databases provides the connection string of the target to the export
+	databases *ManagedDatabaseController
+}
+
+// NewAnonymizedSnapshotController will instantiate an
+// AnonymizedSnapshotController with the supplied arguments and logical
+// defaults. diag enables debug mode, see diagnostics.Recorder.
+func NewAnonymizedSnapshotController(
+	c client.Client,
+	scheme *runtime.Scheme,
+	l logr.Logger,
+	diag *diagnostics.Recorder,
+	cfg config.Provider,
+	databases *ManagedDatabaseController,
+) (*AnonymizedSnapshotController, []prometheus.Collector) {
+	metrics := generateAnonymizedSnapshotControllerMetrics()
+
+	return &AnonymizedSnapshotController{
+		Client:      c,
+		Scheme:      scheme,
+		Log:         l,
+		config:      cfg,
+		metrics:     metrics,
+		diagnostics: diag,
+		databases:   databases,
+	}, getAllMetrics(metrics)
+}
+
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=anonymizedsnapshots,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=anonymizedsnapshots/status,verbs=get;update;patch
+
+// ReconcileAnonymizedSnapshot should be invoked whenever an
+// AnonymizedSnapshot or its export Job is created or changed.
+func (c *AnonymizedSnapshotController) ReconcileAnonymizedSnapshot(req ctrl.Request) (ctrl.Result, error) {
+	var ctx = context.Background()
+	var log = c.Log.WithValues("anonymizedsnapshot", req.NamespacedName)
+
+	var snapshot dba.AnonymizedSnapshot
+	if err := c.Get(ctx, req.NamespacedName, &snapshot); err != nil {
+		if apierrs.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "unable to fetch AnonymizedSnapshot", "phase", phaseFetch)
+		return ctrl.Result{}, err
+	}
+
+	if snapshot.Status.Phase == snapshotSucceeded || snapshot.Status.Phase == snapshotFailed {
+		return ctrl.Result{}, nil
+	}
+	log = log.WithValues("source", snapshot.Spec.Source, "target", snapshot.Spec.Target)
+
+	if snapshot.Status.Phase == "" {
+		// Recording the start first makes a conflicting update fail before
+		// anything has been copied
+		now := metav1.Now()
+		snapshot.Status.Phase = snapshotCopying
+		snapshot.Status.StartedAt = &now
+		if err := c.Status().Update(ctx, &snapshot); err != nil {
+			return ctrl.Result{}, fmt.Errorf("Unable to start AnonymizedSnapshot: %w", err)
+		}
+	}
+
+	var err error
+	if snapshot.Status.Phase == snapshotCopying {
+		err = c.copySnapshot(ctx, log, &snapshot)
+	} else {
+		err = c.reconcileExport(ctx, log, &snapshot)
+	}
+
+	var maybeTemporary xerrors.EnhancedError
+	if errors.As(err, &maybeTemporary) && maybeTemporary.Temporary() {
+		// The copied tables are dropped and created again, so the copy is
+		// simply started over
+		delay := temporaryErrorDelay(c.config.Current().Backoff, err, snapshot.Status.TemporaryErrorRetries)
+		log.Info("Retrying after temporary error", "error", err.Error(), "retryAfter", delay)
+		snapshot.Status.Message = redact.String(err.Error())
+		snapshot.Status.TemporaryErrorRetries = xerrors.CountRetry(snapshot.Status.TemporaryErrorRetries)
+		if err := c.Status().Update(ctx, &snapshot); err != nil {
+			log.Error(err, "Unable to update AnonymizedSnapshot status block")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	if err != nil {
+		log.Error(err, "snapshot failed")
+		now := metav1.Now()
+		snapshot.Status.Phase = snapshotFailed
+		snapshot.Status.Message = redact.String(err.Error())
+		snapshot.Status.CompletedAt = &now
+		c.metrics.SnapshotsFailed.Inc()
+	} else if snapshot.Status.Phase == snapshotSucceeded {
+		log.Info("Snapshot succeeded", "result", snapshot.Status.Message)
+		c.metrics.SnapshotsSucceeded.Inc()
+	}
+
+	if err := c.Status().Update(ctx, &snapshot); err != nil {
+		log.Error(err, "Unable to update AnonymizedSnapshot status block")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// copySnapshot restores the tables of the source into the target with their
+// columns masked, then moves the snapshot on to exporting or success
+func (c *AnonymizedSnapshotController) copySnapshot(ctx context.Context, log logr.Logger, snapshot *dba.AnonymizedSnapshot) error {
+	if snapshot.Spec.Source == snapshot.Spec.Target {
+		return fmt.Errorf("A snapshot can't be restored into its own source")
+	}
+	source, err := c.fetchDatabase(ctx, snapshot.Namespace, snapshot.Spec.Source)
+	if err != nil {
+		return err
+	}
+	target, err := c.fetchDatabase(ctx, snapshot.Namespace, snapshot.Spec.Target)
+	if err != nil {
+		return err
+	}
+	if target.Annotations[SnapshotTargetAnnotation] != "true" {
+		return fmt.Errorf("ManagedDatabase %s must be annotated with %s: \"true\" to have snapshots restored into it", target.Name, SnapshotTargetAnnotation)
+	}
+
+	cfg := c.config.Current()
+	for _, db := range []*dba.ManagedDatabase{source, target} {
+		if reason := pauseReason(cfg, db); reason != "" {
+			return xerrors.NewTempErrorf("Waiting to copy the snapshot, %s", reason)
+		}
+	}
+
+	sourceAdmin, err := initializeAdminConnection(ctx, log, c.diagnostics, c.Client, source.Namespace, &source.Spec)
+	if err != nil {
+		return fmt.Errorf("Unable to create database connection to the source: %w", err)
+	}
+	defer sourceAdmin.Close()
+	targetAdmin, err := initializeAdminConnection(ctx, log, c.diagnostics, c.Client, target.Namespace, &target.Spec)
+	if err != nil {
+		return fmt.Errorf("Unable to create database connection to the target: %w", err)
+	}
+	defer targetAdmin.Close()
+	unlock, err := lockOperator(log, targetAdmin, cfg.Leases)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tables, masks, err := snapshotTables(snapshot, sourceAdmin)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Copying tables", "numTables", len(tables), "sourceVersion", source.Status.CurrentVersion)
+	copied := make([]dba.SnapshotTableStatus, 0, len(tables))
+	var total int64
+	for _, table := range tables {
+		rows, err := copyTable(sourceAdmin, targetAdmin, table, masks[table])
+		if err != nil {
+			return err
+		}
+		log.Info("Copied table", "table", table, "rows", rows)
+		copied = append(copied, dba.SnapshotTableStatus{Table: table, Rows: rows})
+		total += rows
+		c.metrics.SnapshotRowsCopied.Add(float64(rows))
+	}
+
+	snapshot.Status.Tables = copied
+	snapshot.Status.SourceVersion = source.Status.CurrentVersion
+	snapshot.Status.Message = fmt.Sprintf("Copied %d rows from %d tables", total, len(copied))
+	if snapshot.Spec.Export == nil {
+		now := metav1.Now()
+		snapshot.Status.Phase = snapshotSucceeded
+		snapshot.Status.CompletedAt = &now
+		return nil
+	}
+
+	if err := c.createExportJob(ctx, log, snapshot, target); err != nil {
+		return err
+	}
+	snapshot.Status.Phase = snapshotExporting
+	return nil
+}
+
+func (c *AnonymizedSnapshotController) fetchDatabase(ctx context.Context, namespace, name string) (*dba.ManagedDatabase, error) {
+	dbName := types.NamespacedName{Namespace: namespace, Name: name}
+	var db dba.ManagedDatabase
+	if err := c.Get(ctx, dbName, &db); err != nil {
+		return nil, fmt.Errorf("Unable to fetch ManagedDatabase (%s): %w", dbName, err)
+	}
+	if err := applyManagedDatabaseClass(ctx, c.Client, &db); err != nil {
+		return nil, err
+	}
+	return &db, nil
+}
+
+// snapshotTables returns the tables to copy and the masks of their columns.
+// A mask for a table which isn't copied is an error, so that a misspelled
+// table isn't copied unmasked.
+func snapshotTables(snapshot *dba.AnonymizedSnapshot, source dbadmin.DbAdmin) ([]string, map[string]map[string]dbadmin.ColumnMask, error) {
+	tables := snapshot.Spec.Tables
+	if len(tables) == 0 {
+		listed, err := source.ListTables()
+		if err != nil {
+			return nil, nil, err
+		}
+		tables = listed
+	}
+	copied := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		copied[table] = true
+	}
+
+	masks := make(map[string]map[string]dbadmin.ColumnMask, len(snapshot.Spec.Masks))
+	for _, tableMask := range snapshot.Spec.Masks {
+		if !copied[tableMask.Table] {
+			return nil, nil, fmt.Errorf("Masks are specified for table %s, which isn't copied", tableMask.Table)
+		}
+		columns := make(map[string]dbadmin.ColumnMask, len(tableMask.Columns))
+		for _, column := range tableMask.Columns {
+			columns[column.Name] = dbadmin.ColumnMask(column.Mask)
+		}
+		masks[tableMask.Table] = columns
+	}
+	return tables, masks, nil
+}
+
+// copyTable creates the table in the target with the definition it has in
+// the source, and copies its rows over in batches, returning how many
+func copyTable(source, target dbadmin.DbAdmin, table string, masks map[string]dbadmin.ColumnMask) (int64, error) {
+	definition, err := source.GetTableDefinition(table)
+	if err != nil {
+		return 0, err
+	}
+	if err := target.RestoreTable(table, definition); err != nil {
+		return 0, err
+	}
+
+	var rowCount int64
+	err = source.ReadTable(table, masks, snapshotBatchSize, func(columns []string, rows [][]interface{}) error {
+		if err := target.InsertRows(table, columns, rows); err != nil {
+			return err
+		}
+		rowCount += int64(len(rows))
+		return nil
+	})
+	return rowCount, err
+}
+
+// createExportJob runs the export container against the target
+func (c *AnonymizedSnapshotController) createExportJob(ctx context.Context, log logr.Logger, snapshot *dba.AnonymizedSnapshot, target *dba.ManagedDatabase) error {
+	dsnSecretName, err := c.databases.migrationDSNSecret(migrationContext{ctx: ctx, log: log, db: target})
+	if err != nil {
+		return fmt.Errorf("Unable to provide connection DSN to the export: %w", err)
+	}
+
+	var containerSpec corev1.Container
+	snapshot.Spec.Export.DeepCopyInto(&containerSpec)
+
+	name := exportJobName(snapshot)
+	falseBool := false
+	csSource := &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: dsnSecretName},
+		Key:                  "dsn",
+		Optional:             &falseBool,
+	}}
+	containerSpec.Env = append(containerSpec.Env, corev1.EnvVar{Name: "DBA_OP_CONNECTION_STRING", ValueFrom: csSource})
+	containerSpec.Env = append(containerSpec.Env, corev1.EnvVar{Name: "DBA_OP_JOB_ID", Value: name})
+	containerSpec.Env = append(containerSpec.Env, corev1.EnvVar{Name: "DBA_OP_LABEL_DATABASE", Value: target.Name})
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"database": target.Name,
+				"snapshot": snapshot.Name,
+			},
+			Name:      name,
+			Namespace: snapshot.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers:    []corev1.Container{containerSpec},
+					RestartPolicy: corev1.RestartPolicyNever,
+				},
+			},
+		},
+	}
+	if err := ctrl.SetControllerReference(snapshot, job, c.Scheme); err != nil {
+		return fmt.Errorf("Unable to set owner for new job (%s): %w", job.Name, err)
+	}
+
+	log.Info("Running export Job", "job", name)
+	if err := c.Create(ctx, job); err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("Unable to create Job (%s) for the export: %w", name, err)
+	}
+	return nil
+}
+
+// reconcileExport finishes the snapshot once its export Job is done
+func (c *AnonymizedSnapshotController) reconcileExport(ctx context.Context, log logr.Logger, snapshot *dba.AnonymizedSnapshot) error {
+	name := exportJobName(snapshot)
+	var job batchv1.Job
+	if err := c.Get(ctx, types.NamespacedName{Namespace: snapshot.Namespace, Name: name}, &job); err != nil {
+		return fmt.Errorf("Unable to fetch export Job (%s): %w", name, err)
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return fmt.Errorf("Export Job (%s) failed: %s", name, condition.Message)
+		}
+	}
+	if job.Status.Succeeded == 0 {
+		log.Info("Waiting for export Job", "job", name)
+		return nil
+	}
+
+	now := metav1.Now()
+	if job.Status.CompletionTime != nil {
+		now = *job.Status.CompletionTime
+	}
+	snapshot.Status.Phase = snapshotSucceeded
+	snapshot.Status.CompletedAt = &now
+	snapshot.Status.Message += ", and exported the copy"
+	return nil
+}
+
+func exportJobName(snapshot *dba.AnonymizedSnapshot) string {
+	return snapshot.Name + "-export"
+}
+
+// SetupWithManager should be called to finish initialization of an
+// AnonymizedSnapshotController and bind it to the manager specified.
+func (c *AnonymizedSnapshotController) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dba.AnonymizedSnapshot{}).
+		Owns(&batchv1.Job{}).
+		Complete(drained("anonymizedsnapshot", traced(c.diagnostics, "anonymizedsnapshot", c.ReconcileAnonymizedSnapshot)))
+}
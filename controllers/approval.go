@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"fmt"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/approval"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// approveCredentials submits credentials to the approver before they are
+// provisioned. A policy which can't be reached is retried.
+func approveCredentials(approver approval.Approver, request approval.Request, grants []dbadmin.DatabaseGrant) (approval.Decision, error) {
+	request.Grants = approval.GrantsOf(grants)
+
+	decision, err := approver.Approve(request)
+	if err != nil {
+		return decision, xerrors.NewTempErrorf("Unable to approve credentials for user (%s): %s", request.Username, err.Error())
+	}
+	return decision, nil
+}
+
+// policyDenial is the error of credentials which the policy denied, which is
+// permanent until the object changes
+func policyDenial(username string, decision approval.Decision) error {
+	reason := decision.Reason
+	if reason == "" {
+		reason = "no reason given"
+	}
+	return fmt.Errorf("Credentials for user (%s) were denied by policy: %s", username, reason)
+}
+
+// approvedGrants applies the class the policy decided on to grants. Only
+// readwrite and readonly may be decided on for the credentials of a
+// ManagedDatabase itself.
+func approvedGrants(decision approval.Decision, grants []dbadmin.DatabaseGrant) ([]dbadmin.DatabaseGrant, error) {
+	switch class := dbadmin.GrantClass(decision.Class); class {
+	case "":
+		return grants, nil
+	case dbadmin.GrantClassReadWrite, dbadmin.GrantClassReadOnly:
+		return narrowGrants(grants, class), nil
+	default:
+		return nil, fmt.Errorf("Policy decided on unsupported grant class: %s", decision.Class)
+	}
+}
+
+// databaseApprovalRequest describes credentials which are provisioned for
+// the ManagedDatabase itself
+func databaseApprovalRequest(kind string, db *dba.ManagedDatabase, username string) approval.Request {
+	reference := approval.ObjectReference{Namespace: db.Namespace, Name: db.Name}
+	return approval.Request{
+		Kind:            kind,
+		Object:          reference,
+		ManagedDatabase: reference,
+		Username:        username,
+	}
+}
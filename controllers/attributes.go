@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// Kinds of object which database users are issued for
+const (
+	ownerKindDatabase          = "ManagedDatabase"
+	ownerKindCredentialRequest = "DatabaseCredentialRequest"
+)
+
+// credentialAttributes returns the attributes recorded on a new user in the
+// database, which was issued for the owner
+func credentialAttributes(db *dba.ManagedDatabase, ownerKind string, owner metav1.Object, class dbadmin.GrantClass, now time.Time) *dbadmin.UserAttributes {
+	created := now.UTC()
+	return &dbadmin.UserAttributes{
+		Database:    db.Namespace + "/" + db.Name,
+		DatabaseUID: string(db.UID),
+		Owner:       ownerKind + "/" + owner.GetNamespace() + "/" + owner.GetName(),
+		OwnerUID:    string(owner.GetUID()),
+		Class:       class,
+		CreatedAt:   &created,
+	}
+}
+
+// rotatedAttributes returns the attributes merged into those of a user whose
+// password is being rotated
+func rotatedAttributes(db *dba.ManagedDatabase, recorded map[string]dbadmin.UserAttributes, username string, now time.Time) *dbadmin.UserAttributes {
+	rotated := now.UTC()
+	return &dbadmin.UserAttributes{
+		Database:           db.Namespace + "/" + db.Name,
+		DatabaseUID:        string(db.UID),
+		RotationGeneration: recorded[username].RotationGeneration + 1,
+		RotatedAt:          &rotated,
+	}
+}
+
+// ownedByDatabase returns false if the attributes of the user show that it
+// was issued by another ManagedDatabase on the same server. Users without
+// attributes predate them, and are assumed to belong to the database.
+func ownedByDatabase(recorded map[string]dbadmin.UserAttributes, db *dba.ManagedDatabase, username string) bool {
+	attributes, ok := recorded[username]
+	return !ok || attributes.DatabaseUID == "" || attributes.DatabaseUID == string(db.UID)
+}
+
+// listIssuedUserAttributes returns the attributes of every user issued for
+// ManagedDatabases and DatabaseCredentialRequests on the server
+func listIssuedUserAttributes(admin dbadmin.DbAdmin) (map[string]dbadmin.UserAttributes, error) {
+	recorded := make(map[string]dbadmin.UserAttributes)
+	for _, prefix := range []string{DBUsernamePrefix, CredentialRequestUsernamePrefix} {
+		attributes, err := admin.ListUserAttributes(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read attributes of existing db users: %w", err)
+		}
+		for username, userAttributes := range attributes {
+			recorded[username] = userAttributes
+		}
+	}
+	return recorded, nil
+}
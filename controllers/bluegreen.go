@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// blueGreenRecheckInterval is how often the app pods are checked for the
+// schema versions that they still use
+const blueGreenRecheckInterval = time.Minute
+
+// blueGreenVersions returns the schema versions which need credentials: the
+// current one, and every earlier version which app pods are still labeled
+// with. Versions that the schema hasn't passed through never get credentials.
+func blueGreenVersions(ctx context.Context, apiClient client.Client, db *dba.ManagedDatabase, currentDbVersion string) (map[string]*dba.DatabaseMigration, error) {
+	versions := make(map[string]*dba.DatabaseMigration)
+	if currentDbVersion == "" {
+		return versions, nil
+	}
+
+	chain, err := appliedChain(ctx, apiClient, db.Namespace, currentDbVersion)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[string]*dba.DatabaseMigration, len(chain))
+	for i := range chain {
+		applied[chain[i].Name] = &chain[i]
+	}
+	versions[currentDbVersion] = applied[currentDbVersion]
+
+	var pods corev1.PodList
+	if err := apiClient.List(ctx, &pods, client.InNamespace(db.Namespace), client.MatchingLabels(db.Spec.BlueGreen.PodSelector)); err != nil {
+		return nil, fmt.Errorf("Unable to list app pods: %w", err)
+	}
+
+	label := db.Spec.BlueGreen.VersionLabel
+	if label == "" {
+		label = dba.DefaultSchemaVersionLabel
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if migration, ok := applied[pod.Labels[label]]; ok {
+			versions[migration.Name] = migration
+		}
+	}
+	return versions, nil
+}
+
+// fetchMigration returns the DatabaseMigration called name, or nil if it
+// was deleted
+func fetchMigration(ctx context.Context, apiClient client.Client, namespace, name string) (*dba.DatabaseMigration, error) {
+	var migration dba.DatabaseMigration
+	if err := apiClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &migration); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Unable to fetch DatabaseMigration (%s): %w", name, err)
+	}
+	return &migration, nil
+}
+
+// versionGrants returns the grants of the credentials for a schema version,
+// which are those of the migration when it lists any
+func versionGrants(db *dba.ManagedDatabase, migration *dba.DatabaseMigration, cfg config.Config) []dbadmin.DatabaseGrant {
+	if migration != nil && len(migration.Spec.Grants) > 0 {
+		return databaseGrants(&dba.ManagedDatabaseSpec{Grants: migration.Spec.Grants}, cfg)
+	}
+	return databaseGrants(&db.Spec, cfg)
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/dba-operator/pkg/bundle"
+	"github.com/app-sre/dba-operator/pkg/registry"
+)
+
+// registryTimeout bounds how long pulling a bundle, or verifying the
+// signature of an image, may take
+const registryTimeout = time.Minute
+
+// bundleMountPath is where the files of a bundle are mounted into the
+// migration Job
+const bundleMountPath = "/dba-op/bundle"
+
+var registryClient = &http.Client{Timeout: registryTimeout}
+
+// pullBundle pulls the bundle of the migration and verifies its signature
+// against the public keys of the operator config
+func (c *ManagedDatabaseController) pullBundle(oneMigration migrationContext) (*bundle.Bundle, error) {
+	spec := oneMigration.version.Spec.Bundle
+	ref, err := registry.ParseReference(spec.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := c.config.Current().Bundles
+	if !settings.RegistryAllowed(ref.Registry) {
+		return nil, fmt.Errorf("Bundles may not be pulled from registry %s", ref.Registry)
+	}
+	verifier, err := settings.Verifier()
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := c.registryCredentials(oneMigration, spec.PullSecret, ref.Registry)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(oneMigration.ctx, registryTimeout)
+	defer cancel()
+
+	puller := bundle.Puller{Client: registryClient, Verifier: verifier}
+	pulled, err := puller.Pull(ctx, ref, creds)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to pull bundle of migration (%s): %w", oneMigration.version.Name, err)
+	}
+	oneMigration.log.Info("Pulled migration bundle", "reference", ref.String(), "digest", pulled.Digest, "files", len(pulled.Files))
+	return pulled, nil
+}
+
+// registryCredentials reads the credentials of the registry from the pull
+// secret, there are none when secretName is empty
+func (c *ManagedDatabaseController) registryCredentials(oneMigration migrationContext, secretName, host string) (*registry.Credentials, error) {
+	if secretName == "" {
+		return nil, nil
+	}
+
+	var secret corev1.Secret
+	path := types.NamespacedName{Namespace: oneMigration.db.Namespace, Name: secretName}
+	if err := c.Get(oneMigration.ctx, path, &secret); err != nil {
+		return nil, fmt.Errorf("Unable to fetch pull secret (%s): %w", secretName, err)
+	}
+	creds, err := registry.CredentialsFromDockerConfig(secret.Data[corev1.DockerConfigJsonKey], host)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read pull secret (%s): %w", secretName, err)
+	}
+	return creds, nil
+}
+
+func bundleConfigMapName(jobName string) string {
+	return jobName + "-bundle"
+}
+
+// mountBundle pulls the bundle of the migration into a ConfigMap which is
+// mounted into the Job, with the digest of the bundle in its environment
+func (c *ManagedDatabaseController) mountBundle(oneMigration migrationContext, job *batchv1.Job) error {
+	pulled, err := c.pullBundle(oneMigration)
+	if err != nil {
+		return err
+	}
+
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bundleConfigMapName(job.Name),
+			Namespace: job.Namespace,
+			Labels:    getStandardLabels(oneMigration.db, oneMigration.version),
+		},
+		Data: pulled.Files,
+	}
+	if err := ctrl.SetControllerReference(oneMigration.db, &configMap, c.Scheme); err != nil {
+		return fmt.Errorf("Unable to set owner for bundle ConfigMap (%s): %w", configMap.Name, err)
+	}
+
+	// The ConfigMap of an earlier attempt whose Job wasn't created is
+	// replaced, the bundle may have been pulled by a tag which has moved
+	if err := c.Create(oneMigration.ctx, &configMap); apierrs.IsAlreadyExists(err) {
+		var existing corev1.ConfigMap
+		if err := c.Get(oneMigration.ctx, types.NamespacedName{Namespace: configMap.Namespace, Name: configMap.Name}, &existing); err != nil {
+			return fmt.Errorf("Unable to fetch bundle ConfigMap (%s): %w", configMap.Name, err)
+		}
+		existing.Data = pulled.Files
+		err = c.Update(oneMigration.ctx, &existing)
+		if err != nil {
+			return fmt.Errorf("Unable to update bundle ConfigMap (%s): %w", configMap.Name, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("Unable to create bundle ConfigMap (%s): %w", configMap.Name, err)
+	}
+
+	podSpec := &job.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "bundle",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name}},
+		},
+	})
+	container := &podSpec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: "bundle", MountPath: bundleMountPath, ReadOnly: true})
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "DBA_OP_BUNDLE_DIR", Value: bundleMountPath},
+		corev1.EnvVar{Name: "DBA_OP_BUNDLE_DIGEST", Value: pulled.Digest},
+	)
+	return nil
+}
+
+// deleteBundleConfigMap removes the bundle of a migration Job which is
+// being cleaned up, if it had one
+func deleteBundleConfigMap(ctx context.Context, apiClient client.Client, namespace, jobName string) error {
+	configMap := corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: bundleConfigMapName(jobName)}}
+	if err := client.IgnoreNotFound(apiClient.Delete(ctx, &configMap)); err != nil {
+		return fmt.Errorf("Unable to delete bundle ConfigMap (%s): %w", configMap.Name, err)
+	}
+	return nil
+}
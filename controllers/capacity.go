@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+// CapacityReport is the JSON document delivered to the capacity report
+// webhook after every pass
+type CapacityReport struct {
+	Time      time.Time          `json:"time"`
+	Databases []DatabaseCapacity `json:"databases"`
+}
+
+// DatabaseCapacity is the server capacity used by one ManagedDatabase
+type DatabaseCapacity struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Instance    string `json:"instance,omitempty"`
+	DataBytes   int64  `json:"dataBytes"`
+	IndexBytes  int64  `json:"indexBytes"`
+	Connections int64  `json:"connections"`
+
+	// QueriesPerSecond is averaged since the previous pass, and is left out
+	// of the first report after the operator or the server restarts
+	QueriesPerSecond *float64 `json:"queriesPerSecond,omitempty"`
+}
+
+type statementSample struct {
+	statements int64
+	at         time.Time
+}
+
+type capacityMeasurement struct {
+	capacity DatabaseCapacity
+	at       time.Time
+}
+
+// CapacityReportController periodically measures the storage, sessions and
+// query rate of every ManagedDatabase, so that the capacity of shared
+// servers can be attributed to the teams using it.
+type CapacityReportController struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	config      config.Provider
+	metrics     CapacityReportControllerMetrics
+	diagnostics *diagnostics.Recorder
+	http        *http.Client
+
+	// samples contains the statement counter of each database at the
+	// previous pass, keyed by namespace and name
+	samples map[types.NamespacedName]statementSample
+
+	// measured contains the latest capacity of each database which could be
+	// measured, for the inventory export
+	measuredLock sync.RWMutex
+	measured     map[types.NamespacedName]capacityMeasurement
+}
+
+// NewCapacityReportController will instantiate a CapacityReportController
+// with the supplied arguments and logical defaults. diag enables debug mode,
+// see diagnostics.Recorder.
+func NewCapacityReportController(
+	c client.Client,
+	scheme *runtime.Scheme,
+	l logr.Logger,
+	diag *diagnostics.Recorder,
+	cfg config.Provider,
+) (*CapacityReportController, []prometheus.Collector) {
+	metrics := generateCapacityReportControllerMetrics()
+
+	return &CapacityReportController{
+		Client:      c,
+		Scheme:      scheme,
+		Log:         l,
+		config:      cfg,
+		metrics:     metrics,
+		diagnostics: diag,
+		http:        &http.Client{Timeout: 10 * time.Second},
+		samples:     make(map[types.NamespacedName]statementSample),
+		measured:    make(map[types.NamespacedName]capacityMeasurement),
+	}, getAllMetrics(metrics)
+}
+
+// Start implements manager.Runnable. The interval is re-read from the config
+// before every pass.
+func (crc *CapacityReportController) Start(stop <-chan struct{}) error {
+	for {
+		wait := crc.config.Current().CapacityReport.Interval.Duration
+		enabled := wait > 0
+		if !enabled {
+			wait = rotationRecheckInterval
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(wait):
+			if !enabled {
+				continue
+			}
+			if err := crc.report(time.Now()); err != nil {
+				crc.Log.Error(err, "Capacity report pass did not complete")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (crc *CapacityReportController) NeedLeaderElection() bool {
+	return true
+}
+
+func (crc *CapacityReportController) report(now time.Time) error {
+	ctx := context.Background()
+
+	var allDatabases dba.ManagedDatabaseList
+	if err := crc.List(ctx, &allDatabases); err != nil {
+		return fmt.Errorf("Unable to list ManagedDatabases: %w", err)
+	}
+
+	// Start from scratch so that deleted databases stop being reported
+	crc.metrics.DataBytes.Reset()
+	crc.metrics.IndexBytes.Reset()
+	crc.metrics.Connections.Reset()
+	crc.metrics.QueriesPerSecond.Reset()
+
+	report := CapacityReport{Time: now.UTC(), Databases: make([]DatabaseCapacity, 0, len(allDatabases.Items))}
+	nextSamples := make(map[types.NamespacedName]statementSample, len(allDatabases.Items))
+	nextMeasured := make(map[types.NamespacedName]capacityMeasurement, len(allDatabases.Items))
+	for i := range allDatabases.Items {
+		db := &allDatabases.Items[i]
+		key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+		log := crc.Log.WithValues("manageddatabase", key)
+
+		capacity, sample, err := crc.measureDatabase(ctx, log, db, now)
+		if err != nil {
+			log.Error(err, "unable to measure capacity")
+			crc.metrics.SampleFailures.Inc()
+			if previous, ok := crc.samples[key]; ok {
+				nextSamples[key] = previous
+			}
+			if previous, ok := crc.latestCapacity(key); ok {
+				nextMeasured[key] = previous
+			}
+			continue
+		}
+		nextSamples[key] = sample
+		nextMeasured[key] = capacityMeasurement{capacity: capacity, at: now}
+
+		labels := prometheus.Labels{"namespace": db.Namespace, "database": db.Name, "instance": capacity.Instance}
+		crc.metrics.DataBytes.With(labels).Set(float64(capacity.DataBytes))
+		crc.metrics.IndexBytes.With(labels).Set(float64(capacity.IndexBytes))
+		crc.metrics.Connections.With(labels).Set(float64(capacity.Connections))
+		if capacity.QueriesPerSecond != nil {
+			crc.metrics.QueriesPerSecond.With(labels).Set(*capacity.QueriesPerSecond)
+		}
+		report.Databases = append(report.Databases, capacity)
+	}
+	crc.samples = nextSamples
+
+	crc.measuredLock.Lock()
+	crc.measured = nextMeasured
+	crc.measuredLock.Unlock()
+
+	return crc.deliver(report)
+}
+
+// latestCapacity returns the latest capacity which could be measured for
+// the database
+func (crc *CapacityReportController) latestCapacity(key types.NamespacedName) (capacityMeasurement, bool) {
+	crc.measuredLock.RLock()
+	defer crc.measuredLock.RUnlock()
+
+	measurement, ok := crc.measured[key]
+	return measurement, ok
+}
+
+func (crc *CapacityReportController) measureDatabase(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, now time.Time) (DatabaseCapacity, statementSample, error) {
+	if err := applyManagedDatabaseClass(ctx, crc.Client, db); err != nil {
+		return DatabaseCapacity{}, statementSample{}, err
+	}
+
+	admin, err := initializeAdminConnection(ctx, log, crc.diagnostics, crc.Client, db.Namespace, &db.Spec)
+	if err != nil {
+		return DatabaseCapacity{}, statementSample{}, fmt.Errorf("Unable to create database connection: %w", err)
+	}
+	defer admin.Close()
+
+	usage, err := admin.GetCapacityUsage()
+	if err != nil {
+		return DatabaseCapacity{}, statementSample{}, err
+	}
+
+	capacity := DatabaseCapacity{
+		Namespace:   db.Namespace,
+		Name:        db.Name,
+		Instance:    db.Status.Instance,
+		DataBytes:   usage.DataBytes,
+		IndexBytes:  usage.IndexBytes,
+		Connections: usage.Connections,
+	}
+	sample := statementSample{statements: usage.Statements, at: now}
+
+	// The counter starts from zero again when the server restarts
+	previous, ok := crc.samples[types.NamespacedName{Namespace: db.Namespace, Name: db.Name}]
+	if elapsed := now.Sub(previous.at).Seconds(); ok && elapsed > 0 && usage.Statements >= previous.statements {
+		qps := float64(usage.Statements-previous.statements) / elapsed
+		capacity.QueriesPerSecond = &qps
+	}
+
+	return capacity, sample, nil
+}
+
+// deliver posts the report to the configured webhook, if there is one
+func (crc *CapacityReportController) deliver(report CapacityReport) error {
+	url := crc.config.Current().CapacityReport.WebhookURL
+	if url == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("Unable to encode capacity report: %w", err)
+	}
+
+	resp, err := crc.http.Post(url, "application/json", bytes.NewReader(body))
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			err = fmt.Errorf("Webhook responded with status %d", resp.StatusCode)
+		}
+	}
+	if err != nil {
+		crc.metrics.DeliveryFailures.Inc()
+		return fmt.Errorf("Unable to deliver capacity report: %w", redact.Error(err))
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// grantClassCDC is a credential request class for change data capture
+// consumers, which read the tables once and then stream the binary log
+const grantClassCDC dbadmin.GrantClass = "cdc"
+
+// binlogConsumerRefreshInterval is how often the change data capture users
+// are checked for sessions streaming the binary log
+const binlogConsumerRefreshInterval = 5 * time.Minute
+
+// cdcGrants computes the grants of a change data capture user: read-only on
+// everything the ManagedDatabase grants, and replication on the server
+func cdcGrants(db *dba.ManagedDatabase, cfg config.Config) ([]dbadmin.DatabaseGrant, error) {
+	grants, err := credentialRequestGrants(db, cfg, dbadmin.GrantClassReadOnly)
+	if err != nil {
+		return nil, err
+	}
+	return append(grants, dbadmin.DatabaseGrant{Class: dbadmin.GrantClassReplication}), nil
+}
+
+// reconcileBinlogConsumers records which change data capture users of the
+// database are streaming the binary log, and marks those which have been
+// away for longer than the server retains it as abandoned. It returns
+// whether there are any such users to keep following.
+func (c *ManagedDatabaseController) reconcileBinlogConsumers(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, now time.Time) (bool, error) {
+	recorded, err := listIssuedUserAttributes(admin)
+	if err != nil {
+		return false, err
+	}
+
+	previous := make(map[string]dba.BinlogConsumerStatus, len(db.Status.BinlogConsumers))
+	for _, consumer := range db.Status.BinlogConsumers {
+		previous[consumer.Username] = consumer
+	}
+
+	var users []string
+	for username, attributes := range recorded {
+		if attributes.DatabaseUID == string(db.UID) && attributes.Class == grantClassCDC {
+			users = append(users, username)
+		}
+	}
+	for username := range previous {
+		if _, ok := recorded[username]; !ok || recorded[username].Class != grantClassCDC {
+			// The user was revoked
+			c.metrics.BinlogConsumerIdle.Delete(binlogConsumerLabels(db, username))
+			c.metrics.BinlogConsumerAbandoned.Delete(binlogConsumerLabels(db, username))
+		}
+	}
+	if len(users) == 0 {
+		db.Status.BinlogConsumers = nil
+		return false, nil
+	}
+	sort.Strings(users)
+
+	consumers, err := admin.ListBinlogConsumers(CredentialRequestUsernamePrefix)
+	if err != nil {
+		return false, err
+	}
+	streaming := make(map[string]bool, len(consumers))
+	for _, consumer := range consumers {
+		streaming[consumer.Username] = true
+	}
+
+	retention, err := binlogRetention(admin)
+	if err != nil {
+		return false, err
+	}
+
+	statuses := make([]dba.BinlogConsumerStatus, 0, len(users))
+	for _, username := range users {
+		status := previous[username]
+		status.Username = username
+		status.Owner = recorded[username].Owner
+		status.Streaming = streaming[username]
+
+		since := now
+		if status.Streaming {
+			streamedAt := metav1.NewTime(now)
+			status.LastStreamedAt = &streamedAt
+		} else if status.LastStreamedAt != nil {
+			since = status.LastStreamedAt.Time
+		} else if created := recorded[username].CreatedAt; created != nil {
+			since = *created
+		}
+		idle := now.Sub(since)
+
+		wasAbandoned := status.Abandoned
+		status.Abandoned = !status.Streaming && retention > 0 && idle > retention
+		if status.Abandoned && !wasAbandoned {
+			log.Info("Change data capture consumer has been away for longer than binary logs are retained, it must take a new snapshot",
+				"username", username, "owner", status.Owner, "idle", idle, "retention", retention)
+		}
+
+		abandoned := 0.0
+		if status.Abandoned {
+			abandoned = 1
+		}
+		labels := binlogConsumerLabels(db, username)
+		c.metrics.BinlogConsumerIdle.With(labels).Set(idle.Seconds())
+		c.metrics.BinlogConsumerAbandoned.With(labels).Set(abandoned)
+		statuses = append(statuses, status)
+	}
+
+	db.Status.BinlogConsumers = statuses
+	return true, nil
+}
+
+// binlogRetention returns how long the server keeps binary logs, or zero
+// when they are never purged automatically
+func binlogRetention(admin dbadmin.DbAdmin) (time.Duration, error) {
+	values, err := admin.GetServerVariables([]string{"binlog_expire_logs_seconds"})
+	if err == nil {
+		seconds, err := strconv.ParseInt(values["binlog_expire_logs_seconds"], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Unable to parse binlog_expire_logs_seconds: %w", err)
+		}
+		if seconds > 0 {
+			return time.Duration(seconds) * time.Second, nil
+		}
+	}
+
+	// Older servers only have the retention in days
+	values, err = admin.GetServerVariables([]string{"expire_logs_days"})
+	if err != nil {
+		return 0, err
+	}
+	days, err := strconv.ParseFloat(values["expire_logs_days"], 64)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to parse expire_logs_days: %w", err)
+	}
+	return time.Duration(days * float64(24*time.Hour)), nil
+}
+
+func binlogConsumerLabels(db *dba.ManagedDatabase, username string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace": db.Namespace,
+		"database":  db.Name,
+		"username":  username,
+	}
+}
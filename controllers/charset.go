@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+)
+
+// Policies for a database whose character set doesn't match the spec
+const (
+	charsetPolicyFail    = "fail"
+	charsetPolicyWarn    = "warn"
+	charsetPolicyConvert = "convert"
+)
+
+// collationMatches returns true if the collation belongs to the specified
+// character set, and is the specified collation if there is one
+func collationMatches(spec *dba.CharsetSpec, collation string) bool {
+	if spec.Collation != "" {
+		return collation == spec.Collation
+	}
+	return strings.HasPrefix(collation, spec.CharacterSet+"_")
+}
+
+// reconcileCharset checks the database's default character set against the
+// spec and applies the policy when it differs. It returns how long to wait
+// for the maintenance window in which the database will be converted.
+func (c *ManagedDatabaseController) reconcileCharset(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, now time.Time) (time.Duration, error) {
+	spec := db.Spec.Charset
+	if spec == nil {
+		db.Status.Charset = nil
+		return 0, nil
+	}
+
+	current, err := admin.GetCharset()
+	if err != nil {
+		return 0, err
+	}
+
+	status := &dba.CharsetStatus{
+		CharacterSet: current.CharacterSet,
+		Collation:    current.Collation,
+		Matches:      current.CharacterSet == spec.CharacterSet && collationMatches(spec, current.Collation),
+	}
+	for collation, count := range current.TableCollations {
+		if !collationMatches(spec, collation) {
+			status.MismatchedTables += count
+		}
+	}
+	previous := db.Status.Charset
+	db.Status.Charset = status
+
+	if status.Matches {
+		return 0, nil
+	}
+	message := fmt.Sprintf("Database defaults to character set %s with collation %s, instead of %s", current.CharacterSet, current.Collation, spec.CharacterSet)
+
+	switch spec.Policy {
+	case charsetPolicyWarn:
+		if previous == nil || previous.Matches {
+			log.Info("Database character set doesn't match the spec", "characterSet", current.CharacterSet, "collation", current.Collation)
+			c.notifier.Notify(notify.Event{
+				Reason:    "CharsetMismatch",
+				Namespace: db.Namespace,
+				Name:      db.Name,
+				Message:   message,
+			})
+		}
+		return 0, nil
+
+	case charsetPolicyConvert:
+		open, next, err := maintenanceWindowOpen(db, now)
+		if err != nil {
+			return 0, err
+		}
+		if !open {
+			log.Info("Waiting for a maintenance window to convert the character set", "opens", next)
+			return next.Sub(now), nil
+		}
+
+		log.Info("Converting database character set", "from", current.CharacterSet, "to", spec.CharacterSet)
+		if err := admin.SetCharset(spec.CharacterSet, spec.Collation); err != nil {
+			return 0, err
+		}
+
+		converted, err := admin.GetCharset()
+		if err != nil {
+			return 0, err
+		}
+		status.CharacterSet = converted.CharacterSet
+		status.Collation = converted.Collation
+		status.Matches = true
+		return 0, nil
+	}
+
+	return 0, fmt.Errorf("%s, which the charset policy refuses", message)
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/adminapi"
+	"github.com/app-sre/dba-operator/pkg/chatops"
+)
+
+// chatOpsPermissions are the admin API actions which each command requires
+var chatOpsPermissions = map[string]string{
+	chatops.VerbRotate:  adminapi.ActionRotate,
+	chatops.VerbStatus:  adminapi.ActionRead,
+	chatops.VerbApprove: adminapi.ActionApprove,
+}
+
+// ChatOpsHandler runs the slash commands which Slack delivers from the
+// workspace TeamID, as the admin API caller which the sending user is mapped to
+type ChatOpsHandler struct {
+	Client        client.Client
+	Callers       []adminapi.Caller
+	SigningSecret []byte
+	TeamID        string
+	Log           logr.Logger
+}
+
+func (h ChatOpsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	slack, err := chatops.VerifySlack(h.SigningSecret, r, time.Now())
+	if err != nil {
+		h.Log.Info("Refused unverified chat command", "reason", err.Error())
+		http.Error(w, "Unable to verify the command", http.StatusUnauthorized)
+		return
+	}
+	log := h.Log.WithValues("chatTeam", slack.TeamID, "chatUser", slack.UserID, "chatUserName", slack.UserName, "text", slack.Text)
+
+	if slack.TeamID != h.TeamID {
+		log.Info("Refused chat command from another workspace")
+		http.Error(w, "Commands are only accepted from the configured workspace", http.StatusForbidden)
+		return
+	}
+
+	caller := adminapi.ChatCaller(h.Callers, slack.UserID)
+	if caller == nil {
+		log.Info("Refused chat command from unmapped user")
+		chatops.RespondSlack(w, true, fmt.Sprintf("You (%s) are not allowed to run dba-operator commands", slack.UserID))
+		return
+	}
+	log = log.WithValues("caller", caller.Name)
+
+	command, err := chatops.Parse(slack.Text)
+	if err != nil {
+		chatops.RespondSlack(w, true, err.Error()+"\n"+chatops.Usage)
+		return
+	}
+
+	permission := chatOpsPermissions[command.Verb]
+	if !caller.Allows(command.Namespace, permission) {
+		log.Info("Refused chat command", "action", permission)
+		chatops.RespondSlack(w, true, fmt.Sprintf("%s may not %s in namespace %s", caller.Name, permission, command.Namespace))
+		return
+	}
+
+	var db dba.ManagedDatabase
+	if err := h.Client.Get(r.Context(), types.NamespacedName{Namespace: command.Namespace, Name: command.Name}, &db); err != nil {
+		if apierrs.IsNotFound(err) {
+			chatops.RespondSlack(w, true, fmt.Sprintf("ManagedDatabase %s/%s does not exist", command.Namespace, command.Name))
+			return
+		}
+		log.Error(err, "Unable to fetch ManagedDatabase")
+		chatops.RespondSlack(w, true, "Unable to fetch the ManagedDatabase, try again")
+		return
+	}
+
+	log.Info("Running chat command", "action", permission)
+	requestedBy := fmt.Sprintf("chatops/%s/%s", caller.Name, slack.UserID)
+	switch command.Verb {
+	case chatops.VerbRotate:
+		operation, err := createOperation(r.Context(), h.Client, &db, operationRotateCredentials, requestedBy)
+		if err != nil {
+			log.Error(err, "Unable to start rotation")
+			chatops.RespondSlack(w, true, "Unable to start the rotation, try again")
+			return
+		}
+		chatops.RespondSlack(w, false, fmt.Sprintf("<@%s> started rotating the credentials of %s/%s as DatabaseOperation %s", slack.UserID, db.Namespace, db.Name, operation.Name))
+	case chatops.VerbStatus:
+		chatops.RespondSlack(w, true, migrationStatusText(&db, time.Now()))
+	case chatops.VerbApprove:
+		text, err := approvePendingPlan(r.Context(), h.Client, &db, command.Migration, command.PlanHash)
+		if err != nil {
+			log.Info("Unable to approve plan", "reason", err.Error())
+			chatops.RespondSlack(w, true, err.Error())
+			return
+		}
+		log.Info("Approved plan", "migration", command.Migration, "hash", db.Spec.PlanApproval.ApprovedHash)
+		chatops.RespondSlack(w, false, fmt.Sprintf("<@%s> %s", slack.UserID, text))
+	}
+}
+
+// migrationStatusText summarizes where the database's migrations stand
+func migrationStatusText(db *dba.ManagedDatabase, now time.Time) string {
+	lines := []string{fmt.Sprintf("%s/%s is at schema version %s, the desired version is %s",
+		db.Namespace, db.Name, orNone(db.Status.CurrentVersion), orNone(db.Spec.DesiredSchemaVersion))}
+
+	if progress := db.Status.MigrationProgress; progress != nil {
+		lines = append(lines, fmt.Sprintf("Migration %s is at step %d of %d, %d rows processed, reported %s ago",
+			progress.Migration, progress.Step, progress.Total, progress.RowsProcessed, now.Sub(progress.UpdatedAt.Time).Round(time.Second)))
+	}
+	if failure := db.Status.MigrationFailure; failure != nil {
+		lines = append(lines, fmt.Sprintf("Migration %s was aborted (%s): %s", failure.Migration, failure.Reason, failure.Message))
+	}
+	if plan := db.Status.PendingPlan; plan != nil {
+		lines = append(lines, fmt.Sprintf("Plan %s with hash %s is waiting for approval:\n```\n%s\n```", plan.ID, plan.Hash, strings.Join(plan.Statements, "\n")))
+	}
+	for _, condition := range db.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue && condition.Message != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", condition.Type, condition.Message))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// approvePendingPlan approves the plan which is waiting to provision the
+// users of the migration, and describes what was approved. The plan's hash
+// must start with planHash, so that a plan which changed after the sender
+// reviewed it is never approved in its place.
+func approvePendingPlan(ctx context.Context, apiClient client.Client, db *dba.ManagedDatabase, migration, planHash string) (string, error) {
+	plan := db.Status.PendingPlan
+	switch {
+	case db.Spec.PlanApproval == nil:
+		return "", fmt.Errorf("%s/%s doesn't require plans to be approved", db.Namespace, db.Name)
+	case db.Spec.DesiredSchemaVersion != migration:
+		return "", fmt.Errorf("%s/%s is migrating to %s, not %s", db.Namespace, db.Name, orNone(db.Spec.DesiredSchemaVersion), migration)
+	case plan == nil:
+		return "", fmt.Errorf("%s/%s has no plan waiting for approval", db.Namespace, db.Name)
+	case planHash == "" || !strings.HasPrefix(plan.Hash, planHash):
+		return "", fmt.Errorf("The plan waiting for approval on %s/%s has hash %s, not %s, review it again", db.Namespace, db.Name, plan.Hash, planHash)
+	case db.Spec.PlanApproval.ApprovedHash == plan.Hash:
+		return "", fmt.Errorf("Plan %s of %s/%s is already approved", plan.ID, db.Namespace, db.Name)
+	}
+
+	db.Spec.PlanApproval.ApprovedHash = plan.Hash
+	if err := apiClient.Update(ctx, db); err != nil {
+		if apierrs.IsConflict(err) {
+			return "", fmt.Errorf("%s/%s changed while approving, check its status and try again", db.Namespace, db.Name)
+		}
+		return "", fmt.Errorf("Unable to approve plan: %w", err)
+	}
+	return fmt.Sprintf("approved plan %s with %d statements for migration %s of %s/%s", plan.ID, len(plan.Statements), migration, db.Namespace, db.Name), nil
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
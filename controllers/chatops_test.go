@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+const pendingPlanHash = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestApprovePendingPlan(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		migration string
+		planHash  string
+		approved  bool
+	}{
+		{"full hash", "v3", pendingPlanHash, true},
+		{"hash prefix", "v3", pendingPlanHash[:12], true},
+		{"other hash", "v3", "fedcba987654", false},
+		{"no hash", "v3", "", false},
+		{"other migration", "v2", pendingPlanHash, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := &dba.ManagedDatabase{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "quay", Name: "quayio"},
+				Spec: dba.ManagedDatabaseSpec{
+					DesiredSchemaVersion: "v3",
+					PlanApproval:         &dba.PlanApprovalSpec{},
+				},
+				Status: dba.ManagedDatabaseStatus{
+					PendingPlan: &dba.PendingPlan{ID: "migrate-v3", Hash: pendingPlanHash, Statements: []string{"CREATE USER"}},
+				},
+			}
+			apiClient := fake.NewFakeClientWithScheme(testScheme(t), db.DeepCopy())
+
+			_, err := approvePendingPlan(context.Background(), apiClient, db, tc.migration, tc.planHash)
+			if tc.approved && err != nil {
+				t.Fatal(err)
+			}
+			if !tc.approved && err == nil {
+				t.Fatal("Expected the approval to be refused")
+			}
+
+			var stored dba.ManagedDatabase
+			if err := apiClient.Get(context.Background(), types.NamespacedName{Namespace: "quay", Name: "quayio"}, &stored); err != nil {
+				t.Fatal(err)
+			}
+			if approved := stored.Spec.PlanApproval.ApprovedHash == pendingPlanHash; approved != tc.approved {
+				t.Errorf("Expected the stored approval to be %t, got %q", tc.approved, stored.Spec.PlanApproval.ApprovedHash)
+			}
+		})
+	}
+}
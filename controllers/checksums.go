@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/migrationset"
+)
+
+// migrationContent extracts the parts of a DatabaseMigration which determine
+// what it applies, settings such as its guard don't change its checksum
+func migrationContent(migration *dba.DatabaseMigration) (migrationset.Migration, error) {
+	container := migration.Spec.MigrationContainerSpec
+	env := make([]string, 0, len(container.Env))
+	for _, envVar := range container.Env {
+		if envVar.ValueFrom == nil {
+			env = append(env, envVar.Name+"="+envVar.Value)
+			continue
+		}
+		source, err := json.Marshal(envVar.ValueFrom)
+		if err != nil {
+			return migrationset.Migration{}, fmt.Errorf("Unable to encode environment of migration (%s): %w", migration.Name, err)
+		}
+		env = append(env, envVar.Name+" from "+string(source))
+	}
+
+	content := migrationset.Migration{
+		Version:  migration.Name,
+		Previous: migration.Spec.Previous,
+		Image:    container.Image,
+		Command:  container.Command,
+		Args:     container.Args,
+		Env:      env,
+	}
+	if migration.Spec.Embedded != nil {
+		content.ConfigMap = migration.Spec.Embedded.ConfigMap
+	}
+	if migration.Spec.Bundle != nil {
+		content.Bundle = migration.Spec.Bundle.Reference
+	}
+	return content, nil
+}
+
+func migrationChecksum(migration *dba.DatabaseMigration) (string, error) {
+	content, err := migrationContent(migration)
+	if err != nil {
+		return "", err
+	}
+	return migrationset.Checksum(content)
+}
+
+// resolveDesiredVersion returns the name of the migration the database should
+// be migrated to. A digest is resolved against every DatabaseMigration in the
+// namespace, so a migration which was changed under a reused name no longer
+// matches it.
+func resolveDesiredVersion(ctx context.Context, apiClient client.Client, db *dba.ManagedDatabase) (string, error) {
+	desired := db.Spec.DesiredSchemaVersion
+	if !migrationset.IsDigest(desired) {
+		db.Status.ResolvedVersion = ""
+		return desired, nil
+	}
+
+	var allMigrations dba.DatabaseMigrationList
+	if err := apiClient.List(ctx, &allMigrations, client.InNamespace(db.Namespace)); err != nil {
+		return "", fmt.Errorf("Unable to list DatabaseMigrations: %w", err)
+	}
+
+	migrations := make(map[string]migrationset.Migration, len(allMigrations.Items))
+	for i := range allMigrations.Items {
+		content, err := migrationContent(&allMigrations.Items[i])
+		if err != nil {
+			return "", err
+		}
+		migrations[content.Version] = content
+	}
+
+	version, err := migrationset.Resolve(migrations, desired)
+	if err != nil {
+		return "", fmt.Errorf("Unable to resolve desired schema version: %w", err)
+	}
+	db.Status.ResolvedVersion = version
+	return version, nil
+}
+
+// verifyAppliedMigrations refuses to continue when a migration which the
+// database has already reached was changed since, and records the checksum
+// of the current version the first time it is reached. Migrations which were
+// deleted can't be run again and are skipped.
+func verifyAppliedMigrations(ctx context.Context, apiClient client.Client, db *dba.ManagedDatabase, currentDbVersion string) error {
+	recorded := false
+	for _, applied := range db.Status.AppliedMigrations {
+		recorded = recorded || applied.Version == currentDbVersion
+
+		var migration dba.DatabaseMigration
+		if err := apiClient.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: applied.Version}, &migration); err != nil {
+			if apierrs.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("Unable to fetch DatabaseMigration (%s): %w", applied.Version, err)
+		}
+
+		checksum, err := migrationChecksum(&migration)
+		if err != nil {
+			return err
+		}
+		if checksum != applied.Checksum {
+			return fmt.Errorf("DatabaseMigration %s was changed after it was applied, restore it or publish the change under a new version", applied.Version)
+		}
+	}
+
+	if recorded || currentDbVersion == "" {
+		return nil
+	}
+
+	var migration dba.DatabaseMigration
+	if err := apiClient.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: currentDbVersion}, &migration); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("Unable to fetch DatabaseMigration (%s): %w", currentDbVersion, err)
+	}
+	checksum, err := migrationChecksum(&migration)
+	if err != nil {
+		return err
+	}
+	db.Status.AppliedMigrations = append(db.Status.AppliedMigrations, dba.AppliedMigration{Version: currentDbVersion, Checksum: checksum})
+	return nil
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/schedule"
+)
+
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabaseclasses,verbs=get;list;watch
+
+// applyManagedDatabaseClass fills in every field of the spec which is left
+// empty from the ManagedDatabaseClass it references. The result is only used
+// in memory, the ManagedDatabase itself is never updated with the defaults.
+func applyManagedDatabaseClass(ctx context.Context, apiClient client.Client, db *dba.ManagedDatabase) error {
+	if db.Spec.ClassName == "" {
+		return nil
+	}
+
+	var class dba.ManagedDatabaseClass
+	if err := apiClient.Get(ctx, types.NamespacedName{Name: db.Spec.ClassName}, &class); err != nil {
+		return fmt.Errorf("Unable to fetch ManagedDatabaseClass (%s): %w", db.Spec.ClassName, err)
+	}
+
+	mergeClassDefaults(&db.Spec, &class.Spec)
+	return nil
+}
+
+func mergeClassDefaults(spec *dba.ManagedDatabaseSpec, class *dba.ManagedDatabaseClassSpec) {
+	if spec.Connection.Engine == "" {
+		spec.Connection.Engine = class.Engine
+	}
+	if spec.MigrationEngine == "" {
+		spec.MigrationEngine = class.MigrationEngine
+	}
+	if len(spec.Grants) == 0 && len(class.Grants) > 0 {
+		spec.Grants = append([]dba.DatabaseGrant(nil), class.Grants...)
+	}
+	if spec.AuthPlugin == "" {
+		spec.AuthPlugin = class.AuthPlugin
+	}
+	if spec.Rotation == nil && class.Rotation != nil {
+		spec.Rotation = class.Rotation.DeepCopy()
+	}
+	if len(spec.MaintenanceWindows) == 0 && len(class.MaintenanceWindows) > 0 {
+		spec.MaintenanceWindows = make([]dba.MaintenanceWindow, len(class.MaintenanceWindows))
+		for i := range class.MaintenanceWindows {
+			class.MaintenanceWindows[i].DeepCopyInto(&spec.MaintenanceWindows[i])
+		}
+	}
+	if spec.Compatibility == nil && class.Compatibility != nil {
+		spec.Compatibility = class.Compatibility.DeepCopy()
+	}
+	if spec.Charset == nil && class.Charset != nil {
+		spec.Charset = class.Charset.DeepCopy()
+	}
+	if spec.OperatorSession == nil && class.OperatorSession != nil {
+		spec.OperatorSession = class.OperatorSession.DeepCopy()
+	}
+	if spec.SecretConvention == nil && class.SecretConvention != nil {
+		spec.SecretConvention = class.SecretConvention.DeepCopy()
+	}
+
+	// A raw DSN can't be merged with, so it replaces the class connection
+	if class.Connection != nil && spec.Connection.DSNSecret == "" {
+		spec.Connection.Spec = mergeConnectionSpec(class.Connection, spec.Connection.Spec)
+	}
+}
+
+// mergeConnectionSpec overlays the fields of own which are set on the class
+// defaults
+func mergeConnectionSpec(defaults, own *dba.ConnectionSpec) *dba.ConnectionSpec {
+	merged := defaults.DeepCopy()
+	if own == nil {
+		return merged
+	}
+
+	// The address is replaced as a whole, so that a socket can't be combined
+	// with a host from the class
+	if own.Host != "" || own.Socket != "" || len(own.Hosts) > 0 {
+		merged.Host = own.Host
+		merged.Port = own.Port
+		merged.Socket = own.Socket
+		merged.Hosts = own.Hosts
+		merged.HostSelection = own.HostSelection
+	} else if own.Port != 0 {
+		merged.Port = own.Port
+	}
+	if own.Dialer != "" {
+		merged.Dialer = own.Dialer
+	}
+	if own.Database != "" {
+		merged.Database = own.Database
+	}
+	if own.CredentialsSecret != "" {
+		merged.CredentialsSecret = own.CredentialsSecret
+	}
+	if own.TLS != "" {
+		merged.TLS = own.TLS
+	}
+	if own.ServerPublicKey != "" {
+		merged.ServerPublicKey = own.ServerPublicKey
+	}
+	if own.AllowPublicKeyRetrieval {
+		merged.AllowPublicKeyRetrieval = true
+	}
+	if len(own.Params) > 0 {
+		if merged.Params == nil {
+			merged.Params = make(map[string]string, len(own.Params))
+		}
+		for name, value := range own.Params {
+			merged.Params[name] = value
+		}
+	}
+	return merged
+}
+
+// maintenanceWindowOpen returns true if migrations may start now, otherwise
+// it returns the time that the next maintenance window opens.
+func maintenanceWindowOpen(db *dba.ManagedDatabase, now time.Time) (bool, time.Time, error) {
+	windows := make([]schedule.Window, 0, len(db.Spec.MaintenanceWindows))
+	for _, window := range db.Spec.MaintenanceWindows {
+		parsed, err := schedule.ParseWindow(window.Days, window.Start, window.Duration.Duration)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("Invalid maintenance window: %w", err)
+		}
+		windows = append(windows, parsed)
+	}
+
+	open, next := schedule.Open(windows, now)
+	return open, next, nil
+}
+
+// managedDatabasesForClass maps a ManagedDatabaseClass to all of the
+// ManagedDatabases which reference it, so that changes to the defaults are
+// applied to existing databases.
+func (c *ManagedDatabaseController) managedDatabasesForClass(obj handler.MapObject) []reconcile.Request {
+	var allDatabases dba.ManagedDatabaseList
+	if err := c.List(context.Background(), &allDatabases); err != nil {
+		c.Log.Error(err, "unable to list ManagedDatabases")
+		return nil
+	}
+
+	var toReconcile []reconcile.Request
+	for _, db := range allDatabases.Items {
+		if db.Spec.ClassName == obj.Meta.GetName() {
+			toReconcile = append(toReconcile, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: db.Namespace, Name: db.Name},
+			})
+		}
+	}
+	return toReconcile
+}
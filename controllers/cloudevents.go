@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/cloudevents"
+)
+
+// cloudEventBuffer is how many requeues may wait for the controller before
+// more are dropped, which the periodic resync makes up for
+const cloudEventBuffer = 100
+
+// rdsHost matches the endpoints of RDS instances and of Aurora clusters and
+// their readers, whose first label is the identifier of the instance or
+// cluster
+var rdsHost = regexp.MustCompile(`^([a-z][a-z0-9-]*)\.(cluster-(ro-)?)?[a-z0-9]+\.[a-z0-9-]+\.rds\.amazonaws\.com(\.cn)?$`)
+
+// cloudIdentifiers returns the identifiers of the cloud instances and
+// clusters which serve the database
+func cloudIdentifiers(db *dba.ManagedDatabase) []string {
+	identifiers := append([]string(nil), db.Spec.Connection.CloudIdentifiers...)
+
+	spec := db.Spec.Connection.Spec
+	if spec == nil {
+		return identifiers
+	}
+	hosts := []string{spec.Host}
+	for _, host := range spec.Hosts {
+		hosts = append(hosts, host.Host)
+	}
+	for _, host := range hosts {
+		if match := rdsHost.FindStringSubmatch(strings.ToLower(host)); match != nil {
+			identifiers = append(identifiers, match[1])
+		}
+	}
+	return identifiers
+}
+
+// RequeueForCloudEvent reconciles the ManagedDatabases served by the instance
+// or cluster which a topology event is about, instead of waiting for their
+// next resync to notice the failover
+func (c *ManagedDatabaseController) RequeueForCloudEvent(cloudEvent cloudevents.Event) {
+	log := c.Log.WithValues("source", cloudEvent.Identifier, "event", cloudEvent.ID)
+	if !cloudEvent.Topology() {
+		c.metrics.CloudEvents.WithLabelValues("ignored").Inc()
+		return
+	}
+
+	ctx := context.Background()
+	var allDatabases dba.ManagedDatabaseList
+	if err := c.List(ctx, &allDatabases); err != nil {
+		log.Error(err, "unable to list ManagedDatabases")
+		return
+	}
+
+	var requeued []string
+	for i := range allDatabases.Items {
+		db := &allDatabases.Items[i]
+
+		// The host may come from the class
+		if err := applyManagedDatabaseClass(ctx, c.Client, db); err != nil {
+			log.Error(err, "unable to apply class, matching the database's own connection", "manageddatabase", db.Name)
+		}
+
+		for _, identifier := range cloudIdentifiers(db) {
+			if !strings.EqualFold(identifier, cloudEvent.Identifier) {
+				continue
+			}
+
+			select {
+			case c.cloudEvents <- event.GenericEvent{Meta: db, Object: db}:
+				requeued = append(requeued, db.Namespace+"/"+db.Name)
+			default:
+				log.Info("Too many requeues pending, leaving database to its resync", "manageddatabase", db.Name)
+			}
+			break
+		}
+	}
+
+	if len(requeued) == 0 {
+		c.metrics.CloudEvents.WithLabelValues("unmatched").Inc()
+		log.Info("No database is served by the source of the topology event")
+		return
+	}
+	c.metrics.CloudEvents.WithLabelValues("requeued").Inc()
+	log.Info("Requeued databases after topology event", "databases", requeued, "message", cloudEvent.Message)
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"fmt"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// incompatibleServerError is returned when the detected server doesn't meet
+// the compatibility requirements of the ManagedDatabase
+type incompatibleServerError struct {
+	server dbadmin.ServerInfo
+	reason string
+}
+
+func (ise *incompatibleServerError) Error() string {
+	return fmt.Sprintf("Database server %s %s is not compatible: %s", ise.server.Flavor, ise.server.Version, ise.reason)
+}
+
+// checkCompatibility verifies the detected server against the compatibility
+// requirements in the spec
+func checkCompatibility(dbSpec *dba.ManagedDatabaseSpec, server dbadmin.ServerInfo) error {
+	compat := dbSpec.Compatibility
+	if compat == nil {
+		return nil
+	}
+
+	if compat.MinServerVersion != "" && dbadmin.CompareVersions(server.Version, compat.MinServerVersion) < 0 {
+		return &incompatibleServerError{server, fmt.Sprintf("older than the minimum version %s", compat.MinServerVersion)}
+	}
+	if compat.MaxServerVersion != "" && dbadmin.CompareVersions(server.Version, compat.MaxServerVersion) > 0 {
+		return &incompatibleServerError{server, fmt.Sprintf("newer than the maximum version %s", compat.MaxServerVersion)}
+	}
+	for _, feature := range compat.RequiredFeatures {
+		if !server.HasFeature(dbadmin.ServerFeature(feature)) {
+			return &incompatibleServerError{server, fmt.Sprintf("missing required feature %s", feature)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/consistency"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/mysqladmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+	"github.com/app-sre/dba-operator/pkg/schedule"
+)
+
+// consistencyQueryTimeout bounds how long each side of a check may run
+const consistencyQueryTimeout = 5 * time.Minute
+
+// valueQueriers contains the consistency check runner for each engine which
+// supports it
+var valueQueriers = map[string]dbadmin.ValueQuerier{
+	"mysql": mysqladmin.ValueQuerier{},
+}
+
+// reconcileConsistencyChecks runs the consistency checks which are due, and
+// sets the Consistent condition from the outcome of the last run of every
+// check. It returns how long until the next check is due.
+func (c *ManagedDatabaseController) reconcileConsistencyChecks(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, now time.Time) (time.Duration, error) {
+	if len(db.Spec.ConsistencyChecks) == 0 {
+		db.Status.ConsistencyChecks = nil
+		removeCondition(db, dba.ConditionConsistent)
+		return 0, nil
+	}
+
+	recorded := make(map[string]dba.ConsistencyCheckStatus, len(db.Status.ConsistencyChecks))
+	for _, status := range db.Status.ConsistencyChecks {
+		recorded[status.Name] = status
+	}
+
+	var requeue time.Duration
+	var errs []string
+	var failed []string
+	statuses := make([]dba.ConsistencyCheckStatus, 0, len(db.Spec.ConsistencyChecks))
+	for _, check := range db.Spec.ConsistencyChecks {
+		status, ok := recorded[check.Name]
+		if !ok {
+			status = dba.ConsistencyCheckStatus{Name: check.Name}
+		}
+
+		cron, err := schedule.ParseCron(check.Schedule)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid schedule for consistency check (%s): %w", check.Name, err)
+		}
+		if status.LastRun == nil || !cron.Next(status.LastRun.Time).After(now) {
+			if err := c.runConsistencyCheck(ctx, log.WithValues("check", check.Name), db, check, &status, now); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", check.Name, err))
+			}
+		}
+		if status.LastRun != nil && !status.Passed {
+			failed = append(failed, check.Name)
+		}
+		statuses = append(statuses, status)
+
+		if next := cron.Next(now); !next.IsZero() && (requeue == 0 || next.Sub(now) < requeue) {
+			requeue = next.Sub(now)
+		}
+	}
+	db.Status.ConsistencyChecks = statuses
+
+	condition := dba.ManagedDatabaseCondition{
+		Type:               dba.ConditionConsistent,
+		Status:             corev1.ConditionTrue,
+		Reason:             "ChecksPassed",
+		LastTransitionTime: metav1.NewTime(now),
+	}
+	if len(failed) > 0 {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "ChecksFailed"
+		condition.Message = "Consistency checks found values which don't match: " + strings.Join(failed, ", ")
+	}
+	setCondition(db, condition)
+
+	if len(errs) > 0 {
+		return requeue, fmt.Errorf("Unable to run consistency checks: %s", strings.Join(errs, "; "))
+	}
+	return requeue, nil
+}
+
+// runConsistencyCheck runs both queries of the check and compares their
+// values. A query which can't be run leaves the outcome of the last run.
+func (c *ManagedDatabaseController) runConsistencyCheck(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, check dba.ConsistencyCheck, status *dba.ConsistencyCheckStatus, now time.Time) error {
+	tolerance, err := consistency.ParseTolerance(check.Tolerance)
+	if err != nil {
+		return err
+	}
+	left, err := c.runConsistencyQuery(ctx, db, check.Left)
+	if err != nil {
+		return fmt.Errorf("Unable to run left query: %w", err)
+	}
+	right, err := c.runConsistencyQuery(ctx, db, check.Right)
+	if err != nil {
+		return fmt.Errorf("Unable to run right query: %w", err)
+	}
+
+	wasPassing := status.LastRun == nil || status.Passed
+	lastRun := metav1.NewTime(now)
+	status.LastRun = &lastRun
+	status.Left = strconv.FormatFloat(left, 'f', -1, 64)
+	status.Right = strconv.FormatFloat(right, 'f', -1, 64)
+	status.Passed = tolerance.Within(left, right)
+
+	labels := checkLabels(db, check.Name)
+	passed := 0.0
+	if status.Passed {
+		passed = 1
+	}
+	c.metrics.ConsistencyCheckPassed.With(labels).Set(passed)
+	labels["side"] = "left"
+	c.metrics.ConsistencyCheckValue.With(labels).Set(left)
+	labels["side"] = "right"
+	c.metrics.ConsistencyCheckValue.With(labels).Set(right)
+
+	if status.Passed {
+		log.Info("Consistency check passed", "left", status.Left, "right", status.Right)
+		return nil
+	}
+
+	log.Info("Consistency check found values which don't match", "left", status.Left, "right", status.Right, "tolerance", check.Tolerance)
+	if wasPassing {
+		c.notifier.Notify(notify.Event{
+			Reason:    "ConsistencyCheckFailed",
+			Namespace: db.Namespace,
+			Name:      db.Name,
+			Message:   fmt.Sprintf("Consistency check %s found %s on the left and %s on the right", check.Name, status.Left, status.Right),
+		})
+	}
+	return nil
+}
+
+// runConsistencyQuery runs one side of a check on its ManagedDatabase with
+// the credentials from its Secret
+func (c *ManagedDatabaseController) runConsistencyQuery(ctx context.Context, db *dba.ManagedDatabase, query dba.ConsistencyQuery) (float64, error) {
+	target := db
+	if query.ManagedDatabase != "" && query.ManagedDatabase != db.Name {
+		dbName := types.NamespacedName{Namespace: db.Namespace, Name: query.ManagedDatabase}
+		var other dba.ManagedDatabase
+		if err := c.Get(ctx, dbName, &other); err != nil {
+			return 0, fmt.Errorf("Unable to fetch ManagedDatabase (%s): %w", dbName, err)
+		}
+		if err := applyManagedDatabaseClass(ctx, c.Client, &other); err != nil {
+			return 0, err
+		}
+		target = &other
+	}
+
+	conn := target.Spec.Connection.Spec
+	if conn == nil {
+		return 0, fmt.Errorf("Consistency checks require a typed connection spec on ManagedDatabase %s", target.Name)
+	}
+	querier, ok := valueQueriers[target.Spec.Connection.Engine]
+	if !ok {
+		return 0, fmt.Errorf("Database engine %s does not support consistency checks", target.Spec.Connection.Engine)
+	}
+
+	var secret corev1.Secret
+	secretName := types.NamespacedName{Namespace: db.Namespace, Name: query.CredentialsSecret}
+	if err := c.Get(ctx, secretName, &secret); err != nil {
+		return 0, fmt.Errorf("Unable to fetch secret (%s): %w", secretName, err)
+	}
+	username, password := string(secret.Data["username"]), string(secret.Data["password"])
+	if username == "" {
+		return 0, fmt.Errorf("Secret %s must contain a username and password", secretName)
+	}
+
+	spec, err := selectConnectionHost(target.Spec.Connection.Engine, typedConnectionSpec(conn, username, password))
+	if err != nil {
+		return 0, err
+	}
+	return querier.QueryValue(spec, query.Query, consistencyQueryTimeout)
+}
+
+// removeCondition drops the condition of the type, if there is one
+func removeCondition(db *dba.ManagedDatabase, conditionType string) {
+	kept := db.Status.Conditions[:0]
+	for _, condition := range db.Status.Conditions {
+		if condition.Type != conditionType {
+			kept = append(kept, condition)
+		}
+	}
+	db.Status.Conditions = kept
+}
+
+func checkLabels(db *dba.ManagedDatabase, check string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace": db.Namespace,
+		"database":  db.Name,
+		"check":     check,
+	}
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+// consumerRefreshInterval is how often the consumers are counted again while
+// pods still use credentials from before the last rotation
+const consumerRefreshInterval = 5 * time.Minute
+
+// podWorkload names the controller of a pod as Kind/name. The pods of a
+// Deployment are attributed to the Deployment instead of its ReplicaSet.
+func podWorkload(pod *corev1.Pod) string {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod/" + pod.Name
+	}
+	if hash, ok := pod.Labels["pod-template-hash"]; ok && owner.Kind == "ReplicaSet" && strings.HasSuffix(owner.Name, "-"+hash) {
+		return "Deployment/" + strings.TrimSuffix(owner.Name, "-"+hash)
+	}
+	return owner.Kind + "/" + owner.Name
+}
+
+// credentialsIssuedAt returns when the secret got its current credentials
+func credentialsIssuedAt(secret *corev1.Secret) time.Time {
+	if rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[CredentialsRotatedAtAnnotation]); err == nil {
+		return rotatedAt
+	}
+	return secret.CreationTimestamp.Time
+}
+
+// secretConsumers groups the pods using a secret by the credentials they
+// were started with. Pods read credentials from the environment when they
+// start, so a pod created before the last rotation still holds the previous
+// credentials.
+func secretConsumers(secret *corev1.Secret, pods []corev1.Pod) dba.SecretConsumers {
+	current := dba.CredentialGeneration{Checksum: secret.Annotations[CredentialsChecksumAnnotation], Current: true}
+	previous := dba.CredentialGeneration{Checksum: secret.Annotations[PreviousChecksumAnnotation]}
+	currentWorkloads := make(map[string]bool)
+	previousWorkloads := make(map[string]bool)
+
+	issuedAt := credentialsIssuedAt(secret)
+	for i := range pods {
+		pod := &pods[i]
+		if pod.CreationTimestamp.Time.Before(issuedAt) {
+			previous.Pods++
+			previousWorkloads[podWorkload(pod)] = true
+		} else {
+			current.Pods++
+			currentWorkloads[podWorkload(pod)] = true
+		}
+	}
+
+	consumers := dba.SecretConsumers{Secret: secret.Name}
+	for _, generation := range []struct {
+		generation dba.CredentialGeneration
+		workloads  map[string]bool
+	}{{current, currentWorkloads}, {previous, previousWorkloads}} {
+		if generation.generation.Pods == 0 {
+			continue
+		}
+		for workload := range generation.workloads {
+			generation.generation.Workloads = append(generation.generation.Workloads, workload)
+		}
+		sort.Strings(generation.generation.Workloads)
+		consumers.Generations = append(consumers.Generations, generation.generation)
+	}
+	return consumers
+}
+
+// discoverConsumers records which pods use each of the database's secrets,
+// and returns true when some of them still hold credentials from before the
+// last rotation
+func discoverConsumers(ctx context.Context, apiClient client.Client, db *dba.ManagedDatabase) (bool, error) {
+	secretList, err := listSecretsForDatabase(ctx, apiClient, db)
+	if err != nil {
+		return false, fmt.Errorf("Unable to list existing cluster secrets: %w", err)
+	}
+
+	var allPods corev1.PodList
+	if err := apiClient.List(ctx, &allPods, client.InNamespace(db.Namespace)); err != nil {
+		return false, fmt.Errorf("Unable to list pods: %w", err)
+	}
+	var running []corev1.Pod
+	for _, pod := range allPods.Items {
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			running = append(running, pod)
+		}
+	}
+
+	stale := false
+	var consumers []dba.SecretConsumers
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+
+		var users []corev1.Pod
+		for _, pod := range running {
+			if podUsesSecret(&pod, secret.Name) {
+				users = append(users, pod)
+			}
+		}
+		if len(users) == 0 {
+			continue
+		}
+
+		found := secretConsumers(secret, users)
+		for _, generation := range found.Generations {
+			stale = stale || !generation.Current
+		}
+		consumers = append(consumers, found)
+	}
+
+	sort.Slice(consumers, func(i, j int) bool { return consumers[i].Secret < consumers[j].Secret })
+	db.Status.Consumers = consumers
+	return stale, nil
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+// ConsumerLabel marks a pod as a consumer of the named ManagedDatabase in
+// the same namespace, so that its connection details are injected
+const ConsumerLabel = "dbaoperator.app-sre.redhat.com/consumer-of"
+
+// +kubebuilder:webhook:path=/mutate-v1-pod,mutating=true,failurePolicy=ignore,groups="",resources=pods,verbs=create,versions=v1,name=consumers.dbaoperator.app-sre.redhat.com
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases;manageddatabaseclasses,verbs=get;list;watch
+
+// ConsumerInjector is a mutating webhook which adds environment variables
+// with the connection details of a ManagedDatabase to the containers of the
+// pods which consume it. Variables which a container already defines are
+// left alone.
+type ConsumerInjector struct {
+	client  client.Client
+	log     logr.Logger
+	decoder *admission.Decoder
+}
+
+// NewConsumerInjector will instantiate a ConsumerInjector with the supplied
+// arguments
+func NewConsumerInjector(c client.Client, l logr.Logger) *ConsumerInjector {
+	return &ConsumerInjector{client: c, log: l}
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (ci *ConsumerInjector) InjectDecoder(d *admission.Decoder) error {
+	ci.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler
+func (ci *ConsumerInjector) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var pod corev1.Pod
+	if err := ci.decoder.Decode(req, &pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	dbName, ok := pod.Labels[ConsumerLabel]
+	if !ok {
+		return admission.Allowed("Pod is not a database consumer")
+	}
+
+	log := ci.log.WithValues("namespace", req.Namespace, "pod", pod.GenerateName+pod.Name, "manageddatabase", dbName)
+
+	var db dba.ManagedDatabase
+	if err := ci.client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: dbName}, &db); err != nil {
+		if apierrs.IsNotFound(err) {
+			log.Info("Consumed ManagedDatabase does not exist")
+			return admission.Allowed("ManagedDatabase does not exist")
+		}
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if err := applyManagedDatabaseClass(ctx, ci.client, &db); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	env, err := consumerEnv(&db, consumedVersion(&db, &pod))
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	for i := range pod.Spec.InitContainers {
+		injectEnv(&pod.Spec.InitContainers[i], env)
+	}
+	for i := range pod.Spec.Containers {
+		injectEnv(&pod.Spec.Containers[i], env)
+	}
+
+	marshaled, err := json.Marshal(&pod)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	log.Info("Injecting database connection details", "numVariables", len(env))
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// consumedVersion returns the schema version whose credentials the pod
+// should use, which is the one it is labeled with during blue/green rollouts
+func consumedVersion(db *dba.ManagedDatabase, pod *corev1.Pod) string {
+	if db.Spec.BlueGreen != nil {
+		label := db.Spec.BlueGreen.VersionLabel
+		if label == "" {
+			label = dba.DefaultSchemaVersionLabel
+		}
+		if version, ok := pod.Labels[label]; ok {
+			return version
+		}
+	}
+	return db.Status.CurrentVersion
+}
+
+// consumerEnv returns the environment variables which describe how to
+// connect to the database. The credentials are those of the schema version,
+// and are referenced from their secret rather than copied.
+func consumerEnv(db *dba.ManagedDatabase, version string) ([]corev1.EnvVar, error) {
+	var env []corev1.EnvVar
+
+	metadata := connectionMetadata(db)
+	for _, variable := range []struct{ name, key string }{
+		{"DATABASE_HOST", "host"},
+		{"DATABASE_HOSTS", "hosts"},
+		{"DATABASE_PORT", "port"},
+		{"DATABASE_SOCKET", "socket"},
+		{"DATABASE_NAME", "database"},
+		{"DATABASE_TLS", "tls"},
+	} {
+		if value, ok := metadata[variable.key]; ok {
+			env = append(env, corev1.EnvVar{Name: variable.name, Value: value})
+		}
+	}
+
+	if version != "" {
+		secretName, err := credentialsSecretName(db, version)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env,
+			corev1.EnvVar{Name: "DATABASE_SECRET", Value: secretName},
+			secretEnv("DATABASE_USERNAME", secretName, "username"),
+			secretEnv("DATABASE_PASSWORD", secretName, "password"),
+		)
+	}
+	return env, nil
+}
+
+func secretEnv(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+// injectEnv adds the variables which the container doesn't already define
+func injectEnv(container *corev1.Container, env []corev1.EnvVar) {
+	defined := make(map[string]bool, len(container.Env))
+	for _, existing := range container.Env {
+		defined[existing.Name] = true
+	}
+
+	for _, variable := range env {
+		if !defined[variable.Name] {
+			container.Env = append(container.Env, variable)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/dba-operator/pkg/config"
+)
+
+// credentialAgeInterval is how often the credential age metrics are updated
+const credentialAgeInterval = time.Minute
+
+// CredentialAgeController periodically exports the age of every credential
+// issued by the operator, so that violations of the rotation SLO can be
+// alerted on directly from the operator's metrics.
+type CredentialAgeController struct {
+	client.Client
+	Log     logr.Logger
+	Scheme  *runtime.Scheme
+	config  config.Provider
+	metrics CredentialAgeControllerMetrics
+
+	// overdue contains the last rotation time of each credential which has
+	// already been counted as overdue, so that it is only counted once
+	overdue map[string]time.Time
+}
+
+// NewCredentialAgeController will instantiate a CredentialAgeController with
+// the supplied arguments and logical defaults.
+func NewCredentialAgeController(
+	c client.Client,
+	scheme *runtime.Scheme,
+	l logr.Logger,
+	cfg config.Provider,
+) (*CredentialAgeController, []prometheus.Collector) {
+	metrics := generateCredentialAgeControllerMetrics()
+
+	return &CredentialAgeController{
+		Client:  c,
+		Scheme:  scheme,
+		Log:     l,
+		config:  cfg,
+		metrics: metrics,
+		overdue: make(map[string]time.Time),
+	}, getAllMetrics(metrics)
+}
+
+// +kubebuilder:rbac:groups=,resources=secrets,verbs=get;list
+
+// Start implements manager.Runnable
+func (cac *CredentialAgeController) Start(stop <-chan struct{}) error {
+	for {
+		if err := cac.measure(time.Now()); err != nil {
+			cac.Log.Error(err, "Unable to update credential age metrics")
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(credentialAgeInterval):
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (cac *CredentialAgeController) NeedLeaderElection() bool {
+	return true
+}
+
+func (cac *CredentialAgeController) measure(now time.Time) error {
+	var allSecrets corev1.SecretList
+	if err := cac.List(context.Background(), &allSecrets); err != nil {
+		return fmt.Errorf("Unable to list secrets: %w", err)
+	}
+
+	rotation := cac.config.Current().Rotation
+
+	// Start from scratch so that deleted credentials stop being reported
+	cac.metrics.CredentialAge.Reset()
+	cac.metrics.CredentialSinceRotation.Reset()
+	cac.metrics.CredentialUntilRotation.Reset()
+
+	stillOverdue := make(map[string]time.Time, len(cac.overdue))
+	for _, secret := range allSecrets.Items {
+		_, forDatabase := secret.Labels["database-uid"]
+		_, forRequest := secret.Labels["credential-request-uid"]
+		if !forDatabase && !forRequest {
+			continue
+		}
+		if _, hasPassword := secret.Data["password"]; !hasPassword {
+			// Workload identity credentials have nothing to rotate
+			continue
+		}
+
+		labels := prometheus.Labels{
+			"namespace": secret.Namespace,
+			"secret":    secret.Name,
+			"database":  secret.Labels["database"],
+		}
+
+		created := secret.CreationTimestamp.Time
+		rotated := credentialRotatedAt(&secret)
+
+		cac.metrics.CredentialAge.With(labels).Set(now.Sub(created).Seconds())
+		cac.metrics.CredentialSinceRotation.With(labels).Set(now.Sub(rotated).Seconds())
+		if rotation.Interval.Duration > 0 {
+			// Negative once the scheduled rotation has been missed
+			nextRotation := rotated.Add(rotation.Interval.Duration)
+			cac.metrics.CredentialUntilRotation.With(labels).Set(nextRotation.Sub(now).Seconds())
+		}
+
+		if rotation.MaxAge.Duration > 0 && now.Sub(rotated) > rotation.MaxAge.Duration {
+			key := secret.Namespace + "/" + secret.Name
+			if counted, ok := cac.overdue[key]; !ok || !counted.Equal(rotated) {
+				cac.Log.Info("Credential is overdue for rotation", "namespace", secret.Namespace, "secret", secret.Name, "rotatedAt", rotated)
+				cac.metrics.RotationOverdue.Inc()
+			}
+			stillOverdue[key] = rotated
+		}
+	}
+	cac.overdue = stillOverdue
+
+	return nil
+}
+
+// credentialRotatedAt returns when the password in the secret was last set,
+// which is its creation time if it has never been rotated
+func credentialRotatedAt(secret *corev1.Secret) time.Time {
+	if rotatedAt, ok := secret.Annotations[CredentialsRotatedAtAnnotation]; ok {
+		if parsed, err := time.Parse(time.RFC3339, rotatedAt); err == nil {
+			return parsed
+		}
+	}
+	return secret.CreationTimestamp.Time
+}
@@ -0,0 +1,703 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/approval"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/secretconvention"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// credentialAuthWorkloadIdentity selects users which authenticate with
+// service account tokens instead of the default of a password
+const credentialAuthWorkloadIdentity = "workload-identity"
+
+// CredentialRequestUsernamePrefix is prepended to the database usernames
+// issued for DatabaseCredentialRequests. It must not overlap DBUsernamePrefix,
+// whose users are pruned by the ManagedDatabaseController.
+const CredentialRequestUsernamePrefix = "dbr_"
+
+// credentialRequestFinalizer holds a DatabaseCredentialRequest until its
+// database user has been removed
+const credentialRequestFinalizer = "dbaoperator.app-sre.redhat.com/credential-request"
+
+// CredentialRequestController reconciles DatabaseCredentialRequests, which
+// allow workloads in one namespace to obtain credentials for a
+// ManagedDatabase that lives in another.
+type CredentialRequestController struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	config      config.Provider
+	metrics     CredentialRequestControllerMetrics
+	diagnostics *diagnostics.Recorder
+	approver    approval.Approver
+
+	// verifyRequesters is set when the RequesterVerifier admits requests,
+	// and only then are their service accounts trusted
+	verifyRequesters bool
+}
+
+// NewCredentialRequestController will instantiate a
+// CredentialRequestController with the supplied arguments and logical
+// defaults. diag enables debug mode, see diagnostics.Recorder.
+// verifyRequesters must only be set when the RequesterVerifier webhook is
+// served.
+func NewCredentialRequestController(
+	c client.Client,
+	scheme *runtime.Scheme,
+	l logr.Logger,
+	diag *diagnostics.Recorder,
+	cfg config.Provider,
+	approver approval.Approver,
+	verifyRequesters bool,
+) (*CredentialRequestController, []prometheus.Collector) {
+	metrics := generateCredentialRequestControllerMetrics()
+
+	return &CredentialRequestController{
+		Client:           c,
+		Scheme:           scheme,
+		Log:              l,
+		config:           cfg,
+		metrics:          metrics,
+		diagnostics:      diag,
+		approver:         approver,
+		verifyRequesters: verifyRequesters,
+	}, getAllMetrics(metrics)
+}
+
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=databasecredentialrequests,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=databasecredentialrequests/status,verbs=get;update;patch
+
+// ReconcileDatabaseCredentialRequest should be invoked whenever there is a
+// change to a DatabaseCredentialRequest, its secret, or the ManagedDatabase
+// that it references.
+func (c *CredentialRequestController) ReconcileDatabaseCredentialRequest(req ctrl.Request) (ctrl.Result, error) {
+	var ctx = context.Background()
+	var log = c.Log.WithValues("databasecredentialrequest", req.NamespacedName)
+
+	var request dba.DatabaseCredentialRequest
+	if err := c.Get(ctx, req.NamespacedName, &request); err != nil {
+		if apierrs.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "unable to fetch DatabaseCredentialRequest", "phase", phaseFetch)
+		return ctrl.Result{}, err
+	}
+
+	dbName := types.NamespacedName{
+		Namespace: request.Spec.ManagedDatabase.Namespace,
+		Name:      request.Spec.ManagedDatabase.Name,
+	}
+	log = phaseLogger(log.WithValues("manageddatabase", dbName), phaseCredentials)
+
+	if !request.DeletionTimestamp.IsZero() {
+		if !containsString(request.Finalizers, credentialRequestFinalizer) {
+			return ctrl.Result{}, nil
+		}
+
+		if err := c.revoke(ctx, log, &request, dbName); err != nil {
+			return c.handleError(ctx, &request, log, err)
+		}
+
+		request.Finalizers = removeString(request.Finalizers, credentialRequestFinalizer)
+		if err := c.Update(ctx, &request); err != nil {
+			return ctrl.Result{}, fmt.Errorf("Unable to remove finalizer: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !containsString(request.Finalizers, credentialRequestFinalizer) {
+		request.Finalizers = append(request.Finalizers, credentialRequestFinalizer)
+		if err := c.Update(ctx, &request); err != nil {
+			return ctrl.Result{}, fmt.Errorf("Unable to add finalizer: %w", err)
+		}
+	}
+
+	var db dba.ManagedDatabase
+	if err := c.Get(ctx, dbName, &db); err != nil {
+		return c.handleError(ctx, &request, log, fmt.Errorf("Unable to fetch ManagedDatabase (%s): %w", dbName, err))
+	}
+	if err := applyManagedDatabaseClass(ctx, c.Client, &db); err != nil {
+		return c.handleError(ctx, &request, log, err)
+	}
+
+	class := dbadmin.GrantClass(request.Spec.Class)
+	if class == "" {
+		class = c.config.Current().DefaultGrantClass
+	}
+
+	serviceAccount := c.requestServiceAccount(&request)
+	if !credentialRequestAllowed(&db, request.Namespace, serviceAccount, class) {
+		c.metrics.CredentialRequestsDenied.Inc()
+		denied := fmt.Errorf(
+			"Namespace %s service account (%s) is not allowed to request %s credentials for ManagedDatabase %s",
+			request.Namespace,
+			request.Spec.ServiceAccountName,
+			class,
+			dbName,
+		)
+		if serviceAccount != request.Spec.ServiceAccountName {
+			denied = fmt.Errorf("%w, the service account of the request was not verified", denied)
+		}
+		log.Info("Denying credential request", "class", class)
+
+		if request.Status.Granted {
+			if err := c.revoke(ctx, log, &request, dbName); err != nil {
+				return c.handleError(ctx, &request, log, err)
+			}
+		}
+		return c.handleError(ctx, &request, log, denied)
+	}
+
+	if err := c.grant(ctx, log, &request, &db, class); err != nil {
+		return c.handleError(ctx, &request, log, err)
+	}
+
+	if err := c.Status().Update(ctx, &request); err != nil {
+		log.Error(err, "Unable to update DatabaseCredentialRequest status block", "phase", phaseStatus)
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (c *CredentialRequestController) grant(ctx context.Context, log logr.Logger, request *dba.DatabaseCredentialRequest, db *dba.ManagedDatabase, class dbadmin.GrantClass) error {
+	secretName := credentialRequestSecretName(request)
+	username := credentialRequestUsername(request)
+
+	var existing corev1.Secret
+	err := c.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: secretName}, &existing)
+	if err == nil {
+		if existing.Labels["credential-request-uid"] != string(request.UID) {
+			return fmt.Errorf("Secret %s already exists and does not belong to this request", secretName)
+		}
+
+		if requestedClassChanged(request, class) {
+			if err := c.regrant(ctx, log, request, db, username, class); err != nil {
+				return err
+			}
+		}
+
+		request.Status.Granted = true
+		request.Status.Username = username
+		request.Status.SecretName = secretName
+		request.Status.Errors = nil
+		request.Status.TemporaryErrorRetries = 0
+		return nil
+	} else if !apierrs.IsNotFound(err) {
+		return fmt.Errorf("Unable to fetch secret (%s): %w", secretName, err)
+	}
+
+	if reason := pauseReason(c.config.Current(), db); reason != "" {
+		return xerrors.NewTempErrorf("Unable to grant credentials while %s", reason)
+	}
+
+	requested := class
+	class, grants, err := c.approveRequest(log, request, db, username, requested)
+	if err != nil {
+		return err
+	}
+
+	admin, err := initializeAdminConnection(ctx, log, c.diagnostics, c.Client, db.Namespace, &db.Spec)
+	if err != nil {
+		return fmt.Errorf("Unable to create database connection: %w", err)
+	}
+	defer admin.Close()
+
+	unlock, err := lockOperator(log, admin, c.config.Current().Leases)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	credentials := dbadmin.Credentials{
+		Username:   username,
+		Grants:     grants,
+		AuthPlugin: dbadmin.AuthPlugin(db.Spec.AuthPlugin),
+		Attributes: credentialAttributes(db, ownerKindCredentialRequest, request, class, time.Now()),
+	}
+	secretData := connectionMetadata(db)
+	secretData["username"] = username
+
+	if request.Spec.Authentication == credentialAuthWorkloadIdentity {
+		identity, err := workloadIdentity(request, db)
+		if err != nil {
+			return err
+		}
+		credentials.AuthPlugin = dbadmin.AuthPluginOpenIDConnect
+		credentials.Identity = identity
+
+		secretData["authentication"] = credentialAuthWorkloadIdentity
+		secretData["identityProvider"] = identity.Provider
+		secretData["subject"] = identity.Subject
+	} else {
+		password, err := randPassword()
+		if err != nil {
+			return fmt.Errorf("Unable to generate password for user (%s): %w", username, err)
+		}
+		credentials.Password = password
+		secretData["password"] = password
+
+		formatted, err := secretFormatData(db, username, password)
+		if err != nil {
+			return err
+		}
+		for key, value := range formatted {
+			secretData[key] = value
+		}
+	}
+
+	// The metadata must render before a user is created for the secret
+	secretLabels, secretAnnotations, err := secretMetadata(db, request.Namespace, secretconvention.KindCredentialRequest, "", map[string]string{
+		"credential-request":     request.Name,
+		"credential-request-uid": string(request.UID),
+	})
+	if err != nil {
+		return err
+	}
+
+	existingUsernames, err := admin.ListUsernames(username)
+	if err != nil {
+		return fmt.Errorf("Unable to list existing db usernames: %w", err)
+	}
+
+	if containsString(existingUsernames, username) {
+		// The user was created but we lost track of its secret, so the only
+		// way to recover the password is to reset it. Identity mappings are
+		// reapplied in the same way.
+		log.Info("Resetting authentication for user without a secret", "username", username)
+		if err := admin.RotateCredentials([]dbadmin.Credentials{credentials}); err != nil {
+			return fmt.Errorf("Unable to reset authentication for user (%s): %w", username, err)
+		}
+	} else {
+		if err := checkUserQuota(admin, c.config.Current().Quotas, 1); err != nil {
+			return err
+		}
+
+		log.Info("Provisioning user account", "username", username, "class", class)
+		if err := admin.WriteCredentialsBatch([]dbadmin.Credentials{credentials}); err != nil {
+			return fmt.Errorf("Unable to create db user (%s): %w", username, err)
+		}
+	}
+
+	if err := writeSecret(
+		ctx,
+		c.Client,
+		request.Namespace,
+		secretName,
+		secretData,
+		secretLabels,
+		secretAnnotations,
+		request,
+		c.Scheme,
+	); err != nil {
+		return fmt.Errorf("Unable to write secret (%s) to cluster: %w", secretName, err)
+	}
+
+	c.metrics.CredentialRequestsGranted.Inc()
+
+	request.Status.Granted = true
+	request.Status.Username = username
+	request.Status.SecretName = secretName
+	request.Status.Class = string(class)
+	request.Status.RequestedClass = string(requested)
+	request.Status.Errors = nil
+	request.Status.TemporaryErrorRetries = 0
+	return nil
+}
+
+// requestedClassChanged returns true if the request asks for another class
+// than the one its credentials were provisioned for. Requests provisioned
+// before the requested class was recorded are compared with their class.
+func requestedClassChanged(request *dba.DatabaseCredentialRequest, class dbadmin.GrantClass) bool {
+	requested := request.Status.RequestedClass
+	if requested == "" {
+		requested = request.Status.Class
+	}
+	return requested != "" && requested != string(class)
+}
+
+// regrant applies the grants of a changed class to the user issued for the
+// request, revoking the privileges of the previous class
+func (c *CredentialRequestController) regrant(
+	ctx context.Context,
+	log logr.Logger,
+	request *dba.DatabaseCredentialRequest,
+	db *dba.ManagedDatabase,
+	username string,
+	requested dbadmin.GrantClass,
+) error {
+	if reason := pauseReason(c.config.Current(), db); reason != "" {
+		return xerrors.NewTempErrorf("Unable to change the class of the credentials while %s", reason)
+	}
+
+	class, grants, err := c.approveRequest(log, request, db, username, requested)
+	if err != nil {
+		return err
+	}
+
+	admin, err := initializeAdminConnection(ctx, log, c.diagnostics, c.Client, db.Namespace, &db.Spec)
+	if err != nil {
+		return fmt.Errorf("Unable to create database connection: %w", err)
+	}
+	defer admin.Close()
+
+	unlock, err := lockOperator(log, admin, c.config.Current().Leases)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	log.Info("Changing the class of issued credentials", "username", username, "previous", request.Status.Class, "class", class)
+	if err := admin.ReapplyGrants([]dbadmin.Credentials{{Username: username, Grants: grants}}); err != nil {
+		return fmt.Errorf("Unable to change the grants of user (%s): %w", username, err)
+	}
+
+	request.Status.Class = string(class)
+	request.Status.RequestedClass = string(requested)
+	return nil
+}
+
+// revoke removes the database user and secret issued for the request. If the
+// ManagedDatabase no longer exists there is nothing left to revoke in it.
+func (c *CredentialRequestController) revoke(ctx context.Context, log logr.Logger, request *dba.DatabaseCredentialRequest, dbName types.NamespacedName) error {
+	username := credentialRequestUsername(request)
+
+	if c.config.Current().SafeMode {
+		return xerrors.NewTempErrorf("Unable to revoke credentials while the operator is in safe-mode")
+	}
+
+	var db dba.ManagedDatabase
+	if err := c.Get(ctx, dbName, &db); err != nil {
+		if !apierrs.IsNotFound(err) {
+			return fmt.Errorf("Unable to fetch ManagedDatabase (%s): %w", dbName, err)
+		}
+		log.Info("ManagedDatabase is gone, skipping user removal", "username", username)
+	} else {
+		if err := applyManagedDatabaseClass(ctx, c.Client, &db); err != nil {
+			return err
+		}
+
+		if reason := pauseReason(c.config.Current(), &db); reason != "" {
+			return xerrors.NewTempErrorf("Unable to revoke credentials while %s", reason)
+		}
+
+		admin, err := initializeAdminConnection(ctx, log, c.diagnostics, c.Client, db.Namespace, &db.Spec)
+		if err != nil {
+			return fmt.Errorf("Unable to create database connection: %w", err)
+		}
+		defer admin.Close()
+
+		unlock, err := lockOperator(log, admin, c.config.Current().Leases)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		existingUsernames, err := admin.ListUsernames(username)
+		if err != nil {
+			return fmt.Errorf("Unable to list existing db usernames: %w", err)
+		}
+
+		if containsString(existingUsernames, username) {
+			log.Info("Deprovisioning user account", "username", username)
+			if err := admin.VerifyUnusedAndDeleteCredentials(username); err != nil {
+				return fmt.Errorf("Unable to delete user (%s) from db: %w", username, err)
+			}
+		}
+	}
+
+	secretName := credentialRequestSecretName(request)
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: secretName}, &secret); err == nil {
+		if secret.Labels["credential-request-uid"] == string(request.UID) {
+			if err := c.Delete(ctx, &secret); err != nil && !apierrs.IsNotFound(err) {
+				return fmt.Errorf("Unable to delete secret (%s): %w", secretName, err)
+			}
+		}
+	} else if !apierrs.IsNotFound(err) {
+		return fmt.Errorf("Unable to fetch secret (%s): %w", secretName, err)
+	}
+
+	c.metrics.CredentialRequestsRevoked.Inc()
+
+	request.Status.Granted = false
+	request.Status.Username = ""
+	request.Status.SecretName = ""
+	return nil
+}
+
+func (c *CredentialRequestController) handleError(ctx context.Context, request *dba.DatabaseCredentialRequest, log logr.Logger, err error) (ctrl.Result, error) {
+	var maybeTemporary xerrors.EnhancedError
+	var result ctrl.Result
+
+	statusError := dba.ManagedDatabaseError{Message: redact.String(err.Error()), Temporary: false}
+
+	if errors.As(err, &maybeTemporary) && maybeTemporary.Temporary() {
+		result = ctrl.Result{RequeueAfter: temporaryErrorDelay(c.config.Current().Backoff, err, request.Status.TemporaryErrorRetries)}
+		log.Info("Retrying after temporary error", "error", statusError.Message, "retryAfter", result.RequeueAfter)
+		request.Status.TemporaryErrorRetries = xerrors.CountRetry(request.Status.TemporaryErrorRetries)
+		statusError.Temporary = true
+	}
+
+	request.Status.Errors = append(request.Status.Errors, statusError)
+
+	if err := c.Status().Update(ctx, request); err != nil {
+		log.Error(err, "Unable to update DatabaseCredentialRequest status block")
+		return ctrl.Result{}, err
+	}
+
+	return result, nil
+}
+
+// requestsForManagedDatabase maps a ManagedDatabase to all of the
+// DatabaseCredentialRequests which reference it, so that policy changes are
+// applied to existing requests.
+func (c *CredentialRequestController) requestsForManagedDatabase(obj handler.MapObject) []reconcile.Request {
+	var allRequests dba.DatabaseCredentialRequestList
+	if err := c.List(context.Background(), &allRequests); err != nil {
+		c.Log.Error(err, "unable to list DatabaseCredentialRequests")
+		return nil
+	}
+
+	var toReconcile []reconcile.Request
+	for _, request := range allRequests.Items {
+		ref := request.Spec.ManagedDatabase
+		if ref.Namespace == obj.Meta.GetNamespace() && ref.Name == obj.Meta.GetName() {
+			toReconcile = append(toReconcile, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: request.Namespace, Name: request.Name},
+			})
+		}
+	}
+	return toReconcile
+}
+
+// SetupWithManager should be called to finish initialization of a
+// CredentialRequestController and bind it to the manager specified.
+func (c *CredentialRequestController) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dba.DatabaseCredentialRequest{}).
+		Owns(&corev1.Secret{}).
+		Watches(
+			&source.Kind{Type: &dba.ManagedDatabase{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(c.requestsForManagedDatabase)},
+		).
+		Complete(drained("databasecredentialrequest", traced(c.diagnostics, "databasecredentialrequest", c.ReconcileDatabaseCredentialRequest)))
+}
+
+// credentialRequestAllowed returns true if any of the ManagedDatabase's
+// policies allow the namespace and service account to request the class.
+func credentialRequestAllowed(db *dba.ManagedDatabase, namespace, serviceAccount string, class dbadmin.GrantClass) bool {
+	for _, policy := range db.Spec.CredentialRequestPolicies {
+		if !containsString(policy.Namespaces, namespace) {
+			continue
+		}
+		if len(policy.ServiceAccounts) > 0 && !containsString(policy.ServiceAccounts, serviceAccount) {
+			continue
+		}
+		if len(policy.Classes) > 0 && !containsString(policy.Classes, string(class)) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// approveRequest submits the request to the approver, and returns the class
+// and grants it may be provisioned with. The policy may change the class,
+// but only to one that the ManagedDatabase allows the requester as well.
+func (c *CredentialRequestController) approveRequest(
+	log logr.Logger,
+	request *dba.DatabaseCredentialRequest,
+	db *dba.ManagedDatabase,
+	username string,
+	class dbadmin.GrantClass,
+) (dbadmin.GrantClass, []dbadmin.DatabaseGrant, error) {
+	cfg := c.config.Current()
+	grants, err := credentialRequestGrants(db, cfg, class)
+	if err != nil {
+		return "", nil, err
+	}
+
+	decision, err := approveCredentials(c.approver, approval.Request{
+		Kind:            approval.KindCredentialRequest,
+		Object:          approval.ObjectReference{Namespace: request.Namespace, Name: request.Name},
+		ManagedDatabase: approval.ObjectReference{Namespace: db.Namespace, Name: db.Name},
+		Requester:       &approval.Requester{Namespace: request.Namespace, ServiceAccount: c.requestServiceAccount(request)},
+		Username:        username,
+		Class:           string(class),
+	}, grants)
+	if err != nil {
+		return "", nil, err
+	}
+	if !decision.Allowed {
+		c.metrics.CredentialRequestsDenied.Inc()
+		log.Info("Policy denied credential request", "class", class, "reason", decision.Reason)
+		return "", nil, policyDenial(username, decision)
+	}
+
+	decided := dbadmin.GrantClass(decision.Class)
+	if decided != "" && decided != class {
+		if !credentialRequestAllowed(db, request.Namespace, c.requestServiceAccount(request), decided) {
+			return "", nil, fmt.Errorf("Policy decided on %s credentials, which ManagedDatabase %s/%s does not allow for the request", decided, db.Namespace, db.Name)
+		}
+
+		log.Info("Policy changed the class of the credential request", "requested", class, "class", decided, "reason", decision.Reason)
+		class = decided
+		if grants, err = credentialRequestGrants(db, cfg, class); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := enforceGrantPolicies(cfg.Policies, log, db, username, grants); err != nil {
+		c.metrics.CredentialRequestsDenied.Inc()
+		return "", nil, err
+	}
+	return class, grants, nil
+}
+
+// credentialRequestGrants computes the grants for a requested class. A
+// request never receives more access to a database than the ManagedDatabase
+// itself grants on it. Execute grants are dropped for read-only requests, as
+// routines defined with the definer's privileges may write.
+func credentialRequestGrants(db *dba.ManagedDatabase, cfg config.Config, class dbadmin.GrantClass) ([]dbadmin.DatabaseGrant, error) {
+	switch class {
+	case grantClassMasked:
+		return maskedViewGrants(db)
+	case grantClassCDC:
+		return cdcGrants(db, cfg)
+	}
+
+	grants := databaseGrants(&db.Spec, cfg)
+	if len(grants) == 0 {
+		return []dbadmin.DatabaseGrant{{Class: class}}, nil
+	}
+	return narrowGrants(grants, class), nil
+}
+
+// narrowGrants replaces the readwrite grants with the class
+func narrowGrants(grants []dbadmin.DatabaseGrant, class dbadmin.GrantClass) []dbadmin.DatabaseGrant {
+	requested := make([]dbadmin.DatabaseGrant, 0, len(grants))
+	for _, grant := range grants {
+		switch grant.Class {
+		case dbadmin.GrantClassReadWrite:
+			grant.Class = class
+		case dbadmin.GrantClassExecute:
+			if class == dbadmin.GrantClassReadOnly {
+				continue
+			}
+		}
+		requested = append(requested, grant)
+	}
+	if len(requested) == 0 {
+		// An empty list would be read as the backend's read-write default
+		return []dbadmin.DatabaseGrant{{Class: class}}
+	}
+	return requested
+}
+
+// workloadIdentity maps the service account of the request to a user through
+// the identity provider that the database trusts
+func workloadIdentity(request *dba.DatabaseCredentialRequest, db *dba.ManagedDatabase) (*dbadmin.IdentityMapping, error) {
+	if db.Spec.WorkloadIdentity == nil || db.Spec.WorkloadIdentity.IdentityProvider == "" {
+		return nil, fmt.Errorf("ManagedDatabase %s/%s does not have a workload identity provider", db.Namespace, db.Name)
+	}
+	if request.Spec.ServiceAccountName == "" {
+		return nil, errors.New("Workload identity credentials require a service account name")
+	}
+
+	return &dbadmin.IdentityMapping{
+		Provider: db.Spec.WorkloadIdentity.IdentityProvider,
+		Subject:  fmt.Sprintf("system:serviceaccount:%s:%s", request.Namespace, request.Spec.ServiceAccountName),
+	}, nil
+}
+
+// connectionMetadata returns the non-secret connection details of a database
+// with a typed connection spec, so that consumers of credentials which
+// contain no password know where to connect
+func connectionMetadata(db *dba.ManagedDatabase) map[string]string {
+	metadata := make(map[string]string)
+
+	spec := db.Spec.Connection.Spec
+	if spec == nil {
+		return metadata
+	}
+
+	metadata["database"] = spec.Database
+	if spec.Socket != "" {
+		metadata["socket"] = spec.Socket
+	} else if len(spec.Hosts) > 0 {
+		hosts := make([]string, 0, len(spec.Hosts))
+		for _, host := range spec.Hosts {
+			hosts = append(hosts, hostAddress(host.Host, host.Port, spec.Port))
+		}
+		metadata["hosts"] = strings.Join(hosts, ",")
+	} else {
+		metadata["host"] = spec.Host
+		if spec.Port != 0 {
+			metadata["port"] = strconv.Itoa(int(spec.Port))
+		}
+	}
+	if spec.TLS != "" {
+		metadata["tls"] = spec.TLS
+	}
+	return metadata
+}
+
+func credentialRequestSecretName(request *dba.DatabaseCredentialRequest) string {
+	if request.Spec.SecretName != "" {
+		return request.Spec.SecretName
+	}
+	return request.Name
+}
+
+// credentialRequestUsername derives a stable username from the request UID,
+// which stays within the MySQL username length limit.
+func credentialRequestUsername(request *dba.DatabaseCredentialRequest) string {
+	digest := sha256.Sum256([]byte(request.UID))
+	return CredentialRequestUsernamePrefix + hex.EncodeToString(digest[:8])
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, item := range haystack {
+		if item == needle {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(haystack []string, needle string) []string {
+	result := make([]string, 0, len(haystack))
+	for _, item := range haystack {
+		if item != needle {
+			result = append(result, item)
+		}
+	}
+	return result
+}
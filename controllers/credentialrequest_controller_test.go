@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"testing"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestCredentialRequestAllowed(t *testing.T) {
+	db := &dba.ManagedDatabase{Spec: dba.ManagedDatabaseSpec{CredentialRequestPolicies: []dba.CredentialRequestPolicy{
+		{Namespaces: []string{"analytics"}, ServiceAccounts: []string{"reporter"}, Classes: []string{"readonly"}},
+		{Namespaces: []string{"billing"}},
+	}}}
+
+	for _, tc := range []struct {
+		name           string
+		namespace      string
+		serviceAccount string
+		class          dbadmin.GrantClass
+		allowed        bool
+	}{
+		{"listed service account and class", "analytics", "reporter", dbadmin.GrantClassReadOnly, true},
+		{"class which isn't listed", "analytics", "reporter", dbadmin.GrantClassReadWrite, false},
+		{"service account which isn't listed", "analytics", "admin", dbadmin.GrantClassReadOnly, false},
+		{"unverified service account", "analytics", "", dbadmin.GrantClassReadOnly, false},
+		{"namespace without restrictions", "billing", "", dbadmin.GrantClassReadWrite, true},
+		{"namespace which isn't listed", "quay", "reporter", dbadmin.GrantClassReadOnly, false},
+	} {
+		if allowed := credentialRequestAllowed(db, tc.namespace, tc.serviceAccount, tc.class); allowed != tc.allowed {
+			t.Errorf("%s: expected allowed to be %t", tc.name, tc.allowed)
+		}
+	}
+
+	if credentialRequestAllowed(&dba.ManagedDatabase{}, "analytics", "reporter", dbadmin.GrantClassReadOnly) {
+		t.Error("databases without policies must deny every request")
+	}
+}
+
+func TestCredentialRequestGrants(t *testing.T) {
+	cfg := config.Default()
+	db := &dba.ManagedDatabase{Spec: dba.ManagedDatabaseSpec{Grants: []dba.DatabaseGrant{
+		{Database: "quay"},
+		{Database: "quay", Class: "execute"},
+	}}}
+
+	grants, err := credentialRequestGrants(db, cfg, dbadmin.GrantClassReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grants) != 1 || grants[0].Database != "quay" || grants[0].Class != dbadmin.GrantClassReadOnly {
+		t.Errorf("read-only requests must be narrowed and lose execute, got %+v", grants)
+	}
+
+	grants, err = credentialRequestGrants(&dba.ManagedDatabase{}, cfg, dbadmin.GrantClassReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grants) != 1 || grants[0].Database != "" || grants[0].Class != dbadmin.GrantClassReadOnly {
+		t.Errorf("databases without grants must be narrowed on their own database, got %+v", grants)
+	}
+
+	cfg.DefaultGrants = []config.GrantTemplate{{Database: "reporting", Tables: []string{"exports"}}}
+	grants, err = credentialRequestGrants(&dba.ManagedDatabase{}, cfg, dbadmin.GrantClassReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grants) != 1 || grants[0].Database != "reporting" || grants[0].Class != dbadmin.GrantClassReadOnly || len(grants[0].Tables) != 1 {
+		t.Errorf("the default grants must be narrowed, got %+v", grants)
+	}
+}
+
+func TestRequestedClassChanged(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		status    dba.DatabaseCredentialRequestStatus
+		requested dbadmin.GrantClass
+		changed   bool
+	}{
+		{"same class", dba.DatabaseCredentialRequestStatus{Class: "readonly", RequestedClass: "readonly"}, dbadmin.GrantClassReadOnly, false},
+		{"class narrowed by the approval policy", dba.DatabaseCredentialRequestStatus{Class: "readonly", RequestedClass: "readwrite"}, dbadmin.GrantClassReadWrite, false},
+		{"class changed in the spec", dba.DatabaseCredentialRequestStatus{Class: "readonly", RequestedClass: "readonly"}, dbadmin.GrantClassReadWrite, true},
+		{"provisioned before the requested class was recorded", dba.DatabaseCredentialRequestStatus{Class: "readwrite"}, dbadmin.GrantClassReadOnly, true},
+		{"never provisioned", dba.DatabaseCredentialRequestStatus{}, dbadmin.GrantClassReadOnly, false},
+	} {
+		request := &dba.DatabaseCredentialRequest{Status: tc.status}
+		if changed := requestedClassChanged(request, tc.requested); changed != tc.changed {
+			t.Errorf("%s: expected changed to be %t", tc.name, tc.changed)
+		}
+	}
+}
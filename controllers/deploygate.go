@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+// appliedChain returns the migrations which lead up to currentDbVersion,
+// newest first. The chain ends early at a migration which was deleted.
+func appliedChain(ctx context.Context, apiClient client.Client, namespace, currentDbVersion string) ([]dba.DatabaseMigration, error) {
+	var chain []dba.DatabaseMigration
+	seen := make(map[string]bool)
+	for name := currentDbVersion; name != "" && !seen[name]; {
+		seen[name] = true
+
+		var migration dba.DatabaseMigration
+		if err := apiClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &migration); err != nil {
+			if apierrs.IsNotFound(err) {
+				break
+			}
+			return nil, fmt.Errorf("Unable to fetch DatabaseMigration (%s): %w", name, err)
+		}
+		chain = append(chain, migration)
+		name = migration.Spec.Previous
+	}
+	return chain, nil
+}
+
+// evaluateAppVersions reports which versions of the app can be rolled out
+// against the schema at currentDbVersion. An app version is safe once the
+// schema version it needs has been reached, as long as no breaking migration
+// was applied after it.
+func evaluateAppVersions(ctx context.Context, apiClient client.Client, db *dba.ManagedDatabase, currentDbVersion string) error {
+	if len(db.Spec.AppVersions) == 0 {
+		db.Status.AppVersions = nil
+		return nil
+	}
+
+	chain, err := appliedChain(ctx, apiClient, db.Namespace, currentDbVersion)
+	if err != nil {
+		return err
+	}
+
+	statuses := make([]dba.AppVersionStatus, 0, len(db.Spec.AppVersions))
+	for _, required := range db.Spec.AppVersions {
+		status := dba.AppVersionStatus{Version: required.Version, SchemaVersion: required.SchemaVersion}
+
+		live := false
+		breaking := ""
+		for _, migration := range chain {
+			if migration.Name == required.SchemaVersion {
+				live = true
+				break
+			}
+			if migration.Spec.Breaking {
+				breaking = migration.Name
+			}
+		}
+
+		switch {
+		case !live:
+			status.Reason = fmt.Sprintf("Schema version %s is not live, the database is at %s", required.SchemaVersion, currentDbVersion)
+		case breaking != "":
+			status.Reason = fmt.Sprintf("Migration %s was applied after %s and is breaking", breaking, required.SchemaVersion)
+		default:
+			status.Safe = true
+		}
+		statuses = append(statuses, status)
+	}
+	db.Status.AppVersions = statuses
+	return nil
+}
+
+// deployGateResponse is returned for every question to the deploy gate
+type deployGateResponse struct {
+	Safe           bool   `json:"safe"`
+	SchemaVersion  string `json:"schemaVersion,omitempty"`
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// DeployGateHandler answers whether a version of an app is safe to roll out,
+// for deploy pipelines which can't read the ManagedDatabase themselves. It
+// serves GET /safe-to-roll/<namespace>/<database>/<app version>, which
+// returns 200 when the version is safe and 409 when it isn't.
+type DeployGateHandler struct {
+	Client client.Client
+}
+
+func (h DeployGateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/safe-to-roll/"), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		http.Error(w, "Expected /safe-to-roll/<namespace>/<database>/<app version>", http.StatusNotFound)
+		return
+	}
+	namespace, name, appVersion := parts[0], parts[1], parts[2]
+
+	var db dba.ManagedDatabase
+	if err := h.Client.Get(r.Context(), types.NamespacedName{Namespace: namespace, Name: name}, &db); err != nil {
+		if apierrs.IsNotFound(err) {
+			http.Error(w, fmt.Sprintf("ManagedDatabase %s/%s does not exist", namespace, name), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Unable to fetch ManagedDatabase", http.StatusInternalServerError)
+		return
+	}
+
+	response := deployGateResponse{
+		CurrentVersion: db.Status.CurrentVersion,
+		Reason:         fmt.Sprintf("App version %s is not listed in the spec.appVersions of %s/%s, or hasn't been evaluated yet", appVersion, namespace, name),
+	}
+	for _, status := range db.Status.AppVersions {
+		if status.Version == appVersion {
+			response.Safe = status.Safe
+			response.SchemaVersion = status.SchemaVersion
+			response.Reason = status.Reason
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !response.Safe {
+		w.WriteHeader(http.StatusConflict)
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/embedded"
+	"github.com/app-sre/dba-operator/pkg/policy"
+)
+
+// embeddedRecheckInterval is how soon the version is read again after an
+// embedded migration has been applied
+const embeddedRecheckInterval = 5 * time.Second
+
+// applyEmbeddedMigration applies the SQL files of a migration which the
+// operator runs itself rather than in a Job, read from its ConfigMap or its
+// bundle, and returns true once they have been applied. The files are only
+// applied if startAllowed is true.
+func (c *ManagedDatabaseController) applyEmbeddedMigration(oneMigration migrationContext, admin dbadmin.DbAdmin, startAllowed bool) (bool, error) {
+	spec := oneMigration.version.Spec.Embedded
+	if oneMigration.db.Spec.MigrationEngine != "embedded" {
+		return false, fmt.Errorf("Embedded migration (%s) requires the embedded migration engine, not %q", oneMigration.version.Name, oneMigration.db.Spec.MigrationEngine)
+	}
+	if (spec.ConfigMap == "") == (oneMigration.version.Spec.Bundle == nil) {
+		return false, fmt.Errorf("Embedded migration (%s) requires either a ConfigMap or a bundle", oneMigration.version.Name)
+	}
+	if !startAllowed {
+		oneMigration.log.Info("Waiting for a maintenance window to apply the migration")
+		return false, nil
+	}
+
+	var data map[string]string
+	source := "configMap/" + spec.ConfigMap
+	if oneMigration.version.Spec.Bundle != nil {
+		pulled, err := c.pullBundle(oneMigration)
+		if err != nil {
+			return false, err
+		}
+		data, source = pulled.Files, "bundle@"+pulled.Digest
+	} else {
+		var configMap corev1.ConfigMap
+		path := types.NamespacedName{Namespace: oneMigration.db.Namespace, Name: spec.ConfigMap}
+		if err := c.Get(oneMigration.ctx, path, &configMap); err != nil {
+			return false, fmt.Errorf("Unable to fetch ConfigMap (%s) of migration (%s): %w", spec.ConfigMap, oneMigration.version.Name, err)
+		}
+		data = configMap.Data
+	}
+
+	files, err := embedded.ParseFiles(data)
+	if err != nil {
+		return false, fmt.Errorf("Unable to read migration (%s): %w", oneMigration.version.Name, err)
+	}
+
+	var statements []string
+	for _, file := range files {
+		statements = append(statements, file.Statements...)
+	}
+	subject := fmt.Sprintf("Migration %s", oneMigration.version.Name)
+	if err := enforcePolicies(c.config.Current().Policies, oneMigration.log, oneMigration.db, subject, policy.Input{Statements: statements}); err != nil {
+		return false, err
+	}
+
+	oneMigration.log.Info("Applying embedded migration", "currentVersion", oneMigration.version.Spec.Previous, "source", source, "files", len(files))
+	start := time.Now()
+	if err := admin.ApplyMigrationFiles(oneMigration.version.Name, files); err != nil {
+		return false, fmt.Errorf("Unable to apply migration (%s): %w", oneMigration.version.Name, err)
+	}
+
+	labels := migrationLabels(oneMigration.db, oneMigration.version.Name)
+	c.metrics.MigrationDuration.With(labels).Set(time.Since(start).Seconds())
+	return true, nil
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/notify"
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// phaseError is an error which occurred in one phase of a reconcile
+type phaseError struct {
+	phase string
+	err   error
+}
+
+// errorCode returns the stable code of the errors from a phase, such as
+// PostMigrationFailed for post-migration
+func errorCode(phase string) string {
+	var code strings.Builder
+	for _, word := range strings.Split(phase, "-") {
+		if word == "" {
+			continue
+		}
+		code.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	code.WriteString("Failed")
+	return code.String()
+}
+
+func isTemporary(err error) bool {
+	var maybeTemporary xerrors.EnhancedError
+	return errors.As(err, &maybeTemporary) && maybeTemporary.Temporary()
+}
+
+func findStatusError(statusErrors []dba.ManagedDatabaseError, code string) *dba.ManagedDatabaseError {
+	for i := range statusErrors {
+		if statusErrors[i].Code == code {
+			return &statusErrors[i]
+		}
+	}
+	return nil
+}
+
+// setCondition replaces the condition of the same type, the transition time
+// is only moved when the status changes
+func setCondition(db *dba.ManagedDatabase, condition dba.ManagedDatabaseCondition) {
+	for i, existing := range db.Status.Conditions {
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		db.Status.Conditions[i] = condition
+		return
+	}
+	db.Status.Conditions = append(db.Status.Conditions, condition)
+}
+
+// markReady clears the errors of the previous reconciles
+func markReady(db *dba.ManagedDatabase, now time.Time) {
+	db.Status.LastErrors = nil
+	setCondition(db, dba.ManagedDatabaseCondition{
+		Type:               dba.ConditionReady,
+		Status:             corev1.ConditionTrue,
+		Reason:             "Reconciled",
+		LastTransitionTime: metav1.NewTime(now),
+	})
+}
+
+// reportErrors records the errors of a reconcile in the status block. An
+// error which was already reported by the previous reconcile is counted
+// instead of being notified again, and the most actionable error, the first
+// one which won't go away by itself, is surfaced in the Ready condition.
+func (c *ManagedDatabaseController) reportErrors(ctx context.Context, db *dba.ManagedDatabase, log logr.Logger, failures []phaseError) (ctrl.Result, error) {
+	now := metav1.Now()
+	backoff := c.config.Current().Backoff
+
+	var retryAfter time.Duration
+	reported := make([]dba.ManagedDatabaseError, 0, len(failures))
+	for _, failure := range failures {
+		statusError := dba.ManagedDatabaseError{
+			Code:      errorCode(failure.phase),
+			Message:   redact.String(failure.err.Error()),
+			Temporary: isTemporary(failure.err),
+			Count:     1,
+			FirstSeen: &now,
+			LastSeen:  &now,
+		}
+
+		previous := findStatusError(db.Status.LastErrors, statusError.Code)
+		if previous != nil {
+			statusError.Count = previous.Count + 1
+			statusError.FirstSeen = previous.FirstSeen
+		}
+
+		if statusError.Temporary {
+			// Returning the error would replace the delay with the rate
+			// limiting of controller-runtime
+			delay := temporaryErrorDelay(backoff, failure.err, db.Status.TemporaryErrorRetries)
+			log.Info("Retrying after temporary error", "code", statusError.Code, "error", statusError.Message, "retryAfter", delay)
+			if retryAfter == 0 || delay < retryAfter {
+				retryAfter = delay
+			}
+		} else if previous == nil || previous.Message != statusError.Message {
+			c.notifier.Notify(notify.Event{
+				Reason:    "ReconcileFailed",
+				Namespace: db.Namespace,
+				Name:      db.Name,
+				Message:   statusError.Code + ": " + statusError.Message,
+			})
+		}
+
+		reported = append(reported, statusError)
+	}
+
+	sort.SliceStable(reported, func(i, j int) bool {
+		return !reported[i].Temporary && reported[j].Temporary
+	})
+	db.Status.LastErrors = reported
+
+	if retryAfter > 0 {
+		db.Status.TemporaryErrorRetries = xerrors.CountRetry(db.Status.TemporaryErrorRetries)
+	}
+
+	if len(reported) > 0 {
+		message := reported[0].Message
+		if len(reported) > 1 {
+			message = fmt.Sprintf("%s (and %d more)", message, len(reported)-1)
+		}
+		setCondition(db, dba.ManagedDatabaseCondition{
+			Type:               dba.ConditionReady,
+			Status:             corev1.ConditionFalse,
+			Reason:             reported[0].Code,
+			Message:            message,
+			LastTransitionTime: now,
+		})
+	}
+
+	recordPerformance(ctx, db, now.Time)
+	regionalResults.recordRegionalPrimaries(db)
+	if err := c.Status().Update(ctx, db); err != nil {
+		log.Error(err, "Unable to update ManagedDatabase status block")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: retryAfter}, nil
+}
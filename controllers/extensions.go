@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+// reconcileExtensions has the backend create the extensions of the spec
+// which don't exist yet, and reports in the ExtensionsReady condition
+// whether they are all installed. Engines without extensions only set the
+// condition, they don't fail the reconcile.
+func (c *ManagedDatabaseController) reconcileExtensions(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, now time.Time) error {
+	if len(db.Spec.Extensions) == 0 {
+		db.Status.InstalledExtensions = nil
+		removeCondition(db, dba.ConditionExtensionsReady)
+		return nil
+	}
+
+	extensions := make([]dbadmin.Extension, 0, len(db.Spec.Extensions))
+	names := make([]string, 0, len(db.Spec.Extensions))
+	for _, extension := range db.Spec.Extensions {
+		if err := dbadmin.CheckVersionConstraint(extension.Version); err != nil {
+			return fmt.Errorf("Invalid version of extension (%s): %w", extension.Name, err)
+		}
+		extensions = append(extensions, dbadmin.Extension{Name: extension.Name, Version: extension.Version})
+		names = append(names, extension.Name)
+	}
+
+	installed, err := admin.EnsureExtensions(extensions)
+	condition := extensionsCondition(db.Spec.Connection.Engine, names, err, now)
+	setCondition(db, condition)
+	if errors.Is(err, dbadmin.ErrExtensionsUnsupported) {
+		// Retrying won't help, and the rest of the reconcile doesn't depend
+		// on the extensions, so only the condition reports it
+		log.Info("Extensions are not supported by the engine", "engine", db.Spec.Connection.Engine)
+		db.Status.InstalledExtensions = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to create extensions: %w", err)
+	}
+
+	log.Info("Extensions are installed", "extensions", installed)
+	db.Status.InstalledExtensions = installed
+	return nil
+}
+
+// extensionsCondition returns the ExtensionsReady condition for the outcome
+// of creating the extensions
+func extensionsCondition(engine string, names []string, err error, now time.Time) dba.ManagedDatabaseCondition {
+	condition := dba.ManagedDatabaseCondition{
+		Type:               dba.ConditionExtensionsReady,
+		Status:             corev1.ConditionTrue,
+		Reason:             "Installed",
+		LastTransitionTime: metav1.NewTime(now),
+	}
+	switch {
+	case errors.Is(err, dbadmin.ErrExtensionsUnsupported):
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "UnsupportedEngine"
+		condition.Message = fmt.Sprintf("Engine %s does not support extensions, %s can't be created", engine, strings.Join(names, ", "))
+	case err != nil:
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "InstallFailed"
+		condition.Message = redact.String(err.Error())
+	}
+	return condition
+}
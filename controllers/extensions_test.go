@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// extensionsAdmin installs the extensions it has versions for
+type extensionsAdmin struct {
+	dbadmin.DbAdmin
+	unsupported bool
+	available   map[string]string
+}
+
+func (ea *extensionsAdmin) EnsureExtensions(extensions []dbadmin.Extension) (map[string]string, error) {
+	if ea.unsupported {
+		return nil, fmt.Errorf("No extensions: %w", dbadmin.ErrExtensionsUnsupported)
+	}
+	installed := make(map[string]string, len(extensions))
+	for _, extension := range extensions {
+		version, ok := ea.available[extension.Name]
+		if !ok {
+			return nil, fmt.Errorf("Extension %s is not available on the server", extension.Name)
+		}
+		installed[extension.Name] = version
+	}
+	return installed, nil
+}
+
+func TestReconcileExtensions(t *testing.T) {
+	extensions := []dba.DatabaseExtension{{Name: "pg_stat_statements"}, {Name: "postgis", Version: ">= 3.1"}}
+	for _, tc := range []struct {
+		name       string
+		engine     string
+		extensions []dba.DatabaseExtension
+		admin      *extensionsAdmin
+		status     corev1.ConditionStatus
+		reason     string
+	}{
+		{
+			name:       "installed",
+			engine:     "postgres",
+			extensions: extensions,
+			admin:      &extensionsAdmin{available: map[string]string{"pg_stat_statements": "1.8", "postgis": "3.1.4"}},
+			status:     corev1.ConditionTrue,
+			reason:     "Installed",
+		},
+		{
+			name:       "unsupported engine",
+			engine:     "mysql",
+			extensions: extensions,
+			admin:      &extensionsAdmin{unsupported: true},
+			status:     corev1.ConditionFalse,
+			reason:     "UnsupportedEngine",
+		},
+		{
+			name:       "not available on the server",
+			engine:     "postgres",
+			extensions: extensions,
+			admin:      &extensionsAdmin{available: map[string]string{"pg_stat_statements": "1.8"}},
+			status:     corev1.ConditionFalse,
+			reason:     "InstallFailed",
+		},
+		{
+			name:   "no extensions",
+			engine: "mysql",
+			admin:  &extensionsAdmin{unsupported: true},
+		},
+	} {
+		db := &dba.ManagedDatabase{Spec: dba.ManagedDatabaseSpec{
+			Connection: dba.DatabaseConnectionInfo{Engine: tc.engine},
+			Extensions: tc.extensions,
+		}}
+		c := &ManagedDatabaseController{}
+
+		err := c.reconcileExtensions(logf.NullLogger{}, tc.admin, db, time.Now())
+		if tc.reason == "InstallFailed" && err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		} else if tc.reason != "InstallFailed" && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+
+		if tc.status == "" {
+			if len(db.Status.Conditions) != 0 {
+				t.Errorf("%s: expected no condition, got %+v", tc.name, db.Status.Conditions)
+			}
+			continue
+		}
+		if len(db.Status.Conditions) != 1 {
+			t.Fatalf("%s: expected one condition, got %+v", tc.name, db.Status.Conditions)
+		}
+		condition := db.Status.Conditions[0]
+		if condition.Type != dba.ConditionExtensionsReady || condition.Status != tc.status || condition.Reason != tc.reason {
+			t.Errorf("%s: unexpected condition %+v", tc.name, condition)
+		}
+		if tc.reason == "UnsupportedEngine" && !strings.Contains(condition.Message, "mysql") {
+			t.Errorf("%s: expected the engine in the message, got %s", tc.name, condition.Message)
+		}
+		if tc.status == corev1.ConditionTrue && db.Status.InstalledExtensions["postgis"] != "3.1.4" {
+			t.Errorf("%s: expected the installed versions in the status, got %v", tc.name, db.Status.InstalledExtensions)
+		}
+	}
+}
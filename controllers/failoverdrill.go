@@ -0,0 +1,419 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/failover"
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/schedule"
+	"github.com/app-sre/dba-operator/pkg/sigv4"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+const operationFailoverDrill = "failover-drill"
+
+// drillPollInterval is how often the database and the credentials are tried
+// while they recover from the failover of a drill
+const drillPollInterval = time.Second
+
+// failoverTrigger returns the trigger of the method, as configured for the
+// operator
+func failoverTrigger(cfg config.FailoverDrills, method string) (failover.Trigger, error) {
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	switch method {
+	case failover.MethodRDSCluster, failover.MethodRDSInstance:
+		if cfg.RDSRegion == "" {
+			return nil, fmt.Errorf("The %s failover drill requires failoverDrills.rdsRegion in the operator config", method)
+		}
+		credentials, err := sigv4.CredentialsFromEnvironment()
+		if err != nil {
+			return nil, err
+		}
+		return failover.RDS{
+			Endpoint:    cfg.RDSEndpoint,
+			Region:      cfg.RDSRegion,
+			Credentials: credentials,
+			Client:      httpClient,
+			Cluster:     method == failover.MethodRDSCluster,
+		}, nil
+	case failover.MethodOrchestrator:
+		if cfg.OrchestratorURL == "" {
+			return nil, errors.New("The orchestrator failover drill requires failoverDrills.orchestratorURL in the operator config")
+		}
+		return failover.Orchestrator{URL: cfg.OrchestratorURL, Client: httpClient}, nil
+	}
+	return nil, fmt.Errorf("Unknown failover drill method: %s", method)
+}
+
+// drillTarget returns what is failed over by the database's drills
+func drillTarget(db *dba.ManagedDatabase) (string, error) {
+	if target := db.Spec.FailoverDrill.Target; target != "" {
+		return target, nil
+	}
+	if identifiers := cloudIdentifiers(db); len(identifiers) > 0 {
+		return identifiers[0], nil
+	}
+	return "", errors.New("The failover drill requires a target, the connection has no cloud identifiers")
+}
+
+// runFailoverDrill fails the primary of the database over and measures how
+// long the operator and the issued credentials take to recover. The result
+// is recorded in the status of the ManagedDatabase, unless the drill can be
+// retried without having triggered the failover.
+func (c *OperationController) runFailoverDrill(ctx context.Context, log logr.Logger, operation *dba.DatabaseOperation, db *dba.ManagedDatabase) (string, error) {
+	if db.Spec.FailoverDrill == nil {
+		return "", fmt.Errorf("ManagedDatabase %s does not approve of failover drills", db.Name)
+	}
+	if triggeredAt := operation.Status.FailoverTriggeredAt; triggeredAt != nil {
+		// Triggering again could fail back to the original primary
+		return "", fmt.Errorf("The drill was interrupted after its failover was triggered at %s, it is not triggered again", triggeredAt.UTC().Format(time.RFC3339))
+	}
+
+	result := dba.FailoverDrillResult{
+		Operation: operation.Name,
+		Method:    db.Spec.FailoverDrill.Method,
+		StartedAt: metav1.NewTime(time.Now().Truncate(time.Second)),
+	}
+	message, err := c.drill(ctx, log, operation, db, &result)
+
+	var maybeTemporary xerrors.EnhancedError
+	if errors.As(err, &maybeTemporary) && maybeTemporary.Temporary() && operation.Status.FailoverTriggeredAt == nil {
+		return "", err
+	}
+
+	result.Succeeded = err == nil
+	result.Message = message
+	if err != nil {
+		result.Message = redact.String(err.Error())
+	}
+	c.recordFailoverDrill(ctx, log, db, result)
+	return message, err
+}
+
+func (c *OperationController) drill(ctx context.Context, log logr.Logger, operation *dba.DatabaseOperation, db *dba.ManagedDatabase, result *dba.FailoverDrillResult) (string, error) {
+	cfg := c.config.Current()
+	target, err := drillTarget(db)
+	if err != nil {
+		return "", err
+	}
+	result.Target = target
+	trigger, err := failoverTrigger(cfg.FailoverDrills, db.Spec.FailoverDrill.Method)
+	if err != nil {
+		return "", err
+	}
+
+	before, err := c.probeOperator(ctx, log, db)
+	if err != nil {
+		return "", xerrors.NewTempErrorf("Unable to query the database before the failover: %s", err.Error())
+	}
+	result.PrimaryBefore = before
+
+	// Recording the trigger first keeps a conflicting update from running
+	// the drill twice
+	triggeredAt := metav1.NewTime(time.Now().Truncate(time.Second))
+	operation.Status.FailoverTriggeredAt = &triggeredAt
+	if err := c.Status().Update(ctx, operation); err != nil {
+		operation.Status.FailoverTriggeredAt = nil
+		return "", xerrors.NewTempErrorf("Unable to record the failover of the drill: %s", err.Error())
+	}
+	result.StartedAt = triggeredAt
+
+	log.Info("Triggering failover", "method", result.Method, "target", target, "primary", before)
+	if err := trigger.Failover(ctx, target); err != nil {
+		return "", err
+	}
+	deadline := triggeredAt.Add(cfg.FailoverDrills.Timeout.Duration)
+
+	after, err := c.awaitOperator(ctx, log, db, before, deadline)
+	if err != nil {
+		return "", err
+	}
+	operatorRecovery := metav1.Duration{Duration: time.Since(triggeredAt.Time).Truncate(time.Second)}
+	result.PrimaryAfter = after
+	result.OperatorRecovery = &operatorRecovery
+	c.metrics.FailoverDrillRecovery.With(prometheus.Labels{"namespace": db.Namespace, "database": db.Name, "stage": "operator"}).Set(operatorRecovery.Seconds())
+
+	summary := fmt.Sprintf("Failed over from %s to %s, the operator recovered after %s", before, after, operatorRecovery.Duration)
+	if after == before {
+		summary = fmt.Sprintf("The primary %s was restarted without changing, the operator recovered after %s", before, operatorRecovery.Duration)
+	}
+
+	probed, err := c.awaitCredentials(ctx, log, db, deadline)
+	if err != nil {
+		return "", fmt.Errorf("%s, but %w", summary, err)
+	}
+	if probed == 0 {
+		return summary + ", no credentials were probed", nil
+	}
+	credentialsRecovery := metav1.Duration{Duration: time.Since(triggeredAt.Time).Truncate(time.Second)}
+	result.CredentialsRecovery = &credentialsRecovery
+	c.metrics.FailoverDrillRecovery.With(prometheus.Labels{"namespace": db.Namespace, "database": db.Name, "stage": "credentials"}).Set(credentialsRecovery.Seconds())
+
+	return fmt.Sprintf("%s and %d credentials after %s", summary, probed, credentialsRecovery.Duration), nil
+}
+
+// probeOperator connects to the database the way every reconcile does, and
+// returns the instance of the primary
+func (c *OperationController) probeOperator(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase) (string, error) {
+	admin, err := initializeAdminConnection(ctx, log, c.diagnostics, c.Client, db.Namespace, &db.Spec)
+	if err != nil {
+		return "", err
+	}
+	defer admin.Close()
+
+	server, err := admin.DetectServer()
+	if err != nil {
+		return "", err
+	}
+	if _, err := admin.GetSchemaVersion(); err != nil {
+		return "", err
+	}
+	return server.Instance, nil
+}
+
+// awaitOperator waits for the operator to query the database again after
+// the failover, which has happened once the primary has changed or the
+// database couldn't be queried for a while
+func (c *OperationController) awaitOperator(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, before string, deadline time.Time) (string, error) {
+	interrupted := false
+	for {
+		instance, err := c.probeOperator(ctx, log, db)
+		switch {
+		case err != nil:
+			interrupted = true
+		case instance != before || interrupted:
+			return instance, nil
+		}
+
+		if time.Now().After(deadline) {
+			if !interrupted {
+				return "", fmt.Errorf("The failover of %s was not observed within %s", before, c.config.Current().FailoverDrills.Timeout.Duration)
+			}
+			return "", fmt.Errorf("The operator did not recover within %s: %w", c.config.Current().FailoverDrills.Timeout.Duration, err)
+		}
+		time.Sleep(drillPollInterval)
+	}
+}
+
+// awaitCredentials waits for every credential issued for the database to log
+// in again, and returns how many there are. Credentials can only be probed
+// with a typed connection spec.
+func (c *OperationController) awaitCredentials(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, deadline time.Time) (int, error) {
+	conn := db.Spec.Connection.Spec
+	checker, ok := endpointCheckers[db.Spec.Connection.Engine]
+	if conn == nil || !ok {
+		log.Info("Not probing the credentials, which requires a typed connection spec and a supported engine")
+		return 0, nil
+	}
+
+	secretList, err := listSecretsForDatabase(ctx, c.Client, db)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to list existing cluster secrets: %w", err)
+	}
+	pending := make(map[string]string)
+	for _, secret := range secretList.Items {
+		username, password := string(secret.Data["username"]), string(secret.Data["password"])
+		for _, prefix := range issuedUsernamePrefixes {
+			if strings.HasPrefix(username, prefix) && password != "" {
+				pending[username] = password
+			}
+		}
+	}
+	probed := len(pending)
+
+	for {
+		var lastErr error
+		for username, password := range pending {
+			spec, err := selectConnectionHost(db.Spec.Connection.Engine, typedConnectionSpec(conn, username, password))
+			if err == nil {
+				err = checker.CheckEndpoint(spec)
+			}
+			if err != nil {
+				lastErr = fmt.Errorf("user %s could not log in: %w", username, err)
+				continue
+			}
+			delete(pending, username)
+		}
+		if len(pending) == 0 {
+			return probed, nil
+		}
+
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("%d credentials did not recover within %s, %w", len(pending), c.config.Current().FailoverDrills.Timeout.Duration, lastErr)
+		}
+		time.Sleep(drillPollInterval)
+	}
+}
+
+// recordFailoverDrill publishes the result of a drill in the status of the
+// ManagedDatabase, which is fetched again because the drill took a while
+func (c *OperationController) recordFailoverDrill(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, result dba.FailoverDrillResult) {
+	c.metrics.FailoverDrills.WithLabelValues(fmt.Sprintf("%t", result.Succeeded)).Inc()
+
+	var latest dba.ManagedDatabase
+	if err := c.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: db.Name}, &latest); err != nil {
+		log.Error(err, "unable to fetch ManagedDatabase to record the failover drill")
+		return
+	}
+	if latest.Status.FailoverDrill == nil {
+		latest.Status.FailoverDrill = &dba.FailoverDrillStatus{}
+	}
+	latest.Status.FailoverDrill.LastDrill = &result
+	if err := c.Status().Update(ctx, &latest); err != nil {
+		log.Error(err, "unable to record the failover drill")
+	}
+}
+
+// failoverDrillScheduleInterval is how often the drill schedules are checked
+const failoverDrillScheduleInterval = time.Minute
+
+// FailoverDrillController creates the failover-drill DatabaseOperations of
+// the databases whose drills are scheduled, which the OperationController
+// then runs
+type FailoverDrillController struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	config config.Provider
+}
+
+// NewFailoverDrillController will instantiate a FailoverDrillController with
+// the supplied arguments
+func NewFailoverDrillController(c client.Client, scheme *runtime.Scheme, l logr.Logger, cfg config.Provider) *FailoverDrillController {
+	return &FailoverDrillController{Client: c, Scheme: scheme, Log: l, config: cfg}
+}
+
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=databaseoperations,verbs=get;list;watch;create
+
+// Start implements manager.Runnable
+func (fc *FailoverDrillController) Start(stop <-chan struct{}) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(failoverDrillScheduleInterval):
+			if err := fc.scheduleDrills(time.Now()); err != nil {
+				fc.Log.Error(err, "Scheduled failover drills did not complete")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (fc *FailoverDrillController) NeedLeaderElection() bool {
+	return true
+}
+
+// nextFailoverDrill returns the first scheduled drill of the database after
+// its latest drill
+func nextFailoverDrill(db *dba.ManagedDatabase) (time.Time, error) {
+	cron, err := schedule.ParseCron(db.Spec.FailoverDrill.Schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid failover drill schedule: %w", err)
+	}
+
+	last := db.CreationTimestamp.Time
+	if status := db.Status.FailoverDrill; status != nil && status.LastDrill != nil {
+		last = status.LastDrill.StartedAt.Time
+	}
+	next := cron.Next(last)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("Failover drill schedule %s never matches", db.Spec.FailoverDrill.Schedule)
+	}
+	return next, nil
+}
+
+// scheduleDrills creates a DatabaseOperation for every database whose drill
+// is due. The operation is named after the scheduled time, so that it is only
+// created once however long it waits to be run.
+func (fc *FailoverDrillController) scheduleDrills(now time.Time) error {
+	ctx := context.Background()
+
+	var allDatabases dba.ManagedDatabaseList
+	if err := fc.List(ctx, &allDatabases); err != nil {
+		return fmt.Errorf("Unable to list ManagedDatabases: %w", err)
+	}
+
+	for i := range allDatabases.Items {
+		db := &allDatabases.Items[i]
+		log := fc.Log.WithValues("manageddatabase", types.NamespacedName{Namespace: db.Namespace, Name: db.Name}, "phase", phaseFailoverDrill)
+
+		if err := applyManagedDatabaseClass(ctx, fc.Client, db); err != nil {
+			log.Error(err, "unable to apply ManagedDatabaseClass")
+			continue
+		}
+		if db.Spec.FailoverDrill == nil || db.Spec.FailoverDrill.Schedule == "" {
+			continue
+		}
+
+		next, err := nextFailoverDrill(db)
+		if err != nil {
+			log.Error(err, "unable to schedule failover drill")
+			continue
+		}
+
+		if !now.Before(next) {
+			if reason := pauseReason(fc.config.Current(), db); reason != "" {
+				log.Info("Delaying scheduled failover drill", "reason", reason)
+			} else if err := fc.createDrill(ctx, log, db, next); err != nil {
+				log.Error(err, "unable to create failover drill")
+			}
+		}
+
+		status := db.Status.FailoverDrill
+		if status == nil {
+			status = &dba.FailoverDrillStatus{}
+		}
+		if status.NextDrillAt != nil && status.NextDrillAt.Time.Equal(next) {
+			continue
+		}
+		nextAt := metav1.NewTime(next)
+		status.NextDrillAt = &nextAt
+		db.Status.FailoverDrill = status
+		if err := fc.Status().Update(ctx, db); err != nil {
+			log.Error(err, "unable to record failover drill schedule")
+		}
+	}
+	return nil
+}
+
+func (fc *FailoverDrillController) createDrill(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, scheduled time.Time) error {
+	operation := dba.DatabaseOperation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-failover-drill-%d", db.Name, scheduled.Unix()),
+			Namespace: db.Namespace,
+		},
+		Spec: dba.DatabaseOperationSpec{
+			ManagedDatabase: db.Name,
+			Action:          operationFailoverDrill,
+		},
+	}
+	if err := ctrl.SetControllerReference(db, &operation, fc.Scheme); err != nil {
+		return fmt.Errorf("Unable to set owner for failover drill (%s): %w", operation.Name, err)
+	}
+
+	if err := fc.Create(ctx, &operation); err != nil {
+		if apierrs.IsAlreadyExists(err) {
+			return nil
+		}
+		return fmt.Errorf("Unable to create failover drill (%s): %w", operation.Name, err)
+	}
+	log.Info("Created scheduled failover drill", "databaseoperation", operation.Name)
+	return nil
+}
@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+	"github.com/app-sre/dba-operator/pkg/notify"
+)
+
+// Kinds of orphaned objects which are found by garbage collection
+const (
+	orphanKindUser   = "user"
+	orphanKindSecret = "secret"
+)
+
+// orphan is a database user or secret which the operator created but which no
+// longer has the object it was created for
+type orphan struct {
+	kind      string
+	namespace string
+	name      string
+	reason    string
+
+	// remove deletes the orphan, it is nil when the orphan must be kept
+	remove func() error
+}
+
+func (o orphan) key() string {
+	return strings.Join([]string{o.kind, o.namespace, o.name}, "/")
+}
+
+// GarbageCollectionController periodically cross-references the database
+// users and secrets created by the operator against the objects they were
+// created for, and reports or removes the ones that have been orphaned.
+type GarbageCollectionController struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	config      config.Provider
+	notifier    notify.Notifier
+	metrics     GarbageCollectionControllerMetrics
+	diagnostics *diagnostics.Recorder
+
+	// suspects contains the orphans found by the previous pass. An orphan
+	// is only removed once it has been seen by two consecutive passes, so
+	// that objects which are halfway through being created are left alone.
+	suspects map[string]bool
+}
+
+// NewGarbageCollectionController will instantiate a
+// GarbageCollectionController with the supplied arguments and logical
+// defaults. diag enables debug mode, see diagnostics.Recorder.
+func NewGarbageCollectionController(
+	c client.Client,
+	scheme *runtime.Scheme,
+	l logr.Logger,
+	diag *diagnostics.Recorder,
+	cfg config.Provider,
+	notifier notify.Notifier,
+) (*GarbageCollectionController, []prometheus.Collector) {
+	metrics := generateGarbageCollectionControllerMetrics()
+
+	return &GarbageCollectionController{
+		Client:      c,
+		Scheme:      scheme,
+		Log:         l,
+		config:      cfg,
+		notifier:    notifier,
+		metrics:     metrics,
+		diagnostics: diag,
+		suspects:    make(map[string]bool),
+	}, getAllMetrics(metrics)
+}
+
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases;databasecredentialrequests,verbs=get;list;watch
+// +kubebuilder:rbac:groups=,resources=secrets,verbs=get;list;delete
+// +kubebuilder:rbac:groups=,resources=pods,verbs=list
+
+// Start implements manager.Runnable. The garbage collection settings are
+// re-read from the config before every pass.
+func (gc *GarbageCollectionController) Start(stop <-chan struct{}) error {
+	for {
+		wait := gc.config.Current().GarbageCollection.Interval.Duration
+		enabled := wait > 0
+		if !enabled {
+			wait = rotationRecheckInterval
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(wait):
+			if !enabled {
+				continue
+			}
+			if err := gc.collect(); err != nil {
+				gc.Log.Error(err, "Garbage collection pass did not complete")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (gc *GarbageCollectionController) NeedLeaderElection() bool {
+	return true
+}
+
+func (gc *GarbageCollectionController) collect() error {
+	var ctx = context.Background()
+	cfg := gc.config.Current()
+	policy := cfg.GarbageCollection.Policy
+	if cfg.SafeMode {
+		policy = config.GCPolicyReport
+	}
+
+	var allDatabases dba.ManagedDatabaseList
+	if err := gc.List(ctx, &allDatabases); err != nil {
+		return fmt.Errorf("Unable to list ManagedDatabases: %w", err)
+	}
+
+	var allRequests dba.DatabaseCredentialRequestList
+	if err := gc.List(ctx, &allRequests); err != nil {
+		return fmt.Errorf("Unable to list DatabaseCredentialRequests: %w", err)
+	}
+
+	var allSecrets corev1.SecretList
+	if err := gc.List(ctx, &allSecrets); err != nil {
+		return fmt.Errorf("Unable to list secrets: %w", err)
+	}
+
+	gc.Log.Info("Starting garbage collection pass", "numDatabases", len(allDatabases.Items), "policy", policy)
+
+	// The connections are kept open until the user orphans are removed
+	var admins []dbadmin.DbAdmin
+	defer func() {
+		for _, admin := range admins {
+			admin.Close()
+		}
+	}()
+
+	orphans := gc.parentlessSecrets(ctx, &allDatabases, &allRequests, &allSecrets)
+	for i := range allDatabases.Items {
+		db := &allDatabases.Items[i]
+		log := gc.Log.WithValues(
+			"manageddatabase", types.NamespacedName{Namespace: db.Namespace, Name: db.Name},
+			"engine", db.Spec.Connection.Engine,
+		)
+
+		if err := applyManagedDatabaseClass(ctx, gc.Client, db); err != nil {
+			log.Error(err, "unable to apply ManagedDatabaseClass")
+			continue
+		}
+
+		admin, err := initializeAdminConnection(ctx, log, gc.diagnostics, gc.Client, db.Namespace, &db.Spec)
+		if err != nil {
+			log.Error(err, "unable to create database connection")
+			continue
+		}
+		admins = append(admins, admin)
+
+		found, err := gc.databaseOrphans(ctx, log, db, admin, &allRequests, &allSecrets)
+		if err != nil {
+			log.Error(err, "unable to check database for orphans")
+			continue
+		}
+		if db.Spec.Paused {
+			// Report the orphans of paused databases but leave them in place
+			for j := range found {
+				found[j].remove = nil
+			}
+		}
+		orphans = append(orphans, found...)
+	}
+
+	if !drainer.Begin("garbage collection") {
+		gc.Log.Info("Skipping report of garbage collection pass, the operator is shutting down")
+		return nil
+	}
+	defer drainer.Done("garbage collection")
+
+	gc.report(orphans, policy)
+	return nil
+}
+
+// parentlessSecrets finds the generated secrets whose ManagedDatabase or
+// DatabaseCredentialRequest no longer exists.
+func (gc *GarbageCollectionController) parentlessSecrets(
+	ctx context.Context,
+	allDatabases *dba.ManagedDatabaseList,
+	allRequests *dba.DatabaseCredentialRequestList,
+	allSecrets *corev1.SecretList,
+) []orphan {
+	databaseUIDs := make(map[string]bool, len(allDatabases.Items))
+	for _, db := range allDatabases.Items {
+		databaseUIDs[string(db.UID)] = true
+	}
+
+	requestUIDs := make(map[string]bool, len(allRequests.Items))
+	for _, request := range allRequests.Items {
+		requestUIDs[string(request.UID)] = true
+	}
+
+	var orphans []orphan
+	for _, secret := range allSecrets.Items {
+		var reason string
+		if uid, ok := secret.Labels["database-uid"]; ok && !databaseUIDs[uid] {
+			reason = "ManagedDatabase no longer exists"
+		} else if uid, ok := secret.Labels["credential-request-uid"]; ok && !requestUIDs[uid] {
+			reason = "DatabaseCredentialRequest no longer exists"
+		} else {
+			continue
+		}
+
+		orphans = append(orphans, gc.secretOrphan(ctx, secret.Namespace, secret.Name, reason))
+	}
+	return orphans
+}
+
+// databaseOrphans finds the users in the database which have no secret or
+// request, and the secrets for the database which have no user.
+func (gc *GarbageCollectionController) databaseOrphans(
+	ctx context.Context,
+	log logr.Logger,
+	db *dba.ManagedDatabase,
+	admin dbadmin.DbAdmin,
+	allRequests *dba.DatabaseCredentialRequestList,
+	allSecrets *corev1.SecretList,
+) ([]orphan, error) {
+	dbUsernames := make(map[string]bool)
+	for _, prefix := range []string{DBUsernamePrefix, CredentialRequestUsernamePrefix} {
+		usernames, err := admin.ListUsernames(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to list existing db usernames: %w", err)
+		}
+		for _, username := range usernames {
+			// LIKE treats the underscore in the prefix as a wildcard
+			if strings.HasPrefix(username, prefix) {
+				dbUsernames[username] = true
+			}
+		}
+	}
+
+	recorded, err := listIssuedUserAttributes(admin)
+	if err != nil {
+		return nil, err
+	}
+
+	secretUsernames := make(map[string]bool)
+	var orphans []orphan
+	for _, secret := range allSecrets.Items {
+		if secret.Namespace != db.Namespace || secret.Labels["database-uid"] != string(db.UID) {
+			continue
+		}
+
+		username := string(secret.Data["username"])
+		secretUsernames[username] = true
+		if !dbUsernames[username] {
+			orphans = append(orphans, gc.secretOrphan(ctx, secret.Namespace, secret.Name, "database user no longer exists"))
+		}
+	}
+
+	requestUsernames := make(map[string]bool)
+	for i := range allRequests.Items {
+		ref := allRequests.Items[i].Spec.ManagedDatabase
+		if ref.Namespace == db.Namespace && ref.Name == db.Name {
+			requestUsernames[credentialRequestUsername(&allRequests.Items[i])] = true
+		}
+	}
+
+	// Quarantined users have no secret, but are dropped by the
+	// ManagedDatabaseController once their quarantine has passed
+	quarantined := quarantinedUsernames(db)
+
+	for username := range dbUsernames {
+		if quarantined[username] || !ownedByDatabase(recorded, db, username) {
+			continue
+		}
+
+		var reason string
+		if strings.HasPrefix(username, CredentialRequestUsernamePrefix) {
+			if requestUsernames[username] {
+				continue
+			}
+			reason = "DatabaseCredentialRequest no longer exists"
+		} else {
+			if secretUsernames[username] {
+				continue
+			}
+			reason = "credentials secret no longer exists"
+		}
+
+		toRemove := username
+		orphans = append(orphans, orphan{
+			kind:      orphanKindUser,
+			namespace: db.Namespace,
+			name:      toRemove,
+			reason:    reason,
+			remove: func() error {
+				unlock, err := lockOperator(log, admin, gc.config.Current().Leases)
+				if err != nil {
+					return err
+				}
+				defer unlock()
+
+				return admin.VerifyUnusedAndDeleteCredentials(toRemove)
+			},
+		})
+	}
+
+	return orphans, nil
+}
+
+func (gc *GarbageCollectionController) secretOrphan(ctx context.Context, namespace, name, reason string) orphan {
+	return orphan{
+		kind:      orphanKindSecret,
+		namespace: namespace,
+		name:      name,
+		reason:    reason,
+		remove: func() error {
+			return deleteSecretIfUnused(ctx, gc.Log, gc.Client, namespace, name)
+		},
+	}
+}
+
+// report logs every orphan and, if the policy allows it, removes the ones
+// which were also found by the previous pass.
+func (gc *GarbageCollectionController) report(orphans []orphan, policy string) {
+	numUsers, numSecrets := 0, 0
+	nextSuspects := make(map[string]bool, len(orphans))
+
+	for _, found := range orphans {
+		log := gc.Log.WithValues("kind", found.kind, "namespace", found.namespace, "name", found.name)
+		log.Info("Found orphan", "reason", found.reason)
+
+		if found.kind == orphanKindUser {
+			numUsers++
+		} else {
+			numSecrets++
+		}
+
+		key := found.key()
+		if policy != config.GCPolicyRemove || found.remove == nil || !gc.suspects[key] {
+			nextSuspects[key] = true
+			continue
+		}
+
+		if err := found.remove(); err != nil {
+			log.Error(err, "unable to remove orphan")
+			nextSuspects[key] = true
+			continue
+		}
+		log.Info("Removed orphan")
+		gc.metrics.OrphansRemoved.Inc()
+	}
+
+	gc.suspects = nextSuspects
+	gc.metrics.OrphanedUsers.Set(float64(numUsers))
+	gc.metrics.OrphanedSecrets.Set(float64(numSecrets))
+
+	if len(orphans) > 0 {
+		gc.notifier.Notify(notify.Event{
+			Reason:  "OrphansFound",
+			Message: fmt.Sprintf("Found %d orphaned database users and %d orphaned secrets", numUsers, numSecrets),
+		})
+	}
+}
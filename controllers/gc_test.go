@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+)
+
+// recordingNotifier keeps the events instead of delivering them
+type recordingNotifier struct {
+	events []notify.Event
+}
+
+func (rn *recordingNotifier) Notify(event notify.Event) {
+	rn.events = append(rn.events, event)
+}
+
+// usersAdmin lists the users of the server like LIKE would, with the
+// underscore of the prefix matching any character
+type usersAdmin struct {
+	dbadmin.DbAdmin
+	usernames  []string
+	attributes map[string]dbadmin.UserAttributes
+}
+
+func (ua *usersAdmin) ListUsernames(prefix string) ([]string, error) {
+	var usernames []string
+	for _, username := range ua.usernames {
+		if len(username) >= len(prefix) && username[:len(prefix)-1] == prefix[:len(prefix)-1] {
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames, nil
+}
+
+func (ua *usersAdmin) ListUserAttributes(prefix string) (map[string]dbadmin.UserAttributes, error) {
+	attributes := make(map[string]dbadmin.UserAttributes)
+	for username, userAttributes := range ua.attributes {
+		if strings.HasPrefix(username, prefix) {
+			attributes[username] = userAttributes
+		}
+	}
+	return attributes, nil
+}
+
+func credentialsSecret(namespace, name, databaseUID, username string) corev1.Secret {
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{"database-uid": databaseUID}},
+		Data:       map[string][]byte{"username": []byte(username)},
+	}
+}
+
+func orphanNames(orphans []orphan) []string {
+	var names []string
+	for _, found := range orphans {
+		names = append(names, fmt.Sprintf("%s %s/%s", found.kind, found.namespace, found.name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestParentlessSecrets(t *testing.T) {
+	gc := &GarbageCollectionController{Log: logf.NullLogger{}}
+	databases := &dba.ManagedDatabaseList{Items: []dba.ManagedDatabase{{ObjectMeta: metav1.ObjectMeta{UID: "db-uid"}}}}
+	requests := &dba.DatabaseCredentialRequestList{Items: []dba.DatabaseCredentialRequest{{ObjectMeta: metav1.ObjectMeta{UID: "request-uid"}}}}
+
+	requestSecret := func(name, uid string) corev1.Secret {
+		return corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "analytics", Name: name, Labels: map[string]string{"credential-request-uid": uid}}}
+	}
+	secrets := &corev1.SecretList{Items: []corev1.Secret{
+		credentialsSecret("quay", "quayio-v1", "db-uid", "dba_v1"),
+		credentialsSecret("quay", "deleted-v1", "deleted-uid", "dba_v1"),
+		requestSecret("reports", "request-uid"),
+		requestSecret("old-reports", "deleted-request-uid"),
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "quay", Name: "unlabeled"}},
+	}}
+
+	orphans := gc.parentlessSecrets(context.Background(), databases, requests, secrets)
+	expected := []string{"secret analytics/old-reports", "secret quay/deleted-v1"}
+	if names := orphanNames(orphans); strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected orphans %v, got %v", expected, names)
+	}
+}
+
+func TestDatabaseOrphans(t *testing.T) {
+	gc := &GarbageCollectionController{Log: logf.NullLogger{}}
+	db := &dba.ManagedDatabase{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "quay", Name: "quayio", UID: "db-uid"},
+		Status: dba.ManagedDatabaseStatus{Quarantined: []dba.QuarantinedUser{
+			{Username: "dba_quarantined", LockedAt: metav1.Now()},
+		}},
+	}
+
+	request := dba.DatabaseCredentialRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "analytics", Name: "reports", UID: "request-uid"},
+		Spec:       dba.DatabaseCredentialRequestSpec{ManagedDatabase: dba.ManagedDatabaseReference{Namespace: "quay", Name: "quayio"}},
+	}
+	requestUsername := credentialRequestUsername(&request)
+	requests := &dba.DatabaseCredentialRequestList{Items: []dba.DatabaseCredentialRequest{request}}
+
+	admin := &usersAdmin{
+		usernames: []string{
+			"dba_v1", "dba_v2", "dba_quarantined", "dba_other_database", "dbaXlookalike",
+			requestUsername, "dbr_deleted",
+		},
+		attributes: map[string]dbadmin.UserAttributes{
+			"dba_other_database": {DatabaseUID: "other-uid"},
+		},
+	}
+	secrets := &corev1.SecretList{Items: []corev1.Secret{
+		credentialsSecret("quay", "quayio-v1", "db-uid", "dba_v1"),
+		credentialsSecret("quay", "quayio-v3", "db-uid", "dba_v3"),
+		credentialsSecret("quay", "other-v1", "other-uid", "dba_v4"),
+	}}
+
+	orphans, err := gc.databaseOrphans(context.Background(), logf.NullLogger{}, db, admin, requests, secrets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"secret quay/quayio-v3", "user quay/dba_v2", "user quay/dbr_deleted"}
+	if names := orphanNames(orphans); strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected orphans %v, got %v", expected, names)
+	}
+}
+
+func TestReportRemovesOrphansSeenTwice(t *testing.T) {
+	notifier := &recordingNotifier{}
+	gc := &GarbageCollectionController{
+		Log:      logf.NullLogger{},
+		notifier: notifier,
+		metrics:  generateGarbageCollectionControllerMetrics(),
+		suspects: map[string]bool{},
+	}
+
+	var removed []string
+	found := func(name string) orphan {
+		return orphan{kind: orphanKindUser, namespace: "quay", name: name, reason: "credentials secret no longer exists", remove: func() error {
+			removed = append(removed, name)
+			return nil
+		}}
+	}
+
+	gc.report([]orphan{found("dba_v1")}, config.GCPolicyRemove)
+	if len(removed) != 0 {
+		t.Errorf("orphans must not be removed the first time they are seen, removed %v", removed)
+	}
+
+	gc.report([]orphan{found("dba_v1"), found("dba_v2")}, config.GCPolicyReport)
+	if len(removed) != 0 {
+		t.Errorf("orphans must not be removed by the report policy, removed %v", removed)
+	}
+
+	gc.report([]orphan{found("dba_v1"), found("dba_v2")}, config.GCPolicyRemove)
+	sort.Strings(removed)
+	if strings.Join(removed, ",") != "dba_v1,dba_v2" {
+		t.Errorf("expected the orphans seen by the previous pass to be removed, removed %v", removed)
+	}
+	if len(notifier.events) != 3 {
+		t.Errorf("expected every pass with orphans to be notified, got %d events", len(notifier.events))
+	}
+}
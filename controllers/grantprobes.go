@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/mysqladmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+)
+
+// grantProbers contains the privilege smoke test for each engine which
+// supports it
+var grantProbers = map[string]dbadmin.GrantProber{
+	"mysql": mysqladmin.GrantProber{},
+}
+
+func describeProbe(probe dbadmin.GrantProbe) string {
+	database := probe.Database
+	if database == "" {
+		database = "the managed database"
+	}
+	return fmt.Sprintf("%s on %s", probe.Privilege, database)
+}
+
+// verifyGrants logs in as a newly provisioned user and records whether the
+// server lets it use exactly the privileges it was meant to get. A failed
+// verification is reported, but doesn't stop the user from being used.
+func (c *ManagedDatabaseController) verifyGrants(log logr.Logger, db *dba.ManagedDatabase, credentials dbadmin.Credentials) {
+	if !db.Spec.VerifyGrants || credentials.Identity != nil {
+		return
+	}
+
+	status := &dba.GrantVerificationStatus{
+		Username:  credentials.Username,
+		Passed:    true,
+		CheckedAt: metav1.Now(),
+	}
+	db.Status.GrantVerification = status
+
+	conn := db.Spec.Connection.Spec
+	prober, ok := grantProbers[db.Spec.Connection.Engine]
+	switch {
+	case conn == nil:
+		status.Passed = false
+		status.Message = "Verifying grants requires a typed connection spec"
+	case !ok:
+		status.Passed = false
+		status.Message = fmt.Sprintf("Database engine %s does not support verifying grants", db.Spec.Connection.Engine)
+	}
+	if !status.Passed {
+		log.Info("Unable to verify grants", "reason", status.Message)
+		return
+	}
+
+	probes := dbadmin.GrantProbes(credentials.Grants)
+	spec, err := selectConnectionHost(db.Spec.Connection.Engine, typedConnectionSpec(conn, credentials.Username, credentials.Password))
+	var results []dbadmin.GrantProbeResult
+	if err == nil {
+		results, err = prober.ProbeGrants(spec, probes)
+	}
+	if err != nil {
+		status.Passed = false
+		status.Message = err.Error()
+	}
+	for _, result := range results {
+		description := describeProbe(result.Probe)
+		status.Probes = append(status.Probes, description)
+		switch {
+		case result.Allowed && !result.Probe.Expected:
+			status.Failures = append(status.Failures, description+" was allowed")
+		case !result.Allowed && result.Probe.Expected:
+			status.Failures = append(status.Failures, description+" was denied")
+		}
+	}
+	if len(status.Failures) > 0 {
+		status.Passed = false
+		status.Message = strings.Join(status.Failures, ", ")
+	}
+	if status.Passed {
+		return
+	}
+
+	log.Info("Issued user does not have the expected privileges", "username", credentials.Username, "reason", status.Message)
+	c.notifier.Notify(notify.Event{
+		Reason:    "GrantVerificationFailed",
+		Namespace: db.Namespace,
+		Name:      db.Name,
+		Message:   fmt.Sprintf("User %s does not have the expected privileges: %s", credentials.Username, status.Message),
+	})
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+const (
+	defaultIntegrityBatchSize = 1000
+	defaultIntegrityInterval  = time.Minute
+)
+
+// reconcileIntegrityChecks scans the next batch of each integrity check which
+// is due, and returns how soon the next batch is due. A check whose batch
+// can't be scanned doesn't hold up the others.
+func (c *ManagedDatabaseController) reconcileIntegrityChecks(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, now time.Time) (time.Duration, error) {
+	if len(db.Spec.IntegrityChecks) == 0 {
+		db.Status.IntegrityChecks = nil
+		return 0, nil
+	}
+
+	recorded := make(map[string]dba.IntegrityCheckStatus, len(db.Status.IntegrityChecks))
+	for _, status := range db.Status.IntegrityChecks {
+		recorded[status.Name] = status
+	}
+
+	var requeue time.Duration
+	var errs []string
+	statuses := make([]dba.IntegrityCheckStatus, 0, len(db.Spec.IntegrityChecks))
+	for _, check := range db.Spec.IntegrityChecks {
+		status, ok := recorded[check.Name]
+		if !ok {
+			status = dba.IntegrityCheckStatus{Name: check.Name}
+		}
+
+		interval := check.Interval.Duration
+		if interval <= 0 {
+			interval = defaultIntegrityInterval
+		}
+		if status.LastScan == nil || !now.Before(status.LastScan.Add(interval)) {
+			if err := c.scanIntegrityBatch(log.WithValues("check", check.Name), admin, db, check, &status, now); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", check.Name, err))
+			}
+		}
+		statuses = append(statuses, status)
+
+		next := interval
+		if status.LastScan != nil {
+			next = status.LastScan.Add(interval).Sub(now)
+		}
+		if requeue == 0 || next < requeue {
+			requeue = next
+		}
+	}
+	db.Status.IntegrityChecks = statuses
+
+	if len(errs) > 0 {
+		return requeue, fmt.Errorf("Unable to scan for orphaned rows: %s", strings.Join(errs, "; "))
+	}
+	return requeue, nil
+}
+
+// scanIntegrityBatch scans the batch after the check's cursor, and starts a
+// new pass once the end of the table is reached
+func (c *ManagedDatabaseController) scanIntegrityBatch(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, check dba.IntegrityCheck, status *dba.IntegrityCheckStatus, now time.Time) error {
+	batchSize := int(check.BatchSize)
+	if batchSize <= 0 {
+		batchSize = defaultIntegrityBatchSize
+	}
+
+	result, err := admin.ScanOrphanedRows(dbadmin.OrphanScan{
+		Table:            check.Table,
+		Column:           check.Column,
+		ReferencedTable:  check.ReferencedTable,
+		ReferencedColumn: check.ReferencedColumn,
+		After:            status.Cursor,
+		Limit:            batchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	scannedAt := metav1.NewTime(now)
+	status.LastScan = &scannedAt
+	status.Scanned += result.Scanned
+	status.Orphaned += result.Orphaned
+	status.Cursor = result.Last
+
+	labels := checkLabels(db, check.Name)
+	c.metrics.IntegrityRowsScanned.With(labels).Add(float64(result.Scanned))
+	c.metrics.IntegrityOrphansFound.With(labels).Add(float64(result.Orphaned))
+	if result.Orphaned > 0 {
+		log.Info("Found orphaned rows", "table", check.Table, "column", check.Column, "orphaned", result.Orphaned, "after", status.Cursor)
+	}
+
+	if result.Scanned < int64(batchSize) {
+		log.Info("Finished a pass over the table", "table", check.Table, "scanned", status.Scanned, "orphaned", status.Orphaned)
+		status.LastPassOrphaned = status.Orphaned
+		status.LastPassCompletedAt = &scannedAt
+		status.Cursor = ""
+		status.Scanned = 0
+		status.Orphaned = 0
+	}
+
+	// Orphans found by the current pass are reported before it completes
+	orphaned := status.LastPassOrphaned
+	if status.Orphaned > orphaned {
+		orphaned = status.Orphaned
+	}
+	c.metrics.IntegrityOrphanedRows.With(labels).Set(float64(orphaned))
+	return nil
+}
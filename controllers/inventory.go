@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/inventory"
+	"github.com/app-sre/dba-operator/pkg/objectstore"
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/sigv4"
+)
+
+// InventoryController periodically exports the inventory of every
+// ManagedDatabase and the credentials issued for it. The inventory is built
+// from what the operator already knows, no database is connected to.
+type InventoryController struct {
+	client.Client
+	Log     logr.Logger
+	Scheme  *runtime.Scheme
+	config  config.Provider
+	metrics InventoryControllerMetrics
+	http    *http.Client
+
+	// capacity supplies the sizes of the databases, when capacity reports
+	// are enabled
+	capacity *CapacityReportController
+}
+
+// NewInventoryController will instantiate an InventoryController with the
+// supplied arguments and logical defaults. The sizes of the databases are
+// taken from the latest measurements of capacity, if it isn't nil.
+func NewInventoryController(
+	c client.Client,
+	scheme *runtime.Scheme,
+	l logr.Logger,
+	cfg config.Provider,
+	capacity *CapacityReportController,
+) (*InventoryController, []prometheus.Collector) {
+	metrics := generateInventoryControllerMetrics()
+
+	return &InventoryController{
+		Client:   c,
+		Scheme:   scheme,
+		Log:      l,
+		config:   cfg,
+		metrics:  metrics,
+		http:     &http.Client{Timeout: 30 * time.Second},
+		capacity: capacity,
+	}, getAllMetrics(metrics)
+}
+
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=databasecredentialrequests,verbs=get;list;watch
+
+// Start implements manager.Runnable. The interval is re-read from the config
+// before every export.
+func (ic *InventoryController) Start(stop <-chan struct{}) error {
+	for {
+		wait := ic.config.Current().Inventory.Interval.Duration
+		enabled := wait > 0
+		if !enabled {
+			wait = rotationRecheckInterval
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(wait):
+			if !enabled {
+				continue
+			}
+			if err := ic.export(time.Now()); err != nil {
+				ic.Log.Error(err, "Inventory export did not complete")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (ic *InventoryController) NeedLeaderElection() bool {
+	return true
+}
+
+func (ic *InventoryController) export(now time.Time) error {
+	ctx := context.Background()
+	cfg := ic.config.Current()
+
+	report, err := ic.collect(ctx, cfg, now)
+	if err != nil {
+		return err
+	}
+	encoded, contentType, err := report.Encode(cfg.Inventory.Format)
+	if err != nil {
+		return err
+	}
+
+	// Both destinations are attempted even if one of them fails
+	var deliveryErr error
+	if url := cfg.Inventory.WebhookURL; url != "" {
+		if err := ic.post(url, contentType, encoded); err != nil {
+			ic.metrics.DeliveryFailures.Inc()
+			deliveryErr = err
+		}
+	}
+	if storage := cfg.Inventory.ObjectStorage; storage != nil {
+		key := fmt.Sprintf("%sinventory-%s.%s", storage.Prefix, now.UTC().Format("20060102T150405Z"), cfg.Inventory.Format)
+		if err := ic.store(ctx, storage, key, contentType, encoded); err != nil {
+			ic.metrics.DeliveryFailures.Inc()
+			deliveryErr = err
+		}
+	}
+	if deliveryErr != nil {
+		return deliveryErr
+	}
+
+	ic.metrics.LastExport.Set(float64(now.Unix()))
+	ic.Log.Info("Exported inventory", "databases", len(report.Databases))
+	return nil
+}
+
+func (ic *InventoryController) collect(ctx context.Context, cfg config.Config, now time.Time) (inventory.Report, error) {
+	var allDatabases dba.ManagedDatabaseList
+	if err := ic.List(ctx, &allDatabases); err != nil {
+		return inventory.Report{}, fmt.Errorf("Unable to list ManagedDatabases: %w", err)
+	}
+
+	var allRequests dba.DatabaseCredentialRequestList
+	if err := ic.List(ctx, &allRequests); err != nil {
+		return inventory.Report{}, fmt.Errorf("Unable to list DatabaseCredentialRequests: %w", err)
+	}
+	requests := make(map[types.NamespacedName][]dba.DatabaseCredentialRequest)
+	for _, request := range allRequests.Items {
+		if !request.Status.Granted {
+			continue
+		}
+		key := types.NamespacedName{Namespace: request.Spec.ManagedDatabase.Namespace, Name: request.Spec.ManagedDatabase.Name}
+		requests[key] = append(requests[key], request)
+	}
+
+	report := inventory.Report{GeneratedAt: now.UTC(), Databases: make([]inventory.Database, 0, len(allDatabases.Items))}
+	for i := range allDatabases.Items {
+		db := &allDatabases.Items[i]
+		if err := applyManagedDatabaseClass(ctx, ic.Client, db); err != nil {
+			ic.Log.Error(err, "unable to apply class, the database is inventoried without it", "manageddatabase", types.NamespacedName{Namespace: db.Namespace, Name: db.Name})
+		}
+		key := types.NamespacedName{Namespace: db.Namespace, Name: db.Name}
+		report.Databases = append(report.Databases, ic.inventoryDatabase(cfg, db, requests[key]))
+	}
+	return report, nil
+}
+
+func (ic *InventoryController) inventoryDatabase(cfg config.Config, db *dba.ManagedDatabase, requests []dba.DatabaseCredentialRequest) inventory.Database {
+	entry := inventory.Database{
+		Namespace:       db.Namespace,
+		Name:            db.Name,
+		Engine:          db.Spec.Connection.Engine,
+		MigrationEngine: db.Spec.MigrationEngine,
+		ServerFlavor:    db.Status.ServerFlavor,
+		ServerVersion:   db.Status.ServerVersion,
+		Instance:        db.Status.Instance,
+		SchemaVersion:   db.Status.CurrentVersion,
+		Paused:          db.Status.Paused,
+	}
+
+	for _, applied := range db.Status.AppliedMigrations {
+		if applied.Version == db.Status.CurrentVersion && applied.After != nil {
+			migratedAt := applied.After.CapturedAt.UTC()
+			entry.LastMigratedAt = &migratedAt
+		}
+	}
+
+	if ic.capacity != nil {
+		if measurement, ok := ic.capacity.latestCapacity(types.NamespacedName{Namespace: db.Namespace, Name: db.Name}); ok {
+			dataBytes, indexBytes, measuredAt := measurement.capacity.DataBytes, measurement.capacity.IndexBytes, measurement.at.UTC()
+			entry.DataBytes, entry.IndexBytes, entry.CapacityMeasuredAt = &dataBytes, &indexBytes, &measuredAt
+		}
+	}
+
+	lastLogins := make(map[string]time.Time, len(db.Status.LastLogins))
+	for _, login := range db.Status.LastLogins {
+		lastLogins[login.Username] = login.LastLogin.UTC()
+	}
+	lastLogin := func(username string) *time.Time {
+		if login, ok := lastLogins[username]; ok {
+			return &login
+		}
+		return nil
+	}
+
+	// The backends grant readwrite on the database itself without grants
+	grants := databaseGrants(&db.Spec, cfg)
+	if len(grants) == 0 {
+		grants = []dbadmin.DatabaseGrant{{Class: dbadmin.GrantClassReadWrite}}
+	}
+	for _, grant := range grants {
+		entry.Credentials = append(entry.Credentials, inventory.Credential{
+			Kind:     inventory.KindAppCredentials,
+			Class:    string(grant.Class),
+			Database: grant.Database,
+		})
+	}
+
+	if db.Status.ReplicaUsername != "" {
+		entry.Credentials = append(entry.Credentials, inventory.Credential{
+			Kind:      inventory.KindReplicaUser,
+			Username:  db.Status.ReplicaUsername,
+			Class:     string(dbadmin.GrantClassReplication),
+			LastLogin: lastLogin(db.Status.ReplicaUsername),
+		})
+	}
+
+	for _, request := range requests {
+		entry.Credentials = append(entry.Credentials, inventory.Credential{
+			Kind:      inventory.KindCredentialRequest,
+			Name:      request.Namespace + "/" + request.Name,
+			Username:  request.Status.Username,
+			Class:     request.Status.Class,
+			LastLogin: lastLogin(request.Status.Username),
+		})
+	}
+
+	return entry
+}
+
+// post delivers the inventory to the webhook
+func (ic *InventoryController) post(url, contentType string, encoded []byte) error {
+	resp, err := ic.http.Post(url, contentType, bytes.NewReader(encoded))
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			err = fmt.Errorf("Webhook responded with status %d", resp.StatusCode)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to deliver inventory: %w", redact.Error(err))
+	}
+	return nil
+}
+
+// store uploads the inventory as a new object, so that every export is kept
+// as evidence
+func (ic *InventoryController) store(ctx context.Context, storage *config.ObjectStorage, key, contentType string, encoded []byte) error {
+	credentials, err := sigv4.CredentialsFromEnvironment()
+	if err != nil {
+		return err
+	}
+
+	bucket := objectstore.Bucket{
+		Endpoint:    storage.Endpoint,
+		Region:      storage.Region,
+		Name:        storage.Bucket,
+		Credentials: credentials,
+		Client:      ic.http,
+	}
+	if err := bucket.Put(ctx, key, contentType, encoded); err != nil {
+		return fmt.Errorf("Unable to store inventory: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/progress"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -24,6 +25,11 @@ func constructJobForMigration(managedDatabase *dba.ManagedDatabase, migration *d
 	containerSpec.Env = append(containerSpec.Env, corev1.EnvVar{Name: "DBA_OP_PROMETHEUS_PUSH_GATEWAY_ADDR", Value: "prom-pushgateway:9091"})
 	containerSpec.Env = append(containerSpec.Env, corev1.EnvVar{Name: "DBA_OP_LABEL_DATABASE", Value: managedDatabase.Name})
 	containerSpec.Env = append(containerSpec.Env, corev1.EnvVar{Name: "DBA_OP_LABEL_MIGRATION", Value: migration.Name})
+	containerSpec.Env = append(containerSpec.Env, corev1.EnvVar{Name: "DBA_OP_PROGRESS_PREFIX", Value: progress.Prefix})
+
+	if managedDatabase.Spec.Galera != nil && managedDatabase.Spec.Galera.RollingSchemaUpgrades {
+		containerSpec.Env = append(containerSpec.Env, corev1.EnvVar{Name: "DBA_OP_WSREP_OSU_METHOD", Value: "RSU"})
+	}
 
 	containerSpec.ImagePullPolicy = "IfNotPresent" // TODO removeme before prod
 
@@ -46,6 +52,18 @@ func constructJobForMigration(managedDatabase *dba.ManagedDatabase, migration *d
 		},
 	}
 
+	if migration.Spec.ImagePullSecret != "" {
+		job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: migration.Spec.ImagePullSecret}}
+	}
+
+	if deadline := migration.Spec.ActiveDeadline; deadline != nil && deadline.Duration > 0 {
+		seconds := int64(deadline.Duration.Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		job.Spec.ActiveDeadlineSeconds = &seconds
+	}
+
 	// TODO figure out a policy for adding annotations and labels
 
 	return job, nil
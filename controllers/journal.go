@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// JournalTokenAnnotation carries the token of the journal entry which
+// dispatched the creation of an object
+const JournalTokenAnnotation = "dbaoperator.app-sre.redhat.com/journal-token"
+
+// Operations which are recorded in the journal
+const (
+	operationDropUser  = "drop-user"
+	operationCreateJob = "create-migration-job"
+)
+
+// beginOperation records the operation in the status block before it is
+// dispatched, and returns the token identifying this dispatch
+func (c *ManagedDatabaseController) beginOperation(ctx context.Context, db *dba.ManagedDatabase, phase, operation, target string) (string, error) {
+	token, err := randPassword()
+	if err != nil {
+		return "", fmt.Errorf("Unable to generate journal token: %w", err)
+	}
+
+	db.Status.Journal = &dba.JournalEntry{
+		Phase:     phase,
+		Operation: operation,
+		Target:    target,
+		Token:     token,
+		Started:   metav1.NewTime(time.Now()),
+	}
+	if err := c.Status().Update(ctx, db); err != nil {
+		return "", fmt.Errorf("Unable to journal %s of %s: %w", operation, target, err)
+	}
+	return token, nil
+}
+
+// endOperation clears the journal once the operation is known to have
+// completed. The cleared journal is written with the rest of the status, if
+// that is lost the next reconcile verifies the operation again.
+func endOperation(db *dba.ManagedDatabase) {
+	db.Status.Journal = nil
+}
+
+// recoverJournal verifies the outcome of an operation which was interrupted
+// before it could be confirmed, so that the rest of the reconcile starts
+// from what actually happened
+func (c *ManagedDatabaseController) recoverJournal(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase) error {
+	entry := db.Status.Journal
+	if entry == nil {
+		return nil
+	}
+	log = log.WithValues("operation", entry.Operation, "target", entry.Target, "started", entry.Started)
+
+	switch entry.Operation {
+	case operationDropUser:
+		usernames, err := admin.ListUsernames(entry.Target)
+		if err != nil {
+			return fmt.Errorf("Unable to verify journaled %s: %w", entry.Operation, err)
+		}
+		if containsString(usernames, entry.Target) {
+			log.Info("Interrupted operation did not complete, it will be retried")
+		} else {
+			log.Info("Interrupted operation completed")
+		}
+	case operationCreateJob:
+		var job batchv1.Job
+		err := c.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: entry.Target}, &job)
+		switch {
+		case err == nil && job.Annotations[JournalTokenAnnotation] == entry.Token:
+			log.Info("Interrupted operation completed")
+		case err == nil:
+			log.Info("Job was created by a different dispatch")
+		case apierrs.IsNotFound(err):
+			log.Info("Interrupted operation did not complete, it will be retried")
+		default:
+			return fmt.Errorf("Unable to verify journaled %s: %w", entry.Operation, err)
+		}
+	default:
+		// Operations which are safe to repeat only need to be logged
+		log.Info("Interrupted operation will be repeated")
+	}
+
+	endOperation(db)
+	return nil
+}
@@ -0,0 +1,327 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+	"github.com/app-sre/dba-operator/pkg/leastprivilege"
+	"github.com/app-sre/dba-operator/pkg/notify"
+	"github.com/app-sre/dba-operator/pkg/privdiff"
+)
+
+// LeastPrivilegeController periodically samples which privileges the users
+// issued by the operator exercise, and suggests revoking the ones which went
+// unused for the configured window. The suggestions are only revoked once
+// their hash is approved in the spec of the ManagedDatabase.
+type LeastPrivilegeController struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	config      config.Provider
+	metrics     LeastPrivilegeControllerMetrics
+	diagnostics *diagnostics.Recorder
+	notifier    notify.Notifier
+
+	// samples contains the statement counter of each privilege of each user
+	// at the previous sample, keyed by namespace, database, username and
+	// privilege
+	samples map[string]int64
+}
+
+// NewLeastPrivilegeController will instantiate a LeastPrivilegeController
+// with the supplied arguments and logical defaults. diag enables debug mode,
+// see diagnostics.Recorder.
+func NewLeastPrivilegeController(
+	c client.Client,
+	scheme *runtime.Scheme,
+	l logr.Logger,
+	diag *diagnostics.Recorder,
+	cfg config.Provider,
+	notifier notify.Notifier,
+) (*LeastPrivilegeController, []prometheus.Collector) {
+	metrics := generateLeastPrivilegeControllerMetrics()
+
+	return &LeastPrivilegeController{
+		Client:      c,
+		Scheme:      scheme,
+		Log:         l,
+		config:      cfg,
+		metrics:     metrics,
+		diagnostics: diag,
+		notifier:    notifier,
+		samples:     make(map[string]int64),
+	}, getAllMetrics(metrics)
+}
+
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases/status,verbs=get;update;patch
+
+// Start implements manager.Runnable. The sampling interval is re-read from
+// the config before every pass.
+func (lpc *LeastPrivilegeController) Start(stop <-chan struct{}) error {
+	for {
+		wait := lpc.config.Current().LeastPrivilege.Interval.Duration
+		enabled := wait > 0
+		if !enabled {
+			wait = rotationRecheckInterval
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(wait):
+			if !enabled {
+				continue
+			}
+			if err := lpc.sample(time.Now()); err != nil {
+				lpc.Log.Error(err, "Privilege sampling pass did not complete")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (lpc *LeastPrivilegeController) NeedLeaderElection() bool {
+	return true
+}
+
+func (lpc *LeastPrivilegeController) sample(now time.Time) error {
+	ctx := context.Background()
+
+	var allDatabases dba.ManagedDatabaseList
+	if err := lpc.List(ctx, &allDatabases); err != nil {
+		return fmt.Errorf("Unable to list ManagedDatabases: %w", err)
+	}
+
+	// Start from scratch so that deleted databases stop being reported
+	lpc.metrics.Suggestions.Reset()
+
+	// The status block only keeps seconds, so that unchanged usage compares
+	// equal to what was read back
+	now = now.Truncate(time.Second)
+
+	nextSamples := make(map[string]int64, len(lpc.samples))
+	for i := range allDatabases.Items {
+		db := &allDatabases.Items[i]
+		log := lpc.Log.WithValues("manageddatabase", types.NamespacedName{Namespace: db.Namespace, Name: db.Name})
+
+		if err := lpc.sampleDatabase(ctx, log, db, now, nextSamples); err != nil {
+			log.Error(err, "unable to sample privilege use")
+			lpc.metrics.SampleFailures.Inc()
+
+			// Keep the previous samples so that use in the meantime is
+			// still noticed by the next pass
+			for key, total := range lpc.samples {
+				if strings.HasPrefix(key, sampleKey(db, "")) {
+					nextSamples[key] = total
+				}
+			}
+		}
+
+		if tightening := db.Status.PrivilegeTightening; tightening != nil {
+			lpc.metrics.Suggestions.With(prometheus.Labels{
+				"namespace": db.Namespace,
+				"database":  db.Name,
+			}).Set(float64(len(tightening.Suggestions)))
+		}
+	}
+	lpc.samples = nextSamples
+
+	return nil
+}
+
+func (lpc *LeastPrivilegeController) sampleDatabase(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, now time.Time, nextSamples map[string]int64) error {
+	if err := applyManagedDatabaseClass(ctx, lpc.Client, db); err != nil {
+		return err
+	}
+
+	admin, err := initializeAdminConnection(ctx, log, lpc.diagnostics, lpc.Client, db.Namespace, &db.Spec)
+	if err != nil {
+		return fmt.Errorf("Unable to create database connection: %w", err)
+	}
+	defer admin.Close()
+
+	held := make(map[string][]dbadmin.UserPrivilege)
+	current := make(map[string]int64)
+	for _, prefix := range issuedUsernamePrefixes {
+		privileges, err := admin.ListUserPrivileges(prefix)
+		if err != nil {
+			return err
+		}
+		for username, userPrivileges := range privileges {
+			held[username] = userPrivileges
+		}
+
+		use, err := admin.ListPrivilegeUse(prefix)
+		if err != nil {
+			return err
+		}
+		for _, one := range use {
+			current[leastprivilege.CounterKey(one.Username, one.Privilege)] = one.Statements
+		}
+	}
+
+	previous := make(map[string]int64)
+	for key, total := range lpc.samples {
+		if strings.HasPrefix(key, sampleKey(db, "")) {
+			previous[strings.TrimPrefix(key, sampleKey(db, ""))] = total
+		}
+	}
+	for key, total := range current {
+		nextSamples[sampleKey(db, key)] = total
+	}
+
+	cfg := lpc.config.Current()
+	usage := leastprivilege.Observe(recordedPrivilegeUsage(db), held, previous, current, now)
+	suggestions := leastprivilege.Suggest(usage, held, cfg.LeastPrivilege.Window.Duration, now)
+	hash := leastprivilege.Hash(suggestions)
+
+	if len(suggestions) > 0 && db.Spec.PrivilegeTightening != nil && db.Spec.PrivilegeTightening.ApprovedHash == hash {
+		if reason := pauseReason(cfg, db); reason != "" {
+			log.Info("Not revoking approved privileges", "reason", reason)
+		} else {
+			usage, err = lpc.revokeSuggested(log, admin, db, usage, suggestions, hash)
+			if err != nil {
+				return err
+			}
+			suggestions = nil
+		}
+	}
+
+	before := db.Status.DeepCopy()
+	db.Status.PrivilegeUsage = privilegeUsageStatus(usage)
+	lpc.publishSuggestions(log, db, suggestions, hash, now)
+
+	if apiequality.Semantic.DeepEqual(before, &db.Status) {
+		return nil
+	}
+	if err := lpc.Status().Update(ctx, db); err != nil {
+		return fmt.Errorf("Unable to update ManagedDatabase status block: %w", err)
+	}
+	return nil
+}
+
+// revokeSuggested takes the approved privileges away, and stops watching
+// them
+func (lpc *LeastPrivilegeController) revokeSuggested(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, usage []leastprivilege.Usage, suggestions []leastprivilege.Suggestion, hash string) ([]leastprivilege.Usage, error) {
+	if !drainer.Begin("privilege tightening") {
+		return usage, fmt.Errorf("Not revoking privileges while the operator shuts down")
+	}
+	defer drainer.Done("privilege tightening")
+
+	revoked := make(map[string]bool, len(suggestions))
+	var lines []string
+	for _, suggestion := range suggestions {
+		if err := admin.RevokePrivileges(suggestion.Username, suggestion.Revoke); err != nil {
+			return usage, err
+		}
+		revoked[leastprivilege.CounterKey(suggestion.Username, suggestion.Privilege)] = true
+		lpc.metrics.Revocations.Add(float64(len(suggestion.Revoke)))
+		lines = append(lines, fmt.Sprintf("%s: %s", suggestion.Username, suggestion.Privilege))
+	}
+	log.Info("Revoked approved privileges", "hash", hash, "privileges", len(revoked))
+
+	lpc.notifier.Notify(notify.Event{
+		Reason:    "PrivilegesRevoked",
+		Namespace: db.Namespace,
+		Name:      db.Name,
+		Message:   fmt.Sprintf("Revoked the unused privileges with hash %s:\n%s", hash, strings.Join(lines, "\n")),
+	})
+
+	kept := make([]leastprivilege.Usage, 0, len(usage))
+	for _, one := range usage {
+		if !revoked[leastprivilege.CounterKey(one.Username, one.Privilege)] {
+			kept = append(kept, one)
+		}
+	}
+	return kept, nil
+}
+
+// publishSuggestions records the suggestions in the status block, and
+// announces them whenever they change
+func (lpc *LeastPrivilegeController) publishSuggestions(log logr.Logger, db *dba.ManagedDatabase, suggestions []leastprivilege.Suggestion, hash string, now time.Time) {
+	if len(suggestions) == 0 {
+		db.Status.PrivilegeTightening = nil
+		return
+	}
+	if existing := db.Status.PrivilegeTightening; existing != nil && existing.Hash == hash {
+		return
+	}
+
+	tightening := &dba.PrivilegeTightening{Hash: hash, ComputedAt: metav1.NewTime(now)}
+	var lines []string
+	for _, suggestion := range suggestions {
+		revoke := make([]string, 0, len(suggestion.Revoke))
+		for _, privilege := range suggestion.Revoke {
+			revoke = append(revoke, privdiff.FormatPrivilege(privilege))
+		}
+		tightening.Suggestions = append(tightening.Suggestions, dba.PrivilegeSuggestion{
+			Username:    suggestion.Username,
+			Privilege:   suggestion.Privilege,
+			UnusedSince: metav1.NewTime(suggestion.UnusedSince),
+			Revoke:      revoke,
+		})
+		lines = append(lines, fmt.Sprintf("%s: %s unused since %s", suggestion.Username, suggestion.Privilege, suggestion.UnusedSince.Format(time.RFC3339)))
+	}
+	db.Status.PrivilegeTightening = tightening
+
+	log.Info("Unused privileges can be revoked", "hash", hash, "suggestions", len(suggestions))
+	lpc.notifier.Notify(notify.Event{
+		Reason:    "PrivilegeTighteningSuggested",
+		Namespace: db.Namespace,
+		Name:      db.Name,
+		Message:   fmt.Sprintf("Unused privileges can be revoked by approving hash %s:\n%s", hash, strings.Join(lines, "\n")),
+	})
+}
+
+func recordedPrivilegeUsage(db *dba.ManagedDatabase) []leastprivilege.Usage {
+	usage := make([]leastprivilege.Usage, 0, len(db.Status.PrivilegeUsage))
+	for _, recorded := range db.Status.PrivilegeUsage {
+		one := leastprivilege.Usage{
+			Username:  recorded.Username,
+			Privilege: recorded.Privilege,
+			Since:     recorded.Since.Time,
+		}
+		if recorded.LastUsed != nil {
+			lastUsed := recorded.LastUsed.Time
+			one.LastUsed = &lastUsed
+		}
+		usage = append(usage, one)
+	}
+	return usage
+}
+
+func privilegeUsageStatus(usage []leastprivilege.Usage) []dba.PrivilegeUsage {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	status := make([]dba.PrivilegeUsage, 0, len(usage))
+	for _, one := range usage {
+		recorded := dba.PrivilegeUsage{
+			Username:  one.Username,
+			Privilege: one.Privilege,
+			Since:     metav1.NewTime(one.Since),
+		}
+		if one.LastUsed != nil {
+			lastUsed := metav1.NewTime(*one.LastUsed)
+			recorded.LastUsed = &lastUsed
+		}
+		status = append(status, recorded)
+	}
+	return status
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/leastprivilege"
+)
+
+// revokingAdmin records the privileges it takes away
+type revokingAdmin struct {
+	dbadmin.DbAdmin
+	revoked map[string][]dbadmin.UserPrivilege
+	fail    bool
+}
+
+func (ra *revokingAdmin) RevokePrivileges(username string, privileges []dbadmin.UserPrivilege) error {
+	if ra.fail {
+		return fmt.Errorf("Unable to revoke privileges")
+	}
+	ra.revoked[username] = append(ra.revoked[username], privileges...)
+	return nil
+}
+
+func TestPrivilegeUsageStatusRoundTrip(t *testing.T) {
+	since := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	lastUsed := since.Add(48 * time.Hour)
+	usage := []leastprivilege.Usage{
+		{Username: "dba_v1", Privilege: "DELETE", Since: since},
+		{Username: "dba_v1", Privilege: "SELECT", Since: since, LastUsed: &lastUsed},
+	}
+
+	db := &dba.ManagedDatabase{Status: dba.ManagedDatabaseStatus{PrivilegeUsage: privilegeUsageStatus(usage)}}
+	recorded := recordedPrivilegeUsage(db)
+	if len(recorded) != 2 || recorded[0].LastUsed != nil || recorded[1].LastUsed == nil || !recorded[1].LastUsed.Equal(lastUsed) || !recorded[0].Since.Equal(since) {
+		t.Errorf("usage must survive the status block, got %+v", recorded)
+	}
+	if privilegeUsageStatus(nil) != nil {
+		t.Error("no usage must clear the status block")
+	}
+}
+
+func TestPublishSuggestions(t *testing.T) {
+	notifier := &recordingNotifier{}
+	lpc := &LeastPrivilegeController{notifier: notifier, metrics: generateLeastPrivilegeControllerMetrics()}
+	db := &dba.ManagedDatabase{}
+	now := time.Now()
+
+	suggestions := []leastprivilege.Suggestion{{
+		Username:    "dba_v1",
+		Privilege:   "DELETE",
+		UnusedSince: now.Add(-31 * 24 * time.Hour),
+		Revoke:      []dbadmin.UserPrivilege{{Database: "quay", Privilege: "DELETE"}},
+	}}
+	hash := leastprivilege.Hash(suggestions)
+
+	lpc.publishSuggestions(logf.NullLogger{}, db, suggestions, hash, now)
+	tightening := db.Status.PrivilegeTightening
+	if tightening == nil || tightening.Hash != hash || len(tightening.Suggestions) != 1 || len(tightening.Suggestions[0].Revoke) != 1 {
+		t.Fatalf("expected the suggestion in the status block, got %+v", tightening)
+	}
+
+	lpc.publishSuggestions(logf.NullLogger{}, db, suggestions, hash, now.Add(time.Hour))
+	if len(notifier.events) != 1 || !db.Status.PrivilegeTightening.ComputedAt.Time.Equal(tightening.ComputedAt.Time) {
+		t.Errorf("unchanged suggestions must not be announced again, got %d events", len(notifier.events))
+	}
+
+	lpc.publishSuggestions(logf.NullLogger{}, db, nil, leastprivilege.Hash(nil), now)
+	if db.Status.PrivilegeTightening != nil {
+		t.Errorf("suggestions must be cleared once there are none, got %+v", db.Status.PrivilegeTightening)
+	}
+}
+
+func TestRevokeSuggested(t *testing.T) {
+	now := time.Now()
+	usage := []leastprivilege.Usage{
+		{Username: "dba_v1", Privilege: "DELETE", Since: now},
+		{Username: "dba_v1", Privilege: "SELECT", Since: now},
+	}
+	suggestions := []leastprivilege.Suggestion{{
+		Username:  "dba_v1",
+		Privilege: "DELETE",
+		Revoke:    []dbadmin.UserPrivilege{{Database: "quay", Privilege: "DELETE"}},
+	}}
+	db := &dba.ManagedDatabase{}
+
+	notifier := &recordingNotifier{}
+	lpc := &LeastPrivilegeController{notifier: notifier, metrics: generateLeastPrivilegeControllerMetrics()}
+	admin := &revokingAdmin{revoked: map[string][]dbadmin.UserPrivilege{}}
+	kept, err := lpc.revokeSuggested(logf.NullLogger{}, admin, db, usage, suggestions, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(admin.revoked["dba_v1"]) != 1 || admin.revoked["dba_v1"][0].Privilege != "DELETE" {
+		t.Errorf("expected DELETE to be revoked, got %v", admin.revoked)
+	}
+	if len(kept) != 1 || kept[0].Privilege != "SELECT" {
+		t.Errorf("revoked privileges must no longer be tracked, got %+v", kept)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Reason != "PrivilegesRevoked" {
+		t.Errorf("expected the revocation to be announced, got %+v", notifier.events)
+	}
+
+	failing := &revokingAdmin{fail: true}
+	kept, err = lpc.revokeSuggested(logf.NullLogger{}, failing, db, usage, suggestions, "hash")
+	if err == nil || len(kept) != 2 {
+		t.Errorf("a failed revocation must keep tracking every privilege, got %+v, %v", kept, err)
+	}
+}
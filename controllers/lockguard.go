@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+)
+
+// Metadata lock guard policies
+const (
+	lockGuardPolicyAlert       = "alert"
+	lockGuardPolicyPause       = "pause"
+	lockGuardPolicyKillBlocker = "kill-blocker"
+)
+
+const (
+	defaultLockGuardMaxWait       = 30 * time.Second
+	defaultLockGuardCheckInterval = 10 * time.Second
+)
+
+// guardMetadataLocks looks for schema changes which have been waiting on a
+// metadata lock for too long, and applies the database's policy to them. It
+// returns how long to wait before checking again.
+func (c *ManagedDatabaseController) guardMetadataLocks(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase) (time.Duration, error) {
+	guard := db.Spec.MetadataLockGuard
+
+	maxWait := guard.MaxWait.Duration
+	if maxWait <= 0 {
+		maxWait = defaultLockGuardMaxWait
+	}
+	checkInterval := guard.CheckInterval.Duration
+	if checkInterval <= 0 {
+		checkInterval = defaultLockGuardCheckInterval
+	}
+
+	waits, err := admin.ListMetadataLockWaits()
+	if err != nil {
+		return 0, err
+	}
+
+	var stalled []dbadmin.LockWait
+	for _, wait := range waits {
+		if wait.Exclusive && wait.Wait >= maxWait {
+			stalled = append(stalled, wait)
+		}
+	}
+	if len(stalled) == 0 {
+		return checkInterval, nil
+	}
+
+	c.metrics.MetadataLockPileUps.Inc()
+	log.Info(
+		"Schema change is stalled behind a metadata lock",
+		"table", stalled[0].Table,
+		"numWaiting", len(waits),
+		"blockingSession", stalled[0].BlockingSession,
+		"policy", guard.Policy,
+	)
+	c.notifier.Notify(notify.Event{
+		Reason:    "MetadataLockPileUp",
+		Namespace: db.Namespace,
+		Name:      db.Name,
+		Message: fmt.Sprintf(
+			"A schema change on %s has waited %s for a metadata lock held by session %d (%s), %d sessions are queued, applying policy %s",
+			stalled[0].Table,
+			stalled[0].Wait,
+			stalled[0].BlockingSession,
+			stalled[0].BlockingAccount,
+			len(waits),
+			guard.Policy,
+		),
+	})
+
+	killed := make(map[int64]bool)
+	for _, wait := range stalled {
+		var session int64
+		var queryOnly bool
+		switch guard.Policy {
+		case lockGuardPolicyPause:
+			session, queryOnly = wait.WaitingSession, true
+		case lockGuardPolicyKillBlocker:
+			session, queryOnly = wait.BlockingSession, false
+		case lockGuardPolicyAlert:
+			continue
+		default:
+			return checkInterval, fmt.Errorf("Unknown metadata lock guard policy: %s", guard.Policy)
+		}
+
+		if killed[session] {
+			continue
+		}
+
+		log.Info("Killing session to release metadata lock queue", "session", session, "queryOnly", queryOnly)
+		if err := admin.KillSession(session, queryOnly); err != nil {
+			return checkInterval, err
+		}
+		killed[session] = true
+		c.metrics.SessionsKilled.Inc()
+	}
+
+	return checkInterval, nil
+}
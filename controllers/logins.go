@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/cardinality"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+)
+
+// issuedUsernamePrefixes are the prefixes of every user the operator creates
+var issuedUsernamePrefixes = []string{DBUsernamePrefix, CredentialRequestUsernamePrefix, ReplicaUsernamePrefix}
+
+// LoginTrackingController periodically samples the connection counters of
+// the users issued by the operator, and records when each was last seen
+// logging in. A user is seen when it has a session open, or when it has
+// opened sessions since the previous sample.
+type LoginTrackingController struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	config      config.Provider
+	metrics     LoginTrackingControllerMetrics
+	diagnostics *diagnostics.Recorder
+
+	// samples contains the total connections of each user at the previous
+	// sample, keyed by namespace, database and username
+	samples map[string]int64
+}
+
+// NewLoginTrackingController will instantiate a LoginTrackingController with
+// the supplied arguments and logical defaults. diag enables debug mode, see
+// diagnostics.Recorder.
+func NewLoginTrackingController(
+	c client.Client,
+	scheme *runtime.Scheme,
+	l logr.Logger,
+	diag *diagnostics.Recorder,
+	cfg config.Provider,
+) (*LoginTrackingController, []prometheus.Collector) {
+	metrics := generateLoginTrackingControllerMetrics()
+
+	return &LoginTrackingController{
+		Client:      c,
+		Scheme:      scheme,
+		Log:         l,
+		config:      cfg,
+		metrics:     metrics,
+		diagnostics: diag,
+		samples:     make(map[string]int64),
+	}, getAllMetrics(metrics)
+}
+
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases/status,verbs=get;update;patch
+
+// Start implements manager.Runnable. The sampling interval is re-read from
+// the config before every pass.
+func (ltc *LoginTrackingController) Start(stop <-chan struct{}) error {
+	for {
+		wait := ltc.config.Current().LoginTracking.Interval.Duration
+		enabled := wait > 0
+		if !enabled {
+			wait = rotationRecheckInterval
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(wait):
+			if !enabled {
+				continue
+			}
+			if err := ltc.sample(time.Now()); err != nil {
+				ltc.Log.Error(err, "Login sampling pass did not complete")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (ltc *LoginTrackingController) NeedLeaderElection() bool {
+	return true
+}
+
+func (ltc *LoginTrackingController) sample(now time.Time) error {
+	ctx := context.Background()
+
+	var allDatabases dba.ManagedDatabaseList
+	if err := ltc.List(ctx, &allDatabases); err != nil {
+		return fmt.Errorf("Unable to list ManagedDatabases: %w", err)
+	}
+
+	// Start from scratch so that removed users stop being reported
+	ltc.metrics.LastLogin.Reset()
+
+	nextSamples := make(map[string]int64, len(ltc.samples))
+	for i := range allDatabases.Items {
+		db := &allDatabases.Items[i]
+		log := ltc.Log.WithValues("manageddatabase", types.NamespacedName{Namespace: db.Namespace, Name: db.Name})
+
+		if err := ltc.sampleDatabase(ctx, log, db, now, nextSamples); err != nil {
+			log.Error(err, "unable to sample logins")
+			ltc.metrics.SampleFailures.Inc()
+
+			// Keep the previous samples so that logins in the meantime are
+			// still noticed by the next pass
+			for key, total := range ltc.samples {
+				if strings.HasPrefix(key, sampleKey(db, "")) {
+					nextSamples[key] = total
+				}
+			}
+		}
+
+		ltc.exportLastLogins(db)
+	}
+	ltc.samples = nextSamples
+
+	return nil
+}
+
+// exportLastLogins records when each user was last seen. Within the series
+// budget the users which have been idle the longest are reported on their
+// own, the rest are reported together by the oldest of their logins.
+func (ltc *LoginTrackingController) exportLastLogins(db *dba.ManagedDatabase) {
+	ranks := make(map[string]float64, len(db.Status.LastLogins))
+	lastLogins := make(map[string]float64, len(db.Status.LastLogins))
+	for _, login := range db.Status.LastLogins {
+		ranks[login.Username] = -float64(login.LastLogin.Unix())
+		lastLogins[login.Username] = float64(login.LastLogin.Unix())
+	}
+
+	budget := ltc.config.Current().Metrics.UserBudget()
+	for username, lastLogin := range budget.Fold(lastLogins, budget.Select(ranks), cardinality.Min) {
+		ltc.metrics.LastLogin.With(prometheus.Labels{
+			"namespace": db.Namespace,
+			"database":  db.Name,
+			"username":  username,
+		}).Set(lastLogin)
+	}
+}
+
+func (ltc *LoginTrackingController) sampleDatabase(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, now time.Time, nextSamples map[string]int64) error {
+	if err := applyManagedDatabaseClass(ctx, ltc.Client, db); err != nil {
+		return err
+	}
+
+	admin, err := initializeAdminConnection(ctx, log, ltc.diagnostics, ltc.Client, db.Namespace, &db.Spec)
+	if err != nil {
+		return fmt.Errorf("Unable to create database connection: %w", err)
+	}
+	defer admin.Close()
+
+	existing := make(map[string]bool)
+	var activity []dbadmin.AccountActivity
+	for _, prefix := range issuedUsernamePrefixes {
+		usernames, err := admin.ListUsernames(prefix)
+		if err != nil {
+			return err
+		}
+		for _, username := range usernames {
+			existing[username] = true
+		}
+
+		found, err := admin.ListAccountActivity(prefix)
+		if err != nil {
+			return err
+		}
+		activity = append(activity, found...)
+	}
+
+	lastLogins := make(map[string]metav1.Time, len(db.Status.LastLogins))
+	for _, login := range db.Status.LastLogins {
+		if existing[login.Username] {
+			lastLogins[login.Username] = login.LastLogin
+		}
+	}
+	changed := len(lastLogins) != len(db.Status.LastLogins)
+
+	for _, account := range activity {
+		key := sampleKey(db, account.Username)
+		previous, sampled := ltc.samples[key]
+		nextSamples[key] = account.TotalConnections
+
+		// The counters start from zero again when the server restarts, so
+		// any change at all means that there were new sessions
+		if account.CurrentConnections > 0 || (sampled && account.TotalConnections != previous && account.TotalConnections > 0) {
+			lastLogins[account.Username] = metav1.NewTime(now)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	usernames := make([]string, 0, len(lastLogins))
+	for username := range lastLogins {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	db.Status.LastLogins = make([]dba.UserLogin, 0, len(lastLogins))
+	for _, username := range usernames {
+		db.Status.LastLogins = append(db.Status.LastLogins, dba.UserLogin{Username: username, LastLogin: lastLogins[username]})
+	}
+
+	if err := ltc.Status().Update(ctx, db); err != nil {
+		return fmt.Errorf("Unable to update ManagedDatabase status block: %w", err)
+	}
+	return nil
+}
+
+func sampleKey(db *dba.ManagedDatabase, username string) string {
+	return strings.Join([]string{db.Namespace, db.Name, username}, "/")
+}
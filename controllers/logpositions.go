@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// LogPositionBeforeAnnotation is written to a migration's Job when it is
+// created, and holds the binary log position from just before the migration
+const LogPositionBeforeAnnotation = "dbaoperator.app-sre.redhat.com/log-position-before"
+
+// currentLogPosition returns the binary log position of the server, or nil
+// when binary logging is disabled
+func currentLogPosition(admin dbadmin.DbAdmin, now time.Time) (*dba.LogPosition, error) {
+	position, err := admin.GetLogPosition()
+	if err != nil {
+		return nil, err
+	}
+	if position.File == "" {
+		return nil, nil
+	}
+
+	return &dba.LogPosition{
+		File:       position.File,
+		Position:   position.Position,
+		GTIDSet:    position.GTIDSet,
+		CapturedAt: metav1.NewTime(now),
+	}, nil
+}
+
+// annotateLogPosition records the binary log position on a Job which is about
+// to be created
+func annotateLogPosition(admin dbadmin.DbAdmin, job *batchv1.Job) error {
+	before, err := currentLogPosition(admin, time.Now())
+	if err != nil {
+		return fmt.Errorf("Unable to capture binary log position before migration: %w", err)
+	}
+	if before == nil {
+		return nil
+	}
+
+	encoded, err := json.Marshal(before)
+	if err != nil {
+		return fmt.Errorf("Unable to encode binary log position: %w", err)
+	}
+	job.Annotations[LogPositionBeforeAnnotation] = string(encoded)
+	return nil
+}
+
+// recordLogPositions adds the binary log positions from before and after a
+// migration to the database's migration history, once its Job has succeeded.
+// Jobs created before positions were captured are left alone.
+func recordLogPositions(oneMigration migrationContext, admin dbadmin.DbAdmin, job *batchv1.Job) error {
+	encoded, ok := job.Annotations[LogPositionBeforeAnnotation]
+	if !ok {
+		return nil
+	}
+
+	applied, err := appliedMigrationEntry(oneMigration)
+	if err != nil {
+		return err
+	}
+	if applied.After != nil {
+		return nil
+	}
+
+	var before dba.LogPosition
+	if err := json.Unmarshal([]byte(encoded), &before); err != nil {
+		return fmt.Errorf("Unable to decode binary log position of Job (%s): %w", job.Name, err)
+	}
+	after, err := currentLogPosition(admin, time.Now())
+	if err != nil {
+		return fmt.Errorf("Unable to capture binary log position after migration: %w", err)
+	}
+
+	oneMigration.log.Info("Recording binary log positions of migration", "file", before.File, "position", before.Position)
+	applied.Before = &before
+	applied.After = after
+	return nil
+}
+
+// appliedMigrationEntry returns the entry of the migration in the database's
+// migration history, which is added when it is missing
+func appliedMigrationEntry(oneMigration migrationContext) (*dba.AppliedMigration, error) {
+	history := &oneMigration.db.Status.AppliedMigrations
+	for i := len(*history) - 1; i >= 0; i-- {
+		if (*history)[i].Version == oneMigration.version.Name {
+			return &(*history)[i], nil
+		}
+	}
+
+	checksum, err := migrationChecksum(oneMigration.version)
+	if err != nil {
+		return nil, err
+	}
+	*history = append(*history, dba.AppliedMigration{Version: oneMigration.version.Name, Checksum: checksum})
+	return &(*history)[len(*history)-1], nil
+}
@@ -32,14 +32,29 @@ import (
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/approval"
+	"github.com/app-sre/dba-operator/pkg/config"
 	"github.com/app-sre/dba-operator/pkg/dbadmin"
 	"github.com/app-sre/dba-operator/pkg/dbadmin/alembic"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/embedded"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/faults"
 	"github.com/app-sre/dba-operator/pkg/dbadmin/mysqladmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/readers"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/regions"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+	"github.com/app-sre/dba-operator/pkg/notify"
+	"github.com/app-sre/dba-operator/pkg/secretconvention"
+	"github.com/app-sre/dba-operator/pkg/silence"
 	"github.com/app-sre/dba-operator/pkg/xerrors"
 )
 
@@ -47,7 +62,41 @@ import (
 // specific database credentials when created in a managed database
 const DBUsernamePrefix = "dba_"
 
-var requeueAfterDelay = ctrl.Result{Requeue: true, RequeueAfter: 60 * time.Second}
+// Reconcile phases, attached to log lines so that a single reconcile can be
+// followed through the logs
+const (
+	phaseFetch         = "fetch"
+	phaseConnect       = "connect"
+	phaseVersionCheck  = "version-check"
+	phaseCredentials   = "credentials"
+	phaseMigration     = "migration"
+	phaseStatus        = "status"
+	phaseSnapshot      = "snapshot"
+	phaseQuarantine    = "quarantine"
+	phaseService       = "service"
+	phaseRoutines      = "routines"
+	phaseMasking       = "masking"
+	phaseQuota         = "quota"
+	phasePostMigration = "post-migration"
+	phaseLockGuard     = "lock-guard"
+	phaseAbort         = "abort"
+	phaseRotation      = "rotation"
+	phaseProfile       = "profile"
+	phaseScheduling    = "scheduling"
+	phasePartitioning  = "partitioning"
+	phaseReplicas      = "replicas"
+	phaseParameters    = "parameters"
+	phaseExtensions    = "extensions"
+	phaseCharset       = "charset"
+	phaseConsumers     = "consumers"
+	phaseCDC           = "cdc"
+	phaseConsistency   = "consistency"
+	phaseIntegrity     = "integrity"
+	phaseSeedData      = "seed-data"
+	phaseRetention     = "retention"
+	phaseScope         = "scope"
+	phaseFailoverDrill = "failover-drill"
+)
 
 // ManagedDatabaseController reconciles ManagedDatabase and DatabaseMigration objects
 type ManagedDatabaseController struct {
@@ -56,11 +105,38 @@ type ManagedDatabaseController struct {
 	Scheme        *runtime.Scheme
 	metrics       ManagedDatabaseControllerMetrics
 	databaseLinks map[string]interface{}
+	diagnostics   *diagnostics.Recorder
+
+	// partitionSeries contains the table labels which the partition metrics
+	// were last exported with for each database
+	partitionSeries map[string][]string
+
+	// cloudEvents requeues the databases which topology events are about
+	cloudEvents chan event.GenericEvent
+
+	config   config.Provider
+	notifier notify.Notifier
+	pods     corev1client.PodsGetter
+	silencer silence.Silencer
+	approver approval.Approver
 }
 
 // NewManagedDatabaseController will instantiate a ManagedDatabaseController
-// with the supplied arguments and logical defaults.
-func NewManagedDatabaseController(c client.Client, scheme *runtime.Scheme, l logr.Logger) (*ManagedDatabaseController, []prometheus.Collector) {
+// with the supplied arguments and logical defaults. diag enables debug mode,
+// see diagnostics.Recorder. The progress reported by migration Jobs is read
+// from their pods' output through pods, which may be nil to disable it.
+// Alerts about databases are silenced during migrations through silencer.
+func NewManagedDatabaseController(
+	c client.Client,
+	scheme *runtime.Scheme,
+	l logr.Logger,
+	diag *diagnostics.Recorder,
+	cfg config.Provider,
+	notifier notify.Notifier,
+	pods corev1client.PodsGetter,
+	silencer silence.Silencer,
+	approver approval.Approver,
+) (*ManagedDatabaseController, []prometheus.Collector) {
 	metrics := generateManagedDatabaseControllerMetrics()
 
 	return &ManagedDatabaseController{
@@ -69,17 +145,28 @@ func NewManagedDatabaseController(c client.Client, scheme *runtime.Scheme, l log
 		Log:           l,
 		metrics:       metrics,
 		databaseLinks: make(map[string]interface{}),
+		diagnostics:   diag,
+		config:        cfg,
+		notifier:      notifier,
+		pods:          pods,
+		silencer:      silencer,
+		approver:      approver,
+
+		partitionSeries: make(map[string][]string),
+		cloudEvents:     make(chan event.GenericEvent, cloudEventBuffer),
 	}, getAllMetrics(metrics)
 }
 
 // +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases;databasemigrations,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases/status;databasemigrations/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=,resources=secrets,verbs=get;list;create;delete
+// +kubebuilder:rbac:groups=,resources=secrets,verbs=get;list;create;update;delete
+// +kubebuilder:rbac:groups=,resources=configmaps,verbs=get;create;update;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;delete
 
 // ReconcileManagedDatabase should be invoked whenever there is a change to a
 // ManagedDatabase or one of the objects that are created on its behalf
 func (c *ManagedDatabaseController) ReconcileManagedDatabase(req ctrl.Request) (ctrl.Result, error) {
-	var ctx = context.Background()
+	ctx, timer := withPhaseTimer(context.Background(), phaseFetch, time.Now())
 	var log = c.Log.WithValues("manageddatabase", req.NamespacedName)
 
 	var db dba.ManagedDatabase
@@ -91,42 +178,371 @@ func (c *ManagedDatabaseController) ReconcileManagedDatabase(req ctrl.Request) (
 			return ctrl.Result{}, nil
 		}
 
-		log.Error(err, "unable to fetch ManagedDatabase")
-		return handleError(ctx, c.Client, &db, log, err)
+		log.Error(err, "unable to fetch ManagedDatabase", "phase", phaseFetch)
+		return c.handleError(ctx, &db, log, phaseFetch, err)
 	}
 
 	c.databaseLinks[db.SelfLink] = nil
 	c.metrics.ManagedDatabases.Set(float64(len(c.databaseLinks)))
 
-	admin, err := initializeAdminConnection(ctx, log, c.Client, req.Namespace, &db.Spec)
-	if err != nil {
-		log.Error(err, "unable to create database connection")
+	if err := applyManagedDatabaseClass(ctx, c.Client, &db); err != nil {
+		log.Error(err, "unable to apply ManagedDatabaseClass", "phase", phaseFetch)
+		return c.handleError(ctx, &db, log, phaseFetch, err)
+	}
 
-		return handleError(ctx, c.Client, &db, log, err)
+	log = log.WithValues("engine", db.Spec.Connection.Engine)
+	cfg := c.config.Current()
+
+	if !cfg.EngineAllowed(db.Spec.Connection.Engine) {
+		err := fmt.Errorf("Database engine %s is not allowed by the operator configuration", db.Spec.Connection.Engine)
+		log.Error(err, "refusing to manage database", "phase", phaseConnect)
+		return c.handleError(ctx, &db, log, phaseConnect, err)
+	}
+
+	timer.enter(phaseConnect)
+	connectLog := phaseLogger(log, phaseConnect)
+	admin, err := initializeAdminConnection(ctx, connectLog, c.diagnostics, c.Client, req.Namespace, &db.Spec)
+	var incompatible *incompatibleServerError
+	if errors.As(err, &incompatible) {
+		db.Status.ServerFlavor = string(incompatible.server.Flavor)
+		db.Status.ServerVersion = incompatible.server.Version
+		db.Status.Incompatible = incompatible.reason
+	} else {
+		db.Status.Incompatible = ""
 	}
+	var outOfScope *outOfScopeError
+	if errors.As(err, &outOfScope) {
+		log.Error(err, "refusing to manage database", "phase", phaseScope)
+		return c.handleError(ctx, &db, log, phaseScope, err)
+	} else if err != nil {
+		connectLog.Error(err, "unable to create database connection")
+
+		return c.handleError(ctx, &db, log, phaseConnect, err)
+	}
+	defer admin.Close()
 
+	server, err := admin.DetectServer()
+	if err != nil {
+		connectLog.Error(err, "unable to detect database server")
+		return c.handleError(ctx, &db, log, phaseConnect, err)
+	}
+	db.Status.ServerFlavor = string(server.Flavor)
+	db.Status.ServerVersion = server.Version
+	db.Status.Instance = server.Instance
+
+	log = log.WithValues("database", server.Database)
+	connectLog = phaseLogger(log, phaseConnect)
+	if err := c.recoverJournal(ctx, connectLog, admin, &db); err != nil {
+		connectLog.Error(err, "unable to recover interrupted operation")
+		return c.handleError(ctx, &db, log, phaseConnect, err)
+	}
+
+	timer.enter(phaseVersionCheck)
+	versionLog := phaseLogger(log, phaseVersionCheck)
 	currentDbVersion, err := admin.GetSchemaVersion()
 	if err != nil {
-		log.Error(err, "unable to retrieve database version")
-		return handleError(ctx, c.Client, &db, log, err)
+		versionLog.Error(err, "unable to retrieve database version")
+		return c.handleError(ctx, &db, log, phaseVersionCheck, err)
 	}
-	log.Info("Versions", "startVersion", currentDbVersion, "desiredVersion", db.Spec.DesiredSchemaVersion)
+	versionLog.Info("Versions", "startVersion", currentDbVersion, "desiredVersion", db.Spec.DesiredSchemaVersion)
 
 	db.Status.CurrentVersion = currentDbVersion
 
-	needVersion := db.Spec.DesiredSchemaVersion
+	if err := evaluateAppVersions(ctx, c.Client, &db, currentDbVersion); err != nil {
+		versionLog.Error(err, "unable to evaluate app versions")
+		return c.handleError(ctx, &db, log, phaseVersionCheck, err)
+	}
+
+	var result ctrl.Result
+	if reason := pauseReason(cfg, &db); reason != "" {
+		log.Info("Skipping changes to the database", "reason", reason)
+		db.Status.Paused = true
+
+		// Safe-mode is left without a change to the ManagedDatabase, so keep
+		// checking whether it has ended
+		if cfg.SafeMode {
+			result.RequeueAfter = cfg.Backoff.TemporaryErrorDelay.Duration
+		}
+		return c.updateStatus(ctx, log, &db, result)
+	}
+	db.Status.Paused = false
+
+	timer.enter(phaseConnect)
+	unlock, err := lockOperator(log, admin, cfg.Leases)
+	var held leaseHeldError
+	if errors.As(err, &held) {
+		// Observe the database until the other cluster's lease expires,
+		// then try to take it over
+		log.Info("Observing database managed by another cluster", "holder", held.lease.Holder, "expiresIn", held.lease.Remaining)
+		db.Status.LeaseHolder = held.lease.Holder
+		requeueWithin(&result, held.lease.Remaining+time.Second)
+		return c.updateStatus(ctx, log, &db, result)
+	} else if err != nil {
+		log.Error(err, "unable to acquire operator lock", "phase", phaseConnect)
+		return c.handleError(ctx, &db, log, phaseConnect, err)
+	}
+	defer unlock()
+
+	db.Status.LeaseHolder = cfg.Leases.ClusterID
+	if cfg.Leases.ClusterID != "" {
+		// Renew the lease well before it expires, even when nothing else
+		// needs doing
+		requeueWithin(&result, cfg.Leases.Duration.Duration/2)
+	}
+
+	timer.enter(phaseQuota)
+	quotaLog := phaseLogger(log, phaseQuota)
+	if err := c.checkDatabaseQuota(ctx, &db, cfg.Quotas); err != nil {
+		quotaLog.Error(err, "refusing to manage database")
+		return c.handleError(ctx, &db, log, phaseQuota, err)
+	}
+	if err := c.measureUserQuota(admin, server.Instance, cfg.Quotas); err != nil {
+		quotaLog.Error(err, "unable to measure user quota")
+		return c.handleError(ctx, &db, log, phaseQuota, err)
+	}
+
+	timer.enter(phaseVersionCheck)
+	if err := verifyAppliedMigrations(ctx, c.Client, &db, currentDbVersion); err != nil {
+		versionLog.Error(err, "refusing to migrate database")
+		return c.handleError(ctx, &db, log, phaseVersionCheck, err)
+	}
+
+	needVersion, err := resolveDesiredVersion(ctx, c.Client, &db)
+	if err != nil {
+		versionLog.Error(err, "unable to resolve desired version")
+		return c.handleError(ctx, &db, log, phaseVersionCheck, err)
+	}
 	var migrationToRun *dba.DatabaseMigration
 
 	for needVersion != currentDbVersion {
-		found, err := loadMigration(ctx, log, c.Client, db.Namespace, needVersion)
+		found, err := loadMigration(ctx, versionLog, c.Client, db.Namespace, needVersion)
 		if err != nil {
-			return handleError(ctx, c.Client, &db, log, err)
+			return c.handleError(ctx, &db, log, phaseVersionCheck, err)
 		}
 
 		migrationToRun = found
 		needVersion = found.Spec.Previous
 	}
 
+	if err := checkAdminProfile(cfg.AdminProfile, &db, migrationToRun); err != nil {
+		log.Error(err, "refusing to manage database", "phase", phaseProfile)
+		return c.handleError(ctx, &db, log, phaseProfile, err)
+	}
+
+	timer.enter(phaseCharset)
+	charsetLog := phaseLogger(log, phaseCharset)
+	charsetRecheck, err := c.reconcileCharset(charsetLog, admin, &db, time.Now())
+	if err != nil {
+		charsetLog.Error(err, "refusing to manage database")
+		return c.handleError(ctx, &db, log, phaseCharset, err)
+	}
+	requeueWithin(&result, charsetRecheck)
+
+	if shuttingDown(log) {
+		return c.updateStatus(ctx, log, &db, result)
+	}
+
+	// The maintenance phases don't depend on each other, so all of them are
+	// attempted and their errors are reported together. Migrations wait until
+	// all of them succeed.
+	var failures []phaseError
+
+	timer.enter(phaseService)
+	serviceLog := phaseLogger(log, phaseService)
+	if err := c.reconcileService(ctx, serviceLog, admin, &db); err != nil {
+		serviceLog.Error(err, "unable to publish Service")
+		failures = append(failures, phaseError{phase: phaseService, err: err})
+	}
+	if db.Spec.Service != nil && db.Spec.GroupReplication {
+		// Follow the primary through failovers
+		requeueWithin(&result, serviceRefreshInterval)
+	}
+
+	timer.enter(phaseReplicas)
+	replicasLog := phaseLogger(log, phaseReplicas)
+	if err := c.reconcileReplicas(ctx, replicasLog, admin, &db); err != nil {
+		replicasLog.Error(err, "unable to publish credentials for replicas")
+		failures = append(failures, phaseError{phase: phaseReplicas, err: err})
+	}
+	if db.Spec.Replicas != nil && len(db.Spec.Replicas.Endpoints) > 0 {
+		requeueWithin(&result, replicaCheckInterval)
+	}
+
+	timer.enter(phaseParameters)
+	parametersLog := phaseLogger(log, phaseParameters)
+	if err := c.reconcileParameters(parametersLog, admin, &db, cfg.Parameters); err != nil {
+		parametersLog.Error(err, "unable to reconcile server parameters")
+		failures = append(failures, phaseError{phase: phaseParameters, err: err})
+	}
+	if len(db.Spec.Parameters) > 0 {
+		requeueWithin(&result, parameterRefreshInterval)
+	}
+
+	timer.enter(phaseExtensions)
+	extensionsLog := phaseLogger(log, phaseExtensions)
+	if err := c.reconcileExtensions(extensionsLog, admin, &db, time.Now()); err != nil {
+		extensionsLog.Error(err, "unable to create extensions")
+		failures = append(failures, phaseError{phase: phaseExtensions, err: err})
+	}
+
+	timer.enter(phaseRoutines)
+	routinesLog := phaseLogger(log, phaseRoutines)
+	if err := c.reconcileHelperRoutines(routinesLog, admin, &db); err != nil {
+		routinesLog.Error(err, "unable to reconcile helper routines")
+		failures = append(failures, phaseError{phase: phaseRoutines, err: err})
+	}
+
+	timer.enter(phaseQuarantine)
+	quarantineLog := phaseLogger(log, phaseQuarantine)
+	quarantineRecheck, err := c.reconcileQuarantine(ctx, quarantineLog, admin, &db, time.Now())
+	if err != nil {
+		quarantineLog.Error(err, "unable to deprovision quarantined users")
+		failures = append(failures, phaseError{phase: phaseQuarantine, err: err})
+	}
+
+	timer.enter(phaseConsumers)
+	consumersLog := phaseLogger(log, phaseConsumers)
+	staleConsumers, err := discoverConsumers(ctx, c.Client, &db)
+	if err != nil {
+		consumersLog.Error(err, "unable to discover secret consumers")
+		failures = append(failures, phaseError{phase: phaseConsumers, err: err})
+	}
+	if staleConsumers {
+		// Follow the pods which hold old credentials until they are gone
+		requeueWithin(&result, consumerRefreshInterval)
+	}
+	if err == nil {
+		if err := discardRetainedPasswords(ctx, phaseLogger(log, phaseRotation), c.Client, admin, &db); err != nil {
+			consumersLog.Error(err, "unable to discard previous passwords")
+			failures = append(failures, phaseError{phase: phaseConsumers, err: err})
+		}
+	}
+
+	timer.enter(phaseCDC)
+	cdcLog := phaseLogger(log, phaseCDC)
+	followConsumers, err := c.reconcileBinlogConsumers(cdcLog, admin, &db, time.Now())
+	if err != nil {
+		cdcLog.Error(err, "unable to check change data capture consumers")
+		failures = append(failures, phaseError{phase: phaseCDC, err: err})
+	}
+	if followConsumers {
+		requeueWithin(&result, binlogConsumerRefreshInterval)
+	}
+
+	timer.enter(phaseConsistency)
+	consistencyLog := phaseLogger(log, phaseConsistency)
+	nextCheck, err := c.reconcileConsistencyChecks(ctx, consistencyLog, &db, time.Now())
+	if err != nil {
+		consistencyLog.Error(err, "unable to run consistency checks")
+		failures = append(failures, phaseError{phase: phaseConsistency, err: err})
+	}
+	requeueWithin(&result, nextCheck)
+
+	if migrationToRun == nil && currentDbVersion != "" {
+		timer.enter(phasePostMigration)
+		postMigrationLog := phaseLogger(log, phasePostMigration)
+		if err := c.reconcilePostMigration(ctx, postMigrationLog, admin, &db, currentDbVersion); err != nil {
+			postMigrationLog.Error(err, "unable to run post-migration maintenance")
+			failures = append(failures, phaseError{phase: phasePostMigration, err: err})
+		}
+
+		// The views select from tables which the migrations create
+		timer.enter(phaseMasking)
+		maskingLog := phaseLogger(log, phaseMasking)
+		if err := c.reconcileMaskedViews(maskingLog, admin, &db); err != nil {
+			maskingLog.Error(err, "unable to reconcile masked views")
+			failures = append(failures, phaseError{phase: phaseMasking, err: err})
+		}
+
+		// Purges and partition rotation refer to tables from the migrations
+		timer.enter(phaseScheduling)
+		schedulingLog := phaseLogger(log, phaseScheduling)
+		if err := c.reconcileScheduledStatements(schedulingLog, admin, &db); err != nil {
+			schedulingLog.Error(err, "unable to reconcile scheduled statements")
+			failures = append(failures, phaseError{phase: phaseScheduling, err: err})
+		}
+
+		timer.enter(phasePartitioning)
+		partitioningLog := phaseLogger(log, phasePartitioning)
+		if err := c.reconcilePartitions(partitioningLog, admin, &db, time.Now()); err != nil {
+			partitioningLog.Error(err, "unable to maintain partitions")
+			failures = append(failures, phaseError{phase: phasePartitioning, err: err})
+		}
+		if len(db.Spec.Partitioning) > 0 {
+			requeueWithin(&result, partitionRefreshInterval)
+		}
+
+		timer.enter(phaseRetention)
+		retentionLog := phaseLogger(log, phaseRetention)
+		retentionAfter, err := c.reconcileRetention(retentionLog, admin, &db, time.Now())
+		if err != nil {
+			retentionLog.Error(err, "unable to purge expired rows")
+			failures = append(failures, phaseError{phase: phaseRetention, err: err})
+		}
+		requeueWithin(&result, retentionAfter)
+
+		// Seed data fills the tables which the migrations create
+		timer.enter(phaseSeedData)
+		seedDataLog := phaseLogger(log, phaseSeedData)
+		if err := c.reconcileSeedData(ctx, seedDataLog, admin, &db, currentDbVersion); err != nil {
+			seedDataLog.Error(err, "unable to apply seed data")
+			failures = append(failures, phaseError{phase: phaseSeedData, err: err})
+		}
+		if len(db.Spec.SeedData) > 0 {
+			requeueWithin(&result, seedDataRefreshInterval)
+		}
+
+		timer.enter(phaseIntegrity)
+		integrityLog := phaseLogger(log, phaseIntegrity)
+		nextScan, err := c.reconcileIntegrityChecks(integrityLog, admin, &db, time.Now())
+		if err != nil {
+			integrityLog.Error(err, "unable to scan for orphaned rows")
+			failures = append(failures, phaseError{phase: phaseIntegrity, err: err})
+		}
+		requeueWithin(&result, nextScan)
+	}
+
+	if migrationToRun == nil && db.Spec.ExportSchemaSnapshots && currentDbVersion != "" {
+		timer.enter(phaseSnapshot)
+		snapshotLog := phaseLogger(log, phaseSnapshot)
+		if err := c.reconcileSchemaSnapshot(ctx, snapshotLog, admin, &db, currentDbVersion); err != nil {
+			snapshotLog.Error(err, "unable to export schema snapshot")
+			failures = append(failures, phaseError{phase: phaseSnapshot, err: err})
+		}
+	}
+
+	if len(failures) > 0 {
+		return c.reportErrors(ctx, &db, log, failures)
+	}
+
+	// Credentials and migration Jobs are left to the operator which takes
+	// over, so that none of them is cut short by the shutdown
+	if shuttingDown(log) {
+		return c.updateStatus(ctx, log, &db, result)
+	}
+
+	if migrationToRun == nil && db.Spec.BlueGreen != nil && currentDbVersion != "" {
+		// Without a migration to run, the credentials of earlier versions
+		// are retired once the last app pod using them is gone
+		timer.enter(phaseCredentials)
+		current, err := loadMigration(ctx, versionLog, c.Client, db.Namespace, currentDbVersion)
+		if err != nil {
+			return c.handleError(ctx, &db, log, phaseCredentials, err)
+		}
+		currentMigration := migrationContext{
+			ctx:     ctx,
+			log:     log.WithValues("migration", current.Name),
+			db:      &db,
+			version: current,
+		}
+		if err := c.reconcileCredentialsForVersion(currentMigration.withPhase(phaseCredentials), admin, currentDbVersion); err != nil {
+			return c.handleError(ctx, &db, log, phaseCredentials, err)
+		}
+	}
+	if db.Spec.BlueGreen != nil {
+		requeueWithin(&result, blueGreenRecheckInterval)
+	}
+
 	if migrationToRun != nil {
 		oneMigration := migrationContext{
 			ctx:     ctx,
@@ -135,22 +551,127 @@ func (c *ManagedDatabaseController) ReconcileManagedDatabase(req ctrl.Request) (
 			version: migrationToRun,
 		}
 
-		if err := c.reconcileCredentialsForVersion(oneMigration, admin, currentDbVersion); err != nil {
-			return handleError(ctx, c.Client, &db, log, err)
+		timer.enter(phaseCredentials)
+		if err := c.reconcileCredentialsForVersion(oneMigration.withPhase(phaseCredentials), admin, currentDbVersion); err != nil {
+			return c.handleError(ctx, &db, log, phaseCredentials, err)
+		}
+
+		timer.enter(phaseMigration)
+		windowOpen, nextWindow, err := maintenanceWindowOpen(&db, time.Now())
+		if err != nil {
+			return c.handleError(ctx, &db, log, phaseMigration, err)
+		}
+
+		running := false
+		if migrationAborted(&db, migrationToRun) {
+			timer.enter(phaseAbort)
+			abortLog := phaseLogger(oneMigration.log, phaseAbort)
+			abortLog.Info("Migration was aborted, it will be retried once the DatabaseMigration is changed", "reason", db.Status.MigrationFailure.Reason)
+			pending, err := c.finishAbort(oneMigration.withPhase(phaseAbort), admin)
+			if err != nil {
+				abortLog.Error(err, "unable to finish aborting migration")
+				return c.handleError(ctx, &db, log, phaseAbort, err)
+			}
+			if pending {
+				requeueWithin(&result, abortRecheckInterval)
+			}
+		} else {
+			db.Status.MigrationFailure = nil
+
+			if migrationToRun.Spec.Embedded != nil {
+				applied, err := c.applyEmbeddedMigration(oneMigration.withPhase(phaseMigration), admin, windowOpen)
+				if err != nil {
+					return c.handleError(ctx, &db, log, phaseMigration, err)
+				}
+				if applied {
+					// The next reconcile reads the new version and provisions
+					// its credentials
+					requeueWithin(&result, embeddedRecheckInterval)
+				}
+			} else {
+				running, err = c.reconcileMigrationJob(oneMigration.withPhase(phaseMigration), admin, windowOpen)
+				if err != nil {
+					return c.handleError(ctx, &db, log, phaseMigration, err)
+				}
+			}
+
+			if running {
+				timer.enter(phaseAbort)
+				abortLog := phaseLogger(oneMigration.log, phaseAbort)
+				aborted, recheck, err := c.guardMigration(oneMigration.withPhase(phaseAbort), admin)
+				if err != nil {
+					abortLog.Error(err, "unable to check migration guard")
+					return c.handleError(ctx, &db, log, phaseAbort, err)
+				}
+				requeueWithin(&result, recheck)
+				running = !aborted
+			}
+
+			if !running && !windowOpen {
+				requeueWithin(&result, time.Until(nextWindow))
+			}
+		}
+
+		if running {
+			timer.enter(phaseMigration)
+			// Progress is informational, failing to read it doesn't hold
+			// up the migration
+			if err := c.relayMigrationProgress(oneMigration.withPhase(phaseMigration)); err != nil {
+				oneMigration.log.Error(err, "unable to read migration progress")
+			}
+			requeueWithin(&result, progressRefreshInterval)
+		} else {
+			c.clearMigrationProgress(&db)
+			c.expireMigrationSilence(oneMigration.log, &db)
 		}
 
-		if err := c.reconcileMigrationJob(oneMigration); err != nil {
-			return handleError(ctx, c.Client, &db, log, err)
+		if running && db.Spec.MetadataLockGuard != nil {
+			timer.enter(phaseLockGuard)
+			guardLog := phaseLogger(oneMigration.log, phaseLockGuard)
+			recheck, err := c.guardMetadataLocks(guardLog, admin, &db)
+			if err != nil {
+				guardLog.Error(err, "unable to check for metadata lock waits")
+				return c.handleError(ctx, &db, log, phaseLockGuard, err)
+			}
+
+			// Keep watching for as long as the migration is running
+			requeueWithin(&result, recheck)
 		}
 	}
 
-	// Update the status block with the information that we've generated
-	if err := c.Status().Update(ctx, &db); err != nil {
-		log.Error(err, "Unable to update ManagedDatabase status block")
+	if migrationToRun == nil {
+		c.clearMigrationProgress(&db)
+		c.expireMigrationSilence(log, &db)
+		db.Status.MigrationFailure = nil
+	}
+
+	// Come back to drop the next quarantined user
+	requeueWithin(&result, quarantineRecheck)
+
+	return c.updateStatus(ctx, log, &db, result)
+}
+
+// requeueWithin makes sure that the reconcile is repeated no later than
+// after, unless after is zero
+func requeueWithin(result *ctrl.Result, after time.Duration) {
+	if after > 0 && (result.RequeueAfter == 0 || after < result.RequeueAfter) {
+		result.RequeueAfter = after
+	}
+}
+
+// updateStatus writes the status block with the information that we've
+// generated, and returns the result of the reconcile
+func (c *ManagedDatabaseController) updateStatus(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, result ctrl.Result) (ctrl.Result, error) {
+	db.Status.TemporaryErrorRetries = 0
+	markReady(db, time.Now())
+	recordPerformance(ctx, db, time.Now())
+	regionalResults.recordRegionalPrimaries(db)
+	if err := c.Status().Update(ctx, db); err != nil {
+		log.Error(err, "Unable to update ManagedDatabase status block", "phase", phaseStatus)
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 type migrationContext struct {
@@ -160,7 +681,15 @@ type migrationContext struct {
 	version *dba.DatabaseMigration
 }
 
-func (c *ManagedDatabaseController) reconcileMigrationJob(oneMigration migrationContext) error {
+func (mc migrationContext) withPhase(phase string) migrationContext {
+	mc.log = phaseLogger(mc.log, phase)
+	return mc
+}
+
+// reconcileMigrationJob ensures that a Job is running the migration, and
+// returns true while that Job has not yet succeeded. A new Job is only
+// started if startAllowed is true.
+func (c *ManagedDatabaseController) reconcileMigrationJob(oneMigration migrationContext, admin dbadmin.DbAdmin, startAllowed bool) (bool, error) {
 	oneMigration.log.Info("Reconciling migration jobs")
 
 	// Check if this migration is already running
@@ -170,10 +699,11 @@ func (c *ManagedDatabaseController) reconcileMigrationJob(oneMigration migration
 	var jobsForDatabase batchv1.JobList
 	if err := c.List(oneMigration.ctx, &jobsForDatabase, client.InNamespace(oneMigration.db.Namespace), client.MatchingLabels(labelSelector)); err != nil {
 		oneMigration.log.Error(err, "unable to list migration Jobs")
-		return fmt.Errorf("Unable to list existing migration Job(s): %w", err)
+		return false, fmt.Errorf("Unable to list existing migration Job(s): %w", err)
 	}
 
 	foundJob := false
+	running := false
 	for _, job := range jobsForDatabase.Items {
 		if job.Labels["migration-uid"] == string(oneMigration.version.UID) {
 			// This is the job for the migration in question
@@ -183,42 +713,115 @@ func (c *ManagedDatabaseController) reconcileMigrationJob(oneMigration migration
 			if job.Status.Succeeded > 0 {
 				oneMigration.log.Info("Migration is complete")
 
-				// TODO: should we write the metric here or wait until cleanup?
+				if err := recordLogPositions(oneMigration, admin, &job); err != nil {
+					return false, err
+				}
+				if err := recordImageVerification(oneMigration, &job); err != nil {
+					return false, err
+				}
+
+				if job.Status.StartTime != nil && job.Status.CompletionTime != nil {
+					took := job.Status.CompletionTime.Sub(job.Status.StartTime.Time)
+					labels := migrationLabels(oneMigration.db, oneMigration.version.Name)
+					c.metrics.MigrationDuration.With(labels).Set(took.Seconds())
+				}
+			} else {
+				running = true
 			}
 		} else {
 			// This is an old job and should be cleaned up
 			oneMigration.log.Info("Cleaning up job for old migration", "oldMigrationName", job.Name)
 
 			if err := c.Client.Delete(oneMigration.ctx, &job); err != nil {
-				return fmt.Errorf("Unable to delete migration job (%s): %w", job.Name, err)
+				return false, fmt.Errorf("Unable to delete migration job (%s): %w", job.Name, err)
+			}
+			if err := deleteBundleConfigMap(oneMigration.ctx, c.Client, job.Namespace, job.Name); err != nil {
+				return false, err
 			}
 
 			// TODO: maybe write metrics here?
 		}
 	}
 
-	if !foundJob {
+	if !foundJob && !startAllowed {
+		oneMigration.log.Info("Waiting for a maintenance window to start the migration")
+	} else if !foundJob {
 		// Start the migration
 		oneMigration.log.Info("Running migration", "currentVersion", oneMigration.version.Spec.Previous)
-		job, err := constructJobForMigration(oneMigration.db, oneMigration.version, oneMigration.db.Spec.Connection.DSNSecret)
+		dsnSecretName, err := c.migrationDSNSecret(oneMigration)
 		if err != nil {
-			return fmt.Errorf("Unable to create Job for migration (%s): %w", oneMigration.version.Name, err)
+			return false, fmt.Errorf("Unable to provide connection DSN to migration (%s): %w", oneMigration.version.Name, err)
+		}
+
+		job, err := constructJobForMigration(oneMigration.db, oneMigration.version, dsnSecretName)
+		if err != nil {
+			return false, fmt.Errorf("Unable to create Job for migration (%s): %w", oneMigration.version.Name, err)
+		}
+
+		if oneMigration.version.Spec.Bundle != nil {
+			if err := c.mountBundle(oneMigration, job); err != nil {
+				return false, err
+			}
+		}
+		if err := c.verifyMigrationImage(oneMigration, job); err != nil {
+			return false, err
+		}
+
+		if err := annotateBinlogBaseline(admin, oneMigration.version, job); err != nil {
+			return false, err
+		}
+		if err := annotateLogPosition(admin, job); err != nil {
+			return false, err
 		}
 
 		// Set the CR to own the new job
 		if err := ctrl.SetControllerReference(oneMigration.db, job, c.Scheme); err != nil {
-			return fmt.Errorf("Unable to set owner for new job (%s): %w", job.Name, err)
+			return false, fmt.Errorf("Unable to set owner for new job (%s): %w", job.Name, err)
+		}
+
+		token, err := c.beginOperation(oneMigration.ctx, oneMigration.db, phaseMigration, operationCreateJob, job.Name)
+		if err != nil {
+			return false, err
 		}
+		job.Annotations[JournalTokenAnnotation] = token
 
 		if err := c.Create(oneMigration.ctx, job); err != nil {
 			oneMigration.log.Error(err, "unable to create Job for migration", "job", job.Name)
-			return fmt.Errorf("Unable to create Job (%s) for migration: %w", job.Name, err)
+			return false, fmt.Errorf("Unable to create Job (%s) for migration: %w", job.Name, err)
 		}
+		endOperation(oneMigration.db)
 
 		c.metrics.MigrationJobsSpawned.Inc()
+		c.silenceMigration(oneMigration)
+		running = true
 	}
 
-	return nil
+	return running, nil
+}
+
+// migrationDSNSecret returns the name of a secret whose dsn key can be passed
+// to migration jobs. Databases with a typed connection spec have the built
+// DSN written to a secret of their own.
+func (c *ManagedDatabaseController) migrationDSNSecret(oneMigration migrationContext) (string, error) {
+	conn := &oneMigration.db.Spec.Connection
+	if conn.Spec == nil {
+		return conn.DSNSecret, nil
+	}
+
+	dsn, err := connectionDSN(oneMigration.ctx, oneMigration.log, c.Client, oneMigration.db.Namespace, conn)
+	if err != nil {
+		return "", err
+	}
+
+	secretName := connectionSecretName(oneMigration.db.Name)
+	labels, annotations, err := secretMetadata(oneMigration.db, oneMigration.db.Namespace, secretconvention.KindConnection, "", nil)
+	if err != nil {
+		return "", err
+	}
+	if err := writeDSNSecret(oneMigration.ctx, c.Client, oneMigration.db.Namespace, secretName, dsn, labels, annotations, oneMigration.db, c.Scheme); err != nil {
+		return "", fmt.Errorf("Unable to write DSN secret (%s): %w", secretName, err)
+	}
+	return secretName, nil
 }
 
 func loadMigration(ctx context.Context, log logr.Logger, apiClient client.Client, namespace, versionName string) (*dba.DatabaseMigration, error) {
@@ -240,19 +843,27 @@ func (c *ManagedDatabaseController) reconcileCredentialsForVersion(oneMigration
 	oneMigration.log.Info("Reconciling credentials")
 
 	// Compute the list of credentials that we need for this database version
-	secretNames := mapset.NewSet()
-	dbUsernames := mapset.NewSet()
-
-	if currentDbVersion == oneMigration.version.Name {
-		// We have achieved the proper version, so the credentials for that
-		// version should be present/added
-		secretNames.Add(migrationName(oneMigration.db.Name, oneMigration.version.Name))
-		dbUsernames.Add(migrationDBUsername(oneMigration.version.Name))
-	}
+	versions := make(map[string]*dba.DatabaseMigration)
+	if oneMigration.db.Spec.BlueGreen != nil {
+		found, err := blueGreenVersions(oneMigration.ctx, c.Client, oneMigration.db, currentDbVersion)
+		if err != nil {
+			return err
+		}
+		versions = found
+	} else {
+		if currentDbVersion == oneMigration.version.Name {
+			// We have achieved the proper version, so the credentials for
+			// that version should be present/added
+			versions[oneMigration.version.Name] = oneMigration.version
+		}
 
-	if oneMigration.version.Spec.Previous != "" {
-		secretNames.Add(migrationName(oneMigration.db.Name, oneMigration.version.Spec.Previous))
-		dbUsernames.Add(migrationDBUsername(oneMigration.version.Spec.Previous))
+		if oneMigration.version.Spec.Previous != "" {
+			previous, err := fetchMigration(oneMigration.ctx, c.Client, oneMigration.db.Namespace, oneMigration.version.Spec.Previous)
+			if err != nil {
+				return err
+			}
+			versions[oneMigration.version.Spec.Previous] = previous
+		}
 	}
 
 	// List the secrets in the system
@@ -261,6 +872,22 @@ func (c *ManagedDatabaseController) reconcileCredentialsForVersion(oneMigration
 		return fmt.Errorf("Unable to list existing cluster secrets: %w", err)
 	}
 
+	// The names of the secrets depend on the convention, which can't change
+	// once they are written
+	if err := c.pinSecretConvention(oneMigration.ctx, oneMigration.log, oneMigration.db, secretList.Items); err != nil {
+		return err
+	}
+	secretNames := mapset.NewSet()
+	versionSecrets := make(map[string]string, len(versions))
+	for version := range versions {
+		secretName, err := credentialsSecretName(oneMigration.db, version)
+		if err != nil {
+			return err
+		}
+		secretNames.Add(secretName)
+		versionSecrets[version] = secretName
+	}
+
 	existingSecretSet := mapset.NewSet()
 	for _, foundSecret := range secretList.Items {
 		existingSecretSet.Add(foundSecret.Name)
@@ -274,6 +901,20 @@ func (c *ManagedDatabaseController) reconcileCredentialsForVersion(oneMigration
 		}
 	}
 
+	// Rewrite the secrets which are kept when the secret format changed
+	keptSecrets := make([]corev1.Secret, 0, len(secretList.Items))
+	for _, foundSecret := range secretList.Items {
+		if secretNames.Contains(foundSecret.Name) {
+			keptSecrets = append(keptSecrets, foundSecret)
+		}
+	}
+	if err := c.reformatSecrets(oneMigration.ctx, oneMigration.log, oneMigration.db, keptSecrets); err != nil {
+		return err
+	}
+	if err := c.conformSecrets(oneMigration.ctx, oneMigration.log, oneMigration.db, keptSecrets); err != nil {
+		return err
+	}
+
 	// List credentials in the database that match our namespace prefix
 	existingDbUsernames, err := admin.ListUsernames(DBUsernamePrefix)
 	if err != nil {
@@ -281,62 +922,172 @@ func (c *ManagedDatabaseController) reconcileCredentialsForVersion(oneMigration
 	}
 	oneMigration.log.Info("Found matching usernames", "numUsername", len(existingDbUsernames))
 
+	recorded, err := admin.ListUserAttributes(DBUsernamePrefix)
+	if err != nil {
+		return fmt.Errorf("Unable to read attributes of existing db users: %w", err)
+	}
+
 	existingDbUsernamesSet := mapset.NewSet()
 	for _, username := range existingDbUsernames {
 		existingDbUsernamesSet.Add(username)
 	}
 
+	server, err := admin.DetectServer()
+	if err != nil {
+		return fmt.Errorf("Unable to detect database server: %w", err)
+	}
+	dbUsernames := mapset.NewSet()
+	usernameVersions := make(map[string]string, len(versions))
+	for version := range versions {
+		username, err := versionUsername(version, server.MaxUsernameLength, existingDbUsernames)
+		if err != nil {
+			return err
+		}
+		dbUsernames.Add(username)
+		usernameVersions[username] = version
+	}
+
 	// Remove any users that shouldn't be there
 	dbUsersToRemove := existingDbUsernamesSet.Difference(dbUsernames)
 	for dbUserToRemoveItem := range dbUsersToRemove.Iterator().C {
 		dbUserToRemove := dbUserToRemoveItem.(string)
-		oneMigration.log.Info("Deprovisioning user account", "username", dbUserToRemove)
-		if err := admin.VerifyUnusedAndDeleteCredentials(dbUserToRemove); err != nil {
+		if !ownedByDatabase(recorded, oneMigration.db, dbUserToRemove) {
+			// Another ManagedDatabase on the same server issued it
+			continue
+		}
+		if err := c.deprovisionUser(oneMigration.ctx, oneMigration.log, admin, oneMigration.db, dbUserToRemove, time.Now()); err != nil {
 			return fmt.Errorf("Unable to delete user (%s) from db: %w", dbUserToRemove, err)
 		}
-		c.metrics.CredentialsRevoked.Inc()
+	}
+
+	// Users which are needed again, e.g. after a rollback, can't stay locked
+	for dbUsernameItem := range dbUsernames.Intersect(existingDbUsernamesSet).Iterator().C {
+		if err := releaseQuarantine(oneMigration.log, admin, oneMigration.db, dbUsernameItem.(string)); err != nil {
+			return fmt.Errorf("Unable to release user (%s) from quarantine: %w", dbUsernameItem, err)
+		}
 	}
 
 	// Create any missing credentials in the database
 	dbUsersToAdd := dbUsernames.Difference(existingDbUsernamesSet)
 	secretsToAdd := secretNames.Difference(existingSecretSet)
+
+	// The secret format must be valid before users are created for it
+	if _, err := secretFormatData(oneMigration.db, "", ""); err != nil {
+		return err
+	}
+
+	credentialsToAdd := make([]dbadmin.Credentials, 0, dbUsersToAdd.Cardinality())
 	for dbUserToAddItem := range dbUsersToAdd.Iterator().C {
 		dbUserToAdd := dbUserToAddItem.(string)
-		newPassword, err := randPassword()
+		newPassword, checkpointed := checkpointedPassword(keptSecrets, versionSecrets[usernameVersions[dbUserToAdd]], dbUserToAdd)
+		if !checkpointed {
+			if newPassword, err = randPassword(); err != nil {
+				return fmt.Errorf("Unable to add user (%s) to db: %w", dbUserToAdd, err)
+			}
+		}
+		grants := versionGrants(oneMigration.db, versions[usernameVersions[dbUserToAdd]], c.config.Current())
+		decision, err := approveCredentials(c.approver, databaseApprovalRequest(approval.KindAppCredentials, oneMigration.db, dbUserToAdd), grants)
 		if err != nil {
-			return fmt.Errorf("Unable to add user (%s) to db: %w", dbUserToAdd, err)
+			return err
+		}
+		if !decision.Allowed {
+			return policyDenial(dbUserToAdd, decision)
+		}
+		if grants, err = approvedGrants(decision, grants); err != nil {
+			return err
+		}
+		if err := enforceGrantPolicies(c.config.Current().Policies, oneMigration.log, oneMigration.db, dbUserToAdd, grants); err != nil {
+			return err
+		}
+
+		credentialsToAdd = append(credentialsToAdd, dbadmin.Credentials{
+			Username:   dbUserToAdd,
+			Password:   newPassword,
+			Grants:     grants,
+			AuthPlugin: dbadmin.AuthPlugin(oneMigration.db.Spec.AuthPlugin),
+			Attributes: credentialAttributes(oneMigration.db, ownerKindDatabase, oneMigration.db, "", time.Now()),
+		})
+	}
+
+	if err := checkUserQuota(admin, c.config.Current().Quotas, len(credentialsToAdd)); err != nil {
+		return err
+	}
+
+	var plan dbadmin.AdminPlan
+	if len(credentialsToAdd) > 0 {
+		// Write the database users, as long as nothing has moved the schema
+		// since we looked
+		oneMigration.log.Info("Provisioning user accounts", "numUsername", len(credentialsToAdd))
+		steps := []dbadmin.PlanStep{
+			{Kind: dbadmin.StepCheckSchemaVersion, Version: currentDbVersion},
+			{Kind: dbadmin.StepWriteCredentials, Credentials: credentialsToAdd},
+		}
+		plan = dbadmin.AdminPlan{
+			ID:          planID("credentials", steps),
+			Steps:       steps,
+			Checkpoints: &statusCheckpointer{ctx: oneMigration.ctx, client: c.Client, db: oneMigration.db},
+		}
+		if err := c.approvePlan(oneMigration.log, admin, oneMigration.db, plan); err != nil {
+			return err
 		}
+	}
 
-		// Write the database user
-		oneMigration.log.Info("Provisioning user account", "username", dbUserToAdd)
-		if err := admin.WriteCredentials(dbUserToAdd, newPassword); err != nil {
-			return fmt.Errorf("Unable to create new db user (%s): %w", dbUserToAdd, err)
+	// The secrets are written before the users, so that the passwords of
+	// users which were created by an interrupted reconcile are never lost
+	for _, newCredentials := range credentialsToAdd {
+		newSecretName := versionSecrets[usernameVersions[newCredentials.Username]]
+		if !secretsToAdd.Contains(newSecretName) {
+			// Written by an earlier reconcile, its password is reused
+			continue
 		}
 
-		// Write the corresponding secret
-		secretLabels := getStandardLabels(oneMigration.db, oneMigration.version)
-		newSecretName := migrationName(oneMigration.db.Name, oneMigration.version.Name)
+		// Write the corresponding secret, labeled with the version that the
+		// credentials are for
+		version := usernameVersions[newCredentials.Username]
+		labelMigration := versions[version]
+		if labelMigration == nil {
+			labelMigration = oneMigration.version
+		}
+		secretLabels, secretAnnotations, err := secretMetadata(oneMigration.db, oneMigration.db.Namespace, secretconvention.KindCredentials, version, getStandardLabels(oneMigration.db, labelMigration))
+		if err != nil {
+			return err
+		}
+		formatted, err := secretFormatData(oneMigration.db, newCredentials.Username, newCredentials.Password)
+		if err != nil {
+			return err
+		}
 		if err := writeCredentialsSecret(
 			oneMigration.ctx,
 			c.Client,
 			oneMigration.db.Namespace,
 			newSecretName,
-			dbUserToAdd,
-			newPassword,
+			newCredentials.Username,
+			newCredentials.Password,
+			formatted,
 			secretLabels,
+			secretAnnotations,
 			oneMigration.db,
 			c.Scheme,
 		); err != nil {
 			return fmt.Errorf("Unable to write secret (%s) to cluster: %w", newSecretName, err)
 		}
 
-		c.metrics.CredentialsCreated.Inc()
-
 		secretsToAdd.Remove(newSecretName)
 	}
 
-	// TODO: handle the case of regenerating any database users for which we've
-	// lost the secret (secretsToAdd remainder)
+	if len(credentialsToAdd) > 0 {
+		if err := admin.ExecutePlan(plan); err != nil {
+			return fmt.Errorf("Unable to create new db users: %w", err)
+		}
+	}
+
+	for _, newCredentials := range credentialsToAdd {
+		c.metrics.CredentialsCreated.Inc()
+		c.verifyGrants(oneMigration.log, oneMigration.db, newCredentials)
+	}
+
+	// TODO: handle the case of regenerating any database users whose secret
+	// was deleted by someone else (secretsToAdd remainder)
 	return nil
 }
 
@@ -358,7 +1109,13 @@ func (c *ManagedDatabaseController) SetupWithManager(mgr ctrl.Manager) error {
 		For(&dba.ManagedDatabase{}).
 		Owns(&batchv1.Job{}).
 		Owns(&corev1.Secret{}).
-		Complete(reconcile.Func(c.ReconcileManagedDatabase))
+		Owns(&corev1.Service{}).
+		Watches(
+			&source.Kind{Type: &dba.ManagedDatabaseClass{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(c.managedDatabasesForClass)},
+		).
+		Watches(&source.Channel{Source: c.cloudEvents}, &handler.EnqueueRequestForObject{}).
+		Complete(drained("manageddatabase", traced(c.diagnostics, "manageddatabase", c.ReconcileManagedDatabase)))
 	if err != nil {
 		return fmt.Errorf("Unable to finish operator setup: %w", err)
 	}
@@ -368,37 +1125,416 @@ func (c *ManagedDatabaseController) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(reconcile.Func(c.ReconcileDatabaseMigration))
 }
 
-func initializeAdminConnection(ctx context.Context, log logr.Logger, apiClient client.Client, namespace string, dbSpec *dba.ManagedDatabaseSpec) (dbadmin.DbAdmin, error) {
+// phaseStatementCategories are the categories of the statements which the
+// phases that change the database mostly issue
+var phaseStatementCategories = map[string]string{
+	phaseCredentials:   dbadmin.CategoryDCL,
+	phaseRotation:      dbadmin.CategoryDCL,
+	phaseQuarantine:    dbadmin.CategoryDCL,
+	phaseReplicas:      dbadmin.CategoryDCL,
+	phaseMigration:     dbadmin.CategoryDDL,
+	phasePostMigration: dbadmin.CategoryDDL,
+	phaseRoutines:      dbadmin.CategoryDDL,
+	phaseMasking:       dbadmin.CategoryDDL,
+	phaseScheduling:    dbadmin.CategoryDDL,
+	phasePartitioning:  dbadmin.CategoryDDL,
+	phaseCharset:       dbadmin.CategoryDDL,
+	phaseExtensions:    dbadmin.CategoryDDL,
+	phaseRetention:     dbadmin.CategoryDML,
+	phaseSeedData:      dbadmin.CategoryDML,
+	phaseVersionCheck:  dbadmin.CategoryQuery,
+	phaseConsistency:   dbadmin.CategoryQuery,
+	phaseIntegrity:     dbadmin.CategoryQuery,
+	phaseParameters:    dbadmin.CategoryOther,
+}
 
-	secretName := types.NamespacedName{Namespace: namespace, Name: dbSpec.Connection.DSNSecret}
+// phaseLogger returns the logger of a reconcile phase, tagged with the
+// phase and the category of the statements it issues
+func phaseLogger(log logr.Logger, phase string) logr.Logger {
+	if category, ok := phaseStatementCategories[phase]; ok {
+		return log.WithValues("phase", phase, "category", category)
+	}
+	return log.WithValues("phase", phase)
+}
 
-	var credsSecret corev1.Secret
-	if err := apiClient.Get(ctx, secretName, &credsSecret); err != nil {
-		log.Error(err, "unable to fetch credentials secret")
-		return nil, err
+// sqlLogger returns the logger which should receive the SQL statement
+// templates issued by a DbAdmin, which are only logged in debug mode.
+func sqlLogger(log logr.Logger, debug bool) logr.Logger {
+	if debug {
+		return log.WithName("sql")
 	}
+	return logf.NullLogger{}
+}
+
+// dsnBuilders contains the DSN builder for each engine which supports typed
+// connection specs
+var dsnBuilders = map[string]dbadmin.DSNBuilder{
+	"mysql": mysqladmin.DSNBuilder{},
+}
+
+// hostSelectors contains the host selection for each engine which supports
+// connection specs with several hosts
+var hostSelectors = map[string]dbadmin.HostSelector{
+	"mysql": mysqladmin.HostSelector{},
+}
 
-	dsn := string(credsSecret.Data["dsn"])
+// endpointCheckers contains the health check for each engine which supports
+// read replicas
+var endpointCheckers = map[string]dbadmin.EndpointChecker{
+	"mysql": mysqladmin.EndpointChecker{},
+}
+
+// faultInjector wraps every DbAdmin when fault injection is enabled
+var faultInjector *faults.Injector
+
+// EnableFaultInjection makes every database connection opened by the
+// controllers randomly delay and fail operations. It is only meant for
+// testing recovery in staging, and must be called before the controllers
+// are started.
+func EnableFaultInjection(injector *faults.Injector) {
+	faultInjector = injector
+}
+
+func initializeAdminConnection(ctx context.Context, log logr.Logger, diag *diagnostics.Recorder, apiClient client.Client, namespace string, dbSpec *dba.ManagedDatabaseSpec) (dbadmin.DbAdmin, error) {
+	if dbSpec.Connection.Tunnel != nil && dbSpec.Connection.Spec != nil && len(dbSpec.Connection.Spec.Hosts) > 0 {
+		// Selecting a host connects to each of them directly
+		return nil, errors.New("Connections through a tunnel can't select between several hosts")
+	}
+
+	dsn, err := connectionDSN(ctx, log, apiClient, namespace, &dbSpec.Connection)
+	if err != nil {
+		return nil, err
+	}
 
 	var migrationEngine dbadmin.MigrationEngine
 	switch dbSpec.MigrationEngine {
 	case "alembic":
 		migrationEngine = alembic.CreateMigrationEngine()
+	case "embedded":
+		migrationEngine = embedded.CreateMigrationEngine()
+	}
+
+	factory, ok := dbadmin.Lookup(dbSpec.Connection.Engine)
+	if !ok {
+		return nil, fmt.Errorf("Unknown database engine: %s", dbSpec.Connection.Engine)
+	}
+	options, err := backendOptions(ctx, apiClient, namespace, dbSpec, diag)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := dbadmin.AdminSettings{
+		MigrationEngine: migrationEngine,
+		Log:             sqlLogger(log, diag != nil),
+		ObserveRetry: func(reason string) {
+			statementRetries.WithLabelValues(reason).Inc()
+		},
+		Parameters: dbSpec.Connection.Parameters,
+		Options:    options,
+	}
+	if session := dbSpec.OperatorSession; session != nil {
+		settings.Session = &dbadmin.SessionSettings{
+			SQLMode:               session.SQLMode,
+			LockWaitTimeout:       session.LockWaitTimeout.Duration,
+			InnoDBLockWaitTimeout: session.InnoDBLockWaitTimeout.Duration,
+			StatementTimeout:      session.StatementTimeout.Duration,
+		}
+	}
+	admin, err := factory(dsn, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse unsupported servers before anything is changed on them
+	server, err := admin.DetectServer()
+	if err != nil {
+		admin.Close()
+		return nil, err
+	}
+	if err := checkCompatibility(dbSpec, server); err != nil {
+		admin.Close()
+		return nil, err
+	}
+
+	if dbSpec.Connection.ReaderDSNSecret != "" {
+		// The reader is optional, the primary answers every check when it
+		// can't be used
+		reader, err := readerConnection(ctx, apiClient, dbSpec.Connection.Engine, namespace, dbSpec.Connection.ReaderDSNSecret, func(readerDSN string) (dbadmin.DbAdmin, error) {
+			readerSettings := settings
+			readerSettings.Log = sqlLogger(log.WithValues("endpoint", "reader"), diag != nil)
+			return factory(readerDSN, readerSettings)
+		})
+		if err != nil {
+			log.Error(err, "unable to connect to reader, using the primary for every check")
+		} else {
+			admin = readers.Wrap(admin, reader, log)
+		}
+	}
+
+	if len(dbSpec.Connection.RegionalPrimaries) > 0 {
+		endpoints := connectRegionalPrimaries(ctx, log, apiClient, namespace, dbSpec, func(name, regionalDSN string) (dbadmin.DbAdmin, error) {
+			regionalSettings := settings
+			regionalSettings.Log = sqlLogger(log.WithValues("endpoint", name), diag != nil)
+			return factory(regionalDSN, regionalSettings)
+		})
+		admin = regions.Wrap(admin, endpoints, observeRegionalResults(namespace, dbSpec), log)
+	}
+
+	if faultInjector != nil {
+		return faultInjector.Wrap(admin), nil
+	}
+	return admin, nil
+}
+
+// backendOptions builds the options of the in-tree backend from the parts of
+// the spec which only it supports. Out-of-tree backends are configured with
+// the connection's parameters instead.
+func backendOptions(ctx context.Context, apiClient client.Client, namespace string, dbSpec *dba.ManagedDatabaseSpec, diag *diagnostics.Recorder) ([]interface{}, error) {
+	if dbSpec.Connection.Engine != "mysql" {
+		if dbSpec.Galera != nil || dbSpec.GroupReplication || dbSpec.AutoPause != nil || dbSpec.Connection.Tunnel != nil {
+			return nil, fmt.Errorf("Database engine %s doesn't support galera, groupReplication, autoPause or tunnel", dbSpec.Connection.Engine)
+		}
+		return nil, nil
 	}
 
-	switch dbSpec.Connection.Engine {
-	case "mysql":
-		return mysqladmin.CreateMySQLAdmin(dsn, migrationEngine)
+	var options []interface{}
+	if dbSpec.Galera != nil {
+		options = append(options, mysqladmin.WithGalera(mysqladmin.GaleraOptions{
+			RollingSchemaUpgrades: dbSpec.Galera.RollingSchemaUpgrades,
+		}))
+	}
+	if dbSpec.GroupReplication {
+		options = append(options, mysqladmin.WithGroupReplication())
+	}
+	if tunnel := dbSpec.Connection.Tunnel; tunnel != nil {
+		tunnelOptions, err := connectionTunnel(ctx, apiClient, namespace, tunnel)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, mysqladmin.WithTunnel(tunnelOptions))
 	}
-	return nil, fmt.Errorf("Unknown database engine: %s", dbSpec.Connection.Engine)
+	if autoPause := dbSpec.AutoPause; autoPause != nil {
+		options = append(options, mysqladmin.WithAutoPause(mysqladmin.AutoPauseOptions{
+			WakeUp:        autoPause.WakeUp,
+			ResumeTimeout: autoPause.ResumeTimeout.Duration,
+		}))
+	}
+	if diag != nil {
+		options = append(options, mysqladmin.WithDiagnostics(diag))
+	}
+	return options, nil
+}
+
+// connectionTunnel reads the credentials of a tunnel from its secret
+func connectionTunnel(ctx context.Context, apiClient client.Client, namespace string, tunnel *dba.ConnectionTunnel) (mysqladmin.TunnelOptions, error) {
+	options := mysqladmin.TunnelOptions{
+		Type:    tunnel.Type,
+		Address: tunnel.Address,
+		User:    tunnel.User,
+	}
+	if tunnel.CredentialsSecret == "" {
+		return options, nil
+	}
+
+	var secret corev1.Secret
+	secretName := types.NamespacedName{Namespace: namespace, Name: tunnel.CredentialsSecret}
+	if err := apiClient.Get(ctx, secretName, &secret); err != nil {
+		return mysqladmin.TunnelOptions{}, fmt.Errorf("Unable to fetch tunnel credentials secret (%s): %w", secretName, err)
+	}
+	options.PrivateKey = secret.Data["privateKey"]
+	options.KnownHosts = secret.Data["knownHosts"]
+	options.Password = string(secret.Data["password"])
+	return options, nil
+}
+
+// readerConnection reads the reader's DSN from its secret and connects to it
+// with create
+func readerConnection(ctx context.Context, apiClient client.Client, engine, namespace, secretName string, create func(string) (dbadmin.DbAdmin, error)) (dbadmin.DbAdmin, error) {
+	var dsnSecret corev1.Secret
+	if err := apiClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: secretName}, &dsnSecret); err != nil {
+		return nil, fmt.Errorf("Unable to fetch reader DSN secret (%s): %w", secretName, err)
+	}
+	dsn := string(dsnSecret.Data["dsn"])
+	if err := checkDSNScope(engine, dsn); err != nil {
+		return nil, err
+	}
+	return create(dsn)
+}
+
+// connectionDSN returns the DSN for a database, either read verbatim from the
+// DSN secret or built from the typed connection spec.
+func connectionDSN(ctx context.Context, log logr.Logger, apiClient client.Client, namespace string, conn *dba.DatabaseConnectionInfo) (string, error) {
+	if (conn.DSNSecret == "") == (conn.Spec == nil) {
+		return "", errors.New("Exactly one of dsnSecret and spec must be specified for the connection")
+	}
+	if err := checkNamespaceScope(namespace); err != nil {
+		return "", err
+	}
+
+	if conn.Spec == nil {
+		secretName := types.NamespacedName{Namespace: namespace, Name: conn.DSNSecret}
+
+		var dsnSecret corev1.Secret
+		if err := apiClient.Get(ctx, secretName, &dsnSecret); err != nil {
+			log.Error(err, "unable to fetch credentials secret")
+			return "", err
+		}
+
+		dsn := string(dsnSecret.Data["dsn"])
+		if err := checkDSNScope(conn.Engine, dsn); err != nil {
+			return "", err
+		}
+		return dsn, nil
+	}
+
+	builder, ok := dsnBuilders[conn.Engine]
+	if !ok {
+		return "", fmt.Errorf("Database engine %s does not support typed connection specs", conn.Engine)
+	}
+
+	secretName := types.NamespacedName{Namespace: namespace, Name: conn.Spec.CredentialsSecret}
+
+	var credsSecret corev1.Secret
+	if err := apiClient.Get(ctx, secretName, &credsSecret); err != nil {
+		log.Error(err, "unable to fetch credentials secret")
+		return "", err
+	}
+
+	if err := checkCandidateScope(conn.Spec); err != nil {
+		return "", err
+	}
+	spec, err := selectConnectionHost(conn.Engine, typedConnectionSpec(conn.Spec, string(credsSecret.Data["username"]), string(credsSecret.Data["password"])))
+	if err != nil {
+		return "", err
+	}
+	if len(conn.Spec.Hosts) > 0 {
+		log.Info("Selected host", "host", spec.Host, "port", spec.Port, "selection", conn.Spec.HostSelection)
+	}
+
+	dsn, err := builder.BuildDSN(spec)
+	if err != nil {
+		return "", fmt.Errorf("Unable to build connection DSN: %w", err)
+	}
+	if err := checkDSNScope(conn.Engine, dsn); err != nil {
+		return "", err
+	}
+	return dsn, nil
+}
+
+// selectConnectionHost chooses which of the hosts of a connection spec to
+// connect to, specs with a single host are returned as they are
+func selectConnectionHost(engine string, spec dbadmin.ConnectionSpec) (dbadmin.ConnectionSpec, error) {
+	if len(spec.Hosts) == 0 {
+		return spec, nil
+	}
+
+	selector, ok := hostSelectors[engine]
+	if !ok {
+		return dbadmin.ConnectionSpec{}, fmt.Errorf("Database engine %s does not support connection specs with several hosts", engine)
+	}
+	selected, err := selector.SelectHost(spec)
+	if err != nil {
+		return dbadmin.ConnectionSpec{}, fmt.Errorf("Unable to select connection host: %w", err)
+	}
+	return selected, nil
+}
+
+// typedConnectionSpec converts a typed connection spec to its engine
+// independent equivalent, which logs in with the supplied credentials
+func typedConnectionSpec(spec *dba.ConnectionSpec, username, password string) dbadmin.ConnectionSpec {
+	var hosts []dbadmin.HostPort
+	for _, host := range spec.Hosts {
+		hosts = append(hosts, dbadmin.HostPort{Host: host.Host, Port: int(host.Port)})
+	}
+
+	return dbadmin.ConnectionSpec{
+		Host:     spec.Host,
+		Port:     int(spec.Port),
+		Socket:   spec.Socket,
+		Dialer:   spec.Dialer,
+		Database: spec.Database,
+		Username: username,
+		Password: password,
+		TLS:      dbadmin.TLSMode(spec.TLS),
+		Params:   spec.Params,
+
+		ServerPublicKey:         spec.ServerPublicKey,
+		AllowPublicKeyRetrieval: spec.AllowPublicKeyRetrieval,
+
+		Hosts:         hosts,
+		HostSelection: dbadmin.HostSelection(spec.HostSelection),
+	}
+}
+
+// databaseGrants converts the grants listed in the spec to their DbAdmin
+// equivalents, applying the default class where none was specified. Specs
+// which list no grants get the default grants of the config.
+func databaseGrants(dbSpec *dba.ManagedDatabaseSpec, cfg config.Config) []dbadmin.DatabaseGrant {
+	if len(dbSpec.Grants) == 0 {
+		return defaultGrants(cfg)
+	}
+
+	grants := make([]dbadmin.DatabaseGrant, 0, len(dbSpec.Grants))
+	for _, grant := range dbSpec.Grants {
+		class := dbadmin.GrantClass(grant.Class)
+		if class == "" {
+			class = cfg.DefaultGrantClass
+		}
+
+		var tables []dbadmin.TableGrant
+		for _, table := range grant.Tables {
+			tables = append(tables, dbadmin.TableGrant{Table: table.Name, Columns: table.Columns})
+		}
+		grants = append(grants, dbadmin.DatabaseGrant{Database: grant.Database, Class: class, Tables: tables})
+	}
+	return grants
+}
+
+// defaultGrants converts the default grants of the config to their DbAdmin
+// equivalents
+func defaultGrants(cfg config.Config) []dbadmin.DatabaseGrant {
+	grants := make([]dbadmin.DatabaseGrant, 0, len(cfg.DefaultGrants))
+	for _, grant := range cfg.DefaultGrants {
+		class := grant.Class
+		if class == "" {
+			class = cfg.DefaultGrantClass
+		}
+
+		var tables []dbadmin.TableGrant
+		for _, table := range grant.Tables {
+			tables = append(tables, dbadmin.TableGrant{Table: table})
+		}
+		grants = append(grants, dbadmin.DatabaseGrant{Database: grant.Database, Class: class, Tables: tables})
+	}
+	return grants
+}
+
+// temporaryErrorDelay returns how long to wait before retrying a reconcile
+// which failed with a temporary error, after it had failed retries times in a
+// row
+func temporaryErrorDelay(backoff config.Backoff, err error, retries int) time.Duration {
+	return xerrors.Backoff(err, retries, backoff.TemporaryErrorDelay.Duration, backoff.MaxTemporaryErrorDelay.Duration)
+}
+
+func connectionSecretName(dbName string) string {
+	return fmt.Sprintf("%s-connection", dbName)
 }
 
 func migrationName(dbName, migrationName string) string {
 	return fmt.Sprintf("%s-%s", dbName, migrationName)
 }
 
-func migrationDBUsername(migrationName string) string {
-	return fmt.Sprintf("%s%s", DBUsernamePrefix, migrationName)
+// versionUsername returns the username of the credentials for a schema
+// version, within the server's limit. A user which already exists for the
+// version keeps its name, whether it predates derived names or the limit has
+// changed since, so that its credentials aren't replaced.
+func versionUsername(version string, limit int, existing []string) (string, error) {
+	for _, username := range existing {
+		if dbadmin.UsernameDerivedFrom(username, DBUsernamePrefix, version) {
+			return username, nil
+		}
+	}
+	return dbadmin.DeriveUsername(DBUsernamePrefix, version, limit)
 }
 
 func randPassword() (string, error) {
@@ -419,23 +1555,6 @@ func getStandardLabels(db *dba.ManagedDatabase, migration *dba.DatabaseMigration
 	}
 }
 
-func handleError(ctx context.Context, apiClient client.Client, db *dba.ManagedDatabase, log logr.Logger, err error) (finalResult ctrl.Result, finalError error) {
-	var maybeTemporary xerrors.EnhancedError
-
-	statusError := dba.ManagedDatabaseError{Message: err.Error(), Temporary: false}
-
-	if errors.As(err, &maybeTemporary) && maybeTemporary.Temporary() {
-		finalResult = requeueAfterDelay
-		finalError = err
-		statusError.Temporary = true
-	}
-
-	db.Status.Errors = append(db.Status.Errors, statusError)
-
-	if err := apiClient.Status().Update(ctx, db); err != nil {
-		log.Error(err, "Unable to update ManagedDatabase status block")
-		return ctrl.Result{}, err
-	}
-
-	return finalResult, finalError
+func (c *ManagedDatabaseController) handleError(ctx context.Context, db *dba.ManagedDatabase, log logr.Logger, phase string, err error) (ctrl.Result, error) {
+	return c.reportErrors(ctx, db, log, []phaseError{{phase: phase, err: err}})
 }
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+)
+
+// grantClassMasked is a credential request class which may only select from
+// the masked views of the database, instead of any of its tables
+const grantClassMasked dbadmin.GrantClass = "masked"
+
+// maskedViewGrants returns read-only grants on each of the masked views
+func maskedViewGrants(db *dba.ManagedDatabase) ([]dbadmin.DatabaseGrant, error) {
+	if len(db.Spec.MaskedViews) == 0 {
+		// An unscoped grant would cover every table in the database
+		return nil, fmt.Errorf("ManagedDatabase %s/%s does not define any masked views", db.Namespace, db.Name)
+	}
+
+	tables := make([]dbadmin.TableGrant, 0, len(db.Spec.MaskedViews))
+	for _, view := range db.Spec.MaskedViews {
+		tables = append(tables, dbadmin.TableGrant{Table: view.Name})
+	}
+	return []dbadmin.DatabaseGrant{{Class: dbadmin.GrantClassReadOnly, Tables: tables}}, nil
+}
+
+func maskedView(view dba.MaskedView) dbadmin.MaskedView {
+	columns := make([]dbadmin.ViewColumn, 0, len(view.Columns))
+	for _, column := range view.Columns {
+		columns = append(columns, dbadmin.ViewColumn{Name: column.Name, Mask: dbadmin.ColumnMask(column.Mask)})
+	}
+	return dbadmin.MaskedView{Name: view.Name, Table: view.Table, Columns: columns}
+}
+
+func maskedViewSpecHash(view dba.MaskedView) (string, error) {
+	encoded, err := json.Marshal(view)
+	if err != nil {
+		return "", fmt.Errorf("Unable to encode masked view (%s): %w", view.Name, err)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// reconcileMaskedViews writes every masked view whose spec has changed or
+// whose stored definition no longer matches the one recorded when it was
+// written, and drops the views which were removed from the spec.
+func (c *ManagedDatabaseController) reconcileMaskedViews(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase) error {
+	recorded := make(map[string]dba.MaskedViewStatus, len(db.Status.MaskedViews))
+	for _, view := range db.Status.MaskedViews {
+		recorded[view.Name] = view
+	}
+
+	written := make([]dba.MaskedViewStatus, 0, len(db.Spec.MaskedViews))
+	for _, view := range db.Spec.MaskedViews {
+		specHash, err := maskedViewSpecHash(view)
+		if err != nil {
+			return err
+		}
+		checksum, err := admin.GetViewChecksum(view.Name)
+		if err != nil {
+			return err
+		}
+
+		previous, known := recorded[view.Name]
+		delete(recorded, view.Name)
+		if known && previous.SpecHash == specHash && previous.Checksum == checksum {
+			written = append(written, previous)
+			continue
+		}
+
+		if known && previous.SpecHash == specHash {
+			log.Info("Masked view has drifted from its spec", "view", view.Name)
+			c.notifier.Notify(notify.Event{
+				Reason:    "MaskedViewDrifted",
+				Namespace: db.Namespace,
+				Name:      db.Name,
+				Message:   fmt.Sprintf("Masked view %s was changed outside of the operator and is being restored", view.Name),
+			})
+		}
+
+		log.Info("Writing masked view", "view", view.Name)
+		if err := admin.WriteMaskedView(maskedView(view)); err != nil {
+			return err
+		}
+		if checksum, err = admin.GetViewChecksum(view.Name); err != nil {
+			return err
+		}
+		written = append(written, dba.MaskedViewStatus{Name: view.Name, SpecHash: specHash, Checksum: checksum})
+	}
+
+	for _, removed := range db.Status.MaskedViews {
+		if _, ok := recorded[removed.Name]; !ok {
+			continue
+		}
+		log.Info("Dropping masked view which was removed from the spec", "view", removed.Name)
+		if err := admin.DropView(removed.Name); err != nil {
+			return err
+		}
+	}
+
+	db.Status.MaskedViews = written
+	return nil
+}
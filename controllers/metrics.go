@@ -6,6 +6,12 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// statementRetries is shared by the admin connections of every controller,
+// it is registered with the metrics of the ManagedDatabaseController
+var statementRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "dba_operator_statement_retries_total",
+}, []string{"reason"})
+
 // ManagedDatabaseControllerMetrics should contain all of the metrics exported
 // by the ManagedDatabaseController
 type ManagedDatabaseControllerMetrics struct {
@@ -14,9 +20,126 @@ type ManagedDatabaseControllerMetrics struct {
 	CredentialsRevoked   prometheus.Counter
 	RegisteredMigrations prometheus.Gauge
 	ManagedDatabases     prometheus.Gauge
+	MetadataLockPileUps  prometheus.Counter
+	SessionsKilled       prometheus.Counter
+	MigrationsAborted    prometheus.Counter
+	QuotaUsed            *prometheus.GaugeVec
+	QuotaLimit           *prometheus.GaugeVec
+
+	MigrationProgress      *prometheus.GaugeVec
+	MigrationRowsProcessed *prometheus.GaugeVec
+	MigrationDuration      *prometheus.GaugeVec
+
+	PartitionsAhead  *prometheus.GaugeVec
+	PartitionsBehind *prometheus.GaugeVec
+
+	RetentionRowsPurged *prometheus.CounterVec
+	RetentionBackoffs   *prometheus.CounterVec
+	RetentionCaughtUp   *prometheus.GaugeVec
+
+	BinlogConsumerIdle      *prometheus.GaugeVec
+	BinlogConsumerAbandoned *prometheus.GaugeVec
+
+	ConsistencyCheckValue  *prometheus.GaugeVec
+	ConsistencyCheckPassed *prometheus.GaugeVec
+
+	IntegrityRowsScanned  *prometheus.CounterVec
+	IntegrityOrphansFound *prometheus.CounterVec
+	IntegrityOrphanedRows *prometheus.GaugeVec
+
+	CloudEvents *prometheus.CounterVec
+
+	// StatementRetries counts the statements retried by every admin
+	// connection the controllers open
+	StatementRetries *prometheus.CounterVec
+}
+
+// FleetRotationControllerMetrics should contain all of the metrics exported
+// by the FleetRotationController
+type FleetRotationControllerMetrics struct {
+	CredentialsRotated  prometheus.Counter
+	RotationFailures    prometheus.Counter
+	RotationPassAborted prometheus.Counter
+	RotationsRolledBack prometheus.Counter
+	NextRotation        *prometheus.GaugeVec
+}
+
+// CredentialRequestControllerMetrics should contain all of the metrics
+// exported by the CredentialRequestController
+type CredentialRequestControllerMetrics struct {
+	CredentialRequestsGranted prometheus.Counter
+	CredentialRequestsDenied  prometheus.Counter
+	CredentialRequestsRevoked prometheus.Counter
+}
+
+// OperationControllerMetrics should contain all of the metrics exported by
+// the OperationController
+type OperationControllerMetrics struct {
+	OperationsSucceeded   prometheus.Counter
+	OperationsFailed      prometheus.Counter
+	FailoverDrills        *prometheus.CounterVec
+	FailoverDrillRecovery *prometheus.GaugeVec
+}
+
+// AnonymizedSnapshotControllerMetrics should contain all of the metrics
+// exported by the AnonymizedSnapshotController
+type AnonymizedSnapshotControllerMetrics struct {
+	SnapshotsSucceeded prometheus.Counter
+	SnapshotsFailed    prometheus.Counter
+	SnapshotRowsCopied prometheus.Counter
+}
+
+// GarbageCollectionControllerMetrics should contain all of the metrics
+// exported by the GarbageCollectionController
+type GarbageCollectionControllerMetrics struct {
+	OrphanedUsers   prometheus.Gauge
+	OrphanedSecrets prometheus.Gauge
+	OrphansRemoved  prometheus.Counter
+}
+
+// LoginTrackingControllerMetrics should contain all of the metrics exported
+// by the LoginTrackingController
+type LoginTrackingControllerMetrics struct {
+	LastLogin      *prometheus.GaugeVec
+	SampleFailures prometheus.Counter
+}
+
+// LeastPrivilegeControllerMetrics should contain all of the metrics exported
+// by the LeastPrivilegeController
+type LeastPrivilegeControllerMetrics struct {
+	Suggestions    *prometheus.GaugeVec
+	Revocations    prometheus.Counter
+	SampleFailures prometheus.Counter
+}
+
+// InventoryControllerMetrics should contain all of the metrics exported by
+// the InventoryController
+type InventoryControllerMetrics struct {
+	LastExport       prometheus.Gauge
+	DeliveryFailures prometheus.Counter
 }
 
-func getAllMetrics(metrics ManagedDatabaseControllerMetrics) []prometheus.Collector {
+// CredentialAgeControllerMetrics should contain all of the metrics exported
+// by the CredentialAgeController
+type CredentialAgeControllerMetrics struct {
+	CredentialAge           *prometheus.GaugeVec
+	CredentialSinceRotation *prometheus.GaugeVec
+	CredentialUntilRotation *prometheus.GaugeVec
+	RotationOverdue         prometheus.Counter
+}
+
+// CapacityReportControllerMetrics should contain all of the metrics exported
+// by the CapacityReportController
+type CapacityReportControllerMetrics struct {
+	DataBytes        *prometheus.GaugeVec
+	IndexBytes       *prometheus.GaugeVec
+	Connections      *prometheus.GaugeVec
+	QueriesPerSecond *prometheus.GaugeVec
+	SampleFailures   prometheus.Counter
+	DeliveryFailures prometheus.Counter
+}
+
+func getAllMetrics(metrics interface{}) []prometheus.Collector {
 	metricsValue := reflect.ValueOf(metrics)
 	collectors := make([]prometheus.Collector, 0, metricsValue.NumField())
 	for i := 0; i < metricsValue.NumField(); i++ {
@@ -42,5 +165,230 @@ func generateManagedDatabaseControllerMetrics() ManagedDatabaseControllerMetrics
 		ManagedDatabases: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "dba_operator_managed_databases_total",
 		}),
+		MetadataLockPileUps: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_metadata_lock_pileups_total",
+		}),
+		SessionsKilled: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_sessions_killed_total",
+		}),
+		MigrationsAborted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_migrations_aborted_total",
+		}),
+		QuotaUsed: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_instance_quota_used",
+		}, []string{"instance", "resource"}),
+		QuotaLimit: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_instance_quota_limit",
+		}, []string{"instance", "resource"}),
+		MigrationProgress: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_migration_progress_ratio",
+		}, []string{"namespace", "database", "migration"}),
+		MigrationRowsProcessed: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_migration_rows_processed",
+		}, []string{"namespace", "database", "migration"}),
+		MigrationDuration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_migration_duration_seconds",
+		}, []string{"namespace", "database", "migration"}),
+		PartitionsAhead: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_partitions_ahead",
+		}, []string{"namespace", "database", "table"}),
+		PartitionsBehind: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_partitions_behind",
+		}, []string{"namespace", "database", "table"}),
+		RetentionRowsPurged: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dba_operator_retention_rows_purged_total",
+		}, []string{"namespace", "database", "rule"}),
+		RetentionBackoffs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dba_operator_retention_backoffs_total",
+		}, []string{"namespace", "database", "rule"}),
+		RetentionCaughtUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_retention_caught_up",
+		}, []string{"namespace", "database", "rule"}),
+		BinlogConsumerIdle: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_binlog_consumer_idle_seconds",
+		}, []string{"namespace", "database", "username"}),
+		BinlogConsumerAbandoned: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_binlog_consumer_abandoned",
+		}, []string{"namespace", "database", "username"}),
+		ConsistencyCheckValue: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_consistency_check_value",
+		}, []string{"namespace", "database", "check", "side"}),
+		ConsistencyCheckPassed: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_consistency_check_passed",
+		}, []string{"namespace", "database", "check"}),
+		IntegrityRowsScanned: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dba_operator_integrity_rows_scanned_total",
+		}, []string{"namespace", "database", "check"}),
+		IntegrityOrphansFound: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dba_operator_integrity_orphans_found_total",
+		}, []string{"namespace", "database", "check"}),
+		IntegrityOrphanedRows: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_integrity_orphaned_rows",
+		}, []string{"namespace", "database", "check"}),
+		CloudEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dba_operator_cloud_events_total",
+		}, []string{"result"}),
+		StatementRetries: statementRetries,
+	}
+}
+
+func generateFleetRotationControllerMetrics() FleetRotationControllerMetrics {
+	return FleetRotationControllerMetrics{
+		CredentialsRotated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_credentials_rotated_total",
+		}),
+		RotationFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_credential_rotation_failures_total",
+		}),
+		RotationPassAborted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_credential_rotation_passes_aborted_total",
+		}),
+		RotationsRolledBack: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_credential_rotations_rolled_back_total",
+		}),
+		NextRotation: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_credential_next_rotation_timestamp_seconds",
+		}, []string{"namespace", "database"}),
+	}
+}
+
+func generateCredentialRequestControllerMetrics() CredentialRequestControllerMetrics {
+	return CredentialRequestControllerMetrics{
+		CredentialRequestsGranted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_credential_requests_granted_total",
+		}),
+		CredentialRequestsDenied: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_credential_requests_denied_total",
+		}),
+		CredentialRequestsRevoked: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_credential_requests_revoked_total",
+		}),
+	}
+}
+
+func generateOperationControllerMetrics() OperationControllerMetrics {
+	return OperationControllerMetrics{
+		OperationsSucceeded: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_operations_succeeded_total",
+		}),
+		OperationsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_operations_failed_total",
+		}),
+		FailoverDrills: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dba_operator_failover_drills_total",
+		}, []string{"succeeded"}),
+		FailoverDrillRecovery: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_failover_drill_recovery_seconds",
+		}, []string{"namespace", "database", "stage"}),
+	}
+}
+
+func generateAnonymizedSnapshotControllerMetrics() AnonymizedSnapshotControllerMetrics {
+	return AnonymizedSnapshotControllerMetrics{
+		SnapshotsSucceeded: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_snapshots_succeeded_total",
+		}),
+		SnapshotsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_snapshots_failed_total",
+		}),
+		SnapshotRowsCopied: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_snapshot_rows_copied_total",
+		}),
+	}
+}
+
+func generateGarbageCollectionControllerMetrics() GarbageCollectionControllerMetrics {
+	return GarbageCollectionControllerMetrics{
+		OrphanedUsers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "dba_operator_orphaned_users_total",
+		}),
+		OrphanedSecrets: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "dba_operator_orphaned_secrets_total",
+		}),
+		OrphansRemoved: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_orphans_removed_total",
+		}),
+	}
+}
+
+// credentialLabels identify the secret holding each credential
+var credentialLabels = []string{"namespace", "secret", "database"}
+
+func generateCredentialAgeControllerMetrics() CredentialAgeControllerMetrics {
+	return CredentialAgeControllerMetrics{
+		CredentialAge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_credential_age_seconds",
+		}, credentialLabels),
+		CredentialSinceRotation: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_credential_seconds_since_rotation",
+		}, credentialLabels),
+		CredentialUntilRotation: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_credential_seconds_until_rotation",
+		}, credentialLabels),
+		RotationOverdue: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_credential_rotation_overdue_total",
+		}),
+	}
+}
+
+func generateLoginTrackingControllerMetrics() LoginTrackingControllerMetrics {
+	return LoginTrackingControllerMetrics{
+		LastLogin: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_credential_last_login_timestamp_seconds",
+		}, []string{"namespace", "database", "username"}),
+		SampleFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_login_sample_failures_total",
+		}),
+	}
+}
+
+func generateLeastPrivilegeControllerMetrics() LeastPrivilegeControllerMetrics {
+	return LeastPrivilegeControllerMetrics{
+		Suggestions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_privilege_tightening_suggestions",
+		}, []string{"namespace", "database"}),
+		Revocations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_privilege_revocations_total",
+		}),
+		SampleFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_privilege_sample_failures_total",
+		}),
+	}
+}
+
+func generateInventoryControllerMetrics() InventoryControllerMetrics {
+	return InventoryControllerMetrics{
+		LastExport: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "dba_operator_inventory_last_export_timestamp_seconds",
+		}),
+		DeliveryFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_inventory_delivery_failures_total",
+		}),
+	}
+}
+
+// capacityLabels identify the database and the server it is on
+var capacityLabels = []string{"namespace", "database", "instance"}
+
+func generateCapacityReportControllerMetrics() CapacityReportControllerMetrics {
+	return CapacityReportControllerMetrics{
+		DataBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_database_data_bytes",
+		}, capacityLabels),
+		IndexBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_database_index_bytes",
+		}, capacityLabels),
+		Connections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_database_connections",
+		}, capacityLabels),
+		QueriesPerSecond: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dba_operator_database_queries_per_second",
+		}, capacityLabels),
+		SampleFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_capacity_sample_failures_total",
+		}),
+		DeliveryFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dba_operator_capacity_report_delivery_failures_total",
+		}),
 	}
 }
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+)
+
+// BinlogBytesAtStartAnnotation is written to a migration's Job when it is
+// created, and contains the size of the binary logs at that time, which the
+// binlog growth guard is measured from
+const BinlogBytesAtStartAnnotation = "dbaoperator.app-sre.redhat.com/binlog-bytes-at-start"
+
+// Reasons that a migration is aborted
+const (
+	abortReasonDeadline     = "DeadlineExceeded"
+	abortReasonBinlogGrowth = "BinlogGrowth"
+	abortReasonReplicaLag   = "ReplicaLag"
+	abortReasonLockWait     = "LockWait"
+)
+
+const defaultMigrationGuardInterval = 30 * time.Second
+
+// abortRecheckInterval is how often an aborted migration is cleaned up
+// until its Job is gone
+const abortRecheckInterval = 10 * time.Second
+
+// migrationAborted returns true if the migration was aborted and hasn't been
+// changed since, in which case it isn't started again
+func migrationAborted(db *dba.ManagedDatabase, migration *dba.DatabaseMigration) bool {
+	failure := db.Status.MigrationFailure
+	return failure != nil && failure.Migration == migration.Name && failure.Generation == migration.Generation
+}
+
+// migrationGuardInterval returns how often the migration's guard thresholds
+// are checked
+func migrationGuardInterval(migration *dba.DatabaseMigration) time.Duration {
+	if guard := migration.Spec.Guard; guard != nil && guard.CheckInterval.Duration > 0 {
+		return guard.CheckInterval.Duration
+	}
+	return defaultMigrationGuardInterval
+}
+
+// annotateBinlogBaseline records the size of the binary logs on a Job which
+// is about to be created, if the migration guards their growth
+func annotateBinlogBaseline(admin dbadmin.DbAdmin, migration *dba.DatabaseMigration, job *batchv1.Job) error {
+	if migration.Spec.Guard == nil || migration.Spec.Guard.MaxBinlogGrowth == nil {
+		return nil
+	}
+
+	health, err := admin.GetReplicationHealth()
+	if err != nil {
+		return fmt.Errorf("Unable to measure binary logs before migration: %w", err)
+	}
+	job.Annotations[BinlogBytesAtStartAnnotation] = strconv.FormatInt(health.BinlogBytes, 10)
+	return nil
+}
+
+// guardMigration aborts the running migration if its Job has exceeded its
+// deadline, or if it has crossed any of the guard's thresholds. It returns
+// true if the migration was aborted, and how long to wait before checking
+// again.
+func (c *ManagedDatabaseController) guardMigration(oneMigration migrationContext, admin dbadmin.DbAdmin) (bool, time.Duration, error) {
+	db := oneMigration.db
+	migration := oneMigration.version
+	interval := migrationGuardInterval(migration)
+
+	var job batchv1.Job
+	jobName := migrationName(db.Name, migration.Name)
+	if err := c.Get(oneMigration.ctx, types.NamespacedName{Namespace: db.Namespace, Name: jobName}, &job); err != nil {
+		if apierrs.IsNotFound(err) {
+			return false, interval, nil
+		}
+		return false, interval, fmt.Errorf("Unable to fetch migration Job (%s): %w", jobName, err)
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue && condition.Reason == "DeadlineExceeded" {
+			message := fmt.Sprintf("Migration %s ran for longer than its deadline: %s", migration.Name, condition.Message)
+			return true, abortRecheckInterval, c.abortMigration(oneMigration, admin, abortReasonDeadline, message)
+		}
+	}
+
+	guard := migration.Spec.Guard
+	if guard == nil {
+		return false, 0, nil
+	}
+
+	if guard.MaxBinlogGrowth != nil || guard.MaxReplicaLag.Duration > 0 {
+		health, err := admin.GetReplicationHealth()
+		if err != nil {
+			return false, interval, err
+		}
+
+		if guard.MaxBinlogGrowth != nil {
+			// Logs which were purged since the start only make the growth
+			// look smaller
+			baseline, err := strconv.ParseInt(job.Annotations[BinlogBytesAtStartAnnotation], 10, 64)
+			if err == nil && health.BinlogBytes-baseline > guard.MaxBinlogGrowth.Value() {
+				message := fmt.Sprintf("Migration %s grew the binary logs by %d bytes, more than the limit of %s", migration.Name, health.BinlogBytes-baseline, guard.MaxBinlogGrowth.String())
+				return true, abortRecheckInterval, c.abortMigration(oneMigration, admin, abortReasonBinlogGrowth, message)
+			}
+		}
+
+		if guard.MaxReplicaLag.Duration > 0 && health.ReplicaLag > guard.MaxReplicaLag.Duration {
+			message := fmt.Sprintf("Replicas fell %s behind during migration %s, more than the limit of %s", health.ReplicaLag, migration.Name, guard.MaxReplicaLag.Duration)
+			return true, abortRecheckInterval, c.abortMigration(oneMigration, admin, abortReasonReplicaLag, message)
+		}
+	}
+
+	if guard.MaxLockWait.Duration > 0 {
+		waits, err := admin.ListMetadataLockWaits()
+		if err != nil {
+			return false, interval, err
+		}
+		for _, wait := range waits {
+			if wait.Wait > guard.MaxLockWait.Duration {
+				message := fmt.Sprintf("Session %d (%s) waited %s for a metadata lock on %s during migration %s, more than the limit of %s", wait.WaitingSession, wait.WaitingAccount, wait.Wait, wait.Table, migration.Name, guard.MaxLockWait.Duration)
+				return true, abortRecheckInterval, c.abortMigration(oneMigration, admin, abortReasonLockWait, message)
+			}
+		}
+	}
+
+	return false, interval, nil
+}
+
+// abortMigration records why the migration is being aborted and then stops
+// it. The failure is written first, so that an interrupted abort is finished
+// by the next reconcile instead of the migration carrying on.
+func (c *ManagedDatabaseController) abortMigration(oneMigration migrationContext, admin dbadmin.DbAdmin, reason, message string) error {
+	db := oneMigration.db
+	oneMigration.log.Info("Aborting migration", "reason", reason, "message", message)
+
+	db.Status.MigrationFailure = &dba.MigrationFailure{
+		Migration:  oneMigration.version.Name,
+		Generation: oneMigration.version.Generation,
+		Reason:     reason,
+		Message:    message,
+		AbortedAt:  metav1.Now(),
+	}
+	if err := c.Status().Update(oneMigration.ctx, db); err != nil {
+		return fmt.Errorf("Unable to record aborted migration: %w", err)
+	}
+
+	c.metrics.MigrationsAborted.Inc()
+	c.notifier.Notify(notify.Event{
+		Reason:    "MigrationAborted",
+		Namespace: db.Namespace,
+		Name:      db.Name,
+		Message:   message,
+	})
+
+	_, err := c.finishAbort(oneMigration, admin)
+	return err
+}
+
+// finishAbort deletes the Job of an aborted migration and terminates the
+// sessions it left behind, which keep running on the server after the pod is
+// gone. The Job is deleted in the foreground, so that it is only gone once
+// its pods are, and sessions are killed again until then. It returns true
+// while the Job still exists.
+func (c *ManagedDatabaseController) finishAbort(oneMigration migrationContext, admin dbadmin.DbAdmin) (bool, error) {
+	db := oneMigration.db
+
+	var job batchv1.Job
+	jobName := migrationName(db.Name, oneMigration.version.Name)
+	if err := c.Get(oneMigration.ctx, types.NamespacedName{Namespace: db.Namespace, Name: jobName}, &job); err != nil {
+		if apierrs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("Unable to fetch migration Job (%s): %w", jobName, err)
+	}
+
+	if job.DeletionTimestamp == nil {
+		if err := c.Delete(oneMigration.ctx, &job, client.PropagationPolicy(metav1.DeletePropagationForeground)); err != nil && !apierrs.IsNotFound(err) {
+			return true, fmt.Errorf("Unable to delete migration job (%s): %w", jobName, err)
+		}
+	}
+
+	existing, err := admin.ListUsernames(DBUsernamePrefix)
+	if err != nil {
+		return true, fmt.Errorf("Unable to list existing db usernames: %w", err)
+	}
+	server, err := admin.DetectServer()
+	if err != nil {
+		return true, fmt.Errorf("Unable to detect database server: %w", err)
+	}
+	username, err := versionUsername(oneMigration.version.Name, server.MaxUsernameLength, existing)
+	if err != nil {
+		return true, err
+	}
+	if err := admin.AbortMigration(username); err != nil {
+		return true, fmt.Errorf("Unable to stop migration (%s) on the database: %w", oneMigration.version.Name, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/dba-operator/pkg/dashboards"
+)
+
+// Kinds of Prometheus operator monitor which the operator can create for
+// itself
+const (
+	MonitorKindService = "ServiceMonitor"
+	MonitorKindPod     = "PodMonitor"
+)
+
+const (
+	monitoringName          = "dba-operator"
+	dashboardsConfigMapName = "dba-operator-dashboards"
+
+	// grafanaDashboardLabel is the label that the Grafana dashboard sidecar
+	// discovers ConfigMaps with
+	grafanaDashboardLabel = "grafana_dashboard"
+
+	// monitoringRefreshInterval is how often changes made to the monitor
+	// and dashboards outside of the operator are reverted
+	monitoringRefreshInterval = 10 * time.Minute
+)
+
+var monitoringGroupVersion = schema.GroupVersion{Group: "monitoring.coreos.com", Version: "v1"}
+
+// MonitoringOptions describes how the operator's own metrics endpoint is
+// scraped
+type MonitoringOptions struct {
+	// Namespace is where the operator runs, the monitor and dashboards are
+	// created in it
+	Namespace string
+
+	// Kind is ServiceMonitor to scrape the Service in front of the operator,
+	// or PodMonitor to scrape its pods directly
+	Kind string
+
+	// Selector matches the labels of the Service or pods
+	Selector map[string]string
+
+	// Port is the name of the metrics port, and Scheme is http or https.
+	// Scraping over https authenticates with the service account token.
+	Port   string
+	Scheme string
+}
+
+// MonitoringController maintains a Prometheus operator monitor for the
+// operator's metrics, and a ConfigMap with the Grafana dashboards for them.
+type MonitoringController struct {
+	client.Client
+	Log     logr.Logger
+	options MonitoringOptions
+}
+
+// NewMonitoringController will instantiate a MonitoringController with the
+// supplied arguments, and return an error if the options are incomplete.
+func NewMonitoringController(c client.Client, l logr.Logger, options MonitoringOptions) (*MonitoringController, error) {
+	if options.Namespace == "" {
+		return nil, fmt.Errorf("The namespace that the operator runs in is required for monitoring")
+	}
+	if options.Kind != MonitorKindService && options.Kind != MonitorKindPod {
+		return nil, fmt.Errorf("Unknown monitor kind: %s", options.Kind)
+	}
+	if len(options.Selector) == 0 || options.Port == "" {
+		return nil, fmt.Errorf("Monitoring requires a selector and a port")
+	}
+	if options.Scheme != "http" && options.Scheme != "https" {
+		return nil, fmt.Errorf("Unknown monitoring scheme: %s", options.Scheme)
+	}
+
+	return &MonitoringController{Client: c, Log: l, options: options}, nil
+}
+
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;podmonitors,verbs=get;create;update
+// +kubebuilder:rbac:groups=,resources=configmaps,verbs=get;create;update
+
+// Start implements manager.Runnable
+func (mc *MonitoringController) Start(stop <-chan struct{}) error {
+	for {
+		if err := mc.reconcileMonitor(context.Background()); err != nil {
+			mc.Log.Error(err, "Unable to maintain monitor", "kind", mc.options.Kind)
+		}
+		if err := mc.reconcileDashboards(context.Background()); err != nil {
+			mc.Log.Error(err, "Unable to maintain Grafana dashboards")
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(monitoringRefreshInterval):
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (mc *MonitoringController) NeedLeaderElection() bool {
+	return true
+}
+
+func monitoringLabels() map[string]string {
+	return map[string]string{"app.kubernetes.io/managed-by": monitoringName}
+}
+
+// monitorSpec builds the spec of the monitor, in the form expected by the
+// Prometheus operator
+func (mc *MonitoringController) monitorSpec() map[string]interface{} {
+	endpoint := map[string]interface{}{
+		"port":     mc.options.Port,
+		"scheme":   mc.options.Scheme,
+		"interval": "30s",
+	}
+	if mc.options.Scheme == "https" {
+		// The metrics endpoint is served by an auth proxy with a self signed
+		// certificate
+		endpoint["tlsConfig"] = map[string]interface{}{"insecureSkipVerify": true}
+		if mc.options.Kind == MonitorKindService {
+			endpoint["bearerTokenFile"] = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+		}
+	}
+
+	matchLabels := make(map[string]interface{}, len(mc.options.Selector))
+	for name, value := range mc.options.Selector {
+		matchLabels[name] = value
+	}
+
+	endpointsField := "endpoints"
+	if mc.options.Kind == MonitorKindPod {
+		endpointsField = "podMetricsEndpoints"
+	}
+
+	return map[string]interface{}{
+		"selector":     map[string]interface{}{"matchLabels": matchLabels},
+		endpointsField: []interface{}{endpoint},
+	}
+}
+
+func (mc *MonitoringController) reconcileMonitor(ctx context.Context) error {
+	monitor := &unstructured.Unstructured{}
+	monitor.SetGroupVersionKind(monitoringGroupVersion.WithKind(mc.options.Kind))
+
+	err := mc.Get(ctx, types.NamespacedName{Namespace: mc.options.Namespace, Name: monitoringName}, monitor)
+	if meta.IsNoMatchError(err) {
+		return fmt.Errorf("The Prometheus operator's %s resource is not installed: %w", mc.options.Kind, err)
+	} else if apierrs.IsNotFound(err) {
+		monitor.SetNamespace(mc.options.Namespace)
+		monitor.SetName(monitoringName)
+		monitor.SetLabels(monitoringLabels())
+		monitor.Object["spec"] = mc.monitorSpec()
+
+		mc.Log.Info("Creating monitor for operator metrics", "kind", mc.options.Kind)
+		if err := mc.Create(ctx, monitor); err != nil {
+			return fmt.Errorf("Unable to create %s: %w", mc.options.Kind, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to fetch %s: %w", mc.options.Kind, err)
+	}
+
+	monitor.Object["spec"] = mc.monitorSpec()
+	if err := mc.Update(ctx, monitor); err != nil {
+		return fmt.Errorf("Unable to update %s: %w", mc.options.Kind, err)
+	}
+	return nil
+}
+
+func (mc *MonitoringController) reconcileDashboards(ctx context.Context) error {
+	files, err := dashboards.RenderAll()
+	if err != nil {
+		return err
+	}
+
+	labels := monitoringLabels()
+	labels[grafanaDashboardLabel] = "1"
+
+	var configMap corev1.ConfigMap
+	err = mc.Get(ctx, types.NamespacedName{Namespace: mc.options.Namespace, Name: dashboardsConfigMapName}, &configMap)
+	if apierrs.IsNotFound(err) {
+		configMap = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: mc.options.Namespace,
+				Name:      dashboardsConfigMapName,
+				Labels:    labels,
+			},
+			Data: files,
+		}
+
+		mc.Log.Info("Creating Grafana dashboards", "configMap", dashboardsConfigMapName)
+		if err := mc.Create(ctx, &configMap); err != nil {
+			return fmt.Errorf("Unable to create dashboards ConfigMap: %w", err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to fetch dashboards ConfigMap: %w", err)
+	}
+
+	if configMap.Labels == nil {
+		configMap.Labels = make(map[string]string, len(labels))
+	}
+	for name, value := range labels {
+		configMap.Labels[name] = value
+	}
+	configMap.Data = files
+	if err := mc.Update(ctx, &configMap); err != nil {
+		return fmt.Errorf("Unable to update dashboards ConfigMap: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,417 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// Actions which a DatabaseOperation may request
+const (
+	operationRotateCredentials = "rotate-credentials"
+	operationReconcileGrants   = "reconcile-grants"
+	operationKillSessions      = "kill-sessions"
+	operationDriftScan         = "drift-scan"
+	operationUpgradeCheck      = "upgrade-check"
+)
+
+// UpgradeReportKey is the ConfigMap key which holds an upgrade-check's report
+const UpgradeReportKey = "report"
+
+// Phases of a DatabaseOperation
+const (
+	operationRunning   = "Running"
+	operationSucceeded = "Succeeded"
+	operationFailed    = "Failed"
+)
+
+// OperationController runs the one-shot actions requested by
+// DatabaseOperations. Each operation is run until it succeeds or fails
+// permanently, and is never run again afterwards.
+type OperationController struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	config      config.Provider
+	metrics     OperationControllerMetrics
+	diagnostics *diagnostics.Recorder
+
+	// databases and rotations carry out the actions which are otherwise
+	// part of their own reconciliation
+	databases *ManagedDatabaseController
+	rotations *FleetRotationController
+}
+
+// NewOperationController will instantiate an OperationController with the
+// supplied arguments and logical defaults. Actions are carried out through
+// the ManagedDatabaseController and FleetRotationController, so that they
+// behave exactly as when those controllers run them. diag enables debug mode,
+// see diagnostics.Recorder.
+func NewOperationController(
+	c client.Client,
+	scheme *runtime.Scheme,
+	l logr.Logger,
+	diag *diagnostics.Recorder,
+	cfg config.Provider,
+	databases *ManagedDatabaseController,
+	rotations *FleetRotationController,
+) (*OperationController, []prometheus.Collector) {
+	metrics := generateOperationControllerMetrics()
+
+	return &OperationController{
+		Client:      c,
+		Scheme:      scheme,
+		Log:         l,
+		config:      cfg,
+		metrics:     metrics,
+		diagnostics: diag,
+		databases:   databases,
+		rotations:   rotations,
+	}, getAllMetrics(metrics)
+}
+
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=databaseoperations,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=databaseoperations/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=,resources=configmaps,verbs=get;create;update
+
+// ReconcileDatabaseOperation should be invoked whenever a DatabaseOperation
+// is created or changed.
+func (c *OperationController) ReconcileDatabaseOperation(req ctrl.Request) (ctrl.Result, error) {
+	var ctx = context.Background()
+	var log = c.Log.WithValues("databaseoperation", req.NamespacedName)
+
+	var operation dba.DatabaseOperation
+	if err := c.Get(ctx, req.NamespacedName, &operation); err != nil {
+		if apierrs.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "unable to fetch DatabaseOperation", "phase", phaseFetch)
+		return ctrl.Result{}, err
+	}
+
+	if operation.Status.Phase == operationSucceeded || operation.Status.Phase == operationFailed {
+		return ctrl.Result{}, nil
+	}
+	log = log.WithValues("action", operation.Spec.Action, "manageddatabase", operation.Spec.ManagedDatabase)
+
+	if operation.Status.Phase == "" {
+		// Recording the start first makes a conflicting update fail before
+		// anything has been run
+		now := metav1.Now()
+		operation.Status.Phase = operationRunning
+		operation.Status.StartedAt = &now
+		if err := c.Status().Update(ctx, &operation); err != nil {
+			return ctrl.Result{}, fmt.Errorf("Unable to start DatabaseOperation: %w", err)
+		}
+	}
+
+	log.Info("Running operation")
+	message, err := c.run(ctx, log, &operation)
+
+	var maybeTemporary xerrors.EnhancedError
+	if errors.As(err, &maybeTemporary) && maybeTemporary.Temporary() {
+		// Every action is safe to repeat, so it is simply run again
+		delay := temporaryErrorDelay(c.config.Current().Backoff, err, operation.Status.TemporaryErrorRetries)
+		log.Info("Retrying after temporary error", "error", err.Error(), "retryAfter", delay)
+		operation.Status.Message = redact.String(err.Error())
+		operation.Status.TemporaryErrorRetries = xerrors.CountRetry(operation.Status.TemporaryErrorRetries)
+		if err := c.Status().Update(ctx, &operation); err != nil {
+			log.Error(err, "Unable to update DatabaseOperation status block")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	now := metav1.Now()
+	operation.Status.CompletedAt = &now
+	if err != nil {
+		log.Error(err, "operation failed")
+		operation.Status.Phase = operationFailed
+		operation.Status.Message = redact.String(err.Error())
+		c.metrics.OperationsFailed.Inc()
+	} else {
+		log.Info("Operation succeeded", "result", message)
+		operation.Status.Phase = operationSucceeded
+		operation.Status.Message = message
+		c.metrics.OperationsSucceeded.Inc()
+	}
+
+	if err := c.Status().Update(ctx, &operation); err != nil {
+		log.Error(err, "Unable to update DatabaseOperation status block")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// run carries out the operation's action and describes its outcome
+func (c *OperationController) run(ctx context.Context, log logr.Logger, operation *dba.DatabaseOperation) (string, error) {
+	dbName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Spec.ManagedDatabase}
+	var db dba.ManagedDatabase
+	if err := c.Get(ctx, dbName, &db); err != nil {
+		return "", fmt.Errorf("Unable to fetch ManagedDatabase (%s): %w", dbName, err)
+	}
+	if err := applyManagedDatabaseClass(ctx, c.Client, &db); err != nil {
+		return "", err
+	}
+
+	cfg := c.config.Current()
+	if reason := pauseReason(cfg, &db); reason != "" {
+		return "", xerrors.NewTempErrorf("Waiting to run the operation, %s", reason)
+	}
+
+	switch operation.Spec.Action {
+	case operationRotateCredentials:
+		if db.Spec.Rotation != nil && db.Spec.Rotation.Disabled {
+			return "", fmt.Errorf("Rotation is disabled for ManagedDatabase %s", dbName)
+		}
+		if err := c.rotations.rotateDatabase(ctx, phaseLogger(log, phaseRotation), &db); err != nil {
+			return "", err
+		}
+		return "Rotated the credentials", nil
+	case operationFailoverDrill:
+		return c.runFailoverDrill(ctx, phaseLogger(log, phaseFailoverDrill), operation, &db)
+	case operationReconcileGrants, operationKillSessions, operationDriftScan, operationUpgradeCheck:
+	default:
+		return "", fmt.Errorf("Unknown action %s", operation.Spec.Action)
+	}
+
+	admin, err := initializeAdminConnection(ctx, log, c.diagnostics, c.Client, db.Namespace, &db.Spec)
+	if err != nil {
+		return "", fmt.Errorf("Unable to create database connection: %w", err)
+	}
+	defer admin.Close()
+	unlock, err := lockOperator(log, admin, cfg.Leases)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	switch operation.Spec.Action {
+	case operationReconcileGrants:
+		return c.reconcileGrants(ctx, phaseLogger(log, phaseCredentials), admin, &db)
+	case operationKillSessions:
+		return killIssuedUserSessions(log, admin, operation.Spec.Username)
+	case operationUpgradeCheck:
+		return c.checkUpgrade(ctx, log, admin, operation)
+	}
+	return c.scanDrift(ctx, log, admin, &db)
+}
+
+// reconcileGrants grants every migration user of the database the
+// privileges from its spec again
+func (c *OperationController) reconcileGrants(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase) (string, error) {
+	secretList, err := listSecretsForDatabase(ctx, c.Client, db)
+	if err != nil {
+		return "", fmt.Errorf("Unable to list existing cluster secrets: %w", err)
+	}
+
+	// The credentials of each schema version can have their own grants
+	migrations := make(map[string]*dba.DatabaseMigration)
+	var credentials []dbadmin.Credentials
+	for _, secret := range secretList.Items {
+		username := string(secret.Data["username"])
+		if !strings.HasPrefix(username, DBUsernamePrefix) {
+			continue
+		}
+
+		// Derived usernames can't be turned back into their version
+		version := secret.Labels["migration"]
+		if version == "" {
+			version = strings.TrimPrefix(username, DBUsernamePrefix)
+		}
+		migration, ok := migrations[version]
+		if !ok {
+			migration, err = fetchMigration(ctx, c.Client, db.Namespace, version)
+			if err != nil {
+				return "", err
+			}
+			migrations[version] = migration
+		}
+		credentials = append(credentials, dbadmin.Credentials{Username: username, Grants: versionGrants(db, migration, c.config.Current())})
+	}
+
+	log.Info("Granting privileges again", "numUsername", len(credentials))
+	if err := admin.ReapplyGrants(credentials); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Granted privileges to %d users", len(credentials)), nil
+}
+
+// killIssuedUserSessions terminates the sessions of a user, which must be one
+// that the operator issued so that the sessions of administrators and of
+// replication can't be killed
+func killIssuedUserSessions(log logr.Logger, admin dbadmin.DbAdmin, username string) (string, error) {
+	issued := false
+	for _, prefix := range issuedUsernamePrefixes {
+		issued = issued || strings.HasPrefix(username, prefix)
+	}
+	if !issued {
+		return "", fmt.Errorf("Only the sessions of users issued by the operator can be killed, not of %q", username)
+	}
+
+	killed, err := admin.KillUserSessions(username)
+	if err != nil {
+		return "", fmt.Errorf("Unable to kill sessions after killing %d: %w", killed, err)
+	}
+	log.Info("Killed sessions", "username", username, "count", killed)
+	return fmt.Sprintf("Killed %d sessions of %s", killed, username), nil
+}
+
+// scanDrift runs the database's drift checks immediately, with the same
+// repairs and notifications as when the ManagedDatabaseController runs them,
+// and summarizes what had drifted
+func (c *OperationController) scanDrift(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase) (string, error) {
+	cfg := c.config.Current()
+	if err := checkAdminProfile(cfg.AdminProfile, db, nil); err != nil {
+		return "", err
+	}
+
+	views := make(map[string]dba.MaskedViewStatus, len(db.Status.MaskedViews))
+	for _, view := range db.Status.MaskedViews {
+		views[view.Name] = view
+	}
+
+	if err := c.databases.reconcileParameters(phaseLogger(log, phaseParameters), admin, db, cfg.Parameters); err != nil {
+		return "", err
+	}
+	if err := c.databases.reconcileHelperRoutines(phaseLogger(log, phaseRoutines), admin, db); err != nil {
+		return "", err
+	}
+	if _, err := c.databases.reconcileCharset(phaseLogger(log, phaseCharset), admin, db, time.Now()); err != nil {
+		return "", err
+	}
+	if db.Status.CurrentVersion != "" {
+		// The views select from tables which the migrations create
+		if err := c.databases.reconcileMaskedViews(phaseLogger(log, phaseMasking), admin, db); err != nil {
+			return "", err
+		}
+	}
+
+	if err := c.Status().Update(ctx, db); err != nil {
+		return "", fmt.Errorf("Unable to update ManagedDatabase status block: %w", err)
+	}
+
+	var findings []string
+	if len(db.Status.ParameterDrift) > 0 {
+		findings = append(findings, "server parameters "+strings.Join(db.Status.ParameterDrift, ", ")+" were set again")
+	}
+	if len(db.Status.DriftedRoutines) > 0 {
+		findings = append(findings, "helper routines "+strings.Join(db.Status.DriftedRoutines, ", ")+" have drifted")
+	}
+	var restored []string
+	for _, view := range db.Status.MaskedViews {
+		if previous, ok := views[view.Name]; ok && previous.SpecHash == view.SpecHash && previous.Checksum != view.Checksum {
+			restored = append(restored, view.Name)
+		}
+	}
+	if len(restored) > 0 {
+		findings = append(findings, "masked views "+strings.Join(restored, ", ")+" were restored")
+	}
+	if charset := db.Status.Charset; charset != nil && (!charset.Matches || charset.MismatchedTables > 0) {
+		findings = append(findings, fmt.Sprintf("the character set doesn't match on the database or %d tables", charset.MismatchedTables))
+	}
+
+	if len(findings) == 0 {
+		return "No drift found", nil
+	}
+	return "Drift found: " + strings.Join(findings, "; "), nil
+}
+
+// checkUpgrade scans for what would break after upgrading to the target
+// version, and publishes the findings in a ConfigMap owned by the operation
+func (c *OperationController) checkUpgrade(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, operation *dba.DatabaseOperation) (string, error) {
+	target := operation.Spec.TargetVersion
+	if target == "" {
+		return "", fmt.Errorf("The upgrade-check action requires a targetVersion")
+	}
+
+	findings, err := admin.CheckUpgradeReadiness(target, issuedUsernamePrefixes)
+	if err != nil {
+		return "", err
+	}
+
+	var blocking int
+	for _, finding := range findings {
+		if finding.Blocking {
+			blocking++
+		}
+	}
+	log.Info("Checked upgrade readiness", "target", target, "blocking", blocking, "warnings", len(findings)-blocking)
+
+	name := operation.Name + "-upgrade-report"
+	report := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: operation.Namespace,
+		},
+		Data: map[string]string{
+			UpgradeReportKey: formatUpgradeReport(operation.Spec.ManagedDatabase, target, findings),
+		},
+	}
+	if err := ctrl.SetControllerReference(operation, &report, c.Scheme); err != nil {
+		return "", fmt.Errorf("Unable to set owner for upgrade report (%s): %w", name, err)
+	}
+	var existing corev1.ConfigMap
+	if err := c.Get(ctx, types.NamespacedName{Namespace: operation.Namespace, Name: name}, &existing); err == nil {
+		// A retried operation replaces the report of the earlier attempt
+		existing.Data = report.Data
+		if err := c.Update(ctx, &existing); err != nil {
+			return "", fmt.Errorf("Unable to update upgrade report (%s): %w", name, err)
+		}
+	} else if !apierrs.IsNotFound(err) {
+		return "", fmt.Errorf("Unable to fetch upgrade report (%s): %w", name, err)
+	} else if err := c.Create(ctx, &report); err != nil {
+		return "", fmt.Errorf("Unable to create upgrade report (%s): %w", name, err)
+	}
+
+	if len(findings) == 0 {
+		return fmt.Sprintf("Ready for MySQL %s, nothing was found", target), nil
+	}
+	return fmt.Sprintf("Found %d blocking issues and %d warnings for MySQL %s, see ConfigMap %s", blocking, len(findings)-blocking, target, name), nil
+}
+
+// formatUpgradeReport lists the blocking findings before the warnings
+func formatUpgradeReport(dbName, target string, findings []dbadmin.UpgradeFinding) string {
+	var report strings.Builder
+	fmt.Fprintf(&report, "# Readiness of ManagedDatabase %s for MySQL %s\n", dbName, target)
+	for _, blocking := range []bool{true, false} {
+		for _, finding := range findings {
+			if finding.Blocking != blocking {
+				continue
+			}
+			severity := "warning"
+			if blocking {
+				severity = "blocking"
+			}
+			fmt.Fprintf(&report, "%s\t%s\t%s\t%s\n", severity, finding.Check, finding.Object, finding.Message)
+		}
+	}
+	return report.String()
+}
+
+// SetupWithManager should be called to finish initialization of an
+// OperationController and bind it to the manager specified.
+func (c *OperationController) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dba.DatabaseOperation{}).
+		Complete(drained("databaseoperation", traced(c.diagnostics, "databaseoperation", c.ReconcileDatabaseOperation)))
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// operatorLockTimeout is how long to wait for another operator to release
+// the operator lock on a database
+const operatorLockTimeout = 10 * time.Second
+
+// leaseHeldError is returned when the operator of another cluster holds the
+// lease on the database, it is retried once the lease may have expired
+type leaseHeldError struct {
+	lease dbadmin.Lease
+}
+
+func (lhe leaseHeldError) Error() string {
+	return fmt.Sprintf("Database is managed by cluster %s, whose lease expires in %s", lhe.lease.Holder, lhe.lease.Remaining.Round(time.Second))
+}
+
+func (lhe leaseHeldError) Temporary() bool {
+	return true
+}
+
+func (lhe leaseHeldError) RetryAfter() time.Duration {
+	return lhe.lease.Remaining + time.Second
+}
+
+// lockOperator takes the operator lock on the database, so that another
+// operator instance managing the same database can't interleave its changes
+// with ours. When leases are enabled it also takes or renews this cluster's
+// lease, and fails with a leaseHeldError while another cluster holds it. The
+// returned function releases the lock.
+func lockOperator(log logr.Logger, admin dbadmin.DbAdmin, leases config.Leases) (func(), error) {
+	release, err := admin.AcquireOperatorLock(operatorLockTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := func() {
+		if err := release(); err != nil {
+			log.Error(err, "unable to release operator lock")
+		}
+	}
+
+	if leases.ClusterID == "" {
+		return unlock, nil
+	}
+
+	lease, err := admin.AcquireLease(leases.ClusterID, leases.Duration.Duration)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	if lease.Holder != leases.ClusterID {
+		unlock()
+		return nil, leaseHeldError{lease: lease}
+	}
+	if lease.PreviousHolder != "" {
+		log.Info("Took over database from cluster whose lease expired", "previousHolder", lease.PreviousHolder)
+	}
+	return unlock, nil
+}
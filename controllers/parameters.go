@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+)
+
+// parameterRefreshInterval is how often the server variables are checked
+// for changes made outside of the operator
+const parameterRefreshInterval = 10 * time.Minute
+
+// normalizedParameter returns the form of a server variable's value which
+// is compared, servers report switches as 1 and 0
+func normalizedParameter(value string) string {
+	switch upper := strings.ToUpper(value); upper {
+	case "ON", "TRUE":
+		return "1"
+	case "OFF", "FALSE":
+		return "0"
+	default:
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return strconv.FormatFloat(number, 'g', -1, 64)
+		}
+		return upper
+	}
+}
+
+func parameterMatches(desired, actual string) bool {
+	return normalizedParameter(desired) == normalizedParameter(actual)
+}
+
+// reconcileParameters sets the server variables which differ from the spec,
+// and reports the ones that had been changed since the operator set them.
+// Variables which are removed from the spec keep their current value.
+func (c *ManagedDatabaseController) reconcileParameters(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, allowed config.Parameters) error {
+	if len(db.Spec.Parameters) == 0 {
+		db.Status.AppliedParameters = nil
+		db.Status.ParameterDrift = nil
+		return nil
+	}
+
+	names := make([]string, 0, len(db.Spec.Parameters))
+	for name := range db.Spec.Parameters {
+		if !allowed.Allows(name) {
+			return fmt.Errorf("Server parameter %s is denied by the operator configuration", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	current, err := admin.GetServerVariables(names)
+	if err != nil {
+		return err
+	}
+
+	changes := make(map[string]string)
+	var drifted []string
+	for _, name := range names {
+		desired := db.Spec.Parameters[name]
+		if parameterMatches(desired, current[name]) {
+			continue
+		}
+		changes[name] = desired
+
+		if applied, ok := db.Status.AppliedParameters[name]; ok && parameterMatches(applied, desired) {
+			drifted = append(drifted, name)
+		}
+	}
+
+	if len(drifted) > 0 {
+		log.Info("Server parameters were changed outside of the operator", "parameters", drifted)
+		c.notifier.Notify(notify.Event{
+			Reason:    "ParameterDrift",
+			Namespace: db.Namespace,
+			Name:      db.Name,
+			Message:   fmt.Sprintf("Server parameters %s were changed outside of the operator and are being set again", strings.Join(drifted, ", ")),
+		})
+	}
+
+	if len(changes) > 0 {
+		log.Info("Setting server parameters", "count", len(changes))
+		if err := admin.SetServerVariables(changes); err != nil {
+			return err
+		}
+	}
+
+	applied := make(map[string]string, len(db.Spec.Parameters))
+	for name, value := range db.Spec.Parameters {
+		applied[name] = value
+	}
+	db.Status.AppliedParameters = applied
+	db.Status.ParameterDrift = drifted
+	return nil
+}
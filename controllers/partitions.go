@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/cardinality"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/partitions"
+)
+
+// partitionRefreshInterval is how often partitioned tables are checked for
+// partitions which are due to be added or dropped
+const partitionRefreshInterval = time.Hour
+
+// reconcilePartitions adds the upcoming partitions of every partitioned
+// table and drops the ones past their retention, recording how far each
+// table was from its schedule.
+func (c *ManagedDatabaseController) reconcilePartitions(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, now time.Time) error {
+	plans := make(map[string]partitions.Plan, len(db.Spec.Partitioning))
+	defer c.exportPartitionMetrics(db, plans)
+
+	statuses := make([]dba.PartitionedTableStatus, 0, len(db.Spec.Partitioning))
+	for _, table := range db.Spec.Partitioning {
+		existing, err := admin.ListPartitions(table.Table)
+		if err != nil {
+			return err
+		}
+
+		plan, err := partitions.Compute(existing, partitions.Policy{
+			Interval:  partitions.Interval(table.Interval),
+			Ahead:     table.Ahead,
+			Retention: table.Retention.Duration,
+		}, now)
+		if err != nil {
+			return err
+		}
+
+		plans[table.Table] = plan
+
+		if len(plan.Add) > 0 {
+			log.Info("Adding partitions", "table", table.Table, "count", len(plan.Add))
+			if err := admin.AddPartitions(table.Table, plan.Add); err != nil {
+				return err
+			}
+		}
+		if len(plan.Drop) > 0 {
+			log.Info("Dropping expired partitions", "table", table.Table, "partitions", plan.Drop)
+			if err := admin.DropPartitions(table.Table, plan.Drop); err != nil {
+				return err
+			}
+		}
+
+		statuses = append(statuses, dba.PartitionedTableStatus{Table: table.Table, Ahead: plan.Ahead, Behind: plan.Behind})
+	}
+
+	db.Status.Partitioning = statuses
+	return nil
+}
+
+// exportPartitionMetrics records how far each table is from its schedule.
+// Within the series budget the tables furthest behind are reported on their
+// own, the rest are reported together by their worst values. Tables which
+// are no longer maintained keep their partitions, but stop being reported.
+func (c *ManagedDatabaseController) exportPartitionMetrics(db *dba.ManagedDatabase, plans map[string]partitions.Plan) {
+	key := db.Namespace + "/" + db.Name
+	for _, table := range c.partitionSeries[key] {
+		labels := partitionLabels(db, table)
+		c.metrics.PartitionsAhead.Delete(labels)
+		c.metrics.PartitionsBehind.Delete(labels)
+	}
+
+	ranks := make(map[string]float64, len(plans))
+	ahead := make(map[string]float64, len(plans))
+	behind := make(map[string]float64, len(plans))
+	for table, plan := range plans {
+		ranks[table] = float64(plan.Behind - plan.Ahead)
+		ahead[table] = float64(plan.Ahead)
+		behind[table] = float64(plan.Behind)
+	}
+
+	budget := c.config.Current().Metrics.TableBudget()
+	selected := budget.Select(ranks)
+	exported := make([]string, 0, len(selected)+1)
+	for table, value := range budget.Fold(ahead, selected, cardinality.Min) {
+		c.metrics.PartitionsAhead.With(partitionLabels(db, table)).Set(value)
+		exported = append(exported, table)
+	}
+	for table, value := range budget.Fold(behind, selected, cardinality.Max) {
+		c.metrics.PartitionsBehind.With(partitionLabels(db, table)).Set(value)
+	}
+	c.partitionSeries[key] = exported
+}
+
+func partitionLabels(db *dba.ManagedDatabase, table string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace": db.Namespace,
+		"database":  db.Name,
+		"table":     table,
+	}
+}
@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+)
+
+// pauseReason describes why changes to a ManagedDatabase and its credentials
+// are currently suspended, and is empty when they aren't.
+func pauseReason(cfg config.Config, db *dba.ManagedDatabase) string {
+	switch {
+	case cfg.SafeMode:
+		return "the operator is in safe-mode"
+	case db.Spec.Paused:
+		return "the ManagedDatabase is paused"
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+// phaseTimer measures how long a reconcile spends in each of its phases. A
+// phase which is entered more than once accumulates the time of every visit.
+type phaseTimer struct {
+	started time.Time
+	phase   string
+	entered time.Time
+	phases  []dba.PhaseDuration
+}
+
+type phaseTimerKey struct{}
+
+// withPhaseTimer starts timing a reconcile in phase, the timer travels with
+// the context to the functions which write the status block
+func withPhaseTimer(ctx context.Context, phase string, now time.Time) (context.Context, *phaseTimer) {
+	timer := &phaseTimer{started: now, phase: phase, entered: now}
+	return context.WithValue(ctx, phaseTimerKey{}, timer), timer
+}
+
+// enter ends the current phase and starts timing the next one
+func (pt *phaseTimer) enter(phase string) {
+	now := time.Now()
+	pt.stop(now)
+	pt.phase, pt.entered = phase, now
+}
+
+func (pt *phaseTimer) stop(now time.Time) {
+	if pt.phase == "" {
+		return
+	}
+	took := now.Sub(pt.entered)
+	for i := range pt.phases {
+		if pt.phases[i].Phase == pt.phase {
+			pt.phases[i].Duration.Duration += took
+			return
+		}
+	}
+	pt.phases = append(pt.phases, dba.PhaseDuration{Phase: pt.phase, Duration: metav1.Duration{Duration: took}})
+}
+
+// summary ends the current phase, and returns the durations of the phases
+// in the order they were first entered
+func (pt *phaseTimer) summary(now time.Time) *dba.ReconcilePerformance {
+	pt.stop(now)
+	pt.phase = ""
+
+	phases := make([]dba.PhaseDuration, len(pt.phases))
+	for i, phase := range pt.phases {
+		phases[i] = dba.PhaseDuration{Phase: phase.Phase, Duration: roundDuration(phase.Duration.Duration)}
+	}
+	return &dba.ReconcilePerformance{
+		StartedAt: metav1.NewTime(pt.started),
+		Duration:  roundDuration(now.Sub(pt.started)),
+		Phases:    phases,
+	}
+}
+
+// recordPerformance puts the phase durations of the reconcile running in
+// ctx into the status block, if it is being timed
+func recordPerformance(ctx context.Context, db *dba.ManagedDatabase, now time.Time) {
+	if timer, ok := ctx.Value(phaseTimerKey{}).(*phaseTimer); ok {
+		db.Status.Performance = timer.summary(now)
+	}
+}
+
+// roundDuration drops the precision which only makes the status block
+// harder to read
+func roundDuration(d time.Duration) metav1.Duration {
+	return metav1.Duration{Duration: d.Round(time.Millisecond)}
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// statusCheckpointer records the progress of admin plans in the status block
+// of the ManagedDatabase they are executed on
+type statusCheckpointer struct {
+	ctx    context.Context
+	client client.Client
+	db     *dba.ManagedDatabase
+}
+
+// Completed implements dbadmin.Checkpointer
+func (sc *statusCheckpointer) Completed(planID string) (int, error) {
+	if sc.db.Status.Plan == nil || sc.db.Status.Plan.ID != planID {
+		return 0, nil
+	}
+	return sc.db.Status.Plan.CompletedSteps, nil
+}
+
+// Complete implements dbadmin.Checkpointer
+func (sc *statusCheckpointer) Complete(planID string, steps int) error {
+	sc.db.Status.Plan = &dba.PlanProgress{ID: planID, CompletedSteps: steps}
+	if err := sc.client.Status().Update(sc.ctx, sc.db); err != nil {
+		return fmt.Errorf("Unable to update ManagedDatabase status block: %w", err)
+	}
+	return nil
+}
+
+// approvePlan returns nil if the plan may be executed. Plans which the policy
+// rules deny are never executed. In plan approval mode a plan which hasn't
+// been approved is rendered into the status block for review instead, and a
+// temporary error is returned until it is.
+func (c *ManagedDatabaseController) approvePlan(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, plan dbadmin.AdminPlan) error {
+	if err := enforcePlanPolicies(c.config.Current().Policies, log, admin, db, plan); err != nil {
+		return err
+	}
+
+	if db.Spec.PlanApproval == nil {
+		return nil
+	}
+
+	statements, err := admin.RenderPlan(plan)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	fmt.Fprintln(hash, plan.ID)
+	for _, statement := range statements {
+		fmt.Fprintln(hash, statement)
+	}
+	planHash := hex.EncodeToString(hash.Sum(nil))
+
+	if db.Spec.PlanApproval.ApprovedHash == planHash {
+		log.Info("Executing approved plan", "plan", plan.ID, "hash", planHash)
+		db.Status.PendingPlan = nil
+		return nil
+	}
+
+	if db.Status.PendingPlan == nil || db.Status.PendingPlan.Hash != planHash {
+		log.Info("Plan is waiting for approval", "plan", plan.ID, "hash", planHash)
+		c.notifier.Notify(notify.Event{
+			Reason:    "PlanPendingApproval",
+			Namespace: db.Namespace,
+			Name:      db.Name,
+			Message:   fmt.Sprintf("Plan %s with hash %s is waiting for approval:\n%s", plan.ID, planHash, strings.Join(statements, "\n")),
+		})
+	}
+	db.Status.PendingPlan = &dba.PendingPlan{ID: plan.ID, Hash: planHash, Statements: statements}
+
+	return xerrors.NewTempErrorf("Plan %s is waiting for approval of hash %s", plan.ID, planHash)
+}
+
+// planID names a plan after its purpose and the steps it contains, so that
+// the checkpoint of a plan is never applied to a different one. Passwords
+// are deliberately left out, they are regenerated every time a plan is built.
+func planID(purpose string, steps []dbadmin.PlanStep) string {
+	hash := sha256.New()
+	for _, step := range steps {
+		usernames := []string{step.Username}
+		for _, cred := range step.Credentials {
+			usernames = append(usernames, cred.Username)
+		}
+		sort.Strings(usernames)
+		fmt.Fprintf(hash, "%s\x00%s\x00%s\n", step.Kind, step.Version, strings.Join(usernames, "\x00"))
+	}
+	return purpose + "-" + hex.EncodeToString(hash.Sum(nil))[:12]
+}
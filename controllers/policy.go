@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/policy"
+)
+
+// enforcePolicies evaluates the policy rules of the operator configuration,
+// and returns an error if they deny the input. Every decision is logged.
+func enforcePolicies(rules []config.PolicyRule, log logr.Logger, db *dba.ManagedDatabase, subject string, input policy.Input) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	input.Annotations = db.Annotations
+	decision, err := policy.Evaluate(rules, input)
+	if err != nil {
+		return err
+	}
+
+	if len(decision.Exempted) > 0 {
+		log.Info("Policy rules exempted by annotation", "subject", subject, "rules", decision.Exempted)
+	}
+	if !decision.Allowed {
+		log.Info("Policy denied", "subject", subject, "rule", decision.Rule, "reason", decision.Reason)
+		return fmt.Errorf("%s was denied by policy rule %s: %s", subject, decision.Rule, decision.Reason)
+	}
+	log.V(1).Info("Policy allowed", "subject", subject)
+	return nil
+}
+
+// enforceGrantPolicies evaluates the policy rules against the grants of
+// credentials before they are provisioned
+func enforceGrantPolicies(rules []config.PolicyRule, log logr.Logger, db *dba.ManagedDatabase, username string, grants []dbadmin.DatabaseGrant) error {
+	return enforcePolicies(rules, log, db, fmt.Sprintf("Credentials for user (%s)", username), policy.Input{Grants: grants})
+}
+
+// enforcePlanPolicies evaluates the policy rules against the statements of
+// the plan before it is approved or executed
+func enforcePlanPolicies(rules []config.PolicyRule, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, plan dbadmin.AdminPlan) error {
+	if !policy.HasStatementRules(rules) {
+		return nil
+	}
+
+	statements, err := admin.RenderPlan(plan)
+	if err != nil {
+		return err
+	}
+	return enforcePolicies(rules, log, db, fmt.Sprintf("Plan %s", plan.ID), policy.Input{Statements: statements})
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// PostMigrationCompleteAnnotation is written to a migration's Job once the
+// post-migration table maintenance has run, and contains the RFC3339 time at
+// which it finished
+const PostMigrationCompleteAnnotation = "dbaoperator.app-sre.redhat.com/post-migration-complete"
+
+// defaultPostMigrationTimeLimit bounds table maintenance which doesn't
+// specify a time limit of its own
+const defaultPostMigrationTimeLimit = 10 * time.Minute
+
+// reconcilePostMigration runs the table maintenance described by the
+// migration for the current version, once its Job has succeeded.
+func (c *ManagedDatabaseController) reconcilePostMigration(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, version string) error {
+	var migration dba.DatabaseMigration
+	if err := c.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: version}, &migration); err != nil {
+		if apierrs.IsNotFound(err) {
+			// The version wasn't reached through a migration we know about
+			return nil
+		}
+		return fmt.Errorf("Unable to fetch DatabaseMigration (%s): %w", version, err)
+	}
+
+	postMigration := migration.Spec.PostMigration
+	if postMigration == nil {
+		return nil
+	}
+
+	labelSelector := map[string]string{
+		"database-uid":  string(db.UID),
+		"migration-uid": string(migration.UID),
+	}
+
+	var jobs batchv1.JobList
+	if err := c.List(ctx, &jobs, client.InNamespace(db.Namespace), client.MatchingLabels(labelSelector)); err != nil {
+		return fmt.Errorf("Unable to list migration Job(s): %w", err)
+	}
+
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if job.Status.Succeeded == 0 || job.Annotations[PostMigrationCompleteAnnotation] != "" {
+			continue
+		}
+
+		tables := postMigrationTables(&migration)
+		timeLimit := postMigration.TimeLimit.Duration
+		if timeLimit <= 0 {
+			timeLimit = defaultPostMigrationTimeLimit
+		}
+
+		log.Info("Running post-migration table maintenance", "numTables", len(tables), "optimize", postMigration.Optimize, "timeLimit", timeLimit)
+		if err := admin.AnalyzeTables(tables, postMigration.Optimize, timeLimit); err != nil {
+			return fmt.Errorf("Unable to run post-migration maintenance for migration (%s): %w", migration.Name, err)
+		}
+
+		if job.Annotations == nil {
+			job.Annotations = make(map[string]string)
+		}
+		job.Annotations[PostMigrationCompleteAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		if err := c.Update(ctx, job); err != nil {
+			return fmt.Errorf("Unable to mark post-migration maintenance complete on job (%s): %w", job.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// postMigrationTables returns the tables listed for maintenance, falling back
+// to the tables named in the migration's schema hints.
+func postMigrationTables(migration *dba.DatabaseMigration) []string {
+	if len(migration.Spec.PostMigration.AnalyzeTables) > 0 {
+		return migration.Spec.PostMigration.AnalyzeTables
+	}
+
+	tables := make([]string, 0, len(migration.Spec.SchemaHints))
+	for _, hint := range migration.Spec.SchemaHints {
+		if hint.Name != "" {
+			tables = append(tables, hint.Name)
+		}
+	}
+	return tables
+}
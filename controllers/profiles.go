@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"fmt"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// requiredOperations lists the operations that the ManagedDatabase asks the
+// operator to perform, beyond issuing credentials
+func requiredOperations(db *dba.ManagedDatabase, migrationToRun *dba.DatabaseMigration) []dbadmin.Operation {
+	operations := []dbadmin.Operation{dbadmin.OperationCredentials}
+	if migrationToRun != nil || db.Spec.MetadataLockGuard != nil || db.Spec.ExportSchemaSnapshots {
+		operations = append(operations, dbadmin.OperationMigrations)
+	}
+	if db.Spec.HelperRoutines != nil {
+		operations = append(operations, dbadmin.OperationHelperRoutines)
+	}
+
+	// Views which were created before are still removed by the operator
+	if len(db.Spec.MaskedViews) > 0 || len(db.Status.MaskedViews) > 0 {
+		operations = append(operations, dbadmin.OperationMaskedViews)
+	}
+	if len(db.Spec.ScheduledStatements) > 0 || len(db.Status.ScheduledStatements) > 0 {
+		operations = append(operations, dbadmin.OperationScheduling)
+	}
+	if len(db.Spec.Partitioning) > 0 {
+		operations = append(operations, dbadmin.OperationPartitioning)
+	}
+	if len(db.Spec.Parameters) > 0 {
+		operations = append(operations, dbadmin.OperationParameters)
+	}
+	if db.Spec.Charset != nil && db.Spec.Charset.Policy == charsetPolicyConvert {
+		operations = append(operations, dbadmin.OperationCharset)
+	}
+	return operations
+}
+
+// checkAdminProfile refuses a ManagedDatabase which needs operations that
+// the admin user wasn't granted the privileges for, before any of them fail
+// part of the way through
+func checkAdminProfile(profile dbadmin.PrivilegeProfile, db *dba.ManagedDatabase, migrationToRun *dba.DatabaseMigration) error {
+	for _, operation := range requiredOperations(db, migrationToRun) {
+		if !profile.Allows(operation) {
+			return fmt.Errorf("ManagedDatabase requires %s, which the %s admin profile doesn't permit", operation, profile)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/progress"
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+// progressRefreshInterval is how often the progress of a running migration
+// is read from its Job
+const progressRefreshInterval = 30 * time.Second
+
+// progressTailLines is how much of the migration output is searched for the
+// latest progress report
+var progressTailLines int64 = 500
+
+// +kubebuilder:rbac:groups=,resources=pods,verbs=list;watch
+// +kubebuilder:rbac:groups=,resources=pods/log,verbs=get
+
+// relayMigrationProgress copies the latest progress report written by the
+// migration Job into the status block and the progress metrics. Output which
+// doesn't contain a report leaves the previous one in place.
+func (c *ManagedDatabaseController) relayMigrationProgress(oneMigration migrationContext) error {
+	if c.pods == nil {
+		return nil
+	}
+	db := oneMigration.db
+	if db.Status.MigrationProgress != nil && db.Status.MigrationProgress.Migration != oneMigration.version.Name {
+		c.clearMigrationProgress(db)
+	}
+
+	var jobPods corev1.PodList
+	jobName := migrationName(db.Name, oneMigration.version.Name)
+	if err := c.List(oneMigration.ctx, &jobPods, client.InNamespace(db.Namespace), client.MatchingLabels(map[string]string{"job-name": jobName})); err != nil {
+		return fmt.Errorf("Unable to list pods for migration Job (%s): %w", jobName, err)
+	}
+
+	// Retried pods start over, so only the newest one is relevant
+	var newest *corev1.Pod
+	for i := range jobPods.Items {
+		pod := &jobPods.Items[i]
+		if pod.Status.Phase == corev1.PodPending {
+			continue
+		}
+		if newest == nil || newest.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			newest = pod
+		}
+	}
+	if newest == nil {
+		return nil
+	}
+
+	output, err := c.pods.Pods(db.Namespace).GetLogs(newest.Name, &corev1.PodLogOptions{TailLines: &progressTailLines}).Stream()
+	if err != nil {
+		return fmt.Errorf("Unable to read output of migration pod (%s): %w", newest.Name, err)
+	}
+	defer output.Close()
+
+	report, err := progress.Latest(output)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		return nil
+	}
+
+	// The statement may contain literal values, which mustn't end up in the
+	// status block
+	db.Status.MigrationProgress = &dba.MigrationProgress{
+		Migration:     oneMigration.version.Name,
+		Step:          report.Step,
+		Total:         report.Total,
+		Statement:     redact.String(report.Statement),
+		RowsProcessed: report.RowsProcessed,
+		UpdatedAt:     metav1.Now(),
+	}
+
+	labels := migrationLabels(db, oneMigration.version.Name)
+	if ratio, known := report.Ratio(); known {
+		c.metrics.MigrationProgress.With(labels).Set(ratio)
+	}
+	c.metrics.MigrationRowsProcessed.With(labels).Set(float64(report.RowsProcessed))
+
+	return nil
+}
+
+// clearMigrationProgress forgets the progress of a migration which is no
+// longer running
+func (c *ManagedDatabaseController) clearMigrationProgress(db *dba.ManagedDatabase) {
+	if db.Status.MigrationProgress == nil {
+		return
+	}
+
+	labels := migrationLabels(db, db.Status.MigrationProgress.Migration)
+	c.metrics.MigrationProgress.Delete(labels)
+	c.metrics.MigrationRowsProcessed.Delete(labels)
+	db.Status.MigrationProgress = nil
+}
+
+func migrationLabels(db *dba.ManagedDatabase, migration string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace": db.Namespace,
+		"database":  db.Name,
+		"migration": migration,
+	}
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// deprovisionUser removes a user which is no longer needed. With a
+// quarantine configured the user is only locked, and is dropped later by
+// reconcileQuarantine if nobody needed it in the meantime.
+func (c *ManagedDatabaseController) deprovisionUser(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, username string, now time.Time) error {
+	if db.Spec.DeprovisionQuarantine.Duration <= 0 {
+		log.Info("Deprovisioning user account", "username", username)
+		return c.dropUser(ctx, admin, db, username)
+	}
+
+	if quarantineIndex(db, username) >= 0 {
+		// Dropped by reconcileQuarantine once the quarantine has passed
+		return nil
+	}
+
+	log.Info("Quarantining user account", "username", username, "quarantine", db.Spec.DeprovisionQuarantine.Duration)
+	if err := admin.LockCredentials(username); err != nil {
+		return err
+	}
+	db.Status.Quarantined = append(db.Status.Quarantined, dba.QuarantinedUser{
+		Username: username,
+		LockedAt: metav1.NewTime(now),
+	})
+	return nil
+}
+
+// releaseQuarantine unlocks a quarantined user which is needed again
+func releaseQuarantine(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, username string) error {
+	index := quarantineIndex(db, username)
+	if index < 0 {
+		return nil
+	}
+
+	log.Info("Releasing user account from quarantine", "username", username)
+	if err := admin.UnlockCredentials(username); err != nil {
+		return err
+	}
+	db.Status.Quarantined = append(db.Status.Quarantined[:index], db.Status.Quarantined[index+1:]...)
+	return nil
+}
+
+// reconcileQuarantine drops the quarantined users whose quarantine has
+// passed, and returns how long until the next one will, or zero if there are
+// none left.
+func (c *ManagedDatabaseController) reconcileQuarantine(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, now time.Time) (time.Duration, error) {
+	if len(db.Status.Quarantined) == 0 {
+		return 0, nil
+	}
+
+	existing, err := admin.ListUsernames(DBUsernamePrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	var recheck time.Duration
+	remaining := make([]dba.QuarantinedUser, 0, len(db.Status.Quarantined))
+	for i, quarantined := range db.Status.Quarantined {
+		if !containsString(existing, quarantined.Username) {
+			// Already removed by someone else
+			continue
+		}
+
+		release := quarantined.LockedAt.Add(db.Spec.DeprovisionQuarantine.Duration)
+		if now.Before(release) {
+			remaining = append(remaining, quarantined)
+			if wait := release.Sub(now); recheck == 0 || wait < recheck {
+				recheck = wait
+			}
+			continue
+		}
+
+		log.Info("Deprovisioning quarantined user account", "username", quarantined.Username, "lockedAt", quarantined.LockedAt)
+		if err := c.dropUser(ctx, admin, db, quarantined.Username); err != nil {
+			db.Status.Quarantined = append(remaining, db.Status.Quarantined[i:]...)
+			return 0, err
+		}
+	}
+	db.Status.Quarantined = remaining
+
+	return recheck, nil
+}
+
+// dropUser journals and then drops a user which is no longer needed
+func (c *ManagedDatabaseController) dropUser(ctx context.Context, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, username string) error {
+	if _, err := c.beginOperation(ctx, db, phaseCredentials, operationDropUser, username); err != nil {
+		return err
+	}
+	if err := admin.VerifyUnusedAndDeleteCredentials(username); err != nil {
+		return err
+	}
+	endOperation(db)
+
+	c.metrics.CredentialsRevoked.Inc()
+	return nil
+}
+
+// quarantinedUsernames returns the names of all of the users quarantined on
+// the database
+func quarantinedUsernames(db *dba.ManagedDatabase) map[string]bool {
+	names := make(map[string]bool, len(db.Status.Quarantined))
+	for _, quarantined := range db.Status.Quarantined {
+		names[quarantined.Username] = true
+	}
+	return names
+}
+
+func quarantineIndex(db *dba.ManagedDatabase, username string) int {
+	for i, quarantined := range db.Status.Quarantined {
+		if quarantined.Username == username {
+			return i
+		}
+	}
+	return -1
+}
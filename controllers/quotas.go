@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// Resources which quotas apply to, used as metric labels
+const (
+	quotaResourceUsers     = "users"
+	quotaResourceDatabases = "databases"
+)
+
+// checkDatabaseQuota returns a temporary error if the database is beyond the
+// limit of ManagedDatabases on its server. Databases are admitted in the
+// order they were created, so the ones which already fit are never pushed
+// out by a newer one.
+func (c *ManagedDatabaseController) checkDatabaseQuota(ctx context.Context, db *dba.ManagedDatabase, quotas config.Quotas) error {
+	instance := db.Status.Instance
+
+	var allDatabases dba.ManagedDatabaseList
+	if err := c.List(ctx, &allDatabases); err != nil {
+		return fmt.Errorf("Unable to list ManagedDatabases: %w", err)
+	}
+
+	onInstance := []dba.ManagedDatabase{*db}
+	for _, other := range allDatabases.Items {
+		if other.Status.Instance == instance && other.UID != db.UID {
+			onInstance = append(onInstance, other)
+		}
+	}
+	sort.Slice(onInstance, func(i, j int) bool {
+		if !onInstance[i].CreationTimestamp.Equal(&onInstance[j].CreationTimestamp) {
+			return onInstance[i].CreationTimestamp.Before(&onInstance[j].CreationTimestamp)
+		}
+		return onInstance[i].Namespace+"/"+onInstance[i].Name < onInstance[j].Namespace+"/"+onInstance[j].Name
+	})
+
+	limit := quotas.ForInstance(instance).MaxDatabases
+	c.recordQuota(instance, quotaResourceDatabases, len(onInstance), limit)
+	if limit == 0 {
+		return nil
+	}
+
+	for position, admitted := range onInstance {
+		if admitted.UID != db.UID {
+			continue
+		}
+		if position >= limit {
+			return xerrors.NewTempErrorf("Instance %s already holds its quota of %d ManagedDatabases", instance, limit)
+		}
+	}
+	return nil
+}
+
+func (c *ManagedDatabaseController) recordQuota(instance, resource string, used, limit int) {
+	labels := prometheus.Labels{"instance": instance, "resource": resource}
+	c.metrics.QuotaUsed.With(labels).Set(float64(used))
+	c.metrics.QuotaLimit.With(labels).Set(float64(limit))
+}
+
+// measureUserQuota exports how much of the server's user quota is in use
+func (c *ManagedDatabaseController) measureUserQuota(admin dbadmin.DbAdmin, instance string, quotas config.Quotas) error {
+	used, err := countIssuedUsers(admin)
+	if err != nil {
+		return fmt.Errorf("Unable to count issued users: %w", err)
+	}
+	c.recordQuota(instance, quotaResourceUsers, used, quotas.ForInstance(instance).MaxUsers)
+	return nil
+}
+
+// countIssuedUsers returns the number of users the operator has issued on
+// the server, for every database on it
+func countIssuedUsers(admin dbadmin.DbAdmin) (int, error) {
+	var count int
+	for _, prefix := range issuedUsernamePrefixes {
+		usernames, err := admin.ListUsernames(prefix)
+		if err != nil {
+			return 0, err
+		}
+		count += len(usernames)
+	}
+	return count, nil
+}
+
+// checkUserQuota returns a temporary error if issuing more users would take
+// the server beyond its limit
+func checkUserQuota(admin dbadmin.DbAdmin, quotas config.Quotas, adding int) error {
+	server, err := admin.DetectServer()
+	if err != nil {
+		return err
+	}
+
+	limit := quotas.ForInstance(server.Instance).MaxUsers
+	if limit == 0 || adding == 0 {
+		return nil
+	}
+
+	used, err := countIssuedUsers(admin)
+	if err != nil {
+		return fmt.Errorf("Unable to count issued users: %w", err)
+	}
+	if used+adding > limit {
+		return xerrors.NewTempErrorf("Instance %s has %d of its quota of %d users, and can't hold %d more", server.Instance, used, limit, adding)
+	}
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-dbaoperator-app-sre-redhat-com-v1alpha1-manageddatabase,mutating=false,failurePolicy=ignore,groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases,verbs=create,versions=v1alpha1,name=quotas.dbaoperator.app-sre.redhat.com
+
+// QuotaValidator is a validating webhook which rejects new ManagedDatabases
+// on servers that are already at their database quota. The server that a
+// new database will be on isn't known until it is connected to, so it is
+// learned from existing databases with the same configured address, and
+// databases on an unknown server are left to the reconcile time check.
+type QuotaValidator struct {
+	client  client.Client
+	log     logr.Logger
+	config  config.Provider
+	decoder *admission.Decoder
+}
+
+// NewQuotaValidator will instantiate a QuotaValidator with the supplied
+// arguments
+func NewQuotaValidator(c client.Client, l logr.Logger, cfg config.Provider) *QuotaValidator {
+	return &QuotaValidator{client: c, log: l, config: cfg}
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (qv *QuotaValidator) InjectDecoder(d *admission.Decoder) error {
+	qv.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler
+func (qv *QuotaValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var db dba.ManagedDatabase
+	if err := qv.decoder.Decode(req, &db); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if err := applyManagedDatabaseClass(ctx, qv.client, &db); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	address := configuredAddress(&db.Spec)
+	if address == "" {
+		return admission.Allowed("Database address is only known once connected")
+	}
+
+	var allDatabases dba.ManagedDatabaseList
+	if err := qv.client.List(ctx, &allDatabases); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	var instance string
+	for i := range allDatabases.Items {
+		other := allDatabases.Items[i]
+		if other.Status.Instance == "" {
+			continue
+		}
+		if err := applyManagedDatabaseClass(ctx, qv.client, &other); err != nil {
+			continue
+		}
+		if configuredAddress(&other.Spec) == address {
+			instance = other.Status.Instance
+			break
+		}
+	}
+	if instance == "" {
+		return admission.Allowed("No databases are known to be on the same instance")
+	}
+
+	limit := qv.config.Current().Quotas.ForInstance(instance).MaxDatabases
+	if limit == 0 {
+		return admission.Allowed("Instance has no database quota")
+	}
+
+	var used int
+	for _, other := range allDatabases.Items {
+		if other.Status.Instance == instance {
+			used++
+		}
+	}
+	if used >= limit {
+		qv.log.Info("Rejecting ManagedDatabase beyond quota", "namespace", req.Namespace, "manageddatabase", db.Name, "instance", instance)
+		return admission.Denied(fmt.Sprintf("Instance %s already holds its quota of %d ManagedDatabases", instance, limit))
+	}
+	return admission.Allowed("Instance has room for the database")
+}
+
+// configuredAddress returns the address a database spec connects to, which
+// is empty when it is only known from a DSN secret. It is only compared with
+// other configured addresses, so the default port isn't filled in.
+func configuredAddress(dbSpec *dba.ManagedDatabaseSpec) string {
+	conn := dbSpec.Connection.Spec
+	if dbSpec.Connection.DSNSecret != "" || conn == nil {
+		return ""
+	}
+	if conn.Socket != "" {
+		return "unix:" + conn.Socket
+	}
+	if len(conn.Hosts) > 0 {
+		// Whichever host is the primary, the databases are on the same
+		// instance when they list the same hosts
+		hosts := make([]string, 0, len(conn.Hosts))
+		for _, host := range conn.Hosts {
+			port := host.Port
+			if port == 0 {
+				port = conn.Port
+			}
+			hosts = append(hosts, net.JoinHostPort(host.Host, strconv.Itoa(int(port))))
+		}
+		sort.Strings(hosts)
+		return strings.Join(hosts, ",")
+	}
+	return net.JoinHostPort(conn.Host, strconv.Itoa(int(conn.Port)))
+}
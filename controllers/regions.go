@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/regions"
+)
+
+// regionalKey identifies a regional primary by the namespace and DSN secret
+// of the ManagedDatabase it belongs to
+type regionalKey struct {
+	namespace string
+	dsnSecret string
+}
+
+// regionalResultTracker remembers the last credential operation on each
+// regional primary, whichever controller ran it, until the ManagedDatabase
+// controller records it in the status
+type regionalResultTracker struct {
+	lock    sync.Mutex
+	results map[regionalKey]dba.RegionalPrimaryStatus
+}
+
+var regionalResults = &regionalResultTracker{results: make(map[regionalKey]dba.RegionalPrimaryStatus)}
+
+func (rt *regionalResultTracker) observe(key regionalKey, result regions.Result) {
+	status := dba.RegionalPrimaryStatus{
+		Name:      result.Endpoint,
+		Operation: result.Operation,
+		Succeeded: result.Err == nil,
+		At:        metav1.NewTime(time.Now().Truncate(time.Second)),
+	}
+	if result.Err != nil {
+		status.Error = result.Err.Error()
+	}
+
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+	rt.results[key] = status
+}
+
+// recordRegionalPrimaries updates the status of every regional primary of
+// the database with the results observed since the status was last written
+func (rt *regionalResultTracker) recordRegionalPrimaries(db *dba.ManagedDatabase) {
+	previous := make(map[string]dba.RegionalPrimaryStatus, len(db.Status.RegionalPrimaries))
+	for _, status := range db.Status.RegionalPrimaries {
+		previous[status.Name] = status
+	}
+
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	var statuses []dba.RegionalPrimaryStatus
+	for _, primary := range db.Spec.Connection.RegionalPrimaries {
+		if status, ok := rt.results[regionalKey{namespace: db.Namespace, dsnSecret: primary.DSNSecret}]; ok {
+			status.Name = primary.Name
+			statuses = append(statuses, status)
+		} else if status, ok := previous[primary.Name]; ok {
+			statuses = append(statuses, status)
+		}
+	}
+	db.Status.RegionalPrimaries = statuses
+}
+
+// connectRegionalPrimaries connects to every regional primary of the
+// database. A primary which can't be connected to is still returned, so that
+// the credential operations fail instead of skipping it.
+func connectRegionalPrimaries(ctx context.Context, log logr.Logger, apiClient client.Client, namespace string, dbSpec *dba.ManagedDatabaseSpec, create func(name, dsn string) (dbadmin.DbAdmin, error)) []regions.Endpoint {
+	endpoints := make([]regions.Endpoint, 0, len(dbSpec.Connection.RegionalPrimaries))
+	for _, primary := range dbSpec.Connection.RegionalPrimaries {
+		endpoint := regions.Endpoint{Name: primary.Name}
+		admin, err := readerConnection(ctx, apiClient, dbSpec.Connection.Engine, namespace, primary.DSNSecret, func(dsn string) (dbadmin.DbAdmin, error) {
+			return create(primary.Name, dsn)
+		})
+		if err == nil {
+			err = checkRegionalPrimary(dbSpec, admin)
+			if err != nil {
+				admin.Close()
+			}
+		}
+
+		if err != nil {
+			log.Error(err, "unable to connect to regional primary, credentials can't be issued", "region", primary.Name)
+			endpoint.Unavailable = err
+			regionalResults.observe(regionalKey{namespace: namespace, dsnSecret: primary.DSNSecret}, regions.Result{Endpoint: primary.Name, Operation: "Connect", Err: err})
+		} else {
+			endpoint.Admin = admin
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// checkRegionalPrimary applies the same compatibility requirements as the
+// primary, and adjusts the regional primary to its server's flavor
+func checkRegionalPrimary(dbSpec *dba.ManagedDatabaseSpec, admin dbadmin.DbAdmin) error {
+	server, err := admin.DetectServer()
+	if err != nil {
+		return err
+	}
+	return checkCompatibility(dbSpec, server)
+}
+
+// observeRegionalResults returns the observer of the regional primaries of a
+// database in the namespace
+func observeRegionalResults(namespace string, dbSpec *dba.ManagedDatabaseSpec) func(regions.Result) {
+	secrets := make(map[string]string, len(dbSpec.Connection.RegionalPrimaries))
+	for _, primary := range dbSpec.Connection.RegionalPrimaries {
+		secrets[primary.Name] = primary.DSNSecret
+	}
+	return func(result regions.Result) {
+		regionalResults.observe(regionalKey{namespace: namespace, dsnSecret: secrets[result.Endpoint]}, result)
+	}
+}
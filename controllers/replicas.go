@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/approval"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+	"github.com/app-sre/dba-operator/pkg/secretconvention"
+)
+
+// ReplicaUsernamePrefix is prepended to the read-only usernames created for
+// the replicas of a ManagedDatabase. Like CredentialRequestUsernamePrefix it
+// must not overlap DBUsernamePrefix, including through LIKE wildcards.
+const ReplicaUsernamePrefix = "rdb_"
+
+// replicaCheckInterval is how often the replicas are logged in to with the
+// read-only user
+const replicaCheckInterval = time.Minute
+
+// Labels of the Secrets published for replicas, which aren't labelled with
+// database-uid as they don't hold versioned credentials
+const (
+	replicaDatabaseUIDLabel = "replica-database-uid"
+	replicaEndpointLabel    = "replica-endpoint"
+)
+
+func replicaUsername(db *dba.ManagedDatabase) string {
+	digest := sha256.Sum256([]byte(db.UID))
+	return ReplicaUsernamePrefix + hex.EncodeToString(digest[:8])
+}
+
+func replicaSecretName(db *dba.ManagedDatabase, endpoint string) string {
+	if endpoint == "" {
+		return db.Name + "-replicas"
+	}
+	return db.Name + "-replica-" + endpoint
+}
+
+func replicaAddress(endpoint dba.ReplicaEndpoint, defaultPort int32) string {
+	return hostAddress(endpoint.Host, endpoint.Port, defaultPort)
+}
+
+// hostAddress returns host:port, or only the host when neither port is set
+func hostAddress(host string, port, defaultPort int32) string {
+	if port == 0 {
+		port = defaultPort
+	}
+	if port == 0 {
+		return host
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
+
+// replicaSecretData returns the contents of each Secret published for the
+// replicas, keyed by the endpoint it is for, or by the empty string for the
+// combined Secret
+func replicaSecretData(db *dba.ManagedDatabase, username, password string) map[string]map[string]string {
+	conn := db.Spec.Connection.Spec
+	base := func() map[string]string {
+		data := map[string]string{
+			"database": conn.Database,
+			"username": username,
+			"password": password,
+		}
+		if conn.TLS != "" {
+			data["tls"] = conn.TLS
+		}
+		return data
+	}
+
+	published := make(map[string]map[string]string)
+	if db.Spec.Replicas.Combined {
+		hosts := make([]string, 0, len(db.Spec.Replicas.Endpoints))
+		for _, endpoint := range db.Spec.Replicas.Endpoints {
+			hosts = append(hosts, replicaAddress(endpoint, conn.Port))
+		}
+		data := base()
+		data["hosts"] = strings.Join(hosts, ",")
+		published[""] = data
+		return published
+	}
+
+	for _, endpoint := range db.Spec.Replicas.Endpoints {
+		data := base()
+		data["host"] = endpoint.Host
+		if port := endpoint.Port; port != 0 {
+			data["port"] = strconv.Itoa(int(port))
+		} else if conn.Port != 0 {
+			data["port"] = strconv.Itoa(int(conn.Port))
+		}
+		published[endpoint.Name] = data
+	}
+	return published
+}
+
+// reconcileReplicas creates the read-only user for the replicas on the
+// primary, publishes it for every replica, and records whether each replica
+// accepts it. The user and its Secrets are removed once no replicas are
+// listed.
+func (c *ManagedDatabaseController) reconcileReplicas(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase) error {
+	published, err := listReplicaSecrets(ctx, c.Client, db)
+	if err != nil {
+		return err
+	}
+
+	if db.Spec.Replicas == nil || len(db.Spec.Replicas.Endpoints) == 0 {
+		return c.removeReplicaCredentials(ctx, log, admin, db, published)
+	}
+
+	conn := db.Spec.Connection.Spec
+	if conn == nil {
+		return errors.New("Replicas require a typed connection spec")
+	}
+	checker, ok := endpointCheckers[db.Spec.Connection.Engine]
+	if !ok {
+		return fmt.Errorf("Database engine %s does not support replicas", db.Spec.Connection.Engine)
+	}
+
+	username := replicaUsername(db)
+	password, err := c.ensureReplicaUser(log, admin, db, username, published)
+	if err != nil {
+		return err
+	}
+	db.Status.ReplicaUsername = username
+
+	desired := replicaSecretData(db, username, password)
+	for endpoint, data := range desired {
+		if err := c.writeReplicaSecret(ctx, db, endpoint, data, published[endpoint]); err != nil {
+			return err
+		}
+	}
+	for endpoint, secret := range published {
+		if _, ok := desired[endpoint]; ok {
+			continue
+		}
+		log.Info("Removing Secret of replica which is no longer listed", "secret", secret.Name)
+		if err := deleteSecretIfUnused(ctx, log, c.Client, db.Namespace, secret.Name); err != nil {
+			log.Error(err, "unable to remove replica Secret", "secret", secret.Name)
+		}
+	}
+
+	previous := make(map[string]bool, len(db.Status.Replicas))
+	for _, replica := range db.Status.Replicas {
+		previous[replica.Name] = replica.Healthy
+	}
+
+	statuses := make([]dba.ReplicaStatus, 0, len(db.Spec.Replicas.Endpoints))
+	for _, endpoint := range db.Spec.Replicas.Endpoints {
+		spec := typedConnectionSpec(conn, username, password)
+		spec.Host = endpoint.Host
+		spec.Hosts = nil
+		spec.Socket = ""
+		if endpoint.Port != 0 {
+			spec.Port = int(endpoint.Port)
+		}
+
+		secretEndpoint := endpoint.Name
+		if db.Spec.Replicas.Combined {
+			secretEndpoint = ""
+		}
+		status := dba.ReplicaStatus{
+			Name:       endpoint.Name,
+			SecretName: replicaSecretName(db, secretEndpoint),
+			Healthy:    true,
+			CheckedAt:  metav1.Now(),
+		}
+
+		err := checkHostScope(endpoint.Host)
+		if err == nil {
+			err = checker.CheckEndpoint(spec)
+		}
+		if err != nil {
+			status.Healthy = false
+			status.Message = err.Error()
+
+			if wasHealthy, known := previous[endpoint.Name]; !known || wasHealthy {
+				log.Info("Replica is unhealthy", "replica", endpoint.Name, "error", err.Error())
+				c.notifier.Notify(notify.Event{
+					Reason:    "ReplicaUnhealthy",
+					Namespace: db.Namespace,
+					Name:      db.Name,
+					Message:   fmt.Sprintf("Replica %s can't be queried with the read-only user: %s", endpoint.Name, err),
+				})
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	db.Status.Replicas = statuses
+
+	return nil
+}
+
+// ensureReplicaUser returns the password of the read-only user, creating the
+// user first if it doesn't exist. A user whose Secrets were all lost has its
+// password reset.
+func (c *ManagedDatabaseController) ensureReplicaUser(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, username string, published map[string]*corev1.Secret) (string, error) {
+	existingUsernames, err := admin.ListUsernames(username)
+	if err != nil {
+		return "", fmt.Errorf("Unable to list existing db usernames: %w", err)
+	}
+	exists := containsString(existingUsernames, username)
+
+	if exists {
+		for _, secret := range published {
+			if string(secret.Data["username"]) == username && len(secret.Data["password"]) > 0 {
+				return string(secret.Data["password"]), nil
+			}
+		}
+	}
+
+	grants, err := credentialRequestGrants(db, c.config.Current(), dbadmin.GrantClassReadOnly)
+	if err != nil {
+		return "", err
+	}
+	decision, err := approveCredentials(c.approver, databaseApprovalRequest(approval.KindReplicaUser, db, username), grants)
+	if err != nil {
+		return "", err
+	}
+	if !decision.Allowed {
+		return "", policyDenial(username, decision)
+	}
+	if grants, err = approvedGrants(decision, grants); err != nil {
+		return "", err
+	}
+	if err := enforceGrantPolicies(c.config.Current().Policies, log, db, username, grants); err != nil {
+		return "", err
+	}
+
+	password, err := randPassword()
+	if err != nil {
+		return "", fmt.Errorf("Unable to generate password for user (%s): %w", username, err)
+	}
+	credentials := dbadmin.Credentials{
+		Username:   username,
+		Password:   password,
+		Grants:     grants,
+		AuthPlugin: dbadmin.AuthPlugin(db.Spec.AuthPlugin),
+		Attributes: credentialAttributes(db, ownerKindDatabase, db, dbadmin.GrantClassReadOnly, time.Now()),
+	}
+
+	if exists {
+		log.Info("Resetting password of replica user without a secret", "username", username)
+		if err := admin.RotateCredentials([]dbadmin.Credentials{credentials}); err != nil {
+			return "", fmt.Errorf("Unable to reset password for user (%s): %w", username, err)
+		}
+		return password, nil
+	}
+
+	if err := checkUserQuota(admin, c.config.Current().Quotas, 1); err != nil {
+		return "", err
+	}
+	log.Info("Provisioning read-only user for replicas", "username", username)
+	if err := admin.WriteCredentialsBatch([]dbadmin.Credentials{credentials}); err != nil {
+		return "", fmt.Errorf("Unable to create db user (%s): %w", username, err)
+	}
+	c.metrics.CredentialsCreated.Inc()
+	return password, nil
+}
+
+func (c *ManagedDatabaseController) writeReplicaSecret(ctx context.Context, db *dba.ManagedDatabase, endpoint string, data map[string]string, existing *corev1.Secret) error {
+	secretName := replicaSecretName(db, endpoint)
+	if existing == nil {
+		// Secrets which weren't published for the replicas are left alone
+		var unrelated corev1.Secret
+		err := c.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: secretName}, &unrelated)
+		if err == nil {
+			return fmt.Errorf("Secret %s already exists and was not published for the replicas", secretName)
+		} else if !apierrs.IsNotFound(err) {
+			return fmt.Errorf("Unable to fetch secret (%s): %w", secretName, err)
+		}
+
+		labels, annotations, err := secretMetadata(db, db.Namespace, secretconvention.KindReplica, "", map[string]string{
+			replicaDatabaseUIDLabel: string(db.UID),
+			replicaEndpointLabel:    endpoint,
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeSecret(ctx, c.Client, db.Namespace, secretName, data, labels, annotations, db, c.Scheme); err != nil {
+			return fmt.Errorf("Unable to write secret (%s) to cluster: %w", secretName, err)
+		}
+		return nil
+	}
+
+	encoded := make(map[string][]byte, len(data))
+	for key, value := range data {
+		encoded[key] = []byte(value)
+	}
+	if reflect.DeepEqual(existing.Data, encoded) {
+		return nil
+	}
+	existing.Data = encoded
+	if err := c.Update(ctx, existing); err != nil {
+		return fmt.Errorf("Unable to update secret (%s): %w", secretName, err)
+	}
+	return nil
+}
+
+// removeReplicaCredentials drops the read-only user and its Secrets once the
+// replicas are removed from the spec. Secrets which are still in use are kept
+// until the next reconcile.
+func (c *ManagedDatabaseController) removeReplicaCredentials(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, published map[string]*corev1.Secret) error {
+	db.Status.Replicas = nil
+
+	remaining := false
+	for _, secret := range published {
+		log.Info("Removing replica Secret", "secret", secret.Name)
+		if err := deleteSecretIfUnused(ctx, log, c.Client, db.Namespace, secret.Name); err != nil {
+			log.Error(err, "unable to remove replica Secret", "secret", secret.Name)
+			remaining = true
+		}
+	}
+	if remaining || db.Status.ReplicaUsername == "" {
+		return nil
+	}
+
+	log.Info("Removing read-only user for replicas", "username", db.Status.ReplicaUsername)
+	if err := admin.VerifyUnusedAndDeleteCredentials(db.Status.ReplicaUsername); err != nil {
+		return fmt.Errorf("Unable to remove replica user (%s): %w", db.Status.ReplicaUsername, err)
+	}
+	c.metrics.CredentialsRevoked.Inc()
+	db.Status.ReplicaUsername = ""
+	return nil
+}
+
+// listReplicaSecrets returns the Secrets published for the replicas of the
+// database, keyed by the endpoint they are for
+func listReplicaSecrets(ctx context.Context, apiClient client.Client, db *dba.ManagedDatabase) (map[string]*corev1.Secret, error) {
+	var secrets corev1.SecretList
+	selector := map[string]string{replicaDatabaseUIDLabel: string(db.UID)}
+	if err := apiClient.List(ctx, &secrets, client.InNamespace(db.Namespace), client.MatchingLabels(selector)); err != nil {
+		return nil, fmt.Errorf("Unable to list replica secrets: %w", err)
+	}
+
+	published := make(map[string]*corev1.Secret, len(secrets.Items))
+	for i := range secrets.Items {
+		published[secrets.Items[i].Labels[replicaEndpointLabel]] = &secrets.Items[i]
+	}
+	return published, nil
+}
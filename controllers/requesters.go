@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+// VerifiedServiceAccountAnnotation records the service account which the
+// creator of a DatabaseCredentialRequest was verified to act as. It is only
+// written by the RequesterVerifier, which replaces any value set by clients.
+const VerifiedServiceAccountAnnotation = "dbaoperator.app-sre.redhat.com/verified-service-account"
+
+// +kubebuilder:webhook:path=/mutate-dbaoperator-app-sre-redhat-com-v1alpha1-databasecredentialrequest,mutating=true,failurePolicy=fail,groups=dbaoperator.app-sre.redhat.com,resources=databasecredentialrequests,verbs=create;update,versions=v1alpha1,name=requesters.dbaoperator.app-sre.redhat.com
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// RequesterVerifier is a mutating webhook which checks that whoever names a
+// service account in a DatabaseCredentialRequest is that service account, or
+// may impersonate it, and records the verified service account on the
+// request. Credential request policies which list service accounts are only
+// matched against verified ones.
+type RequesterVerifier struct {
+	client  client.Client
+	log     logr.Logger
+	decoder *admission.Decoder
+}
+
+// NewRequesterVerifier will instantiate a RequesterVerifier with the supplied
+// arguments
+func NewRequesterVerifier(c client.Client, l logr.Logger) *RequesterVerifier {
+	return &RequesterVerifier{client: c, log: l}
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (rv *RequesterVerifier) InjectDecoder(d *admission.Decoder) error {
+	rv.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler
+func (rv *RequesterVerifier) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var request dba.DatabaseCredentialRequest
+	if err := rv.decoder.Decode(req, &request); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	namespace := request.Namespace
+	if namespace == "" {
+		namespace = req.Namespace
+	}
+
+	var old *dba.DatabaseCredentialRequest
+	if len(req.OldObject.Raw) > 0 {
+		old = &dba.DatabaseCredentialRequest{}
+		if err := rv.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	verified, err := rv.verifiedServiceAccount(ctx, req.UserInfo, namespace, &request, old)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if request.Spec.ServiceAccountName != "" && verified != request.Spec.ServiceAccountName {
+		rv.log.Info("Rejecting credential request for a service account the requester can't act as",
+			"namespace", namespace, "databasecredentialrequest", request.Name, "serviceAccount", request.Spec.ServiceAccountName, "user", req.UserInfo.Username)
+		return admission.Denied(fmt.Sprintf("User %s may not act as service account %s", req.UserInfo.Username, request.Spec.ServiceAccountName))
+	}
+
+	if verified == "" {
+		delete(request.Annotations, VerifiedServiceAccountAnnotation)
+	} else {
+		if request.Annotations == nil {
+			request.Annotations = make(map[string]string)
+		}
+		request.Annotations[VerifiedServiceAccountAnnotation] = verified
+	}
+
+	marshaled, err := json.Marshal(&request)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// verifiedServiceAccount returns the service account of the request if the
+// user may act as it. Updates which don't change the spec, such as those of
+// the operator, keep the one verified when it was set. Any other update is
+// checked again, since the policies match the service account together with
+// the database and class it asks for.
+func (rv *RequesterVerifier) verifiedServiceAccount(
+	ctx context.Context,
+	user authenticationv1.UserInfo,
+	namespace string,
+	request *dba.DatabaseCredentialRequest,
+	old *dba.DatabaseCredentialRequest,
+) (string, error) {
+	serviceAccount := request.Spec.ServiceAccountName
+	if serviceAccount == "" {
+		return "", nil
+	}
+	if old != nil && old.Spec == request.Spec && old.Annotations[VerifiedServiceAccountAnnotation] == serviceAccount {
+		return serviceAccount, nil
+	}
+	if user.Username == fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccount) {
+		return serviceAccount, nil
+	}
+
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review := authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "impersonate",
+				Resource:  "serviceaccounts",
+				Name:      serviceAccount,
+			},
+		},
+	}
+	if err := rv.client.Create(ctx, &review); err != nil {
+		return "", fmt.Errorf("Unable to review access of user (%s) to service account (%s): %w", user.Username, serviceAccount, err)
+	}
+	if !review.Status.Allowed {
+		return "", nil
+	}
+	return serviceAccount, nil
+}
+
+// requestServiceAccount returns the service account of the request which
+// credential request policies may match, which is only one the
+// RequesterVerifier verified. Without the verifier nothing stops clients from
+// writing the annotation themselves, so no service account is trusted.
+func (c *CredentialRequestController) requestServiceAccount(request *dba.DatabaseCredentialRequest) string {
+	if !c.verifyRequesters {
+		return ""
+	}
+	verified := request.Annotations[VerifiedServiceAccountAnnotation]
+	if verified == "" || verified != request.Spec.ServiceAccountName {
+		return ""
+	}
+	return verified
+}
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+// reviewClient answers SubjectAccessReviews with a fixed decision
+type reviewClient struct {
+	client.Client
+	allowed bool
+	reviews []authorizationv1.SubjectAccessReview
+}
+
+func (rc *reviewClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOptionFunc) error {
+	if review, ok := obj.(*authorizationv1.SubjectAccessReview); ok {
+		review.Status.Allowed = rc.allowed
+		rc.reviews = append(rc.reviews, *review)
+		return nil
+	}
+	return rc.Client.Create(ctx, obj, opts...)
+}
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := dba.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := authorizationv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func testCredentialRequest(serviceAccount string, annotations map[string]string) *dba.DatabaseCredentialRequest {
+	return &dba.DatabaseCredentialRequest{
+		TypeMeta:   metav1.TypeMeta{APIVersion: dba.GroupVersion.String(), Kind: "DatabaseCredentialRequest"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "analytics", Name: "reports", Annotations: annotations},
+		Spec: dba.DatabaseCredentialRequestSpec{
+			ManagedDatabase:    dba.ManagedDatabaseReference{Namespace: "quay", Name: "quayio"},
+			ServiceAccountName: serviceAccount,
+		},
+	}
+}
+
+func withClass(request *dba.DatabaseCredentialRequest, class string) *dba.DatabaseCredentialRequest {
+	request.Spec.Class = class
+	return request
+}
+
+func admitCredentialRequest(t *testing.T, verifier *RequesterVerifier, username string, request, old *dba.DatabaseCredentialRequest) admission.Response {
+	raw, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admissionRequest := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Namespace: "analytics",
+		Object:    runtime.RawExtension{Raw: raw},
+		UserInfo:  authenticationv1.UserInfo{Username: username, Groups: []string{"system:authenticated"}},
+	}}
+	if old != nil {
+		oldRaw, err := json.Marshal(old)
+		if err != nil {
+			t.Fatal(err)
+		}
+		admissionRequest.Operation = admissionv1beta1.Update
+		admissionRequest.OldObject = runtime.RawExtension{Raw: oldRaw}
+	}
+	return verifier.Handle(context.Background(), admissionRequest)
+}
+
+// annotationPatch returns the value the response patches the verified
+// service account annotation to, and whether it is removed
+func annotationPatch(response admission.Response) (string, bool) {
+	for _, patch := range response.Patches {
+		switch patch.Path {
+		case "/metadata/annotations":
+			annotations, _ := patch.Value.(map[string]interface{})
+			value, _ := annotations[VerifiedServiceAccountAnnotation].(string)
+			return value, patch.Operation == "remove"
+		case "/metadata/annotations/dbaoperator.app-sre.redhat.com~1verified-service-account":
+			value, _ := patch.Value.(string)
+			return value, patch.Operation == "remove"
+		}
+	}
+	return "", false
+}
+
+func TestRequesterVerifier(t *testing.T) {
+	scheme := testScheme(t)
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified := map[string]string{VerifiedServiceAccountAnnotation: "reporter"}
+	for _, tc := range []struct {
+		name        string
+		username    string
+		request     *dba.DatabaseCredentialRequest
+		old         *dba.DatabaseCredentialRequest
+		impersonate bool
+		allowed     bool
+		reviewed    bool
+		annotation  string
+		removed     bool
+	}{
+		{
+			name:     "claimed service account of another requester",
+			username: "system:serviceaccount:analytics:other",
+			request:  testCredentialRequest("reporter", nil),
+			reviewed: true,
+		},
+		{
+			name:     "claimed service account with a forged annotation",
+			username: "jane",
+			request:  testCredentialRequest("reporter", verified),
+			reviewed: true,
+		},
+		{
+			name:       "service account itself",
+			username:   "system:serviceaccount:analytics:reporter",
+			request:    testCredentialRequest("reporter", nil),
+			allowed:    true,
+			annotation: "reporter",
+		},
+		{
+			name:        "user who may impersonate the service account",
+			username:    "jane",
+			request:     testCredentialRequest("reporter", nil),
+			impersonate: true,
+			allowed:     true,
+			reviewed:    true,
+			annotation:  "reporter",
+		},
+		{
+			name:     "no service account with a forged annotation",
+			username: "jane",
+			request:  testCredentialRequest("", verified),
+			allowed:  true,
+			removed:  true,
+		},
+		{
+			name:     "update which keeps the verified service account",
+			username: "system:serviceaccount:dba-operator:dba-operator",
+			request:  testCredentialRequest("reporter", verified),
+			old:      testCredentialRequest("reporter", verified),
+			allowed:  true,
+		},
+		{
+			name:     "update by another user which changes the class",
+			username: "jane",
+			request:  withClass(testCredentialRequest("reporter", verified), "readwrite"),
+			old:      withClass(testCredentialRequest("reporter", verified), "readonly"),
+			reviewed: true,
+		},
+		{
+			name:     "update which changes the service account",
+			username: "jane",
+			request:  testCredentialRequest("admin", verified),
+			old:      testCredentialRequest("reporter", verified),
+			reviewed: true,
+		},
+	} {
+		reviews := &reviewClient{Client: fake.NewFakeClientWithScheme(scheme), allowed: tc.impersonate}
+		verifier := NewRequesterVerifier(reviews, logf.NullLogger{})
+		if err := verifier.InjectDecoder(decoder); err != nil {
+			t.Fatal(err)
+		}
+
+		response := admitCredentialRequest(t, verifier, tc.username, tc.request, tc.old)
+		if response.Allowed != tc.allowed {
+			t.Errorf("%s: expected allowed to be %t, got %t", tc.name, tc.allowed, response.Allowed)
+		}
+		if tc.reviewed != (len(reviews.reviews) > 0) {
+			t.Errorf("%s: expected reviewed to be %t, got %d reviews", tc.name, tc.reviewed, len(reviews.reviews))
+		}
+		for _, review := range reviews.reviews {
+			attributes := review.Spec.ResourceAttributes
+			if review.Spec.User != tc.username || attributes.Verb != "impersonate" || attributes.Namespace != "analytics" || attributes.Name != tc.request.Spec.ServiceAccountName {
+				t.Errorf("%s: unexpected review %+v", tc.name, review.Spec)
+			}
+		}
+		if !tc.allowed {
+			continue
+		}
+		annotation, removed := annotationPatch(response)
+		if annotation != tc.annotation || removed != tc.removed {
+			t.Errorf("%s: expected annotation %q (removed %t), got %q (removed %t) from %v", tc.name, tc.annotation, tc.removed, annotation, removed, response.Patches)
+		}
+	}
+}
+
+func TestRequestServiceAccount(t *testing.T) {
+	verified := map[string]string{VerifiedServiceAccountAnnotation: "reporter"}
+	for _, tc := range []struct {
+		name     string
+		verify   bool
+		request  *dba.DatabaseCredentialRequest
+		expected string
+	}{
+		{"verified", true, testCredentialRequest("reporter", verified), "reporter"},
+		{"not verified", true, testCredentialRequest("reporter", nil), ""},
+		{"verified another service account", true, testCredentialRequest("admin", verified), ""},
+		{"verification disabled", false, testCredentialRequest("reporter", verified), ""},
+	} {
+		c := &CredentialRequestController{verifyRequesters: tc.verify}
+		if serviceAccount := c.requestServiceAccount(tc.request); serviceAccount != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, serviceAccount)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+const (
+	defaultRetentionBatchSize     = 1000
+	defaultRetentionPause         = time.Second
+	defaultRetentionMaxReplicaLag = 30 * time.Second
+
+	// retentionPassBudget bounds how long one reconcile spends purging, the
+	// rules which aren't caught up continue in the next one
+	retentionPassBudget = 20 * time.Second
+
+	// retentionRefreshInterval is how often rules which are caught up are
+	// checked for rows which have expired since
+	retentionRefreshInterval = 15 * time.Minute
+
+	minRetentionBackoff = 30 * time.Second
+	maxRetentionBackoff = 30 * time.Minute
+)
+
+// reconcileRetention deletes the expired rows of each retention rule a batch
+// at a time while a maintenance window is open, backing off while the
+// replicas are too far behind. It returns how soon purging should continue.
+func (c *ManagedDatabaseController) reconcileRetention(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, now time.Time) (time.Duration, error) {
+	if len(db.Spec.Retention) == 0 {
+		db.Status.Retention = nil
+		return 0, nil
+	}
+
+	recorded := make(map[string]dba.RetentionStatus, len(db.Status.Retention))
+	for _, status := range db.Status.Retention {
+		recorded[status.Name] = status
+	}
+	statuses := make([]dba.RetentionStatus, len(db.Spec.Retention))
+	for i, rule := range db.Spec.Retention {
+		statuses[i] = dba.RetentionStatus{Name: rule.Name}
+		if status, ok := recorded[rule.Name]; ok {
+			statuses[i] = status
+		}
+	}
+	db.Status.Retention = statuses
+
+	open, next, err := maintenanceWindowOpen(db, now)
+	if err != nil {
+		return 0, err
+	}
+	if !open {
+		log.Info("Waiting for a maintenance window to purge expired rows", "opens", next)
+		return next.Sub(now), nil
+	}
+
+	requeue := retentionRefreshInterval
+	deadline := now.Add(retentionPassBudget)
+	for i, rule := range db.Spec.Retention {
+		after, err := c.purgeExpiredRows(log.WithValues("rule", rule.Name), admin, db, rule, &statuses[i], deadline)
+
+		caughtUp := 0.0
+		if statuses[i].CaughtUp {
+			caughtUp = 1
+		}
+		c.metrics.RetentionCaughtUp.With(retentionLabels(db, rule.Name)).Set(caughtUp)
+		if err != nil {
+			return 0, err
+		}
+		if after < requeue {
+			requeue = after
+		}
+	}
+	return requeue, nil
+}
+
+// purgeExpiredRows deletes batches for the rule until it is caught up, the
+// replicas fall behind, or the deadline passes, and returns how soon it
+// should continue
+func (c *ManagedDatabaseController) purgeExpiredRows(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, rule dba.RetentionRule, status *dba.RetentionStatus, deadline time.Time) (time.Duration, error) {
+	if status.BackoffUntil != nil && time.Now().Before(status.BackoffUntil.Time) {
+		return time.Until(status.BackoffUntil.Time), nil
+	}
+
+	batchSize := int(rule.BatchSize)
+	if batchSize <= 0 {
+		batchSize = defaultRetentionBatchSize
+	}
+	pause := rule.Pause.Duration
+	if pause <= 0 {
+		pause = defaultRetentionPause
+	}
+	maxLag := rule.MaxReplicaLag.Duration
+	if maxLag <= 0 {
+		maxLag = defaultRetentionMaxReplicaLag
+	}
+
+	labels := retentionLabels(db, rule.Name)
+	for {
+		health, err := admin.GetReplicationHealth()
+		if err != nil {
+			return 0, err
+		}
+		if health.ReplicaLag > maxLag {
+			backoff := status.Backoff.Duration * 2
+			if backoff < minRetentionBackoff {
+				backoff = minRetentionBackoff
+			} else if backoff > maxRetentionBackoff {
+				backoff = maxRetentionBackoff
+			}
+			log.Info("Replicas are behind, backing off purges", "replicaLag", health.ReplicaLag, "backoff", backoff)
+			until := metav1.NewTime(time.Now().Add(backoff))
+			status.Backoff = metav1.Duration{Duration: backoff}
+			status.BackoffUntil = &until
+			c.metrics.RetentionBackoffs.With(labels).Inc()
+			return backoff, nil
+		}
+
+		purged, err := admin.PurgeRows(rule.Table, rule.TimestampColumn, rule.Retain.Duration, batchSize)
+		if err != nil {
+			return 0, err
+		}
+		batchAt := metav1.Now()
+		status.RowsPurged += purged
+		status.LastBatch = &batchAt
+		status.CaughtUp = purged < int64(batchSize)
+		status.Backoff = metav1.Duration{}
+		status.BackoffUntil = nil
+		c.metrics.RetentionRowsPurged.With(labels).Add(float64(purged))
+
+		if status.CaughtUp {
+			log.Info("Purged expired rows", "table", rule.Table, "rowsPurged", status.RowsPurged)
+			return retentionRefreshInterval, nil
+		}
+		if time.Now().Add(pause).After(deadline) {
+			log.Info("Purging expired rows continues in the next reconcile", "table", rule.Table, "rowsPurged", status.RowsPurged)
+			return pause, nil
+		}
+		time.Sleep(pause)
+	}
+}
+
+func retentionLabels(db *dba.ManagedDatabase, rule string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace": db.Namespace,
+		"database":  db.Name,
+		"rule":      rule,
+	}
+}
@@ -0,0 +1,428 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+	"github.com/app-sre/dba-operator/pkg/notify"
+	"github.com/app-sre/dba-operator/pkg/silence"
+)
+
+// CredentialsRotatedAtAnnotation is written to every credentials secret when
+// its password is rotated, and contains the RFC3339 time of the rotation
+const CredentialsRotatedAtAnnotation = "dbaoperator.app-sre.redhat.com/rotated-at"
+
+// OldPasswordRetainedAnnotation is written to a credentials secret when its
+// password is rotated, and removed once the previous password, which the
+// database still accepts, has been discarded
+const OldPasswordRetainedAnnotation = "dbaoperator.app-sre.redhat.com/old-password-retained"
+
+// errDualPasswordsUnsupported is returned when rotating credentials on a
+// server which can't accept the previous password until consumers have
+// picked up the new one
+var errDualPasswordsUnsupported = errors.New("the server can't keep the previous password valid during the rotation")
+
+// FleetRotationController periodically rotates the passwords for all of the
+// credentials issued to every ManagedDatabase, pacing the rotations so that
+// large fleets aren't all rotated at once.
+type FleetRotationController struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	config      config.Provider
+	notifier    notify.Notifier
+	silencer    silence.Silencer
+	metrics     FleetRotationControllerMetrics
+	diagnostics *diagnostics.Recorder
+}
+
+// rotationRecheckInterval is how often the config is checked for a change
+// while rotation is disabled
+const rotationRecheckInterval = time.Minute
+
+// NewFleetRotationController will instantiate a FleetRotationController with
+// the supplied arguments and logical defaults. diag enables debug mode, see
+// diagnostics.Recorder. Alerts about each database are silenced through
+// silencer while it is rotated.
+func NewFleetRotationController(
+	c client.Client,
+	scheme *runtime.Scheme,
+	l logr.Logger,
+	diag *diagnostics.Recorder,
+	cfg config.Provider,
+	notifier notify.Notifier,
+	silencer silence.Silencer,
+) (*FleetRotationController, []prometheus.Collector) {
+	metrics := generateFleetRotationControllerMetrics()
+
+	return &FleetRotationController{
+		Client:      c,
+		Scheme:      scheme,
+		Log:         l,
+		config:      cfg,
+		notifier:    notifier,
+		silencer:    silencer,
+		metrics:     metrics,
+		diagnostics: diag,
+	}, getAllMetrics(metrics)
+}
+
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases/status,verbs=get;update
+// +kubebuilder:rbac:groups=,resources=secrets,verbs=get;list;update
+
+// Start implements manager.Runnable. The rotation settings are re-read from
+// the config before every pass, so changes take effect without a restart.
+// Databases with their own rotation schedule are rotated alongside the
+// passes.
+func (frc *FleetRotationController) Start(stop <-chan struct{}) error {
+	go frc.runSchedules(stop)
+
+	for {
+		wait := frc.config.Current().Rotation.Interval.Duration
+		enabled := wait > 0
+		if !enabled {
+			wait = rotationRecheckInterval
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(wait):
+			if !enabled || frc.config.Current().Rotation.Interval.Duration == 0 {
+				continue
+			}
+			if err := frc.rotateFleet(stop); err != nil {
+				frc.Log.Error(err, "Credential rotation pass did not complete")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (frc *FleetRotationController) NeedLeaderElection() bool {
+	return true
+}
+
+func (frc *FleetRotationController) rotateFleet(stop <-chan struct{}) error {
+	var ctx = context.Background()
+
+	var allDatabases dba.ManagedDatabaseList
+	if err := frc.List(ctx, &allDatabases); err != nil {
+		return fmt.Errorf("Unable to list ManagedDatabases: %w", err)
+	}
+	frc.Log.Info("Starting credential rotation pass", "numDatabases", len(allDatabases.Items))
+
+	rotation := frc.config.Current().Rotation
+	if rotation.RotationsPerMinute < 1 {
+		rotation.RotationsPerMinute = 1
+	}
+
+	pacer := time.NewTicker(time.Minute / time.Duration(rotation.RotationsPerMinute))
+	defer pacer.Stop()
+
+	failures := 0
+	for i := range allDatabases.Items {
+		db := &allDatabases.Items[i]
+		log := phaseLogger(frc.Log.WithValues(
+			"manageddatabase", types.NamespacedName{Namespace: db.Namespace, Name: db.Name},
+			"engine", db.Spec.Connection.Engine,
+		), phaseRotation)
+
+		if err := applyManagedDatabaseClass(ctx, frc.Client, db); err != nil {
+			log.Error(err, "unable to apply ManagedDatabaseClass")
+			frc.metrics.RotationFailures.Inc()
+			continue
+		}
+		if db.Spec.Rotation != nil && db.Spec.Rotation.Disabled {
+			log.Info("Skipping rotation, it is disabled for the database")
+			continue
+		}
+		if db.Spec.Rotation != nil && db.Spec.Rotation.Schedule != "" {
+			log.Info("Skipping rotation, the database is rotated on its own schedule")
+			continue
+		}
+
+		if reason := pauseReason(frc.config.Current(), db); reason != "" {
+			log.Info("Skipping rotation", "reason", reason)
+			continue
+		}
+
+		if i > 0 {
+			select {
+			case <-stop:
+				return nil
+			case <-pacer.C:
+			}
+		}
+
+		name := "rotation " + db.Namespace + "/" + db.Name
+		if !drainer.Begin(name) {
+			log.Info("Stopping rotation pass, the operator is shutting down")
+			return nil
+		}
+		err := frc.rotateDatabase(ctx, log, db)
+		drainer.Done(name)
+		if errors.Is(err, errDualPasswordsUnsupported) {
+			log.Info("Skipping rotation", "reason", err.Error())
+			continue
+		}
+		if err != nil {
+			log.Error(err, "unable to rotate credentials")
+			frc.metrics.RotationFailures.Inc()
+
+			failures++
+			if failures > rotation.ErrorBudget {
+				frc.metrics.RotationPassAborted.Inc()
+				abortErr := fmt.Errorf("Aborting rotation pass after %d failures exceeded the error budget of %d", failures, rotation.ErrorBudget)
+				frc.notifier.Notify(notify.Event{Reason: "RotationAborted", Message: abortErr.Error()})
+				return abortErr
+			}
+		}
+	}
+
+	return nil
+}
+
+func (frc *FleetRotationController) rotateDatabase(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase) error {
+	secretList, err := listSecretsForDatabase(ctx, frc.Client, db)
+	if err != nil {
+		return fmt.Errorf("Unable to list existing cluster secrets: %w", err)
+	}
+
+	toRotate := make([]*corev1.Secret, 0, len(secretList.Items))
+	credentials := make([]dbadmin.Credentials, 0, len(secretList.Items))
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+
+		username := string(secret.Data["username"])
+		if username == "" {
+			log.Info("Skipping secret without a username", "secret", secret.Name)
+			continue
+		}
+		if _, ok := secret.Annotations[OldPasswordRetainedAnnotation]; ok {
+			// Rotating again would discard the password those pods hold
+			log.Info("Skipping secret, pods still use the password from the previous rotation", "secret", secret.Name)
+			continue
+		}
+
+		newPassword, err := randPassword()
+		if err != nil {
+			return fmt.Errorf("Unable to generate password for user (%s): %w", username, err)
+		}
+
+		toRotate = append(toRotate, secret)
+		credentials = append(credentials, dbadmin.Credentials{
+			Username:              username,
+			Password:              newPassword,
+			AuthPlugin:            dbadmin.AuthPlugin(db.Spec.AuthPlugin),
+			RetainCurrentPassword: true,
+		})
+	}
+
+	if len(credentials) == 0 {
+		return nil
+	}
+
+	admin, err := initializeAdminConnection(ctx, log, frc.diagnostics, frc.Client, db.Namespace, &db.Spec)
+	if err != nil {
+		return fmt.Errorf("Unable to create database connection: %w", err)
+	}
+	defer admin.Close()
+
+	server, err := admin.DetectServer()
+	if err != nil {
+		return fmt.Errorf("Unable to detect database server: %w", err)
+	}
+	if !server.HasFeature(dbadmin.FeatureDualPasswords) {
+		// Replacing the password in place would lock out every pod until
+		// it restarts with the new one
+		return fmt.Errorf("Unable to rotate credentials on %s %s: %w", server.Flavor, server.Version, errDualPasswordsUnsupported)
+	}
+
+	unlock, err := lockOperator(log, admin, frc.config.Current().Leases)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	recorded, err := listIssuedUserAttributes(admin)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range credentials {
+		credentials[i].Attributes = rotatedAttributes(db, recorded, credentials[i].Username, now)
+	}
+
+	if id := silenceRotation(log, frc.silencer, frc.config.Current().Silences, db); id != "" {
+		defer expireSilence(log, frc.silencer, id)
+	}
+
+	log.Info("Rotating credentials", "numUsername", len(credentials))
+	return frc.rotateSecrets(ctx, log, admin, db, toRotate, credentials, recorded, now)
+}
+
+// rotateSecrets rotates the passwords of all of the secrets' users in one
+// batch, keeping the current passwords valid, and then writes the new ones to
+// the secrets. Pods which started with the previous password can still
+// connect until the retained passwords are discarded, once they have rolled.
+// The users whose secrets can't be written get the previous password back.
+func (frc *FleetRotationController) rotateSecrets(
+	ctx context.Context,
+	log logr.Logger,
+	admin dbadmin.DbAdmin,
+	db *dba.ManagedDatabase,
+	secrets []*corev1.Secret,
+	credentials []dbadmin.Credentials,
+	recorded map[string]dbadmin.UserAttributes,
+	now time.Time,
+) error {
+	previous := make([]dbadmin.Credentials, 0, len(credentials))
+	for i, secret := range secrets {
+		restored := dbadmin.Credentials{
+			Username:   credentials[i].Username,
+			Password:   string(secret.Data["password"]),
+			AuthPlugin: credentials[i].AuthPlugin,
+		}
+		if attributes, ok := recorded[restored.Username]; ok {
+			restored.Attributes = &attributes
+		}
+		previous = append(previous, restored)
+	}
+
+	if err := admin.RotateCredentials(credentials); err != nil {
+		// Some of the passwords may have changed even though the batch
+		// failed, so the ones that the secrets still hold are set again
+		err = fmt.Errorf("Unable to rotate credentials of %d users in the database: %w", len(credentials), err)
+		return frc.restorePasswords(log, admin, previous, err)
+	}
+
+	rotatedAt := now.UTC().Format(time.RFC3339)
+	var unwritten []dbadmin.Credentials
+	var writeErr error
+	for i, secret := range secrets {
+		if err := frc.writeRotatedSecret(ctx, log, db, secret, credentials[i], rotatedAt); err != nil {
+			log.Error(err, "Unable to write rotated password", "secret", secret.Name)
+			unwritten = append(unwritten, previous[i])
+			writeErr = err
+			continue
+		}
+		frc.metrics.CredentialsRotated.Inc()
+	}
+	if len(unwritten) == 0 {
+		return nil
+	}
+
+	writeErr = fmt.Errorf("Unable to write %d of %d rotated secrets: %w", len(unwritten), len(secrets), writeErr)
+	return frc.restorePasswords(log, admin, unwritten, writeErr)
+}
+
+// restorePasswords sets the passwords which the secrets still hold again, and
+// returns the error which made it necessary
+func (frc *FleetRotationController) restorePasswords(log logr.Logger, admin dbadmin.DbAdmin, previous []dbadmin.Credentials, err error) error {
+	usernames := make([]string, 0, len(previous))
+	for _, cred := range previous {
+		usernames = append(usernames, cred.Username)
+	}
+
+	log.Info("Restoring previous passwords after failed rotation", "usernames", usernames)
+	if restoreErr := admin.RotateCredentials(previous); restoreErr != nil {
+		log.Error(restoreErr, "Unable to restore previous passwords", "usernames", usernames)
+		return fmt.Errorf("%w, and the previous passwords of users (%s) could not be restored: %v", err, strings.Join(usernames, ", "), restoreErr)
+	}
+	frc.metrics.RotationsRolledBack.Add(float64(len(previous)))
+	return err
+}
+
+// writeRotatedSecret writes the rotated password of the secret's user to the
+// secret
+func (frc *FleetRotationController) writeRotatedSecret(
+	ctx context.Context,
+	log logr.Logger,
+	db *dba.ManagedDatabase,
+	secret *corev1.Secret,
+	rotated dbadmin.Credentials,
+	rotatedAt string,
+) error {
+	updated := secret.DeepCopy()
+	updated.Data["password"] = []byte(rotated.Password)
+	if _, err := applySecretFormat(db, updated.Data); err != nil {
+		// The password has already changed, so it has to be written
+		log.Error(err, "Unable to format rotated secret", "secret", secret.Name)
+	}
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+	updated.Annotations[CredentialsRotatedAtAnnotation] = rotatedAt
+	updated.Annotations[OldPasswordRetainedAnnotation] = rotatedAt
+	if previous, ok := updated.Annotations[CredentialsChecksumAnnotation]; ok {
+		updated.Annotations[PreviousChecksumAnnotation] = previous
+	}
+	updated.Annotations[CredentialsChecksumAnnotation] = secretChecksum(updated.Data)
+
+	if err := frc.Update(ctx, updated); err != nil {
+		return fmt.Errorf("Unable to update secret (%s) with rotated password: %w", secret.Name, err)
+	}
+	*secret = *updated
+	return nil
+}
+
+// discardRetainedPasswords discards the previous passwords of the users whose
+// secrets no running pod was started before the rotation of, using the
+// consumers recorded in the database's status
+func discardRetainedPasswords(ctx context.Context, log logr.Logger, apiClient client.Client, admin dbadmin.DbAdmin, db *dba.ManagedDatabase) error {
+	secretList, err := listSecretsForDatabase(ctx, apiClient, db)
+	if err != nil {
+		return fmt.Errorf("Unable to list existing cluster secrets: %w", err)
+	}
+
+	staleSecrets := make(map[string]bool)
+	for _, consumers := range db.Status.Consumers {
+		for _, generation := range consumers.Generations {
+			if !generation.Current {
+				staleSecrets[consumers.Secret] = true
+			}
+		}
+	}
+
+	var discarded []*corev1.Secret
+	var usernames []string
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		if _, ok := secret.Annotations[OldPasswordRetainedAnnotation]; ok && !staleSecrets[secret.Name] {
+			discarded = append(discarded, secret)
+			usernames = append(usernames, string(secret.Data["username"]))
+		}
+	}
+	if len(discarded) == 0 {
+		return nil
+	}
+
+	log.Info("Discarding previous passwords, no pod uses them", "usernames", usernames)
+	if err := admin.DiscardOldPasswords(usernames); err != nil {
+		return fmt.Errorf("Unable to discard previous passwords: %w", err)
+	}
+	for _, secret := range discarded {
+		updated := secret.DeepCopy()
+		delete(updated.Annotations, OldPasswordRetainedAnnotation)
+		if err := apiClient.Update(ctx, updated); err != nil {
+			return fmt.Errorf("Unable to update secret (%s) after discarding the previous password: %w", secret.Name, err)
+		}
+	}
+	return nil
+}
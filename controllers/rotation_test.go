@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// rotationAdmin records the passwords which the database accepts for each
+// user, the current one and the one retained by the last rotation
+type rotationAdmin struct {
+	dbadmin.DbAdmin
+	passwords map[string]string
+	retained  map[string]string
+
+	// failRotation makes batches rotating to other passwords fail after
+	// they are applied, and failRestore makes rotations back to a password
+	// fail
+	failRotation bool
+	failRestore  bool
+	restored     []string
+	discarded    []string
+}
+
+func (ra *rotationAdmin) RotateCredentials(credentials []dbadmin.Credentials) error {
+	restoring := false
+	for _, cred := range credentials {
+		if strings.HasPrefix(cred.Password, "old-") {
+			if ra.failRestore {
+				return fmt.Errorf("connection lost")
+			}
+			restoring = true
+			ra.restored = append(ra.restored, cred.Username)
+		}
+		if cred.RetainCurrentPassword {
+			ra.retained[cred.Username] = ra.passwords[cred.Username]
+		}
+		ra.passwords[cred.Username] = cred.Password
+	}
+	if ra.failRotation && !restoring {
+		return fmt.Errorf("Unable to record rotation on users")
+	}
+	return nil
+}
+
+func (ra *rotationAdmin) DiscardOldPasswords(usernames []string) error {
+	for _, username := range usernames {
+		delete(ra.retained, username)
+		ra.discarded = append(ra.discarded, username)
+	}
+	return nil
+}
+
+// accepts returns true if the user can log in with the password
+func (ra *rotationAdmin) accepts(username, password string) bool {
+	return ra.passwords[username] == password || ra.retained[username] == password
+}
+
+// updateFailingClient fails to update the named secrets
+type updateFailingClient struct {
+	client.Client
+	failUpdate map[string]bool
+}
+
+func (ufc *updateFailingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOptionFunc) error {
+	if secret, ok := obj.(*corev1.Secret); ok && ufc.failUpdate[secret.Name] {
+		return fmt.Errorf("the object has been modified")
+	}
+	return ufc.Client.Update(ctx, obj, opts...)
+}
+
+func TestRotateSecretsPartialFailure(t *testing.T) {
+	users := []string{"dba_app_v1", "dba_app_v2", "dba_app_v3"}
+	for _, tc := range []struct {
+		name         string
+		failUpdate   map[string]bool
+		failRotation bool
+		failRestore  bool
+		rotated      []string
+		restored     []string
+		rejected     []string
+	}{
+		{
+			name:    "every secret written",
+			rotated: users,
+		},
+		{
+			name:       "secret of the second user can't be written",
+			failUpdate: map[string]bool{"app-v2": true},
+			rotated:    []string{users[0], users[2]},
+			restored:   users[1:2],
+		},
+		{
+			name:         "rotation of the batch fails after it was applied",
+			failRotation: true,
+			restored:     users,
+		},
+		{
+			name:        "previous password can't be restored",
+			failUpdate:  map[string]bool{"app-v2": true},
+			failRestore: true,
+			rotated:     []string{users[0], users[2]},
+		},
+	} {
+		scheme := testScheme(t)
+		db := &dba.ManagedDatabase{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "app", UID: "db-uid"}}
+
+		admin := &rotationAdmin{passwords: map[string]string{}, retained: map[string]string{}, failRotation: tc.failRotation, failRestore: tc.failRestore}
+		var objects []runtime.Object
+		var secrets []*corev1.Secret
+		var credentials []dbadmin.Credentials
+		for i, username := range users {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: fmt.Sprintf("app-v%d", i+1), Labels: map[string]string{"database-uid": "db-uid"}},
+				Data:       map[string][]byte{"username": []byte(username), "password": []byte("old-" + username)},
+			}
+			admin.passwords[username] = "old-" + username
+			objects = append(objects, secret.DeepCopy())
+			secrets = append(secrets, secret)
+			credentials = append(credentials, dbadmin.Credentials{Username: username, Password: "new-" + username, RetainCurrentPassword: true})
+		}
+
+		apiClient := &updateFailingClient{Client: fake.NewFakeClientWithScheme(scheme, objects...), failUpdate: tc.failUpdate}
+		frc := &FleetRotationController{Client: apiClient, Log: logf.NullLogger{}, metrics: generateFleetRotationControllerMetrics()}
+
+		err := frc.rotateSecrets(context.Background(), logf.NullLogger{}, admin, db, secrets, credentials, map[string]dbadmin.UserAttributes{}, time.Now())
+		if len(tc.rotated) == len(users) && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		} else if len(tc.rotated) < len(users) && err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+		if tc.failRestore && (err == nil || !strings.Contains(err.Error(), "could not be restored")) {
+			t.Errorf("%s: expected the failed restore to be reported, got %v", tc.name, err)
+		}
+		if strings.Join(admin.restored, ",") != strings.Join(tc.restored, ",") {
+			t.Errorf("%s: expected %v to be restored, got %v", tc.name, tc.restored, admin.restored)
+		}
+
+		for i, username := range users {
+			var stored corev1.Secret
+			if err := apiClient.Get(context.Background(), types.NamespacedName{Namespace: "apps", Name: secrets[i].Name}, &stored); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := "old-" + username
+			if containsString(tc.rotated, username) {
+				expected = "new-" + username
+				if stored.Annotations[CredentialsRotatedAtAnnotation] == "" || stored.Annotations[OldPasswordRetainedAnnotation] == "" {
+					t.Errorf("%s: expected secret %s to be annotated with the rotation", tc.name, stored.Name)
+				}
+				if !admin.accepts(username, "old-"+username) {
+					t.Errorf("%s: expected the previous password of %s to be retained", tc.name, username)
+				}
+			}
+			if string(stored.Data["password"]) != expected {
+				t.Errorf("%s: expected secret %s to hold %s, got %s", tc.name, stored.Name, expected, stored.Data["password"])
+			}
+
+			// Pods keep working with the password the secret holds, even
+			// when restoring the previous one failed
+			if !admin.accepts(username, string(stored.Data["password"])) {
+				t.Errorf("%s: expected the database to accept the password of secret %s", tc.name, stored.Name)
+			}
+		}
+	}
+}
+
+func TestDiscardRetainedPasswords(t *testing.T) {
+	retained := map[string]string{OldPasswordRetainedAnnotation: "2020-03-01T00:00:00Z"}
+	secret := func(name, username string, annotations map[string]string) runtime.Object {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: name, Labels: map[string]string{"database-uid": "db-uid"}, Annotations: annotations},
+			Data:       map[string][]byte{"username": []byte(username)},
+		}
+	}
+	apiClient := fake.NewFakeClientWithScheme(testScheme(t),
+		secret("app-v1", "dba_app_v1", retained),
+		secret("app-v2", "dba_app_v2", retained),
+		secret("app-v3", "dba_app_v3", nil),
+	)
+
+	db := &dba.ManagedDatabase{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "app", UID: "db-uid"},
+		Status: dba.ManagedDatabaseStatus{Consumers: []dba.SecretConsumers{
+			{Secret: "app-v1", Generations: []dba.CredentialGeneration{{Current: true, Pods: 2}}},
+			{Secret: "app-v2", Generations: []dba.CredentialGeneration{{Current: true, Pods: 1}, {Pods: 1}}},
+		}},
+	}
+
+	admin := &rotationAdmin{passwords: map[string]string{}, retained: map[string]string{}}
+	if err := discardRetainedPasswords(context.Background(), logf.NullLogger{}, apiClient, admin, db); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(admin.discarded, ",") != "dba_app_v1" {
+		t.Errorf("expected only the password no pod uses to be discarded, discarded %v", admin.discarded)
+	}
+
+	for name, annotated := range map[string]bool{"app-v1": false, "app-v2": true} {
+		var stored corev1.Secret
+		if err := apiClient.Get(context.Background(), types.NamespacedName{Namespace: "apps", Name: name}, &stored); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := stored.Annotations[OldPasswordRetainedAnnotation]; ok != annotated {
+			t.Errorf("expected secret %s to have the retained annotation to be %t", name, annotated)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/schedule"
+)
+
+// scheduledRotationInterval is how often the rotation schedules are checked
+const scheduledRotationInterval = time.Minute
+
+// rotationJitter returns the delay which is added to every scheduled rotation
+// of the database, which is always the same for a given database
+func rotationJitter(db *dba.ManagedDatabase, jitter time.Duration) time.Duration {
+	if jitter < time.Second {
+		return 0
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(db.Namespace + "/" + db.Name))
+	return time.Duration(int64(hash.Sum32())%int64(jitter/time.Second)) * time.Second
+}
+
+// nextScheduledRotation returns the first scheduled rotation of the database
+// after the time given
+func nextScheduledRotation(db *dba.ManagedDatabase, after time.Time) (time.Time, error) {
+	cron, err := schedule.ParseCron(db.Spec.Rotation.Schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid rotation schedule: %w", err)
+	}
+
+	// Shifting back by the delay first keeps a delayed rotation from
+	// counting as the scheduled time it was delayed from
+	delay := rotationJitter(db, db.Spec.Rotation.Jitter.Duration)
+	next := cron.Next(after.Add(-delay))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("Rotation schedule %s never matches", db.Spec.Rotation.Schedule)
+	}
+	return next.Add(delay), nil
+}
+
+func (frc *FleetRotationController) runSchedules(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(scheduledRotationInterval):
+			if err := frc.rotateScheduled(time.Now()); err != nil {
+				frc.Log.Error(err, "Scheduled credential rotations did not complete")
+			}
+		}
+	}
+}
+
+// rotateScheduled rotates every database whose scheduled rotation is due, at
+// most the configured number per minute, and reports when each is rotated
+// next
+func (frc *FleetRotationController) rotateScheduled(now time.Time) error {
+	var ctx = context.Background()
+
+	var allDatabases dba.ManagedDatabaseList
+	if err := frc.List(ctx, &allDatabases); err != nil {
+		return fmt.Errorf("Unable to list ManagedDatabases: %w", err)
+	}
+
+	budget := frc.config.Current().Rotation.RotationsPerMinute
+	if budget < 1 {
+		budget = 1
+	}
+
+	// Start from scratch so that deleted databases stop being reported
+	frc.metrics.NextRotation.Reset()
+
+	for i := range allDatabases.Items {
+		db := &allDatabases.Items[i]
+		log := phaseLogger(frc.Log.WithValues(
+			"manageddatabase", types.NamespacedName{Namespace: db.Namespace, Name: db.Name},
+			"engine", db.Spec.Connection.Engine,
+		), phaseRotation)
+
+		if err := applyManagedDatabaseClass(ctx, frc.Client, db); err != nil {
+			log.Error(err, "unable to apply ManagedDatabaseClass")
+			continue
+		}
+		if db.Spec.Rotation == nil || db.Spec.Rotation.Schedule == "" || db.Spec.Rotation.Disabled {
+			continue
+		}
+
+		status := db.Status.Rotation
+		if status == nil {
+			status = &dba.RotationStatus{}
+		}
+		previous := status.DeepCopy()
+
+		last := db.CreationTimestamp.Time
+		if status.LastRotatedAt != nil {
+			last = status.LastRotatedAt.Time
+		}
+		next, err := nextScheduledRotation(db, last)
+		if err != nil {
+			log.Error(err, "unable to schedule rotation")
+			continue
+		}
+
+		reason := pauseReason(frc.config.Current(), db)
+		switch {
+		case now.Before(next):
+		case reason != "":
+			log.Info("Skipping scheduled rotation", "reason", reason)
+		case budget == 0:
+			log.Info("Delaying scheduled rotation, too many are due at once")
+		default:
+			name := "rotation " + db.Namespace + "/" + db.Name
+			if !drainer.Begin(name) {
+				log.Info("Skipping scheduled rotation, the operator is shutting down")
+				return nil
+			}
+			budget--
+			err := frc.rotateDatabase(ctx, log, db)
+			drainer.Done(name)
+			if err != nil {
+				log.Error(err, "unable to rotate credentials")
+				frc.metrics.RotationFailures.Inc()
+				break
+			}
+
+			rotatedAt := metav1.NewTime(now)
+			status.LastRotatedAt = &rotatedAt
+			next, err = nextScheduledRotation(db, now)
+		}
+
+		if err != nil {
+			log.Error(err, "unable to schedule rotation")
+			status.NextRotationAt = nil
+		} else {
+			nextAt := metav1.NewTime(next)
+			status.NextRotationAt = &nextAt
+			frc.metrics.NextRotation.With(prometheus.Labels{"namespace": db.Namespace, "database": db.Name}).Set(float64(next.Unix()))
+		}
+
+		if previous.LastRotatedAt.Equal(status.LastRotatedAt) && previous.NextRotationAt.Equal(status.NextRotationAt) {
+			continue
+		}
+		db.Status.Rotation = status
+		if err := frc.Status().Update(ctx, db); err != nil {
+			log.Error(err, "unable to record rotation schedule")
+		}
+	}
+
+	return nil
+}
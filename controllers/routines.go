@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+)
+
+// reconcileHelperRoutines creates the helper routines requested by the
+// database, and records the ones whose definitions have drifted. Drift is
+// announced once when first seen, rather than on every reconcile.
+func (c *ManagedDatabaseController) reconcileHelperRoutines(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase) error {
+	if db.Spec.HelperRoutines == nil {
+		db.Status.DriftedRoutines = nil
+		return nil
+	}
+
+	names := make([]string, 0, len(db.Spec.HelperRoutines.Names))
+	for _, name := range db.Spec.HelperRoutines.Names {
+		names = append(names, string(name))
+	}
+
+	repair := db.Spec.HelperRoutines.RepairDrift
+	drifted, err := admin.EnsureHelperRoutines(names, repair)
+	if err != nil {
+		return fmt.Errorf("Unable to create helper routines: %w", err)
+	}
+
+	if repair {
+		if len(drifted) > 0 {
+			log.Info("Repaired drifted helper routines", "routines", drifted)
+		}
+		db.Status.DriftedRoutines = nil
+		return nil
+	}
+
+	if len(drifted) > 0 && strings.Join(drifted, ",") != strings.Join(db.Status.DriftedRoutines, ",") {
+		log.Info("Helper routine definitions have drifted", "routines", drifted)
+		c.notifier.Notify(notify.Event{
+			Reason:    "RoutineDrifted",
+			Namespace: db.Namespace,
+			Name:      db.Name,
+			Message:   fmt.Sprintf("Helper routines no longer match their shipped definitions: %s", strings.Join(drifted, ", ")),
+		})
+	}
+	db.Status.DriftedRoutines = drifted
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/notify"
+)
+
+func scheduledStatement(statement dba.ScheduledStatement) dbadmin.ScheduledStatement {
+	return dbadmin.ScheduledStatement{
+		Name:      statement.Name,
+		Interval:  statement.Every.Duration,
+		Statement: statement.Statement,
+		Disabled:  statement.Disabled,
+	}
+}
+
+func scheduledStatementSpecHash(statement dba.ScheduledStatement) (string, error) {
+	encoded, err := json.Marshal(statement)
+	if err != nil {
+		return "", fmt.Errorf("Unable to encode scheduled statement (%s): %w", statement.Name, err)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// reconcileScheduledStatements writes every scheduled statement whose spec
+// has changed or whose stored definition no longer matches the one recorded
+// when it was written, and drops the ones which were removed from the spec.
+func (c *ManagedDatabaseController) reconcileScheduledStatements(log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase) error {
+	recorded := make(map[string]dba.ScheduledStatementStatus, len(db.Status.ScheduledStatements))
+	for _, statement := range db.Status.ScheduledStatements {
+		recorded[statement.Name] = statement
+	}
+
+	written := make([]dba.ScheduledStatementStatus, 0, len(db.Spec.ScheduledStatements))
+	for _, statement := range db.Spec.ScheduledStatements {
+		specHash, err := scheduledStatementSpecHash(statement)
+		if err != nil {
+			return err
+		}
+		checksum, err := admin.GetScheduledStatementChecksum(statement.Name)
+		if err != nil {
+			return err
+		}
+
+		previous, known := recorded[statement.Name]
+		delete(recorded, statement.Name)
+		if known && previous.SpecHash == specHash && previous.Checksum == checksum {
+			written = append(written, previous)
+			continue
+		}
+
+		if known && previous.SpecHash == specHash {
+			log.Info("Scheduled statement has drifted from its spec", "statement", statement.Name)
+			c.notifier.Notify(notify.Event{
+				Reason:    "ScheduledStatementDrifted",
+				Namespace: db.Namespace,
+				Name:      db.Name,
+				Message:   fmt.Sprintf("Scheduled statement %s was changed outside of the operator and is being restored", statement.Name),
+			})
+		}
+
+		if err := scheduledStatementRules.Lint(statement.Statement); err != nil {
+			return fmt.Errorf("Scheduled statement %s is invalid: %w", statement.Name, err)
+		}
+		log.Info("Writing scheduled statement", "statement", statement.Name, "every", statement.Every.Duration)
+		if err := admin.WriteScheduledStatement(scheduledStatement(statement)); err != nil {
+			return err
+		}
+		if checksum, err = admin.GetScheduledStatementChecksum(statement.Name); err != nil {
+			return err
+		}
+		written = append(written, dba.ScheduledStatementStatus{Name: statement.Name, SpecHash: specHash, Checksum: checksum})
+	}
+
+	for _, removed := range db.Status.ScheduledStatements {
+		if _, ok := recorded[removed.Name]; !ok {
+			continue
+		}
+		log.Info("Dropping scheduled statement which was removed from the spec", "statement", removed.Name)
+		if err := admin.DropScheduledStatement(removed.Name); err != nil {
+			return err
+		}
+	}
+
+	db.Status.ScheduledStatements = written
+	return nil
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"fmt"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/mysqladmin"
+)
+
+// dsnParsers contains the DSN parser for each engine whose hosts can be
+// restricted by the operator's scope
+var dsnParsers = map[string]dbadmin.DSNParser{
+	"mysql": mysqladmin.DSNParser{},
+}
+
+// scopeProvider supplies the scope of the operator, every namespace and host
+// is in scope until RestrictScope is called
+var scopeProvider config.Provider
+
+// RestrictScope makes every connection opened by the controllers check the
+// current scope of the operator config first. It must be called before the
+// controllers are started.
+func RestrictScope(provider config.Provider) {
+	scopeProvider = provider
+}
+
+// outOfScopeError is returned for connections to databases which the
+// operator must not act on
+type outOfScopeError struct {
+	reason string
+}
+
+func (oose *outOfScopeError) Error() string {
+	return fmt.Sprintf("Refusing to connect outside of the operator's scope: %s", oose.reason)
+}
+
+func currentScope() config.Scope {
+	if scopeProvider == nil {
+		return config.Scope{}
+	}
+	return scopeProvider.Current().Scope
+}
+
+// checkNamespaceScope refuses the databases of namespaces outside of the
+// operator's scope
+func checkNamespaceScope(namespace string) error {
+	if !currentScope().NamespaceAllowed(namespace) {
+		return &outOfScopeError{fmt.Sprintf("namespace %s is not allowed", namespace)}
+	}
+	return nil
+}
+
+// checkHostScope refuses hosts outside of the operator's scope
+func checkHostScope(host string) error {
+	if !currentScope().HostAllowed(host) {
+		return &outOfScopeError{fmt.Sprintf("host %s is not allowed", host)}
+	}
+	return nil
+}
+
+// checkCandidateScope refuses typed connection specs with several hosts
+// unless all of them are in scope, before any of them is health checked
+func checkCandidateScope(spec *dba.ConnectionSpec) error {
+	for _, candidate := range spec.Hosts {
+		if err := checkHostScope(candidate.Host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDSNScope refuses DSNs whose host is outside of the operator's scope.
+// The host of an engine without a DSN parser can't be checked, so its DSNs
+// are refused whenever the hosts are restricted.
+func checkDSNScope(engine, dsn string) error {
+	if len(currentScope().Hosts) == 0 {
+		return nil
+	}
+
+	parser, ok := dsnParsers[engine]
+	if !ok {
+		return &outOfScopeError{fmt.Sprintf("the host of %s DSNs can't be checked", engine)}
+	}
+	host, err := parser.DSNHost(dsn)
+	if err != nil {
+		return err
+	}
+	return checkHostScope(host)
+}
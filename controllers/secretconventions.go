@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/secretconvention"
+)
+
+// +kubebuilder:webhook:path=/validate-secret-convention-dbaoperator-app-sre-redhat-com-v1alpha1-manageddatabase,mutating=false,failurePolicy=ignore,groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases,verbs=create;update,versions=v1alpha1,name=secretconventions.dbaoperator.app-sre.redhat.com
+
+// SecretConventionValidator is a validating webhook which rejects
+// ManagedDatabases whose secret convention doesn't parse, or differs from the
+// one their Secrets were generated with
+type SecretConventionValidator struct {
+	client  client.Client
+	log     logr.Logger
+	decoder *admission.Decoder
+}
+
+// NewSecretConventionValidator will instantiate a SecretConventionValidator
+// with the supplied arguments
+func NewSecretConventionValidator(c client.Client, l logr.Logger) *SecretConventionValidator {
+	return &SecretConventionValidator{client: c, log: l}
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (scv *SecretConventionValidator) InjectDecoder(d *admission.Decoder) error {
+	scv.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler
+func (scv *SecretConventionValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var db dba.ManagedDatabase
+	if err := scv.decoder.Decode(req, &db); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if db.Namespace == "" {
+		db.Namespace = req.Namespace
+	}
+	if err := applyManagedDatabaseClass(ctx, scv.client, &db); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	// The status the convention is fixed in is the one already stored
+	if len(req.OldObject.Raw) > 0 {
+		var old dba.ManagedDatabase
+		if err := scv.decoder.DecodeRaw(req.OldObject, &old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		db.Status.SecretConvention = old.Status.SecretConvention
+	}
+
+	if err := checkSecretConvention(&db); err != nil {
+		scv.log.Info("Rejecting ManagedDatabase with invalid secret convention", "namespace", req.Namespace, "manageddatabase", db.Name, "error", err.Error())
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("Secret convention is valid")
+}
+
+// reservedSecretLabels are the labels the operator selects its Secrets by,
+// which a convention can't set
+var reservedSecretLabels = []string{
+	"migration", "migration-uid", "database", "database-uid",
+	"credential-request", "credential-request-uid",
+	replicaDatabaseUIDLabel, replicaEndpointLabel,
+}
+
+// checkSecretConvention returns an error if the convention of the spec
+// doesn't parse, or differs from the one fixed in the status block
+func checkSecretConvention(db *dba.ManagedDatabase) error {
+	wanted := toConvention(db.Spec.SecretConvention)
+	if err := wanted.Validate(); err != nil {
+		return fmt.Errorf("Secret convention is invalid: %w", err)
+	}
+	for _, reserved := range reservedSecretLabels {
+		if _, ok := wanted.Labels[reserved]; ok {
+			return fmt.Errorf("Secret convention can't set the %s label, which the operator selects Secrets by", reserved)
+		}
+	}
+	if db.Status.SecretConvention != nil && !wanted.Equal(toConvention(db.Status.SecretConvention)) {
+		return fmt.Errorf("Secret convention can't be changed once Secrets were generated with it, it must be the one in the status block")
+	}
+	return nil
+}
+
+// pinSecretConvention fixes the convention of the spec in the status block,
+// before any Secret is generated with it. Secrets which were generated before
+// the database had a convention are named with the default name template.
+func (c *ManagedDatabaseController) pinSecretConvention(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, secrets []corev1.Secret) error {
+	if err := checkSecretConvention(db); err != nil {
+		return err
+	}
+	if db.Status.SecretConvention != nil {
+		return nil
+	}
+
+	pinned := &dba.SecretConvention{NameTemplate: secretconvention.DefaultNameTemplate}
+	if db.Spec.SecretConvention != nil {
+		db.Spec.SecretConvention.DeepCopyInto(pinned)
+		if pinned.NameTemplate == "" {
+			pinned.NameTemplate = secretconvention.DefaultNameTemplate
+		}
+	}
+	if len(secrets) > 0 && pinned.NameTemplate != secretconvention.DefaultNameTemplate {
+		return fmt.Errorf("Secret name template can't be applied, the database already has Secrets named with the default template")
+	}
+
+	log.Info("Fixing secret convention", "nameTemplate", pinned.NameTemplate, "numLabels", len(pinned.Labels), "numAnnotations", len(pinned.Annotations))
+	db.Status.SecretConvention = pinned
+	if err := c.Status().Update(ctx, db); err != nil {
+		db.Status.SecretConvention = nil
+		return fmt.Errorf("Unable to update ManagedDatabase status block: %w", err)
+	}
+	return nil
+}
+
+// conformSecrets adds the labels and annotations of the convention which the
+// credentials Secrets are missing, such as those generated before it was set
+func (c *ManagedDatabaseController) conformSecrets(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, secrets []corev1.Secret) error {
+	for i := range secrets {
+		secret := &secrets[i]
+		labels, annotations, err := secretMetadata(db, secret.Namespace, secretconvention.KindCredentials, secret.Labels["migration"], secret.Labels)
+		if err != nil {
+			return err
+		}
+
+		missing := len(labels) != len(secret.Labels)
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		for key, value := range annotations {
+			if _, ok := secret.Annotations[key]; !ok {
+				secret.Annotations[key] = value
+				missing = true
+			}
+		}
+		if !missing {
+			continue
+		}
+		secret.Labels = labels
+
+		log.Info("Adding secret convention metadata to secret", "secret", secret.Name)
+		if err := c.Update(ctx, secret); err != nil {
+			return fmt.Errorf("Unable to update secret (%s) with secret convention: %w", secret.Name, err)
+		}
+	}
+	return nil
+}
+
+// databaseConvention returns the convention which the Secrets of the database
+// are generated with
+func databaseConvention(db *dba.ManagedDatabase) secretconvention.Convention {
+	if db.Status.SecretConvention != nil {
+		return toConvention(db.Status.SecretConvention)
+	}
+	return toConvention(db.Spec.SecretConvention)
+}
+
+// credentialsSecretName returns the name of the credentials Secret of the
+// schema version
+func credentialsSecretName(db *dba.ManagedDatabase, version string) (string, error) {
+	name, err := databaseConvention(db).Name(conventionTarget(db, db.Namespace, secretconvention.KindCredentials, version))
+	if err != nil {
+		return "", fmt.Errorf("Unable to name credentials secret of version (%s): %w", version, err)
+	}
+	return name, nil
+}
+
+// secretMetadata renders the labels and annotations of a Secret generated for
+// the database. The labels the operator sets itself take precedence, and are
+// included in the returned labels.
+func secretMetadata(db *dba.ManagedDatabase, namespace, kind, version string, own map[string]string) (map[string]string, map[string]string, error) {
+	labels, annotations, err := databaseConvention(db).Metadata(conventionTarget(db, namespace, kind, version))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to render secret convention of %s secret: %w", kind, err)
+	}
+	for key, value := range own {
+		labels[key] = value
+	}
+	return labels, annotations, nil
+}
+
+func conventionTarget(db *dba.ManagedDatabase, namespace, kind, version string) secretconvention.Target {
+	labels := db.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return secretconvention.Target{
+		Namespace: namespace,
+		Database:  db.Name,
+		Labels:    labels,
+		Version:   version,
+		Kind:      kind,
+	}
+}
+
+func toConvention(spec *dba.SecretConvention) secretconvention.Convention {
+	if spec == nil {
+		return secretconvention.Convention{}
+	}
+	return secretconvention.Convention{
+		NameTemplate: spec.NameTemplate,
+		Labels:       spec.Labels,
+		Annotations:  spec.Annotations,
+	}
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+func TestCheckSecretConvention(t *testing.T) {
+	pinned := &dba.SecretConvention{
+		NameTemplate: "{{.Database}}-{{.Version}}",
+		Labels:       map[string]string{"team": "{{.Labels.team}}"},
+	}
+
+	for _, tc := range []struct {
+		name   string
+		spec   *dba.SecretConvention
+		status *dba.SecretConvention
+		valid  bool
+	}{
+		{"no convention", nil, nil, true},
+		{"not yet fixed", &dba.SecretConvention{NameTemplate: "{{.Database}}-{{.Version}}-creds"}, nil, true},
+		{"unchanged", pinned.DeepCopy(), pinned, true},
+		{"default name template", &dba.SecretConvention{Labels: pinned.Labels}, pinned, true},
+		{"changed name template", &dba.SecretConvention{NameTemplate: "{{.Database}}-{{.Version}}-creds", Labels: pinned.Labels}, pinned, false},
+		{"changed label", &dba.SecretConvention{Labels: map[string]string{"team": "quay"}}, pinned, false},
+		{"removed", nil, pinned, false},
+		{"unparseable", &dba.SecretConvention{NameTemplate: "{{.Database"}, nil, false},
+		{"reserved label", &dba.SecretConvention{Labels: map[string]string{"database-uid": "x"}}, nil, false},
+	} {
+		db := &dba.ManagedDatabase{
+			ObjectMeta: metav1.ObjectMeta{Name: "quayio", Namespace: "quay"},
+			Spec:       dba.ManagedDatabaseSpec{SecretConvention: tc.spec},
+			Status:     dba.ManagedDatabaseStatus{SecretConvention: tc.status},
+		}
+		err := checkSecretConvention(db)
+		if tc.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
+
+func TestSecretMetadata(t *testing.T) {
+	db := &dba.ManagedDatabase{
+		ObjectMeta: metav1.ObjectMeta{Name: "quayio", Namespace: "quay", Labels: map[string]string{"team": "quay"}},
+		Status: dba.ManagedDatabaseStatus{SecretConvention: &dba.SecretConvention{
+			NameTemplate: "{{.Database}}-{{.Version}}-creds",
+			Labels:       map[string]string{"team": "{{.Labels.team}}", "kind": "{{.Kind}}"},
+			Annotations:  map[string]string{"example.com/owner": "{{.Namespace}}"},
+		}},
+	}
+
+	name, err := credentialsSecretName(db, "v3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "quayio-v3-creds" {
+		t.Errorf("expected quayio-v3-creds, got %s", name)
+	}
+
+	labels, annotations, err := secretMetadata(db, "apps", "replica", "", map[string]string{"kind": "own", replicaEndpointLabel: "reporting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels["team"] != "quay" || labels["kind"] != "own" || labels[replicaEndpointLabel] != "reporting" || len(labels) != 3 {
+		t.Errorf("unexpected labels %v", labels)
+	}
+	if annotations["example.com/owner"] != "apps" {
+		t.Errorf("unexpected annotations %v", annotations)
+	}
+
+	delete(db.Labels, "team")
+	if _, _, err := secretMetadata(db, "quay", "credentials", "v3", nil); err == nil {
+		t.Error("expected an error for a missing database label")
+	}
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/secretformat"
+)
+
+// secretFormatData returns the keys which the secret format of the database
+// adds to a credentials Secret, or nothing when it has none
+func secretFormatData(db *dba.ManagedDatabase, username, password string) (map[string]string, error) {
+	if db.Spec.SecretFormat == "" {
+		return nil, nil
+	}
+
+	conn := db.Spec.Connection.Spec
+	if conn == nil || conn.Host == "" {
+		return nil, fmt.Errorf("Secret format %s requires a connection spec with a single host", db.Spec.SecretFormat)
+	}
+	return secretformat.Render(db.Spec.SecretFormat, secretformat.Connection{
+		Host:     conn.Host,
+		Port:     int(conn.Port),
+		Database: conn.Database,
+		Username: username,
+		Password: password,
+		TLS:      conn.TLS,
+	})
+}
+
+// applySecretFormat replaces the keys of any secret format in the data with
+// those of the database's format, and returns whether the data changed.
+// Secrets without a password, such as those of workload identities, are
+// left alone.
+func applySecretFormat(db *dba.ManagedDatabase, data map[string][]byte) (bool, error) {
+	username, password := string(data["username"]), string(data["password"])
+	if username == "" || password == "" {
+		return false, nil
+	}
+
+	formatted, err := secretFormatData(db, username, password)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, key := range secretformat.Keys() {
+		if _, ok := formatted[key]; !ok {
+			if _, present := data[key]; present {
+				delete(data, key)
+				changed = true
+			}
+		}
+	}
+	for key, value := range formatted {
+		if string(data[key]) != value {
+			data[key] = []byte(value)
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// reformatSecrets brings the existing credentials Secrets in line with the
+// database's secret format, when it has changed since they were written
+func (c *ManagedDatabaseController) reformatSecrets(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, secrets []corev1.Secret) error {
+	for i := range secrets {
+		secret := &secrets[i]
+		changed, err := applySecretFormat(db, secret.Data)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		if previous, ok := secret.Annotations[CredentialsChecksumAnnotation]; ok {
+			secret.Annotations[PreviousChecksumAnnotation] = previous
+		}
+		secret.Annotations[CredentialsChecksumAnnotation] = secretChecksum(secret.Data)
+
+		log.Info("Rewriting secret in new format", "secret", secret.Name, "format", db.Spec.SecretFormat)
+		if err := c.Update(ctx, secret); err != nil {
+			return fmt.Errorf("Unable to update secret (%s) with secret format: %w", secret.Name, err)
+		}
+	}
+	return nil
+}
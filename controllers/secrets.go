@@ -2,11 +2,15 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
 
 	dba "github.com/app-sre/dba-operator/api/v1alpha1"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -14,27 +18,60 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// CredentialsChecksumAnnotation is written to every secret the operator
+// issues, and changes whenever the contents of the secret change
+const CredentialsChecksumAnnotation = "dbaoperator.app-sre.redhat.com/credentials-checksum"
+
+// PreviousChecksumAnnotation contains the checksum of the credentials which
+// were replaced by the last rotation
+const PreviousChecksumAnnotation = "dbaoperator.app-sre.redhat.com/previous-checksum"
+
+// secretChecksum hashes the contents of a secret, so that the generation of
+// its credentials can be told apart without revealing them
+func secretChecksum(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s=%d:%s\n", key, len(data[key]), data[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
 func secretUsedInPod(secretName string, pods *corev1.PodList) *corev1.Pod {
-	for _, pod := range pods.Items {
-		for _, volume := range pod.Spec.Volumes {
-			if volume.Secret != nil && volume.Secret.SecretName == secretName {
-				return &pod
-			}
+	for i := range pods.Items {
+		if podUsesSecret(&pods.Items[i], secretName) {
+			return &pods.Items[i]
 		}
-		for _, container := range pod.Spec.Containers {
-			for _, source := range container.EnvFrom {
-				if source.SecretRef != nil && source.SecretRef.Name == secretName {
-					return &pod
-				}
+	}
+	return nil
+}
+
+// podUsesSecret returns true if the pod mounts the secret or reads any of
+// its keys into the environment
+func podUsesSecret(pod *corev1.Pod, secretName string) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == secretName {
+			return true
+		}
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, source := range container.EnvFrom {
+			if source.SecretRef != nil && source.SecretRef.Name == secretName {
+				return true
 			}
-			for _, envVar := range container.Env {
-				if envVar.ValueFrom != nil && envVar.ValueFrom.SecretKeyRef != nil && envVar.ValueFrom.SecretKeyRef.Name == secretName {
-					return &pod
-				}
+		}
+		for _, envVar := range container.Env {
+			if envVar.ValueFrom != nil && envVar.ValueFrom.SecretKeyRef != nil && envVar.ValueFrom.SecretKeyRef.Name == secretName {
+				return true
 			}
 		}
 	}
-	return nil
+	return false
 }
 
 func deleteSecretIfUnused(ctx context.Context, log logr.Logger, apiClient client.Client, namespace, secretName string) error {
@@ -74,26 +111,110 @@ func writeCredentialsSecret(
 	secretName string,
 	username string,
 	password string,
+	formatted map[string]string,
 	labels map[string]string,
+	annotations map[string]string,
 	owner metav1.Object,
 	scheme *runtime.Scheme,
 ) error {
+	data := map[string]string{
+		"username": username,
+		"password": password,
+	}
+	for key, value := range formatted {
+		data[key] = value
+	}
+	return writeSecret(ctx, apiClient, namespace, secretName, data, labels, annotations, owner, scheme)
+}
+
+// checkpointedPassword returns the password of the user from the secret which
+// was written for it before the user was created
+func checkpointedPassword(secrets []corev1.Secret, secretName, username string) (string, bool) {
+	for _, secret := range secrets {
+		if secret.Name == secretName && string(secret.Data["username"]) == username && len(secret.Data["password"]) > 0 {
+			return string(secret.Data["password"]), true
+		}
+	}
+	return "", false
+}
+
+// writeSecret creates a secret owned by the owner with the supplied data and
+// metadata, the checksum annotation is always set by it
+func writeSecret(
+	ctx context.Context,
+	apiClient client.Client,
+	namespace string,
+	secretName string,
+	data map[string]string,
+	labels map[string]string,
+	annotations map[string]string,
+	owner metav1.Object,
+	scheme *runtime.Scheme,
+) error {
+	encoded := make(map[string][]byte, len(data))
+	for key, value := range data {
+		encoded[key] = []byte(value)
+	}
+
+	secretAnnotations := make(map[string]string, len(annotations)+1)
+	for key, value := range annotations {
+		secretAnnotations[key] = value
+	}
+	secretAnnotations[CredentialsChecksumAnnotation] = secretChecksum(encoded)
+
+	newSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      labels,
+			Annotations: secretAnnotations,
+			Name:        secretName,
+			Namespace:   namespace,
+		},
+		StringData: data,
+	}
+
+	ctrl.SetControllerReference(owner, &newSecret, scheme)
+
+	return apiClient.Create(ctx, &newSecret)
+}
+
+// writeDSNSecret creates or updates a secret containing only a connection
+// DSN, under the same key that user supplied DSN secrets use. The metadata is
+// only written when the secret is created.
+func writeDSNSecret(
+	ctx context.Context,
+	apiClient client.Client,
+	namespace string,
+	secretName string,
+	dsn string,
+	labels map[string]string,
+	annotations map[string]string,
+	owner metav1.Object,
+	scheme *runtime.Scheme,
+) error {
+	var existing corev1.Secret
+	err := apiClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: secretName}, &existing)
+	if err == nil {
+		if string(existing.Data["dsn"]) == dsn {
+			return nil
+		}
+		existing.Data = map[string][]byte{"dsn": []byte(dsn)}
+		return apiClient.Update(ctx, &existing)
+	} else if !apierrs.IsNotFound(err) {
+		return err
+	}
 
 	newSecret := corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels:      labels,
-			Annotations: make(map[string]string),
+			Annotations: annotations,
 			Name:        secretName,
 			Namespace:   namespace,
 		},
 		StringData: map[string]string{
-			"username": username,
-			"password": password,
+			"dsn": dsn,
 		},
 	}
 
-	// TODO figure out a policy for adding annotations and labels
-
 	ctrl.SetControllerReference(owner, &newSecret, scheme)
 
 	return apiClient.Create(ctx, &newSecret)
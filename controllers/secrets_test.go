@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckpointedPassword(t *testing.T) {
+	secrets := []corev1.Secret{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "quayio-v3"},
+			Data:       map[string][]byte{"username": []byte("dba_quay_v3"), "password": []byte("checkpointed")},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "quayio-v2"},
+			Data:       map[string][]byte{"username": []byte("dba_quay_v2")},
+		},
+	}
+
+	if password, ok := checkpointedPassword(secrets, "quayio-v3", "dba_quay_v3"); !ok || password != "checkpointed" {
+		t.Errorf("Expected the checkpointed password, got %q, %t", password, ok)
+	}
+	if _, ok := checkpointedPassword(secrets, "quayio-v3", "dba_quay_v3_1"); ok {
+		t.Error("Expected the secret of another user not to be used")
+	}
+	if _, ok := checkpointedPassword(secrets, "quayio-v2", "dba_quay_v2"); ok {
+		t.Error("Expected a secret without a password not to be used")
+	}
+	if _, ok := checkpointedPassword(secrets, "quayio-v4", "dba_quay_v4"); ok {
+		t.Error("Expected no password without a secret")
+	}
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/seeddata"
+)
+
+// Seed Jobs aren't labeled with the database-uid of migration Jobs, which
+// would have them cleaned up as the Jobs of old migrations
+const (
+	seedDatabaseUIDLabel = "seed-database-uid"
+	seedLabel            = "seed"
+)
+
+// seedDataRefreshInterval is how often the ConfigMaps of seed data are
+// checked for changes, which aren't watched
+const seedDataRefreshInterval = 10 * time.Minute
+
+// reconcileSeedData applies each seed data source whose migration has been
+// reached and whose content has changed since it was last applied. Seed
+// Jobs report back through the Jobs which the controller owns.
+func (c *ManagedDatabaseController) reconcileSeedData(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, currentDbVersion string) error {
+	if len(db.Spec.SeedData) == 0 {
+		db.Status.SeedData = nil
+		return nil
+	}
+
+	chain, err := appliedChain(ctx, c.Client, db.Namespace, currentDbVersion)
+	if err != nil {
+		return err
+	}
+	reached := make(map[string]bool, len(chain))
+	for _, migration := range chain {
+		reached[migration.Name] = true
+	}
+
+	recorded := make(map[string]dba.SeedDataStatus, len(db.Status.SeedData))
+	for _, seed := range db.Status.SeedData {
+		recorded[seed.Name] = seed
+	}
+
+	applied := make([]dba.SeedDataStatus, 0, len(db.Spec.SeedData))
+	for _, source := range db.Spec.SeedData {
+		previous, known := recorded[source.Name]
+		if source.AfterVersion != "" && !reached[source.AfterVersion] {
+			if known {
+				applied = append(applied, previous)
+			}
+			continue
+		}
+
+		seedLog := log.WithValues("seed", source.Name)
+		var status *dba.SeedDataStatus
+		switch {
+		case (source.ConfigMap == "") == (source.Container == nil):
+			return fmt.Errorf("Exactly one of configMap and container must be specified for seed data (%s)", source.Name)
+		case source.ConfigMap != "":
+			status, err = c.applySeedConfigMap(ctx, seedLog, admin, db, source, previous)
+		default:
+			status, err = c.applySeedContainer(ctx, seedLog, db, source, previous)
+		}
+		if err != nil {
+			return err
+		}
+
+		if status == nil {
+			// The seed Job is still running
+			if known {
+				applied = append(applied, previous)
+			}
+			continue
+		}
+		applied = append(applied, *status)
+	}
+
+	db.Status.SeedData = applied
+	return nil
+}
+
+// applySeedConfigMap applies the seed data in the ConfigMap, unless it was
+// already applied with the same content
+func (c *ManagedDatabaseController) applySeedConfigMap(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, source dba.SeedDataSource, previous dba.SeedDataStatus) (*dba.SeedDataStatus, error) {
+	var configMap corev1.ConfigMap
+	if err := c.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: source.ConfigMap}, &configMap); err != nil {
+		return nil, fmt.Errorf("Unable to fetch ConfigMap (%s) of seed data (%s): %w", source.ConfigMap, source.Name, err)
+	}
+
+	checksum := seeddata.Checksum(configMap.Data)
+	if previous.Checksum == checksum {
+		return &previous, nil
+	}
+
+	steps, err := seeddata.Parse(configMap.Data)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read seed data (%s): %w", source.Name, err)
+	}
+
+	log.Info("Applying seed data", "configMap", source.ConfigMap, "steps", len(steps), "checksum", checksum)
+	if err := admin.ApplySeedData(steps); err != nil {
+		return nil, fmt.Errorf("Unable to apply seed data (%s): %w", source.Name, err)
+	}
+	return &dba.SeedDataStatus{Name: source.Name, Checksum: checksum, AppliedAt: metav1.Now()}, nil
+}
+
+// applySeedContainer runs a Job for the container, unless it was already
+// applied with the same spec. It returns nil while the Job is running.
+func (c *ManagedDatabaseController) applySeedContainer(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, source dba.SeedDataSource, previous dba.SeedDataStatus) (*dba.SeedDataStatus, error) {
+	encoded, err := json.Marshal(source.Container)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to encode container of seed data (%s): %w", source.Name, err)
+	}
+	sum := sha256.Sum256(encoded)
+	checksum := hex.EncodeToString(sum[:])
+
+	name := fmt.Sprintf("%s-seed-%s-%s", db.Name, source.Name, checksum[:8])
+	if err := c.cleanupSeedJobs(ctx, log, db, source.Name, name); err != nil {
+		return nil, err
+	}
+	if previous.Checksum == checksum {
+		return &previous, nil
+	}
+
+	var job batchv1.Job
+	err = c.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: name}, &job)
+	if apierrs.IsNotFound(err) {
+		return nil, c.createSeedJob(ctx, log, db, source, name)
+	} else if err != nil {
+		return nil, fmt.Errorf("Unable to fetch seed data Job (%s): %w", name, err)
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return nil, fmt.Errorf("Seed data Job (%s) failed: %s", name, condition.Message)
+		}
+	}
+	if job.Status.Succeeded == 0 {
+		log.Info("Waiting for seed data Job", "job", name)
+		return nil, nil
+	}
+
+	log.Info("Seed data Job is complete", "job", name, "checksum", checksum)
+	appliedAt := metav1.Now()
+	if job.Status.CompletionTime != nil {
+		appliedAt = *job.Status.CompletionTime
+	}
+	return &dba.SeedDataStatus{Name: source.Name, Checksum: checksum, AppliedAt: appliedAt}, nil
+}
+
+func (c *ManagedDatabaseController) createSeedJob(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, source dba.SeedDataSource, name string) error {
+	dsnSecretName, err := c.migrationDSNSecret(migrationContext{ctx: ctx, log: log, db: db})
+	if err != nil {
+		return fmt.Errorf("Unable to provide connection DSN to seed data (%s): %w", source.Name, err)
+	}
+
+	var containerSpec corev1.Container
+	source.Container.DeepCopyInto(&containerSpec)
+
+	falseBool := false
+	csSource := &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: dsnSecretName},
+		Key:                  "dsn",
+		Optional:             &falseBool,
+	}}
+	containerSpec.Env = append(containerSpec.Env, corev1.EnvVar{Name: "DBA_OP_CONNECTION_STRING", ValueFrom: csSource})
+	containerSpec.Env = append(containerSpec.Env, corev1.EnvVar{Name: "DBA_OP_JOB_ID", Value: name})
+	containerSpec.Env = append(containerSpec.Env, corev1.EnvVar{Name: "DBA_OP_LABEL_DATABASE", Value: db.Name})
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"database":           db.Name,
+				seedDatabaseUIDLabel: string(db.UID),
+				seedLabel:            source.Name,
+			},
+			Name:      name,
+			Namespace: db.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers:    []corev1.Container{containerSpec},
+					RestartPolicy: corev1.RestartPolicyNever,
+				},
+			},
+		},
+	}
+	if err := ctrl.SetControllerReference(db, job, c.Scheme); err != nil {
+		return fmt.Errorf("Unable to set owner for new job (%s): %w", job.Name, err)
+	}
+
+	log.Info("Running seed data Job", "job", name)
+	if err := c.Create(ctx, job); err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("Unable to create Job (%s) for seed data: %w", name, err)
+	}
+	return nil
+}
+
+// cleanupSeedJobs deletes the Jobs which applied earlier versions of the
+// seed data, other than the one named keep. Jobs which are still running
+// are left to finish.
+func (c *ManagedDatabaseController) cleanupSeedJobs(ctx context.Context, log logr.Logger, db *dba.ManagedDatabase, seed, keep string) error {
+	selector := map[string]string{seedDatabaseUIDLabel: string(db.UID), seedLabel: seed}
+
+	var jobs batchv1.JobList
+	if err := c.List(ctx, &jobs, client.InNamespace(db.Namespace), client.MatchingLabels(selector)); err != nil {
+		return fmt.Errorf("Unable to list seed data Job(s): %w", err)
+	}
+
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if job.Name == keep || job.Status.Active > 0 {
+			continue
+		}
+		log.Info("Cleaning up job for old seed data", "job", job.Name)
+		if err := c.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("Unable to delete seed data Job (%s): %w", job.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// serviceRefreshInterval is how often the primary of a replicated database
+// is rediscovered, so that the Service follows failovers
+const serviceRefreshInterval = time.Minute
+
+// servicePortName names the only port of published Services
+const servicePortName = "db"
+
+// +kubebuilder:rbac:groups=,resources=services;endpoints,verbs=get;list;watch;create;update;delete
+
+func serviceName(db *dba.ManagedDatabase) string {
+	if db.Spec.Service.Name != "" {
+		return db.Spec.Service.Name
+	}
+	return db.Name + "-db"
+}
+
+// reconcileService points the published Service at the current primary. A
+// primary with a host name is published as an ExternalName Service, and one
+// with an IP as a headless Service with manually maintained Endpoints.
+func (c *ManagedDatabaseController) reconcileService(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase) error {
+	if db.Spec.Service == nil {
+		return nil
+	}
+
+	addr, err := admin.PrimaryAddress()
+	if err != nil {
+		return fmt.Errorf("Unable to determine primary address: %w", err)
+	}
+	if addr == "" {
+		return errors.New("Database connections without a network address can't be published as a Service")
+	}
+
+	host, portString, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("Unable to parse primary address (%s): %w", addr, err)
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil {
+		return fmt.Errorf("Unable to parse primary port (%s): %w", addr, err)
+	}
+
+	if db.Status.Primary != "" && db.Status.Primary != addr {
+		log.Info("Primary has moved", "previous", db.Status.Primary, "primary", addr)
+	}
+	db.Status.Primary = addr
+
+	ip := net.ParseIP(host)
+	desired := corev1.ServiceSpec{
+		Ports: []corev1.ServicePort{{Name: servicePortName, Port: int32(port)}},
+	}
+	if ip != nil {
+		desired.ClusterIP = corev1.ClusterIPNone
+	} else {
+		desired.Type = corev1.ServiceTypeExternalName
+		desired.ExternalName = host
+	}
+
+	name := types.NamespacedName{Namespace: db.Namespace, Name: serviceName(db)}
+	if err := c.writeService(ctx, name, desired, db); err != nil {
+		return fmt.Errorf("Unable to write Service (%s): %w", name.Name, err)
+	}
+
+	if ip != nil {
+		subsets := []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: ip.String()}},
+			Ports:     []corev1.EndpointPort{{Name: servicePortName, Port: int32(port)}},
+		}}
+		if err := c.writeEndpoints(ctx, name, subsets, db); err != nil {
+			return fmt.Errorf("Unable to write Endpoints (%s): %w", name.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *ManagedDatabaseController) writeService(ctx context.Context, name types.NamespacedName, desired corev1.ServiceSpec, db *dba.ManagedDatabase) error {
+	var existing corev1.Service
+	err := c.Get(ctx, name, &existing)
+	if err == nil {
+		externalName := existing.Spec.Type == corev1.ServiceTypeExternalName
+		if externalName == (desired.Type == corev1.ServiceTypeExternalName) {
+			if existing.Spec.ExternalName == desired.ExternalName && servicePortsMatch(existing.Spec.Ports, desired.Ports) {
+				return nil
+			}
+
+			existing.Spec.ExternalName = desired.ExternalName
+			existing.Spec.Ports = desired.Ports
+			return c.Update(ctx, &existing)
+		}
+
+		// The cluster IP can't be changed in place, so switching between
+		// kinds of Service requires a new one
+		if err := c.Delete(ctx, &existing); err != nil {
+			return err
+		}
+	} else if !apierrs.IsNotFound(err) {
+		return err
+	}
+
+	service := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+		Spec:       desired,
+	}
+	if err := ctrl.SetControllerReference(db, &service, c.Scheme); err != nil {
+		return err
+	}
+	return c.Create(ctx, &service)
+}
+
+func (c *ManagedDatabaseController) writeEndpoints(ctx context.Context, name types.NamespacedName, subsets []corev1.EndpointSubset, db *dba.ManagedDatabase) error {
+	var existing corev1.Endpoints
+	err := c.Get(ctx, name, &existing)
+	if err == nil {
+		if apiequality.Semantic.DeepEqual(existing.Subsets, subsets) {
+			return nil
+		}
+		existing.Subsets = subsets
+		return c.Update(ctx, &existing)
+	} else if !apierrs.IsNotFound(err) {
+		return err
+	}
+
+	endpoints := corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+		Subsets:    subsets,
+	}
+	if err := ctrl.SetControllerReference(db, &endpoints, c.Scheme); err != nil {
+		return err
+	}
+	return c.Create(ctx, &endpoints)
+}
+
+func servicePortsMatch(existing, desired []corev1.ServicePort) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+	for i := range existing {
+		if existing[i].Name != desired[i].Name || existing[i].Port != desired[i].Port {
+			return false
+		}
+	}
+	return true
+}
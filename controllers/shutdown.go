@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/app-sre/dba-operator/pkg/shutdown"
+)
+
+// drainer tracks the reconciles and passes which may change databases
+var drainer = shutdown.NewDrainer()
+
+// Drain stops the controllers from starting new reconciles and passes, and
+// waits up to timeout for the ones in flight to stop at their next
+// checkpoint, with their progress written to the status. It returns the ones
+// which are still in flight, and must be called before the manager is
+// stopped.
+func Drain(timeout time.Duration) []string {
+	return drainer.Drain(timeout)
+}
+
+// drained tracks every reconcile by the named controller, and skips them once
+// the operator is shutting down. The objects are reconciled again by the
+// operator which takes over.
+func drained(controller string, reconciler reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(req ctrl.Request) (ctrl.Result, error) {
+		name := controller + " " + req.NamespacedName.String()
+		if !drainer.Begin(name) {
+			return ctrl.Result{}, nil
+		}
+		defer drainer.Done(name)
+
+		return reconciler.Reconcile(req)
+	})
+}
+
+// shuttingDown returns true at a checkpoint once the operator is shutting
+// down, after which no more changes are started
+func shuttingDown(log logr.Logger) bool {
+	if drainer.Draining() {
+		log.Info("Stopping at checkpoint, the operator is shutting down")
+		return true
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/registry"
+)
+
+// ImageVerificationAnnotation is written to a migration's Job when it is
+// created, and holds the result of verifying the signature of its image
+const ImageVerificationAnnotation = "dbaoperator.app-sre.redhat.com/image-verification"
+
+// verifyMigrationImage verifies the signature of the image of a Job which is
+// about to be created, and pins the image to the digest which was verified.
+// Jobs whose image doesn't verify are refused when the policy requires
+// signatures, and otherwise launched with the failure recorded.
+func (c *ManagedDatabaseController) verifyMigrationImage(oneMigration migrationContext, job *batchv1.Job) error {
+	settings := c.config.Current().ImageSignatures
+	if settings.Policy == config.SignaturePolicyIgnore {
+		return nil
+	}
+
+	container := &job.Spec.Template.Spec.Containers[0]
+	verification := dba.ImageVerification{Image: container.Image, VerifiedAt: metav1.Now()}
+
+	pinned, signer, err := c.verifyImage(oneMigration, container.Image, settings)
+	if err != nil {
+		if settings.Policy == config.SignaturePolicyRequire {
+			return fmt.Errorf("Refusing to launch migration (%s) whose image didn't verify: %w", oneMigration.version.Name, err)
+		}
+		oneMigration.log.Info("Launching migration whose image didn't verify", "image", container.Image, "reason", err.Error())
+		verification.Message = err.Error()
+	} else {
+		oneMigration.log.Info("Verified migration image", "image", container.Image, "digest", pinned.Digest, "signer", signer)
+		verification.Verified, verification.Digest, verification.Signer = true, pinned.Digest, signer
+		container.Image = pinned.Image()
+	}
+
+	encoded, err := json.Marshal(verification)
+	if err != nil {
+		return fmt.Errorf("Unable to encode image verification: %w", err)
+	}
+	job.Annotations[ImageVerificationAnnotation] = string(encoded)
+	return nil
+}
+
+// verifyImage resolves the image to the digest of its manifest, and returns
+// the reference pinned to that digest along with the signer of the manifest
+func (c *ManagedDatabaseController) verifyImage(oneMigration migrationContext, image string, settings config.ImageSignatures) (registry.Reference, string, error) {
+	ref, err := registry.ParseImage(image)
+	if err != nil {
+		return registry.Reference{}, "", err
+	}
+	verifier, err := settings.Verifier()
+	if err != nil {
+		return registry.Reference{}, "", err
+	}
+	creds, err := c.registryCredentials(oneMigration, oneMigration.version.Spec.ImagePullSecret, ref.Registry)
+	if err != nil {
+		return registry.Reference{}, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(oneMigration.ctx, registryTimeout)
+	defer cancel()
+
+	session := registry.NewSession(registryClient, ref, creds)
+	_, digest, err := session.Manifest(ctx, ref.Target())
+	if err != nil {
+		return registry.Reference{}, "", fmt.Errorf("Unable to fetch manifest of image (%s): %w", image, err)
+	}
+	signer, err := verifier.Verify(ctx, session, digest)
+	if err != nil {
+		return registry.Reference{}, "", err
+	}
+	return ref.Pinned(digest), signer, nil
+}
+
+// recordImageVerification adds the result of verifying the image of a Job
+// to the database's migration history, once the Job has succeeded
+func recordImageVerification(oneMigration migrationContext, job *batchv1.Job) error {
+	encoded, ok := job.Annotations[ImageVerificationAnnotation]
+	if !ok {
+		return nil
+	}
+
+	applied, err := appliedMigrationEntry(oneMigration)
+	if err != nil {
+		return err
+	}
+	if applied.Image != nil {
+		return nil
+	}
+
+	var verification dba.ImageVerification
+	if err := json.Unmarshal([]byte(encoded), &verification); err != nil {
+		return fmt.Errorf("Unable to decode image verification of Job (%s): %w", job.Name, err)
+	}
+	applied.Image = &verification
+	return nil
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/silence"
+)
+
+// Operations which alerts are silenced for
+const (
+	silenceOperationMigration = "migration"
+	silenceOperationRotation  = "rotation"
+)
+
+// silenceMigration silences alerts about the database for the expected
+// duration of a migration Job which was just created. Failing to silence
+// doesn't hold up the migration.
+func (c *ManagedDatabaseController) silenceMigration(oneMigration migrationContext) {
+	silences := c.config.Current().Silences
+	if c.silencer == nil || !silences.Migrations {
+		return
+	}
+
+	duration := silences.MigrationDuration.Duration
+	if deadline := oneMigration.version.Spec.ActiveDeadline; deadline != nil && deadline.Duration > 0 {
+		duration = deadline.Duration
+	}
+
+	db := oneMigration.db
+	id, err := c.silencer.Silence(silence.Target{
+		Namespace: db.Namespace,
+		Database:  db.Name,
+		Operation: silenceOperationMigration,
+		Name:      oneMigration.version.Name,
+	}, duration)
+	if err != nil {
+		oneMigration.log.Error(err, "unable to silence alerts for migration")
+		return
+	}
+	if id == "" {
+		return
+	}
+
+	db.Status.MigrationSilence = &dba.AlertSilence{
+		ID:        id,
+		Migration: oneMigration.version.Name,
+		EndsAt:    metav1.NewTime(time.Now().Add(duration)),
+	}
+}
+
+// expireMigrationSilence ends the silence covering a migration which is no
+// longer running. A silence which can't be expired is kept in the status
+// block and retried by the next reconcile.
+func (c *ManagedDatabaseController) expireMigrationSilence(log logr.Logger, db *dba.ManagedDatabase) {
+	active := db.Status.MigrationSilence
+	if active == nil {
+		return
+	}
+
+	if c.silencer != nil && time.Now().Before(active.EndsAt.Time) {
+		if err := c.silencer.Expire(active.ID); err != nil {
+			log.Error(err, "unable to expire migration silence", "silence", active.ID)
+			return
+		}
+	}
+	db.Status.MigrationSilence = nil
+}
+
+// silenceRotation silences alerts about the database while its credentials
+// are rotated, and returns the ID of the silence if one was created
+func silenceRotation(log logr.Logger, silencer silence.Silencer, silences config.Silences, db *dba.ManagedDatabase) string {
+	if silencer == nil || !silences.Rotations {
+		return ""
+	}
+
+	id, err := silencer.Silence(silence.Target{
+		Namespace: db.Namespace,
+		Database:  db.Name,
+		Operation: silenceOperationRotation,
+	}, silences.RotationDuration.Duration)
+	if err != nil {
+		log.Error(err, "unable to silence alerts for rotation")
+	}
+	return id
+}
+
+// expireSilence ends a silence early, silences which can't be expired end on
+// their own
+func expireSilence(log logr.Logger, silencer silence.Silencer, id string) {
+	if err := silencer.Expire(id); err != nil {
+		log.Error(err, "unable to expire silence", "silence", id)
+	}
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// SchemaSnapshotKey is the ConfigMap key which holds the exported DDL
+const SchemaSnapshotKey = "schema.sql"
+
+// maxSnapshotSize leaves room for the rest of the ConfigMap below the 1MiB
+// object size limit
+const maxSnapshotSize = 1000 * 1000
+
+// reconcileSchemaSnapshot writes the DDL for the current schema version to a
+// ConfigMap, unless a snapshot of this version already exists. Snapshots are
+// never overwritten, so each one records the schema as it was first seen at
+// that version.
+func (c *ManagedDatabaseController) reconcileSchemaSnapshot(ctx context.Context, log logr.Logger, admin dbadmin.DbAdmin, db *dba.ManagedDatabase, version string) error {
+	name := schemaSnapshotName(db.Name, version)
+	db.Status.SchemaSnapshot = name
+
+	var existing corev1.ConfigMap
+	if err := c.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: name}, &existing); err == nil {
+		return nil
+	} else if !apierrs.IsNotFound(err) {
+		return fmt.Errorf("Unable to fetch schema snapshot (%s): %w", name, err)
+	}
+
+	log.Info("Exporting schema snapshot", "version", version)
+	ddl, err := admin.ExportSchema()
+	if err != nil {
+		return fmt.Errorf("Unable to export schema: %w", err)
+	}
+	if len(ddl) > maxSnapshotSize {
+		return fmt.Errorf("Schema snapshot is %d bytes, which is too large for a ConfigMap", len(ddl))
+	}
+
+	snapshot := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: db.Namespace,
+			Labels: map[string]string{
+				"schema-snapshot-for-database-uid": string(db.UID),
+				"schema-version":                   version,
+			},
+		},
+		Data: map[string]string{
+			SchemaSnapshotKey: fmt.Sprintf("-- Schema of ManagedDatabase %s at version %s\n\n%s", db.Name, version, ddl),
+		},
+	}
+
+	if err := ctrl.SetControllerReference(db, &snapshot, c.Scheme); err != nil {
+		return fmt.Errorf("Unable to set owner for schema snapshot (%s): %w", name, err)
+	}
+
+	if err := c.Create(ctx, &snapshot); err != nil {
+		return fmt.Errorf("Unable to create schema snapshot (%s): %w", name, err)
+	}
+	return nil
+}
+
+func schemaSnapshotName(dbName, version string) string {
+	return fmt.Sprintf("%s-schema-%s", dbName, version)
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+	"github.com/app-sre/dba-operator/pkg/seeddata"
+	"github.com/app-sre/dba-operator/pkg/sqllint"
+)
+
+// scheduledStatementRules are the statements which are allowed as the body
+// of a scheduled statement
+var scheduledStatementRules = sqllint.Rules{
+	Verbs:  []string{"DELETE", "UPDATE", "INSERT", "REPLACE", "SELECT", "CALL", "ALTER", "OPTIMIZE", "ANALYZE"},
+	Blocks: true,
+}
+
+// consistencyQueryRules are the statements which are allowed as the query of
+// a consistency check
+var consistencyQueryRules = sqllint.Rules{Verbs: []string{"SELECT", "WITH"}}
+
+// +kubebuilder:webhook:path=/lint-dbaoperator-app-sre-redhat-com-v1alpha1-manageddatabase,mutating=false,failurePolicy=ignore,groups=dbaoperator.app-sre.redhat.com,resources=manageddatabases,verbs=create;update,versions=v1alpha1,name=statements.dbaoperator.app-sre.redhat.com
+
+// StatementValidator is a validating webhook which rejects ManagedDatabases
+// whose inline SQL can't be run, because it doesn't lex or isn't a statement
+// which the field allows, instead of leaving it to fail on the primary. The
+// seed data in ConfigMaps which already exist is checked too.
+type StatementValidator struct {
+	client  client.Client
+	log     logr.Logger
+	decoder *admission.Decoder
+}
+
+// NewStatementValidator will instantiate a StatementValidator with the
+// supplied arguments
+func NewStatementValidator(c client.Client, l logr.Logger) *StatementValidator {
+	return &StatementValidator{client: c, log: l}
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (sv *StatementValidator) InjectDecoder(d *admission.Decoder) error {
+	sv.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler
+func (sv *StatementValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var db dba.ManagedDatabase
+	if err := sv.decoder.Decode(req, &db); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if db.Namespace == "" {
+		db.Namespace = req.Namespace
+	}
+	if err := applyManagedDatabaseClass(ctx, sv.client, &db); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	problems, err := lintStatements(ctx, sv.client, &db)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(problems) > 0 {
+		sv.log.Info("Rejecting ManagedDatabase with invalid statements", "namespace", db.Namespace, "manageddatabase", db.Name, "problems", len(problems))
+		return admission.Denied(strings.Join(problems, "; "))
+	}
+	return admission.Allowed("Statements are valid")
+}
+
+// lintStatements returns a description of each inline statement of the
+// database which is invalid
+func lintStatements(ctx context.Context, apiClient client.Client, db *dba.ManagedDatabase) ([]string, error) {
+	var problems []string
+	for _, statement := range db.Spec.ScheduledStatements {
+		if err := scheduledStatementRules.Lint(statement.Statement); err != nil {
+			problems = append(problems, fmt.Sprintf("Scheduled statement %s is invalid: %v", statement.Name, err))
+		}
+	}
+
+	for _, check := range db.Spec.ConsistencyChecks {
+		if err := consistencyQueryRules.Lint(check.Left.Query); err != nil {
+			problems = append(problems, fmt.Sprintf("Consistency check %s has an invalid left query: %v", check.Name, err))
+		}
+		if err := consistencyQueryRules.Lint(check.Right.Query); err != nil {
+			problems = append(problems, fmt.Sprintf("Consistency check %s has an invalid right query: %v", check.Name, err))
+		}
+	}
+
+	for _, source := range db.Spec.SeedData {
+		if source.ConfigMap == "" {
+			continue
+		}
+		var configMap corev1.ConfigMap
+		err := apiClient.Get(ctx, types.NamespacedName{Namespace: db.Namespace, Name: source.ConfigMap}, &configMap)
+		if apierrs.IsNotFound(err) {
+			// It may be created after the database, and is read when applied
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("Unable to fetch ConfigMap (%s) of seed data (%s): %w", source.ConfigMap, source.Name, err)
+		}
+		if _, err := seeddata.Parse(configMap.Data); err != nil {
+			problems = append(problems, fmt.Sprintf("Seed data %s is invalid: %v", source.Name, err))
+		}
+	}
+	return problems, nil
+}
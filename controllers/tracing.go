@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+)
+
+// traced records every reconcile by the named controller into diag in debug
+// mode, and returns reconciler unchanged otherwise
+func traced(diag *diagnostics.Recorder, controller string, reconciler reconcile.Func) reconcile.Reconciler {
+	if diag == nil {
+		return reconciler
+	}
+
+	return reconcile.Func(func(req ctrl.Request) (ctrl.Result, error) {
+		start := time.Now()
+		result, err := reconciler(req)
+
+		trace := diagnostics.Trace{
+			Time:         start.UTC(),
+			Controller:   controller,
+			Object:       req.NamespacedName.String(),
+			Duration:     time.Since(start),
+			RequeueAfter: result.RequeueAfter,
+		}
+		if err != nil {
+			trace.Error = err.Error()
+		}
+		diag.RecordTrace(trace)
+
+		return result, err
+	})
+}
@@ -16,19 +16,42 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"crypto/tls"
 	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/pprof"
 	"os"
+	"strings"
+	"time"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	// +kubebuilder:scaffold:imports
 
 	dbaoperatorv1alpha1 "github.com/app-sre/dba-operator/api/v1alpha1"
 	"github.com/app-sre/dba-operator/controllers"
+	"github.com/app-sre/dba-operator/pkg/adminapi"
+	"github.com/app-sre/dba-operator/pkg/approval"
+	"github.com/app-sre/dba-operator/pkg/cloudevents"
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/faults"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+	"github.com/app-sre/dba-operator/pkg/notify"
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/silence"
 )
 
 var (
@@ -36,6 +59,11 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
+const configPollInterval = 30 * time.Second
+
+// diagnosticsCapacity is the number of queries kept in the debug bundle
+const diagnosticsCapacity = 1000
+
 func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
@@ -48,12 +76,149 @@ func main() {
 
 	var metricsAddr string
 	var enableLeaderElection bool
+	var debug bool
+	var diagnosticsAddr string
+	var diagnosticsTokenFile string
+	var shutdownTimeout time.Duration
+	var deployGateAddr string
+	var cloudEventsAddr string
+	var cloudEventsTopics string
+	var adminAPIAddr string
+	var adminAPICallersFile string
+	var adminAPICertFile string
+	var adminAPIKeyFile string
+	var adminAPIInsecure bool
+	var chatOpsSigningSecretFile string
+	var chatOpsTeamID string
+	var configPath string
+	var environment string
+	var enableConsumerInjection bool
+	var enableQuotaAdmission bool
+	var enableStatementLinting bool
+	var enableSecretConventionValidation bool
+	var enableRequesterVerification bool
+	var injectFaults bool
+	var enableMonitoring bool
+	var monitoringSelector string
+	var allowedNamespaces string
+	var allowedHosts string
+	monitoringOptions := controllers.MonitoringOptions{Namespace: os.Getenv("POD_NAMESPACE")}
+	var faultOptions faults.Options
+	defaults := config.Default()
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&debug, "debug", false,
+		"Log the templates of all SQL statements sent to managed databases and record the timings and plans of read queries. Statement arguments are never logged.")
+	flag.StringVar(&diagnosticsAddr, "diagnostics-addr", "127.0.0.1:8082",
+		"The address the diagnostics endpoints are served on in debug mode.")
+	flag.StringVar(&diagnosticsTokenFile, "diagnostics-token-file", "",
+		"Path to a file containing the bearer token which the diagnostics endpoints require. Required unless the diagnostics address is loopback.")
+	flag.DurationVar(&shutdownTimeout, "shutdown-timeout", 25*time.Second,
+		"How long to wait on SIGTERM for in-flight reconciles to reach a checkpoint before exiting. Keep it below the pod's termination grace period.")
+	flag.StringVar(&deployGateAddr, "deploy-gate-addr", "",
+		"The address deploy pipelines can ask whether an app version is safe to roll out on. The endpoint is disabled when empty.")
+	flag.StringVar(&cloudEventsAddr, "cloud-events-addr", "",
+		"The address SNS subscriptions deliver RDS events on, which requeue the databases affected by failovers. The endpoint is disabled when empty.")
+	flag.StringVar(&cloudEventsTopics, "cloud-events-topics", "",
+		"Comma separated ARNs of the SNS topics whose events are accepted.")
+	flag.StringVar(&adminAPIAddr, "admin-api-addr", "",
+		"The address tooling outside of the cluster can list users, rotate credentials and scan for drift on. The API is disabled when empty.")
+	flag.StringVar(&adminAPICallersFile, "admin-api-callers-file", "",
+		"Path to a YAML file listing the tokens of the admin API's callers and what they may do. Required with --admin-api-addr.")
+	flag.StringVar(&adminAPICertFile, "admin-api-cert-file", "",
+		"Path to the PEM encoded certificate the admin API is served over TLS with. Required with --admin-api-addr, unless --admin-api-insecure is set.")
+	flag.StringVar(&adminAPIKeyFile, "admin-api-key-file", "",
+		"Path to the PEM encoded private key of --admin-api-cert-file.")
+	flag.BoolVar(&adminAPIInsecure, "admin-api-insecure", false,
+		"Serve the admin API over plain HTTP, which sends the callers' tokens in the clear. Only for an API behind a TLS terminating proxy on the same host.")
+	flag.StringVar(&chatOpsSigningSecretFile, "chatops-signing-secret-file", "",
+		"Path to a file containing the signing secret of the Slack app whose slash commands the admin API accepts. Chat commands are disabled when empty.")
+	flag.StringVar(&chatOpsTeamID, "chatops-team-id", "",
+		"The ID of the Slack workspace whose slash commands are accepted. Required with --chatops-signing-secret-file.")
+	flag.BoolVar(&defaults.SafeMode, "safe-mode", false,
+		"Suspend all changes to managed databases and credentials. Can also be enabled through the config file.")
+	flag.StringVar(&configPath, "config", "",
+		"Path to a YAML file containing operator configuration, which is reloaded when it changes. Overrides the flags below.")
+	flag.StringVar(&environment, "environment", "",
+		"The name of the environment whose overrides should be applied from the config file.")
+	flag.BoolVar(&enableConsumerInjection, "enable-consumer-injection", false,
+		"Serve the mutating webhook which injects database connection details into pods labeled as consumers of a ManagedDatabase.")
+	flag.BoolVar(&enableQuotaAdmission, "enable-quota-admission", false,
+		"Serve the validating webhook which rejects new ManagedDatabases on instances that are at their database quota.")
+	flag.BoolVar(&enableStatementLinting, "enable-statement-linting", false,
+		"Serve the validating webhook which rejects ManagedDatabases whose scheduled statements, consistency queries or seed data aren't valid SQL.")
+	flag.BoolVar(&enableRequesterVerification, "enable-requester-verification", false,
+		"Serve the mutating webhook which verifies that the creator of a DatabaseCredentialRequest may act as the service account it names. Credential request policies which list service accounts only match verified requests.")
+	flag.BoolVar(&enableSecretConventionValidation, "enable-secret-convention-validation", false,
+		"Serve the validating webhook which rejects ManagedDatabases whose secret convention doesn't parse or was changed after their Secrets were generated.")
+	flag.BoolVar(&enableMonitoring, "enable-monitoring", false,
+		"Create a Prometheus operator monitor for the operator's metrics and a ConfigMap of Grafana dashboards for them.")
+	flag.StringVar(&monitoringOptions.Namespace, "monitoring-namespace", monitoringOptions.Namespace,
+		"The namespace the operator runs in, where the monitor and dashboards are created. Defaults to $POD_NAMESPACE.")
+	flag.StringVar(&monitoringOptions.Kind, "monitoring-kind", controllers.MonitorKindService,
+		"Either ServiceMonitor to scrape the metrics Service, or PodMonitor to scrape the operator's pods directly.")
+	flag.StringVar(&monitoringSelector, "monitoring-selector", "control-plane=controller-manager",
+		"The labels of the metrics Service or operator pods, formatted as name=value,name=value.")
+	flag.StringVar(&monitoringOptions.Port, "monitoring-port", "https",
+		"The name of the port serving the operator's metrics.")
+	flag.StringVar(&monitoringOptions.Scheme, "monitoring-scheme", "https",
+		"The scheme of the metrics endpoint, https is authenticated with the scraper's service account token.")
+	flag.BoolVar(&injectFaults, "inject-faults", false,
+		"Randomly delay and fail database operations to test recovery. Never enable this in production.")
+	flag.Float64Var(&faultOptions.DelayProbability, "fault-delay-probability", 0,
+		"The chance, between 0 and 1, that a database operation is delayed when faults are injected.")
+	flag.DurationVar(&faultOptions.MaxDelay, "fault-max-delay", 5*time.Second,
+		"The longest delay which is injected into a database operation.")
+	flag.Float64Var(&faultOptions.FailureProbability, "fault-failure-probability", 0,
+		"The chance, between 0 and 1, that a database operation fails without being run when faults are injected.")
+	flag.Float64Var(&faultOptions.PartialProbability, "fault-partial-probability", 0,
+		"The chance, between 0 and 1, that a database change is only partially applied before failing when faults are injected.")
+	flag.Int64Var(&faultOptions.Seed, "fault-seed", 0,
+		"Seed for the injected faults, so that a failing sequence can be reproduced. The clock is used when 0.")
+	flag.DurationVar(&defaults.Rotation.Interval.Duration, "rotation-interval", 0,
+		"The time between credential rotation passes over all ManagedDatabases. A value of 0 disables rotation.")
+	flag.IntVar(&defaults.Rotation.RotationsPerMinute, "rotation-rate", defaults.Rotation.RotationsPerMinute,
+		"The maximum number of ManagedDatabases that will have their credentials rotated each minute.")
+	flag.IntVar(&defaults.Rotation.ErrorBudget, "rotation-error-budget", defaults.Rotation.ErrorBudget,
+		"The number of ManagedDatabases which may fail to rotate before a rotation pass is aborted.")
+	flag.StringVar(&defaults.Leases.ClusterID, "cluster-id", "",
+		"Identifies this cluster when operators in several clusters manage the same databases, only the holder of a database's lease changes it. Leases are disabled when empty.")
+	flag.StringVar(&allowedNamespaces, "allowed-namespaces", "",
+		"Comma separated names or patterns of the namespaces whose databases may be managed. Every namespace may be when empty.")
+	flag.StringVar(&allowedHosts, "allowed-hosts", "",
+		"Comma separated patterns of the database hosts which may be connected to, such as *.prod.internal. Every host may be when empty.")
 	flag.Parse()
 
-	ctrl.SetLogger(zap.Logger(true))
+	defaults.Scope.Namespaces = splitList(allowedNamespaces)
+	defaults.Scope.Hosts = splitList(allowedHosts)
+
+	ctrl.SetLogger(redact.Logger(zap.Logger(true)))
+
+	var configProvider config.Provider = config.Static(defaults)
+	if configPath != "" {
+		watcher, err := config.NewWatcher(configPath, environment, defaults, configPollInterval, ctrl.Log.WithName("config"))
+		if err != nil {
+			setupLog.Error(err, "unable to load config file")
+			os.Exit(1)
+		}
+		configProvider = watcher
+	} else if err := defaults.Validate(); err != nil {
+		setupLog.Error(err, "invalid configuration")
+		os.Exit(1)
+	}
+
+	controllers.RestrictScope(configProvider)
+
+	if injectFaults {
+		injector, err := faults.NewInjector(faultOptions, ctrl.Log.WithName("faults"))
+		if err != nil {
+			setupLog.Error(err, "invalid fault injection options")
+			os.Exit(1)
+		}
+		setupLog.Info("Injecting faults into database operations", "options", faultOptions)
+		controllers.EnableFaultInjection(injector)
+	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
@@ -65,24 +230,428 @@ func main() {
 		os.Exit(1)
 	}
 
+	if watcher, ok := configProvider.(*config.Watcher); ok {
+		if err = mgr.Add(watcher); err != nil {
+			setupLog.Error(err, "unable to add config watcher")
+			os.Exit(1)
+		}
+	}
+
+	var diag *diagnostics.Recorder
+	if debug {
+		token, err := diagnosticsToken(diagnosticsAddr, diagnosticsTokenFile)
+		if err != nil {
+			setupLog.Error(err, "unable to secure diagnostics server")
+			os.Exit(1)
+		}
+
+		diag = diagnostics.NewRecorder(diagnosticsCapacity)
+		mux := http.NewServeMux()
+		mux.Handle("/debug/sql", diag)
+		mux.Handle("/debug/bundle", diag.ArchiveHandler())
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		if err = mgr.Add(httpServer(diagnosticsAddr, "diagnostics", diagnostics.Authenticate(token, mux), "", "")); err != nil {
+			setupLog.Error(err, "unable to add diagnostics server")
+			os.Exit(1)
+		}
+	}
+
+	notifier := notify.NewWebhookNotifier(configProvider, ctrl.Log.WithName("notify"))
+	silencer := silence.NewAlertmanagerSilencer(configProvider, ctrl.Log.WithName("silence"))
+	approver := approval.NewWebhookApprover(configProvider, ctrl.Log.WithName("approval"))
+
+	// The controller-runtime client can't read pod logs, which is where
+	// migration progress is reported
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create kubernetes clientset")
+		os.Exit(1)
+	}
+
 	controller, metricsToRegister := controllers.NewManagedDatabaseController(
 		mgr.GetClient(),
 		mgr.GetScheme(),
 		ctrl.Log.WithName("controllers").WithName("ManagedDatabase"),
+		diag,
+		configProvider,
+		notifier,
+		clientset.CoreV1(),
+		silencer,
+		approver,
 	)
 	if err = controller.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ManagedDatabase")
 		os.Exit(1)
 	}
 
+	rotationController, rotationMetrics := controllers.NewFleetRotationController(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		ctrl.Log.WithName("controllers").WithName("FleetRotation"),
+		diag,
+		configProvider,
+		notifier,
+		silencer,
+	)
+	if err = mgr.Add(rotationController); err != nil {
+		setupLog.Error(err, "unable to add rotation controller", "controller", "FleetRotation")
+		os.Exit(1)
+	}
+	metricsToRegister = append(metricsToRegister, rotationMetrics...)
+
+	operationController, operationMetrics := controllers.NewOperationController(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		ctrl.Log.WithName("controllers").WithName("DatabaseOperation"),
+		diag,
+		configProvider,
+		controller,
+		rotationController,
+	)
+	if err = operationController.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DatabaseOperation")
+		os.Exit(1)
+	}
+	metricsToRegister = append(metricsToRegister, operationMetrics...)
+
+	drillController := controllers.NewFailoverDrillController(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		ctrl.Log.WithName("controllers").WithName("FailoverDrill"),
+		configProvider,
+	)
+	if err = mgr.Add(drillController); err != nil {
+		setupLog.Error(err, "unable to add failover drill controller", "controller", "FailoverDrill")
+		os.Exit(1)
+	}
+
+	snapshotController, snapshotMetrics := controllers.NewAnonymizedSnapshotController(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		ctrl.Log.WithName("controllers").WithName("AnonymizedSnapshot"),
+		diag,
+		configProvider,
+		controller,
+	)
+	if err = snapshotController.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AnonymizedSnapshot")
+		os.Exit(1)
+	}
+	metricsToRegister = append(metricsToRegister, snapshotMetrics...)
+
+	gcController, gcMetrics := controllers.NewGarbageCollectionController(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		ctrl.Log.WithName("controllers").WithName("GarbageCollection"),
+		diag,
+		configProvider,
+		notifier,
+	)
+	if err = mgr.Add(gcController); err != nil {
+		setupLog.Error(err, "unable to add garbage collection controller", "controller", "GarbageCollection")
+		os.Exit(1)
+	}
+	metricsToRegister = append(metricsToRegister, gcMetrics...)
+
+	ageController, ageMetrics := controllers.NewCredentialAgeController(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		ctrl.Log.WithName("controllers").WithName("CredentialAge"),
+		configProvider,
+	)
+	if err = mgr.Add(ageController); err != nil {
+		setupLog.Error(err, "unable to add credential age controller", "controller", "CredentialAge")
+		os.Exit(1)
+	}
+	metricsToRegister = append(metricsToRegister, ageMetrics...)
+
+	loginController, loginMetrics := controllers.NewLoginTrackingController(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		ctrl.Log.WithName("controllers").WithName("LoginTracking"),
+		diag,
+		configProvider,
+	)
+	if err = mgr.Add(loginController); err != nil {
+		setupLog.Error(err, "unable to add login tracking controller", "controller", "LoginTracking")
+		os.Exit(1)
+	}
+	metricsToRegister = append(metricsToRegister, loginMetrics...)
+
+	leastPrivilegeController, leastPrivilegeMetrics := controllers.NewLeastPrivilegeController(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		ctrl.Log.WithName("controllers").WithName("LeastPrivilege"),
+		diag,
+		configProvider,
+		notifier,
+	)
+	if err = mgr.Add(leastPrivilegeController); err != nil {
+		setupLog.Error(err, "unable to add least privilege controller", "controller", "LeastPrivilege")
+		os.Exit(1)
+	}
+	metricsToRegister = append(metricsToRegister, leastPrivilegeMetrics...)
+
+	capacityController, capacityMetrics := controllers.NewCapacityReportController(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		ctrl.Log.WithName("controllers").WithName("CapacityReport"),
+		diag,
+		configProvider,
+	)
+	if err = mgr.Add(capacityController); err != nil {
+		setupLog.Error(err, "unable to add capacity report controller", "controller", "CapacityReport")
+		os.Exit(1)
+	}
+	metricsToRegister = append(metricsToRegister, capacityMetrics...)
+
+	inventoryController, inventoryMetrics := controllers.NewInventoryController(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		ctrl.Log.WithName("controllers").WithName("Inventory"),
+		configProvider,
+		capacityController,
+	)
+	if err = mgr.Add(inventoryController); err != nil {
+		setupLog.Error(err, "unable to add inventory controller", "controller", "Inventory")
+		os.Exit(1)
+	}
+	metricsToRegister = append(metricsToRegister, inventoryMetrics...)
+
+	requestController, requestMetrics := controllers.NewCredentialRequestController(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		ctrl.Log.WithName("controllers").WithName("DatabaseCredentialRequest"),
+		diag,
+		configProvider,
+		approver,
+		enableRequesterVerification,
+	)
+	if err = requestController.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DatabaseCredentialRequest")
+		os.Exit(1)
+	}
+	metricsToRegister = append(metricsToRegister, requestMetrics...)
+
+	if enableConsumerInjection {
+		injector := controllers.NewConsumerInjector(mgr.GetClient(), ctrl.Log.WithName("webhooks").WithName("ConsumerInjector"))
+		mgr.GetWebhookServer().Register("/mutate-v1-pod", &webhook.Admission{Handler: injector})
+	}
+	if enableQuotaAdmission {
+		validator := controllers.NewQuotaValidator(mgr.GetClient(), ctrl.Log.WithName("webhooks").WithName("QuotaValidator"), configProvider)
+		mgr.GetWebhookServer().Register("/validate-dbaoperator-app-sre-redhat-com-v1alpha1-manageddatabase", &webhook.Admission{Handler: validator})
+	}
+	if enableStatementLinting {
+		validator := controllers.NewStatementValidator(mgr.GetClient(), ctrl.Log.WithName("webhooks").WithName("StatementValidator"))
+		mgr.GetWebhookServer().Register("/lint-dbaoperator-app-sre-redhat-com-v1alpha1-manageddatabase", &webhook.Admission{Handler: validator})
+	}
+
+	if enableRequesterVerification {
+		verifier := controllers.NewRequesterVerifier(mgr.GetClient(), ctrl.Log.WithName("webhooks").WithName("RequesterVerifier"))
+		mgr.GetWebhookServer().Register("/mutate-dbaoperator-app-sre-redhat-com-v1alpha1-databasecredentialrequest", &webhook.Admission{Handler: verifier})
+	}
+
+	if enableSecretConventionValidation {
+		validator := controllers.NewSecretConventionValidator(mgr.GetClient(), ctrl.Log.WithName("webhooks").WithName("SecretConventionValidator"))
+		mgr.GetWebhookServer().Register("/validate-secret-convention-dbaoperator-app-sre-redhat-com-v1alpha1-manageddatabase", &webhook.Admission{Handler: validator})
+	}
+
+	if deployGateAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/safe-to-roll/", controllers.DeployGateHandler{Client: mgr.GetClient()})
+		if err = mgr.Add(httpServer(deployGateAddr, "deploy gate", mux, "", "")); err != nil {
+			setupLog.Error(err, "unable to add deploy gate server")
+			os.Exit(1)
+		}
+	}
+
+	if cloudEventsAddr != "" {
+		topics := strings.FieldsFunc(cloudEventsTopics, func(r rune) bool { return r == ',' })
+		if len(topics) == 0 {
+			setupLog.Error(fmt.Errorf("--cloud-events-topics is required with --cloud-events-addr"), "invalid cloud events options")
+			os.Exit(1)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/sns", cloudevents.NewReceiver(topics, ctrl.Log.WithName("cloudevents"), controller.RequeueForCloudEvent))
+		if err = mgr.Add(httpServer(cloudEventsAddr, "cloud events", mux, "", "")); err != nil {
+			setupLog.Error(err, "unable to add cloud events server")
+			os.Exit(1)
+		}
+	}
+
+	if adminAPIAddr != "" {
+		if adminAPICallersFile == "" {
+			setupLog.Error(fmt.Errorf("--admin-api-callers-file is required with --admin-api-addr"), "invalid admin API options")
+			os.Exit(1)
+		}
+		if (adminAPICertFile == "") != (adminAPIKeyFile == "") {
+			setupLog.Error(fmt.Errorf("--admin-api-cert-file and --admin-api-key-file must be set together"), "invalid admin API options")
+			os.Exit(1)
+		}
+		if adminAPICertFile == "" && !adminAPIInsecure {
+			setupLog.Error(fmt.Errorf("--admin-api-cert-file and --admin-api-key-file are required with --admin-api-addr, unless --admin-api-insecure is set"), "invalid admin API options")
+			os.Exit(1)
+		}
+		if adminAPICertFile != "" {
+			if _, err := tls.LoadX509KeyPair(adminAPICertFile, adminAPIKeyFile); err != nil {
+				setupLog.Error(err, "unable to load admin API certificate")
+				os.Exit(1)
+			}
+		} else {
+			setupLog.Info("Serving the admin API without TLS, caller tokens are sent in the clear")
+		}
+		callers, err := adminapi.LoadCallers(adminAPICallersFile)
+		if err != nil {
+			setupLog.Error(err, "unable to load admin API callers")
+			os.Exit(1)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/v1/", controllers.AdminAPIHandler{
+			Client:  mgr.GetClient(),
+			Callers: callers,
+			Log:     ctrl.Log.WithName("adminapi"),
+		})
+		if chatOpsSigningSecretFile != "" {
+			secret, err := ioutil.ReadFile(chatOpsSigningSecretFile)
+			if err == nil && len(bytes.TrimSpace(secret)) == 0 {
+				err = fmt.Errorf("%s is empty", chatOpsSigningSecretFile)
+			}
+			if err == nil && chatOpsTeamID == "" {
+				err = fmt.Errorf("--chatops-team-id is required with --chatops-signing-secret-file")
+			}
+			if err != nil {
+				setupLog.Error(err, "unable to read chatops signing secret")
+				os.Exit(1)
+			}
+			mux.Handle("/v1/chatops/slack", controllers.ChatOpsHandler{
+				Client:        mgr.GetClient(),
+				Callers:       callers,
+				SigningSecret: bytes.TrimSpace(secret),
+				TeamID:        chatOpsTeamID,
+				Log:           ctrl.Log.WithName("chatops"),
+			})
+		}
+		if err = mgr.Add(httpServer(adminAPIAddr, "admin API", mux, adminAPICertFile, adminAPIKeyFile)); err != nil {
+			setupLog.Error(err, "unable to add admin API server")
+			os.Exit(1)
+		}
+	}
+
+	if enableMonitoring {
+		selector, err := labels.ConvertSelectorToLabelsMap(monitoringSelector)
+		if err != nil {
+			setupLog.Error(err, "invalid monitoring selector")
+			os.Exit(1)
+		}
+		monitoringOptions.Selector = selector
+
+		monitoringController, err := controllers.NewMonitoringController(
+			mgr.GetClient(),
+			ctrl.Log.WithName("controllers").WithName("Monitoring"),
+			monitoringOptions,
+		)
+		if err != nil {
+			setupLog.Error(err, "invalid monitoring options")
+			os.Exit(1)
+		}
+		if err = mgr.Add(monitoringController); err != nil {
+			setupLog.Error(err, "unable to add monitoring controller", "controller", "Monitoring")
+			os.Exit(1)
+		}
+	}
+
 	for _, metric := range metricsToRegister {
 		metrics.Registry.MustRegister(metric)
 	}
 	// +kubebuilder:scaffold:builder
 
-	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	setupLog.Info("starting manager", "engines", dbadmin.Engines())
+	if err := mgr.Start(drainBeforeStopping(ctrl.SetupSignalHandler(), shutdownTimeout)); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// drainBeforeStopping returns a channel which is closed once signals is and
+// the reconciles in flight have stopped at a checkpoint, or timeout has
+// passed. The manager returns as soon as it is stopped, so the reconciles
+// have to be drained while it is still running.
+func drainBeforeStopping(signals <-chan struct{}, timeout time.Duration) <-chan struct{} {
+	stop := make(chan struct{})
+	go func() {
+		<-signals
+		setupLog.Info("draining reconciles in flight", "timeout", timeout)
+		if remaining := controllers.Drain(timeout); len(remaining) > 0 {
+			setupLog.Info("stopping with reconciles still in flight", "inFlight", remaining)
+		}
+		close(stop)
+	}()
+	return stop
+}
+
+// diagnosticsToken reads the bearer token of the diagnostics endpoints. The
+// endpoints expose goroutine dumps and profiles, so they may only go without
+// a token when they listen on loopback and are reached by port forwarding.
+func diagnosticsToken(addr, tokenFile string) (string, error) {
+	if tokenFile == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return "", fmt.Errorf("Unable to parse diagnostics address: %w", err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return "", fmt.Errorf("A diagnostics token file is required to serve diagnostics on %s", addr)
+		}
+		return "", nil
+	}
+
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("Unable to read diagnostics token: %w", err)
+	}
+	if trimmed := strings.TrimSpace(string(token)); trimmed != "" {
+		return trimmed, nil
+	}
+	return "", fmt.Errorf("Diagnostics token file %s is empty", tokenFile)
+}
+
+// httpServer serves handler until the manager stops, over TLS when certFile
+// and keyFile are set
+func httpServer(addr, purpose string, handler http.Handler, certFile, keyFile string) manager.RunnableFunc {
+	return func(stop <-chan struct{}) error {
+		server := &http.Server{Addr: addr, Handler: handler}
+
+		go func() {
+			<-stop
+			_ = server.Close()
+		}()
+
+		var err error
+		if certFile != "" {
+			server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			return fmt.Errorf("Unable to serve %s: %w", purpose, err)
+		}
+		return nil
+	}
+}
+
+// splitList splits a comma separated flag, an empty flag is an empty list
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,163 @@
+// Package adminapi authenticates and authorizes the callers of the admin
+// API, which lets tooling outside of the cluster drive the operator without
+// access to its custom resources.
+package adminapi
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Actions which callers may be allowed
+const (
+	// ActionRead lists the users of databases and reads the outcome of
+	// operations
+	ActionRead = "read"
+
+	// ActionRotate rotates the credentials of databases
+	ActionRotate = "rotate"
+
+	// ActionDriftScan runs the drift checks of databases
+	ActionDriftScan = "drift-scan"
+
+	// ActionApprove approves the pending plans of databases
+	ActionApprove = "approve"
+)
+
+// minTokenLength rejects tokens which are easy to guess
+const minTokenLength = 32
+
+// Caller is a client of the admin API, identified by a bearer token or by
+// the chat users who send commands as the caller
+type Caller struct {
+	Name  string `json:"name"`
+	Token string `json:"token,omitempty"`
+
+	// ChatUsers are the IDs of the Slack users whose commands are run as
+	// the caller
+	ChatUsers []string `json:"chatUsers,omitempty"`
+
+	// Namespaces restricts the caller to the ManagedDatabases in the listed
+	// namespaces, every namespace is allowed when empty
+	Namespaces []string `json:"namespaces,omitempty"`
+	Actions    []string `json:"actions"`
+}
+
+// Allows returns true if the caller may take the action on databases in the
+// namespace
+func (c Caller) Allows(namespace, action string) bool {
+	if !contains(c.Actions, action) {
+		return false
+	}
+	return len(c.Namespaces) == 0 || contains(c.Namespaces, namespace)
+}
+
+// LoadCallers reads the callers from a YAML file, which should be mounted
+// from a Secret since it contains their tokens
+func LoadCallers(path string) ([]Caller, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read admin API callers: %w", err)
+	}
+	return ParseCallers(raw)
+}
+
+// ParseCallers decodes and validates a YAML list of callers
+func ParseCallers(raw []byte) ([]Caller, error) {
+	var callers []Caller
+	if err := yaml.UnmarshalStrict(raw, &callers); err != nil {
+		return nil, fmt.Errorf("Unable to parse admin API callers: %w", err)
+	}
+	if len(callers) == 0 {
+		return nil, fmt.Errorf("At least one admin API caller is required")
+	}
+
+	names := make(map[string]bool, len(callers))
+	tokens := make(map[string]bool, len(callers))
+	chatUsers := make(map[string]bool)
+	for _, caller := range callers {
+		if caller.Name == "" {
+			return nil, fmt.Errorf("Admin API callers must be named")
+		}
+		if names[caller.Name] {
+			return nil, fmt.Errorf("Admin API caller %s is defined more than once", caller.Name)
+		}
+		names[caller.Name] = true
+
+		if caller.Token == "" && len(caller.ChatUsers) == 0 {
+			return nil, fmt.Errorf("Admin API caller %s requires a token or chat users", caller.Name)
+		}
+		if caller.Token != "" {
+			if len(caller.Token) < minTokenLength {
+				return nil, fmt.Errorf("Token of admin API caller %s must be at least %d characters long", caller.Name, minTokenLength)
+			}
+			if tokens[caller.Token] {
+				return nil, fmt.Errorf("Token of admin API caller %s is shared with another caller", caller.Name)
+			}
+			tokens[caller.Token] = true
+		}
+		for _, user := range caller.ChatUsers {
+			if user == "" || chatUsers[user] {
+				return nil, fmt.Errorf("Chat users of admin API caller %s must be set and may only map to one caller", caller.Name)
+			}
+			chatUsers[user] = true
+		}
+
+		if len(caller.Actions) == 0 {
+			return nil, fmt.Errorf("Admin API caller %s is allowed no actions", caller.Name)
+		}
+		for _, action := range caller.Actions {
+			switch action {
+			case ActionRead, ActionRotate, ActionDriftScan, ActionApprove:
+			default:
+				return nil, fmt.Errorf("Unknown action %s for admin API caller %s", action, caller.Name)
+			}
+		}
+	}
+	return callers, nil
+}
+
+// Authenticate returns the caller whose token the request presents as its
+// bearer token, or nil when there is none
+func Authenticate(callers []Caller, req *http.Request) *Caller {
+	presented := strings.TrimSpace(req.Header.Get("Authorization"))
+	if !strings.HasPrefix(presented, "Bearer ") {
+		return nil
+	}
+	token := []byte(strings.TrimPrefix(presented, "Bearer "))
+
+	var found *Caller
+	for i := range callers {
+		// Every token is compared so that the time taken doesn't reveal
+		// which caller matched
+		if callers[i].Token != "" && subtle.ConstantTimeCompare(token, []byte(callers[i].Token)) == 1 {
+			found = &callers[i]
+		}
+	}
+	return found
+}
+
+// ChatCaller returns the caller which the chat user sends commands as, or
+// nil when there is none
+func ChatCaller(callers []Caller, userID string) *Caller {
+	for i := range callers {
+		if contains(callers[i].ChatUsers, userID) {
+			return &callers[i]
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
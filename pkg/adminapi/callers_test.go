@@ -0,0 +1,98 @@
+package adminapi
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	chatopsToken = "c5e1f0a8b36d4f6f9a2e7d1c0b4a3f58"
+	runbookToken = "0d9b8c7a6e5f4d3c2b1a09f8e7d6c5b4"
+)
+
+var testCallers = `
+- name: chatops
+  token: ` + chatopsToken + `
+  actions: [read, rotate]
+- name: runbooks
+  token: ` + runbookToken + `
+  namespaces: [quay]
+  actions: [read, drift-scan]
+- name: dbas
+  chatUsers: [U2147483697]
+  actions: [read, approve]
+`
+
+func TestParseCallers(t *testing.T) {
+	callers, err := ParseCallers([]byte(testCallers))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chatops, runbooks := callers[0], callers[1]
+	if !chatops.Allows("analytics", ActionRotate) || chatops.Allows("analytics", ActionDriftScan) {
+		t.Errorf("Expected chatops to rotate anywhere but not scan, got %+v", chatops)
+	}
+	if !runbooks.Allows("quay", ActionDriftScan) || runbooks.Allows("analytics", ActionRead) {
+		t.Errorf("Expected runbooks to be limited to quay, got %+v", runbooks)
+	}
+}
+
+func TestParseCallersInvalid(t *testing.T) {
+	for _, raw := range []string{
+		"[]",
+		"- token: " + chatopsToken + "\n  actions: [read]\n",
+		"- name: short\n  token: secret\n  actions: [read]\n",
+		"- name: anonymous\n  actions: [read]\n",
+		"- name: a\n  chatUsers: [U1]\n  actions: [read]\n- name: b\n  chatUsers: [U1]\n  actions: [read]\n",
+		"- name: none\n  token: " + chatopsToken + "\n",
+		"- name: admin\n  token: " + chatopsToken + "\n  actions: [drop]\n",
+		"- name: a\n  token: " + chatopsToken + "\n  actions: [read]\n- name: b\n  token: " + chatopsToken + "\n  actions: [read]\n",
+		"- name: a\n  token: " + chatopsToken + "\n  actions: [read]\n- name: a\n  token: " + runbookToken + "\n  actions: [read]\n",
+	} {
+		if _, err := ParseCallers([]byte(raw)); err == nil {
+			t.Errorf("Expected an error parsing %q", raw)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	callers, err := ParseCallers([]byte(testCallers))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for header, expected := range map[string]string{
+		"Bearer " + runbookToken: "runbooks",
+		"Bearer " + chatopsToken: "chatops",
+		runbookToken:             "",
+		"Bearer wrong":           "",
+		"":                       "",
+		"Bearer ":                "",
+	} {
+		req := httptest.NewRequest("GET", "/v1/databases/quay/quayio/users", nil)
+		req.Header.Set("Authorization", header)
+
+		name := ""
+		if caller := Authenticate(callers, req); caller != nil {
+			name = caller.Name
+		}
+		if name != expected {
+			t.Errorf("Expected %q to authenticate %q, got %q", header, expected, name)
+		}
+	}
+}
+
+func TestChatCaller(t *testing.T) {
+	callers, err := ParseCallers([]byte(testCallers))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caller := ChatCaller(callers, "U2147483697"); caller == nil || caller.Name != "dbas" {
+		t.Errorf("Expected the chat user to act as dbas, got %+v", caller)
+	}
+	if caller := ChatCaller(callers, "U0"); caller != nil {
+		t.Errorf("Expected an unknown chat user to act as nobody, got %+v", caller)
+	}
+}
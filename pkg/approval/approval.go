@@ -0,0 +1,162 @@
+// Package approval asks an external policy webhook whether new credentials
+// may be provisioned, so that access to managed databases can be governed
+// centrally.
+package approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-logr/logr"
+
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+// Kinds of credentials which are submitted for approval
+const (
+	KindAppCredentials    = "AppCredentials"
+	KindCredentialRequest = "DatabaseCredentialRequest"
+	KindReplicaUser       = "ReplicaUser"
+)
+
+// maxResponseBytes bounds how much of a decision is read
+const maxResponseBytes = 64 * 1024
+
+// ObjectReference names a namespaced object
+type ObjectReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// Requester identifies who the credentials are for, when they are requested
+// through a DatabaseCredentialRequest
+type Requester struct {
+	Namespace      string `json:"namespace"`
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+}
+
+// Grant is a single grant of the credentials
+type Grant struct {
+	Database string   `json:"database,omitempty"`
+	Class    string   `json:"class"`
+	Tables   []string `json:"tables,omitempty"`
+}
+
+// Request describes the credentials which are about to be provisioned
+type Request struct {
+	Kind string `json:"kind"`
+
+	// Object is the object the credentials are provisioned for, the
+	// ManagedDatabase itself or a DatabaseCredentialRequest
+	Object          ObjectReference `json:"object"`
+	ManagedDatabase ObjectReference `json:"managedDatabase"`
+	Requester       *Requester      `json:"requester,omitempty"`
+
+	Username string `json:"username"`
+
+	// Class is the grant class which was requested, it is only set for
+	// DatabaseCredentialRequests
+	Class  string  `json:"class,omitempty"`
+	Grants []Grant `json:"grants"`
+}
+
+// Decision is the answer of the policy
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+
+	// Class replaces the requested grant class when set, e.g. to only hand
+	// out readonly credentials
+	Class string `json:"class,omitempty"`
+}
+
+// Approver decides whether credentials may be provisioned
+type Approver interface {
+	// Approve returns the decision for the request. An error means that no
+	// decision could be reached, and that the request should be retried.
+	Approve(request Request) (Decision, error)
+}
+
+// GrantsOf describes the grants of credentials for a request
+func GrantsOf(grants []dbadmin.DatabaseGrant) []Grant {
+	described := make([]Grant, 0, len(grants))
+	for _, grant := range grants {
+		var tables []string
+		for _, table := range grant.Tables {
+			tables = append(tables, table.Table)
+		}
+		described = append(described, Grant{Database: grant.Database, Class: string(grant.Class), Tables: tables})
+	}
+	return described
+}
+
+// WebhookApprover submits requests to the policy webhook in the operator
+// configuration, OPA-style: the request is posted as the input document, and
+// the decision is read from the result
+type WebhookApprover struct {
+	provider config.Provider
+	log      logr.Logger
+}
+
+type webhookInput struct {
+	Input Request `json:"input"`
+}
+
+type webhookResult struct {
+	Result *Decision `json:"result"`
+}
+
+// NewWebhookApprover returns an approver which uses the webhook configured
+// at the time of each request. Every request is allowed while none is
+// configured.
+func NewWebhookApprover(provider config.Provider, log logr.Logger) *WebhookApprover {
+	return &WebhookApprover{provider: provider, log: log}
+}
+
+// Approve implements Approver
+func (wa *WebhookApprover) Approve(request Request) (Decision, error) {
+	settings := wa.provider.Current().CredentialApproval
+	if settings.WebhookURL == "" {
+		return Decision{Allowed: true}, nil
+	}
+
+	decision, err := ask(settings, request)
+	if err != nil && settings.FailurePolicy == config.ApprovalFailureIgnore {
+		wa.log.Error(redact.Error(err), "Approving credentials without the policy webhook", "kind", request.Kind, "username", request.Username)
+		return Decision{Allowed: true, Reason: "policy webhook unavailable"}, nil
+	}
+	return decision, err
+}
+
+func ask(settings config.CredentialApproval, request Request) (Decision, error) {
+	body, err := json.Marshal(webhookInput{Input: request})
+	if err != nil {
+		return Decision{}, fmt.Errorf("Unable to encode approval request: %w", err)
+	}
+
+	client := &http.Client{Timeout: settings.Timeout.Duration}
+	resp, err := client.Post(settings.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, fmt.Errorf("Unable to reach policy webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Decision{}, fmt.Errorf("Policy webhook responded with status %d", resp.StatusCode)
+	}
+
+	var result webhookResult
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&result); err != nil {
+		return Decision{}, fmt.Errorf("Unable to parse policy decision: %w", err)
+	}
+	if result.Result == nil {
+		// OPA leaves out the result when the policy is undefined for the input
+		return Decision{Allowed: false, Reason: "policy returned no decision"}, nil
+	}
+	return *result.Result, nil
+}
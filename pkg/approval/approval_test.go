@@ -0,0 +1,96 @@
+package approval
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+var testRequest = Request{
+	Kind:            KindCredentialRequest,
+	Object:          ObjectReference{Namespace: "analytics", Name: "reports"},
+	ManagedDatabase: ObjectReference{Namespace: "quay", Name: "quayio"},
+	Requester:       &Requester{Namespace: "analytics", ServiceAccount: "reporter"},
+	Username:        "dbr_reports",
+	Class:           "readwrite",
+	Grants: GrantsOf([]dbadmin.DatabaseGrant{
+		{Database: "quay", Class: dbadmin.GrantClassReadWrite, Tables: []dbadmin.TableGrant{{Table: "repository"}}},
+	}),
+}
+
+func approverFor(url, failurePolicy string) *WebhookApprover {
+	cfg := config.Default()
+	cfg.CredentialApproval.WebhookURL = url
+	if failurePolicy != "" {
+		cfg.CredentialApproval.FailurePolicy = failurePolicy
+	}
+	return NewWebhookApprover(config.Static(cfg), logf.NullLogger{})
+}
+
+func TestWebhookApprover(t *testing.T) {
+	var received webhookInput
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := json.NewDecoder(req.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write([]byte(`{"result": {"allowed": true, "reason": "reports are read only", "class": "readonly"}}`))
+	}))
+	defer server.Close()
+
+	decision, err := approverFor(server.URL, "").Approve(testRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decision.Allowed || decision.Class != "readonly" || decision.Reason != "reports are read only" {
+		t.Errorf("Expected a downgrade to readonly, got %+v", decision)
+	}
+
+	if received.Input.Username != "dbr_reports" || received.Input.Requester.ServiceAccount != "reporter" {
+		t.Errorf("Expected the request as input, got %+v", received.Input)
+	}
+	if len(received.Input.Grants) != 1 || received.Input.Grants[0].Tables[0] != "repository" {
+		t.Errorf("Expected the grants in the input, got %+v", received.Input.Grants)
+	}
+}
+
+func TestWebhookApproverUndefined(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	decision, err := approverFor(server.URL, "").Approve(testRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.Allowed {
+		t.Errorf("Expected an undefined decision to deny, got %+v", decision)
+	}
+}
+
+func TestWebhookApproverFailurePolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if _, err := approverFor(server.URL, config.ApprovalFailureFail).Approve(testRequest); err == nil {
+		t.Error("Expected an error when the webhook fails")
+	}
+
+	decision, err := approverFor(server.URL, config.ApprovalFailureIgnore).Approve(testRequest)
+	if err != nil || !decision.Allowed {
+		t.Errorf("Expected the failure to be ignored, got %+v (%v)", decision, err)
+	}
+
+	decision, err = approverFor("", "").Approve(testRequest)
+	if err != nil || !decision.Allowed {
+		t.Errorf("Expected approval without a webhook, got %+v (%v)", decision, err)
+	}
+}
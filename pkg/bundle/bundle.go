@@ -0,0 +1,103 @@
+// Package bundle pulls the SQL files of migrations which are distributed as
+// OCI artifacts, such as those pushed with oras, and verifies their cosign
+// signatures before they are used.
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/cosign"
+	"github.com/app-sre/dba-operator/pkg/registry"
+)
+
+// DefaultMaxSize bounds the total size of the SQL files of a bundle, which
+// is what fits into a ConfigMap
+const DefaultMaxSize = 1 << 20
+
+const (
+	// titleAnnotation names the file which a layer contains, oras sets it
+	// to the name of each file which is pushed
+	titleAnnotation = "org.opencontainers.image.title"
+
+	sqlSuffix = ".sql"
+)
+
+// Bundle contains the SQL files of a migration, keyed by their names as the
+// data of a ConfigMap would be
+type Bundle struct {
+	// Digest is the digest of the manifest which was pulled, it identifies
+	// the bundle even when it was pulled by a tag
+	Digest string
+	Files  map[string]string
+}
+
+// Puller pulls bundles, and refuses those which its verifier doesn't accept
+type Puller struct {
+	Client   *http.Client
+	Verifier *cosign.Verifier
+
+	// MaxSize bounds the total size of the files, DefaultMaxSize applies
+	// when it is zero
+	MaxSize int64
+}
+
+// Pull fetches the manifest of the bundle, verifies its signature, and then
+// reads the layers whose title ends in .sql. Other layers are ignored.
+func (p *Puller) Pull(ctx context.Context, ref registry.Reference, creds *registry.Credentials) (*Bundle, error) {
+	if p.Verifier == nil {
+		return nil, fmt.Errorf("No verifier is configured for bundles")
+	}
+	maxSize := p.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	session := registry.NewSession(p.Client, ref, creds)
+	body, digest, err := session.Manifest(ctx, ref.Target())
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch manifest of bundle (%s): %w", ref, err)
+	}
+
+	if _, err := p.Verifier.Verify(ctx, session, digest); err != nil {
+		return nil, fmt.Errorf("Unable to verify signature of bundle (%s): %w", ref, err)
+	}
+
+	var manifest registry.Manifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("Unable to read manifest of bundle (%s): %w", ref, err)
+	}
+
+	files := make(map[string]string)
+	var total int64
+	for _, layer := range manifest.Layers {
+		name := layer.Annotations[titleAnnotation]
+		if !strings.HasSuffix(name, sqlSuffix) {
+			continue
+		}
+		if strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("Bundle (%s) contains a file in a directory: %s", ref, name)
+		}
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("Bundle (%s) contains file %s more than once", ref, name)
+		}
+		total += layer.Size
+		if layer.Size < 0 || total > maxSize {
+			return nil, fmt.Errorf("Bundle (%s) is larger than %d bytes", ref, maxSize)
+		}
+
+		blob, err := session.Blob(ctx, layer)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to fetch file %s of bundle (%s): %w", name, ref, err)
+		}
+		files[name] = string(blob)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("Bundle (%s) contains no %s files", ref, sqlSuffix)
+	}
+
+	return &Bundle{Digest: digest, Files: files}, nil
+}
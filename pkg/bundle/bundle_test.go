@@ -0,0 +1,86 @@
+package bundle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/cosign"
+	"github.com/app-sre/dba-operator/pkg/cosign/cosigntest"
+	"github.com/app-sre/dba-operator/pkg/registry/registrytest"
+)
+
+func TestPull(t *testing.T) {
+	server := registrytest.NewServer(t, "app/migrations")
+	defer server.Close()
+
+	key, encoded := cosigntest.GenerateKey(t)
+	keys, err := cosign.ParsePublicKeys([]string{encoded})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := server.PushFiles("v42", map[string]string{
+		"001_users.sql": "CREATE TABLE users (id INT PRIMARY KEY);",
+		"002_index.sql": "CREATE INDEX id_idx ON users (id);",
+		"README.md":     "ignored",
+	})
+	cosigntest.Sign(t, server, key, digest)
+
+	puller := Puller{Client: server.Client(), Verifier: &cosign.Verifier{Keys: keys}}
+	pulled, err := puller.Pull(context.Background(), server.Reference("v42"), server.Credentials())
+	if err != nil {
+		t.Fatalf("Unable to pull bundle: %v", err)
+	}
+	if pulled.Digest != digest || len(pulled.Files) != 2 || pulled.Files["002_index.sql"] != "CREATE INDEX id_idx ON users (id);" {
+		t.Errorf("Unexpected bundle: %+v", pulled)
+	}
+
+	if _, err := puller.Pull(context.Background(), server.Reference("v42").Pinned(digest), server.Credentials()); err != nil {
+		t.Errorf("Unable to pull bundle by digest: %v", err)
+	}
+	if _, err := puller.Pull(context.Background(), server.Reference("v42"), nil); err == nil {
+		t.Errorf("Expected an error pulling without credentials")
+	}
+}
+
+func TestPullRefusesUnverified(t *testing.T) {
+	server := registrytest.NewServer(t, "app/migrations")
+	defer server.Close()
+
+	trusted, encoded := cosigntest.GenerateKey(t)
+	untrusted, _ := cosigntest.GenerateKey(t)
+	keys, err := cosign.ParsePublicKeys([]string{encoded})
+	if err != nil {
+		t.Fatal(err)
+	}
+	puller := Puller{Client: server.Client(), Verifier: &cosign.Verifier{Keys: keys}}
+
+	server.PushFiles("unsigned", map[string]string{"001.sql": "SELECT 1;"})
+	cosigntest.Sign(t, server, untrusted, server.PushFiles("untrusted", map[string]string{"001.sql": "SELECT 2;"}))
+
+	// A signature of another bundle doesn't verify this one
+	other := server.PushFiles("other", map[string]string{"001.sql": "SELECT 3;"})
+	cosigntest.Sign(t, server, trusted, other)
+	replayed := server.PushFiles("replayed", map[string]string{"001.sql": "DROP TABLE users;"})
+	server.Tag("sha256-"+replayed[len("sha256:"):]+".sig", "sha256-"+other[len("sha256:"):]+".sig")
+
+	for _, tag := range []string{"unsigned", "untrusted", "replayed", "missing"} {
+		if _, err := puller.Pull(context.Background(), server.Reference(tag), server.Credentials()); err == nil {
+			t.Errorf("Expected an error pulling %s", tag)
+		}
+	}
+
+	pinned := server.Reference("replayed")
+	pinned.Digest = other
+	if _, err := puller.Pull(context.Background(), pinned, server.Credentials()); err != nil {
+		t.Errorf("A digest takes precedence over the tag: %v", err)
+	}
+
+	tooSmall := Puller{Client: server.Client(), Verifier: puller.Verifier, MaxSize: 4}
+	if _, err := tooSmall.Pull(context.Background(), server.Reference("other"), server.Credentials()); err == nil {
+		t.Errorf("Expected an error pulling a bundle which is too large")
+	}
+	if _, err := (&Puller{Client: server.Client(), Verifier: &cosign.Verifier{}}).Pull(context.Background(), server.Reference("other"), server.Credentials()); err == nil {
+		t.Errorf("Expected an error pulling without keys")
+	}
+}
@@ -0,0 +1,144 @@
+// Package cardinality bounds the number of series exported by metrics whose
+// labels contain unbounded values, such as the names of tables or users, so
+// that databases with thousands of tables can't overwhelm Prometheus.
+package cardinality
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Other is the label value that the values which aren't reported on their
+// own are aggregated under. Parentheses can't appear in unquoted table or
+// user names, so it doesn't collide with a real value.
+const Other = "(other)"
+
+// Budget decides which values of a label are reported on their own
+type Budget struct {
+	// MaxSeries is how many values are reported on their own, there is no
+	// limit when it is 0
+	MaxSeries int
+
+	// Allowed lists path.Match patterns of the values which may be reported
+	// on their own, every value may be when it is empty
+	Allowed []string
+
+	// MaxValueLength truncates longer values, there is no limit when it is 0
+	MaxValueLength int
+}
+
+// Aggregate combines the samples of the values which are folded together
+type Aggregate func(samples []float64) float64
+
+// Sum adds the samples up
+func Sum(samples []float64) float64 {
+	total := 0.0
+	for _, sample := range samples {
+		total += sample
+	}
+	return total
+}
+
+// Max returns the largest sample
+func Max(samples []float64) float64 {
+	max := samples[0]
+	for _, sample := range samples[1:] {
+		if sample > max {
+			max = sample
+		}
+	}
+	return max
+}
+
+// Min returns the smallest sample
+func Min(samples []float64) float64 {
+	min := samples[0]
+	for _, sample := range samples[1:] {
+		if sample < min {
+			min = sample
+		}
+	}
+	return min
+}
+
+// Sanitize returns the value as it is used as a label value. Invalid UTF-8
+// is replaced, control characters are removed and the value is truncated to
+// MaxValueLength bytes.
+func (b Budget) Sanitize(value string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, value)
+
+	if b.MaxValueLength > 0 && len(sanitized) > b.MaxValueLength {
+		end := b.MaxValueLength
+		for end > 0 && !utf8.RuneStart(sanitized[end]) {
+			end--
+		}
+		sanitized = sanitized[:end]
+	}
+	return sanitized
+}
+
+func (b Budget) allowed(value string) bool {
+	if len(b.Allowed) == 0 {
+		return true
+	}
+	for _, pattern := range b.Allowed {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the values which are reported on their own, which are the
+// allowed values with the highest rank, up to MaxSeries of them
+func (b Budget) Select(ranks map[string]float64) map[string]bool {
+	candidates := make([]string, 0, len(ranks))
+	for value := range ranks {
+		if b.allowed(value) {
+			candidates = append(candidates, value)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if ranks[candidates[i]] != ranks[candidates[j]] {
+			return ranks[candidates[i]] > ranks[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+	if b.MaxSeries > 0 && len(candidates) > b.MaxSeries {
+		candidates = candidates[:b.MaxSeries]
+	}
+
+	selected := make(map[string]bool, len(candidates))
+	for _, value := range candidates {
+		selected[value] = true
+	}
+	return selected
+}
+
+// Fold returns the samples to export by label value. The selected values
+// are exported under their sanitized value and the others are aggregated
+// under Other, values which are the same once sanitized are aggregated too.
+func (b Budget) Fold(samples map[string]float64, selected map[string]bool, aggregate Aggregate) map[string]float64 {
+	grouped := make(map[string][]float64)
+	for value, sample := range samples {
+		label := Other
+		if selected[value] {
+			label = b.Sanitize(value)
+		}
+		grouped[label] = append(grouped[label], sample)
+	}
+
+	folded := make(map[string]float64, len(grouped))
+	for label, group := range grouped {
+		folded[label] = aggregate(group)
+	}
+	return folded
+}
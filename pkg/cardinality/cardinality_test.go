@@ -0,0 +1,62 @@
+package cardinality
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	budget := Budget{MaxValueLength: 8}
+	for value, expected := range map[string]string{
+		"orders":         "orders",
+		"line\nbreak":    "linebrea",
+		"héééé":          "hééé",
+		"invalid\xffutf": "invalid",
+	} {
+		if sanitized := budget.Sanitize(value); sanitized != expected {
+			t.Errorf("%q: expected %q, got %q", value, expected, sanitized)
+		}
+	}
+
+	if sanitized := (Budget{}).Sanitize(strings.Repeat("a", 300)); len(sanitized) != 300 {
+		t.Errorf("Expected no truncation without a limit, got %d bytes", len(sanitized))
+	}
+}
+
+func TestSelect(t *testing.T) {
+	ranks := map[string]float64{"orders": 5, "users": 9, "audit_2020": 7, "audit_2021": 7, "tmp_import": 100}
+	budget := Budget{MaxSeries: 3, Allowed: []string{"orders", "users", "audit_*"}}
+
+	selected := budget.Select(ranks)
+	if len(selected) != 3 || !selected["users"] || !selected["audit_2020"] || !selected["audit_2021"] {
+		t.Errorf("Expected the 3 highest ranked allowed values, got %v", selected)
+	}
+
+	if everything := (Budget{}).Select(ranks); len(everything) != len(ranks) {
+		t.Errorf("Expected every value without a budget, got %v", everything)
+	}
+}
+
+func TestFold(t *testing.T) {
+	budget := Budget{MaxValueLength: 6}
+	samples := map[string]float64{"orders": 1, "users": 4, "events_a": 2, "events_b": 3}
+	selected := map[string]bool{"orders": true, "events_a": true, "events_b": true}
+
+	folded := budget.Fold(samples, selected, Sum)
+	expected := map[string]float64{"orders": 1, "events": 5, Other: 4}
+	if len(folded) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, folded)
+	}
+	for label, value := range expected {
+		if folded[label] != value {
+			t.Errorf("%s: expected %v, got %v", label, value, folded[label])
+		}
+	}
+
+	if max := budget.Fold(samples, nil, Max); max[Other] != 4 {
+		t.Errorf("Expected the largest sample, got %v", max)
+	}
+	if min := budget.Fold(samples, nil, Min); min[Other] != 1 {
+		t.Errorf("Expected the smallest sample, got %v", min)
+	}
+}
@@ -0,0 +1,173 @@
+// Package chatops verifies the slash commands which Slack delivers and
+// parses them into the operations they ask for.
+package chatops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Verbs of the commands
+const (
+	// VerbRotate rotates the credentials of a database
+	VerbRotate = "rotate"
+
+	// VerbStatus shows the migration status of a database
+	VerbStatus = "status"
+
+	// VerbApprove approves the pending plan of a database's migration
+	VerbApprove = "approve"
+)
+
+const (
+	// maxRequestAge rejects replayed commands, as Slack recommends
+	maxRequestAge = 5 * time.Minute
+
+	// maxBodyBytes bounds how much of a command is read
+	maxBodyBytes = 64 * 1024
+
+	// minPlanHashLength is how much of a plan's hash must be given to
+	// approve it
+	minPlanHashLength = 12
+)
+
+// Usage describes the commands which are understood
+const Usage = "Commands:\n" +
+	"  rotate credentials for db <namespace>/<name>\n" +
+	"  show migration status for db <namespace>/<name>\n" +
+	"  approve migration <migration> plan <hash> for db <namespace>/<name>"
+
+// Command is a parsed command
+type Command struct {
+	Verb      string
+	Namespace string
+	Name      string
+
+	// Migration is the migration whose plan is approved
+	Migration string
+
+	// PlanHash is the hash, or a prefix of it, of the plan which is approved
+	PlanHash string
+}
+
+// SlackCommand is a slash command as delivered by Slack
+type SlackCommand struct {
+	TeamID   string
+	UserID   string
+	UserName string
+	Text     string
+}
+
+// Parse reads the text of a command. Only the fixed words are matched
+// regardless of case, the migration, plan hash and database are kept as they
+// were typed.
+func Parse(text string) (Command, error) {
+	words := strings.Fields(text)
+
+	var command Command
+	var rest []string
+	switch {
+	case hasPrefix(words, "rotate", "credentials", "for", "db"):
+		command.Verb, rest = VerbRotate, words[4:]
+	case hasPrefix(words, "show", "migration", "status", "for", "db"):
+		command.Verb, rest = VerbStatus, words[5:]
+	case len(words) >= 7 && hasPrefix(words, "approve", "migration") && hasPrefix(words[3:], "plan") && hasPrefix(words[5:], "for", "db"):
+		command.Verb, command.Migration, command.PlanHash, rest = VerbApprove, words[2], strings.ToLower(words[4]), words[7:]
+		if len(command.PlanHash) < minPlanHashLength {
+			return Command{}, fmt.Errorf("Expected at least %d characters of the plan's hash, not %s", minPlanHashLength, words[4])
+		}
+	default:
+		return Command{}, fmt.Errorf("Unknown command %q", text)
+	}
+
+	if len(rest) != 1 {
+		return Command{}, fmt.Errorf("Expected a single database as <namespace>/<name>")
+	}
+	parts := strings.Split(rest[0], "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Command{}, fmt.Errorf("Expected the database as <namespace>/<name>, not %s", rest[0])
+	}
+	command.Namespace, command.Name = parts[0], parts[1]
+	return command, nil
+}
+
+func hasPrefix(words []string, prefix ...string) bool {
+	if len(words) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if !strings.EqualFold(words[i], prefix[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifySlack checks that the request was signed with the app's signing
+// secret no longer than five minutes before now, and returns its command
+func VerifySlack(signingSecret []byte, req *http.Request, now time.Time) (SlackCommand, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, req.Body, maxBodyBytes))
+	if err != nil {
+		return SlackCommand{}, fmt.Errorf("Unable to read command: %w", err)
+	}
+
+	timestamp := req.Header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return SlackCommand{}, fmt.Errorf("Unable to parse request timestamp: %w", err)
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return SlackCommand{}, fmt.Errorf("Request timestamp is %s away from now", age)
+	}
+
+	presented, err := hex.DecodeString(strings.TrimPrefix(req.Header.Get("X-Slack-Signature"), "v0="))
+	if err != nil {
+		return SlackCommand{}, fmt.Errorf("Unable to decode request signature: %w", err)
+	}
+	if !hmac.Equal(presented, slackSignature(signingSecret, timestamp, body)) {
+		return SlackCommand{}, fmt.Errorf("Request signature does not match")
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return SlackCommand{}, fmt.Errorf("Unable to parse command: %w", err)
+	}
+	return SlackCommand{
+		TeamID:   form.Get("team_id"),
+		UserID:   form.Get("user_id"),
+		UserName: form.Get("user_name"),
+		Text:     form.Get("text"),
+	}, nil
+}
+
+func slackSignature(signingSecret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, signingSecret)
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+type slackResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// RespondSlack replies to a command. Replies which are only seen by the user
+// who sent the command are ephemeral, the others are posted to the channel.
+func RespondSlack(w http.ResponseWriter, ephemeral bool, text string) {
+	response := slackResponse{ResponseType: "in_channel", Text: text}
+	if ephemeral {
+		response.ResponseType = "ephemeral"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,86 @@
+package chatops
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for text, expected := range map[string]Command{
+		"rotate credentials for db quay/quayio":                                     {Verb: VerbRotate, Namespace: "quay", Name: "quayio"},
+		"  Show Migration Status for db quay/quayio ":                               {Verb: VerbStatus, Namespace: "quay", Name: "quayio"},
+		"approve migration v3-add-tags plan 0123456789ab for db quay/quayio":        {Verb: VerbApprove, Namespace: "quay", Name: "quayio", Migration: "v3-add-tags", PlanHash: "0123456789ab"},
+		"Approve Migration V3-Add-Tags Plan 0123456789ABCDEF for db   quay/quayio ": {Verb: VerbApprove, Namespace: "quay", Name: "quayio", Migration: "V3-Add-Tags", PlanHash: "0123456789abcdef"},
+	} {
+		command, err := Parse(text)
+		if err != nil {
+			t.Errorf("%q: %v", text, err)
+			continue
+		}
+		if command != expected {
+			t.Errorf("%q: expected %+v, got %+v", text, expected, command)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"drop db quay/quayio",
+		"rotate credentials for db quayio",
+		"rotate credentials for db quay/",
+		"rotate credentials for db quay/quayio quay/other",
+		"approve migration for db quay/quayio",
+		"approve migration v3-add-tags for db quay/quayio",
+		"approve migration v3-add-tags plan 0123 for db quay/quayio",
+	} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("Expected an error parsing %q", text)
+		}
+	}
+}
+
+func signedRequest(secret, timestamp, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/v1/chatops/slack", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(slackSignature([]byte(secret), timestamp, []byte(body))))
+	return req
+}
+
+func TestVerifySlack(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := url.Values{
+		"team_id":   {"T0001"},
+		"user_id":   {"U2147483697"},
+		"user_name": {"jane"},
+		"text":      {"show migration status for db quay/quayio"},
+	}.Encode()
+
+	command, err := VerifySlack([]byte("signing-secret"), signedRequest("signing-secret", timestamp, body), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if command.UserID != "U2147483697" || command.Text != "show migration status for db quay/quayio" {
+		t.Errorf("Unexpected command %+v", command)
+	}
+
+	if _, err := VerifySlack([]byte("signing-secret"), signedRequest("other-secret", timestamp, body), now); err == nil {
+		t.Error("Expected a request signed with another secret to be rejected")
+	}
+	if _, err := VerifySlack([]byte("signing-secret"), signedRequest("signing-secret", timestamp, body), now.Add(10*time.Minute)); err == nil {
+		t.Error("Expected an old request to be rejected")
+	}
+
+	tampered := signedRequest("signing-secret", timestamp, body)
+	tampered.Body = httptest.NewRequest("POST", "/", strings.NewReader(body+"&text=rotate")).Body
+	if _, err := VerifySlack([]byte("signing-secret"), tampered, now); err == nil {
+		t.Error("Expected a tampered request to be rejected")
+	}
+}
@@ -0,0 +1,266 @@
+// Package client provides typed access to the operator's custom resources,
+// for other controllers which want to read ManagedDatabases or react to their
+// status without going through unstructured objects.
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+// NewScheme returns a scheme which contains the operator's resources
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := dba.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("Unable to register operator resources: %w", err)
+	}
+	return scheme, nil
+}
+
+// Clientset reads and writes the operator's resources through the API server
+type Clientset struct {
+	client crclient.Client
+}
+
+// New creates a Clientset which connects to the API server of config
+func New(config *rest.Config) (*Clientset, error) {
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, err
+	}
+	client, err := crclient.New(config, crclient.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create client: %w", err)
+	}
+	return NewForClient(client), nil
+}
+
+// NewForClient creates a Clientset from an existing client, whose scheme
+// has to contain the operator's resources
+func NewForClient(client crclient.Client) *Clientset {
+	return &Clientset{client: client}
+}
+
+// ManagedDatabases returns a client for the ManagedDatabases in namespace
+func (cs *Clientset) ManagedDatabases(namespace string) ManagedDatabaseClient {
+	return ManagedDatabaseClient{ManagedDatabaseLister{cs.client, namespace}, cs.client}
+}
+
+// DatabaseMigrations returns a client for the DatabaseMigrations in namespace
+func (cs *Clientset) DatabaseMigrations(namespace string) DatabaseMigrationClient {
+	return DatabaseMigrationClient{DatabaseMigrationLister{cs.client, namespace}, cs.client}
+}
+
+// DatabaseCredentialRequests returns a client for the
+// DatabaseCredentialRequests in namespace
+func (cs *Clientset) DatabaseCredentialRequests(namespace string) DatabaseCredentialRequestClient {
+	return DatabaseCredentialRequestClient{DatabaseCredentialRequestLister{cs.client, namespace}, cs.client}
+}
+
+// DatabaseOperations returns a client for the DatabaseOperations in namespace
+func (cs *Clientset) DatabaseOperations(namespace string) DatabaseOperationClient {
+	return DatabaseOperationClient{DatabaseOperationLister{cs.client, namespace}, cs.client}
+}
+
+// ManagedDatabaseLister reads ManagedDatabases from the API server or from
+// an informer's cache
+type ManagedDatabaseLister struct {
+	reader    crclient.Reader
+	namespace string
+}
+
+// Get returns the ManagedDatabase called name
+func (l ManagedDatabaseLister) Get(ctx context.Context, name string) (*dba.ManagedDatabase, error) {
+	var db dba.ManagedDatabase
+	if err := l.reader.Get(ctx, types.NamespacedName{Namespace: l.namespace, Name: name}, &db); err != nil {
+		return nil, err
+	}
+	return &db, nil
+}
+
+// List returns the ManagedDatabases which match the options
+func (l ManagedDatabaseLister) List(ctx context.Context, opts ...crclient.ListOptionFunc) ([]dba.ManagedDatabase, error) {
+	var list dba.ManagedDatabaseList
+	if err := l.reader.List(ctx, &list, append(opts, crclient.InNamespace(l.namespace))...); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// ManagedDatabaseClient reads and writes ManagedDatabases
+type ManagedDatabaseClient struct {
+	ManagedDatabaseLister
+	writer crclient.Writer
+}
+
+// Create creates the ManagedDatabase in the client's namespace
+func (c ManagedDatabaseClient) Create(ctx context.Context, db *dba.ManagedDatabase) error {
+	db.Namespace = c.namespace
+	return c.writer.Create(ctx, db)
+}
+
+// Update replaces the spec and metadata of the ManagedDatabase
+func (c ManagedDatabaseClient) Update(ctx context.Context, db *dba.ManagedDatabase) error {
+	db.Namespace = c.namespace
+	return c.writer.Update(ctx, db)
+}
+
+// Delete deletes the ManagedDatabase
+func (c ManagedDatabaseClient) Delete(ctx context.Context, db *dba.ManagedDatabase) error {
+	db.Namespace = c.namespace
+	return c.writer.Delete(ctx, db)
+}
+
+// DatabaseMigrationLister reads DatabaseMigrations from the API server or
+// from an informer's cache
+type DatabaseMigrationLister struct {
+	reader    crclient.Reader
+	namespace string
+}
+
+// Get returns the DatabaseMigration called name
+func (l DatabaseMigrationLister) Get(ctx context.Context, name string) (*dba.DatabaseMigration, error) {
+	var migration dba.DatabaseMigration
+	if err := l.reader.Get(ctx, types.NamespacedName{Namespace: l.namespace, Name: name}, &migration); err != nil {
+		return nil, err
+	}
+	return &migration, nil
+}
+
+// List returns the DatabaseMigrations which match the options
+func (l DatabaseMigrationLister) List(ctx context.Context, opts ...crclient.ListOptionFunc) ([]dba.DatabaseMigration, error) {
+	var list dba.DatabaseMigrationList
+	if err := l.reader.List(ctx, &list, append(opts, crclient.InNamespace(l.namespace))...); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// DatabaseMigrationClient reads and writes DatabaseMigrations
+type DatabaseMigrationClient struct {
+	DatabaseMigrationLister
+	writer crclient.Writer
+}
+
+// Create creates the DatabaseMigration in the client's namespace
+func (c DatabaseMigrationClient) Create(ctx context.Context, migration *dba.DatabaseMigration) error {
+	migration.Namespace = c.namespace
+	return c.writer.Create(ctx, migration)
+}
+
+// Update replaces the spec and metadata of the DatabaseMigration
+func (c DatabaseMigrationClient) Update(ctx context.Context, migration *dba.DatabaseMigration) error {
+	migration.Namespace = c.namespace
+	return c.writer.Update(ctx, migration)
+}
+
+// Delete deletes the DatabaseMigration
+func (c DatabaseMigrationClient) Delete(ctx context.Context, migration *dba.DatabaseMigration) error {
+	migration.Namespace = c.namespace
+	return c.writer.Delete(ctx, migration)
+}
+
+// DatabaseCredentialRequestLister reads DatabaseCredentialRequests from the
+// API server or from an informer's cache
+type DatabaseCredentialRequestLister struct {
+	reader    crclient.Reader
+	namespace string
+}
+
+// Get returns the DatabaseCredentialRequest called name
+func (l DatabaseCredentialRequestLister) Get(ctx context.Context, name string) (*dba.DatabaseCredentialRequest, error) {
+	var request dba.DatabaseCredentialRequest
+	if err := l.reader.Get(ctx, types.NamespacedName{Namespace: l.namespace, Name: name}, &request); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// List returns the DatabaseCredentialRequests which match the options
+func (l DatabaseCredentialRequestLister) List(ctx context.Context, opts ...crclient.ListOptionFunc) ([]dba.DatabaseCredentialRequest, error) {
+	var list dba.DatabaseCredentialRequestList
+	if err := l.reader.List(ctx, &list, append(opts, crclient.InNamespace(l.namespace))...); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// DatabaseCredentialRequestClient reads and writes DatabaseCredentialRequests
+type DatabaseCredentialRequestClient struct {
+	DatabaseCredentialRequestLister
+	writer crclient.Writer
+}
+
+// Create creates the DatabaseCredentialRequest in the client's namespace
+func (c DatabaseCredentialRequestClient) Create(ctx context.Context, request *dba.DatabaseCredentialRequest) error {
+	request.Namespace = c.namespace
+	return c.writer.Create(ctx, request)
+}
+
+// Update replaces the spec and metadata of the DatabaseCredentialRequest
+func (c DatabaseCredentialRequestClient) Update(ctx context.Context, request *dba.DatabaseCredentialRequest) error {
+	request.Namespace = c.namespace
+	return c.writer.Update(ctx, request)
+}
+
+// Delete deletes the DatabaseCredentialRequest
+func (c DatabaseCredentialRequestClient) Delete(ctx context.Context, request *dba.DatabaseCredentialRequest) error {
+	request.Namespace = c.namespace
+	return c.writer.Delete(ctx, request)
+}
+
+// DatabaseOperationLister reads DatabaseOperations from the API server or
+// from an informer's cache
+type DatabaseOperationLister struct {
+	reader    crclient.Reader
+	namespace string
+}
+
+// Get returns the DatabaseOperation called name
+func (l DatabaseOperationLister) Get(ctx context.Context, name string) (*dba.DatabaseOperation, error) {
+	var operation dba.DatabaseOperation
+	if err := l.reader.Get(ctx, types.NamespacedName{Namespace: l.namespace, Name: name}, &operation); err != nil {
+		return nil, err
+	}
+	return &operation, nil
+}
+
+// List returns the DatabaseOperations which match the options
+func (l DatabaseOperationLister) List(ctx context.Context, opts ...crclient.ListOptionFunc) ([]dba.DatabaseOperation, error) {
+	var list dba.DatabaseOperationList
+	if err := l.reader.List(ctx, &list, append(opts, crclient.InNamespace(l.namespace))...); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// DatabaseOperationClient reads and writes DatabaseOperations
+type DatabaseOperationClient struct {
+	DatabaseOperationLister
+	writer crclient.Writer
+}
+
+// Create creates the DatabaseOperation in the client's namespace
+func (c DatabaseOperationClient) Create(ctx context.Context, operation *dba.DatabaseOperation) error {
+	operation.Namespace = c.namespace
+	return c.writer.Create(ctx, operation)
+}
+
+// Update replaces the spec and metadata of the DatabaseOperation
+func (c DatabaseOperationClient) Update(ctx context.Context, operation *dba.DatabaseOperation) error {
+	operation.Namespace = c.namespace
+	return c.writer.Update(ctx, operation)
+}
+
+// Delete deletes the DatabaseOperation
+func (c DatabaseOperationClient) Delete(ctx context.Context, operation *dba.DatabaseOperation) error {
+	operation.Namespace = c.namespace
+	return c.writer.Delete(ctx, operation)
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+func newTestClientset(t *testing.T) *Clientset {
+	scheme, err := NewScheme()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return NewForClient(fake.NewFakeClientWithScheme(scheme,
+		&dba.ManagedDatabase{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "primary", Labels: map[string]string{"tier": "prod"}}},
+		&dba.ManagedDatabase{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "staging"}},
+		&dba.ManagedDatabase{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "primary"}},
+	))
+}
+
+func TestManagedDatabases(t *testing.T) {
+	ctx := context.Background()
+	databases := newTestClientset(t).ManagedDatabases("app")
+
+	db, err := databases.Get(ctx, "primary")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if db.Namespace != "app" || db.Name != "primary" {
+		t.Errorf("Expected app/primary, got %s/%s", db.Namespace, db.Name)
+	}
+
+	all, err := databases.List(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("Expected the 2 databases in app, got %d", len(all))
+	}
+
+	prod, err := databases.List(ctx, crclient.MatchingLabels(map[string]string{"tier": "prod"}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(prod) != 1 || prod[0].Name != "primary" {
+		t.Errorf("Expected only primary to match, got %v", prod)
+	}
+
+	created := &dba.ManagedDatabase{ObjectMeta: metav1.ObjectMeta{Name: "analytics"}}
+	if err := databases.Create(ctx, created); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := databases.Get(ctx, "analytics"); err != nil {
+		t.Errorf("Expected the created database in app: %v", err)
+	}
+}
+
+func TestAtSchemaVersion(t *testing.T) {
+	db := &dba.ManagedDatabase{Status: dba.ManagedDatabaseStatus{CurrentVersion: "v2"}}
+	if IsReady(db) || AtSchemaVersion(db, "v2") {
+		t.Errorf("Expected a database without a Ready condition not to be ready")
+	}
+
+	db.Status.Conditions = []dba.ManagedDatabaseCondition{{Type: dba.ConditionReady, Status: corev1.ConditionFalse}}
+	if AtSchemaVersion(db, "v2") {
+		t.Errorf("Expected a database which isn't ready not to be at v2")
+	}
+
+	db.Status.Conditions[0].Status = corev1.ConditionTrue
+	if !AtSchemaVersion(db, "v2") {
+		t.Errorf("Expected a ready database to be at v2")
+	}
+	if AtSchemaVersion(db, "v3") {
+		t.Errorf("Expected a ready database not to be at v3")
+	}
+}
+
+func TestSafeToRoll(t *testing.T) {
+	db := &dba.ManagedDatabase{Status: dba.ManagedDatabaseStatus{AppVersions: []dba.AppVersionStatus{
+		{Version: "1.2.0", SchemaVersion: "v2", Safe: true},
+		{Version: "1.3.0", SchemaVersion: "v3", Reason: "Schema version v3 is not live, the database is at v2"},
+	}}}
+
+	if safe, _ := SafeToRoll(db, "1.2.0"); !safe {
+		t.Errorf("Expected 1.2.0 to be safe")
+	}
+	if safe, reason := SafeToRoll(db, "1.3.0"); safe || reason == "" {
+		t.Errorf("Expected 1.3.0 not to be safe, with a reason")
+	}
+	if safe, _ := SafeToRoll(db, "2.0.0"); safe {
+		t.Errorf("Expected an unknown version not to be safe")
+	}
+}
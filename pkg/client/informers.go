@@ -0,0 +1,112 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+// Informers watches the operator's resources and keeps them in a local cache,
+// which the listers read from
+type Informers struct {
+	cache cache.Cache
+}
+
+// NewInformers creates Informers for the resources in namespace, or in every
+// namespace when it is empty. Nothing is watched until Start is called.
+func NewInformers(config *rest.Config, namespace string, resync time.Duration) (*Informers, error) {
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, err
+	}
+	informerCache, err := cache.New(config, cache.Options{Scheme: scheme, Namespace: namespace, Resync: &resync})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create informer cache: %w", err)
+	}
+	return &Informers{cache: informerCache}, nil
+}
+
+// Start watches the resources which were asked for until stop is closed, it
+// blocks
+func (inf *Informers) Start(stop <-chan struct{}) error {
+	return inf.cache.Start(stop)
+}
+
+// WaitForCacheSync waits until the cache contains every watched resource,
+// it returns false if stop was closed first
+func (inf *Informers) WaitForCacheSync(stop <-chan struct{}) bool {
+	return inf.cache.WaitForCacheSync(stop)
+}
+
+// ManagedDatabases returns a lister which reads ManagedDatabases in
+// namespace from the cache
+func (inf *Informers) ManagedDatabases(namespace string) ManagedDatabaseLister {
+	return ManagedDatabaseLister{inf.cache, namespace}
+}
+
+// DatabaseMigrations returns a lister which reads DatabaseMigrations in
+// namespace from the cache
+func (inf *Informers) DatabaseMigrations(namespace string) DatabaseMigrationLister {
+	return DatabaseMigrationLister{inf.cache, namespace}
+}
+
+// DatabaseCredentialRequests returns a lister which reads
+// DatabaseCredentialRequests in namespace from the cache
+func (inf *Informers) DatabaseCredentialRequests(namespace string) DatabaseCredentialRequestLister {
+	return DatabaseCredentialRequestLister{inf.cache, namespace}
+}
+
+// DatabaseOperations returns a lister which reads DatabaseOperations in
+// namespace from the cache
+func (inf *Informers) DatabaseOperations(namespace string) DatabaseOperationLister {
+	return DatabaseOperationLister{inf.cache, namespace}
+}
+
+// ManagedDatabaseHandler is notified of changes to ManagedDatabases, any of
+// the functions may be nil
+type ManagedDatabaseHandler struct {
+	OnAdd    func(db *dba.ManagedDatabase)
+	OnUpdate func(old, db *dba.ManagedDatabase)
+	OnDelete func(db *dba.ManagedDatabase)
+}
+
+func (h ManagedDatabaseHandler) resourceEventHandler() toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if db, ok := obj.(*dba.ManagedDatabase); ok && h.OnAdd != nil {
+				h.OnAdd(db)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			old, oldOk := oldObj.(*dba.ManagedDatabase)
+			db, ok := newObj.(*dba.ManagedDatabase)
+			if oldOk && ok && h.OnUpdate != nil {
+				h.OnUpdate(old, db)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if db, ok := obj.(*dba.ManagedDatabase); ok && h.OnDelete != nil {
+				h.OnDelete(db)
+			}
+		},
+	}
+}
+
+// WatchManagedDatabases calls the handler whenever a ManagedDatabase is
+// added, changed or deleted, including changes to its status
+func (inf *Informers) WatchManagedDatabases(handler ManagedDatabaseHandler) error {
+	informer, err := inf.cache.GetInformer(&dba.ManagedDatabase{})
+	if err != nil {
+		return fmt.Errorf("Unable to watch ManagedDatabases: %w", err)
+	}
+	informer.AddEventHandler(handler.resourceEventHandler())
+	return nil
+}
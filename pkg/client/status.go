@@ -0,0 +1,46 @@
+package client
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	dba "github.com/app-sre/dba-operator/api/v1alpha1"
+)
+
+// Condition returns the condition of the ManagedDatabase with conditionType,
+// or nil if the operator hasn't reported it yet
+func Condition(db *dba.ManagedDatabase, conditionType string) *dba.ManagedDatabaseCondition {
+	for i := range db.Status.Conditions {
+		if db.Status.Conditions[i].Type == conditionType {
+			return &db.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsReady returns true if the last reconcile of the ManagedDatabase
+// completed without an error
+func IsReady(db *dba.ManagedDatabase) bool {
+	ready := Condition(db, dba.ConditionReady)
+	return ready != nil && ready.Status == corev1.ConditionTrue
+}
+
+// AtSchemaVersion returns true once the ManagedDatabase is ready and its
+// schema has been migrated to version, which is what deployments of an app
+// that needs the version should wait for
+func AtSchemaVersion(db *dba.ManagedDatabase, version string) bool {
+	return IsReady(db) && db.Status.CurrentVersion == version
+}
+
+// SafeToRoll returns whether appVersion, one of the versions listed in the
+// ManagedDatabase's spec.appVersions, can be rolled out against the current
+// schema, and the reason when it can't
+func SafeToRoll(db *dba.ManagedDatabase, appVersion string) (bool, string) {
+	for _, status := range db.Status.AppVersions {
+		if status.Version == appVersion {
+			return status.Safe, status.Reason
+		}
+	}
+	return false, fmt.Sprintf("App version %s has not been evaluated", appVersion)
+}
@@ -0,0 +1,192 @@
+package cloudevents
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	testTopic   = "arn:aws:sns:us-east-1:123456789012:rds-events"
+	testCertURL = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem"
+)
+
+func TestParse(t *testing.T) {
+	subscription := `{"Event Source":"db-instance","Event Time":"2019-10-22 14:24:55.599","Source ID":"quayio",` +
+		`"Event ID":"http://docs.amazonwebservices.com/AmazonRDS/latest/UserGuide/USER_Events.html#RDS-EVENT-0049",` +
+		`"Event Message":"Multi-AZ instance failover completed"}`
+	event, err := Parse(subscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Identifier != "quayio" || event.ID != "RDS-EVENT-0049" || !event.Topology() {
+		t.Errorf("Expected a failover of quayio, got %+v", event)
+	}
+
+	bridged := `{"source":"aws.rds","detail-type":"RDS DB Cluster Event","detail":{"SourceType":"CLUSTER",` +
+		`"SourceIdentifier":"quay-cluster","EventID":"RDS-EVENT-9999","EventCategories":["failover"],"Message":"Completed failover"}}`
+	event, err = Parse(bridged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Identifier != "quay-cluster" || !event.Topology() {
+		t.Errorf("Expected a failover of quay-cluster, got %+v", event)
+	}
+
+	backup := `{"Event Source":"db-instance","Source ID":"quayio","Event ID":"#RDS-EVENT-0002","Event Message":"Finished DB instance backup"}`
+	if event, err = Parse(backup); err != nil || event.Topology() {
+		t.Errorf("Expected a backup not to change the topology, got %+v (%v)", event, err)
+	}
+
+	for _, invalid := range []string{"not json", `{"Event ID":"#RDS-EVENT-0049"}`, `{"source":"aws.ec2","detail":{}}`} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+type testSigner struct {
+	key  *rsa.PrivateKey
+	cert []byte
+}
+
+func newTestSigner(t *testing.T) testSigner {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{key: key, cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (s testSigner) sign(t *testing.T, message envelope) envelope {
+	message.SignatureVersion = "2"
+	message.SigningCertURL = testCertURL
+	hashed := sha256.Sum256([]byte(canonical(message)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	message.Signature = base64.StdEncoding.EncodeToString(signature)
+	return message
+}
+
+func deliver(t *testing.T, receiver *Receiver, message envelope) int {
+	body, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	receiver.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body))))
+	return recorder.Code
+}
+
+func TestReceiver(t *testing.T) {
+	signer := newTestSigner(t)
+
+	var received []Event
+	var fetched []string
+	receiver := NewReceiver([]string{testTopic}, logf.NullLogger{}, func(event Event) {
+		received = append(received, event)
+	})
+	receiver.fetch = func(url string) ([]byte, error) {
+		fetched = append(fetched, url)
+		if url == testCertURL {
+			return signer.cert, nil
+		}
+		return nil, nil
+	}
+
+	subscribeURL := "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription&Token=abc"
+	confirmation := signer.sign(t, envelope{
+		Type:         "SubscriptionConfirmation",
+		MessageID:    "1",
+		Token:        "abc",
+		TopicArn:     testTopic,
+		Message:      "You have chosen to subscribe",
+		SubscribeURL: subscribeURL,
+		Timestamp:    "2019-10-22T14:24:55.599Z",
+	})
+	if code := deliver(t, receiver, confirmation); code != http.StatusOK {
+		t.Errorf("Expected the subscription to be confirmed, got %d", code)
+	}
+	if len(fetched) != 2 || fetched[1] != subscribeURL {
+		t.Errorf("Expected the subscribe URL to be visited, got %v", fetched)
+	}
+
+	notification := signer.sign(t, envelope{
+		Type:      "Notification",
+		MessageID: "2",
+		TopicArn:  testTopic,
+		Subject:   "RDS Notification Message",
+		Message:   `{"Event Source":"db-instance","Source ID":"quayio","Event ID":"#RDS-EVENT-0049","Event Message":"Failover completed"}`,
+		Timestamp: "2019-10-22T14:25:55.599Z",
+	})
+	if code := deliver(t, receiver, notification); code != http.StatusOK {
+		t.Errorf("Expected the notification to be accepted, got %d", code)
+	}
+	if len(received) != 1 || received[0].Identifier != "quayio" {
+		t.Errorf("Expected the event to be delivered, got %v", received)
+	}
+	if len(fetched) != 2 {
+		t.Errorf("Expected the signing certificate to be cached, got %v", fetched)
+	}
+
+	tampered := notification
+	tampered.Message = strings.Replace(tampered.Message, "quayio", "other", 1)
+	if code := deliver(t, receiver, tampered); code != http.StatusForbidden {
+		t.Errorf("Expected a tampered message to be rejected, got %d", code)
+	}
+
+	unknownTopic := signer.sign(t, envelope{Type: "Notification", MessageID: "3", TopicArn: testTopic + "-other", Message: notification.Message})
+	if code := deliver(t, receiver, unknownTopic); code != http.StatusForbidden {
+		t.Errorf("Expected a message from an unknown topic to be rejected, got %d", code)
+	}
+
+	foreignCert := notification
+	foreignCert.SigningCertURL = "https://example.com/sns.pem"
+	if code := deliver(t, receiver, foreignCert); code != http.StatusForbidden {
+		t.Errorf("Expected a certificate outside of SNS to be rejected, got %d", code)
+	}
+
+	if len(received) != 1 {
+		t.Errorf("Expected rejected messages not to be delivered, got %v", received)
+	}
+}
+
+func TestTrusted(t *testing.T) {
+	for rawURL, expected := range map[string]bool{
+		testCertURL: true,
+		"https://sns.cn-north-1.amazonaws.com.cn/cert.pem":     true,
+		"http://sns.us-east-1.amazonaws.com/cert.pem":          false,
+		"https://sns.us-east-1.amazonaws.com.example.com/cert": false,
+		"https://evil.com/sns.us-east-1.amazonaws.com":         false,
+	} {
+		if err := trusted(rawURL); (err == nil) != expected {
+			t.Errorf("%s: expected trusted to be %v, got %v", rawURL, expected, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package cloudevents receives the events which cloud providers publish
+// about the instances and clusters serving managed databases, so that
+// failovers are acted upon as soon as they happen.
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Event is a single event about a database instance or cluster
+type Event struct {
+	// SourceType is the kind of resource the event is about, e.g.
+	// db-instance or db-cluster
+	SourceType string
+
+	// Identifier names the instance or cluster
+	Identifier string
+
+	// ID identifies the kind of event, e.g. RDS-EVENT-0049
+	ID string
+
+	// Categories are only included by EventBridge, e.g. failover
+	Categories []string
+
+	Message string
+}
+
+// topologyEventIDs contains the RDS events after which the endpoints of an
+// instance or cluster may reach a different server
+var topologyEventIDs = map[string]bool{
+	"RDS-EVENT-0004": true, // instance shut down
+	"RDS-EVENT-0006": true, // instance restarted
+	"RDS-EVENT-0013": true, // Multi-AZ failover started
+	"RDS-EVENT-0015": true, // Multi-AZ failover to standby complete
+	"RDS-EVENT-0049": true, // Multi-AZ failover complete
+	"RDS-EVENT-0065": true, // recovered from partial failover
+	"RDS-EVENT-0069": true, // cluster failover failed
+	"RDS-EVENT-0070": true, // promoting previous primary
+	"RDS-EVENT-0071": true, // cluster failover complete
+	"RDS-EVENT-0072": true, // cluster failover within the AZ started
+	"RDS-EVENT-0073": true, // cluster failover to another AZ started
+}
+
+// topologyCategories contains the EventBridge categories of the events
+// which may change the topology
+var topologyCategories = map[string]bool{
+	"availability": true,
+	"failover":     true,
+	"failure":      true,
+	"recovery":     true,
+}
+
+// Topology returns whether the event may have changed which server the
+// endpoints of the instance or cluster reach
+func (e Event) Topology() bool {
+	if topologyEventIDs[e.ID] {
+		return true
+	}
+	for _, category := range e.Categories {
+		if topologyCategories[strings.ToLower(category)] {
+			return true
+		}
+	}
+	return false
+}
+
+// rdsNotification is the message RDS event subscriptions publish to SNS
+type rdsNotification struct {
+	EventSource string `json:"Event Source"`
+	SourceID    string `json:"Source ID"`
+	EventID     string `json:"Event ID"`
+	Message     string `json:"Event Message"`
+}
+
+// eventBridgeEvent is the message EventBridge rules publish for RDS events
+type eventBridgeEvent struct {
+	Source string `json:"source"`
+	Detail *struct {
+		SourceType       string   `json:"SourceType"`
+		SourceIdentifier string   `json:"SourceIdentifier"`
+		EventID          string   `json:"EventID"`
+		EventCategories  []string `json:"EventCategories"`
+		Message          string   `json:"Message"`
+	} `json:"detail"`
+}
+
+// Parse reads an RDS event, either as published to SNS by an RDS event
+// subscription or by an EventBridge rule
+func Parse(message string) (Event, error) {
+	var bridged eventBridgeEvent
+	if err := json.Unmarshal([]byte(message), &bridged); err != nil {
+		return Event{}, fmt.Errorf("Unable to parse event: %w", err)
+	}
+	if bridged.Detail != nil {
+		if bridged.Source != "aws.rds" {
+			return Event{}, fmt.Errorf("Unsupported event source: %s", bridged.Source)
+		}
+		return validate(Event{
+			SourceType: bridged.Detail.SourceType,
+			Identifier: bridged.Detail.SourceIdentifier,
+			ID:         bridged.Detail.EventID,
+			Categories: bridged.Detail.EventCategories,
+			Message:    bridged.Detail.Message,
+		})
+	}
+
+	var notification rdsNotification
+	if err := json.Unmarshal([]byte(message), &notification); err != nil {
+		return Event{}, fmt.Errorf("Unable to parse event: %w", err)
+	}
+
+	// The event ID is a link to the documentation of the event
+	id := notification.EventID
+	if anchor := strings.LastIndex(id, "#"); anchor >= 0 {
+		id = id[anchor+1:]
+	}
+	return validate(Event{
+		SourceType: notification.EventSource,
+		Identifier: notification.SourceID,
+		ID:         id,
+		Message:    notification.Message,
+	})
+}
+
+func validate(event Event) (Event, error) {
+	if event.Identifier == "" {
+		return Event{}, fmt.Errorf("Event %s doesn't identify its source", event.ID)
+	}
+	return event, nil
+}
@@ -0,0 +1,247 @@
+package cloudevents
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// maxMessageBytes is the largest message SNS delivers, with room for the
+// envelope
+const maxMessageBytes = 300 * 1024
+
+// snsHost matches the hosts SNS serves its signing certificates and
+// subscription confirmations from
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// envelope is the JSON body of every message SNS delivers over HTTP
+type envelope struct {
+	Type             string
+	MessageID        string `json:"MessageId"`
+	Token            string
+	TopicArn         string
+	Subject          string
+	Message          string
+	Timestamp        string
+	SignatureVersion string
+	Signature        string
+	SigningCertURL   string
+	SubscribeURL     string
+}
+
+// Receiver is the HTTP endpoint of the SNS subscriptions which deliver
+// events. It confirms the subscriptions to the allowed topics, and passes
+// every event they deliver to the sink once its signature is verified.
+type Receiver struct {
+	topics map[string]bool
+	log    logr.Logger
+	sink   func(Event)
+
+	// fetch retrieves the signing certificates and confirms subscriptions,
+	// only ever from the hosts of SNS
+	fetch func(url string) ([]byte, error)
+
+	mutex sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+// NewReceiver returns a receiver which accepts the messages of the topics
+// with the listed ARNs
+func NewReceiver(topicARNs []string, log logr.Logger, sink func(Event)) *Receiver {
+	topics := make(map[string]bool, len(topicARNs))
+	for _, arn := range topicARNs {
+		topics[arn] = true
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &Receiver{
+		topics: topics,
+		log:    log,
+		sink:   sink,
+		fetch: func(url string) ([]byte, error) {
+			resp, err := client.Get(url)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("Unexpected response status: %s", resp.Status)
+			}
+			return ioutil.ReadAll(io.LimitReader(resp.Body, maxMessageBytes))
+		},
+		certs: make(map[string]*x509.Certificate),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "Only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var message envelope
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxMessageBytes)).Decode(&message); err != nil {
+		http.Error(w, "Unable to parse message", http.StatusBadRequest)
+		return
+	}
+	if !r.topics[message.TopicArn] {
+		r.log.Info("Rejecting message from unknown topic", "topic", message.TopicArn)
+		http.Error(w, "Unknown topic", http.StatusForbidden)
+		return
+	}
+	if err := r.verify(message); err != nil {
+		r.log.Error(err, "Rejecting message with invalid signature", "topic", message.TopicArn)
+		http.Error(w, "Invalid signature", http.StatusForbidden)
+		return
+	}
+
+	switch message.Type {
+	case "SubscriptionConfirmation":
+		if err := r.confirm(message); err != nil {
+			r.log.Error(err, "Unable to confirm subscription", "topic", message.TopicArn)
+			http.Error(w, "Unable to confirm subscription", http.StatusBadGateway)
+			return
+		}
+		r.log.Info("Confirmed subscription", "topic", message.TopicArn)
+	case "UnsubscribeConfirmation":
+		r.log.Info("Unsubscribed from topic", "topic", message.TopicArn)
+	case "Notification":
+		event, err := Parse(message.Message)
+		if err != nil {
+			// SNS would keep retrying a message which can't be parsed
+			r.log.Error(err, "Ignoring unsupported event", "topic", message.TopicArn, "messageID", message.MessageID)
+			break
+		}
+		r.sink(event)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// confirm visits the subscribe URL, which is how SNS subscriptions start
+// delivering messages
+func (r *Receiver) confirm(message envelope) error {
+	if err := trusted(message.SubscribeURL); err != nil {
+		return err
+	}
+	_, err := r.fetch(message.SubscribeURL)
+	return err
+}
+
+// verify checks the signature of the message against the signing
+// certificate of SNS
+func (r *Receiver) verify(message envelope) error {
+	var hash crypto.Hash
+	var hashed []byte
+	stringToSign := []byte(canonical(message))
+	switch message.SignatureVersion {
+	case "1":
+		sum := sha1.Sum(stringToSign)
+		hash, hashed = crypto.SHA1, sum[:]
+	case "2":
+		sum := sha256.Sum256(stringToSign)
+		hash, hashed = crypto.SHA256, sum[:]
+	default:
+		return fmt.Errorf("Unsupported signature version: %s", message.SignatureVersion)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(message.Signature)
+	if err != nil {
+		return fmt.Errorf("Unable to decode signature: %w", err)
+	}
+
+	cert, err := r.signingCert(message.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("Signing certificate doesn't contain an RSA key")
+	}
+	return rsa.VerifyPKCS1v15(key, hash, hashed, signature)
+}
+
+// signingCert returns the certificate at the URL, which is cached as SNS
+// only rotates them rarely
+func (r *Receiver) signingCert(certURL string) (*x509.Certificate, error) {
+	if err := trusted(certURL); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if cert, ok := r.certs[certURL]; ok {
+		return cert, nil
+	}
+
+	encoded, err := r.fetch(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch signing certificate: %w", err)
+	}
+	block, _ := pem.Decode(encoded)
+	if block == nil {
+		return nil, fmt.Errorf("Signing certificate isn't PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse signing certificate: %w", err)
+	}
+
+	r.certs[certURL] = cert
+	return cert, nil
+}
+
+// trusted checks that the URL points at SNS, as it comes from the message
+// which is being verified
+func trusted(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("Unable to parse URL: %w", err)
+	}
+	if parsed.Scheme != "https" || !snsHost.MatchString(parsed.Hostname()) {
+		return fmt.Errorf("URL doesn't belong to SNS: %s", rawURL)
+	}
+	return nil
+}
+
+// canonical returns the string SNS signs for the message, the name and value
+// of each signed field on their own lines
+func canonical(message envelope) string {
+	fields := [][2]string{{"Message", message.Message}, {"MessageId", message.MessageID}}
+	if message.Type == "Notification" {
+		if message.Subject != "" {
+			fields = append(fields, [2]string{"Subject", message.Subject})
+		}
+	} else {
+		fields = append(fields, [2]string{"SubscribeURL", message.SubscribeURL})
+	}
+	fields = append(fields, [2]string{"Timestamp", message.Timestamp})
+	if message.Type != "Notification" {
+		fields = append(fields, [2]string{"Token", message.Token})
+	}
+	fields = append(fields, [2]string{"TopicArn", message.TopicArn}, [2]string{"Type", message.Type})
+
+	var canonical strings.Builder
+	for _, field := range fields {
+		canonical.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return canonical.String()
+}
@@ -0,0 +1,882 @@
+// Package config contains the operator wide settings which can be supplied
+// in a YAML file, optionally with per-environment overrides.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/app-sre/dba-operator/pkg/cardinality"
+	"github.com/app-sre/dba-operator/pkg/cosign"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// Config contains operator wide settings
+type Config struct {
+	// DefaultGrantClass is applied to grants which don't specify a class
+	DefaultGrantClass dbadmin.GrantClass `json:"defaultGrantClass,omitempty"`
+
+	// DefaultGrants are granted to the credentials of ManagedDatabases which
+	// neither list grants themselves nor get any from their class, instead
+	// of read-write on their database
+	DefaultGrants []GrantTemplate `json:"defaultGrants,omitempty"`
+
+	// AdminProfile is the privilege profile that the operator's admin user
+	// was bootstrapped with, ManagedDatabases which need operations outside
+	// of it are refused
+	AdminProfile dbadmin.PrivilegeProfile `json:"adminProfile,omitempty"`
+
+	Rotation          Rotation          `json:"rotation,omitempty"`
+	Backoff           Backoff           `json:"backoff,omitempty"`
+	GarbageCollection GarbageCollection `json:"garbageCollection,omitempty"`
+	LoginTracking     LoginTracking     `json:"loginTracking,omitempty"`
+	LeastPrivilege    LeastPrivilege    `json:"leastPrivilege,omitempty"`
+	Quotas            Quotas            `json:"quotas,omitempty"`
+	CapacityReport    CapacityReport    `json:"capacityReport,omitempty"`
+	Inventory         Inventory         `json:"inventory,omitempty"`
+	FailoverDrills    FailoverDrills    `json:"failoverDrills,omitempty"`
+	Silences          Silences          `json:"silences,omitempty"`
+	Leases            Leases            `json:"leases,omitempty"`
+	Parameters        Parameters        `json:"parameters,omitempty"`
+	Metrics           Metrics           `json:"metrics,omitempty"`
+
+	// AllowedEngines restricts which database engines ManagedDatabases may
+	// use, all supported engines are allowed when empty
+	AllowedEngines []string `json:"allowedEngines,omitempty"`
+
+	Scope Scope `json:"scope,omitempty"`
+
+	Bundles         Bundles         `json:"bundles,omitempty"`
+	ImageSignatures ImageSignatures `json:"imageSignatures,omitempty"`
+
+	NotificationSinks []NotificationSink `json:"notificationSinks,omitempty"`
+
+	CredentialApproval CredentialApproval `json:"credentialApproval,omitempty"`
+
+	// Policies are evaluated by the operator against the statements of
+	// every admin plan and the grants of every credential
+	Policies []PolicyRule `json:"policies,omitempty"`
+
+	// SafeMode suspends every change to managed databases and credentials
+	// across the fleet, for use during incidents. Read-only reconciliation
+	// and status reporting continue.
+	SafeMode bool `json:"safeMode,omitempty"`
+
+	// Environments contains overrides which are applied on top of the rest of
+	// the file when the operator is started for the named environment
+	Environments map[string]Config `json:"environments,omitempty"`
+}
+
+// GrantTemplate is a grant which is applied to ManagedDatabases that don't
+// list their own
+type GrantTemplate struct {
+	// Database is the database of the grant, the one of the ManagedDatabase
+	// when empty
+	Database string `json:"database,omitempty"`
+
+	// Class is the default grant class when empty
+	Class dbadmin.GrantClass `json:"class,omitempty"`
+
+	// Tables restricts the grant to the named tables
+	Tables []string `json:"tables,omitempty"`
+}
+
+// Scope limits which ManagedDatabases the operator acts on, to protect
+// against a resource which points the operator at the wrong environment
+type Scope struct {
+	// Namespaces are the names or patterns of the namespaces whose
+	// databases are managed, every namespace is when empty
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Hosts are the patterns of the database hosts which may be connected
+	// to, such as *.prod.internal. Every host may be when empty.
+	Hosts []string `json:"hosts,omitempty"`
+}
+
+// Bundles controls the migration bundles which are pulled from OCI registries
+type Bundles struct {
+	// PublicKeys are the PEM encoded cosign public keys which bundles must
+	// be signed with, bundles are refused while there are none
+	PublicKeys []string `json:"publicKeys,omitempty"`
+
+	// Registries are the patterns of the registries which bundles may be
+	// pulled from, such as quay.io. Every registry may be when empty.
+	Registries []string `json:"registries,omitempty"`
+}
+
+// Image signature policies
+const (
+	// SignaturePolicyIgnore launches migration Jobs without verifying the
+	// signatures of their images
+	SignaturePolicyIgnore = "ignore"
+
+	// SignaturePolicyRecord verifies the images and records the result,
+	// Jobs whose images don't verify are launched all the same
+	SignaturePolicyRecord = "record"
+
+	// SignaturePolicyRequire refuses to launch Jobs whose images don't
+	// verify
+	SignaturePolicyRequire = "require"
+)
+
+// ImageSignatures controls the verification of migration images before
+// their Jobs are launched
+type ImageSignatures struct {
+	Policy string `json:"policy,omitempty"`
+
+	// PublicKeys are the PEM encoded cosign public keys which images may be
+	// signed with
+	PublicKeys []string `json:"publicKeys,omitempty"`
+
+	// Keyless are the identities which may sign images keylessly
+	Keyless []KeylessSigner `json:"keyless,omitempty"`
+
+	// FulcioRoots are the PEM encoded certificates of the authorities which
+	// issue the certificates of keyless signatures
+	FulcioRoots []string `json:"fulcioRoots,omitempty"`
+
+	// RekorPublicKeys are the PEM encoded public keys of the transparency
+	// logs which keyless signatures must be recorded in
+	RekorPublicKeys []string `json:"rekorPublicKeys,omitempty"`
+}
+
+// KeylessSigner is an identity which may sign keylessly
+type KeylessSigner struct {
+	// Issuer is the URL of the OIDC issuer, such as
+	// https://token.actions.githubusercontent.com
+	Issuer string `json:"issuer"`
+
+	// Subject is a regular expression which the whole email address or
+	// workflow URI of the signer must match
+	Subject string `json:"subject"`
+}
+
+// Rotation controls fleet wide credential rotation
+type Rotation struct {
+	// Interval is the time between rotation passes, zero disables rotation
+	Interval           metav1.Duration `json:"interval,omitempty"`
+	RotationsPerMinute int             `json:"rotationsPerMinute,omitempty"`
+	ErrorBudget        int             `json:"errorBudget,omitempty"`
+
+	// MaxAge is the rotation SLO, credentials which haven't been rotated for
+	// longer are counted as overdue
+	MaxAge metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// Backoff controls how quickly failed reconciles are retried
+type Backoff struct {
+	// TemporaryErrorDelay is how long to wait before retrying a reconcile
+	// which failed with a temporary error
+	TemporaryErrorDelay metav1.Duration `json:"temporaryErrorDelay,omitempty"`
+
+	// MaxTemporaryErrorDelay limits how far the delay grows while the same
+	// ManagedDatabase keeps failing, it is doubled on every retry. Errors
+	// which hint at their own delay are retried after it instead.
+	MaxTemporaryErrorDelay metav1.Duration `json:"maxTemporaryErrorDelay,omitempty"`
+}
+
+// Garbage collection policies
+const (
+	// GCPolicyReport only reports orphaned users and secrets
+	GCPolicyReport = "report"
+
+	// GCPolicyRemove reports and then removes orphaned users and secrets
+	GCPolicyRemove = "remove"
+)
+
+// GarbageCollection controls the periodic search for orphaned database users
+// and secrets
+type GarbageCollection struct {
+	// Interval is the time between passes, zero disables garbage collection
+	Interval metav1.Duration `json:"interval,omitempty"`
+	Policy   string          `json:"policy,omitempty"`
+}
+
+// LoginTracking controls the sampling of when operator issued credentials
+// were last used to log in
+type LoginTracking struct {
+	// Interval is the time between samples, zero disables login tracking
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+// LeastPrivilege controls the sampling of which privileges operator issued
+// credentials use, and the suggestions to revoke the ones they don't
+type LeastPrivilege struct {
+	// Interval is the time between samples, zero disables the sampling
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// Window is how long a privilege has to go unused before revoking it is
+	// suggested
+	Window metav1.Duration `json:"window,omitempty"`
+}
+
+// CapacityReport controls the periodic measurement of the server capacity
+// used by each ManagedDatabase
+type CapacityReport struct {
+	// Interval is the time between reports, zero disables reporting
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// WebhookURL receives every report as JSON, reports are only exported
+	// as metrics when empty
+	WebhookURL string `json:"webhookURL,omitempty"`
+}
+
+// Inventory formats of the inventory export
+const (
+	InventoryFormatJSON = "json"
+	InventoryFormatCSV  = "csv"
+)
+
+// Inventory controls the periodic export of every ManagedDatabase and its
+// credentials, for ingestion by a CMDB and as compliance evidence
+type Inventory struct {
+	// Interval is the time between exports, zero disables the export
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// Format is json or csv
+	Format string `json:"format,omitempty"`
+
+	// WebhookURL receives every export
+	WebhookURL string `json:"webhookURL,omitempty"`
+
+	// ObjectStorage receives every export as a new object
+	ObjectStorage *ObjectStorage `json:"objectStorage,omitempty"`
+}
+
+// ObjectStorage is an S3 compatible bucket. The credentials are read from
+// the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+// environment variables of the operator.
+type ObjectStorage struct {
+	// Endpoint is the URL of the storage service, objects are addressed in
+	// the path style
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"`
+	Bucket   string `json:"bucket"`
+
+	// Prefix is prepended to the name of every object
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// FailoverDrills configures how the failovers of drills are triggered, which
+// the ManagedDatabases approve of individually
+type FailoverDrills struct {
+	// Timeout is how long the operator and the issued credentials have to
+	// recover from a failover before the drill fails
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// RDSRegion is the region of the RDS API which fails over rds-cluster
+	// and rds-instance drills. The credentials are read from the same
+	// environment variables as for object storage.
+	RDSRegion string `json:"rdsRegion,omitempty"`
+
+	// RDSEndpoint overrides the regional endpoint of the RDS API
+	RDSEndpoint string `json:"rdsEndpoint,omitempty"`
+
+	// OrchestratorURL is the address of the orchestrator API which fails
+	// over orchestrator drills
+	OrchestratorURL string `json:"orchestratorURL,omitempty"`
+}
+
+// Silences controls the Alertmanager silences which are created while
+// planned operations run on a database
+type Silences struct {
+	// AlertmanagerURL is the base URL of the Alertmanager API, silences are
+	// disabled when empty
+	AlertmanagerURL string `json:"alertmanagerURL,omitempty"`
+
+	// Matchers select the alerts about the affected database. Their values
+	// are templates which may refer to .Namespace, .Database, .Operation and
+	// .Name.
+	Matchers []SilenceMatcher `json:"matchers,omitempty"`
+
+	// Migrations and Rotations choose which operations are silenced
+	Migrations bool `json:"migrations,omitempty"`
+	Rotations  bool `json:"rotations,omitempty"`
+
+	// MigrationDuration is how long to silence a migration for when it has
+	// no active deadline
+	MigrationDuration metav1.Duration `json:"migrationDuration,omitempty"`
+
+	// RotationDuration is how long to silence a rotation for, the silence is
+	// expired as soon as the rotation is done
+	RotationDuration metav1.Duration `json:"rotationDuration,omitempty"`
+
+	CreatedBy string `json:"createdBy,omitempty"`
+}
+
+// SilenceMatcher is a label matcher of a silence
+type SilenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex,omitempty"`
+}
+
+// Quotas limit how much the operator will place on a single database server,
+// zero is unlimited
+type Quotas struct {
+	// MaxUsersPerInstance limits the users issued by the operator on each
+	// server, across all of the databases on it
+	MaxUsersPerInstance int `json:"maxUsersPerInstance,omitempty"`
+
+	// MaxDatabasesPerInstance limits the ManagedDatabases on each server
+	MaxDatabasesPerInstance int `json:"maxDatabasesPerInstance,omitempty"`
+
+	// Instances replaces the limits for individual servers, keyed by the
+	// hostname:port the server reports for itself
+	Instances map[string]InstanceQuota `json:"instances,omitempty"`
+}
+
+// InstanceQuota contains the limits for a single server
+type InstanceQuota struct {
+	MaxUsers     int `json:"maxUsers,omitempty"`
+	MaxDatabases int `json:"maxDatabases,omitempty"`
+}
+
+// ForInstance returns the limits which apply to the server
+func (q Quotas) ForInstance(instance string) InstanceQuota {
+	if quota, ok := q.Instances[instance]; ok {
+		return quota
+	}
+	return InstanceQuota{MaxUsers: q.MaxUsersPerInstance, MaxDatabases: q.MaxDatabasesPerInstance}
+}
+
+// Leases controls database ownership leases, which let the operators of
+// several clusters watch the same databases while only one of them changes
+// each database
+type Leases struct {
+	// ClusterID identifies the cluster that the operator runs in, leases
+	// are disabled when it is empty
+	ClusterID string `json:"clusterID,omitempty"`
+
+	// Duration is how long a lease lasts without being renewed, before the
+	// operator of another cluster may take over the database
+	Duration metav1.Duration `json:"duration,omitempty"`
+}
+
+// Parameters controls which server variables ManagedDatabases may set
+type Parameters struct {
+	// Denied lists the variables which ManagedDatabases are refused for,
+	// because changing them affects replication, security or the operator
+	// itself
+	Denied []string `json:"denied,omitempty"`
+}
+
+// Allows returns true if ManagedDatabases may set the server variable
+func (p Parameters) Allows(name string) bool {
+	for _, denied := range p.Denied {
+		if strings.EqualFold(denied, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Metrics bounds the series exported by the metrics which are labeled with
+// the names of tables or users
+type Metrics struct {
+	// MaxSeriesPerDatabase is how many tables or users of a database each of
+	// those metrics reports on their own, the rest are aggregated into one
+	// series. There is no limit when it is 0.
+	MaxSeriesPerDatabase int `json:"maxSeriesPerDatabase,omitempty"`
+
+	// AllowedTables and AllowedUsers list patterns of the names which may be
+	// reported on their own, every name may be when they are empty
+	AllowedTables []string `json:"allowedTables,omitempty"`
+	AllowedUsers  []string `json:"allowedUsers,omitempty"`
+
+	// MaxLabelValueLength truncates longer names
+	MaxLabelValueLength int `json:"maxLabelValueLength,omitempty"`
+}
+
+// TableBudget returns the budget of the metrics labeled with table names
+func (m Metrics) TableBudget() cardinality.Budget {
+	return cardinality.Budget{MaxSeries: m.MaxSeriesPerDatabase, Allowed: m.AllowedTables, MaxValueLength: m.MaxLabelValueLength}
+}
+
+// UserBudget returns the budget of the metrics labeled with usernames
+func (m Metrics) UserBudget() cardinality.Budget {
+	return cardinality.Budget{MaxSeries: m.MaxSeriesPerDatabase, Allowed: m.AllowedUsers, MaxValueLength: m.MaxLabelValueLength}
+}
+
+// Credential approval failure policies
+const (
+	// ApprovalFailureFail retries credentials until the policy webhook
+	// decides on them
+	ApprovalFailureFail = "fail"
+
+	// ApprovalFailureIgnore provisions credentials when the policy webhook
+	// can't be reached
+	ApprovalFailureIgnore = "ignore"
+)
+
+// CredentialApproval controls the policy webhook which has to approve every
+// credential before it is provisioned
+type CredentialApproval struct {
+	// WebhookURL receives each credential, every credential is provisioned
+	// without approval when empty
+	WebhookURL    string          `json:"webhookURL,omitempty"`
+	Timeout       metav1.Duration `json:"timeout,omitempty"`
+	FailurePolicy string          `json:"failurePolicy,omitempty"`
+}
+
+// PolicyRule denies the admin plans and credentials which it matches
+type PolicyRule struct {
+	Name    string `json:"name"`
+	Message string `json:"message,omitempty"`
+
+	// Statements are regular expressions, plans with a statement which
+	// matches any of them are denied
+	Statements []string `json:"statements,omitempty"`
+
+	// GrantDatabases are patterns of database names, credentials with a
+	// grant on a database which matches any of them are denied
+	GrantDatabases []string `json:"grantDatabases,omitempty"`
+
+	// ExemptAnnotation exempts the ManagedDatabases which set it to "true"
+	ExemptAnnotation string `json:"exemptAnnotation,omitempty"`
+}
+
+// NotificationSink is a webhook which receives operator events
+type NotificationSink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Default returns the configuration used when no file is supplied
+func Default() Config {
+	return Config{
+		DefaultGrantClass: dbadmin.GrantClassReadWrite,
+		AdminProfile:      dbadmin.ProfileFull,
+		Rotation: Rotation{
+			RotationsPerMinute: 10,
+			ErrorBudget:        5,
+			MaxAge:             metav1.Duration{Duration: 90 * 24 * time.Hour},
+		},
+		Backoff: Backoff{
+			TemporaryErrorDelay:    metav1.Duration{Duration: 60 * time.Second},
+			MaxTemporaryErrorDelay: metav1.Duration{Duration: 30 * time.Minute},
+		},
+		GarbageCollection: GarbageCollection{
+			Interval: metav1.Duration{Duration: time.Hour},
+			Policy:   GCPolicyReport,
+		},
+		ImageSignatures: ImageSignatures{
+			Policy: SignaturePolicyIgnore,
+		},
+		LoginTracking: LoginTracking{
+			Interval: metav1.Duration{Duration: 5 * time.Minute},
+		},
+		LeastPrivilege: LeastPrivilege{
+			Window: metav1.Duration{Duration: 30 * 24 * time.Hour},
+		},
+		CapacityReport: CapacityReport{
+			Interval: metav1.Duration{Duration: 15 * time.Minute},
+		},
+		Inventory: Inventory{
+			Format: InventoryFormatJSON,
+		},
+		FailoverDrills: FailoverDrills{
+			Timeout: metav1.Duration{Duration: 10 * time.Minute},
+		},
+		Silences: Silences{
+			MigrationDuration: metav1.Duration{Duration: 2 * time.Hour},
+			RotationDuration:  metav1.Duration{Duration: 10 * time.Minute},
+			CreatedBy:         "dba-operator",
+		},
+		Leases: Leases{
+			Duration: metav1.Duration{Duration: 2 * time.Minute},
+		},
+		Parameters: Parameters{
+			Denied: []string{
+				"read_only", "super_read_only", "offline_mode",
+				"gtid_mode", "enforce_gtid_consistency", "binlog_format", "server_id",
+				"sql_log_bin", "log_bin_trust_function_creators",
+				"init_connect", "local_infile", "require_secure_transport",
+				"default_authentication_plugin", "general_log", "general_log_file",
+				"event_scheduler", "performance_schema",
+			},
+		},
+		Metrics: Metrics{
+			MaxSeriesPerDatabase: 100,
+			MaxLabelValueLength:  128,
+		},
+		CredentialApproval: CredentialApproval{
+			Timeout:       metav1.Duration{Duration: 10 * time.Second},
+			FailurePolicy: ApprovalFailureFail,
+		},
+	}
+}
+
+// Load reads the configuration file at path, applies it over the defaults,
+// and then applies the overrides for the named environment, if any.
+func Load(path, environment string, defaults Config) (Config, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("Unable to read config file (%s): %w", path, err)
+	}
+
+	return Parse(raw, environment, defaults)
+}
+
+// Parse decodes YAML configuration, applies it over the defaults, and then
+// applies the overrides for the named environment, if any. Only the fields
+// which are present replace those they are applied over, so zero values
+// such as an error budget of 0 can be set. Lists are replaced as a whole,
+// and maps key by key.
+func Parse(raw []byte, environment string, defaults Config) (Config, error) {
+	merged, err := defaults.deepCopy()
+	if err != nil {
+		return Config{}, err
+	}
+	if err := yaml.UnmarshalStrict(raw, &merged); err != nil {
+		return Config{}, fmt.Errorf("Unable to parse config: %w", err)
+	}
+
+	if environment != "" {
+		var layers struct {
+			Environments map[string]json.RawMessage `json:"environments"`
+		}
+		if err := yaml.Unmarshal(raw, &layers); err != nil {
+			return Config{}, fmt.Errorf("Unable to parse config: %w", err)
+		}
+		override, ok := layers.Environments[environment]
+		if !ok {
+			return Config{}, fmt.Errorf("Config does not define environment %s", environment)
+		}
+		if err := yaml.UnmarshalStrict(override, &merged); err != nil {
+			return Config{}, fmt.Errorf("Unable to parse config of environment %s: %w", environment, err)
+		}
+	}
+	merged.Environments = nil
+
+	// Safe-mode which is enabled on the command line can't be left by the file
+	if defaults.SafeMode {
+		merged.SafeMode = true
+	}
+
+	if err := merged.Validate(); err != nil {
+		return Config{}, err
+	}
+	return merged, nil
+}
+
+// deepCopy returns a copy of the config which doesn't share any lists or
+// maps with it, so that decoding over the copy leaves the config unchanged
+func (c Config) deepCopy() (Config, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return Config{}, fmt.Errorf("Unable to copy config: %w", err)
+	}
+	var copied Config
+	if err := json.Unmarshal(raw, &copied); err != nil {
+		return Config{}, fmt.Errorf("Unable to copy config: %w", err)
+	}
+	return copied, nil
+}
+
+// Validate checks that the configuration is internally consistent
+func (c Config) Validate() error {
+	switch c.DefaultGrantClass {
+	case dbadmin.GrantClassReadWrite, dbadmin.GrantClassReadOnly:
+	default:
+		return fmt.Errorf("Unknown default grant class: %s", c.DefaultGrantClass)
+	}
+	for _, grant := range c.DefaultGrants {
+		switch grant.Class {
+		case "", dbadmin.GrantClassReadWrite, dbadmin.GrantClassReadOnly, dbadmin.GrantClassExecute:
+		default:
+			return fmt.Errorf("Unknown class of default grant on database (%s): %s", grant.Database, grant.Class)
+		}
+		for _, table := range grant.Tables {
+			if table == "" {
+				return fmt.Errorf("Default grant on database (%s) lists a table without a name", grant.Database)
+			}
+		}
+	}
+
+	if !c.AdminProfile.Known() {
+		return fmt.Errorf("Unknown admin privilege profile: %s", c.AdminProfile)
+	}
+
+	if c.Rotation.Interval.Duration < 0 || c.Rotation.RotationsPerMinute < 0 || c.Rotation.ErrorBudget < 0 || c.Rotation.MaxAge.Duration < 0 {
+		return fmt.Errorf("Rotation settings may not be negative")
+	}
+
+	if c.Backoff.TemporaryErrorDelay.Duration <= 0 {
+		return fmt.Errorf("Temporary error delay must be positive")
+	}
+	if c.Backoff.MaxTemporaryErrorDelay.Duration < c.Backoff.TemporaryErrorDelay.Duration {
+		return fmt.Errorf("Maximum temporary error delay may not be less than the temporary error delay")
+	}
+
+	if c.GarbageCollection.Interval.Duration < 0 {
+		return fmt.Errorf("Garbage collection interval may not be negative")
+	}
+
+	if c.LoginTracking.Interval.Duration < 0 {
+		return fmt.Errorf("Login tracking interval may not be negative")
+	}
+
+	if c.LeastPrivilege.Interval.Duration < 0 {
+		return fmt.Errorf("Least privilege sampling interval may not be negative")
+	}
+	if c.LeastPrivilege.Window.Duration <= 0 {
+		return fmt.Errorf("Least privilege window must be positive")
+	}
+	if c.LeastPrivilege.Interval.Duration > c.LeastPrivilege.Window.Duration {
+		return fmt.Errorf("Least privilege sampling interval may not be longer than the window")
+	}
+
+	if c.CapacityReport.Interval.Duration < 0 {
+		return fmt.Errorf("Capacity report interval may not be negative")
+	}
+
+	if c.Inventory.Interval.Duration < 0 {
+		return fmt.Errorf("Inventory interval may not be negative")
+	}
+	if c.Inventory.Format != InventoryFormatJSON && c.Inventory.Format != InventoryFormatCSV {
+		return fmt.Errorf("Unknown inventory format: %s", c.Inventory.Format)
+	}
+	if c.Inventory.Interval.Duration > 0 && c.Inventory.WebhookURL == "" && c.Inventory.ObjectStorage == nil {
+		return fmt.Errorf("Inventory requires a webhook URL or object storage")
+	}
+	if storage := c.Inventory.ObjectStorage; storage != nil {
+		if storage.Region == "" || storage.Bucket == "" {
+			return fmt.Errorf("Inventory object storage requires a region and a bucket")
+		}
+		if !strings.HasPrefix(storage.Endpoint, "https://") && !strings.HasPrefix(storage.Endpoint, "http://") {
+			return fmt.Errorf("Inventory object storage endpoint must be an http or https URL")
+		}
+	}
+
+	if c.FailoverDrills.Timeout.Duration <= 0 {
+		return fmt.Errorf("Failover drill timeout must be positive")
+	}
+	for _, url := range []string{c.FailoverDrills.RDSEndpoint, c.FailoverDrills.OrchestratorURL} {
+		if url != "" && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			return fmt.Errorf("Failover drill endpoint must be an http or https URL: %s", url)
+		}
+	}
+
+	if c.Quotas.MaxUsersPerInstance < 0 || c.Quotas.MaxDatabasesPerInstance < 0 {
+		return fmt.Errorf("Quotas may not be negative")
+	}
+	for instance, quota := range c.Quotas.Instances {
+		if quota.MaxUsers < 0 || quota.MaxDatabases < 0 {
+			return fmt.Errorf("Quotas for instance %s may not be negative", instance)
+		}
+	}
+
+	if c.Silences.MigrationDuration.Duration <= 0 || c.Silences.RotationDuration.Duration <= 0 {
+		return fmt.Errorf("Silence durations must be positive")
+	}
+	if c.Silences.AlertmanagerURL != "" && len(c.Silences.Matchers) == 0 {
+		return fmt.Errorf("Silences require at least one matcher, otherwise every alert would be silenced")
+	}
+	for _, matcher := range c.Silences.Matchers {
+		if matcher.Name == "" || matcher.Value == "" {
+			return fmt.Errorf("Silence matchers require both a name and value")
+		}
+		if _, err := template.New(matcher.Name).Parse(matcher.Value); err != nil {
+			return fmt.Errorf("Invalid value template for silence matcher (%s): %w", matcher.Name, err)
+		}
+	}
+
+	if c.Leases.Duration.Duration < time.Second {
+		return fmt.Errorf("Lease duration must be at least a second")
+	}
+	if len(c.Leases.ClusterID) > 64 {
+		return fmt.Errorf("Cluster ID may be at most 64 characters long")
+	}
+
+	for _, name := range c.Parameters.Denied {
+		if name == "" {
+			return fmt.Errorf("Denied parameters must be named")
+		}
+	}
+
+	if c.Metrics.MaxSeriesPerDatabase < 0 || c.Metrics.MaxLabelValueLength < 0 {
+		return fmt.Errorf("Metric limits may not be negative")
+	}
+	for _, pattern := range append(append([]string{}, c.Metrics.AllowedTables...), c.Metrics.AllowedUsers...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("Invalid metric allow-list pattern (%s): %w", pattern, err)
+		}
+	}
+
+	for _, pattern := range append(append([]string{}, c.Scope.Namespaces...), c.Scope.Hosts...) {
+		if pattern == "" {
+			return fmt.Errorf("Scope patterns may not be empty")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("Invalid scope pattern (%s): %w", pattern, err)
+		}
+	}
+
+	if _, err := c.Bundles.Verifier(); err != nil {
+		return fmt.Errorf("Invalid bundle public key: %w", err)
+	}
+	for _, pattern := range c.Bundles.Registries {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("Invalid bundle registry pattern (%s)", pattern)
+		}
+	}
+
+	switch c.ImageSignatures.Policy {
+	case SignaturePolicyIgnore:
+	case SignaturePolicyRecord, SignaturePolicyRequire:
+		if len(c.ImageSignatures.PublicKeys) == 0 && len(c.ImageSignatures.Keyless) == 0 {
+			return fmt.Errorf("Verifying image signatures requires public keys or keyless signers")
+		}
+	default:
+		return fmt.Errorf("Unknown image signature policy: %s", c.ImageSignatures.Policy)
+	}
+	for _, signer := range c.ImageSignatures.Keyless {
+		if signer.Issuer == "" || signer.Subject == "" {
+			return fmt.Errorf("Keyless signers require both an issuer and subject")
+		}
+	}
+	if len(c.ImageSignatures.Keyless) > 0 && (len(c.ImageSignatures.FulcioRoots) == 0 || len(c.ImageSignatures.RekorPublicKeys) == 0) {
+		return fmt.Errorf("Keyless signers require Fulcio roots and Rekor public keys")
+	}
+	if _, err := c.ImageSignatures.Verifier(); err != nil {
+		return fmt.Errorf("Invalid image signature settings: %w", err)
+	}
+
+	switch c.GarbageCollection.Policy {
+	case GCPolicyReport, GCPolicyRemove:
+	default:
+		return fmt.Errorf("Unknown garbage collection policy: %s", c.GarbageCollection.Policy)
+	}
+
+	for _, sink := range c.NotificationSinks {
+		if sink.Name == "" || sink.URL == "" {
+			return fmt.Errorf("Notification sinks require both a name and url")
+		}
+	}
+
+	if c.CredentialApproval.Timeout.Duration <= 0 {
+		return fmt.Errorf("Credential approval timeout must be positive")
+	}
+	switch c.CredentialApproval.FailurePolicy {
+	case ApprovalFailureFail, ApprovalFailureIgnore:
+	default:
+		return fmt.Errorf("Unknown credential approval failure policy: %s", c.CredentialApproval.FailurePolicy)
+	}
+
+	names := make(map[string]bool, len(c.Policies))
+	for _, rule := range c.Policies {
+		if rule.Name == "" {
+			return fmt.Errorf("Policy rules must be named")
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("Policy rule %s is defined more than once", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if len(rule.Statements) == 0 && len(rule.GrantDatabases) == 0 {
+			return fmt.Errorf("Policy rule %s matches nothing", rule.Name)
+		}
+		for _, expression := range rule.Statements {
+			if _, err := regexp.Compile(expression); err != nil {
+				return fmt.Errorf("Invalid statement pattern of policy rule %s: %w", rule.Name, err)
+			}
+		}
+		for _, pattern := range rule.GrantDatabases {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("Invalid grant database pattern of policy rule %s: %w", rule.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// EngineAllowed returns true if ManagedDatabases may use the named engine
+func (c Config) EngineAllowed(engine string) bool {
+	if len(c.AllowedEngines) == 0 {
+		return true
+	}
+	for _, allowed := range c.AllowedEngines {
+		if allowed == engine {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespaceAllowed returns true if the operator may manage the databases of
+// the namespace
+func (s Scope) NamespaceAllowed(namespace string) bool {
+	return scopeMatches(s.Namespaces, namespace)
+}
+
+// HostAllowed returns true if the operator may connect to the database host,
+// host names are compared regardless of case
+func (s Scope) HostAllowed(host string) bool {
+	return scopeMatches(s.Hosts, strings.ToLower(host))
+}
+
+// Verifier returns the verifier of the signatures of bundles
+func (b Bundles) Verifier() (*cosign.Verifier, error) {
+	keys, err := cosign.ParsePublicKeys(b.PublicKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &cosign.Verifier{Keys: keys}, nil
+}
+
+// Verifier returns the verifier of the signatures of migration images
+func (s ImageSignatures) Verifier() (*cosign.Verifier, error) {
+	keys, err := cosign.ParsePublicKeys(s.PublicKeys)
+	if err != nil {
+		return nil, err
+	}
+	verifier := &cosign.Verifier{Keys: keys}
+	if len(s.Keyless) == 0 {
+		return verifier, nil
+	}
+
+	for _, signer := range s.Keyless {
+		subject, err := regexp.Compile("^(?:" + signer.Subject + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("Invalid subject of keyless signer (%s): %w", signer.Issuer, err)
+		}
+		verifier.Identities = append(verifier.Identities, cosign.Identity{Issuer: signer.Issuer, Subject: subject})
+	}
+	if verifier.FulcioRoots, err = cosign.ParseCertificates(s.FulcioRoots); err != nil {
+		return nil, err
+	}
+	if verifier.RekorKeys, err = cosign.ParsePublicKeys(s.RekorPublicKeys); err != nil {
+		return nil, err
+	}
+	return verifier, nil
+}
+
+// RegistryAllowed returns true if bundles may be pulled from the registry,
+// registry names are compared regardless of case
+func (b Bundles) RegistryAllowed(registry string) bool {
+	return scopeMatches(b.Registries, strings.ToLower(registry))
+}
+
+func scopeMatches(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		// Patterns are validated when the config is loaded
+		if matched, _ := path.Match(strings.ToLower(pattern), name); matched {
+			return true
+		}
+	}
+	return false
+}
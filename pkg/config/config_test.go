@@ -0,0 +1,230 @@
+package config
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestExampleConfigEnvironments(t *testing.T) {
+	raw, err := ioutil.ReadFile("../../deploy/examples/operator-config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := Parse(raw, "", Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.Rotation.RotationsPerMinute != 10 || base.Rotation.MaxAge.Duration != 90*24*time.Hour || base.Backoff.TemporaryErrorDelay.Duration != time.Minute {
+		t.Errorf("unexpected base config: %+v", base)
+	}
+
+	prod, err := Parse(raw, "prod", Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prod.Rotation.RotationsPerMinute != 2 || prod.Rotation.ErrorBudget != 1 {
+		t.Errorf("prod overrides not applied: %+v", prod.Rotation)
+	}
+	if prod.Rotation.Interval.Duration != 720*time.Hour {
+		t.Errorf("prod should inherit the base interval, got %s", prod.Rotation.Interval.Duration)
+	}
+	if base.AdminProfile != dbadmin.ProfileFull || prod.AdminProfile != dbadmin.ProfileCredentialsMigrations {
+		t.Errorf("unexpected admin profiles, base %s and prod %s", base.AdminProfile, prod.AdminProfile)
+	}
+	if len(prod.NotificationSinks) != 1 || !prod.EngineAllowed("mysql") || prod.EngineAllowed("postgres") {
+		t.Errorf("prod should inherit base sinks and engines: %+v", prod)
+	}
+	if !prod.Scope.HostAllowed("db-1.prod.internal") || prod.Scope.HostAllowed("db-1.stage.internal") || !base.Scope.HostAllowed("db-1.stage.internal") {
+		t.Errorf("only prod should be restricted to its hosts: %+v", prod.Scope)
+	}
+
+	if len(prod.Bundles.PublicKeys) != 1 || !prod.Bundles.RegistryAllowed("Quay.io") || prod.Bundles.RegistryAllowed("docker.io") {
+		t.Errorf("prod should inherit the base bundle settings: %+v", prod.Bundles)
+	}
+	if base.ImageSignatures.Policy != SignaturePolicyRecord || prod.ImageSignatures.Policy != SignaturePolicyRequire || len(prod.ImageSignatures.PublicKeys) != 1 {
+		t.Errorf("only prod should require image signatures: %+v", prod.ImageSignatures)
+	}
+
+	if len(prod.DefaultGrants) != 2 || prod.DefaultGrants[1].Class != dbadmin.GrantClassExecute {
+		t.Errorf("prod should inherit the base default grants: %+v", prod.DefaultGrants)
+	}
+
+	if _, err := Parse(raw, "missing", Default()); err == nil {
+		t.Error("expected an error for an undefined environment")
+	}
+}
+
+func TestSafeModeOverride(t *testing.T) {
+	raw := []byte("environments:\n  prod:\n    safeMode: true\n  staging: {}\n")
+
+	prod, err := Parse(raw, "prod", Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !prod.SafeMode {
+		t.Error("expected prod to be in safe-mode")
+	}
+
+	staging, err := Parse(raw, "staging", Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staging.SafeMode {
+		t.Error("expected staging not to be in safe-mode")
+	}
+
+	defaults := Default()
+	defaults.SafeMode = true
+	fromFlag, err := Parse(raw, "staging", defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fromFlag.SafeMode {
+		t.Error("an environment must not be able to leave safe-mode enabled by the defaults")
+	}
+}
+
+func TestZeroValueOverrides(t *testing.T) {
+	raw := []byte(`rotation:
+  interval: 24h
+silences:
+  migrations: true
+defaultGrants:
+- class: readonly
+environments:
+  prod:
+    rotation:
+      interval: 0s
+      errorBudget: 0
+    silences:
+      migrations: false
+    defaultGrants: []
+    quotas:
+      instances:
+        db-1:3306:
+          maxUsers: 10
+  staging: {}
+`)
+
+	prod, err := Parse(raw, "prod", Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prod.Rotation.Interval.Duration != 0 || prod.Rotation.ErrorBudget != 0 {
+		t.Errorf("prod should disable rotation and have no error budget: %+v", prod.Rotation)
+	}
+	if prod.Rotation.RotationsPerMinute != 10 {
+		t.Errorf("prod should keep the default rotations per minute, got %d", prod.Rotation.RotationsPerMinute)
+	}
+	if prod.Silences.Migrations || prod.Silences.CreatedBy != "dba-operator" {
+		t.Errorf("prod should only disable migration silences: %+v", prod.Silences)
+	}
+	if len(prod.DefaultGrants) != 0 {
+		t.Errorf("prod should clear the default grants: %+v", prod.DefaultGrants)
+	}
+
+	staging, err := Parse(raw, "staging", Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staging.Rotation.Interval.Duration != 24*time.Hour || staging.Rotation.ErrorBudget != 5 || !staging.Silences.Migrations {
+		t.Errorf("staging should inherit the file: %+v", staging)
+	}
+	if len(staging.DefaultGrants) != 1 || staging.DefaultGrants[0].Class != dbadmin.GrantClassReadOnly {
+		t.Errorf("staging should inherit the default grants: %+v", staging.DefaultGrants)
+	}
+
+	defaults := Default()
+	defaults.Quotas.Instances = map[string]InstanceQuota{"db-2:3306": {MaxUsers: 20}}
+	if _, err := Parse(raw, "prod", defaults); err != nil {
+		t.Fatal(err)
+	}
+	if len(defaults.Quotas.Instances) != 1 {
+		t.Errorf("parsing must not modify the defaults: %+v", defaults.Quotas.Instances)
+	}
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	for _, raw := range []string{
+		"defaultGrantClass: superuser\n",
+		"defaultGrants:\n- class: superuser\n",
+		"defaultGrants:\n- tables: [\"\"]\n",
+		"adminProfile: owner\n",
+		"rotation:\n  errorBudget: -1\n",
+		"rotation:\n  maxAge: -1h\n",
+		"loginTracking:\n  interval: -5m\n",
+		"leastPrivilege:\n  interval: -1h\n",
+		"leastPrivilege:\n  interval: 1h\n  window: 30m\n",
+		"capacityReport:\n  interval: -15m\n",
+		"inventory:\n  interval: 24h\n",
+		"inventory:\n  format: xml\n",
+		"inventory:\n  objectStorage:\n    endpoint: s3.amazonaws.com\n    region: us-east-1\n    bucket: cmdb\n",
+		"inventory:\n  objectStorage:\n    endpoint: https://s3.amazonaws.com\n    region: us-east-1\n",
+		"failoverDrills:\n  timeout: -10m\n",
+		"failoverDrills:\n  orchestratorURL: orchestrator:3000\n",
+		"silences:\n  alertmanagerURL: http://alertmanager:9093\n",
+		"silences:\n  matchers:\n  - name: database\n    value: '{{.Database'\n",
+		"quotas:\n  maxUsersPerInstance: -1\n",
+		"quotas:\n  instances:\n    db-1:3306:\n      maxDatabases: -1\n",
+		"unknownField: true\n",
+		"notificationSinks:\n- name: nourl\n",
+		"leases:\n  duration: 100ms\n",
+		"parameters:\n  denied:\n  - \"\"\n",
+		"backoff:\n  maxTemporaryErrorDelay: 30s\n",
+		"metrics:\n  maxSeriesPerDatabase: -1\n",
+		"metrics:\n  allowedUsers:\n  - \"dba_[\"\n",
+		"credentialApproval:\n  failurePolicy: maybe\n",
+		"credentialApproval:\n  timeout: -1s\n",
+		"policies:\n- statements: [DROP]\n",
+		"policies:\n- name: empty\n",
+		"policies:\n- name: drop\n  statements: ['(DROP']\n",
+		"policies:\n- name: twice\n  grantDatabases: [mysql]\n- name: twice\n  grantDatabases: [sys]\n",
+		"scope:\n  hosts:\n  - \"[prod\"\n",
+		"scope:\n  namespaces:\n  - \"\"\n",
+		"bundles:\n  publicKeys:\n  - not a key\n",
+		"bundles:\n  registries:\n  - \"[quay\"\n",
+		"imageSignatures:\n  policy: sometimes\n",
+		"imageSignatures:\n  policy: require\n",
+		"imageSignatures:\n  policy: record\n  keyless:\n  - issuer: https://token.actions.githubusercontent.com\n    subject: .*\n",
+		"imageSignatures:\n  keyless:\n  - issuer: https://accounts.google.com\n",
+	} {
+		if _, err := Parse([]byte(raw), "", Default()); err == nil {
+			t.Errorf("expected an error parsing %q", raw)
+		}
+	}
+}
+
+func TestScope(t *testing.T) {
+	scope := Scope{
+		Namespaces: []string{"quay", "team-*"},
+		Hosts:      []string{"*.prod.internal"},
+	}
+	for _, namespace := range []string{"quay", "team-registry"} {
+		if !scope.NamespaceAllowed(namespace) {
+			t.Errorf("expected namespace %s to be allowed", namespace)
+		}
+	}
+	for _, namespace := range []string{"quay-staging", "default"} {
+		if scope.NamespaceAllowed(namespace) {
+			t.Errorf("expected namespace %s to be refused", namespace)
+		}
+	}
+	for _, host := range []string{"db-1.prod.internal", "DB-2.Prod.Internal"} {
+		if !scope.HostAllowed(host) {
+			t.Errorf("expected host %s to be allowed", host)
+		}
+	}
+	for _, host := range []string{"db-1.stage.internal", "prod.internal", "db-1.prod.internal.example.com"} {
+		if scope.HostAllowed(host) {
+			t.Errorf("expected host %s to be refused", host)
+		}
+	}
+
+	if !(Scope{}).NamespaceAllowed("default") || !(Scope{}).HostAllowed("localhost") {
+		t.Error("an empty scope should allow everything")
+	}
+}
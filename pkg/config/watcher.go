@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Provider returns the configuration which is currently in effect
+type Provider interface {
+	Current() Config
+}
+
+type staticProvider struct {
+	config Config
+}
+
+// Static returns a Provider which always returns the specified config
+func Static(config Config) Provider {
+	return staticProvider{config: config}
+}
+
+func (sp staticProvider) Current() Config {
+	return sp.config
+}
+
+// Watcher is a Provider which polls a config file and reloads it whenever it
+// changes. If a changed file is invalid the last good config stays in effect.
+type Watcher struct {
+	path         string
+	environment  string
+	defaults     Config
+	pollInterval time.Duration
+	log          logr.Logger
+
+	mu      sync.RWMutex
+	current Config
+	modTime time.Time
+}
+
+// NewWatcher loads the config file at path and returns a Watcher which will
+// keep it up to date once started.
+func NewWatcher(path, environment string, defaults Config, pollInterval time.Duration, log logr.Logger) (*Watcher, error) {
+	watcher := &Watcher{
+		path:         path,
+		environment:  environment,
+		defaults:     defaults,
+		pollInterval: pollInterval,
+		log:          log.WithValues("path", path, "environment", environment),
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := Load(path, environment, defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher.current = loaded
+	watcher.modTime = info.ModTime()
+	return watcher, nil
+}
+
+// Current implements Provider
+func (w *Watcher) Current() Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Start implements manager.Runnable
+func (w *Watcher) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			w.reloadIfChanged()
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that
+// standby replicas have up to date config when they take over
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+func (w *Watcher) reloadIfChanged() {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		w.log.Error(err, "Unable to stat config file")
+		return
+	}
+
+	w.mu.RLock()
+	unchanged := info.ModTime().Equal(w.modTime)
+	w.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	loaded, err := Load(w.path, w.environment, w.defaults)
+	if err != nil {
+		w.log.Error(err, "Unable to reload config file, keeping previous config")
+		return
+	}
+
+	w.mu.Lock()
+	w.current = loaded
+	w.modTime = info.ModTime()
+	w.mu.Unlock()
+
+	w.log.Info("Reloaded config file")
+}
@@ -0,0 +1,48 @@
+// Package consistency compares the values which the two sides of a
+// consistency check return.
+package consistency
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Tolerance is how far apart two values may be and still match
+type Tolerance struct {
+	// Absolute is the largest difference allowed
+	Absolute float64
+
+	// Relative is the largest difference allowed as a fraction of the
+	// larger of the two values, it is used instead of Absolute when set
+	Relative float64
+}
+
+// ParseTolerance reads an absolute tolerance such as "10", or one relative
+// to the larger value such as "0.5%". Empty requires the values to be equal.
+func ParseTolerance(tolerance string) (Tolerance, error) {
+	tolerance = strings.TrimSpace(tolerance)
+	if tolerance == "" {
+		return Tolerance{}, nil
+	}
+
+	relative := strings.HasSuffix(tolerance, "%")
+	value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(tolerance, "%")), 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return Tolerance{}, fmt.Errorf("Tolerance must be a non-negative number or percentage: %s", tolerance)
+	}
+	if relative {
+		return Tolerance{Relative: value / 100}, nil
+	}
+	return Tolerance{Absolute: value}, nil
+}
+
+// Within returns true if the values are no further apart than the tolerance
+func (t Tolerance) Within(left, right float64) bool {
+	difference := math.Abs(left - right)
+	if t.Relative > 0 {
+		return difference <= t.Relative*math.Max(math.Abs(left), math.Abs(right))
+	}
+	return difference <= t.Absolute
+}
@@ -0,0 +1,52 @@
+package consistency
+
+import "testing"
+
+func TestParseTolerance(t *testing.T) {
+	cases := map[string]Tolerance{
+		"":      {},
+		"10":    {Absolute: 10},
+		" 2.5 ": {Absolute: 2.5},
+		"0.5%":  {Relative: 0.005},
+		"1 %":   {Relative: 0.01},
+	}
+	for input, expected := range cases {
+		tolerance, err := ParseTolerance(input)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", input, err)
+		}
+		if tolerance != expected {
+			t.Errorf("Tolerance %q was read as %+v", input, tolerance)
+		}
+	}
+}
+
+func TestParseToleranceRejectsInvalid(t *testing.T) {
+	for _, input := range []string{"-1", "ten", "%", "5%%", "NaN", "Inf"} {
+		if _, err := ParseTolerance(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestWithin(t *testing.T) {
+	cases := []struct {
+		tolerance   Tolerance
+		left, right float64
+		within      bool
+	}{
+		{Tolerance{}, 100, 100, true},
+		{Tolerance{}, 100, 101, false},
+		{Tolerance{Absolute: 1}, 100, 101, true},
+		{Tolerance{Absolute: 1}, 101, 99, false},
+		{Tolerance{Relative: 0.01}, 1000, 990, true},
+		{Tolerance{Relative: 0.01}, 1000, 989, false},
+		{Tolerance{Relative: 0.01}, -1000, -990, true},
+		{Tolerance{Relative: 0.01}, 0, 0, true},
+	}
+	for _, c := range cases {
+		if within := c.tolerance.Within(c.left, c.right); within != c.within {
+			t.Errorf("Expected %v within %+v of %v to be %v", c.left, c.tolerance, c.right, c.within)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package cosigntest signs the manifests of a registrytest.Server as cosign
+// does, with keys or keylessly with certificates of a test authority.
+package cosigntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/registry"
+	"github.com/app-sre/dba-operator/pkg/registry/registrytest"
+)
+
+// Annotations of the layers of a signature manifest, as in package cosign
+// which can't be imported by its own tests
+const (
+	signatureAnnotation   = "dev.cosignproject.cosign/signature"
+	certificateAnnotation = "dev.sigstore.cosign/certificate"
+	chainAnnotation       = "dev.sigstore.cosign/chain"
+	bundleAnnotation      = "dev.sigstore.cosign/bundle"
+)
+
+// GenerateKey returns a key pair, with the public key PEM encoded as in
+// cosign.pub
+func GenerateKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// Payload is what cosign signs for the manifest with the digest
+func Payload(digest string) []byte {
+	return []byte(`{"critical":{"identity":{"docker-reference":"registrytest"},"image":{"docker-manifest-digest":"` + digest + `"},"type":"cosign container image signature"},"optional":null}`)
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, content []byte) string {
+	hashed := sha256.Sum256(content)
+	r, s, err := ecdsa.Sign(rand.Reader, key, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+// Attach replaces the signatures of the manifest with the digest
+func Attach(server *registrytest.Server, digest string, signatures ...registry.Descriptor) {
+	server.Manifest(strings.Replace(digest, ":", "-", 1)+".sig", registry.Manifest{Layers: signatures})
+}
+
+// Sign attaches a signature of the manifest made with the key
+func Sign(t *testing.T, server *registrytest.Server, key *ecdsa.PrivateKey, digest string) {
+	signed := Payload(digest)
+	Attach(server, digest, server.Blob(signed, map[string]string{signatureAnnotation: sign(t, key, signed)}))
+}
+
+// Authority stands in for Fulcio and Rekor
+type Authority struct {
+	root     *x509.Certificate
+	rootKey  *ecdsa.PrivateKey
+	rekorKey *ecdsa.PrivateKey
+
+	// RootPEM and RekorPEM are the certificate of the root and the public
+	// key of the log, as the operator config lists them
+	RootPEM  string
+	RekorPEM string
+}
+
+// NewAuthority creates a root and a log key
+func NewAuthority(t *testing.T) *Authority {
+	rootKey, _ := GenerateKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cosigntest root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rekorKey, rekorPEM := GenerateKey(t)
+	return &Authority{
+		root:     root,
+		rootKey:  rootKey,
+		rekorKey: rekorKey,
+		RootPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		RekorPEM: rekorPEM,
+	}
+}
+
+// SignKeyless attaches a keyless signature of the manifest, made by the
+// subject with a certificate which was valid at signedAt. The subject is a
+// URI when it has a scheme, and an email address otherwise.
+func (a *Authority) SignKeyless(t *testing.T, server *registrytest.Server, digest, issuer, subject string, signedAt time.Time) {
+	key, _ := GenerateKey(t)
+	issuerValue, err := asn1.MarshalWithParams(issuer, "utf8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(signedAt.UnixNano()),
+		NotBefore:       signedAt.Add(-time.Minute),
+		NotAfter:        signedAt.Add(10 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}, Value: issuerValue}},
+	}
+	if uri, err := url.Parse(subject); err == nil && uri.Scheme != "" {
+		template.URIs = []*url.URL{uri}
+	} else {
+		template.EmailAddresses = []string{subject}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.root, &key.PublicKey, a.rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	signed := Payload(digest)
+	signature := sign(t, key, signed)
+	decodedSignature, _ := base64.StdEncoding.DecodeString(signature)
+	hashed := sha256.Sum256(signed)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]interface{}{
+			"data":      map[string]interface{}{"hash": map[string]string{"algorithm": "sha256", "value": hex.EncodeToString(hashed[:])}},
+			"signature": map[string]interface{}{"content": decodedSignature, "publicKey": map[string]interface{}{"content": certificate}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := struct {
+		Body           string `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogID          string `json:"logID"`
+		LogIndex       int64  `json:"logIndex"`
+	}{base64.StdEncoding.EncodeToString(body), signedAt.Unix(), "c0d23d6ad406973f", 42}
+	canonical, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timestamp, _ := base64.StdEncoding.DecodeString(sign(t, a.rekorKey, canonical))
+	bundle, err := json.Marshal(map[string]interface{}{"SignedEntryTimestamp": timestamp, "Payload": entry})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Attach(server, digest, server.Blob(signed, map[string]string{
+		signatureAnnotation:   signature,
+		certificateAnnotation: string(certificate),
+		chainAnnotation:       a.RootPEM,
+		bundleAnnotation:      string(bundle),
+	}))
+}
@@ -0,0 +1,209 @@
+package cosign
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Extensions of Fulcio certificates which name the OIDC issuer, the first
+// holds the issuer as is and the second as a DER string
+var (
+	issuerExtension   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	issuerExtensionV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// Identity is a keyless signer, named by the OIDC issuer which vouched for it
+// and the subject of its token: an email address, or the URI of a workflow
+type Identity struct {
+	Issuer string
+
+	// Subject must match the whole subject of the certificate
+	Subject *regexp.Regexp
+}
+
+// rekorBundle is the entry of the transparency log which cosign attaches to
+// keyless signatures, along with the log's promise to include it
+type rekorBundle struct {
+	SignedEntryTimestamp []byte       `json:"SignedEntryTimestamp"`
+	Payload              rekorPayload `json:"Payload"`
+}
+
+// rekorPayload is what the log signs, its fields are in the order of the
+// canonical JSON encoding which the signature is made over
+type rekorPayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// hashedRekord is the body of an entry, which records the signature of a
+// digest along with the certificate that it was made with
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content   []byte `json:"content"`
+			PublicKey struct {
+				Content []byte `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// verifyKeyless returns the identity which made the signature with the key
+// of its certificate. The certificate must have been issued by a Fulcio
+// root, and the signature recorded in the log while it was valid.
+func (v *Verifier) verifyKeyless(annotations map[string]string, signed []byte, signature string) (string, error) {
+	if len(v.Identities) == 0 {
+		return "", fmt.Errorf("Keyless signatures aren't trusted")
+	}
+	if v.FulcioRoots == nil || len(v.RekorKeys) == 0 {
+		return "", fmt.Errorf("Keyless signatures require Fulcio roots and Rekor keys")
+	}
+
+	block, _ := pem.Decode([]byte(annotations[CertificateAnnotation]))
+	if block == nil {
+		return "", fmt.Errorf("Signing certificate isn't PEM encoded")
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("Unable to parse signing certificate: %w", err)
+	}
+	intermediates := x509.NewCertPool()
+	intermediates.AppendCertsFromPEM([]byte(annotations[ChainAnnotation]))
+
+	integrated, err := v.verifyEntry(annotations[BundleAnnotation], leaf, signed, signature)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         v.FulcioRoots,
+		Intermediates: intermediates,
+		CurrentTime:   integrated,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return "", fmt.Errorf("Signing certificate wasn't issued by a trusted root when it was used: %w", err)
+	}
+
+	issuer, subject, err := certificateIdentity(leaf)
+	if err != nil {
+		return "", err
+	}
+	trusted := false
+	for _, identity := range v.Identities {
+		if identity.Issuer == issuer && identity.Subject.MatchString(subject) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return "", fmt.Errorf("Signer %s of issuer %s isn't trusted", subject, issuer)
+	}
+
+	key, ok := leaf.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("Signing certificate doesn't have an ECDSA key")
+	}
+	if err := verifyECDSA(key, signed, signature); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (%s)", subject, issuer), nil
+}
+
+// verifyEntry checks that the log promised to include an entry for the
+// signature and certificate, and returns when the entry was integrated
+func (v *Verifier) verifyEntry(encoded string, leaf *x509.Certificate, signed []byte, signature string) (time.Time, error) {
+	if encoded == "" {
+		return time.Time{}, fmt.Errorf("Keyless signature wasn't recorded in the transparency log")
+	}
+	var bundle rekorBundle
+	if err := json.Unmarshal([]byte(encoded), &bundle); err != nil {
+		return time.Time{}, fmt.Errorf("Unable to read transparency log entry: %w", err)
+	}
+
+	canonical, err := json.Marshal(bundle.Payload)
+	if err != nil {
+		return time.Time{}, err
+	}
+	encodedTimestamp := base64.StdEncoding.EncodeToString(bundle.SignedEntryTimestamp)
+	verified := false
+	for _, key := range v.RekorKeys {
+		if verifyECDSA(key, canonical, encodedTimestamp) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return time.Time{}, fmt.Errorf("Transparency log entry wasn't signed by a trusted log")
+	}
+
+	// The entry is only trusted once the log's signature has been verified
+	body, err := base64.StdEncoding.DecodeString(bundle.Payload.Body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Unable to decode transparency log entry: %w", err)
+	}
+	var entry hashedRekord
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return time.Time{}, fmt.Errorf("Unable to read transparency log entry: %w", err)
+	}
+	hashed := sha256.Sum256(signed)
+	decodedSignature, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Unable to decode signature: %w", err)
+	}
+	recorded, _ := pem.Decode(entry.Spec.Signature.PublicKey.Content)
+	if entry.Kind != "hashedrekord" ||
+		entry.Spec.Data.Hash.Algorithm != "sha256" ||
+		entry.Spec.Data.Hash.Value != hex.EncodeToString(hashed[:]) ||
+		!bytes.Equal(entry.Spec.Signature.Content, decodedSignature) ||
+		recorded == nil || !bytes.Equal(recorded.Bytes, leaf.Raw) {
+		return time.Time{}, fmt.Errorf("Transparency log entry is for another signature")
+	}
+	return time.Unix(bundle.Payload.IntegratedTime, 0), nil
+}
+
+// certificateIdentity returns the OIDC issuer and subject of a Fulcio
+// certificate
+func certificateIdentity(leaf *x509.Certificate) (string, string, error) {
+	var issuer string
+	for _, extension := range leaf.Extensions {
+		switch {
+		case extension.Id.Equal(issuerExtensionV2):
+			if _, err := asn1.Unmarshal(extension.Value, &issuer); err != nil {
+				return "", "", fmt.Errorf("Unable to read the issuer of the signing certificate: %w", err)
+			}
+		case extension.Id.Equal(issuerExtension) && issuer == "":
+			issuer = string(extension.Value)
+		}
+	}
+	if issuer == "" {
+		return "", "", fmt.Errorf("Signing certificate doesn't name an issuer")
+	}
+
+	switch {
+	case len(leaf.EmailAddresses) > 0:
+		return issuer, leaf.EmailAddresses[0], nil
+	case len(leaf.URIs) > 0:
+		return issuer, leaf.URIs[0].String(), nil
+	}
+	return "", "", fmt.Errorf("Signing certificate doesn't name a subject")
+}
@@ -0,0 +1,192 @@
+// Package cosign verifies the signatures which cosign attaches to images and
+// other artifacts in a registry, made either with a key, or keylessly with a
+// Fulcio certificate whose use is recorded in Rekor.
+package cosign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/registry"
+)
+
+// Annotations of the layers of a signature manifest
+const (
+	SignatureAnnotation   = "dev.cosignproject.cosign/signature"
+	CertificateAnnotation = "dev.sigstore.cosign/certificate"
+	ChainAnnotation       = "dev.sigstore.cosign/chain"
+	BundleAnnotation      = "dev.sigstore.cosign/bundle"
+)
+
+// payloadType is the type of the payloads which cosign signs
+const payloadType = "cosign container image signature"
+
+// payload is the part of a signature payload which is checked
+type payload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// Verifier accepts the signatures which were made with one of its keys, or
+// keylessly by one of its identities
+type Verifier struct {
+	Keys []*ecdsa.PublicKey
+
+	Identities []Identity
+
+	// FulcioRoots issue the certificates of keyless signatures
+	FulcioRoots *x509.CertPool
+
+	// RekorKeys sign the entries of the transparency log, in which keyless
+	// signatures must have been recorded while their certificate was valid
+	RekorKeys []*ecdsa.PublicKey
+}
+
+// Verify looks up the signatures which are attached to the manifest with the
+// digest, and returns the signer of the first one which verifies
+func (v *Verifier) Verify(ctx context.Context, session *registry.Session, digest string) (string, error) {
+	if len(v.Keys) == 0 && len(v.Identities) == 0 {
+		return "", fmt.Errorf("No keys or identities are trusted to sign")
+	}
+
+	body, _, err := session.Manifest(ctx, strings.Replace(digest, ":", "-", 1)+".sig")
+	if err == registry.ErrNotFound {
+		return "", fmt.Errorf("Manifest %s isn't signed", digest)
+	} else if err != nil {
+		return "", fmt.Errorf("Unable to fetch signatures: %w", err)
+	}
+
+	var signatures registry.Manifest
+	if err := json.Unmarshal(body, &signatures); err != nil {
+		return "", fmt.Errorf("Unable to read signatures: %w", err)
+	}
+
+	lastErr := fmt.Errorf("Manifest %s has no signatures", digest)
+	for _, layer := range signatures.Layers {
+		signature, ok := layer.Annotations[SignatureAnnotation]
+		if !ok {
+			continue
+		}
+		signed, err := session.Blob(ctx, layer)
+		if err != nil {
+			return "", fmt.Errorf("Unable to fetch signature payload: %w", err)
+		}
+
+		var signer string
+		if _, keyless := layer.Annotations[CertificateAnnotation]; keyless {
+			signer, lastErr = v.verifyKeyless(layer.Annotations, signed, signature)
+		} else {
+			signer, lastErr = v.verifyWithKeys(signed, signature)
+		}
+		if lastErr == nil {
+			lastErr = checkPayload(signed, digest)
+		}
+		if lastErr == nil {
+			return signer, nil
+		}
+	}
+	return "", lastErr
+}
+
+// verifyWithKeys returns the ID of the key which made the signature
+func (v *Verifier) verifyWithKeys(signed []byte, signature string) (string, error) {
+	for _, key := range v.Keys {
+		if err := verifyECDSA(key, signed, signature); err == nil {
+			return KeyID(key), nil
+		}
+	}
+	return "", fmt.Errorf("Signature wasn't made with a trusted key")
+}
+
+// checkPayload returns nil if the verified payload signs the manifest
+func checkPayload(signed []byte, digest string) error {
+	var decoded payload
+	if err := json.Unmarshal(signed, &decoded); err != nil {
+		return fmt.Errorf("Unable to read signature payload: %w", err)
+	}
+	if decoded.Critical.Type != payloadType {
+		return fmt.Errorf("Signature payload has unexpected type %q", decoded.Critical.Type)
+	}
+	if decoded.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("Signature is for manifest %s rather than %s", decoded.Critical.Image.DockerManifestDigest, digest)
+	}
+	return nil
+}
+
+// verifyECDSA checks a base64 encoded ASN.1 signature over the SHA-256
+// digest of the content
+func verifyECDSA(key *ecdsa.PublicKey, content []byte, signature string) error {
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("Unable to decode signature: %w", err)
+	}
+	var parsed struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(decoded, &parsed); err != nil || len(rest) > 0 {
+		return fmt.Errorf("Signature isn't an ASN.1 encoded ECDSA signature")
+	}
+
+	hashed := sha256.Sum256(content)
+	if !ecdsa.Verify(key, hashed[:], parsed.R, parsed.S) {
+		return fmt.Errorf("Signature doesn't verify")
+	}
+	return nil
+}
+
+// KeyID identifies a public key by the digest of its encoding, as the
+// signer of the signatures made with it
+func KeyID(key *ecdsa.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "key"
+	}
+	sum := sha256.Sum256(der)
+	return "key sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// ParsePublicKeys reads PEM encoded ECDSA public keys, as written by
+// cosign generate-key-pair
+func ParsePublicKeys(encoded []string) ([]*ecdsa.PublicKey, error) {
+	keys := make([]*ecdsa.PublicKey, 0, len(encoded))
+	for i, key := range encoded {
+		block, _ := pem.Decode([]byte(key))
+		if block == nil || block.Type != "PUBLIC KEY" {
+			return nil, fmt.Errorf("Public key %d isn't a PEM encoded public key", i)
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse public key %d: %w", i, err)
+		}
+		ecdsaKey, ok := parsed.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("Public key %d isn't an ECDSA key", i)
+		}
+		keys = append(keys, ecdsaKey)
+	}
+	return keys, nil
+}
+
+// ParseCertificates reads PEM encoded certificates into a pool, each entry
+// may contain several of them
+func ParseCertificates(encoded []string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for i, certificates := range encoded {
+		if !pool.AppendCertsFromPEM([]byte(certificates)) {
+			return nil, fmt.Errorf("Certificate %d isn't a PEM encoded certificate", i)
+		}
+	}
+	return pool, nil
+}
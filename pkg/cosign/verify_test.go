@@ -0,0 +1,109 @@
+package cosign
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/cosign/cosigntest"
+	"github.com/app-sre/dba-operator/pkg/registry"
+	"github.com/app-sre/dba-operator/pkg/registry/registrytest"
+)
+
+func TestVerifyWithKey(t *testing.T) {
+	server := registrytest.NewServer(t, "app/migrate")
+	defer server.Close()
+	session := registry.NewSession(server.Client(), server.Reference("v1"), server.Credentials())
+
+	key, encoded := cosigntest.GenerateKey(t)
+	keys, err := ParsePublicKeys([]string{encoded})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := &Verifier{Keys: keys}
+
+	digest := server.PushFiles("v1", map[string]string{"layer": "content"})
+	if _, err := verifier.Verify(context.Background(), session, digest); err == nil {
+		t.Errorf("Expected an unsigned manifest to be refused")
+	}
+
+	cosigntest.Sign(t, server, key, digest)
+	signer, err := verifier.Verify(context.Background(), session, digest)
+	if err != nil {
+		t.Fatalf("Unable to verify signature: %v", err)
+	}
+	if signer != KeyID(keys[0]) || !strings.HasPrefix(signer, "key sha256:") {
+		t.Errorf("Unexpected signer: %s", signer)
+	}
+
+	other, _ := cosigntest.GenerateKey(t)
+	cosigntest.Sign(t, server, other, digest)
+	if _, err := verifier.Verify(context.Background(), session, digest); err == nil {
+		t.Errorf("Expected a signature of an untrusted key to be refused")
+	}
+
+	if _, err := (&Verifier{}).Verify(context.Background(), session, digest); err == nil {
+		t.Errorf("Expected a verifier without keys to refuse everything")
+	}
+}
+
+func TestVerifyKeyless(t *testing.T) {
+	server := registrytest.NewServer(t, "app/migrate")
+	defer server.Close()
+	session := registry.NewSession(server.Client(), server.Reference("v1"), server.Credentials())
+
+	authority := cosigntest.NewAuthority(t)
+	roots, err := ParseCertificates([]string{authority.RootPEM})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorKeys, err := ParsePublicKeys([]string{authority.RekorPEM})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const issuer = "https://token.actions.githubusercontent.com"
+	verifier := &Verifier{
+		Identities:  []Identity{{Issuer: issuer, Subject: regexp.MustCompile(`^https://github\.com/app/migrations/\.github/workflows/release\.yaml@refs/tags/v.*$`)}},
+		FulcioRoots: roots,
+		RekorKeys:   rekorKeys,
+	}
+	const workflow = "https://github.com/app/migrations/.github/workflows/release.yaml@refs/tags/v1"
+
+	// The certificate expired long ago, but was valid when the log
+	// recorded the signature
+	digest := server.PushFiles("v1", map[string]string{"layer": "content"})
+	authority.SignKeyless(t, server, digest, issuer, workflow, time.Now().Add(-30*time.Minute))
+	signer, err := verifier.Verify(context.Background(), session, digest)
+	if err != nil {
+		t.Fatalf("Unable to verify keyless signature: %v", err)
+	}
+	if signer != workflow+" ("+issuer+")" {
+		t.Errorf("Unexpected signer: %s", signer)
+	}
+
+	for name, sign := range map[string]func(digest string){
+		"other subject": func(digest string) {
+			authority.SignKeyless(t, server, digest, issuer, "someone@example.com", time.Now())
+		},
+		"other issuer": func(digest string) {
+			authority.SignKeyless(t, server, digest, "https://accounts.example.com", workflow, time.Now())
+		},
+		"other authority": func(digest string) {
+			cosigntest.NewAuthority(t).SignKeyless(t, server, digest, issuer, workflow, time.Now())
+		},
+	} {
+		digest := server.PushFiles("", map[string]string{"layer": name})
+		sign(digest)
+		if _, err := verifier.Verify(context.Background(), session, digest); err == nil {
+			t.Errorf("%s: expected the signature to be refused", name)
+		}
+	}
+
+	key, _ := cosigntest.GenerateKey(t)
+	cosigntest.Sign(t, server, key, digest)
+	if _, err := verifier.Verify(context.Background(), session, digest); err == nil {
+		t.Errorf("Expected a signature made with a key to be refused by a keyless verifier")
+	}
+}
@@ -0,0 +1,207 @@
+// Package dashboards generates the Grafana dashboards for the metrics that
+// the operator exports.
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Panel is a single graph on a dashboard
+type Panel struct {
+	Title string
+
+	// Expr is the PromQL query, it may refer to the $namespace variable
+	Expr   string
+	Legend string
+
+	// Unit is the Grafana format of the y axis, e.g. bytes or s
+	Unit string
+}
+
+// Dashboard is a set of panels which is rendered as one Grafana dashboard
+type Dashboard struct {
+	// Name is used as the dashboard uid and the name of its file
+	Name   string
+	Title  string
+	Panels []Panel
+}
+
+// All contains every dashboard shipped with the operator
+var All = []Dashboard{
+	{
+		Name:  "dba-operator-databases",
+		Title: "DBA Operator / Databases",
+		Panels: []Panel{
+			{"Data size", `dba_operator_database_data_bytes{namespace=~"$namespace"}`, "{{namespace}}/{{database}}", "bytes"},
+			{"Index size", `dba_operator_database_index_bytes{namespace=~"$namespace"}`, "{{namespace}}/{{database}}", "bytes"},
+			{"Connections", `dba_operator_database_connections{namespace=~"$namespace"}`, "{{namespace}}/{{database}}", "short"},
+			{"Queries per second", `dba_operator_database_queries_per_second{namespace=~"$namespace"}`, "{{namespace}}/{{database}}", "qps"},
+			{"Instance quota used", `dba_operator_instance_quota_used / dba_operator_instance_quota_limit`, "{{instance}} {{resource}}", "percentunit"},
+			{"Metadata lock pile-ups", `rate(dba_operator_metadata_lock_pileups_total[5m])`, "pile-ups", "ops"},
+			{"Sessions killed", `rate(dba_operator_sessions_killed_total[5m])`, "sessions", "ops"},
+			{"Statements retried", `sum by (reason) (rate(dba_operator_statement_retries_total[5m]))`, "{{reason}}", "ops"},
+			{"Managed databases", `dba_operator_managed_databases_total`, "databases", "short"},
+			{"Partitions ahead", `dba_operator_partitions_ahead{namespace=~"$namespace"}`, "{{namespace}}/{{database}} {{table}}", "short"},
+			{"Partitions behind schedule", `dba_operator_partitions_behind{namespace=~"$namespace"}`, "{{namespace}}/{{database}} {{table}}", "short"},
+			{"Rows purged by retention", `rate(dba_operator_retention_rows_purged_total{namespace=~"$namespace"}[5m])`, "{{namespace}}/{{database}} {{rule}}", "short"},
+			{"Retention caught up", `dba_operator_retention_caught_up{namespace=~"$namespace"}`, "{{namespace}}/{{database}} {{rule}}", "short"},
+			{"Retention backoffs", `increase(dba_operator_retention_backoffs_total{namespace=~"$namespace"}[1h])`, "{{namespace}}/{{database}} {{rule}}", "short"},
+		},
+	},
+	{
+		Name:  "dba-operator-migrations",
+		Title: "DBA Operator / Migrations",
+		Panels: []Panel{
+			{"Migration duration", `dba_operator_migration_duration_seconds{namespace=~"$namespace"}`, "{{namespace}}/{{database}} {{migration}}", "s"},
+			{"Migration progress", `dba_operator_migration_progress_ratio{namespace=~"$namespace"}`, "{{namespace}}/{{database}} {{migration}}", "percentunit"},
+			{"Rows processed", `dba_operator_migration_rows_processed{namespace=~"$namespace"}`, "{{namespace}}/{{database}} {{migration}}", "short"},
+			{"Migration jobs spawned", `increase(dba_operator_migration_jobs_spawned_total[1h])`, "jobs", "short"},
+			{"Migrations aborted", `increase(dba_operator_migrations_aborted_total[1h])`, "aborted", "short"},
+		},
+	},
+	{
+		Name:  "dba-operator-credentials",
+		Title: "DBA Operator / Credentials",
+		Panels: []Panel{
+			{"Credential age", `dba_operator_credential_age_seconds{namespace=~"$namespace"}`, "{{namespace}}/{{secret}}", "s"},
+			{"Time since rotation", `dba_operator_credential_seconds_since_rotation{namespace=~"$namespace"}`, "{{namespace}}/{{secret}}", "s"},
+			{"Time until rotation", `dba_operator_credential_seconds_until_rotation{namespace=~"$namespace"}`, "{{namespace}}/{{secret}}", "s"},
+			{"Last login", `time() - dba_operator_credential_last_login_timestamp_seconds{namespace=~"$namespace"}`, "{{namespace}}/{{database}} {{username}}", "s"},
+			{"Rotations", `rate(dba_operator_credentials_rotated_total[5m])`, "rotated", "ops"},
+			{"Rotation failures", `rate(dba_operator_credential_rotation_failures_total[5m])`, "failures", "ops"},
+			{"Credentials overdue for rotation", `increase(dba_operator_credential_rotation_overdue_total[1h])`, "overdue", "short"},
+		},
+	},
+}
+
+// panelsPerRow is how many panels are placed side by side, Grafana grids
+// are 24 units wide
+const (
+	panelsPerRow = 2
+	panelWidth   = 24 / panelsPerRow
+	panelHeight  = 8
+)
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type target struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
+
+type axis struct {
+	Format string `json:"format"`
+	Show   bool   `json:"show"`
+}
+
+type graphPanel struct {
+	ID         int      `json:"id"`
+	Type       string   `json:"type"`
+	Title      string   `json:"title"`
+	Datasource string   `json:"datasource"`
+	GridPos    gridPos  `json:"gridPos"`
+	Targets    []target `json:"targets"`
+	YAxes      []axis   `json:"yaxes"`
+}
+
+type variable struct {
+	Name       string `json:"name"`
+	Label      string `json:"label"`
+	Type       string `json:"type"`
+	Query      string `json:"query"`
+	Datasource string `json:"datasource,omitempty"`
+	Refresh    int    `json:"refresh"`
+	IncludeAll bool   `json:"includeAll"`
+	Multi      bool   `json:"multi"`
+	AllValue   string `json:"allValue,omitempty"`
+}
+
+type timeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type grafanaDashboard struct {
+	UID           string       `json:"uid"`
+	Title         string       `json:"title"`
+	Tags          []string     `json:"tags"`
+	SchemaVersion int          `json:"schemaVersion"`
+	Refresh       string       `json:"refresh"`
+	Time          timeRange    `json:"time"`
+	Panels        []graphPanel `json:"panels"`
+	Templating    struct {
+		List []variable `json:"list"`
+	} `json:"templating"`
+}
+
+// Render returns the Grafana JSON model of the dashboard
+func Render(dashboard Dashboard) ([]byte, error) {
+	model := grafanaDashboard{
+		UID:           dashboard.Name,
+		Title:         dashboard.Title,
+		Tags:          []string{"dba-operator"},
+		SchemaVersion: 16,
+		Refresh:       "1m",
+		Time:          timeRange{From: "now-6h", To: "now"},
+	}
+	model.Templating.List = []variable{
+		{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"},
+		{
+			Name:       "namespace",
+			Label:      "Namespace",
+			Type:       "query",
+			Query:      "label_values(dba_operator_database_connections, namespace)",
+			Datasource: "$datasource",
+			Refresh:    2,
+			IncludeAll: true,
+			Multi:      true,
+			AllValue:   ".*",
+		},
+	}
+
+	for i, panel := range dashboard.Panels {
+		if panel.Expr == "" {
+			return nil, fmt.Errorf("Panel %s of dashboard %s has no query", panel.Title, dashboard.Name)
+		}
+		model.Panels = append(model.Panels, graphPanel{
+			ID:         i + 1,
+			Type:       "graph",
+			Title:      panel.Title,
+			Datasource: "$datasource",
+			GridPos: gridPos{
+				H: panelHeight,
+				W: panelWidth,
+				X: (i % panelsPerRow) * panelWidth,
+				Y: (i / panelsPerRow) * panelHeight,
+			},
+			Targets: []target{{Expr: panel.Expr, LegendFormat: panel.Legend, RefID: "A"}},
+			YAxes:   []axis{{Format: panel.Unit, Show: true}, {Format: "short", Show: false}},
+		})
+	}
+
+	rendered, err := json.MarshalIndent(model, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to encode dashboard %s: %w", dashboard.Name, err)
+	}
+	return rendered, nil
+}
+
+// RenderAll returns every dashboard keyed by its file name
+func RenderAll() (map[string]string, error) {
+	files := make(map[string]string, len(All))
+	for _, dashboard := range All {
+		rendered, err := Render(dashboard)
+		if err != nil {
+			return nil, err
+		}
+		files[dashboard.Name+".json"] = string(rendered)
+	}
+	return files, nil
+}
@@ -0,0 +1,44 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRenderAll(t *testing.T) {
+	files, err := RenderAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(All) {
+		t.Fatalf("expected %d dashboards, got %d", len(All), len(files))
+	}
+
+	for name, rendered := range files {
+		var model grafanaDashboard
+		if err := json.Unmarshal([]byte(rendered), &model); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if name != model.UID+".json" {
+			t.Errorf("%s: unexpected uid %s", name, model.UID)
+		}
+
+		seen := make(map[int]bool)
+		for _, panel := range model.Panels {
+			if seen[panel.ID] {
+				t.Errorf("%s: duplicate panel id %d", name, panel.ID)
+			}
+			seen[panel.ID] = true
+			if panel.GridPos.X+panel.GridPos.W > 24 {
+				t.Errorf("%s: panel %s overflows the grid", name, panel.Title)
+			}
+		}
+	}
+}
+
+func TestRenderRejectsEmptyQuery(t *testing.T) {
+	if _, err := Render(Dashboard{Name: "broken", Panels: []Panel{{Title: "Nothing"}}}); err == nil {
+		t.Error("expected an error for a panel without a query")
+	}
+}
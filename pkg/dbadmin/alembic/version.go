@@ -17,3 +17,9 @@ func CreateMigrationEngine() dbadmin.MigrationEngine {
 func (amm *MigrationEngine) GetVersionQuery() string {
 	return "SELECT version_num FROM alembic_version LIMIT 1"
 }
+
+// GetCleanupStatements implements MigrationEngine. Alembic only updates the
+// version once a migration has completed, so there is nothing to clean up.
+func (amm *MigrationEngine) GetCleanupStatements() []string {
+	return nil
+}
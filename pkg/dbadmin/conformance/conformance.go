@@ -0,0 +1,380 @@
+// Package conformance contains the behavior which every DbAdmin
+// implementation must have, as tests which run against a real database. A
+// backend runs the suite from its own tests, with a throwaway database that
+// the suite may create users and tables in.
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// Backend connects the suite to the database under test
+type Backend struct {
+	// Connect opens a new DbAdmin for the database, with the privileges that
+	// the operator is given in production. The suite closes it.
+	Connect func(t *testing.T) dbadmin.DbAdmin
+
+	// Login opens a session as the user and closes it again, returning an
+	// error if the password is refused. The checks which log in are skipped
+	// when it isn't set.
+	Login func(username, password string) error
+
+	// UsernamePrefix starts the name of every user the suite creates, users
+	// with the prefix which are left over from an earlier run are dropped
+	UsernamePrefix string
+
+	// CanaryTableDefinition is the CREATE TABLE statement of a table named
+	// conformance_canary, which must survive the injection checks
+	CanaryTableDefinition string
+}
+
+// Run runs every check of the suite against the backend
+func Run(t *testing.T, backend Backend) {
+	if backend.Connect == nil || backend.UsernamePrefix == "" {
+		t.Fatal("The backend must connect and isolate the suite's users with a prefix")
+	}
+
+	admin := backend.Connect(t)
+	defer admin.Close()
+	dropLeftovers(t, admin, backend.UsernamePrefix)
+
+	t.Run("CredentialLifecycle", func(t *testing.T) { testCredentialLifecycle(t, admin, backend) })
+	t.Run("Idempotency", func(t *testing.T) { testIdempotency(t, admin, backend) })
+	t.Run("HostilePasswords", func(t *testing.T) { testHostilePasswords(t, admin, backend) })
+	t.Run("HostileUsernames", func(t *testing.T) { testHostileUsernames(t, admin, backend) })
+	t.Run("UsernameLimit", func(t *testing.T) { testUsernameLimit(t, admin, backend) })
+	t.Run("HostileIdentifiers", func(t *testing.T) { testHostileIdentifiers(t, admin, backend) })
+	t.Run("ErrorClassification", func(t *testing.T) { testErrorClassification(t, admin, backend) })
+}
+
+// Passwords which break statements that aren't escaped properly
+var hostilePasswords = []string{
+	`Pa55'word-with-quote`,
+	`Pa55"word-with-double-quote`,
+	`Pa55\word-with-backslash\`,
+	`Pa55word'; DROP TABLE conformance_canary; --`,
+	`Pa55word%_?-with-wildcards`,
+	"Pa55word-ünicöde-✓",
+}
+
+// Suffixes of usernames which break statements that aren't escaped properly
+var hostileUsernames = []string{
+	`o'brien`,
+	`x'@'%' IDENTIFIED BY 'pw`,
+	`back\slash`,
+	"semi;colon",
+}
+
+const canaryTable = "conformance_canary"
+
+// sessionEndTimeout is how long a closed session may still count as active
+const sessionEndTimeout = 5 * time.Second
+
+// Identifiers which try to reach the canary table
+var hostileIdentifiers = []string{
+	"x`; DROP TABLE " + canaryTable + "; --",
+	`x"; DROP TABLE ` + canaryTable + `; --`,
+	"x'; DROP TABLE " + canaryTable + "; --",
+	"x]; DROP TABLE " + canaryTable + "; --",
+}
+
+func testCredentialLifecycle(t *testing.T, admin dbadmin.DbAdmin, backend Backend) {
+	username := backend.UsernamePrefix + "lifecycle"
+	password, rotated := "Initial-Pa55word-1", "Rotated-Pa55word-2"
+
+	if err := admin.WriteCredentials(username, password); err != nil {
+		t.Fatalf("Unable to write credentials: %v", err)
+	}
+	defer deleteCredentials(admin, username)
+
+	expectUsernames(t, admin, backend.UsernamePrefix, username)
+	expectLogin(t, backend, username, password, true)
+
+	if err := admin.RotateCredentials([]dbadmin.Credentials{{Username: username, Password: rotated}}); err != nil {
+		t.Fatalf("Unable to rotate credentials: %v", err)
+	}
+	expectLogin(t, backend, username, rotated, true)
+	expectLogin(t, backend, username, password, false)
+
+	server, err := admin.DetectServer()
+	if err != nil {
+		t.Fatalf("Unable to detect server: %v", err)
+	}
+	if server.HasFeature(dbadmin.FeatureDualPasswords) {
+		retained := "Retained-Pa55word-3"
+		if err := admin.RotateCredentials([]dbadmin.Credentials{{Username: username, Password: retained, RetainCurrentPassword: true}}); err != nil {
+			t.Fatalf("Unable to rotate credentials retaining the current password: %v", err)
+		}
+		expectLogin(t, backend, username, retained, true)
+		expectLogin(t, backend, username, rotated, true)
+
+		if err := admin.DiscardOldPasswords([]string{username}); err != nil {
+			t.Fatalf("Unable to discard old passwords: %v", err)
+		}
+		expectLogin(t, backend, username, retained, true)
+		expectLogin(t, backend, username, rotated, false)
+		rotated = retained
+	}
+
+	if err := deleteCredentials(admin, username); err != nil {
+		t.Fatalf("Unable to delete credentials: %v", err)
+	}
+	expectUsernames(t, admin, backend.UsernamePrefix)
+	expectLogin(t, backend, username, rotated, false)
+}
+
+func testIdempotency(t *testing.T, admin dbadmin.DbAdmin, backend Backend) {
+	username := backend.UsernamePrefix + "idempotent"
+	credentials := []dbadmin.Credentials{{Username: username, Password: "Idempotent-Pa55word-1"}}
+
+	if err := admin.WriteCredentialsBatch(credentials); err != nil {
+		t.Fatalf("Unable to write credentials: %v", err)
+	}
+	defer deleteCredentials(admin, username)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if err := admin.ReapplyGrants(credentials); err != nil {
+			t.Errorf("Granting privileges again failed on attempt %d: %v", attempt, err)
+		}
+		if err := admin.RotateCredentials(credentials); err != nil {
+			t.Errorf("Rotating to the same password failed on attempt %d: %v", attempt, err)
+		}
+	}
+	expectUsernames(t, admin, backend.UsernamePrefix, username)
+	expectLogin(t, backend, username, credentials[0].Password, true)
+
+	first, err := admin.DetectServer()
+	if err != nil {
+		t.Fatalf("Unable to detect server: %v", err)
+	}
+	second, err := admin.DetectServer()
+	if err != nil {
+		t.Fatalf("Unable to detect server again: %v", err)
+	}
+	if first.Flavor != second.Flavor || first.Version != second.Version {
+		t.Errorf("Server detection changed from %+v to %+v", first, second)
+	}
+
+	if err := admin.DropView("conformance_missing_view"); err != nil {
+		t.Errorf("Dropping a view which doesn't exist should succeed: %v", err)
+	}
+}
+
+func testHostilePasswords(t *testing.T, admin dbadmin.DbAdmin, backend Backend) {
+	for i, password := range hostilePasswords {
+		username := fmt.Sprintf("%spassword%d", backend.UsernamePrefix, i)
+		if err := admin.WriteCredentials(username, password); err != nil {
+			t.Errorf("Unable to write credentials with password %q: %v", password, err)
+			continue
+		}
+		expectLogin(t, backend, username, password, true)
+
+		rotated := password + "-rotated"
+		if err := admin.RotateCredentials([]dbadmin.Credentials{{Username: username, Password: rotated}}); err != nil {
+			t.Errorf("Unable to rotate to password %q: %v", rotated, err)
+		} else {
+			expectLogin(t, backend, username, rotated, true)
+		}
+
+		if err := deleteCredentials(admin, username); err != nil {
+			t.Errorf("Unable to delete user %s: %v", username, err)
+		}
+	}
+	expectUsernames(t, admin, backend.UsernamePrefix)
+}
+
+// testHostileUsernames requires that each username is either refused, or
+// created exactly as it was written and nothing else
+func testHostileUsernames(t *testing.T, admin dbadmin.DbAdmin, backend Backend) {
+	for _, suffix := range hostileUsernames {
+		username := backend.UsernamePrefix + suffix
+		if err := admin.WriteCredentials(username, "Hostile-Pa55word-1"); err != nil {
+			expectUsernames(t, admin, backend.UsernamePrefix)
+			continue
+		}
+
+		expectUsernames(t, admin, backend.UsernamePrefix, username)
+		if err := admin.VerifyUnusedAndDeleteCredentials(username); err != nil {
+			t.Errorf("Unable to delete user %q: %v", username, err)
+		}
+		expectUsernames(t, admin, backend.UsernamePrefix)
+	}
+}
+
+// testUsernameLimit requires that the backend reports its username limit,
+// accepts a derived username of exactly that length, and refuses a longer
+// one with a permanent error
+func testUsernameLimit(t *testing.T, admin dbadmin.DbAdmin, backend Backend) {
+	server, err := admin.DetectServer()
+	if err != nil {
+		t.Fatalf("Unable to detect server: %v", err)
+	}
+	limit := server.MaxUsernameLength
+	if limit <= 0 {
+		t.Fatalf("The server must report its username limit, got %d", limit)
+	}
+
+	long := strings.Repeat("limit", limit)
+	username, err := dbadmin.DeriveUsername(backend.UsernamePrefix, long, limit)
+	if err != nil {
+		t.Fatalf("Unable to derive a username: %v", err)
+	}
+	if err := admin.WriteCredentials(username, "Limit-Pa55word-1"); err != nil {
+		t.Errorf("Unable to write credentials for %s of %d characters: %v", username, limit, err)
+	} else {
+		expectUsernames(t, admin, backend.UsernamePrefix, username)
+		if err := deleteCredentials(admin, username); err != nil {
+			t.Errorf("Unable to delete user %s: %v", username, err)
+		}
+	}
+
+	err = admin.WriteCredentials(backend.UsernamePrefix+long, "Limit-Pa55word-1")
+	expectPermanent(t, "writing credentials beyond the username limit", err)
+	expectUsernames(t, admin, backend.UsernamePrefix)
+}
+
+// testHostileIdentifiers requires that names of tables, columns and views
+// can't reach the canary table, whether or not they are refused
+func testHostileIdentifiers(t *testing.T, admin dbadmin.DbAdmin, backend Backend) {
+	if backend.CanaryTableDefinition == "" {
+		t.Skip("The backend has no canary table definition")
+	}
+	if err := admin.RestoreTable(canaryTable, backend.CanaryTableDefinition); err != nil {
+		t.Fatalf("Unable to create canary table: %v", err)
+	}
+
+	for _, name := range hostileIdentifiers {
+		admin.PurgeRows(name, "created_at", time.Hour, 1)
+		admin.PurgeRows(canaryTable, name, time.Hour, 1)
+		admin.DropView(name)
+		admin.ScanOrphanedRows(dbadmin.OrphanScan{Table: name, Column: "id", ReferencedTable: canaryTable, ReferencedColumn: "id", Limit: 1})
+		if _, err := admin.GetTableDefinition(name); err == nil {
+			t.Errorf("Expected no table to be named %q", name)
+		}
+	}
+
+	tables, err := admin.ListTables()
+	if err != nil {
+		t.Fatalf("Unable to list tables: %v", err)
+	}
+	for _, table := range tables {
+		if table == canaryTable {
+			return
+		}
+	}
+	t.Errorf("The canary table was dropped, tables are %v", tables)
+}
+
+func testErrorClassification(t *testing.T, admin dbadmin.DbAdmin, backend Backend) {
+	username := backend.UsernamePrefix + "classification"
+	if err := admin.WriteCredentials(username, "Classified-Pa55word-1"); err != nil {
+		t.Fatalf("Unable to write credentials: %v", err)
+	}
+	defer deleteCredentials(admin, username)
+
+	expectPermanent(t, "creating a user which exists", admin.WriteCredentials(username, "Classified-Pa55word-2"))
+	expectPermanent(t, "rotating a user which doesn't exist", admin.RotateCredentials([]dbadmin.Credentials{
+		{Username: backend.UsernamePrefix + "missing", Password: "Missing-Pa55word-1"},
+	}))
+	expectPermanent(t, "deleting a user which doesn't exist", admin.VerifyUnusedAndDeleteCredentials(backend.UsernamePrefix+"missing"))
+
+	release, err := admin.AcquireOperatorLock(time.Second)
+	if err != nil {
+		t.Fatalf("Unable to acquire operator lock: %v", err)
+	}
+	defer release()
+
+	other := backend.Connect(t)
+	defer other.Close()
+	if otherRelease, err := other.AcquireOperatorLock(time.Second); err == nil {
+		otherRelease()
+		t.Error("Expected a held operator lock to exclude another admin")
+	} else {
+		expectTemporary(t, "waiting for a held operator lock", err)
+	}
+}
+
+// deleteCredentials deletes the user once the sessions which the suite
+// opened as it have ended, which the server may notice a moment after they
+// were closed
+func deleteCredentials(admin dbadmin.DbAdmin, username string) error {
+	deadline := time.Now().Add(sessionEndTimeout)
+	for {
+		err := admin.VerifyUnusedAndDeleteCredentials(username)
+		var enhanced xerrors.EnhancedError
+		if err == nil || !errors.As(err, &enhanced) || !enhanced.Temporary() || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// dropLeftovers removes the users of an earlier run which didn't finish
+func dropLeftovers(t *testing.T, admin dbadmin.DbAdmin, prefix string) {
+	usernames, err := admin.ListUsernames(prefix)
+	if err != nil {
+		t.Fatalf("Unable to list usernames: %v", err)
+	}
+	for _, username := range usernames {
+		if err := deleteCredentials(admin, username); err != nil {
+			t.Fatalf("Unable to drop user %s left over from an earlier run: %v", username, err)
+		}
+	}
+}
+
+func expectUsernames(t *testing.T, admin dbadmin.DbAdmin, prefix string, expected ...string) {
+	t.Helper()
+	usernames, err := admin.ListUsernames(prefix)
+	if err != nil {
+		t.Errorf("Unable to list usernames: %v", err)
+		return
+	}
+
+	sort.Strings(usernames)
+	sort.Strings(expected)
+	if fmt.Sprint(usernames) != fmt.Sprint(expected) {
+		t.Errorf("Expected users %q, found %q", expected, usernames)
+	}
+}
+
+func expectLogin(t *testing.T, backend Backend, username, password string, accepted bool) {
+	t.Helper()
+	if backend.Login == nil {
+		return
+	}
+
+	err := backend.Login(username, password)
+	if accepted && err != nil {
+		t.Errorf("Expected %s to log in with password %q: %v", username, password, err)
+	} else if !accepted && err == nil {
+		t.Errorf("Expected %s to be refused with password %q", username, password)
+	}
+}
+
+func expectPermanent(t *testing.T, action string, err error) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("Expected an error %s", action)
+		return
+	}
+	var enhanced xerrors.EnhancedError
+	if errors.As(err, &enhanced) && enhanced.Temporary() {
+		t.Errorf("Expected a permanent error %s, retrying doesn't help: %v", action, err)
+	}
+}
+
+func expectTemporary(t *testing.T, action string, err error) {
+	t.Helper()
+	var enhanced xerrors.EnhancedError
+	if !errors.As(err, &enhanced) || !enhanced.Temporary() {
+		t.Errorf("Expected a temporary error %s: %v", action, err)
+	}
+}
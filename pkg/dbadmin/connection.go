@@ -0,0 +1,167 @@
+package dbadmin
+
+import "time"
+
+// TLSMode controls whether, and how strictly, TLS is used when connecting to
+// a database
+type TLSMode string
+
+const (
+	// TLSModeDisabled never uses TLS, and is the default
+	TLSModeDisabled TLSMode = "disabled"
+
+	// TLSModePreferred uses TLS when the server supports it
+	TLSModePreferred TLSMode = "preferred"
+
+	// TLSModeRequired requires TLS and verifies the server certificate
+	TLSModeRequired TLSMode = "required"
+
+	// TLSModeSkipVerify requires TLS but does not verify the server
+	// certificate
+	TLSModeSkipVerify TLSMode = "skip-verify"
+)
+
+// ConnectionSpec is an engine independent description of how to connect to a
+// database, which a DSNBuilder can turn into the engine's DSN format.
+type ConnectionSpec struct {
+	// Host may be a host name, an IPv4 address, or an IPv6 address with or
+	// without brackets
+	Host string
+	Port int
+
+	// Hosts are the candidate servers when Host isn't set, a HostSelector
+	// chooses one of them before a DSN can be built
+	Hosts         []HostPort
+	HostSelection HostSelection
+
+	// Socket is the path of a Unix socket, such as one provided by a proxy
+	// sidecar, which is used instead of Host and Port
+	Socket string
+
+	// Dialer names a custom dialer registered with the engine, which is used
+	// to connect to Host and Port instead of a plain TCP connection
+	Dialer string
+
+	Database string
+	Username string
+	Password string
+	TLS      TLSMode
+
+	// ServerPublicKey is the PEM encoded RSA public key of the server, which
+	// is used to encrypt the password when authenticating without TLS
+	ServerPublicKey string
+
+	// AllowPublicKeyRetrieval permits the client to ask the server for its
+	// public key when authenticating without TLS, which trusts the network
+	// not to substitute a key of its own
+	AllowPublicKeyRetrieval bool
+
+	// Params contains engine specific connection parameters
+	Params map[string]string
+}
+
+// HostPort is a server which a ConnectionSpec may connect to, a zero Port is
+// the spec's Port
+type HostPort struct {
+	Host string
+	Port int
+}
+
+// HostSelection is the policy for choosing between the Hosts of a
+// ConnectionSpec
+type HostSelection string
+
+const (
+	// HostSelectionFirstAvailable chooses the first host which answers
+	// queries, and is the default
+	HostSelectionFirstAvailable HostSelection = "first-available"
+
+	// HostSelectionPrimary chooses the first host which accepts writes
+	HostSelectionPrimary HostSelection = "primary"
+)
+
+// HostSelector health checks the Hosts of a ConnectionSpec, and returns the
+// spec with Host and Port set to the one chosen by its HostSelection
+type HostSelector interface {
+	SelectHost(spec ConnectionSpec) (ConnectionSpec, error)
+}
+
+// DSNBuilder turns a ConnectionSpec into the DSN or URI format expected by a
+// particular engine's driver, rejecting parameter combinations which the
+// engine would misinterpret.
+type DSNBuilder interface {
+	BuildDSN(spec ConnectionSpec) (string, error)
+}
+
+// DSNParser returns the host, or the socket path, which a DSN in a
+// particular engine's format connects to
+type DSNParser interface {
+	DSNHost(dsn string) (string, error)
+}
+
+// EndpointChecker verifies that a server accepts the credentials in a
+// ConnectionSpec and can serve queries on its database
+type EndpointChecker interface {
+	CheckEndpoint(spec ConnectionSpec) error
+}
+
+// GrantProbe is one privilege which is exercised while connected as an
+// issued user, on a table which doesn't exist so that nothing is changed
+type GrantProbe struct {
+	// Database is the schema which is probed, empty refers to the database
+	// in the ConnectionSpec
+	Database  string
+	Privilege string
+
+	// Expected is true if the privilege should have been granted
+	Expected bool
+}
+
+// GrantProbeResult records whether the server agreed with a probe
+type GrantProbeResult struct {
+	Probe   GrantProbe
+	Allowed bool
+}
+
+// GrantProber connects as an issued user and checks which of the probed
+// privileges the server lets it use
+type GrantProber interface {
+	ProbeGrants(spec ConnectionSpec, probes []GrantProbe) ([]GrantProbeResult, error)
+}
+
+// GrantProbes returns the probes which check a set of grants: every granted
+// database must be readable, unless only routines were granted on it, and no
+// database may be dropped
+func GrantProbes(grants []DatabaseGrant) []GrantProbe {
+	if len(grants) == 0 {
+		grants = []DatabaseGrant{{Class: GrantClassReadWrite}}
+	}
+
+	readable := make(map[string]bool, len(grants))
+	var databases []string
+	for _, grant := range grants {
+		if _, ok := readable[grant.Database]; !ok {
+			databases = append(databases, grant.Database)
+		}
+
+		// Table scoped grants don't cover a table which doesn't exist, and
+		// replication grants are on the server rather than the database
+		wholeDatabase := len(grant.Tables) == 0 && grant.Class != GrantClassExecute && grant.Class != GrantClassReplication
+		readable[grant.Database] = readable[grant.Database] || wholeDatabase
+	}
+
+	probes := make([]GrantProbe, 0, 2*len(databases))
+	for _, database := range databases {
+		if readable[database] {
+			probes = append(probes, GrantProbe{Database: database, Privilege: "SELECT", Expected: true})
+		}
+		probes = append(probes, GrantProbe{Database: database, Privilege: "DROP", Expected: false})
+	}
+	return probes
+}
+
+// ValueQuerier connects as an issued user and runs a query which returns a
+// single numeric value, in a read-only transaction
+type ValueQuerier interface {
+	QueryValue(spec ConnectionSpec, query string, timeout time.Duration) (float64, error)
+}
@@ -1,24 +1,730 @@
 package dbadmin
 
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GrantClass names a set of privileges which can be granted on a database
+type GrantClass string
+
+const (
+	// GrantClassReadWrite allows reading and writing data, but not altering
+	// the schema
+	GrantClassReadWrite GrantClass = "readwrite"
+
+	// GrantClassReadOnly allows only reading data
+	GrantClassReadOnly GrantClass = "readonly"
+
+	// GrantClassExecute allows calling the stored procedures and functions
+	// in the database. The routines run with the caller's privileges unless
+	// they were defined otherwise, so it is usually combined with another
+	// class.
+	GrantClassExecute GrantClass = "execute"
+
+	// GrantClassReplication allows streaming the binary log, as change data
+	// capture consumers do. It applies to the whole server, so it can't be
+	// scoped to a database or tables.
+	GrantClassReplication GrantClass = "replication"
+)
+
+// DatabaseGrant pairs a database with the class of privileges that should
+// be granted on it. An empty Database refers to the database the DbAdmin is
+// connected to.
+type DatabaseGrant struct {
+	Database string
+	Class    GrantClass
+
+	// Tables restricts the grant to the listed tables, when empty it covers
+	// the whole database
+	Tables []TableGrant
+}
+
+// TableGrant scopes a grant to a single table, and optionally to some of its
+// columns. Column scoping is only supported for read-only grants, since rows
+// can't be deleted column by column.
+type TableGrant struct {
+	Table   string
+	Columns []string
+}
+
+// ColumnMask names a function which hides the value of a column in a masked
+// view
+type ColumnMask string
+
+const (
+	// MaskNone passes the value through unchanged
+	MaskNone ColumnMask = ""
+
+	// MaskRedact replaces every value with a fixed placeholder
+	MaskRedact ColumnMask = "redact"
+
+	// MaskHash replaces values with their SHA-256 hash, so that they can
+	// still be joined and counted
+	MaskHash ColumnMask = "hash"
+
+	// MaskNull replaces every value with NULL
+	MaskNull ColumnMask = "null"
+)
+
+// ViewColumn is a column of a table which is exposed through a masked view
+type ViewColumn struct {
+	Name string
+	Mask ColumnMask
+}
+
+// MaskedView is a view of a single table which only exposes the listed
+// columns, masking some of them. It runs with the privileges of its definer,
+// so users only need to be granted access to the view itself.
+type MaskedView struct {
+	Name    string
+	Table   string
+	Columns []ViewColumn
+}
+
+// ScheduledStatement is SQL which the server runs on a fixed interval
+type ScheduledStatement struct {
+	Name      string
+	Interval  time.Duration
+	Statement string
+	Disabled  bool
+}
+
+// OrphanScan is one batch of a scan for rows whose column references a row
+// of another table which doesn't exist
+type OrphanScan struct {
+	Table            string
+	Column           string
+	ReferencedTable  string
+	ReferencedColumn string
+
+	// After is the value of the column which the batch continues after, the
+	// scan starts from the lowest value when empty
+	After string
+	Limit int
+}
+
+// OrphanScanResult counts the rows of a batch of an OrphanScan
+type OrphanScanResult struct {
+	Scanned  int64
+	Orphaned int64
+
+	// Last is the highest value of the column in the batch, which the next
+	// batch continues after
+	Last string
+}
+
+// UpgradeFinding is something which would break, or has been deprecated, in
+// the server version that an upgrade is being prepared for
+type UpgradeFinding struct {
+	Check string
+
+	// Object is the table, column, routine, user or variable concerned
+	Object  string
+	Message string
+
+	// Blocking is true if the upgrade would fail or change behavior, rather
+	// than only deprecate what was found
+	Blocking bool
+}
+
+// SeedStep is one step of applying seed data, either a statement or rows
+// which are inserted into a table, replacing the rows with the same keys.
+// A value of \N in Rows is NULL.
+type SeedStep struct {
+	Statement string
+
+	Table   string
+	Columns []string
+	Rows    [][]string
+}
+
+// MigrationFile is one file of an embedded migration, Checksum identifies its
+// content once it has been applied
+type MigrationFile struct {
+	Name       string
+	Checksum   string
+	Statements []string
+}
+
+// Partition is one range of a table which is partitioned by time
+type Partition struct {
+	Name string
+
+	// End is the exclusive upper bound of the partition's range. It is zero
+	// for the partition which catches every later value.
+	End time.Time
+}
+
+// Lease records which operator instance may change a database, other
+// instances only observe it until the lease expires
+type Lease struct {
+	Holder    string
+	Remaining time.Duration
+
+	// PreviousHolder is set when the lease was taken over from another
+	// holder whose lease had expired
+	PreviousHolder string
+}
+
+// SessionSettings are applied to every session which the operator opens to
+// a database, zero values leave the server's defaults in place
+type SessionSettings struct {
+	SQLMode string
+
+	// LockWaitTimeout limits how long DDL waits for metadata locks, and
+	// InnoDBLockWaitTimeout how long statements wait for row locks
+	LockWaitTimeout       time.Duration
+	InnoDBLockWaitTimeout time.Duration
+
+	// StatementTimeout limits how long a single statement may run, servers
+	// only enforce it for SELECT statements, except MariaDB
+	StatementTimeout time.Duration
+}
+
+// Charset is the default character set and collation of a database
+type Charset struct {
+	CharacterSet string
+	Collation    string
+
+	// TableCollations counts the tables of the database by their collation
+	TableCollations map[string]int
+}
+
+// AuthPlugin names the authentication plugin a database user is created with
+type AuthPlugin string
+
+const (
+	// AuthPluginDefault uses the server's default authentication plugin
+	AuthPluginDefault AuthPlugin = ""
+
+	// AuthPluginCachingSHA2Password is the default plugin for MySQL 8
+	AuthPluginCachingSHA2Password AuthPlugin = "caching_sha2_password"
+
+	// AuthPluginNativePassword is the plugin used by MySQL 5.x, and is
+	// required by some older clients
+	AuthPluginNativePassword AuthPlugin = "mysql_native_password"
+
+	// AuthPluginOpenIDConnect authenticates with a JWT issued by an OpenID
+	// Connect identity provider instead of a password, and requires an
+	// IdentityMapping
+	AuthPluginOpenIDConnect AuthPlugin = "authentication_openid_connect"
+)
+
+// BinlogConsumer is a session which is streaming the binary log
+type BinlogConsumer struct {
+	Username string
+	Host     string
+
+	// StreamingFor is how long the session has been streaming
+	StreamingFor time.Duration
+}
+
+// AccountActivity contains the connection counters of a database user
+type AccountActivity struct {
+	Username string
+
+	// CurrentConnections is the number of sessions open right now
+	CurrentConnections int64
+
+	// TotalConnections is the number of sessions opened since the server
+	// started tracking the user, including the current ones
+	TotalConnections int64
+}
+
+// CapacityUsage is the share of a server's capacity used by a database
+type CapacityUsage struct {
+	// DataBytes and IndexBytes are estimated from the table statistics
+	DataBytes  int64
+	IndexBytes int64
+
+	// Connections is the number of sessions using the database right now
+	Connections int64
+
+	// Statements is the number of statements run against the database since
+	// the server started counting them
+	Statements int64
+}
+
+// ReplicationHealth describes the replication load on a server
+type ReplicationHealth struct {
+	// BinlogBytes is the combined size of the binary logs on the server,
+	// zero when binary logging is disabled
+	BinlogBytes int64
+
+	// ReplicaLag is how far the furthest behind replica is, zero when the
+	// lag can't be observed from the server
+	ReplicaLag time.Duration
+}
+
+// LogPosition is a position in the binary log of a server, from which a
+// point in time restore can be stopped or started
+type LogPosition struct {
+	File     string
+	Position int64
+
+	// GTIDSet is the set of transactions the server has written to its
+	// binary log, empty when the server doesn't use GTIDs
+	GTIDSet string
+}
+
+// ServerFlavor identifies the distribution of a database server, which can
+// behave differently from others that speak the same protocol
+type ServerFlavor string
+
+const (
+	// FlavorMySQL is Oracle MySQL, and compatible builds such as Percona
+	FlavorMySQL ServerFlavor = "mysql"
+
+	// FlavorMariaDB is MariaDB
+	FlavorMariaDB ServerFlavor = "mariadb"
+
+	// FlavorTiDB is PingCAP TiDB
+	FlavorTiDB ServerFlavor = "tidb"
+
+	// FlavorAuroraMySQL is Amazon Aurora with MySQL compatibility
+	FlavorAuroraMySQL ServerFlavor = "aurora-mysql"
+)
+
+// ServerInfo describes the server a DbAdmin is connected to
+type ServerInfo struct {
+	Flavor ServerFlavor
+
+	// Version is the version of the flavor itself, e.g. the TiDB release
+	// rather than the MySQL version it is compatible with
+	Version string
+
+	// Raw is the version string reported by the server
+	Raw string
+
+	// Instance identifies the physical server as hostname:port, which is
+	// the same for every database and address that reaches it
+	Instance string
+
+	// Database is the database on the server which the DbAdmin manages
+	Database string
+
+	// Features are the optional capabilities the server supports
+	Features []ServerFeature
+
+	// MaxUsernameLength is the longest username in characters which the
+	// server accepts, DeriveUsername fits issued usernames within it
+	MaxUsernameLength int
+}
+
+// ServerFeature is an optional capability which only some servers support
+type ServerFeature string
+
+const (
+	// FeatureRoles is support for CREATE ROLE and granting roles to users
+	FeatureRoles ServerFeature = "roles"
+
+	// FeatureInstantDDL is support for ALGORITHM=INSTANT column changes
+	FeatureInstantDDL ServerFeature = "instant-ddl"
+
+	// FeatureCheckConstraints is support for enforced CHECK constraints
+	FeatureCheckConstraints ServerFeature = "check-constraints"
+
+	// FeatureUserAttributes is support for recording JSON attributes on
+	// users, and reading them back from information_schema
+	FeatureUserAttributes ServerFeature = "user-attributes"
+
+	// FeaturePersistedVariables is support for SET PERSIST, which keeps a
+	// global variable across restarts
+	FeaturePersistedVariables ServerFeature = "persisted-variables"
+
+	// FeatureDualPasswords is support for keeping a user's previous password
+	// valid alongside a new one, until it is discarded
+	FeatureDualPasswords ServerFeature = "dual-passwords"
+)
+
+// HasFeature returns true if the server supports the feature
+func (si ServerInfo) HasFeature(feature ServerFeature) bool {
+	for _, supported := range si.Features {
+		if supported == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareVersions compares dotted numeric versions, returning a negative
+// number, zero, or a positive number like strings.Compare. Missing
+// components are treated as zero.
+func CompareVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+		if aNum != bNum {
+			return aNum - bNum
+		}
+	}
+	return 0
+}
+
+// IdentityMapping configures which external identity may authenticate as a
+// database user, for auth plugins which don't use a password
+type IdentityMapping struct {
+	// Provider is the name of the identity provider as configured on the
+	// database server
+	Provider string
+
+	// Subject is the subject claim which tokens must carry, e.g. the
+	// Kubernetes service account the workload runs as
+	Subject string
+}
+
+// Credentials pairs a database username with the password that should be
+// used to authenticate as that user.
+type Credentials struct {
+	Username string
+	Password string
+
+	// Grants lists the databases the user can access. When empty the user is
+	// granted read-write access to the database the DbAdmin is connected to.
+	Grants []DatabaseGrant
+
+	// AuthPlugin selects how the user authenticates, the server default is
+	// used when empty.
+	AuthPlugin AuthPlugin
+
+	// Identity is required by auth plugins which don't use a password, in
+	// which case Password is ignored
+	Identity *IdentityMapping
+
+	// Attributes are recorded on the user when the server supports it. When
+	// rotating they are merged into the recorded ones, so only the fields
+	// which changed need to be set.
+	Attributes *UserAttributes
+
+	// RetainCurrentPassword keeps the current password valid alongside the
+	// new one when rotating on servers with FeatureDualPasswords, until
+	// DiscardOldPasswords is called
+	RetainCurrentPassword bool
+}
+
+// UserAttributes trace a database user back to what it was created for
+type UserAttributes struct {
+	// Database is the namespace/name of the ManagedDatabase the user was
+	// created in
+	Database    string `json:"database,omitempty"`
+	DatabaseUID string `json:"databaseUID,omitempty"`
+
+	// Owner is the kind/namespace/name of the object which the user was
+	// issued for, a DatabaseMigration or a DatabaseCredentialRequest
+	Owner    string `json:"owner,omitempty"`
+	OwnerUID string `json:"ownerUID,omitempty"`
+
+	Class GrantClass `json:"class,omitempty"`
+
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+
+	// RotationGeneration counts the rotations of the user's password
+	RotationGeneration int64      `json:"rotationGeneration,omitempty"`
+	RotatedAt          *time.Time `json:"rotatedAt,omitempty"`
+}
+
+// UserPrivilege is one privilege held by a user. An empty Database refers
+// to the database the DbAdmin is connected to and a Database of * to every
+// database, an empty Table covers every table of the database and an empty
+// Column every column of the table.
+type UserPrivilege struct {
+	Database  string `json:"database,omitempty"`
+	Table     string `json:"table,omitempty"`
+	Column    string `json:"column,omitempty"`
+	Privilege string `json:"privilege"`
+}
+
+// PrivilegeUse counts the statements run by a user which needed one
+// privilege, since the server started counting
+type PrivilegeUse struct {
+	Username   string
+	Privilege  string
+	Statements int64
+}
+
 // DbAdmin contains the methods that are used to introspect runtime state
 // and control access to a database
 type DbAdmin interface {
 	// WriteCredentials will add a username to the database with the given password
 	WriteCredentials(username, password string) error
 
+	// WriteCredentialsBatch will add all of the specified users to the
+	// database, using as few round trips as the backend allows. Provisioning
+	// is atomic: if the users can't be fully granted their privileges, any
+	// users created by the call are removed before the error is returned.
+	WriteCredentialsBatch(credentials []Credentials) error
+
+	// RotateCredentials will replace the passwords of the specified users,
+	// all of which must already exist in the database.
+	RotateCredentials(credentials []Credentials) error
+
+	// DiscardOldPasswords will stop accepting the passwords which were
+	// retained when the specified users were rotated. It does nothing on
+	// servers without FeatureDualPasswords.
+	DiscardOldPasswords(usernames []string) error
+
+	// ReapplyGrants will grant the specified users, all of which must
+	// already exist in the database, their privileges again, and revoke the
+	// privileges they hold which aren't in the grants.
+	ReapplyGrants(credentials []Credentials) error
+
 	// ListUsernames will return a list of all usernames in the database with
 	// the given prefix.
 	ListUsernames(usernamePrefix string) ([]string, error)
 
+	// ListUserAttributes will return the attributes recorded on every user
+	// with the given prefix, keyed by username. Users without attributes are
+	// left out, as are all users on servers which can't record them.
+	ListUserAttributes(usernamePrefix string) (map[string]UserAttributes, error)
+
+	// ListUserPrivileges will return the privileges held by every user with
+	// the given prefix, keyed by username and sorted
+	ListUserPrivileges(usernamePrefix string) (map[string][]UserPrivilege, error)
+
+	// ListPrivilegeUse will return how many statements needing each of the
+	// SELECT, INSERT, UPDATE, DELETE and EXECUTE privileges were run by the
+	// users with the given prefix. Users which the server hasn't counted
+	// any statements for are left out.
+	ListPrivilegeUse(usernamePrefix string) ([]PrivilegeUse, error)
+
+	// RevokePrivileges will take the specified privileges away from a user,
+	// the user keeps all of its other privileges
+	RevokePrivileges(username string, privileges []UserPrivilege) error
+
 	// VerifyUnusedAndDeleteCredentials will ensure that there are no current
 	// connections using the specified username, and then delete the user.
 	// If there is an active connection using the credentials an error will be
 	// returned.
 	VerifyUnusedAndDeleteCredentials(username string) error
 
+	// PrimaryAddress will return the host:port address of the server which
+	// accepts writes, discovering it if the database is replicated. It is
+	// empty if the database isn't reached over the network.
+	PrimaryAddress() (string, error)
+
+	// AcquireOperatorLock will take a lock on the server which excludes
+	// every other operator instance managing the same database, waiting up
+	// to the timeout for it to be released. The returned function releases
+	// the lock.
+	AcquireOperatorLock(timeout time.Duration) (func() error, error)
+
+	// AcquireLease will take or renew the holder's lease on the database for
+	// the duration, unless another holder's lease hasn't expired yet, in
+	// which case that lease is returned instead. It must be called with the
+	// operator lock held.
+	AcquireLease(holder string, duration time.Duration) (Lease, error)
+
+	// ExecutePlan will run the steps of the plan in order, resuming after
+	// the last step recorded by the plan's Checkpointer.
+	ExecutePlan(plan AdminPlan) error
+
+	// RenderPlan will return the statements that executing the plan would
+	// run, without running any of them. Secret values, such as passwords,
+	// are redacted.
+	RenderPlan(plan AdminPlan) ([]string, error)
+
+	// ListAccountActivity will return the connection counters of every user
+	// with the given prefix which has connected since the server started.
+	ListAccountActivity(usernamePrefix string) ([]AccountActivity, error)
+
+	// ListBinlogConsumers will return the sessions of users with the given
+	// prefix which are streaming the binary log right now.
+	ListBinlogConsumers(usernamePrefix string) ([]BinlogConsumer, error)
+
+	// GetCapacityUsage will return the storage, sessions and statements
+	// attributable to the database.
+	GetCapacityUsage() (CapacityUsage, error)
+
+	// LockCredentials will prevent any new connections from being made
+	// with the specified username, without dropping the user or
+	// interrupting its existing sessions.
+	LockCredentials(username string) error
+
+	// UnlockCredentials will allow a user which was locked to connect
+	// again.
+	UnlockCredentials(username string) error
+
+	// EnsureHelperRoutines will create the named helper routines, which the
+	// backend ships, if they don't exist yet. Routines whose definitions no
+	// longer match what was shipped are returned, and are only replaced when
+	// repair is true.
+	EnsureHelperRoutines(names []string, repair bool) ([]string, error)
+
+	// WriteMaskedView will create the view, or replace the definition of an
+	// existing view with the same name.
+	WriteMaskedView(view MaskedView) error
+
+	// GetViewChecksum will return a checksum of the definition of the view
+	// as stored by the server, or an empty string if there is no such view.
+	GetViewChecksum(name string) (string, error)
+
+	// DropView will remove the view, if it exists.
+	DropView(name string) error
+
+	// WriteScheduledStatement will create the scheduled statement, or
+	// replace the definition of an existing one with the same name.
+	WriteScheduledStatement(statement ScheduledStatement) error
+
+	// GetScheduledStatementChecksum will return a checksum of the definition
+	// and schedule of the scheduled statement as stored by the server, or an
+	// empty string if there is no such statement.
+	GetScheduledStatementChecksum(name string) (string, error)
+
+	// DropScheduledStatement will remove the scheduled statement, if it
+	// exists.
+	DropScheduledStatement(name string) error
+
+	// ListPartitions will return the partitions of a table which is
+	// partitioned by ranges of time, in order.
+	ListPartitions(table string) ([]Partition, error)
+
+	// AddPartitions will append the partitions to the end of the table's
+	// ranges, before the partition catching later values if there is one.
+	AddPartitions(table string, partitions []Partition) error
+
+	// DropPartitions will remove the named partitions and the rows in them.
+	DropPartitions(table string, names []string) error
+
+	// GetCharset will return the default character set and collation of the
+	// database, and the collations of its tables.
+	GetCharset() (Charset, error)
+
+	// SetCharset will change the default character set and collation of the
+	// database, which only applies to tables created afterwards.
+	SetCharset(characterSet, collation string) error
+
+	// ApplySeedData will run the steps in a single transaction, so that none
+	// of them are applied if one fails. Statements may only insert, replace,
+	// update or delete rows.
+	ApplySeedData(steps []SeedStep) error
+
+	// ApplyMigrationFiles will apply the files of an embedded migration in
+	// order, each in a transaction of its own, and then record version as
+	// the schema version. Files which were already applied for the version
+	// are skipped, and files whose checksum has changed since are refused.
+	ApplyMigrationFiles(version string, files []MigrationFile) error
+
+	// PurgeRows will delete up to limit of the rows of the table whose
+	// column is older than the server's clock by more than olderThan, the
+	// oldest first, and return how many were deleted.
+	PurgeRows(table, column string, olderThan time.Duration, limit int) (int64, error)
+
+	// ScanOrphanedRows will read a batch of the rows of the scan's table, in
+	// order of the column, and count those whose value isn't found in the
+	// referenced table. Rows whose column is NULL don't reference anything.
+	ScanOrphanedRows(scan OrphanScan) (OrphanScanResult, error)
+
+	// CheckUpgradeReadiness will scan the schema, and the users with any of
+	// the prefixes, for features which are removed or deprecated in the
+	// target version of the server. Nothing is changed.
+	CheckUpgradeReadiness(target string, usernamePrefixes []string) ([]UpgradeFinding, error)
+
 	// GetSchemaVersion will return the current version of the database, usually
 	// as decoded by a MigrationEngine instance.
 	GetSchemaVersion() (string, error)
+
+	// ExportSchema will return the DDL which recreates every table and view
+	// in the database, without any of the data, in a stable order.
+	ExportSchema() (string, error)
+
+	// ListTables will return the names of the tables in the database, in
+	// order, leaving out views.
+	ListTables() ([]string, error)
+
+	// GetTableDefinition will return the CREATE TABLE statement of the table.
+	GetTableDefinition(table string) (string, error)
+
+	// ReadTable will pass every row of the table to each, a batch at a time,
+	// with the values of the masked columns hidden by their masks. Generated
+	// columns are left out, since their values can't be inserted.
+	ReadTable(table string, masks map[string]ColumnMask, batchSize int, each func(columns []string, rows [][]interface{}) error) error
+
+	// RestoreTable will drop the table if it exists and create it again from
+	// the definition, without checking foreign keys.
+	RestoreTable(table, definition string) error
+
+	// InsertRows will insert the rows into the table, without checking
+	// foreign keys.
+	InsertRows(table string, columns []string, rows [][]interface{}) error
+
+	// AnalyzeTables will refresh the planner statistics for the specified
+	// tables, also rebuilding them if optimize is true, and gives up once
+	// the timeout has elapsed.
+	AnalyzeTables(tables []string, optimize bool, timeout time.Duration) error
+
+	// ListMetadataLockWaits will return the sessions which are waiting for a
+	// metadata lock on a table in the database, and who they are waiting on.
+	ListMetadataLockWaits() ([]LockWait, error)
+
+	// DetectServer will determine the flavor and version of the server, and
+	// return an error if it isn't supported. Behaviors which differ between
+	// flavors are adjusted for the rest of the DbAdmin's lifetime.
+	DetectServer() (ServerInfo, error)
+
+	// KillSession will terminate the specified session, or only its current
+	// statement if queryOnly is true.
+	KillSession(sessionID int64, queryOnly bool) error
+
+	// KillUserSessions will terminate every session of the user, except for
+	// the DbAdmin's own, and return how many were killed.
+	KillUserSessions(username string) (int, error)
+
+	// GetReplicationHealth will return the size of the binary logs and how
+	// far behind the replicas are.
+	GetReplicationHealth() (ReplicationHealth, error)
+
+	// GetLogPosition will return the current position of the binary log, which
+	// is empty when binary logging is disabled.
+	GetLogPosition() (LogPosition, error)
+
+	// GetServerVariables will return the global value of each of the named
+	// server variables.
+	GetServerVariables(names []string) (map[string]string, error)
+
+	// SetServerVariables will set the global value of each server variable,
+	// persisting it across restarts where the server supports that.
+	SetServerVariables(values map[string]string) error
+
+	// EnsureExtensions will create each of the extensions in the database
+	// if it doesn't exist yet, and return the installed version of each. It
+	// returns an error wrapping ErrExtensionsUnsupported if the engine has no
+	// extensions, and one naming the extension if it isn't available on the
+	// server or the installed version doesn't satisfy its constraint.
+	EnsureExtensions(extensions []Extension) (map[string]string, error)
+
+	// AbortMigration will terminate every session of the specified migration
+	// user and then run the migration engine's cleanup statements.
+	AbortMigration(username string) error
+
+	// Close will release the connections to the database, waiting for the
+	// statements in flight to finish. The DbAdmin can't be used afterwards.
+	Close() error
+}
+
+// LockWait describes a session which is waiting for a table lock held by
+// another session
+type LockWait struct {
+	Table string
+
+	WaitingSession int64
+	WaitingAccount string
+	Wait           time.Duration
+
+	// Exclusive is true when the waiting session needs an exclusive lock,
+	// which schema changes do, and which queues all other access behind it
+	Exclusive bool
+
+	BlockingSession int64
+	BlockingAccount string
 }
 
 // MigrationEngine is an interface for deciphering the bookkeeping information
@@ -28,4 +734,10 @@ type MigrationEngine interface {
 	// database to return a single string, which represents the current version
 	// of the database.
 	GetVersionQuery() string
+
+	// GetCleanupStatements will return the statements that should be run
+	// after a migration has been interrupted, to remove any bookkeeping the
+	// framework left behind. They are run as templates without any values,
+	// so a literal % must be written as %%.
+	GetCleanupStatements() []string
 }
@@ -0,0 +1,77 @@
+// Package embedded is the migration engine of migrations whose SQL files the
+// operator applies itself, rather than running them in a Job.
+package embedded
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/seeddata"
+)
+
+// Tables which the operator keeps in the schema of the application
+const (
+	VersionTable = "embedded_schema_version"
+	FilesTable   = "embedded_schema_files"
+)
+
+const sqlSuffix = ".sql"
+
+// MigrationEngine is a type which implements the MigrationEngine
+// interface for embedded migrations
+type MigrationEngine struct{}
+
+// CreateMigrationEngine instantiates an MigrationEngine
+func CreateMigrationEngine() dbadmin.MigrationEngine {
+	return &MigrationEngine{}
+}
+
+// GetVersionQuery implements MigrationEngine
+func (emm *MigrationEngine) GetVersionQuery() string {
+	return "SELECT version_num FROM " + VersionTable + " LIMIT 1"
+}
+
+// GetCleanupStatements implements MigrationEngine. The version is only
+// recorded once every file has been applied, and the files which were applied
+// are skipped when the migration is retried, so there is nothing to clean up.
+func (emm *MigrationEngine) GetCleanupStatements() []string {
+	return nil
+}
+
+// ParseFiles reads the keys of a ConfigMap which end in .sql, in the order of
+// their names, into the files of a migration. Other keys are ignored.
+func ParseFiles(data map[string]string) ([]dbadmin.MigrationFile, error) {
+	names := make([]string, 0, len(data))
+	for name := range data {
+		if strings.HasSuffix(name, sqlSuffix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("Migration contains no %s keys", sqlSuffix)
+	}
+	sort.Strings(names)
+
+	files := make([]dbadmin.MigrationFile, 0, len(names))
+	for _, name := range names {
+		statements, err := seeddata.SplitStatements(data[name])
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read migration file (%s): %w", name, err)
+		}
+		if len(statements) == 0 {
+			return nil, fmt.Errorf("Migration file %s contains no statements", name)
+		}
+
+		digest := sha256.Sum256([]byte(data[name]))
+		files = append(files, dbadmin.MigrationFile{
+			Name:       name,
+			Checksum:   hex.EncodeToString(digest[:]),
+			Statements: statements,
+		})
+	}
+	return files, nil
+}
@@ -0,0 +1,42 @@
+package embedded
+
+import (
+	"testing"
+)
+
+func TestParseFiles(t *testing.T) {
+	files, err := ParseFiles(map[string]string{
+		"002_index.sql": "CREATE INDEX name_idx ON users (name);",
+		"001_users.sql": "CREATE TABLE users (id INT PRIMARY KEY, name TEXT);\nINSERT INTO users VALUES (1, 'a;b');",
+		"README.md":     "ignored",
+	})
+	if err != nil {
+		t.Fatalf("Unable to parse files: %v", err)
+	}
+	if len(files) != 2 || files[0].Name != "001_users.sql" || files[1].Name != "002_index.sql" {
+		t.Fatalf("Unexpected files: %+v", files)
+	}
+	if len(files[0].Statements) != 2 || files[0].Statements[1] != "INSERT INTO users VALUES (1, 'a;b')" {
+		t.Errorf("Unexpected statements: %q", files[0].Statements)
+	}
+	if len(files[0].Checksum) != 64 || files[0].Checksum == files[1].Checksum {
+		t.Errorf("Unexpected checksums: %s, %s", files[0].Checksum, files[1].Checksum)
+	}
+
+	again, err := ParseFiles(map[string]string{"001_users.sql": "CREATE TABLE users (id INT PRIMARY KEY, name TEXT);\nINSERT INTO users VALUES (1, 'a;b');"})
+	if err != nil || again[0].Checksum != files[0].Checksum {
+		t.Errorf("Checksum isn't stable: %v", err)
+	}
+}
+
+func TestParseFilesInvalid(t *testing.T) {
+	for name, data := range map[string]map[string]string{
+		"no sql keys":  {"schema.txt": "CREATE TABLE t (id INT)"},
+		"empty file":   {"001.sql": " ;\n"},
+		"unterminated": {"001.sql": "INSERT INTO t VALUES ('a"},
+	} {
+		if _, err := ParseFiles(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package dbadmin
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrExtensionsUnsupported is returned by the backends of engines which have
+// no extensions the operator could create, such as MySQL, whose plugins are
+// installed by the server's administrators
+var ErrExtensionsUnsupported = errors.New("Engine does not support extensions")
+
+// Extension is a server extension which the database needs, such as a
+// Postgres extension
+type Extension struct {
+	Name string
+
+	// Version constrains the version which must be installed, such as
+	// ">= 1.8", any version is accepted when empty
+	Version string
+}
+
+var versionConstraint = regexp.MustCompile(`^(>=|<=|>|<|=)?\s*([0-9]+(\.[0-9]+)*)$`)
+
+// CheckVersionConstraint returns an error if the constraint of an extension
+// can't be parsed
+func CheckVersionConstraint(constraint string) error {
+	if constraint != "" && !versionConstraint.MatchString(strings.TrimSpace(constraint)) {
+		return fmt.Errorf("Invalid version constraint: %s", constraint)
+	}
+	return nil
+}
+
+// VersionSatisfies returns true if the dotted numeric version satisfies the
+// constraint, a bare version must match exactly
+func VersionSatisfies(version, constraint string) (bool, error) {
+	if err := CheckVersionConstraint(constraint); err != nil {
+		return false, err
+	}
+	if constraint == "" {
+		return true, nil
+	}
+
+	parts := versionConstraint.FindStringSubmatch(strings.TrimSpace(constraint))
+	compared := CompareVersions(version, parts[2])
+	switch parts[1] {
+	case ">=":
+		return compared >= 0, nil
+	case "<=":
+		return compared <= 0, nil
+	case ">":
+		return compared > 0, nil
+	case "<":
+		return compared < 0, nil
+	}
+	return compared == 0, nil
+}
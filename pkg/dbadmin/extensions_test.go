@@ -0,0 +1,33 @@
+package dbadmin
+
+import "testing"
+
+func TestVersionSatisfies(t *testing.T) {
+	for _, tc := range []struct {
+		version    string
+		constraint string
+		satisfied  bool
+	}{
+		{"1.8", "", true},
+		{"1.8", ">= 1.8", true},
+		{"1.10", ">=1.9", true},
+		{"1.8", "> 1.8", false},
+		{"3.1.4", "< 3.2", true},
+		{"3.2", "<= 3.1.9", false},
+		{"1.8", "1.8.0", true},
+		{"1.8", "= 1.9", false},
+	} {
+		satisfied, err := VersionSatisfies(tc.version, tc.constraint)
+		if err != nil {
+			t.Errorf("%s %q: unexpected error: %v", tc.version, tc.constraint, err)
+		} else if satisfied != tc.satisfied {
+			t.Errorf("%s %q: expected %t", tc.version, tc.constraint, tc.satisfied)
+		}
+	}
+
+	for _, constraint := range []string{"~> 1.8", ">= ", "latest", ">= 1.8 < 2"} {
+		if _, err := VersionSatisfies("1.8", constraint); err == nil {
+			t.Errorf("expected an error for constraint %q", constraint)
+		}
+	}
+}
@@ -0,0 +1,436 @@
+package faults
+
+import (
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// faultyAdmin implements DbAdmin by passing every call through to admin,
+// unless the injector decides that it should fail
+type faultyAdmin struct {
+	admin    dbadmin.DbAdmin
+	injector *Injector
+}
+
+func partialError(operation string) error {
+	return xerrors.NewTempErrorf("Injected failure after partially applying %s", operation)
+}
+
+// change runs an operation which modifies the database
+func (fa *faultyAdmin) change(operation string, apply func() error) error {
+	if err := fa.injector.before(operation); err != nil {
+		return err
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	if fa.injector.partial(operation) {
+		return partialError(operation)
+	}
+	return nil
+}
+
+// changeBatch runs an operation on several users, which may be cut short
+// after any of them
+func (fa *faultyAdmin) changeBatch(operation string, credentials []dbadmin.Credentials, apply func([]dbadmin.Credentials) error) error {
+	if err := fa.injector.before(operation); err != nil {
+		return err
+	}
+	if len(credentials) > 1 && fa.injector.partial(operation) {
+		if err := apply(credentials[:1+fa.injector.intn(len(credentials)-1)]); err != nil {
+			return err
+		}
+		return partialError(operation)
+	}
+	return fa.change(operation, func() error { return apply(credentials) })
+}
+
+// WriteCredentials implements DbAdmin
+func (fa *faultyAdmin) WriteCredentials(username, password string) error {
+	return fa.change("WriteCredentials", func() error { return fa.admin.WriteCredentials(username, password) })
+}
+
+// WriteCredentialsBatch implements DbAdmin
+func (fa *faultyAdmin) WriteCredentialsBatch(credentials []dbadmin.Credentials) error {
+	return fa.changeBatch("WriteCredentialsBatch", credentials, fa.admin.WriteCredentialsBatch)
+}
+
+// RotateCredentials implements DbAdmin
+func (fa *faultyAdmin) RotateCredentials(credentials []dbadmin.Credentials) error {
+	return fa.changeBatch("RotateCredentials", credentials, fa.admin.RotateCredentials)
+}
+
+// DiscardOldPasswords implements DbAdmin
+func (fa *faultyAdmin) DiscardOldPasswords(usernames []string) error {
+	return fa.change("DiscardOldPasswords", func() error { return fa.admin.DiscardOldPasswords(usernames) })
+}
+
+// ReapplyGrants implements DbAdmin
+func (fa *faultyAdmin) ReapplyGrants(credentials []dbadmin.Credentials) error {
+	return fa.changeBatch("ReapplyGrants", credentials, fa.admin.ReapplyGrants)
+}
+
+// ListUsernames implements DbAdmin
+func (fa *faultyAdmin) ListUsernames(usernamePrefix string) ([]string, error) {
+	if err := fa.injector.before("ListUsernames"); err != nil {
+		return nil, err
+	}
+	return fa.admin.ListUsernames(usernamePrefix)
+}
+
+// ListUserAttributes implements DbAdmin
+func (fa *faultyAdmin) ListUserAttributes(usernamePrefix string) (map[string]dbadmin.UserAttributes, error) {
+	if err := fa.injector.before("ListUserAttributes"); err != nil {
+		return nil, err
+	}
+	return fa.admin.ListUserAttributes(usernamePrefix)
+}
+
+// ListUserPrivileges implements DbAdmin
+func (fa *faultyAdmin) ListUserPrivileges(usernamePrefix string) (map[string][]dbadmin.UserPrivilege, error) {
+	if err := fa.injector.before("ListUserPrivileges"); err != nil {
+		return nil, err
+	}
+	return fa.admin.ListUserPrivileges(usernamePrefix)
+}
+
+// ListPrivilegeUse implements DbAdmin
+func (fa *faultyAdmin) ListPrivilegeUse(usernamePrefix string) ([]dbadmin.PrivilegeUse, error) {
+	if err := fa.injector.before("ListPrivilegeUse"); err != nil {
+		return nil, err
+	}
+	return fa.admin.ListPrivilegeUse(usernamePrefix)
+}
+
+// RevokePrivileges implements DbAdmin
+func (fa *faultyAdmin) RevokePrivileges(username string, privileges []dbadmin.UserPrivilege) error {
+	return fa.change("RevokePrivileges", func() error { return fa.admin.RevokePrivileges(username, privileges) })
+}
+
+// VerifyUnusedAndDeleteCredentials implements DbAdmin
+func (fa *faultyAdmin) VerifyUnusedAndDeleteCredentials(username string) error {
+	return fa.change("VerifyUnusedAndDeleteCredentials", func() error { return fa.admin.VerifyUnusedAndDeleteCredentials(username) })
+}
+
+// PrimaryAddress implements DbAdmin
+func (fa *faultyAdmin) PrimaryAddress() (string, error) {
+	if err := fa.injector.before("PrimaryAddress"); err != nil {
+		return "", err
+	}
+	return fa.admin.PrimaryAddress()
+}
+
+// AcquireOperatorLock implements DbAdmin
+func (fa *faultyAdmin) AcquireOperatorLock(timeout time.Duration) (func() error, error) {
+	if err := fa.injector.before("AcquireOperatorLock"); err != nil {
+		return nil, err
+	}
+	return fa.admin.AcquireOperatorLock(timeout)
+}
+
+// AcquireLease implements DbAdmin
+func (fa *faultyAdmin) AcquireLease(holder string, duration time.Duration) (dbadmin.Lease, error) {
+	if err := fa.injector.before("AcquireLease"); err != nil {
+		return dbadmin.Lease{}, err
+	}
+	return fa.admin.AcquireLease(holder, duration)
+}
+
+// ExecutePlan implements DbAdmin. The steps are run through the faulty
+// admin, so that a plan can fail between any two of them.
+func (fa *faultyAdmin) ExecutePlan(plan dbadmin.AdminPlan) error {
+	return dbadmin.RunPlan(fa, plan)
+}
+
+// RenderPlan implements DbAdmin
+func (fa *faultyAdmin) RenderPlan(plan dbadmin.AdminPlan) ([]string, error) {
+	return fa.admin.RenderPlan(plan)
+}
+
+// ListAccountActivity implements DbAdmin
+func (fa *faultyAdmin) ListAccountActivity(usernamePrefix string) ([]dbadmin.AccountActivity, error) {
+	if err := fa.injector.before("ListAccountActivity"); err != nil {
+		return nil, err
+	}
+	return fa.admin.ListAccountActivity(usernamePrefix)
+}
+
+// ListBinlogConsumers implements DbAdmin
+func (fa *faultyAdmin) ListBinlogConsumers(usernamePrefix string) ([]dbadmin.BinlogConsumer, error) {
+	if err := fa.injector.before("ListBinlogConsumers"); err != nil {
+		return nil, err
+	}
+	return fa.admin.ListBinlogConsumers(usernamePrefix)
+}
+
+// GetCapacityUsage implements DbAdmin
+func (fa *faultyAdmin) GetCapacityUsage() (dbadmin.CapacityUsage, error) {
+	if err := fa.injector.before("GetCapacityUsage"); err != nil {
+		return dbadmin.CapacityUsage{}, err
+	}
+	return fa.admin.GetCapacityUsage()
+}
+
+// LockCredentials implements DbAdmin
+func (fa *faultyAdmin) LockCredentials(username string) error {
+	return fa.change("LockCredentials", func() error { return fa.admin.LockCredentials(username) })
+}
+
+// UnlockCredentials implements DbAdmin
+func (fa *faultyAdmin) UnlockCredentials(username string) error {
+	return fa.change("UnlockCredentials", func() error { return fa.admin.UnlockCredentials(username) })
+}
+
+// EnsureHelperRoutines implements DbAdmin
+func (fa *faultyAdmin) EnsureHelperRoutines(names []string, repair bool) ([]string, error) {
+	var drifted []string
+	err := fa.change("EnsureHelperRoutines", func() error {
+		var err error
+		drifted, err = fa.admin.EnsureHelperRoutines(names, repair)
+		return err
+	})
+	return drifted, err
+}
+
+// WriteMaskedView implements DbAdmin
+func (fa *faultyAdmin) WriteMaskedView(view dbadmin.MaskedView) error {
+	return fa.change("WriteMaskedView", func() error { return fa.admin.WriteMaskedView(view) })
+}
+
+// GetViewChecksum implements DbAdmin
+func (fa *faultyAdmin) GetViewChecksum(name string) (string, error) {
+	if err := fa.injector.before("GetViewChecksum"); err != nil {
+		return "", err
+	}
+	return fa.admin.GetViewChecksum(name)
+}
+
+// DropView implements DbAdmin
+func (fa *faultyAdmin) DropView(name string) error {
+	return fa.change("DropView", func() error { return fa.admin.DropView(name) })
+}
+
+// WriteScheduledStatement implements DbAdmin
+func (fa *faultyAdmin) WriteScheduledStatement(statement dbadmin.ScheduledStatement) error {
+	return fa.change("WriteScheduledStatement", func() error { return fa.admin.WriteScheduledStatement(statement) })
+}
+
+// GetScheduledStatementChecksum implements DbAdmin
+func (fa *faultyAdmin) GetScheduledStatementChecksum(name string) (string, error) {
+	if err := fa.injector.before("GetScheduledStatementChecksum"); err != nil {
+		return "", err
+	}
+	return fa.admin.GetScheduledStatementChecksum(name)
+}
+
+// DropScheduledStatement implements DbAdmin
+func (fa *faultyAdmin) DropScheduledStatement(name string) error {
+	return fa.change("DropScheduledStatement", func() error { return fa.admin.DropScheduledStatement(name) })
+}
+
+// ApplySeedData implements DbAdmin
+func (fa *faultyAdmin) ApplySeedData(steps []dbadmin.SeedStep) error {
+	return fa.change("ApplySeedData", func() error { return fa.admin.ApplySeedData(steps) })
+}
+
+// ApplyMigrationFiles implements DbAdmin
+func (fa *faultyAdmin) ApplyMigrationFiles(version string, files []dbadmin.MigrationFile) error {
+	return fa.change("ApplyMigrationFiles", func() error { return fa.admin.ApplyMigrationFiles(version, files) })
+}
+
+// PurgeRows implements DbAdmin
+func (fa *faultyAdmin) PurgeRows(table, column string, olderThan time.Duration, limit int) (int64, error) {
+	var purged int64
+	err := fa.change("PurgeRows", func() error {
+		var err error
+		purged, err = fa.admin.PurgeRows(table, column, olderThan, limit)
+		return err
+	})
+	return purged, err
+}
+
+// ScanOrphanedRows implements DbAdmin
+func (fa *faultyAdmin) ScanOrphanedRows(scan dbadmin.OrphanScan) (dbadmin.OrphanScanResult, error) {
+	if err := fa.injector.before("ScanOrphanedRows"); err != nil {
+		return dbadmin.OrphanScanResult{}, err
+	}
+	return fa.admin.ScanOrphanedRows(scan)
+}
+
+// CheckUpgradeReadiness implements DbAdmin
+func (fa *faultyAdmin) CheckUpgradeReadiness(target string, usernamePrefixes []string) ([]dbadmin.UpgradeFinding, error) {
+	if err := fa.injector.before("CheckUpgradeReadiness"); err != nil {
+		return nil, err
+	}
+	return fa.admin.CheckUpgradeReadiness(target, usernamePrefixes)
+}
+
+// GetCharset implements DbAdmin
+func (fa *faultyAdmin) GetCharset() (dbadmin.Charset, error) {
+	if err := fa.injector.before("GetCharset"); err != nil {
+		return dbadmin.Charset{}, err
+	}
+	return fa.admin.GetCharset()
+}
+
+// SetCharset implements DbAdmin
+func (fa *faultyAdmin) SetCharset(characterSet, collation string) error {
+	return fa.change("SetCharset", func() error { return fa.admin.SetCharset(characterSet, collation) })
+}
+
+// ListPartitions implements DbAdmin
+func (fa *faultyAdmin) ListPartitions(table string) ([]dbadmin.Partition, error) {
+	if err := fa.injector.before("ListPartitions"); err != nil {
+		return nil, err
+	}
+	return fa.admin.ListPartitions(table)
+}
+
+// AddPartitions implements DbAdmin
+func (fa *faultyAdmin) AddPartitions(table string, partitions []dbadmin.Partition) error {
+	return fa.change("AddPartitions", func() error { return fa.admin.AddPartitions(table, partitions) })
+}
+
+// DropPartitions implements DbAdmin
+func (fa *faultyAdmin) DropPartitions(table string, names []string) error {
+	return fa.change("DropPartitions", func() error { return fa.admin.DropPartitions(table, names) })
+}
+
+// GetSchemaVersion implements DbAdmin
+func (fa *faultyAdmin) GetSchemaVersion() (string, error) {
+	if err := fa.injector.before("GetSchemaVersion"); err != nil {
+		return "", err
+	}
+	return fa.admin.GetSchemaVersion()
+}
+
+// ExportSchema implements DbAdmin
+func (fa *faultyAdmin) ExportSchema() (string, error) {
+	if err := fa.injector.before("ExportSchema"); err != nil {
+		return "", err
+	}
+	return fa.admin.ExportSchema()
+}
+
+// ListTables implements DbAdmin
+func (fa *faultyAdmin) ListTables() ([]string, error) {
+	if err := fa.injector.before("ListTables"); err != nil {
+		return nil, err
+	}
+	return fa.admin.ListTables()
+}
+
+// GetTableDefinition implements DbAdmin
+func (fa *faultyAdmin) GetTableDefinition(table string) (string, error) {
+	if err := fa.injector.before("GetTableDefinition"); err != nil {
+		return "", err
+	}
+	return fa.admin.GetTableDefinition(table)
+}
+
+// ReadTable implements DbAdmin
+func (fa *faultyAdmin) ReadTable(table string, masks map[string]dbadmin.ColumnMask, batchSize int, each func(columns []string, rows [][]interface{}) error) error {
+	if err := fa.injector.before("ReadTable"); err != nil {
+		return err
+	}
+	return fa.admin.ReadTable(table, masks, batchSize, each)
+}
+
+// RestoreTable implements DbAdmin
+func (fa *faultyAdmin) RestoreTable(table, definition string) error {
+	return fa.change("RestoreTable", func() error { return fa.admin.RestoreTable(table, definition) })
+}
+
+// InsertRows implements DbAdmin
+func (fa *faultyAdmin) InsertRows(table string, columns []string, rows [][]interface{}) error {
+	return fa.change("InsertRows", func() error { return fa.admin.InsertRows(table, columns, rows) })
+}
+
+// AnalyzeTables implements DbAdmin
+func (fa *faultyAdmin) AnalyzeTables(tables []string, optimize bool, timeout time.Duration) error {
+	return fa.change("AnalyzeTables", func() error { return fa.admin.AnalyzeTables(tables, optimize, timeout) })
+}
+
+// ListMetadataLockWaits implements DbAdmin
+func (fa *faultyAdmin) ListMetadataLockWaits() ([]dbadmin.LockWait, error) {
+	if err := fa.injector.before("ListMetadataLockWaits"); err != nil {
+		return nil, err
+	}
+	return fa.admin.ListMetadataLockWaits()
+}
+
+// DetectServer implements DbAdmin
+func (fa *faultyAdmin) DetectServer() (dbadmin.ServerInfo, error) {
+	if err := fa.injector.before("DetectServer"); err != nil {
+		return dbadmin.ServerInfo{}, err
+	}
+	return fa.admin.DetectServer()
+}
+
+// KillSession implements DbAdmin
+func (fa *faultyAdmin) KillSession(sessionID int64, queryOnly bool) error {
+	return fa.change("KillSession", func() error { return fa.admin.KillSession(sessionID, queryOnly) })
+}
+
+// KillUserSessions implements DbAdmin
+func (fa *faultyAdmin) KillUserSessions(username string) (int, error) {
+	var killed int
+	err := fa.change("KillUserSessions", func() error {
+		var err error
+		killed, err = fa.admin.KillUserSessions(username)
+		return err
+	})
+	return killed, err
+}
+
+// GetReplicationHealth implements DbAdmin
+func (fa *faultyAdmin) GetReplicationHealth() (dbadmin.ReplicationHealth, error) {
+	if err := fa.injector.before("GetReplicationHealth"); err != nil {
+		return dbadmin.ReplicationHealth{}, err
+	}
+	return fa.admin.GetReplicationHealth()
+}
+
+// GetLogPosition implements DbAdmin
+func (fa *faultyAdmin) GetLogPosition() (dbadmin.LogPosition, error) {
+	if err := fa.injector.before("GetLogPosition"); err != nil {
+		return dbadmin.LogPosition{}, err
+	}
+	return fa.admin.GetLogPosition()
+}
+
+// GetServerVariables implements DbAdmin
+func (fa *faultyAdmin) GetServerVariables(names []string) (map[string]string, error) {
+	if err := fa.injector.before("GetServerVariables"); err != nil {
+		return nil, err
+	}
+	return fa.admin.GetServerVariables(names)
+}
+
+// SetServerVariables implements DbAdmin
+func (fa *faultyAdmin) SetServerVariables(values map[string]string) error {
+	return fa.change("SetServerVariables", func() error { return fa.admin.SetServerVariables(values) })
+}
+
+// EnsureExtensions implements DbAdmin
+func (fa *faultyAdmin) EnsureExtensions(extensions []dbadmin.Extension) (map[string]string, error) {
+	var installed map[string]string
+	err := fa.change("EnsureExtensions", func() error {
+		var err error
+		installed, err = fa.admin.EnsureExtensions(extensions)
+		return err
+	})
+	return installed, err
+}
+
+// AbortMigration implements DbAdmin
+func (fa *faultyAdmin) AbortMigration(username string) error {
+	return fa.change("AbortMigration", func() error { return fa.admin.AbortMigration(username) })
+}
+
+// Close implements DbAdmin, it is never injected with faults
+func (fa *faultyAdmin) Close() error {
+	return fa.admin.Close()
+}
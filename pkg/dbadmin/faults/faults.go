@@ -0,0 +1,120 @@
+// Package faults wraps a DbAdmin with one which randomly delays and fails
+// its operations, for validating that the controllers recover from the
+// failures that real databases produce. It must never be enabled in
+// production.
+package faults
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// Options contains the probability of each kind of fault, between 0 and 1
+type Options struct {
+	// DelayProbability is the chance that an operation is delayed by up to
+	// MaxDelay before it runs
+	DelayProbability float64
+	MaxDelay         time.Duration
+
+	// FailureProbability is the chance that an operation fails without
+	// being run
+	FailureProbability float64
+
+	// PartialProbability is the chance that a change is only partially
+	// applied before failing. Batches are cut short, and single changes are
+	// applied in full but still reported as failed, as if the connection
+	// was lost before the server replied.
+	PartialProbability float64
+
+	// Seed makes the faults reproducible, the clock is used when zero
+	Seed int64
+}
+
+// Injector decides which operations fail
+type Injector struct {
+	options Options
+	log     logr.Logger
+
+	lock   sync.Mutex
+	random *rand.Rand
+}
+
+// NewInjector will instantiate an Injector with the supplied options, every
+// injected fault is logged to l
+func NewInjector(options Options, l logr.Logger) (*Injector, error) {
+	for name, probability := range map[string]float64{
+		"delay":   options.DelayProbability,
+		"failure": options.FailureProbability,
+		"partial": options.PartialProbability,
+	} {
+		if probability < 0 || probability > 1 {
+			return nil, fmt.Errorf("Fault %s probability must be between 0 and 1: %v", name, probability)
+		}
+	}
+	if options.DelayProbability > 0 && options.MaxDelay <= 0 {
+		return nil, fmt.Errorf("Fault delays require a positive maximum delay")
+	}
+
+	seed := options.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return &Injector{
+		options: options,
+		log:     l,
+		random:  rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+// Wrap returns a DbAdmin which injects faults into every call to admin
+func (i *Injector) Wrap(admin dbadmin.DbAdmin) dbadmin.DbAdmin {
+	return &faultyAdmin{admin: admin, injector: i}
+}
+
+func (i *Injector) chance(probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.random.Float64() < probability
+}
+
+func (i *Injector) intn(n int) int {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.random.Intn(n)
+}
+
+// before may delay the operation, and returns an error if it should fail
+// without being run
+func (i *Injector) before(operation string) error {
+	if i.chance(i.options.DelayProbability) {
+		delay := time.Duration(i.intn(int(i.options.MaxDelay)) + 1)
+		i.log.Info("Injecting delay", "operation", operation, "delay", delay)
+		time.Sleep(delay)
+	}
+	if i.chance(i.options.FailureProbability) {
+		i.log.Info("Injecting failure", "operation", operation)
+		return xerrors.NewTempErrorf("Injected failure in %s", operation)
+	}
+	return nil
+}
+
+// partial returns true if a change should only be partially applied
+func (i *Injector) partial(operation string) bool {
+	if !i.chance(i.options.PartialProbability) {
+		return false
+	}
+	i.log.Info("Injecting partial application", "operation", operation)
+	return true
+}
@@ -0,0 +1,92 @@
+package faults
+
+import (
+	"errors"
+	"testing"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// recordingAdmin only implements the methods used by the tests
+type recordingAdmin struct {
+	dbadmin.DbAdmin
+	written []string
+}
+
+func (ra *recordingAdmin) WriteCredentialsBatch(credentials []dbadmin.Credentials) error {
+	for _, cred := range credentials {
+		ra.written = append(ra.written, cred.Username)
+	}
+	return nil
+}
+
+func isTemporary(err error) bool {
+	var enhanced xerrors.EnhancedError
+	return errors.As(err, &enhanced) && enhanced.Temporary()
+}
+
+func batch() []dbadmin.Credentials {
+	return []dbadmin.Credentials{{Username: "a"}, {Username: "b"}, {Username: "c"}}
+}
+
+func TestFailureSkipsOperation(t *testing.T) {
+	injector, err := NewInjector(Options{FailureProbability: 1, Seed: 1}, logf.NullLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &recordingAdmin{}
+
+	err = injector.Wrap(stub).WriteCredentialsBatch(batch())
+	if !isTemporary(err) {
+		t.Errorf("Expected a temporary error, got %v", err)
+	}
+	if len(stub.written) != 0 {
+		t.Errorf("A failed operation must not be applied: %v", stub.written)
+	}
+}
+
+func TestPartialBatch(t *testing.T) {
+	injector, err := NewInjector(Options{PartialProbability: 1, Seed: 1}, logf.NullLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &recordingAdmin{}
+
+	err = injector.Wrap(stub).WriteCredentialsBatch(batch())
+	if !isTemporary(err) {
+		t.Errorf("Expected a temporary error, got %v", err)
+	}
+	if len(stub.written) == 0 || len(stub.written) >= 3 {
+		t.Errorf("Expected part of the batch to be applied: %v", stub.written)
+	}
+}
+
+func TestNoFaults(t *testing.T) {
+	injector, err := NewInjector(Options{}, logf.NullLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &recordingAdmin{}
+
+	if err := injector.Wrap(stub).WriteCredentialsBatch(batch()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(stub.written) != 3 {
+		t.Errorf("Expected the whole batch to be applied: %v", stub.written)
+	}
+}
+
+func TestNewInjectorRejectsInvalid(t *testing.T) {
+	for _, options := range []Options{
+		{FailureProbability: 1.5},
+		{PartialProbability: -0.1},
+		{DelayProbability: 0.5},
+	} {
+		if _, err := NewInjector(options, logf.NullLogger{}); err == nil {
+			t.Errorf("Expected an error for %+v", options)
+		}
+	}
+}
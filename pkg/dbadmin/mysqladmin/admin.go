@@ -3,13 +3,21 @@ package mysqladmin
 import (
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/go-logr/logr"
 	"github.com/go-sql-driver/mysql"
 
 	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+	"github.com/app-sre/dba-operator/pkg/redact"
 	"github.com/app-sre/dba-operator/pkg/xerrors"
 )
 
@@ -18,27 +26,73 @@ type MySQLDbAdmin struct {
 	handle   *sql.DB
 	database string
 	engine   dbadmin.MigrationEngine
+	log      logr.Logger
+	galera   *GaleraOptions
+
+	// autoPause is set for databases which pause themselves while idle
+	autoPause *AutoPauseOptions
+
+	// tunnel is open while the admin is, if the database is reached
+	// through a bastion or proxy
+	tunnelOptions *TunnelOptions
+	tunnel        *tunnel
+
+	// diagnostics receives the timing and plan of every read query, it is
+	// only set in debug mode
+	diagnostics *diagnostics.Recorder
+
+	// server is the detected flavor and version, nil until DetectServer
+	// has run
+	server *dbadmin.ServerInfo
+
+	// connConfig is the parsed DSN, which is reused to connect to the
+	// primary when group replication is enabled
+	connConfig       *mysql.Config
+	session          *dbadmin.SessionSettings
+	groupReplication bool
+	primary          *sql.DB
+	primaryAddr      string
+
+	// exec runs a single statement built from a template and values,
+	// normally indirectSubstitute
+	exec func(format string, args ...sqlValue) xerrors.EnhancedError
+
+	// observeRetry is told about every statement which is retried in place
+	observeRetry func(reason string)
 }
 
+type valueKind int
+
+const (
+	rawValue valueKind = iota
+	quotedValue
+	identifierValue
+)
+
 type sqlValue struct {
-	value  *string
-	quoted bool
+	value *string
+	kind  valueKind
 }
 
 func quoted(needsToBeQuoted string) sqlValue {
-	return sqlValue{value: &needsToBeQuoted, quoted: true}
+	return sqlValue{value: &needsToBeQuoted, kind: quotedValue}
 }
 
 func noquote(cantBeQuoted string) sqlValue {
-	return sqlValue{value: &cantBeQuoted, quoted: false}
+	return sqlValue{value: &cantBeQuoted, kind: rawValue}
+}
+
+func identifier(name string) sqlValue {
+	return sqlValue{value: &name, kind: identifierValue}
 }
 
 // CreateMySQLAdmin will instantiate a MySQLDbAdmin object with the specified
-// connection information and MigrationEngine.
-func CreateMySQLAdmin(dsn string, engine dbadmin.MigrationEngine) (dbadmin.DbAdmin, error) {
+// connection information and MigrationEngine. The supplied logger will receive
+// the templates of all statements which are sent to the database.
+func CreateMySQLAdmin(dsn string, engine dbadmin.MigrationEngine, log logr.Logger, options ...Option) (dbadmin.DbAdmin, error) {
 	parsed, err := mysql.ParseDSN(dsn)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to parse connection dsn: %w", err)
+		return nil, fmt.Errorf("Unable to parse connection dsn: %w", redact.Error(err, dsn, dsnPassword(dsn)))
 	}
 	if parsed.User == "" || parsed.Passwd == "" {
 		return nil, errors.New("Must provide username and password in the connection DSN")
@@ -47,12 +101,93 @@ func CreateMySQLAdmin(dsn string, engine dbadmin.MigrationEngine) (dbadmin.DbAdm
 		return nil, errors.New("Must provide specific database name in the connection DSN")
 	}
 
-	db, err := sql.Open("mysql", dsn)
+	admin := &MySQLDbAdmin{
+		database: parsed.DBName,
+		engine:   engine,
+		log:      log.WithValues("database", parsed.DBName),
+
+		connConfig: parsed,
+	}
+	admin.exec = admin.indirectSubstitute
+
+	for _, option := range options {
+		option(admin)
+	}
+
+	// The session settings are sent by the driver on every new connection
+	openDSN := dsn
+	if admin.session != nil {
+		if err := admin.setSessionParams(); err != nil {
+			return nil, err
+		}
+		openDSN = admin.connConfig.FormatDSN()
+	}
+
+	// Every connection of the admin, including to a group replication
+	// primary, is dialed through the tunnel
+	if admin.tunnelOptions != nil {
+		if parsed.Net != networkTCP {
+			return nil, fmt.Errorf("A connection through a tunnel can't use the %s network", parsed.Net)
+		}
+		tunnel, err := openTunnel(*admin.tunnelOptions)
+		if err != nil {
+			return nil, redact.Error(err, admin.tunnelOptions.Password)
+		}
+		admin.tunnel = tunnel
+		admin.connConfig.Net = tunnel.name
+		openDSN = admin.connConfig.FormatDSN()
+	}
+
+	db, err := sql.Open("mysql", openDSN)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to open connection to db: %w", wrap(err))
+		if admin.tunnel != nil {
+			admin.tunnel.Close()
+		}
+		return nil, fmt.Errorf("Unable to open connection to db: %w", redact.Error(wrap(err), dsn, parsed.Passwd))
+	}
+	admin.handle = db
+	admin.configurePool(db)
+	admin.trackPool()
+
+	if admin.autoPause != nil {
+		if err := admin.awaitResume(); err != nil {
+			admin.Close()
+			return nil, redact.Error(err, dsn, parsed.Passwd)
+		}
+	}
+	return admin, nil
+}
+
+// Close implements DbAdmin
+func (mdba *MySQLDbAdmin) Close() error {
+	mdba.forgetPrimary()
+	err := mdba.handle.Close()
+	if mdba.tunnel != nil {
+		mdba.tunnel.Close()
+	}
+	return err
+}
+
+// dsnPassword makes a best effort attempt to find the password in a DSN which
+// the driver was unable to parse, so that it can be redacted from errors.
+func dsnPassword(dsn string) string {
+	userInfoEnd := strings.LastIndex(dsn, "@")
+	if userInfoEnd < 0 {
+		return ""
+	}
+	passwordStart := strings.Index(dsn[:userInfoEnd], ":")
+	if passwordStart < 0 {
+		return ""
 	}
+	return dsn[passwordStart+1 : userInfoEnd]
+}
 
-	return &MySQLDbAdmin{db, parsed.DBName, engine}, nil
+func passwords(credentials []dbadmin.Credentials) []string {
+	secrets := make([]string, 0, len(credentials))
+	for _, cred := range credentials {
+		secrets = append(secrets, cred.Password)
+	}
+	return secrets
 }
 
 func randIdentifier(randomBytes int) string {
@@ -68,45 +203,96 @@ func randIdentifier(randomBytes int) string {
 // such as CREATE USER and GRANT which don't support variables in prepared statements.
 // The design of this operator shouldn't require preventing injection as these values
 // are developer supplied and not end-user supplied, but it may help prevent errors
-// and should be considered a best practice.
-func (mdba *MySQLDbAdmin) indirectSubstitute(format string, args ...sqlValue) xerrors.EnhancedError {
-	tx, err := mdba.handle.Begin()
-	if err != nil {
-		return wrap(err)
+// and should be considered a best practice. Statement types which do support
+// placeholders are sent as ordinary prepared statements instead.
+func (mdba *MySQLDbAdmin) indirectSubstitute(format string, args ...sqlValue) (result xerrors.EnhancedError) {
+	mdba.logStatement(format)
+
+	start := time.Now()
+	defer func() { mdba.recordStatement(format, time.Since(start), result) }()
+
+	category := dbadmin.StatementCategory(format)
+	if mdba.galera != nil && (category == dbadmin.CategoryDCL || category == dbadmin.CategoryDDL) {
+		if err := mdba.checkGaleraReady(); err != nil {
+			return err
+		}
 	}
-	defer tx.Rollback()
 
-	finalArgs := make([]interface{}, 0, len(args))
-	for _, arg := range args {
-		newIdent := randIdentifier(16)
+	return mdba.retryStatement(format, func() xerrors.EnhancedError {
+		return mdba.execStatement(format, category, args)
+	})
+}
+
+// execStatement makes a single attempt at running the statement, on the
+// primary when the server is part of a replication group
+func (mdba *MySQLDbAdmin) execStatement(format, category string, args []sqlValue) xerrors.EnhancedError {
+	handle, herr := mdba.writeHandle()
+	if herr != nil {
+		return herr
+	}
 
-		if arg.quoted {
-			finalArgs = append(finalArgs, fmt.Sprintf(`", QUOTE(@%s), "`, newIdent))
-		} else {
-			finalArgs = append(finalArgs, fmt.Sprintf(`", @%s, "`, newIdent))
-		}
+	if query, values, ok, err := buildPreparedStatement(format, args); err != nil {
+		return wrap(fmt.Errorf("Unable to assemble statement: %w", err))
+	} else if ok {
+		_, err := handle.Exec(query, values...)
+		return mdba.checkPrimary(wrap(err))
+	}
 
-		_, err = tx.Exec(fmt.Sprintf("SET @%s := ?", newIdent), arg.value)
-		if err != nil {
-			return wrap(err)
-		}
+	stmt, err := buildIndirectStatement(format, args)
+	if err != nil {
+		return wrap(fmt.Errorf("Unable to assemble statement: %w", err))
 	}
 
-	rawSQLStmt := fmt.Sprintf(format, finalArgs...)
-	stmtStringName := randIdentifier(16)
-	createStmt := fmt.Sprintf(`SET @%s := CONCAT("%s")`, stmtStringName, rawSQLStmt)
-	_, err = tx.Exec(createStmt)
+	return mdba.checkPrimary(mdba.runIndirect(handle, stmt, category))
+}
+
+// checkPrimary forgets the group replication primary when a statement sent
+// to it failed because it is no longer writable
+func (mdba *MySQLDbAdmin) checkPrimary(err xerrors.EnhancedError) xerrors.EnhancedError {
+	if err != nil && mdba.groupReplication && isReadOnlyError(err) {
+		// The primary has changed since it was discovered, the error is
+		// already temporary so the next attempt will rediscover it
+		mdba.log.Info("Group replication primary is now read only")
+		mdba.forgetPrimary()
+	}
+	return err
+}
+
+func (mdba *MySQLDbAdmin) runIndirect(handle *sql.DB, stmt *indirectStatement, category string) xerrors.EnhancedError {
+	tx, err := handle.Begin()
 	if err != nil {
 		return wrap(err)
 	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(stmt.assign, stmt.assignArgs...); err != nil {
+		return wrap(err)
+	}
+
+	if _, err := tx.Exec(stmt.concat); err != nil {
+		return wrap(err)
+	}
 
 	stmtName := randIdentifier(16)
-	_, err = tx.Exec(fmt.Sprintf("PREPARE %s FROM @%s", stmtName, stmtStringName))
-	if err != nil {
+	if _, err := tx.Exec(fmt.Sprintf("PREPARE %s FROM @%s", stmtName, stmt.stmtVar)); err != nil {
 		return wrap(err)
 	}
 
+	// The session is returned to the pool afterwards, so the OSU method is
+	// switched only around the statement itself and always switched back
+	rollingUpgrade := mdba.galera != nil && mdba.galera.RollingSchemaUpgrades && category == dbadmin.CategoryDDL
+	if rollingUpgrade {
+		if _, err := tx.Exec(setOSUMethodRSU); err != nil {
+			tx.Exec(fmt.Sprintf("DEALLOCATE PREPARE %s", stmtName))
+			return wrap(err)
+		}
+	}
+
 	_, err = tx.Exec(fmt.Sprintf("EXECUTE %s", stmtName))
+	if rollingUpgrade {
+		tx.Exec(setOSUMethodTOI)
+	}
+	tx.Exec(fmt.Sprintf("DEALLOCATE PREPARE %s", stmtName))
 	if err != nil {
 		return wrap(err)
 	}
@@ -120,34 +306,424 @@ func (mdba *MySQLDbAdmin) indirectSubstitute(format string, args ...sqlValue) xe
 
 // WriteCredentials implements DbADmin
 func (mdba *MySQLDbAdmin) WriteCredentials(username, password string) error {
+	return mdba.WriteCredentialsBatch([]dbadmin.Credentials{{Username: username, Password: password}})
+}
 
-	err := mdba.indirectSubstitute(
-		"CREATE USER %s@'%%' IDENTIFIED BY %s",
-		quoted(username),
-		quoted(password),
+// WriteCredentialsBatch implements DbAdmin
+func (mdba *MySQLDbAdmin) WriteCredentialsBatch(credentials []dbadmin.Credentials) error {
+	if len(credentials) == 0 {
+		return nil
+	}
+
+	// MySQL allows a single CREATE USER statement to define multiple accounts,
+	// and a single GRANT statement to target multiple accounts, so we can
+	// provision the entire batch in one round trip per distinct grant.
+	createClauses := make([]string, 0, len(credentials))
+	createArgs := make([]sqlValue, 0, len(credentials)*2)
+	usernames := make([]string, 0, len(credentials))
+	for _, cred := range credentials {
+		if limit := mdba.usernameLimit(); utf8.RuneCountInString(cred.Username) > limit {
+			return fmt.Errorf("Username %s is longer than the %d characters the server accepts", cred.Username, limit)
+		}
+		identified, secret, err := mdba.identifiedClause(cred)
+		if err != nil {
+			return err
+		}
+		createClauses = append(createClauses, "%s@'%%' "+identified)
+		createArgs = append(createArgs, quoted(cred.Username), secret)
+		usernames = append(usernames, cred.Username)
+	}
+
+	grants, err := mdba.groupGrants(credentials)
+	if err != nil {
+		return err
+	}
+
+	// Attributes are recorded with the grants, so a user is never left
+	// without them
+	attributes, err := mdba.attributeStatements(credentials)
+	if err != nil {
+		return err
+	}
+	grants = append(grants, attributes...)
+
+	// If this fails we don't know which, if any, of the users were created by
+	// us, so there is nothing that can safely be rolled back.
+	err = mdba.exec(
+		"CREATE USER "+strings.Join(createClauses, ", "),
+		createArgs...,
 	)
 	if err != nil {
-		return fmt.Errorf("Unable to create new user %s: %w", username, err)
+		return fmt.Errorf("Unable to create new users (%s): %w", strings.Join(usernames, ", "), redact.Error(err, passwords(credentials)...))
 	}
 
-	err = mdba.indirectSubstitute(
-		"GRANT SELECT, INSERT, UPDATE, DELETE ON %s.* TO %s",
-		noquote(mdba.database),
-		quoted(username),
+	for _, grant := range grants {
+		if err = mdba.exec(grant.format, grant.args...); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		// MySQL DCL can't participate in a transaction, so compensate by
+		// dropping the users that we just created, leaving the database as we
+		// found it rather than holding accounts without privileges.
+		if dropErr := mdba.dropUsers(usernames); dropErr != nil {
+			return fmt.Errorf(
+				"Unable to grant permission to new users (%s): %w, and unable to remove them afterwards: %v",
+				strings.Join(usernames, ", "),
+				err,
+				dropErr,
+			)
+		}
+		return fmt.Errorf("Unable to grant permission to new users (%s), they have been removed: %w", strings.Join(usernames, ", "), err)
+	}
+
+	return nil
+}
+
+var grantClassPrivileges = map[dbadmin.GrantClass]string{
+	dbadmin.GrantClassReadWrite:   "SELECT, INSERT, UPDATE, DELETE",
+	dbadmin.GrantClassReadOnly:    "SELECT",
+	dbadmin.GrantClassExecute:     "EXECUTE",
+	dbadmin.GrantClassReplication: "REPLICATION SLAVE, REPLICATION CLIENT",
+}
+
+type grantStatement struct {
+	format string
+	args   []sqlValue
+}
+
+// authPlugins contains the authentication plugins which users may be created
+// with. Plugin names can't be passed as parameters, so only these are ever
+// written into statement templates.
+var authPlugins = map[dbadmin.AuthPlugin]bool{
+	dbadmin.AuthPluginCachingSHA2Password: true,
+	dbadmin.AuthPluginNativePassword:      true,
+}
+
+// identityPlugins contains the authentication plugins which map an external
+// identity to the user instead of using a password
+var identityPlugins = map[dbadmin.AuthPlugin]bool{
+	dbadmin.AuthPluginOpenIDConnect: true,
+}
+
+// unsupported contains the auth plugins which each flavor doesn't implement
+var unsupported = map[dbadmin.ServerFlavor]map[dbadmin.AuthPlugin]bool{
+	dbadmin.FlavorMariaDB: {
+		dbadmin.AuthPluginCachingSHA2Password: true,
+		dbadmin.AuthPluginOpenIDConnect:       true,
+	},
+	dbadmin.FlavorTiDB: {
+		dbadmin.AuthPluginOpenIDConnect: true,
+	},
+	dbadmin.FlavorAuroraMySQL: {
+		dbadmin.AuthPluginOpenIDConnect: true,
+	},
+}
+
+// identifiedClause returns the IDENTIFIED clause template for the
+// credentials, and the password or authentication string which is its only
+// argument.
+func (mdba *MySQLDbAdmin) identifiedClause(cred dbadmin.Credentials) (string, sqlValue, error) {
+	if unsupported[mdba.flavor()][cred.AuthPlugin] {
+		return "", sqlValue{}, fmt.Errorf("Auth plugin (%s) for user %s is not supported by %s", cred.AuthPlugin, cred.Username, mdba.flavor())
+	}
+
+	if identityPlugins[cred.AuthPlugin] {
+		authString, err := openIDConnectAuthString(cred)
+		if err != nil {
+			return "", sqlValue{}, err
+		}
+		return fmt.Sprintf("IDENTIFIED WITH %s AS %%s", cred.AuthPlugin), quoted(authString), nil
+	}
+	if cred.Identity != nil {
+		return "", sqlValue{}, fmt.Errorf("Auth plugin (%s) for user %s does not support identity mappings", cred.AuthPlugin, cred.Username)
+	}
+
+	if cred.AuthPlugin == dbadmin.AuthPluginDefault {
+		return "IDENTIFIED BY %s", quoted(cred.Password), nil
+	}
+	if !authPlugins[cred.AuthPlugin] {
+		return "", sqlValue{}, fmt.Errorf("Unknown auth plugin (%s) for user %s", cred.AuthPlugin, cred.Username)
+	}
+	return fmt.Sprintf("IDENTIFIED WITH %s BY %%s", cred.AuthPlugin), quoted(cred.Password), nil
+}
+
+// openIDConnectAuthString returns the authentication string which maps the
+// identity's subject claim to the user
+func openIDConnectAuthString(cred dbadmin.Credentials) (string, error) {
+	if cred.Identity == nil || cred.Identity.Provider == "" || cred.Identity.Subject == "" {
+		return "", fmt.Errorf("User %s requires an identity provider and subject for auth plugin %s", cred.Username, cred.AuthPlugin)
+	}
+
+	authString, err := json.Marshal(map[string]string{
+		"identity_provider": cred.Identity.Provider,
+		"user":              cred.Identity.Subject,
+	})
+	if err != nil {
+		return "", fmt.Errorf("Unable to encode identity mapping for user %s: %w", cred.Username, err)
+	}
+	return string(authString), nil
+}
+
+// grantTarget is a single object that a class of privileges is granted on.
+// Columns are joined so that targets can be compared.
+type grantTarget struct {
+	database string
+	class    dbadmin.GrantClass
+	table    string
+	columns  string
+}
+
+// columnSeparator can't appear in identifiers, so joined columns are never
+// ambiguous
+const columnSeparator = "\x00"
+
+// grantTargets expands a grant into one target per table
+func grantTargets(grant dbadmin.DatabaseGrant) ([]grantTarget, error) {
+	if grant.Class == dbadmin.GrantClassReplication {
+		// Replication privileges are global, the target has no database
+		if len(grant.Tables) > 0 {
+			return nil, fmt.Errorf("Grant class %s can't be scoped to tables", grant.Class)
+		}
+		return []grantTarget{{class: grant.Class}}, nil
+	}
+	if len(grant.Tables) == 0 {
+		return []grantTarget{{database: grant.Database, class: grant.Class}}, nil
+	}
+	if grant.Class == dbadmin.GrantClassExecute {
+		return nil, fmt.Errorf("Grant class %s can't be scoped to tables", grant.Class)
+	}
+
+	targets := make([]grantTarget, 0, len(grant.Tables))
+	for _, table := range grant.Tables {
+		if table.Table == "" {
+			return nil, fmt.Errorf("Table scoped grant on %s is missing the table name", grant.Database)
+		}
+		if len(table.Columns) > 0 && grant.Class != dbadmin.GrantClassReadOnly {
+			return nil, fmt.Errorf("Grant class %s can't be scoped to the columns of %s", grant.Class, table.Table)
+		}
+		targets = append(targets, grantTarget{
+			database: grant.Database,
+			class:    grant.Class,
+			table:    table.Table,
+			columns:  strings.Join(table.Columns, columnSeparator),
+		})
+	}
+	return targets, nil
+}
+
+// groupGrants computes the minimal set of GRANT statements which give every
+// user in the batch its requested privileges, in a deterministic order.
+func (mdba *MySQLDbAdmin) groupGrants(credentials []dbadmin.Credentials) ([]grantStatement, error) {
+	grantees := make(map[grantTarget][]string)
+	var order []grantTarget
+
+	for _, cred := range credentials {
+		grants := cred.Grants
+		if len(grants) == 0 {
+			grants = []dbadmin.DatabaseGrant{{Database: mdba.database, Class: dbadmin.GrantClassReadWrite}}
+		}
+
+		for _, grant := range grants {
+			if grant.Database == "" {
+				grant.Database = mdba.database
+			}
+			if _, ok := grantClassPrivileges[grant.Class]; !ok {
+				return nil, fmt.Errorf("Unknown grant class (%s) for user %s", grant.Class, cred.Username)
+			}
+
+			targets, err := grantTargets(grant)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid grant for user %s: %w", cred.Username, err)
+			}
+			for _, target := range targets {
+				if _, ok := grantees[target]; !ok {
+					order = append(order, target)
+				}
+				grantees[target] = append(grantees[target], cred.Username)
+			}
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].database != order[j].database {
+			return order[i].database < order[j].database
+		}
+		if order[i].class != order[j].class {
+			return order[i].class < order[j].class
+		}
+		if order[i].table != order[j].table {
+			return order[i].table < order[j].table
+		}
+		return order[i].columns < order[j].columns
+	})
+
+	statements := make([]grantStatement, 0, len(order))
+	for _, target := range order {
+		privileges := grantClassPrivileges[target.class]
+		var args []sqlValue
+		if target.columns != "" {
+			columns := strings.Split(target.columns, columnSeparator)
+			placeholders := make([]string, 0, len(columns))
+			for _, column := range columns {
+				placeholders = append(placeholders, "%s")
+				args = append(args, identifier(column))
+			}
+			privileges = fmt.Sprintf("%s (%s)", privileges, strings.Join(placeholders, ", "))
+		}
+
+		object := "*.*"
+		if target.database != "" {
+			object = "%s.*"
+			args = append(args, identifier(target.database))
+		}
+		if target.table != "" {
+			object = "%s.%s"
+			args = append(args, identifier(target.table))
+		}
+
+		users := grantees[target]
+		placeholders := make([]string, 0, len(users))
+		for _, username := range users {
+			placeholders = append(placeholders, "%s")
+			args = append(args, quoted(username))
+		}
+
+		statements = append(statements, grantStatement{
+			format: fmt.Sprintf("GRANT %s ON %s TO %s", privileges, object, strings.Join(placeholders, ", ")),
+			args:   args,
+		})
+	}
+
+	return statements, nil
+}
+
+func (mdba *MySQLDbAdmin) dropUsers(usernames []string) xerrors.EnhancedError {
+	dropClauses := make([]string, 0, len(usernames))
+	dropArgs := make([]sqlValue, 0, len(usernames))
+	for _, username := range usernames {
+		dropClauses = append(dropClauses, "%s@'%%'")
+		dropArgs = append(dropArgs, quoted(username))
+	}
+
+	return mdba.exec("DROP USER IF EXISTS "+strings.Join(dropClauses, ", "), dropArgs...)
+}
+
+// ReapplyGrants implements DbAdmin
+func (mdba *MySQLDbAdmin) ReapplyGrants(credentials []dbadmin.Credentials) error {
+	if len(credentials) == 0 {
+		return nil
+	}
+
+	usernames := make([]string, 0, len(credentials))
+	for _, cred := range credentials {
+		usernames = append(usernames, cred.Username)
+	}
+	held, err := mdba.ListUserPrivileges(commonPrefix(usernames))
+	if err != nil {
+		return err
+	}
+	return mdba.reapplyGrants(credentials, held)
+}
+
+// reapplyGrants grants the users their privileges, and then revokes the
+// held ones which the grants don't give, so that users never lack one they
+// should have
+func (mdba *MySQLDbAdmin) reapplyGrants(credentials []dbadmin.Credentials, held map[string][]dbadmin.UserPrivilege) error {
+	grants, err := mdba.groupGrants(credentials)
+	if err != nil {
+		return err
+	}
+
+	extra := make(map[string][]dbadmin.UserPrivilege)
+	for _, cred := range credentials {
+		if extra[cred.Username], err = mdba.extraPrivileges(cred, held[cred.Username]); err != nil {
+			return err
+		}
+	}
+
+	for _, grant := range grants {
+		if err := mdba.exec(grant.format, grant.args...); err != nil {
+			return fmt.Errorf("Unable to grant permission to existing users: %w", err)
+		}
+	}
+
+	for _, cred := range credentials {
+		if len(extra[cred.Username]) == 0 {
+			continue
+		}
+		if err := mdba.revokePrivileges(cred.Username, extra[cred.Username]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RotateCredentials implements DbAdmin
+func (mdba *MySQLDbAdmin) RotateCredentials(credentials []dbadmin.Credentials) error {
+	if len(credentials) == 0 {
+		return nil
+	}
+
+	alterClauses := make([]string, 0, len(credentials))
+	alterArgs := make([]sqlValue, 0, len(credentials)*2)
+	for _, cred := range credentials {
+		identified, secret, err := mdba.identifiedClause(cred)
+		if err != nil {
+			return err
+		}
+		if cred.RetainCurrentPassword && mdba.hasFeature(dbadmin.FeatureDualPasswords) && !identityPlugins[cred.AuthPlugin] {
+			identified += " RETAIN CURRENT PASSWORD"
+		}
+		alterClauses = append(alterClauses, "%s@'%%' "+identified)
+		alterArgs = append(alterArgs, quoted(cred.Username), secret)
+	}
+
+	attributes, err := mdba.attributeStatements(credentials)
+	if err != nil {
+		return err
+	}
+
+	err = mdba.exec(
+		"ALTER USER "+strings.Join(alterClauses, ", "),
+		alterArgs...,
 	)
 	if err != nil {
-		return fmt.Errorf("Unable to grant permission to new user %s: %w", username, wrap(err))
+		return fmt.Errorf("Unable to rotate passwords for batch of %d users: %w", len(credentials), redact.Error(err, passwords(credentials)...))
+	}
+
+	for _, statement := range attributes {
+		if err := mdba.exec(statement.format, statement.args...); err != nil {
+			return fmt.Errorf("Unable to record rotation on users: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DiscardOldPasswords implements DbAdmin
+func (mdba *MySQLDbAdmin) DiscardOldPasswords(usernames []string) error {
+	if len(usernames) == 0 || !mdba.hasFeature(dbadmin.FeatureDualPasswords) {
+		return nil
 	}
 
+	discardClauses := make([]string, 0, len(usernames))
+	discardArgs := make([]sqlValue, 0, len(usernames))
+	for _, username := range usernames {
+		discardClauses = append(discardClauses, "%s@'%%' DISCARD OLD PASSWORD")
+		discardArgs = append(discardArgs, quoted(username))
+	}
+
+	if err := mdba.exec("ALTER USER "+strings.Join(discardClauses, ", "), discardArgs...); err != nil {
+		return fmt.Errorf("Unable to discard old passwords for batch of %d users: %w", len(usernames), err)
+	}
 	return nil
 }
 
 // ListUsernames implements DbADmin
 func (mdba *MySQLDbAdmin) ListUsernames(usernamePrefix string) ([]string, error) {
-	rows, err := mdba.handle.Query(
-		"SELECT user FROM mysql.user WHERE user LIKE ?",
-		usernamePrefix+"%",
-	)
+	const listUsersQuery = "SELECT user FROM mysql.user WHERE user LIKE ?"
+	rows, err := mdba.query(listUsersQuery, usernamePrefix+"%")
 	if err != nil {
 		return []string{}, fmt.Errorf("Unable to list existing usernames: %w", wrap(err))
 	}
@@ -168,12 +744,14 @@ func (mdba *MySQLDbAdmin) ListUsernames(usernamePrefix string) ([]string, error)
 	return usernames, nil
 }
 
+// activeSessionsRetryDelay is how long to wait before trying again to remove
+// a user which still has sessions
+const activeSessionsRetryDelay = 5 * time.Minute
+
 // VerifyUnusedAndDeleteCredentials implements DbAdmin
 func (mdba *MySQLDbAdmin) VerifyUnusedAndDeleteCredentials(username string) error {
-	sessionCountRow := mdba.handle.QueryRow(
-		"SELECT COUNT(*) FROM information_schema.processlist WHERE user = ?",
-		username,
-	)
+	const sessionCountQuery = "SELECT COUNT(*) FROM information_schema.processlist WHERE user = ?"
+	sessionCountRow := mdba.queryRow(sessionCountQuery, username)
 
 	var sessionCount int
 	err := sessionCountRow.Scan(&sessionCount)
@@ -182,10 +760,15 @@ func (mdba *MySQLDbAdmin) VerifyUnusedAndDeleteCredentials(username string) erro
 	}
 
 	if sessionCount > 0 {
-		return xerrors.NewTempErrorf("Unable to remove user %s, %d active sessions remaining", username, sessionCount)
+		// Applications drain their connections over minutes, not seconds
+		return xerrors.NewTempErrorf("Unable to remove user %s, %d active sessions remaining", username, sessionCount).WithRetryAfter(activeSessionsRetryDelay)
 	}
 
-	err = mdba.indirectSubstitute(
+	return mdba.dropUser(username)
+}
+
+func (mdba *MySQLDbAdmin) dropUser(username string) error {
+	err := mdba.exec(
 		"DROP USER %s",
 		quoted(username),
 	)
@@ -196,9 +779,57 @@ func (mdba *MySQLDbAdmin) VerifyUnusedAndDeleteCredentials(username string) erro
 	return nil
 }
 
+// ExecutePlan implements DbAdmin
+func (mdba *MySQLDbAdmin) ExecutePlan(plan dbadmin.AdminPlan) error {
+	return dbadmin.RunPlan(mdba, plan)
+}
+
+// ListAccountActivity implements DbAdmin
+func (mdba *MySQLDbAdmin) ListAccountActivity(usernamePrefix string) ([]dbadmin.AccountActivity, error) {
+	// There is a row per host that each user has connected from
+	const accountsQuery = "SELECT user, SUM(current_connections), SUM(total_connections) " +
+		"FROM performance_schema.accounts WHERE user LIKE ? GROUP BY user"
+	rows, err := mdba.query(accountsQuery, usernamePrefix+"%")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list account activity: %w", wrap(err))
+	}
+
+	var activity []dbadmin.AccountActivity
+	defer rows.Close()
+	for rows.Next() {
+		var account dbadmin.AccountActivity
+		if err := rows.Scan(&account.Username, &account.CurrentConnections, &account.TotalConnections); err != nil {
+			return nil, fmt.Errorf("Unable to parse account activity from result: %w", wrap(err))
+		}
+		activity = append(activity, account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	return activity, nil
+}
+
+// LockCredentials implements DbAdmin
+func (mdba *MySQLDbAdmin) LockCredentials(username string) error {
+	if err := mdba.exec("ALTER USER %s@'%%' ACCOUNT LOCK", quoted(username)); err != nil {
+		return fmt.Errorf("Unable to lock user %s: %w", username, err)
+	}
+	return nil
+}
+
+// UnlockCredentials implements DbAdmin
+func (mdba *MySQLDbAdmin) UnlockCredentials(username string) error {
+	if err := mdba.exec("ALTER USER %s@'%%' ACCOUNT UNLOCK", quoted(username)); err != nil {
+		return fmt.Errorf("Unable to unlock user %s: %w", username, err)
+	}
+	return nil
+}
+
 // GetSchemaVersion implements DbAdmin
 func (mdba *MySQLDbAdmin) GetSchemaVersion() (string, error) {
-	versionRow := mdba.handle.QueryRow(mdba.engine.GetVersionQuery())
+	versionQuery := mdba.engine.GetVersionQuery()
+	versionRow := mdba.queryRow(versionQuery)
 
 	var version string
 	if err := versionRow.Scan(&version); err != nil {
@@ -0,0 +1,455 @@
+package mysqladmin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/redact/redacttest"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// fakeExecutor records every statement template it receives and fails the
+// statement at the configured position
+type fakeExecutor struct {
+	statements []string
+	failAt     map[int]error
+}
+
+func (fe *fakeExecutor) exec(format string, args ...sqlValue) xerrors.EnhancedError {
+	position := len(fe.statements)
+	fe.statements = append(fe.statements, format)
+	if err, ok := fe.failAt[position]; ok {
+		return wrap(err)
+	}
+	return nil
+}
+
+func newFakeAdmin(failAt map[int]error) (*MySQLDbAdmin, *fakeExecutor) {
+	fake := &fakeExecutor{failAt: failAt}
+	return &MySQLDbAdmin{database: "quay", log: redacttest.NewRecordingLogger(), exec: fake.exec}, fake
+}
+
+var testCredentials = []dbadmin.Credentials{
+	{Username: "dba_v1", Password: seededPassword},
+	{Username: "dba_v2", Password: seededPassword + "2"},
+}
+
+func TestWriteCredentialsSuccess(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	if err := admin.WriteCredentialsBatch(testCredentials); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(fake.statements) != 2 ||
+		!strings.HasPrefix(fake.statements[0], "CREATE USER") ||
+		!strings.HasPrefix(fake.statements[1], "GRANT") {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestWriteCredentialsCreateFailure(t *testing.T) {
+	admin, fake := newFakeAdmin(map[int]error{0: &mysql.MySQLError{Number: 1396, Message: "Operation CREATE USER failed"}})
+
+	err := admin.WriteCredentialsBatch(testCredentials)
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	// A failed CREATE USER may have collided with existing users, which must
+	// never be dropped on our behalf
+	if len(fake.statements) != 1 {
+		t.Errorf("No compensating statements should run after CREATE USER fails: %v", fake.statements)
+	}
+	redacttest.AssertNoSecrets(t, err.Error(), testCredentials[0].Password, testCredentials[1].Password)
+}
+
+func TestWriteCredentialsGrantFailureRollsBack(t *testing.T) {
+	grantErr := &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}
+	admin, fake := newFakeAdmin(map[int]error{1: grantErr})
+
+	err := admin.WriteCredentialsBatch(testCredentials)
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	if len(fake.statements) != 3 || !strings.HasPrefix(fake.statements[2], "DROP USER IF EXISTS %s@'%%', %s@'%%'") {
+		t.Errorf("Expected the created users to be dropped: %v", fake.statements)
+	}
+
+	var enhanced xerrors.EnhancedError
+	if !errors.As(err, &enhanced) || !enhanced.Temporary() {
+		t.Errorf("A temporary grant failure should remain temporary after rollback")
+	}
+}
+
+func TestWriteCredentialsRollbackFailure(t *testing.T) {
+	admin, fake := newFakeAdmin(map[int]error{
+		1: &mysql.MySQLError{Number: 1044, Message: "Access denied"},
+		2: mysql.ErrInvalidConn,
+	})
+
+	err := admin.WriteCredentials(testCredentials[0].Username, testCredentials[0].Password)
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	if len(fake.statements) != 3 {
+		t.Errorf("Expected a rollback attempt: %v", fake.statements)
+	}
+	if !strings.Contains(err.Error(), "unable to remove them") {
+		t.Errorf("Error should report the failed rollback: %v", err)
+	}
+}
+
+func TestWriteCredentialsEmptyBatch(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	if err := admin.WriteCredentialsBatch(nil); err != nil || len(fake.statements) != 0 {
+		t.Errorf("An empty batch should be a no-op: %v %v", err, fake.statements)
+	}
+}
+
+func TestWriteCredentialsRejectsLongUsernames(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	long := []dbadmin.Credentials{{Username: "dba_" + strings.Repeat("v", 29), Password: seededPassword}}
+	if err := admin.WriteCredentialsBatch(long); err == nil || len(fake.statements) != 0 {
+		t.Errorf("Usernames beyond the limit should be refused before any statement: %v %v", err, fake.statements)
+	}
+
+	mariadb, _ := parseServerVersion("10.6.12-MariaDB", "", "")
+	admin.server = &mariadb
+	if err := admin.WriteCredentialsBatch(long); err != nil {
+		t.Errorf("MariaDB accepts usernames of up to 80 characters: %v", err)
+	}
+}
+
+func TestWriteCredentialsGrantsAcrossDatabases(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	shared := []dbadmin.DatabaseGrant{
+		{Database: "quay", Class: dbadmin.GrantClassReadWrite},
+		{Database: "reference-data", Class: dbadmin.GrantClassReadOnly},
+	}
+	credentials := []dbadmin.Credentials{
+		{Username: "dba_v1", Password: "a", Grants: shared},
+		{Username: "dba_v2", Password: "b", Grants: shared},
+	}
+
+	if err := admin.WriteCredentialsBatch(credentials); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"CREATE USER %s@'%%' IDENTIFIED BY %s, %s@'%%' IDENTIFIED BY %s",
+		"GRANT SELECT, INSERT, UPDATE, DELETE ON %s.* TO %s, %s",
+		"GRANT SELECT ON %s.* TO %s, %s",
+	}
+	if strings.Join(fake.statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestWriteCredentialsExecuteGrant(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	credentials := []dbadmin.Credentials{{Username: "dba_v1", Password: "a", Grants: []dbadmin.DatabaseGrant{
+		{Class: dbadmin.GrantClassReadWrite},
+		{Class: dbadmin.GrantClassExecute},
+	}}}
+
+	if err := admin.WriteCredentialsBatch(credentials); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"CREATE USER %s@'%%' IDENTIFIED BY %s",
+		"GRANT EXECUTE ON %s.* TO %s",
+		"GRANT SELECT, INSERT, UPDATE, DELETE ON %s.* TO %s",
+	}
+	if strings.Join(fake.statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestWriteCredentialsReplicationGrant(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	credentials := []dbadmin.Credentials{{Username: "dba_cdc", Password: "a", Grants: []dbadmin.DatabaseGrant{
+		{Class: dbadmin.GrantClassReadOnly},
+		{Class: dbadmin.GrantClassReplication},
+	}}}
+
+	if err := admin.WriteCredentialsBatch(credentials); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"CREATE USER %s@'%%' IDENTIFIED BY %s",
+		"GRANT REPLICATION SLAVE, REPLICATION CLIENT ON *.* TO %s",
+		"GRANT SELECT ON %s.* TO %s",
+	}
+	if strings.Join(fake.statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestWriteCredentialsTableScopedGrants(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	credentials := []dbadmin.Credentials{{Username: "dba_v1", Password: "a", Grants: []dbadmin.DatabaseGrant{
+		{Database: "shared", Class: dbadmin.GrantClassReadOnly, Tables: []dbadmin.TableGrant{
+			{Table: "users", Columns: []string{"id", "name"}},
+			{Table: "orgs"},
+		}},
+		{Database: "shared", Class: dbadmin.GrantClassReadWrite, Tables: []dbadmin.TableGrant{{Table: "events"}}},
+	}}}
+
+	if err := admin.WriteCredentialsBatch(credentials); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"CREATE USER %s@'%%' IDENTIFIED BY %s",
+		"GRANT SELECT ON %s.%s TO %s",
+		"GRANT SELECT (%s, %s) ON %s.%s TO %s",
+		"GRANT SELECT, INSERT, UPDATE, DELETE ON %s.%s TO %s",
+	}
+	if strings.Join(fake.statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestWriteCredentialsRejectsWritableColumnGrants(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	credentials := []dbadmin.Credentials{{Username: "dba_v1", Password: "a", Grants: []dbadmin.DatabaseGrant{
+		{Class: dbadmin.GrantClassReadWrite, Tables: []dbadmin.TableGrant{{Table: "users", Columns: []string{"name"}}}},
+	}}}
+
+	if err := admin.WriteCredentialsBatch(credentials); err == nil {
+		t.Errorf("Expected an error for a column scoped read-write grant")
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("No statements should be run for an invalid grant: %v", fake.statements)
+	}
+}
+
+func TestWriteCredentialsRejectsUnknownGrantClass(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	err := admin.WriteCredentials("dba_v1", "a")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	fake.statements = nil
+	err = admin.WriteCredentialsBatch([]dbadmin.Credentials{
+		{Username: "dba_v1", Password: "a", Grants: []dbadmin.DatabaseGrant{{Database: "quay", Class: "superuser"}}},
+	})
+	if err == nil || len(fake.statements) != 0 {
+		t.Errorf("Unknown grant classes should be rejected before any user is created: %v %v", err, fake.statements)
+	}
+}
+
+func TestWriteCredentialsAuthPlugin(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	credentials := []dbadmin.Credentials{
+		{Username: "dba_v1", Password: seededPassword, AuthPlugin: dbadmin.AuthPluginCachingSHA2Password},
+		{Username: "dba_v2", Password: seededPassword, AuthPlugin: dbadmin.AuthPluginNativePassword},
+		{Username: "dba_v3", Password: seededPassword},
+	}
+	if err := admin.WriteCredentialsBatch(credentials); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "CREATE USER %s@'%%' IDENTIFIED WITH caching_sha2_password BY %s, " +
+		"%s@'%%' IDENTIFIED WITH mysql_native_password BY %s, " +
+		"%s@'%%' IDENTIFIED BY %s"
+	if fake.statements[0] != expected {
+		t.Errorf("Unexpected CREATE USER template: %s", fake.statements[0])
+	}
+}
+
+func TestWriteCredentialsRejectsUnknownAuthPlugin(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	credentials := []dbadmin.Credentials{
+		{Username: "dba_v1", Password: seededPassword, AuthPlugin: "auth_socket BY 'x'; DROP USER root"},
+	}
+	if err := admin.WriteCredentialsBatch(credentials); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if err := admin.RotateCredentials(credentials); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("No statements should run for an unknown auth plugin: %v", fake.statements)
+	}
+}
+
+func TestWriteCredentialsIdentityMapping(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	credentials := []dbadmin.Credentials{{
+		Username:   "dbr_0123456789abcdef",
+		AuthPlugin: dbadmin.AuthPluginOpenIDConnect,
+		Identity: &dbadmin.IdentityMapping{
+			Provider: "kubernetes",
+			Subject:  "system:serviceaccount:quay:quay-app",
+		},
+	}}
+	if err := admin.WriteCredentialsBatch(credentials); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "CREATE USER %s@'%%' IDENTIFIED WITH authentication_openid_connect AS %s"
+	if fake.statements[0] != expected {
+		t.Errorf("Unexpected CREATE USER template: %s", fake.statements[0])
+	}
+
+	authString, err := openIDConnectAuthString(credentials[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authString != `{"identity_provider":"kubernetes","user":"system:serviceaccount:quay:quay-app"}` {
+		t.Errorf("Unexpected authentication string: %s", authString)
+	}
+}
+
+func TestWriteCredentialsRejectsIncompleteIdentity(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	for _, cred := range []dbadmin.Credentials{
+		{Username: "dbr_1", AuthPlugin: dbadmin.AuthPluginOpenIDConnect},
+		{Username: "dbr_2", AuthPlugin: dbadmin.AuthPluginOpenIDConnect, Identity: &dbadmin.IdentityMapping{Provider: "kubernetes"}},
+		{Username: "dbr_3", Password: seededPassword, Identity: &dbadmin.IdentityMapping{Provider: "kubernetes", Subject: "a"}},
+	} {
+		if err := admin.WriteCredentialsBatch([]dbadmin.Credentials{cred}); err == nil {
+			t.Errorf("%s: expected an error", cred.Username)
+		}
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("No statements should run for an invalid identity mapping: %v", fake.statements)
+	}
+}
+
+func TestLockAndUnlockCredentials(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	if err := admin.LockCredentials("dba_v1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := admin.UnlockCredentials("dba_v1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(fake.statements) != 2 ||
+		fake.statements[0] != "ALTER USER %s@'%%' ACCOUNT LOCK" ||
+		fake.statements[1] != "ALTER USER %s@'%%' ACCOUNT UNLOCK" {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestReapplyGrantsOnlyGrants(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	credentials := []dbadmin.Credentials{
+		{Username: "dba_v1", Grants: []dbadmin.DatabaseGrant{{Class: dbadmin.GrantClassReadOnly}}},
+		{Username: "dba_v2", Grants: []dbadmin.DatabaseGrant{{Class: dbadmin.GrantClassReadOnly}}},
+	}
+	if err := admin.reapplyGrants(credentials, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(fake.statements) != 1 || fake.statements[0] != "GRANT SELECT ON %s.* TO %s, %s" {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestReapplyGrantsRevokesExtraPrivileges(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	credentials := []dbadmin.Credentials{
+		{Username: "dba_v1", Grants: []dbadmin.DatabaseGrant{
+			{Class: dbadmin.GrantClassReadOnly},
+			{Database: "reference", Class: dbadmin.GrantClassReadOnly, Tables: []dbadmin.TableGrant{{Table: "regions", Columns: []string{"name"}}}},
+		}},
+		{Username: "dba_v2"},
+	}
+	held := map[string][]dbadmin.UserPrivilege{
+		"dba_v1": {
+			{Privilege: "DELETE"},
+			{Privilege: "SELECT"},
+			{Database: "*", Privilege: "REPLICATION SLAVE"},
+			{Database: "*", Privilege: "PROCESS"},
+			{Database: "reference", Table: "regions", Column: "code", Privilege: "SELECT"},
+			{Database: "reference", Table: "regions", Column: "name", Privilege: "SELECT"},
+		},
+		"dba_v2": {
+			{Privilege: "DELETE"},
+			{Privilege: "INSERT"},
+			{Privilege: "SELECT"},
+			{Privilege: "UPDATE"},
+		},
+	}
+	if err := admin.reapplyGrants(credentials, held); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"GRANT SELECT ON %s.* TO %s",
+		"GRANT SELECT, INSERT, UPDATE, DELETE ON %s.* TO %s",
+		"GRANT SELECT (%s) ON %s.%s TO %s",
+		"REVOKE DELETE ON %s.* FROM %s@'%%'",
+		"REVOKE REPLICATION SLAVE ON *.* FROM %s@'%%'",
+		"REVOKE SELECT (%s) ON %s.%s FROM %s@'%%'",
+	}
+	if strings.Join(fake.statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestRotateCredentialsRetainsCurrentPassword(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+	admin.server = &dbadmin.ServerInfo{Flavor: dbadmin.FlavorMySQL, Version: "8.0.14", Features: []dbadmin.ServerFeature{dbadmin.FeatureDualPasswords}}
+
+	credentials := []dbadmin.Credentials{
+		{Username: "dba_v1", Password: seededPassword, RetainCurrentPassword: true},
+		{Username: "dba_v2", Password: seededPassword + "2"},
+	}
+	if err := admin.RotateCredentials(credentials); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := admin.DiscardOldPasswords([]string{"dba_v1"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(fake.statements) != 2 ||
+		fake.statements[0] != "ALTER USER %s@'%%' IDENTIFIED BY %s RETAIN CURRENT PASSWORD, %s@'%%' IDENTIFIED BY %s" ||
+		fake.statements[1] != "ALTER USER %s@'%%' DISCARD OLD PASSWORD" {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestDiscardOldPasswordsWithoutDualPasswords(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+	admin.server = &dbadmin.ServerInfo{Flavor: dbadmin.FlavorMySQL, Version: "5.7.30"}
+
+	if err := admin.RotateCredentials([]dbadmin.Credentials{{Username: "dba_v1", Password: seededPassword, RetainCurrentPassword: true}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := admin.DiscardOldPasswords([]string{"dba_v1"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(fake.statements) != 1 || fake.statements[0] != "ALTER USER %s@'%%' IDENTIFIED BY %s" {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
@@ -0,0 +1,99 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// attributesKey is the member of each user's attributes which the operator
+// owns, the rest of the object is left to others
+const attributesKey = "dbaOperator"
+
+// attributeStatements returns the statements which record the attributes of
+// the credentials on their users. Nothing is recorded on servers which don't
+// support attributes.
+func (mdba *MySQLDbAdmin) attributeStatements(credentials []dbadmin.Credentials) ([]grantStatement, error) {
+	if !mdba.hasFeature(dbadmin.FeatureUserAttributes) {
+		return nil, nil
+	}
+
+	var statements []grantStatement
+	for _, cred := range credentials {
+		if cred.Attributes == nil {
+			continue
+		}
+
+		// The server merges the object into the existing attributes
+		encoded, err := json.Marshal(map[string]dbadmin.UserAttributes{attributesKey: *cred.Attributes})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to encode attributes for user %s: %w", cred.Username, err)
+		}
+		statements = append(statements, grantStatement{
+			format: "ALTER USER %s@'%%' ATTRIBUTE %s",
+			args:   []sqlValue{quoted(cred.Username), quoted(string(encoded))},
+		})
+	}
+	return statements, nil
+}
+
+// ListUserAttributes implements DbAdmin
+func (mdba *MySQLDbAdmin) ListUserAttributes(usernamePrefix string) (map[string]dbadmin.UserAttributes, error) {
+	attributes := make(map[string]dbadmin.UserAttributes)
+	if !mdba.hasFeature(dbadmin.FeatureUserAttributes) {
+		return attributes, nil
+	}
+
+	const attributesQuery = "SELECT USER, ATTRIBUTE FROM information_schema.USER_ATTRIBUTES WHERE USER LIKE ? AND HOST = '%'"
+	rows, err := mdba.query(attributesQuery, usernamePrefix+"%")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list user attributes: %w", wrap(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var username string
+		var raw sql.NullString
+		if err := rows.Scan(&username, &raw); err != nil {
+			return nil, fmt.Errorf("Unable to parse user attributes from result: %w", wrap(err))
+		}
+		if !raw.Valid {
+			continue
+		}
+
+		parsed, err := parseUserAttributes(raw.String)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse attributes of user %s: %w", username, err)
+		}
+		if parsed != nil {
+			attributes[username] = *parsed
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	return attributes, nil
+}
+
+// parseUserAttributes extracts the operator's member from the attributes of
+// a user, returning nil if it has none
+func parseUserAttributes(raw string) (*dbadmin.UserAttributes, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &object); err != nil {
+		return nil, err
+	}
+
+	member, ok := object[attributesKey]
+	if !ok || string(member) == "null" {
+		return nil, nil
+	}
+
+	var attributes dbadmin.UserAttributes
+	if err := json.Unmarshal(member, &attributes); err != nil {
+		return nil, err
+	}
+	return &attributes, nil
+}
@@ -0,0 +1,68 @@
+package mysqladmin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestWriteCredentialsRecordsAttributes(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	credentials := []dbadmin.Credentials{{
+		Username: "dba_v1",
+		Password: seededPassword,
+		Attributes: &dbadmin.UserAttributes{
+			Database:    "quay/quay",
+			DatabaseUID: "uid-1",
+			CreatedAt:   &created,
+		},
+	}}
+
+	// Servers which can't record attributes don't get any
+	if err := admin.WriteCredentialsBatch(credentials); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fake.statements) != 2 {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+
+	admin, fake = newFakeAdmin(nil)
+	server, _ := parseServerVersion("8.0.32", "", "")
+	admin.server = &server
+	if err := admin.WriteCredentialsBatch(credentials); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fake.statements) != 3 || fake.statements[2] != "ALTER USER %s@'%%' ATTRIBUTE %s" {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+
+	statements, err := admin.attributeStatements(credentials)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `{"dbaOperator":{"database":"quay/quay","databaseUID":"uid-1","createdAt":"2020-01-02T03:04:05Z"}}`
+	if encoded := *statements[0].args[1].value; encoded != expected {
+		t.Errorf("Unexpected attributes: %s", encoded)
+	}
+}
+
+func TestParseUserAttributes(t *testing.T) {
+	parsed, err := parseUserAttributes(`{"comment": "owned by someone else", "dbaOperator": {"databaseUID": "uid-1", "rotationGeneration": 3}}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if parsed == nil || parsed.DatabaseUID != "uid-1" || parsed.RotationGeneration != 3 {
+		t.Errorf("Unexpected attributes: %+v", parsed)
+	}
+
+	parsed, err = parseUserAttributes(`{"comment": "not issued by the operator"}`)
+	if err != nil || parsed != nil {
+		t.Errorf("Expected no attributes, got %+v and %v", parsed, err)
+	}
+
+	if _, err := parseUserAttributes(`not json`); err == nil {
+		t.Errorf("Expected an error for invalid attributes")
+	}
+}
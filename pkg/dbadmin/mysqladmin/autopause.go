@@ -0,0 +1,99 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+const (
+	// resumeRetryDelay is how long a reconcile waits after finding the
+	// database resuming, Aurora Serverless usually resumes within 30s
+	resumeRetryDelay = 15 * time.Second
+
+	// wakePingInterval separates the pings while waiting for a resume
+	wakePingInterval = 2 * time.Second
+
+	defaultResumeTimeout = time.Minute
+)
+
+// AutoPauseOptions enables support for databases which pause themselves while
+// they have no connections, such as Aurora Serverless
+type AutoPauseOptions struct {
+	// WakeUp pings the database until it has resumed before the admin is
+	// returned, instead of failing with a temporary error while it resumes
+	WakeUp bool
+
+	// ResumeTimeout is how long WakeUp waits for the database to resume
+	ResumeTimeout time.Duration
+}
+
+// WithAutoPause closes connections as soon as they are idle, so that the
+// operator doesn't keep the database from pausing, and checks that the
+// database has resumed before the admin is used.
+func WithAutoPause(autoPause AutoPauseOptions) Option {
+	return func(mdba *MySQLDbAdmin) {
+		mdba.autoPause = &autoPause
+	}
+}
+
+// configurePool applies the pool settings of the admin to a new pool
+func (mdba *MySQLDbAdmin) configurePool(handle *sql.DB) {
+	if mdba.autoPause != nil {
+		handle.SetMaxIdleConns(0)
+	}
+}
+
+// awaitResume pings the database, and keeps pinging it while it resumes if
+// the admin should wake it up
+func (mdba *MySQLDbAdmin) awaitResume() error {
+	timeout := mdba.autoPause.ResumeTimeout
+	if timeout <= 0 {
+		timeout = defaultResumeTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		err := mdba.handle.Ping()
+		if err == nil {
+			return nil
+		}
+		if !resuming(err) {
+			return wrap(err)
+		}
+		if !mdba.autoPause.WakeUp || time.Now().Add(wakePingInterval).After(deadline) {
+			return xerrors.NewTempErrorf("Database %s is resuming from a pause: %s", mdba.database, wrap(err)).WithRetryAfter(resumeRetryDelay)
+		}
+
+		mdba.log.Info("Waiting for database to resume from a pause")
+		time.Sleep(wakePingInterval)
+	}
+}
+
+// resuming returns true if the error is one which a paused database returns
+// while it resumes. Connections to Aurora Serverless are either held until
+// it has resumed, and may time out first, or refused with a message saying
+// that it is resuming.
+func resuming(err error) bool {
+	if resumingMessage(err) {
+		return true
+	}
+	if err == mysql.ErrInvalidConn || err == driver.ErrBadConn {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// resumingMessage returns true if the error says that the database is
+// resuming, which it does for every connection during a resume
+func resumingMessage(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "resuming")
+}
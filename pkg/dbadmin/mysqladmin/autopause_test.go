@@ -0,0 +1,51 @@
+package mysqladmin
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestResumingErrorsAreRetriedSoon(t *testing.T) {
+	err := wrap(&mysql.MySQLError{Number: 1105, Message: "Database is resuming, please retry"})
+	if !err.Temporary() {
+		t.Error("Expected resuming errors to be temporary")
+	}
+	if delay := xerrors.Backoff(err, 5, time.Minute, time.Hour); delay != resumeRetryDelay {
+		t.Errorf("Resuming errors should be retried after %s, got %s", resumeRetryDelay, delay)
+	}
+
+	unrelated := wrap(&mysql.MySQLError{Number: 1045, Message: "Access denied"})
+	if unrelated.Temporary() {
+		t.Error("Expected access denied to stay permanent")
+	}
+}
+
+func TestResuming(t *testing.T) {
+	var dialTimeout net.Error = timeoutError{}
+	resumes := []error{
+		errors.New("DatabaseResumingException: the database is resuming"),
+		mysql.ErrInvalidConn,
+		&net.OpError{Op: "dial", Net: "tcp", Err: dialTimeout},
+	}
+	for _, err := range resumes {
+		if !resuming(err) {
+			t.Errorf("Expected %v to be seen as resuming", err)
+		}
+	}
+
+	if resuming(&mysql.MySQLError{Number: 1045, Message: "Access denied"}) {
+		t.Error("Access denied isn't caused by a resume")
+	}
+}
@@ -0,0 +1,137 @@
+package mysqladmin
+
+import (
+	"fmt"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+type bootstrapGrant struct {
+	operation  dbadmin.Operation
+	privileges string
+
+	// object is the template of what the privileges are granted on, a %s in
+	// it is replaced with the managed database
+	object      string
+	grantOption bool
+}
+
+// bootstrapGrants lists what the operator's admin user needs for each
+// operation, the operations of a profile are granted in this order
+var bootstrapGrants = []bootstrapGrant{
+	// Credentials are created and altered for every account, their sessions
+	// and activity are inspected before they are dropped, and their
+	// privileges are delegated from the admin user
+	{operation: dbadmin.OperationCredentials, privileges: "CREATE USER, PROCESS", object: "*.*"},
+	{operation: dbadmin.OperationCredentials, privileges: "SELECT", object: "mysql.user"},
+	{operation: dbadmin.OperationCredentials, privileges: "SELECT", object: "performance_schema.*"},
+	{operation: dbadmin.OperationCredentials, privileges: "SELECT, INSERT, UPDATE, DELETE, EXECUTE", object: "%s.*", grantOption: true},
+
+	// Ownership leases are kept in the operator's own schema, which it
+	// creates when they are first used
+	{operation: dbadmin.OperationCredentials, privileges: "SELECT, INSERT, UPDATE, CREATE", object: leaseSchema + ".*"},
+
+	// Migrations are watched through the replication status and lock waits,
+	// and the sessions which block or outlive them are killed. Maintenance
+	// after a migration and schema snapshots need the rest.
+	{operation: dbadmin.OperationMigrations, privileges: "REPLICATION CLIENT", object: "*.*"},
+	{operation: dbadmin.OperationMigrations, privileges: "SELECT, EXECUTE", object: "sys.*"},
+	{operation: dbadmin.OperationMigrations, privileges: "SHOW VIEW", object: "%s.*"},
+
+	{operation: dbadmin.OperationHelperRoutines, privileges: "CREATE ROUTINE, ALTER ROUTINE", object: "%s.*"},
+
+	// Replacing and removing a view needs the DROP privilege
+	{operation: dbadmin.OperationMaskedViews, privileges: "CREATE VIEW, SHOW VIEW, DROP", object: "%s.*"},
+
+	{operation: dbadmin.OperationScheduling, privileges: "EVENT", object: "%s.*"},
+	{operation: dbadmin.OperationPartitioning, privileges: "ALTER, DROP", object: "%s.*"},
+	{operation: dbadmin.OperationCharset, privileges: "ALTER", object: "%s.*"},
+}
+
+// killPrivilege returns the privilege which allows killing the sessions of
+// other accounts on the flavor of server. MySQL before 8.0 only allows it
+// with SUPER, which the profiles don't grant.
+func killPrivilege(flavor dbadmin.ServerFlavor) string {
+	switch flavor {
+	case dbadmin.FlavorMariaDB:
+		return "CONNECTION ADMIN"
+	case dbadmin.FlavorTiDB:
+		return "SUPER"
+	default:
+		return "CONNECTION_ADMIN"
+	}
+}
+
+// variablesPrivilege returns the privilege which allows setting global server
+// variables on the flavor of server
+func variablesPrivilege(flavor dbadmin.ServerFlavor) string {
+	switch flavor {
+	case dbadmin.FlavorMariaDB, dbadmin.FlavorTiDB:
+		return "SUPER"
+	default:
+		return "SYSTEM_VARIABLES_ADMIN"
+	}
+}
+
+// BootstrapStatements returns the statements which create the operator's
+// admin user with exactly the privileges of the profile, for the specified
+// flavor of server and managed database
+func BootstrapStatements(profile dbadmin.PrivilegeProfile, flavor dbadmin.ServerFlavor, username, host, database string) ([]string, error) {
+	if !profile.Known() {
+		return nil, fmt.Errorf("Unknown privilege profile: %s", profile)
+	}
+	if username == "" || host == "" || database == "" {
+		return nil, fmt.Errorf("A username, host and database are required")
+	}
+
+	account := []sqlValue{quoted(username), quoted(host)}
+	public := map[string]bool{username: true, host: true}
+
+	// The password is chosen by whoever runs the statements
+	created, err := renderStatement("CREATE USER IF NOT EXISTS %s@%s", account, public)
+	if err != nil {
+		return nil, err
+	}
+	statements := []string{created}
+
+	grants := bootstrapGrants
+	if profile.Allows(dbadmin.OperationMigrations) {
+		grants = append(grants[:len(grants):len(grants)], bootstrapGrant{
+			operation:  dbadmin.OperationMigrations,
+			privileges: killPrivilege(flavor),
+			object:     "*.*",
+		})
+	}
+
+	if profile.Allows(dbadmin.OperationParameters) {
+		grants = append(grants[:len(grants):len(grants)], bootstrapGrant{
+			operation:  dbadmin.OperationParameters,
+			privileges: variablesPrivilege(flavor),
+			object:     "*.*",
+		})
+	}
+
+	for _, grant := range grants {
+		if !profile.Allows(grant.operation) {
+			continue
+		}
+
+		format := "GRANT " + grant.privileges + " ON " + grant.object + " TO %s@%s"
+		var args []sqlValue
+		if grant.object == "%s.*" {
+			args = append(args, identifier(database))
+		}
+		args = append(args, account...)
+		if grant.grantOption {
+			format += " WITH GRANT OPTION"
+		}
+
+		rendered, err := renderStatement(format, args, public)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to render grant of %s: %w", grant.privileges, err)
+		}
+		statements = append(statements, rendered)
+	}
+
+	return statements, nil
+}
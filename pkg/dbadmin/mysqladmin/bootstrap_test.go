@@ -0,0 +1,54 @@
+package mysqladmin
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestBootstrapStatements(t *testing.T) {
+	statements, err := BootstrapStatements(dbadmin.ProfileCredentialsOnly, dbadmin.FlavorMySQL, "dba-operator", "%", "quay")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{
+		"CREATE USER IF NOT EXISTS 'dba-operator'@'%'",
+		"GRANT CREATE USER, PROCESS ON *.* TO 'dba-operator'@'%'",
+		"GRANT SELECT ON mysql.user TO 'dba-operator'@'%'",
+		"GRANT SELECT ON performance_schema.* TO 'dba-operator'@'%'",
+		"GRANT SELECT, INSERT, UPDATE, DELETE, EXECUTE ON `quay`.* TO 'dba-operator'@'%' WITH GRANT OPTION",
+		"GRANT SELECT, INSERT, UPDATE, CREATE ON dba_operator.* TO 'dba-operator'@'%'",
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Errorf("Unexpected statements: %#v", statements)
+	}
+
+	migrations, err := BootstrapStatements(dbadmin.ProfileCredentialsMigrations, dbadmin.FlavorMariaDB, "dba-operator", "%", "quay")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(migrations) != len(expected)+4 || migrations[len(migrations)-1] != "GRANT CONNECTION ADMIN ON *.* TO 'dba-operator'@'%'" {
+		t.Errorf("Unexpected statements: %#v", migrations)
+	}
+
+	full, err := BootstrapStatements(dbadmin.ProfileFull, dbadmin.FlavorMySQL, "dba-operator", "%", "quay")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(full) != len(migrations)+6 || full[len(full)-1] != "GRANT SYSTEM_VARIABLES_ADMIN ON *.* TO 'dba-operator'@'%'" {
+		t.Errorf("Unexpected statements: %#v", full)
+	}
+}
+
+func TestBootstrapStatementsRejectsInvalid(t *testing.T) {
+	if _, err := BootstrapStatements("owner", dbadmin.FlavorMySQL, "dba-operator", "%", "quay"); err == nil {
+		t.Errorf("Expected unknown profile to be rejected")
+	}
+	if _, err := BootstrapStatements(dbadmin.ProfileFull, dbadmin.FlavorMySQL, "dba-operator", "%", ""); err == nil {
+		t.Errorf("Expected missing database to be rejected")
+	}
+	if _, err := BootstrapStatements(dbadmin.ProfileFull, dbadmin.FlavorMySQL, "dba-operator", "%", "quay "); err == nil {
+		t.Errorf("Expected invalid database name to be rejected")
+	}
+}
@@ -0,0 +1,31 @@
+package mysqladmin
+
+import (
+	"fmt"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// GetCapacityUsage implements DbAdmin
+func (mdba *MySQLDbAdmin) GetCapacityUsage() (dbadmin.CapacityUsage, error) {
+	var usage dbadmin.CapacityUsage
+
+	const sizeQuery = "SELECT COALESCE(SUM(DATA_LENGTH), 0), COALESCE(SUM(INDEX_LENGTH), 0) FROM information_schema.TABLES WHERE TABLE_SCHEMA = ?"
+	if err := mdba.queryRow(sizeQuery, mdba.database).Scan(&usage.DataBytes, &usage.IndexBytes); err != nil {
+		return dbadmin.CapacityUsage{}, fmt.Errorf("Unable to estimate database size: %w", wrap(err))
+	}
+
+	const connectionsQuery = "SELECT COUNT(*) FROM information_schema.PROCESSLIST WHERE DB = ?"
+	if err := mdba.queryRow(connectionsQuery, mdba.database).Scan(&usage.Connections); err != nil {
+		return dbadmin.CapacityUsage{}, fmt.Errorf("Unable to count database sessions: %w", wrap(err))
+	}
+
+	// Statements are attributed to the default database of the session
+	// which ran them
+	const statementsQuery = "SELECT COALESCE(SUM(COUNT_STAR), 0) FROM performance_schema.events_statements_summary_by_digest WHERE SCHEMA_NAME = ?"
+	if err := mdba.queryRow(statementsQuery, mdba.database).Scan(&usage.Statements); err != nil {
+		return dbadmin.CapacityUsage{}, fmt.Errorf("Unable to count database statements: %w", wrap(err))
+	}
+
+	return usage, nil
+}
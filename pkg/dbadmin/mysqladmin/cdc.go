@@ -0,0 +1,36 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// ListBinlogConsumers implements DbAdmin
+func (mdba *MySQLDbAdmin) ListBinlogConsumers(usernamePrefix string) ([]dbadmin.BinlogConsumer, error) {
+	// Replicas and change data capture clients both show up as dump threads
+	const consumersQuery = "SELECT USER, HOST, TIME FROM information_schema.PROCESSLIST " +
+		"WHERE USER LIKE ? AND COMMAND IN ('Binlog Dump', 'Binlog Dump GTID')"
+	rows, err := mdba.query(consumersQuery, usernamePrefix+"%")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list binary log consumers: %w", wrap(err))
+	}
+
+	var consumers []dbadmin.BinlogConsumer
+	defer rows.Close()
+	for rows.Next() {
+		var consumer dbadmin.BinlogConsumer
+		var seconds int64
+		if err := rows.Scan(&consumer.Username, &consumer.Host, &seconds); err != nil {
+			return nil, fmt.Errorf("Unable to parse binary log consumer from result: %w", wrap(err))
+		}
+		consumer.StreamingFor = time.Duration(seconds) * time.Second
+		consumers = append(consumers, consumer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	return consumers, nil
+}
@@ -0,0 +1,66 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+var charsetName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// GetCharset implements DbAdmin
+func (mdba *MySQLDbAdmin) GetCharset() (dbadmin.Charset, error) {
+	var charset dbadmin.Charset
+
+	const defaultsQuery = "SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?"
+	if err := mdba.queryRow(defaultsQuery, mdba.database).Scan(&charset.CharacterSet, &charset.Collation); err != nil {
+		return charset, fmt.Errorf("Unable to read character set of database: %w", wrap(err))
+	}
+
+	const tablesQuery = "SELECT TABLE_COLLATION, COUNT(*) FROM information_schema.TABLES " +
+		"WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' GROUP BY TABLE_COLLATION"
+	rows, err := mdba.query(tablesQuery, mdba.database)
+	if err != nil {
+		return charset, fmt.Errorf("Unable to list collations of tables: %w", wrap(err))
+	}
+	defer rows.Close()
+
+	charset.TableCollations = make(map[string]int)
+	for rows.Next() {
+		var collation string
+		var count int
+		if err := rows.Scan(&collation, &count); err != nil {
+			return charset, fmt.Errorf("Unable to parse table collation from result: %w", wrap(err))
+		}
+		charset.TableCollations[collation] = count
+	}
+	if err := rows.Err(); err != nil {
+		return charset, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	return charset, nil
+}
+
+// SetCharset implements DbAdmin
+func (mdba *MySQLDbAdmin) SetCharset(characterSet, collation string) error {
+	if characterSet == "" {
+		return fmt.Errorf("A character set is required")
+	}
+	for _, name := range []string{characterSet, collation} {
+		if name != "" && (len(name) > maxIdentifierLength || !charsetName.MatchString(name)) {
+			return fmt.Errorf("Invalid character set or collation name: %s", name)
+		}
+	}
+
+	format := "ALTER DATABASE %s CHARACTER SET %s"
+	args := []sqlValue{identifier(mdba.database), noquote(characterSet)}
+	if collation != "" {
+		format += " COLLATE %s"
+		args = append(args, noquote(collation))
+	}
+	if err := mdba.exec(format, args...); err != nil {
+		return fmt.Errorf("Unable to change character set of database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package mysqladmin
+
+import (
+	"testing"
+)
+
+func TestSetCharset(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	if err := admin.SetCharset("utf8mb4", "utf8mb4_0900_ai_ci"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fake.statements) != 1 || fake.statements[0] != "ALTER DATABASE %s CHARACTER SET %s COLLATE %s" {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+
+	for _, names := range [][2]string{{"", "utf8mb4_bin"}, {"utf8mb4", "utf8mb4_bin; DROP DATABASE quay"}, {"UTF8MB4", "utf8mb4_bin"}} {
+		if err := admin.SetCharset(names[0], names[1]); err == nil {
+			t.Errorf("Expected an error for %v", names)
+		}
+	}
+	if len(fake.statements) != 1 {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+
+	// The character set's default collation is used without one
+	if err := admin.SetCharset("utf8mb4", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fake.statements) != 2 || fake.statements[1] != "ALTER DATABASE %s CHARACTER SET %s" {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
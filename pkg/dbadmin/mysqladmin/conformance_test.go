@@ -0,0 +1,50 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/conformance"
+	"github.com/app-sre/dba-operator/pkg/redact/redacttest"
+)
+
+// conformanceDSNVariable names the environment variable with the DSN of a
+// throwaway database, make conformance starts one in a container
+const conformanceDSNVariable = "CONFORMANCE_MYSQL_DSN"
+
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv(conformanceDSNVariable)
+	if dsn == "" {
+		t.Skipf("%s isn't set", conformanceDSNVariable)
+	}
+	config, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("Unable to parse %s: %v", conformanceDSNVariable, err)
+	}
+
+	conformance.Run(t, conformance.Backend{
+		Connect: func(t *testing.T) dbadmin.DbAdmin {
+			admin, err := CreateMySQLAdmin(dsn, nil, redacttest.NewRecordingLogger())
+			if err != nil {
+				t.Fatalf("Unable to connect: %v", err)
+			}
+			return admin
+		},
+		Login: func(username, password string) error {
+			login := *config
+			login.User, login.Passwd = username, password
+			db, err := sql.Open("mysql", login.FormatDSN())
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			return db.Ping()
+		},
+		UsernamePrefix:        "cf_",
+		CanaryTableDefinition: "CREATE TABLE conformance_canary (id INT PRIMARY KEY)",
+	})
+}
@@ -0,0 +1,75 @@
+package mysqladmin
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+// ValueQuerier runs the queries of consistency checks on MySQL servers
+type ValueQuerier struct{}
+
+// QueryValue implements dbadmin.ValueQuerier
+func (ValueQuerier) QueryValue(spec dbadmin.ConnectionSpec, query string, timeout time.Duration) (float64, error) {
+	db, dsn, err := openEndpoint(spec)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return 0, fmt.Errorf("Unable to start read-only transaction: %w", redact.Error(wrap(err), dsn, spec.Password))
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to run query: %w", redact.Error(wrap(err), dsn, spec.Password))
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, wrap(err)
+	}
+	if len(columns) != 1 {
+		return 0, fmt.Errorf("Query must return a single column, not %d", len(columns))
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, fmt.Errorf("Unable to run query: %w", redact.Error(wrap(err), dsn, spec.Password))
+		}
+		return 0, fmt.Errorf("Query returned no rows")
+	}
+
+	var value sql.NullString
+	if err := rows.Scan(&value); err != nil {
+		return 0, fmt.Errorf("Unable to read query result: %w", wrap(err))
+	}
+	if rows.Next() {
+		return 0, fmt.Errorf("Query must return a single row")
+	}
+	return parseValue(value)
+}
+
+// parseValue reads the value of a numeric column, which the driver returns
+// as text for DECIMAL and aggregate columns alike
+func parseValue(value sql.NullString) (float64, error) {
+	if !value.Valid {
+		return 0, fmt.Errorf("Query returned NULL, aggregates over no rows can be wrapped in COALESCE")
+	}
+	parsed, err := strconv.ParseFloat(value.String, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Query must return a number, not %q", value.String)
+	}
+	return parsed, nil
+}
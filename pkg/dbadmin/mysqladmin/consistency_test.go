@@ -0,0 +1,29 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestParseValue(t *testing.T) {
+	cases := map[string]float64{
+		"42":        42,
+		"-3":        -3,
+		"1234.5600": 1234.56,
+		"1e3":       1000,
+	}
+	for input, expected := range cases {
+		value, err := parseValue(sql.NullString{String: input, Valid: true})
+		if err != nil || value != expected {
+			t.Errorf("Expected %q to be read as %v: %v %v", input, expected, value, err)
+		}
+	}
+}
+
+func TestParseValueRejectsNonNumbers(t *testing.T) {
+	for _, value := range []sql.NullString{{}, {String: "quay", Valid: true}, {String: "", Valid: true}} {
+		if _, err := parseValue(value); err == nil {
+			t.Errorf("Expected an error for %+v", value)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package mysqladmin
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// ListTables implements DbAdmin
+func (mdba *MySQLDbAdmin) ListTables() ([]string, error) {
+	const listTablesQuery = "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME"
+	rows, err := mdba.query(listTablesQuery, mdba.database)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list tables: %w", wrap(err))
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("Unable to parse table from result: %w", wrap(err))
+		}
+		tables = append(tables, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+	return tables, nil
+}
+
+// GetTableDefinition implements DbAdmin
+func (mdba *MySQLDbAdmin) GetTableDefinition(table string) (string, error) {
+	return mdba.showCreate(schemaObject{name: table})
+}
+
+// ReadTable implements DbAdmin
+func (mdba *MySQLDbAdmin) ReadTable(table string, masks map[string]dbadmin.ColumnMask, batchSize int, each func(columns []string, rows [][]interface{}) error) error {
+	columns, err := mdba.insertableColumns(table)
+	if err != nil {
+		return err
+	}
+	query, err := buildMaskedSelect(mdba.database, table, columns, masks)
+	if err != nil {
+		return err
+	}
+
+	rows, err := mdba.query(query)
+	if err != nil {
+		return fmt.Errorf("Unable to read table (%s): %w", table, wrap(err))
+	}
+	defer rows.Close()
+
+	raw := make([]sql.RawBytes, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range raw {
+		scanArgs[i] = &raw[i]
+	}
+
+	batch := make([][]interface{}, 0, batchSize)
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return fmt.Errorf("Unable to parse row of table (%s): %w", table, wrap(err))
+		}
+
+		// RawBytes are only valid until the next row is read
+		row := make([]interface{}, len(raw))
+		for i, value := range raw {
+			if value != nil {
+				row[i] = append([]byte{}, value...)
+			}
+		}
+		batch = append(batch, row)
+
+		if len(batch) == batchSize {
+			if err := each(columns, batch); err != nil {
+				return err
+			}
+			batch = make([][]interface{}, 0, batchSize)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("Unable to read table (%s): %w", table, wrap(err))
+	}
+	if len(batch) > 0 {
+		return each(columns, batch)
+	}
+	return nil
+}
+
+// insertableColumns returns the columns of the table which aren't generated
+func (mdba *MySQLDbAdmin) insertableColumns(table string) ([]string, error) {
+	const columnsQuery = "SELECT COLUMN_NAME FROM information_schema.COLUMNS " +
+		"WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND EXTRA NOT LIKE '%GENERATED%' ORDER BY ORDINAL_POSITION"
+	rows, err := mdba.query(columnsQuery, mdba.database, table)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list columns of table (%s): %w", table, wrap(err))
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("Unable to parse column from result: %w", wrap(err))
+		}
+		columns = append(columns, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("Table %s does not exist or has no columns which can be copied", table)
+	}
+	return columns, nil
+}
+
+// buildMaskedSelect selects the columns of the table with the masks applied.
+// A mask for a column which doesn't exist is an error, so that a misspelled
+// column isn't copied unmasked.
+func buildMaskedSelect(database, table string, columns []string, masks map[string]dbadmin.ColumnMask) (string, error) {
+	if err := validateQuotedIdentifier(table); err != nil {
+		return "", fmt.Errorf("Unable to read table (%s): %w", table, err)
+	}
+
+	unused := make(map[string]bool, len(masks))
+	for column := range masks {
+		unused[column] = true
+	}
+
+	expressions := make([]string, 0, len(columns))
+	for _, column := range columns {
+		mask := masks[column]
+		delete(unused, column)
+
+		template, ok := maskTemplates[mask]
+		if !ok {
+			return "", fmt.Errorf("Unknown mask (%s) for column %s of table %s", mask, column, table)
+		}
+		quoted := quoteIdentifier(column)
+		if strings.Count(template, "%s") == 2 {
+			expressions = append(expressions, fmt.Sprintf(template, quoted, quoted))
+		} else {
+			expressions = append(expressions, fmt.Sprintf(template, quoted))
+		}
+	}
+	for column := range unused {
+		return "", fmt.Errorf("Table %s has no column %s to mask", table, column)
+	}
+
+	return fmt.Sprintf("SELECT %s FROM %s.%s", strings.Join(expressions, ", "), quoteIdentifier(database), quoteIdentifier(table)), nil
+}
+
+// RestoreTable implements DbAdmin
+func (mdba *MySQLDbAdmin) RestoreTable(table, definition string) error {
+	if err := validateQuotedIdentifier(table); err != nil {
+		return fmt.Errorf("Unable to restore table (%s): %w", table, err)
+	}
+	if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(definition)), "CREATE TABLE") {
+		return fmt.Errorf("Unable to restore table (%s): the definition must be a CREATE TABLE statement", table)
+	}
+
+	const dropTable = "DROP TABLE IF EXISTS %s"
+	mdba.logStatement(dropTable)
+	mdba.logStatement("CREATE TABLE %s")
+	return mdba.withoutForeignKeyChecks(func(conn *sql.Conn) error {
+		ctx := context.Background()
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf(dropTable, quoteIdentifier(mdba.database)+"."+quoteIdentifier(table))); err != nil {
+			return fmt.Errorf("Unable to drop table (%s): %w", table, wrap(err))
+		}
+		// The definition names the table without its database
+		if _, err := conn.ExecContext(ctx, "USE "+quoteIdentifier(mdba.database)); err != nil {
+			return fmt.Errorf("Unable to restore table (%s): %w", table, wrap(err))
+		}
+		if _, err := conn.ExecContext(ctx, definition); err != nil {
+			return fmt.Errorf("Unable to create table (%s): %w", table, wrap(err))
+		}
+		return nil
+	})
+}
+
+// InsertRows implements DbAdmin
+func (mdba *MySQLDbAdmin) InsertRows(table string, columns []string, rows [][]interface{}) error {
+	if err := validateQuotedIdentifier(table); err != nil {
+		return fmt.Errorf("Unable to insert into table (%s): %w", table, err)
+	}
+	if len(columns) == 0 || len(rows) == 0 {
+		return nil
+	}
+
+	batchSize := maxPlaceholders / len(columns)
+	mdba.logStatement("INSERT INTO %s (%s) VALUES %s")
+	return mdba.withoutForeignKeyChecks(func(conn *sql.Conn) error {
+		for start := 0; start < len(rows); start += batchSize {
+			end := start + batchSize
+			if end > len(rows) {
+				end = len(rows)
+			}
+
+			values := make([]interface{}, 0, (end-start)*len(columns))
+			for _, row := range rows[start:end] {
+				if len(row) != len(columns) {
+					return fmt.Errorf("Unable to insert into table (%s): rows have %d values for %d columns", table, len(row), len(columns))
+				}
+				values = append(values, row...)
+			}
+
+			query := buildInsert(mdba.database, table, columns, end-start)
+			if _, err := conn.ExecContext(context.Background(), query, values...); err != nil {
+				return fmt.Errorf("Unable to insert into table (%s): %w", table, wrap(err))
+			}
+		}
+		return nil
+	})
+}
+
+func buildInsert(database, table string, columns []string, rowCount int) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+	}
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	tuples := strings.TrimSuffix(strings.Repeat(tuple+", ", rowCount), ", ")
+
+	return fmt.Sprintf("INSERT INTO %s.%s (%s) VALUES %s",
+		quoteIdentifier(database), quoteIdentifier(table), strings.Join(quoted, ", "), tuples)
+}
+
+// withoutForeignKeyChecks runs apply on a single connection of the primary
+// with foreign key checks disabled, so that tables can be restored in any
+// order. The checks are enabled again before the connection is returned to
+// the pool.
+func (mdba *MySQLDbAdmin) withoutForeignKeyChecks(apply func(conn *sql.Conn) error) error {
+	handle, err := mdba.directHandle()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conn, err := handle.Conn(ctx)
+	if err != nil {
+		return wrap(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SET SESSION foreign_key_checks = 0"); err != nil {
+		return wrap(err)
+	}
+	applyErr := apply(conn)
+	if _, err := conn.ExecContext(ctx, "SET SESSION foreign_key_checks = 1"); err != nil {
+		return wrap(err)
+	}
+	return applyErr
+}
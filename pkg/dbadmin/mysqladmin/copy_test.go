@@ -0,0 +1,48 @@
+package mysqladmin
+
+import (
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestBuildMaskedSelect(t *testing.T) {
+	query, err := buildMaskedSelect("quay", "user", []string{"id", "email", "password", "notes"}, map[string]dbadmin.ColumnMask{
+		"email":    dbadmin.MaskHash,
+		"password": dbadmin.MaskNull,
+		"notes":    dbadmin.MaskRedact,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "SELECT `id` AS `id`, SHA2(`email`, 256) AS `email`, NULL AS `password`, IF(`notes` IS NULL, NULL, 'REDACTED') AS `notes` FROM `quay`.`user`"
+	if query != expected {
+		t.Errorf("Unexpected query: %s", query)
+	}
+
+	if _, err := buildMaskedSelect("quay", "user", []string{"id", "email"}, map[string]dbadmin.ColumnMask{"e_mail": dbadmin.MaskHash}); err == nil {
+		t.Error("Expected an error masking a column which doesn't exist")
+	}
+	if _, err := buildMaskedSelect("quay", "user", []string{"id", "email"}, map[string]dbadmin.ColumnMask{"email": "scramble"}); err == nil {
+		t.Error("Expected an error for an unknown mask")
+	}
+}
+
+func TestBuildInsert(t *testing.T) {
+	query := buildInsert("staging", "user", []string{"id", "email"}, 2)
+	expected := "INSERT INTO `staging`.`user` (`id`, `email`) VALUES (?, ?), (?, ?)"
+	if query != expected {
+		t.Errorf("Unexpected query: %s", query)
+	}
+}
+
+func TestRestoreTableRejectsOtherStatements(t *testing.T) {
+	admin, _ := newFakeAdmin(nil)
+
+	for _, definition := range []string{"DROP DATABASE quay", "  create view `user` AS SELECT 1", ""} {
+		if err := admin.RestoreTable("user", definition); err == nil {
+			t.Errorf("Expected an error restoring %q", definition)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Networks which the driver dials itself, and so can't be replaced
+const (
+	networkTCP  = "tcp"
+	networkUnix = "unix"
+)
+
+var (
+	dialersLock sync.RWMutex
+
+	// dialers contains the names which may be used as ConnectionSpec.Dialer.
+	// The tcp4 and tcp6 networks are dialed by the standard library, and
+	// restrict host name resolution to that address family.
+	dialers = map[string]bool{
+		"tcp4": true,
+		"tcp6": true,
+	}
+)
+
+// RegisterDialer makes a custom dial function, such as one which connects
+// through a cloud provider's SQL proxy library, available to connection
+// specs under the supplied name. The dial function receives the host:port
+// address of the connection.
+func RegisterDialer(name string, dial func(addr string) (net.Conn, error)) error {
+	if name == "" || name == networkTCP || name == networkUnix || strings.ContainsAny(name, "()/@:") {
+		return fmt.Errorf("Invalid dialer name: %s", name)
+	}
+
+	dialersLock.Lock()
+	defer dialersLock.Unlock()
+
+	dialers[name] = true
+	mysql.RegisterDial(name, dial)
+	return nil
+}
+
+func dialerRegistered(name string) bool {
+	dialersLock.RLock()
+	defer dialersLock.RUnlock()
+	return dialers[name]
+}
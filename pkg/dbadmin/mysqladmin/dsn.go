@@ -0,0 +1,239 @@
+package mysqladmin
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+const defaultPort = 3306
+
+// mysqlTLSValues maps the engine independent TLS modes to the values of the
+// driver's tls parameter
+var mysqlTLSValues = map[dbadmin.TLSMode]string{
+	"":                        "false",
+	dbadmin.TLSModeDisabled:   "false",
+	dbadmin.TLSModePreferred:  "preferred",
+	dbadmin.TLSModeRequired:   "true",
+	dbadmin.TLSModeSkipVerify: "skip-verify",
+}
+
+// DSNBuilder builds go-sql-driver/mysql DSNs
+type DSNBuilder struct{}
+
+// BuildDSN implements dbadmin.DSNBuilder
+func (DSNBuilder) BuildDSN(spec dbadmin.ConnectionSpec) (string, error) {
+	if spec.Database == "" {
+		return "", errors.New("Must provide specific database name for the connection")
+	}
+	if spec.Username == "" || spec.Password == "" {
+		return "", errors.New("Must provide username and password for the connection")
+	}
+
+	network, addr, err := mysqlAddress(spec)
+	if err != nil {
+		return "", err
+	}
+
+	tlsValue, ok := mysqlTLSValues[spec.TLS]
+	if !ok {
+		return "", fmt.Errorf("Unknown TLS mode: %s", spec.TLS)
+	}
+
+	if err := validateMySQLParams(spec); err != nil {
+		return "", err
+	}
+
+	paramNames := make([]string, 0, len(spec.Params))
+	for name := range spec.Params {
+		paramNames = append(paramNames, name)
+	}
+	sort.Strings(paramNames)
+
+	params := []string{"tls=" + tlsValue}
+
+	if spec.ServerPublicKey != "" {
+		keyName, err := registerServerPublicKey(spec.ServerPublicKey)
+		if err != nil {
+			return "", err
+		}
+		params = append(params, "serverPubKey="+keyName)
+	} else if !secureTransport(spec) && !spec.AllowPublicKeyRetrieval {
+		// MySQL 8 users authenticated with caching_sha2_password can only send
+		// their password over an unencrypted connection by encrypting it with
+		// the server's public key, which the driver will otherwise silently
+		// fetch from the server.
+		return "", errors.New("Connections which don't require TLS must provide the server public key or allow public key retrieval")
+	}
+	for _, name := range paramNames {
+		params = append(params, url.QueryEscape(name)+"="+url.QueryEscape(spec.Params[name]))
+	}
+
+	dsn := fmt.Sprintf("%s:%s@%s(%s)/%s?%s", spec.Username, spec.Password, network, addr, spec.Database, strings.Join(params, "&"))
+
+	// Round trip through the driver so that values it rejects, or which
+	// contain characters that change how the DSN is split up, are caught
+	// here rather than when connecting.
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("Invalid connection parameters: %w", redact.Error(err, dsn, spec.Password))
+	}
+	if parsed.User != spec.Username || parsed.Passwd != spec.Password || parsed.Net != network || parsed.Addr != addr || parsed.DBName != spec.Database {
+		return "", errors.New("Connection username, address, or database name contains characters which can't be used in a DSN")
+	}
+
+	return dsn, nil
+}
+
+// DSNParser finds the server of go-sql-driver/mysql DSNs
+type DSNParser struct{}
+
+// DSNHost implements dbadmin.DSNParser
+func (DSNParser) DSNHost(dsn string) (string, error) {
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("Unable to parse connection dsn: %w", redact.Error(err, dsn, dsnPassword(dsn)))
+	}
+	if parsed.Net == networkUnix {
+		return parsed.Addr, nil
+	}
+
+	host, _, err := net.SplitHostPort(parsed.Addr)
+	if err != nil {
+		// The driver adds the default port to addresses without one
+		return "", fmt.Errorf("Invalid connection address: %s", parsed.Addr)
+	}
+	return host, nil
+}
+
+// mysqlAddress returns the driver network and address for either the host
+// and port or the Unix socket of the connection.
+func mysqlAddress(spec dbadmin.ConnectionSpec) (string, string, error) {
+	if len(spec.Hosts) > 0 {
+		return "", "", errors.New("Connections with several hosts must select one of them before connecting")
+	}
+	if spec.Socket != "" {
+		if spec.Host != "" || spec.Port != 0 {
+			return "", "", errors.New("Connections through a socket can't also provide a host or port")
+		}
+		if spec.Dialer != "" {
+			return "", "", errors.New("Connections through a socket can't use a custom dialer")
+		}
+		if spec.TLS != "" && spec.TLS != dbadmin.TLSModeDisabled {
+			return "", "", errors.New("Connections through a socket are local and must not use TLS")
+		}
+		if !path.IsAbs(spec.Socket) || strings.ContainsAny(spec.Socket, "()") {
+			return "", "", fmt.Errorf("Invalid socket path for the connection: %s", spec.Socket)
+		}
+		return networkUnix, spec.Socket, nil
+	}
+
+	if spec.Host == "" {
+		return "", "", errors.New("Must provide a host or socket for the connection")
+	}
+
+	// IPv6 literals are accepted with or without the brackets used in URLs
+	host := spec.Host
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	if strings.Contains(host, ":") {
+		withoutZone := strings.SplitN(host, "%", 2)[0]
+		if net.ParseIP(withoutZone) == nil {
+			return "", "", fmt.Errorf("Connection host must be a host name or IP address without a port: %s", spec.Host)
+		}
+	} else if strings.ContainsAny(host, "[]/()@?%") {
+		return "", "", fmt.Errorf("Invalid connection host: %s", spec.Host)
+	}
+
+	port := spec.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	if port < 0 || port > 65535 {
+		return "", "", fmt.Errorf("Invalid port for the connection: %d", port)
+	}
+
+	network := networkTCP
+	if spec.Dialer != "" {
+		if !dialerRegistered(spec.Dialer) {
+			return "", "", fmt.Errorf("Unknown dialer: %s", spec.Dialer)
+		}
+		network = spec.Dialer
+	}
+
+	return network, net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+// validateMySQLParams rejects parameters which are set through typed fields
+// and parameter combinations that the driver silently misinterprets.
+func validateMySQLParams(spec dbadmin.ConnectionSpec) error {
+	for _, name := range []string{"tls", "user", "password", "dbname", "serverPubKey"} {
+		if _, ok := spec.Params[name]; ok {
+			return fmt.Errorf("Connection parameter %s must be set through its dedicated field", name)
+		}
+	}
+
+	if _, ok := spec.Params["loc"]; ok && spec.Params["parseTime"] != "true" {
+		return errors.New("Connection parameter loc has no effect unless parseTime is true")
+	}
+
+	if spec.Params["allowCleartextPasswords"] == "true" && !secureTransport(spec) {
+		return errors.New("Connection parameter allowCleartextPasswords requires TLS to be required or a socket")
+	}
+
+	return nil
+}
+
+// tlsGuaranteed returns true if the connection will never fall back to being
+// unencrypted
+func tlsGuaranteed(mode dbadmin.TLSMode) bool {
+	return mode == dbadmin.TLSModeRequired || mode == dbadmin.TLSModeSkipVerify
+}
+
+// secureTransport returns true if passwords can't be observed on the way to
+// the server, which the driver also assumes for Unix sockets
+func secureTransport(spec dbadmin.ConnectionSpec) bool {
+	return tlsGuaranteed(spec.TLS) || spec.Socket != ""
+}
+
+// registerServerPublicKey parses a PEM encoded RSA public key and registers it
+// with the driver under a name derived from its contents, which can be passed
+// as the serverPubKey parameter.
+func registerServerPublicKey(pemKey string) (string, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return "", errors.New("Server public key is not PEM encoded")
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("Unable to parse server public key: %w", err)
+	}
+
+	rsaKey, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return "", errors.New("Server public key must be an RSA key")
+	}
+
+	digest := sha256.Sum256(block.Bytes)
+	name := "dba-operator-" + hex.EncodeToString(digest[:8])
+	mysql.RegisterServerPubKey(name, rsaKey)
+
+	return name, nil
+}
@@ -0,0 +1,291 @@
+package mysqladmin
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func validSpec() dbadmin.ConnectionSpec {
+	return dbadmin.ConnectionSpec{
+		Host:     "mysql.example.com",
+		Database: "quay",
+		Username: "admin",
+		Password: "p@ss:w/rd",
+		TLS:      dbadmin.TLSModeRequired,
+		Params: map[string]string{
+			"parseTime": "true",
+			"loc":       "America/New_York",
+		},
+	}
+}
+
+func TestBuildDSNRoundTrips(t *testing.T) {
+	spec := validSpec()
+	dsn, err := DSNBuilder{}.BuildDSN(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.User != spec.Username || parsed.Passwd != spec.Password || parsed.DBName != spec.Database {
+		t.Errorf("credentials or database did not survive the round trip: %+v", parsed)
+	}
+	if parsed.Addr != "mysql.example.com:3306" {
+		t.Errorf("expected the default port, got %s", parsed.Addr)
+	}
+	if !parsed.ParseTime || parsed.Loc.String() != "America/New_York" {
+		t.Errorf("expected parseTime and loc to be applied, got %v %v", parsed.ParseTime, parsed.Loc)
+	}
+	if parsed.TLSConfig != "true" {
+		t.Errorf("expected TLS to be required, got %s", parsed.TLSConfig)
+	}
+}
+
+func TestBuildDSNRejectsInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(*dbadmin.ConnectionSpec)
+	}{
+		{"missing host", func(s *dbadmin.ConnectionSpec) { s.Host = "" }},
+		{"host with a path", func(s *dbadmin.ConnectionSpec) { s.Host = "mysql.example.com/quay" }},
+		{"host with parentheses", func(s *dbadmin.ConnectionSpec) { s.Host = "tcp(mysql.example.com)" }},
+		{"invalid ipv6", func(s *dbadmin.ConnectionSpec) { s.Host = "fe80::zz" }},
+		{"host and port in host", func(s *dbadmin.ConnectionSpec) { s.Host = "mysql.example.com:3306" }},
+		{"host and socket", func(s *dbadmin.ConnectionSpec) { s.Socket = "/cloudsql/mysql.sock" }},
+		{"unknown dialer", func(s *dbadmin.ConnectionSpec) { s.Dialer = "carrier-pigeon" }},
+		{"unselected hosts", func(s *dbadmin.ConnectionSpec) {
+			s.Host = ""
+			s.Hosts = []dbadmin.HostPort{{Host: "mysql-0.example.com"}, {Host: "mysql-1.example.com"}}
+		}},
+		{"missing database", func(s *dbadmin.ConnectionSpec) { s.Database = "" }},
+		{"missing password", func(s *dbadmin.ConnectionSpec) { s.Password = "" }},
+		{"port out of range", func(s *dbadmin.ConnectionSpec) { s.Port = 70000 }},
+		{"unknown tls mode", func(s *dbadmin.ConnectionSpec) { s.TLS = "sometimes" }},
+		{"tls as a param", func(s *dbadmin.ConnectionSpec) { s.Params["tls"] = "true" }},
+		{"loc without parseTime", func(s *dbadmin.ConnectionSpec) { delete(s.Params, "parseTime") }},
+		{"invalid parseTime", func(s *dbadmin.ConnectionSpec) { s.Params["parseTime"] = "yes" }},
+		{"cleartext without tls", func(s *dbadmin.ConnectionSpec) {
+			s.TLS = dbadmin.TLSModePreferred
+			s.Params["allowCleartextPasswords"] = "true"
+		}},
+		{"username with a colon", func(s *dbadmin.ConnectionSpec) { s.Username = "ad:min" }},
+		{"database with a slash", func(s *dbadmin.ConnectionSpec) { s.Database = "qu/ay" }},
+	}
+
+	for _, tc := range testCases {
+		spec := validSpec()
+		tc.modify(&spec)
+
+		_, err := DSNBuilder{}.BuildDSN(spec)
+		if err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+			continue
+		}
+		if spec.Password != "" && strings.Contains(err.Error(), spec.Password) {
+			t.Errorf("%s: error leaks the password: %v", tc.name, err)
+		}
+	}
+}
+
+func TestBuildDSNWithoutTLS(t *testing.T) {
+	spec := validSpec()
+	spec.TLS = dbadmin.TLSModeDisabled
+
+	if _, err := (DSNBuilder{}).BuildDSN(spec); err == nil {
+		t.Errorf("expected public key retrieval to require permission without TLS")
+	}
+
+	spec.AllowPublicKeyRetrieval = true
+	if _, err := (DSNBuilder{}).BuildDSN(spec); err != nil {
+		t.Errorf("expected public key retrieval to be permitted: %v", err)
+	}
+}
+
+func TestBuildDSNWithServerPublicKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := validSpec()
+	spec.TLS = dbadmin.TLSModeDisabled
+	spec.ServerPublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	dsn, err := DSNBuilder{}.BuildDSN(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dsn, "serverPubKey=dba-operator-") {
+		t.Errorf("expected the registered key to be referenced: %s", dsn)
+	}
+
+	spec.ServerPublicKey = "not a key"
+	if _, err := (DSNBuilder{}).BuildDSN(spec); err == nil {
+		t.Errorf("expected an invalid public key to be rejected")
+	}
+}
+
+func TestBuildDSNWithIPv6(t *testing.T) {
+	testCases := []struct {
+		host     string
+		expected string
+	}{
+		{"::1", "[::1]:3306"},
+		{"[2001:db8::10]", "[2001:db8::10]:3306"},
+		{"fe80::1%eth0", "[fe80::1%eth0]:3306"},
+		{"10.0.0.5", "10.0.0.5:3306"},
+	}
+
+	for _, tc := range testCases {
+		spec := validSpec()
+		spec.Host = tc.host
+
+		dsn, err := DSNBuilder{}.BuildDSN(spec)
+		if err != nil {
+			t.Errorf("%s: %v", tc.host, err)
+			continue
+		}
+
+		parsed, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Net != "tcp" || parsed.Addr != tc.expected {
+			t.Errorf("%s: expected tcp(%s), got %s(%s)", tc.host, tc.expected, parsed.Net, parsed.Addr)
+		}
+	}
+}
+
+func socketSpec() dbadmin.ConnectionSpec {
+	spec := validSpec()
+	spec.Host = ""
+	spec.TLS = dbadmin.TLSModeDisabled
+	spec.Socket = "/cloudsql/project:region:instance"
+	return spec
+}
+
+func TestBuildDSNWithSocket(t *testing.T) {
+	spec := socketSpec()
+	spec.Params["allowCleartextPasswords"] = "true"
+
+	// A socket is local, so it's permitted to carry the password without a
+	// public key
+	dsn, err := DSNBuilder{}.BuildDSN(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Net != "unix" || parsed.Addr != spec.Socket {
+		t.Errorf("expected unix(%s), got %s(%s)", spec.Socket, parsed.Net, parsed.Addr)
+	}
+	if parsed.User != spec.Username || parsed.Passwd != spec.Password {
+		t.Errorf("credentials did not survive the round trip: %+v", parsed)
+	}
+}
+
+func TestBuildDSNRejectsInvalidSocket(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(*dbadmin.ConnectionSpec)
+	}{
+		{"relative path", func(s *dbadmin.ConnectionSpec) { s.Socket = "mysql.sock" }},
+		{"path with parentheses", func(s *dbadmin.ConnectionSpec) { s.Socket = "/run/mysql(1).sock" }},
+		{"with a port", func(s *dbadmin.ConnectionSpec) { s.Port = 3306 }},
+		{"with tls", func(s *dbadmin.ConnectionSpec) { s.TLS = dbadmin.TLSModeRequired }},
+		{"with a dialer", func(s *dbadmin.ConnectionSpec) { s.Dialer = "tcp6" }},
+	}
+
+	for _, tc := range testCases {
+		spec := socketSpec()
+		tc.modify(&spec)
+
+		if _, err := (DSNBuilder{}).BuildDSN(spec); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
+
+func TestBuildDSNWithDialer(t *testing.T) {
+	dial := func(addr string) (net.Conn, error) {
+		return nil, errors.New("not implemented")
+	}
+
+	for _, name := range []string{"", "tcp", "unix", "bad(name)"} {
+		if err := RegisterDialer(name, dial); err == nil {
+			t.Errorf("expected dialer name %q to be rejected", name)
+		}
+	}
+	if err := RegisterDialer("test-proxy", dial); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dialer := range []string{"tcp6", "test-proxy"} {
+		spec := validSpec()
+		spec.Dialer = dialer
+
+		dsn, err := DSNBuilder{}.BuildDSN(spec)
+		if err != nil {
+			t.Errorf("%s: %v", dialer, err)
+			continue
+		}
+
+		parsed, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Net != dialer || parsed.Addr != "mysql.example.com:3306" {
+			t.Errorf("%s: unexpected address %s(%s)", dialer, parsed.Net, parsed.Addr)
+		}
+	}
+}
+
+func TestDSNHost(t *testing.T) {
+	testCases := []struct {
+		dsn  string
+		host string
+	}{
+		{"admin:secret@tcp(db-1.prod.internal:3306)/quay", "db-1.prod.internal"},
+		{"admin:secret@tcp([fe80::1]:3306)/quay", "fe80::1"},
+		{"admin:secret@unix(/cloudsql/project:region:db)/quay", "/cloudsql/project:region:db"},
+		{"admin:secret@tcp/quay", "127.0.0.1"},
+	}
+	for _, tc := range testCases {
+		host, err := DSNParser{}.DSNHost(tc.dsn)
+		if err != nil {
+			t.Errorf("Unable to find the host of %s: %v", tc.dsn, err)
+			continue
+		}
+		if host != tc.host {
+			t.Errorf("Expected host %s for %s, got %s", tc.host, tc.dsn, host)
+		}
+	}
+
+	_, err := DSNParser{}.DSNHost("admin:s3cret@tcp(db-1.prod.internal:3306)quay")
+	if err == nil {
+		t.Fatal("Expected an error for an invalid DSN")
+	}
+	if strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("Error leaks the password: %v", err)
+	}
+}
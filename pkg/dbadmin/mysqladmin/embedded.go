@@ -0,0 +1,148 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/embedded"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// ApplyMigrationFiles implements DbAdmin. MySQL commits implicitly around
+// every DDL statement, so a file which fails part way through may leave the
+// statements before the failure applied; such files are not retried in
+// place, the next reconcile reports the failure again until the file, or the
+// schema, has been fixed.
+func (mdba *MySQLDbAdmin) ApplyMigrationFiles(version string, files []dbadmin.MigrationFile) error {
+	if _, ok := mdba.engine.(*embedded.MigrationEngine); !ok {
+		return fmt.Errorf("Embedded migrations require the embedded migration engine")
+	}
+	if version == "" || len(files) == 0 {
+		return fmt.Errorf("Embedded migrations require a version and at least one file")
+	}
+
+	if err := mdba.createEmbeddedTables(); err != nil {
+		return err
+	}
+
+	applied, err := mdba.appliedMigrationFiles(version)
+	if err != nil {
+		return fmt.Errorf("Unable to read the applied migration files: %w", err)
+	}
+
+	handle, err := mdba.writeHandle()
+	if err != nil {
+		return fmt.Errorf("Unable to connect to the primary: %w", err)
+	}
+
+	for _, file := range files {
+		if checksum, ok := applied[file.Name]; ok {
+			if checksum != file.Checksum {
+				return fmt.Errorf("Migration file %s has changed since it was applied for version %s", file.Name, version)
+			}
+			continue
+		}
+
+		mdba.log.Info("Applying migration file", "version", version, "file", file.Name, "statements", len(file.Statements))
+		if err := mdba.applyMigrationFile(handle, version, file); err != nil {
+			return fmt.Errorf("Unable to apply migration file %s: %w", file.Name, err)
+		}
+	}
+
+	if err := mdba.recordEmbeddedVersion(handle, version); err != nil {
+		return fmt.Errorf("Unable to record schema version %s: %w", version, err)
+	}
+	return nil
+}
+
+// writeHandle returns the connection which changes are sent to
+func (mdba *MySQLDbAdmin) writeHandle() (*sql.DB, xerrors.EnhancedError) {
+	if mdba.groupReplication {
+		return mdba.primaryHandle()
+	}
+	return mdba.handle, nil
+}
+
+func (mdba *MySQLDbAdmin) createEmbeddedTables() error {
+	const createVersionTable = "CREATE TABLE IF NOT EXISTS %s.%s (" +
+		"version_num VARCHAR(255) NOT NULL PRIMARY KEY)"
+	if err := mdba.exec(createVersionTable, identifier(mdba.database), identifier(embedded.VersionTable)); err != nil {
+		return fmt.Errorf("Unable to create table for the schema version: %w", err)
+	}
+
+	const createFilesTable = "CREATE TABLE IF NOT EXISTS %s.%s (" +
+		"version VARCHAR(255) NOT NULL, " +
+		"file VARCHAR(255) NOT NULL, " +
+		"checksum CHAR(64) NOT NULL, " +
+		"applied_at DATETIME NOT NULL, " +
+		"PRIMARY KEY (version, file))"
+	if err := mdba.exec(createFilesTable, identifier(mdba.database), identifier(embedded.FilesTable)); err != nil {
+		return fmt.Errorf("Unable to create table for the applied migration files: %w", err)
+	}
+	return nil
+}
+
+// appliedMigrationFiles returns the checksum of each file which has been
+// applied for the version
+func (mdba *MySQLDbAdmin) appliedMigrationFiles(version string) (map[string]string, error) {
+	query := fmt.Sprintf("SELECT file, checksum FROM %s.%s WHERE version = ?",
+		quoteIdentifier(mdba.database), quoteIdentifier(embedded.FilesTable))
+	rows, err := mdba.query(query, version)
+	if err != nil {
+		return nil, wrap(err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]string)
+	for rows.Next() {
+		var file, checksum string
+		if err := rows.Scan(&file, &checksum); err != nil {
+			return nil, wrap(err)
+		}
+		applied[file] = checksum
+	}
+	return applied, wrap(rows.Err())
+}
+
+// applyMigrationFile runs the statements of the file and records it in the
+// same transaction
+func (mdba *MySQLDbAdmin) applyMigrationFile(handle *sql.DB, version string, file dbadmin.MigrationFile) xerrors.EnhancedError {
+	tx, err := handle.Begin()
+	if err != nil {
+		return wrap(err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range file.Statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return wrap(err)
+		}
+	}
+
+	record := fmt.Sprintf("INSERT INTO %s.%s (version, file, checksum, applied_at) VALUES (?, ?, ?, UTC_TIMESTAMP())",
+		quoteIdentifier(mdba.database), quoteIdentifier(embedded.FilesTable))
+	if _, err := tx.Exec(record, version, file.Name, file.Checksum); err != nil {
+		return wrap(err)
+	}
+	return wrap(tx.Commit())
+}
+
+// recordEmbeddedVersion replaces the schema version once every file of the
+// version has been applied
+func (mdba *MySQLDbAdmin) recordEmbeddedVersion(handle *sql.DB, version string) xerrors.EnhancedError {
+	tx, err := handle.Begin()
+	if err != nil {
+		return wrap(err)
+	}
+	defer tx.Rollback()
+
+	table := quoteIdentifier(mdba.database) + "." + quoteIdentifier(embedded.VersionTable)
+	if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+		return wrap(err)
+	}
+	if _, err := tx.Exec("INSERT INTO "+table+" (version_num) VALUES (?)", version); err != nil {
+		return wrap(err)
+	}
+	return wrap(tx.Commit())
+}
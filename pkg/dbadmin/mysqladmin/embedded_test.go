@@ -0,0 +1,36 @@
+package mysqladmin
+
+import (
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/alembic"
+	"github.com/app-sre/dba-operator/pkg/dbadmin/embedded"
+)
+
+func TestApplyMigrationFilesRequiresEmbeddedEngine(t *testing.T) {
+	files := []dbadmin.MigrationFile{{Name: "001.sql", Statements: []string{"CREATE TABLE t (id INT)"}}}
+
+	for name, engine := range map[string]dbadmin.MigrationEngine{
+		"none":    nil,
+		"alembic": alembic.CreateMigrationEngine(),
+	} {
+		admin, fake := newFakeAdmin(nil)
+		admin.engine = engine
+		if err := admin.ApplyMigrationFiles("v1", files); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if len(fake.statements) != 0 {
+			t.Errorf("%s: unexpected statements: %v", name, fake.statements)
+		}
+	}
+
+	admin, fake := newFakeAdmin(nil)
+	admin.engine = embedded.CreateMigrationEngine()
+	if err := admin.ApplyMigrationFiles("v1", nil); err == nil {
+		t.Errorf("Expected an error applying no files")
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
@@ -0,0 +1,61 @@
+package mysqladmin
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+// endpointCheckTimeout bounds how long an endpoint may take to answer a
+// health check
+const endpointCheckTimeout = 10 * time.Second
+
+// EndpointChecker checks MySQL servers, such as read replicas, which the
+// operator doesn't administer itself
+type EndpointChecker struct{}
+
+// openEndpoint opens a connection pool for a ConnectionSpec, with a connect
+// timeout unless the spec sets one. The DSN is returned for redacting errors.
+func openEndpoint(spec dbadmin.ConnectionSpec) (*sql.DB, string, error) {
+	params := make(map[string]string, len(spec.Params)+1)
+	for name, value := range spec.Params {
+		params[name] = value
+	}
+	if _, ok := params["timeout"]; !ok {
+		params["timeout"] = endpointCheckTimeout.String()
+	}
+	spec.Params = params
+
+	dsn, err := DSNBuilder{}.BuildDSN(spec)
+	if err != nil {
+		return nil, "", err
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, "", fmt.Errorf("Unable to open connection to db: %w", redact.Error(wrap(err), dsn, spec.Password))
+	}
+	return db, dsn, nil
+}
+
+// CheckEndpoint implements dbadmin.EndpointChecker
+func (EndpointChecker) CheckEndpoint(spec dbadmin.ConnectionSpec) error {
+	db, dsn, err := openEndpoint(spec)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), endpointCheckTimeout)
+	defer cancel()
+
+	var one int
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("Unable to query endpoint: %w", redact.Error(wrap(err), dsn, spec.Password))
+	}
+	return nil
+}
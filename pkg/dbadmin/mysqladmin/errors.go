@@ -2,9 +2,11 @@ package mysqladmin
 
 import (
 	"errors"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 
+	"github.com/app-sre/dba-operator/pkg/redact"
 	"github.com/app-sre/dba-operator/pkg/xerrors"
 )
 
@@ -17,6 +19,7 @@ var retryableErrors = map[uint16]interface{}{
 	1020: nil, // ER_CHECKREAD
 	1036: nil, // ER_OPEN_AS_READONLY
 	1040: nil, // ER_CON_COUNT_ERROR
+	1047: nil, // ER_UNKNOWN_COM_ERROR, returned by Galera nodes which aren't synced
 	1043: nil, // ER_HANDSHAKE_ERROR
 	1053: nil, // ER_SERVER_SHUTDOWN
 	1105: nil, // ER_UNKNOWN_ERROR
@@ -29,6 +32,7 @@ var retryableErrors = map[uint16]interface{}{
 	1203: nil, // ER_TOO_MANY_USER_CONNECTIONS
 	1205: nil, // ER_LOCK_WAIT_TIMEOUT
 	1206: nil, // ER_LOCK_TABLE_FULL
+	1213: nil, // ER_LOCK_DEADLOCK, also returned for Galera certification failures
 	1218: nil, // ER_CONNECT_TO_MASTER
 	1220: nil, // ER_ERROR_WHEN_EXECUTING_COMMAND
 	1290: nil, // ER_OPTION_PREVENTS_STATEMENT
@@ -53,6 +57,37 @@ func wrap(err error) xerrors.EnhancedError {
 	return nil
 }
 
+// retryDelays are the retry hints for temporary errors which clear within
+// seconds, the reconciler's own backoff applies to the others
+var retryDelays = map[uint16]time.Duration{
+	1205: 10 * time.Second, // ER_LOCK_WAIT_TIMEOUT
+	1213: 5 * time.Second,  // ER_LOCK_DEADLOCK
+	3572: 5 * time.Second,  // ER_LOCK_NOWAIT
+}
+
+// Error implements error, driver errors sometimes echo connection information
+// so the message is always redacted
+func (err wrappedMySQLError) Error() string {
+	return redact.String(err.error.Error())
+}
+
+// Unwrap allows errors.As to reach the underlying driver error
+func (err wrappedMySQLError) Unwrap() error {
+	return err.error
+}
+
+// RetryAfter implements the RetryHinter interface
+func (err wrappedMySQLError) RetryAfter() time.Duration {
+	if resumingMessage(err.error) {
+		return resumeRetryDelay
+	}
+	var mysqle *mysql.MySQLError
+	if errors.As(err.error, &mysqle) {
+		return retryDelays[mysqle.Number]
+	}
+	return 0
+}
+
 // Temporary implements the EnhancedError interface
 func (err wrappedMySQLError) Temporary() bool {
 	switch err.error {
@@ -67,5 +102,5 @@ func (err wrappedMySQLError) Temporary() bool {
 		}
 	}
 
-	return false
+	return resumingMessage(err.error)
 }
@@ -0,0 +1,95 @@
+package mysqladmin
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/redact/redacttest"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+const seededPassword = "s33ded-Pa55word"
+
+func TestWrappedErrorsAreRedacted(t *testing.T) {
+	driverErr := fmt.Errorf("dial failed for admin:%s@tcp(db.internal:3306)/quay", seededPassword)
+
+	redacttest.AssertNoSecrets(t, wrap(driverErr).Error(), seededPassword)
+}
+
+func TestCreateMySQLAdminErrorsAreRedacted(t *testing.T) {
+	dsns := []string{
+		"admin:" + seededPassword + "@tcp(db.internal:3306)/",
+		"admin:" + seededPassword + "@tcp(db.internal:3306)/quay?timeout=" + seededPassword,
+		"admin:" + seededPassword + "@tcp(db.internal:3306)/quay?timeout=bogus",
+	}
+
+	for _, dsn := range dsns {
+		recorder := redacttest.NewRecordingLogger()
+		_, err := CreateMySQLAdmin(dsn, nil, recorder)
+		if err == nil {
+			t.Fatalf("Expected an error for dsn")
+		}
+		redacttest.AssertNoSecrets(t, err.Error(), seededPassword)
+		redacttest.AssertNoSecrets(t, recorder.Output(), seededPassword)
+	}
+}
+
+func TestWrappedErrorsRemainClassified(t *testing.T) {
+	wrapped := fmt.Errorf("outer: %w", wrap(&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}))
+
+	var enhanced xerrors.EnhancedError
+	if !errors.As(wrapped, &enhanced) || !enhanced.Temporary() {
+		t.Errorf("Lock wait timeout should be temporary")
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(wrapped, &mysqlErr) || mysqlErr.Number != 1205 {
+		t.Errorf("Driver error should still be reachable")
+	}
+}
+
+func TestGaleraErrorsAreTemporary(t *testing.T) {
+	certificationFailure := wrap(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"})
+	if !certificationFailure.Temporary() {
+		t.Errorf("Certification failures should be temporary")
+	}
+
+	notSynced := wrap(&mysql.MySQLError{Number: 1047, Message: "WSREP has not yet prepared node for application use"})
+	if !notSynced.Temporary() {
+		t.Errorf("Unsynced nodes should be temporary")
+	}
+}
+
+func TestGaleraReadiness(t *testing.T) {
+	if err := galeraReadiness(map[string]string{"wsrep_ready": "ON", "wsrep_cluster_status": "Primary"}); err != nil {
+		t.Errorf("Synced primary node should be ready: %v", err)
+	}
+
+	for _, status := range []map[string]string{
+		{"wsrep_ready": "OFF", "wsrep_cluster_status": "Primary"},
+		{"wsrep_ready": "ON", "wsrep_cluster_status": "non-Primary"},
+		{},
+	} {
+		err := galeraReadiness(status)
+		if err == nil || !err.Temporary() {
+			t.Errorf("Expected a temporary error for %v", status)
+		}
+	}
+}
+
+func TestRetryHints(t *testing.T) {
+	deadlock := redact.Error(wrap(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"}), seededPassword)
+	if delay := xerrors.Backoff(deadlock, 3, time.Minute, time.Hour); delay != 5*time.Second {
+		t.Errorf("Deadlocks should be retried within seconds, got %s", delay)
+	}
+
+	unhinted := wrap(&mysql.MySQLError{Number: 1040, Message: "Too many connections"})
+	if delay := xerrors.Backoff(unhinted, 1, time.Minute, time.Hour); delay != 2*time.Minute {
+		t.Errorf("Errors without a hint should back off, got %s", delay)
+	}
+}
@@ -0,0 +1,134 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// eventComment marks the events which the operator manages
+const eventComment = "Managed by dba-operator"
+
+// WriteScheduledStatement implements DbAdmin
+func (mdba *MySQLDbAdmin) WriteScheduledStatement(statement dbadmin.ScheduledStatement) error {
+	if mdba.flavor() == dbadmin.FlavorTiDB {
+		return fmt.Errorf("Scheduled statements are not supported by %s", mdba.flavor())
+	}
+
+	_, found, err := mdba.eventDefinition(statement.Name)
+	if err != nil {
+		return err
+	}
+
+	// ALTER EVENT replaces the whole definition at once, so an existing
+	// event is never missing while it is being changed
+	verb := "CREATE"
+	if found {
+		verb = "ALTER"
+	}
+	template, rendered, err := eventStatement(verb, mdba.database, statement)
+	if err != nil {
+		return err
+	}
+
+	// Event definitions can't be prepared, so they are sent directly. The
+	// names are quoted and the statements come from the ManagedDatabase, the
+	// same as the migrations do.
+	handle, err := mdba.directHandle()
+	if err != nil {
+		return err
+	}
+	mdba.logStatement(template)
+	if _, err := handle.Exec(rendered); err != nil {
+		return fmt.Errorf("Unable to write scheduled statement (%s): %w", statement.Name, wrap(err))
+	}
+
+	mdba.warnIfSchedulerDisabled()
+	return nil
+}
+
+// eventStatement returns the template which is logged for the event, and the
+// statement which defines it
+func eventStatement(verb, database string, statement dbadmin.ScheduledStatement) (string, string, error) {
+	if statement.Name == "" || statement.Statement == "" {
+		return "", "", fmt.Errorf("Scheduled statements require a name and a statement")
+	}
+	if err := validateQuotedIdentifier(statement.Name); err != nil {
+		return "", "", err
+	}
+	if statement.Interval < time.Second || statement.Interval%time.Second != 0 {
+		return "", "", fmt.Errorf("Interval (%s) of scheduled statement %s must be a whole number of seconds", statement.Interval, statement.Name)
+	}
+
+	status := "ENABLE"
+	if statement.Disabled {
+		status = "DISABLE"
+	}
+
+	template := verb + " EVENT %s ON SCHEDULE EVERY %s SECOND ON COMPLETION PRESERVE " + status + " COMMENT '" + eventComment + "' DO\n"
+	rendered := fmt.Sprintf(
+		template,
+		quoteIdentifier(database)+"."+quoteIdentifier(statement.Name),
+		strconv.FormatInt(int64(statement.Interval/time.Second), 10),
+	)
+	return template, rendered + statement.Statement, nil
+}
+
+// eventDefinition returns the definition and schedule of the event as stored
+// by the server, in a form which changes whenever either of them does
+func (mdba *MySQLDbAdmin) eventDefinition(name string) (string, bool, error) {
+	const eventQuery = "SELECT EVENT_DEFINITION, INTERVAL_VALUE, INTERVAL_FIELD, STATUS FROM information_schema.EVENTS WHERE EVENT_SCHEMA = ? AND EVENT_NAME = ?"
+
+	var definition, interval, field, status sql.NullString
+	err := mdba.queryRow(eventQuery, mdba.database, name).Scan(&definition, &interval, &field, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("Unable to read scheduled statement (%s): %w", name, wrap(err))
+	}
+	return fmt.Sprintf("EVERY %s %s %s DO %s", interval.String, field.String, status.String, definition.String), true, nil
+}
+
+// warnIfSchedulerDisabled logs when the server won't run the events that are
+// written to it
+func (mdba *MySQLDbAdmin) warnIfSchedulerDisabled() {
+	var scheduler string
+	if err := mdba.queryRow("SELECT @@event_scheduler").Scan(&scheduler); err != nil {
+		mdba.log.Error(wrap(err), "Unable to check whether the event scheduler is enabled")
+		return
+	}
+	if scheduler != "ON" {
+		mdba.log.Info("The event scheduler is not enabled, scheduled statements won't run", "eventScheduler", scheduler)
+	}
+}
+
+// GetScheduledStatementChecksum implements DbAdmin
+func (mdba *MySQLDbAdmin) GetScheduledStatementChecksum(name string) (string, error) {
+	definition, found, err := mdba.eventDefinition(name)
+	if err != nil || !found {
+		return "", err
+	}
+	return definitionChecksum(definition), nil
+}
+
+// DropScheduledStatement implements DbAdmin
+func (mdba *MySQLDbAdmin) DropScheduledStatement(name string) error {
+	if err := validateQuotedIdentifier(name); err != nil {
+		return err
+	}
+
+	handle, err := mdba.directHandle()
+	if err != nil {
+		return err
+	}
+	const dropTemplate = "DROP EVENT IF EXISTS %s"
+	mdba.logStatement(dropTemplate)
+	if _, err := handle.Exec(fmt.Sprintf(dropTemplate, quoteIdentifier(mdba.database)+"."+quoteIdentifier(name))); err != nil {
+		return fmt.Errorf("Unable to drop scheduled statement (%s): %w", name, wrap(err))
+	}
+	return nil
+}
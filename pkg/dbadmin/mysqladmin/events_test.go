@@ -0,0 +1,51 @@
+package mysqladmin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestEventStatement(t *testing.T) {
+	statement := dbadmin.ScheduledStatement{
+		Name:      "purge_logs",
+		Interval:  time.Hour,
+		Statement: "DELETE FROM logs WHERE created < NOW() - INTERVAL 30 DAY AND message LIKE '%debug%'",
+	}
+
+	_, rendered, err := eventStatement("CREATE", "quay", statement)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "CREATE EVENT `quay`.`purge_logs` ON SCHEDULE EVERY 3600 SECOND ON COMPLETION PRESERVE ENABLE COMMENT 'Managed by dba-operator' DO\n" + statement.Statement
+	if rendered != expected {
+		t.Errorf("Unexpected statement: %s", rendered)
+	}
+
+	statement.Disabled = true
+	template, rendered, err := eventStatement("ALTER", "quay", statement)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if template != "ALTER EVENT %s ON SCHEDULE EVERY %s SECOND ON COMPLETION PRESERVE DISABLE COMMENT 'Managed by dba-operator' DO\n" {
+		t.Errorf("Unexpected template: %s", template)
+	}
+	if rendered[:len("ALTER EVENT `quay`.`purge_logs`")] != "ALTER EVENT `quay`.`purge_logs`" {
+		t.Errorf("Unexpected statement: %s", rendered)
+	}
+}
+
+func TestEventStatementRejectsInvalid(t *testing.T) {
+	for _, statement := range []dbadmin.ScheduledStatement{
+		{Name: "purge", Interval: time.Hour},
+		{Name: "", Interval: time.Hour, Statement: "DO 1"},
+		{Name: "purge", Interval: 1500 * time.Millisecond, Statement: "DO 1"},
+		{Name: "purge", Interval: 0, Statement: "DO 1"},
+		{Name: "purge ", Interval: time.Hour, Statement: "DO 1"},
+	} {
+		if _, _, err := eventStatement("CREATE", "quay", statement); err == nil {
+			t.Errorf("Expected an error for %+v", statement)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/diagnostics"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// WithDiagnostics records the timing of every statement sent to the
+// database, the EXPLAIN output of read queries, and the statistics of the
+// connection pool into the supplied recorder.
+func WithDiagnostics(recorder *diagnostics.Recorder) Option {
+	return func(mdba *MySQLDbAdmin) {
+		mdba.diagnostics = recorder
+	}
+}
+
+// trackPool reports the statistics of the current connection pool
+func (mdba *MySQLDbAdmin) trackPool() {
+	if mdba.diagnostics != nil {
+		mdba.diagnostics.TrackPool(mdba.database, mdba.handle.Stats)
+	}
+}
+
+// query logs and runs a read query on the connected database
+func (mdba *MySQLDbAdmin) query(template string, args ...interface{}) (*sql.Rows, error) {
+	mdba.logStatement(template)
+
+	start := time.Now()
+	rows, err := mdba.handle.Query(template, args...)
+	mdba.recordQuery(template, args, time.Since(start), err)
+	return rows, err
+}
+
+// queryRow logs and runs a read query on the connected database which is
+// expected to return at most one row. Errors are deferred to Scan, as with
+// sql.DB.QueryRow, so they aren't recorded in the diagnostics.
+func (mdba *MySQLDbAdmin) queryRow(template string, args ...interface{}) *sql.Row {
+	mdba.logStatement(template)
+
+	start := time.Now()
+	row := mdba.handle.QueryRow(template, args...)
+	mdba.recordQuery(template, args, time.Since(start), nil)
+	return row
+}
+
+func (mdba *MySQLDbAdmin) recordQuery(template string, args []interface{}, took time.Duration, queryErr error) {
+	if mdba.diagnostics == nil {
+		return
+	}
+
+	record := diagnostics.Query{
+		Time:     time.Now().UTC(),
+		Database: mdba.database,
+		Template: template,
+		Category: dbadmin.CategoryQuery,
+		Duration: took,
+	}
+
+	if queryErr != nil {
+		record.Error = queryErr.Error()
+	} else if explainable(template) {
+		record.Explain, record.Error = mdba.explain(template, args)
+	}
+
+	mdba.diagnostics.Record(record)
+}
+
+// recordStatement records the timing of a statement which isn't a read query
+func (mdba *MySQLDbAdmin) recordStatement(template string, took time.Duration, statementErr xerrors.EnhancedError) {
+	if mdba.diagnostics == nil {
+		return
+	}
+
+	record := diagnostics.Query{
+		Time:     time.Now().UTC(),
+		Database: mdba.database,
+		Template: template,
+		Category: dbadmin.StatementCategory(template),
+		Duration: took,
+	}
+	if statementErr != nil {
+		record.Error = statementErr.Error()
+	}
+
+	mdba.diagnostics.Record(record)
+}
+
+// explain returns the JSON query plan for a statement, or the reason it
+// couldn't be explained
+func (mdba *MySQLDbAdmin) explain(template string, args []interface{}) (string, string) {
+	var plan string
+	if err := mdba.handle.QueryRow("EXPLAIN FORMAT=JSON "+template, args...).Scan(&plan); err != nil {
+		return "", "Unable to explain query: " + err.Error()
+	}
+	return plan, ""
+}
+
+// explainable returns true for statements which EXPLAIN accepts, SHOW
+// statements have no query plan
+func explainable(template string) bool {
+	words := strings.Fields(strings.ToUpper(template))
+	return len(words) > 0 && words[0] == "SELECT"
+}
@@ -0,0 +1,16 @@
+package mysqladmin
+
+import (
+	"fmt"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// EnsureExtensions implements DbAdmin, plugins are installed by the server's
+// administrators rather than created per database
+func (mdba *MySQLDbAdmin) EnsureExtensions(extensions []dbadmin.Extension) (map[string]string, error) {
+	if len(extensions) == 0 {
+		return map[string]string{}, nil
+	}
+	return nil, fmt.Errorf("MySQL servers have no extensions to create, plugins must be installed by their administrators: %w", dbadmin.ErrExtensionsUnsupported)
+}
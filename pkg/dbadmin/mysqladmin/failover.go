@@ -0,0 +1,96 @@
+package mysqladmin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// HostSelector chooses between the servers of self-hosted HA setups which
+// have no proxy in front of them
+type HostSelector struct{}
+
+// SelectHost implements dbadmin.HostSelector
+func (HostSelector) SelectHost(spec dbadmin.ConnectionSpec) (dbadmin.ConnectionSpec, error) {
+	if spec.Host != "" || spec.Socket != "" {
+		return dbadmin.ConnectionSpec{}, fmt.Errorf("Connections with several hosts can't also provide a host or socket")
+	}
+	return selectHost(spec, probeHost)
+}
+
+// hostProbe connects to a single host of a spec, and returns whether it
+// accepts writes
+type hostProbe func(spec dbadmin.ConnectionSpec) (bool, error)
+
+// selectHost returns the spec connecting to the first of its hosts which
+// satisfies its selection, trying them in order
+func selectHost(spec dbadmin.ConnectionSpec, probe hostProbe) (dbadmin.ConnectionSpec, error) {
+	if len(spec.Hosts) == 0 {
+		return dbadmin.ConnectionSpec{}, fmt.Errorf("Must provide at least one host for the connection")
+	}
+
+	selection := spec.HostSelection
+	switch selection {
+	case "":
+		selection = dbadmin.HostSelectionFirstAvailable
+	case dbadmin.HostSelectionFirstAvailable, dbadmin.HostSelectionPrimary:
+	default:
+		return dbadmin.ConnectionSpec{}, fmt.Errorf("Unknown host selection: %s", selection)
+	}
+
+	var failures []string
+	for _, candidate := range spec.Hosts {
+		single := spec
+		single.Hosts = nil
+		single.HostSelection = ""
+		single.Host = candidate.Host
+		if candidate.Port != 0 {
+			single.Port = candidate.Port
+		}
+
+		port := single.Port
+		if port == 0 {
+			port = defaultPort
+		}
+		addr := net.JoinHostPort(strings.Trim(single.Host, "[]"), strconv.Itoa(port))
+
+		writable, err := probe(single)
+		switch {
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("%s: %s", addr, err.Error()))
+		case selection == dbadmin.HostSelectionPrimary && !writable:
+			failures = append(failures, fmt.Sprintf("%s: server is read only", addr))
+		default:
+			return single, nil
+		}
+	}
+
+	// During a failover no host may be the primary for a while
+	return dbadmin.ConnectionSpec{}, xerrors.NewTempErrorf("No host satisfies the %s host selection: %s", selection, strings.Join(failures, "; "))
+}
+
+// probeHost checks that the server answers queries, and whether it is read
+// only, which replicas and the passive members of a cluster are
+func probeHost(spec dbadmin.ConnectionSpec) (bool, error) {
+	db, dsn, err := openEndpoint(spec)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), endpointCheckTimeout)
+	defer cancel()
+
+	// super_read_only implies read_only
+	var readOnly bool
+	if err := db.QueryRowContext(ctx, "SELECT @@global.read_only").Scan(&readOnly); err != nil {
+		return false, fmt.Errorf("Unable to query host: %w", redact.Error(wrap(err), dsn, spec.Password))
+	}
+	return !readOnly, nil
+}
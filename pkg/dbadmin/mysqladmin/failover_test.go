@@ -0,0 +1,89 @@
+package mysqladmin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+func failoverSpec(selection dbadmin.HostSelection) dbadmin.ConnectionSpec {
+	spec := validSpec()
+	spec.Host = ""
+	spec.Port = 3307
+	spec.Hosts = []dbadmin.HostPort{{Host: "mysql-0"}, {Host: "mysql-1", Port: 3308}, {Host: "mysql-2"}}
+	spec.HostSelection = selection
+	return spec
+}
+
+// fakeProbe answers for each host whether it is writable, hosts which are
+// missing are unreachable
+func fakeProbe(writable map[string]bool) hostProbe {
+	return func(spec dbadmin.ConnectionSpec) (bool, error) {
+		if len(spec.Hosts) > 0 {
+			return false, errors.New("probed with several hosts")
+		}
+		primary, ok := writable[spec.Host]
+		if !ok {
+			return false, errors.New("connection refused")
+		}
+		return primary, nil
+	}
+}
+
+func TestSelectHost(t *testing.T) {
+	testCases := []struct {
+		name         string
+		selection    dbadmin.HostSelection
+		writable     map[string]bool
+		expectedHost string
+		expectedPort int
+	}{
+		{"first available by default", "", map[string]bool{"mysql-0": false, "mysql-1": true}, "mysql-0", 3307},
+		{"skips unreachable hosts", dbadmin.HostSelectionFirstAvailable, map[string]bool{"mysql-1": false}, "mysql-1", 3308},
+		{"primary", dbadmin.HostSelectionPrimary, map[string]bool{"mysql-0": false, "mysql-1": false, "mysql-2": true}, "mysql-2", 3307},
+	}
+
+	for _, tc := range testCases {
+		selected, err := selectHost(failoverSpec(tc.selection), fakeProbe(tc.writable))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if selected.Host != tc.expectedHost || selected.Port != tc.expectedPort || len(selected.Hosts) != 0 {
+			t.Errorf("%s: expected %s:%d, got %+v", tc.name, tc.expectedHost, tc.expectedPort, selected)
+		}
+	}
+}
+
+func TestSelectHostWithoutPrimary(t *testing.T) {
+	_, err := selectHost(failoverSpec(dbadmin.HostSelectionPrimary), fakeProbe(map[string]bool{"mysql-0": false, "mysql-2": false}))
+	if err == nil {
+		t.Fatalf("Expected an error without a primary")
+	}
+
+	var enhanced xerrors.EnhancedError
+	if !errors.As(err, &enhanced) || !enhanced.Temporary() {
+		t.Errorf("Expected a temporary error during a failover, got %v", err)
+	}
+	for _, expected := range []string{"mysql-0:3307: server is read only", "mysql-1:3308: connection refused"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q in %q", expected, err.Error())
+		}
+	}
+}
+
+func TestSelectHostRejectsInvalid(t *testing.T) {
+	spec := failoverSpec("fastest")
+	if _, err := selectHost(spec, fakeProbe(nil)); err == nil {
+		t.Errorf("Expected an unknown selection to be rejected")
+	}
+
+	spec = failoverSpec("")
+	spec.Host = "mysql.example.com"
+	if _, err := (HostSelector{}).SelectHost(spec); err == nil {
+		t.Errorf("Expected a host alongside hosts to be rejected")
+	}
+}
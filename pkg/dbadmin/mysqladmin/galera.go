@@ -0,0 +1,69 @@
+package mysqladmin
+
+import (
+	"fmt"
+
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// Session settings which switch how a Galera node replicates schema changes
+const (
+	setOSUMethodRSU = "SET SESSION wsrep_OSU_method = 'RSU'"
+	setOSUMethodTOI = "SET SESSION wsrep_OSU_method = 'TOI'"
+)
+
+// GaleraOptions enables support for Galera based clusters, such as Percona
+// XtraDB Cluster
+type GaleraOptions struct {
+	// RollingSchemaUpgrades applies DDL with the rolling schema upgrade
+	// method, which only changes the connected node instead of blocking the
+	// whole cluster while the change is applied everywhere
+	RollingSchemaUpgrades bool
+}
+
+// Option customizes a MySQLDbAdmin created by CreateMySQLAdmin
+type Option func(*MySQLDbAdmin)
+
+// WithGalera checks that the connected node is synced with the primary
+// component of the cluster before every DDL or DCL statement.
+func WithGalera(galera GaleraOptions) Option {
+	return func(mdba *MySQLDbAdmin) {
+		mdba.galera = &galera
+	}
+}
+
+// checkGaleraReady returns a temporary error if the connected node can't
+// currently accept writes which must be replicated to the cluster.
+func (mdba *MySQLDbAdmin) checkGaleraReady() xerrors.EnhancedError {
+	const wsrepStatusQuery = "SHOW GLOBAL STATUS WHERE Variable_name IN ('wsrep_ready', 'wsrep_cluster_status')"
+	rows, err := mdba.query(wsrepStatusQuery)
+	if err != nil {
+		return wrap(fmt.Errorf("Unable to query Galera node status: %w", err))
+	}
+	defer rows.Close()
+
+	status := make(map[string]string)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return wrap(fmt.Errorf("Unable to parse Galera node status: %w", err))
+		}
+		status[name] = value
+	}
+	if err := rows.Err(); err != nil {
+		return wrap(fmt.Errorf("Galera node status contained an error: %w", err))
+	}
+
+	return galeraReadiness(status)
+}
+
+func galeraReadiness(status map[string]string) xerrors.EnhancedError {
+	if status["wsrep_ready"] != "ON" || status["wsrep_cluster_status"] != "Primary" {
+		return xerrors.NewTempErrorf(
+			"Galera node is not ready for replicated changes (wsrep_ready=%s, wsrep_cluster_status=%s)",
+			status["wsrep_ready"],
+			status["wsrep_cluster_status"],
+		)
+	}
+	return nil
+}
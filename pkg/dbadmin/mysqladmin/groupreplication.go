@@ -0,0 +1,110 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// WithGroupReplication routes all DDL and DCL to the current primary of the
+// Group Replication (InnoDB Cluster) group that the DSN connects to, which is
+// rediscovered whenever the chosen member turns out to be read only.
+func WithGroupReplication() Option {
+	return func(mdba *MySQLDbAdmin) {
+		mdba.groupReplication = true
+	}
+}
+
+// primaryHandle returns a connection to the current primary, discovering it
+// through the member that the DSN connects to if necessary.
+func (mdba *MySQLDbAdmin) primaryHandle() (*sql.DB, xerrors.EnhancedError) {
+	if mdba.primary != nil {
+		return mdba.primary, nil
+	}
+
+	const primaryQuery = "SELECT MEMBER_HOST, MEMBER_PORT FROM performance_schema.replication_group_members " +
+		"WHERE MEMBER_ROLE = 'PRIMARY' AND MEMBER_STATE = 'ONLINE' ORDER BY MEMBER_HOST, MEMBER_PORT LIMIT 1"
+
+	var host string
+	var port int
+	if err := mdba.queryRow(primaryQuery).Scan(&host, &port); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, xerrors.NewTempErrorf("Group replication has no online primary")
+		}
+		return nil, wrap(fmt.Errorf("Unable to discover group replication primary: %w", err))
+	}
+
+	primaryAddr := net.JoinHostPort(host, strconv.Itoa(port))
+	mdba.log.Info("Discovered group replication primary", "primary", primaryAddr)
+
+	if primaryAddr == mdba.connConfig.Addr {
+		mdba.primary = mdba.handle
+		mdba.primaryAddr = primaryAddr
+		return mdba.primary, nil
+	}
+
+	dsn := primaryDSN(mdba.connConfig, primaryAddr)
+	primary, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, wrap(fmt.Errorf("Unable to open connection to primary: %w", redact.Error(err, dsn, mdba.connConfig.Passwd)))
+	}
+
+	mdba.configurePool(primary)
+	mdba.primary = primary
+	mdba.primaryAddr = primaryAddr
+	return mdba.primary, nil
+}
+
+// PrimaryAddress implements DbAdmin
+func (mdba *MySQLDbAdmin) PrimaryAddress() (string, error) {
+	if mdba.groupReplication {
+		if _, err := mdba.primaryHandle(); err != nil {
+			return "", err
+		}
+		return mdba.primaryAddr, nil
+	}
+
+	if mdba.connConfig.Net == networkUnix {
+		return "", nil
+	}
+	return mdba.connConfig.Addr, nil
+}
+
+// forgetPrimary discards the current primary so that it is rediscovered
+// before the next statement which must run there.
+func (mdba *MySQLDbAdmin) forgetPrimary() {
+	if mdba.primary != nil && mdba.primary != mdba.handle {
+		mdba.primary.Close()
+	}
+	mdba.primary = nil
+	mdba.primaryAddr = ""
+}
+
+// primaryDSN returns the DSN for connecting to another member of the group
+// with the same credentials and parameters. Members advertise TCP addresses,
+// so a seed connected through a Unix socket reaches the primary over TCP.
+func primaryDSN(seed *mysql.Config, primaryAddr string) string {
+	primary := *seed
+	primary.Addr = primaryAddr
+	if primary.Net == networkUnix {
+		primary.Net = networkTCP
+	}
+	return primary.FormatDSN()
+}
+
+// isReadOnlyError returns true if the statement was rejected because the
+// member is read only, which is the case for every secondary in a group
+func isReadOnlyError(err error) bool {
+	var mysqle *mysql.MySQLError
+	if errors.As(err, &mysqle) {
+		return mysqle.Number == 1290 || mysqle.Number == 1836
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestPrimaryDSNKeepsCredentialsAndParams(t *testing.T) {
+	seed, err := mysql.ParseDSN("admin:" + seededPassword + "@tcp(member-1:3306)/quay?parseTime=true&tls=skip-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	primary, err := mysql.ParseDSN(primaryDSN(seed, "member-2:3306"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if primary.Addr != "member-2:3306" {
+		t.Errorf("Expected the primary address, got %s", primary.Addr)
+	}
+	if primary.User != seed.User || primary.Passwd != seed.Passwd || primary.DBName != seed.DBName {
+		t.Errorf("Credentials or database were not preserved: %+v", primary)
+	}
+	if !primary.ParseTime || primary.TLSConfig != "skip-verify" {
+		t.Errorf("Connection parameters were not preserved: %+v", primary)
+	}
+	if seed.Addr != "member-1:3306" {
+		t.Errorf("The seed config must not be modified")
+	}
+}
+
+func TestPrimaryDSNFromSocket(t *testing.T) {
+	seed, err := mysql.ParseDSN("admin:" + seededPassword + "@unix(/run/mysqld/mysqld.sock)/quay")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	primary, err := mysql.ParseDSN(primaryDSN(seed, "[2001:db8::2]:3306"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if primary.Net != "tcp" || primary.Addr != "[2001:db8::2]:3306" {
+		t.Errorf("Expected the primary to be reached over TCP, got %s(%s)", primary.Net, primary.Addr)
+	}
+}
+
+func TestPrimaryAddressWithoutGroupReplication(t *testing.T) {
+	for dsn, expected := range map[string]string{
+		"admin:" + seededPassword + "@tcp(db.example.com:3306)/quay":      "db.example.com:3306",
+		"admin:" + seededPassword + "@unix(/run/mysqld/mysqld.sock)/quay": "",
+	} {
+		parsed, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		admin := &MySQLDbAdmin{connConfig: parsed}
+		addr, err := admin.PrimaryAddress()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if addr != expected {
+			t.Errorf("Expected primary address %q, got %q", expected, addr)
+		}
+	}
+}
+
+func TestReadOnlyErrors(t *testing.T) {
+	superReadOnly := wrap(fmt.Errorf("grant: %w", &mysql.MySQLError{
+		Number:  1290,
+		Message: "The MySQL server is running with the --super-read-only option so it cannot execute this statement",
+	}))
+	if !isReadOnlyError(superReadOnly) || !superReadOnly.Temporary() {
+		t.Errorf("super_read_only errors should trigger rediscovery and be temporary")
+	}
+
+	if isReadOnlyError(wrap(&mysql.MySQLError{Number: 1064, Message: "syntax error"})) {
+		t.Errorf("Syntax errors are not read only errors")
+	}
+}
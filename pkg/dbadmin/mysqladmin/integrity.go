@@ -0,0 +1,50 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// ScanOrphanedRows implements DbAdmin
+func (mdba *MySQLDbAdmin) ScanOrphanedRows(scan dbadmin.OrphanScan) (dbadmin.OrphanScanResult, error) {
+	for _, identifier := range []string{scan.Table, scan.Column, scan.ReferencedTable, scan.ReferencedColumn} {
+		if err := validateQuotedIdentifier(identifier); err != nil {
+			return dbadmin.OrphanScanResult{}, fmt.Errorf("Unable to scan table (%s) for orphaned rows: %w", scan.Table, err)
+		}
+	}
+	if scan.Limit < 1 {
+		return dbadmin.OrphanScanResult{}, fmt.Errorf("Unable to scan table (%s) for orphaned rows: at least one row must be scanned at a time", scan.Table)
+	}
+
+	query := buildOrphanScan(mdba.database, scan)
+	var args []interface{}
+	if scan.After != "" {
+		args = append(args, scan.After)
+	}
+
+	var result dbadmin.OrphanScanResult
+	var last sql.NullString
+	if err := mdba.queryRow(query, args...).Scan(&result.Scanned, &result.Orphaned, &last); err != nil {
+		return dbadmin.OrphanScanResult{}, fmt.Errorf("Unable to scan table (%s) for orphaned rows: %w", scan.Table, wrap(err))
+	}
+	result.Last = last.String
+	return result, nil
+}
+
+// buildOrphanScan reads the batch in order of the column, which walks its
+// index, and looks each value up in the referenced table. NOT EXISTS keeps
+// duplicate referenced values from counting a row more than once.
+func buildOrphanScan(database string, scan dbadmin.OrphanScan) string {
+	column := quoteIdentifier(scan.Column)
+	filter := column + " IS NOT NULL"
+	if scan.After != "" {
+		filter += " AND " + column + " > ?"
+	}
+
+	return fmt.Sprintf("SELECT COUNT(*), COALESCE(SUM(NOT EXISTS (SELECT 1 FROM %s.%s AS p WHERE p.%s = c.%s)), 0), MAX(c.%s) "+
+		"FROM (SELECT %s FROM %s.%s WHERE %s ORDER BY %s LIMIT %d) AS c",
+		quoteIdentifier(database), quoteIdentifier(scan.ReferencedTable), quoteIdentifier(scan.ReferencedColumn), column, column,
+		column, quoteIdentifier(database), quoteIdentifier(scan.Table), filter, column, scan.Limit)
+}
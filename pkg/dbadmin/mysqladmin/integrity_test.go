@@ -0,0 +1,40 @@
+package mysqladmin
+
+import (
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestBuildOrphanScan(t *testing.T) {
+	scan := dbadmin.OrphanScan{Table: "repository", Column: "namespace_user_id", ReferencedTable: "user", ReferencedColumn: "id", Limit: 500}
+
+	expected := "SELECT COUNT(*), COALESCE(SUM(NOT EXISTS (SELECT 1 FROM `quay`.`user` AS p WHERE p.`id` = c.`namespace_user_id`)), 0), MAX(c.`namespace_user_id`) " +
+		"FROM (SELECT `namespace_user_id` FROM `quay`.`repository` WHERE `namespace_user_id` IS NOT NULL ORDER BY `namespace_user_id` LIMIT 500) AS c"
+	if query := buildOrphanScan("quay", scan); query != expected {
+		t.Errorf("Unexpected query: %s", query)
+	}
+
+	scan.After = "1234"
+	expected = "SELECT COUNT(*), COALESCE(SUM(NOT EXISTS (SELECT 1 FROM `quay`.`user` AS p WHERE p.`id` = c.`namespace_user_id`)), 0), MAX(c.`namespace_user_id`) " +
+		"FROM (SELECT `namespace_user_id` FROM `quay`.`repository` WHERE `namespace_user_id` IS NOT NULL AND `namespace_user_id` > ? ORDER BY `namespace_user_id` LIMIT 500) AS c"
+	if query := buildOrphanScan("quay", scan); query != expected {
+		t.Errorf("Unexpected query continuing a scan: %s", query)
+	}
+}
+
+func TestScanOrphanedRowsRejectsInvalid(t *testing.T) {
+	admin, _ := newFakeAdmin(nil)
+
+	valid := dbadmin.OrphanScan{Table: "repository", Column: "namespace_user_id", ReferencedTable: "user", ReferencedColumn: "id", Limit: 500}
+	invalid := []dbadmin.OrphanScan{valid, valid, valid}
+	invalid[0].Table = ""
+	invalid[1].ReferencedColumn = "id "
+	invalid[2].Limit = 0
+
+	for _, scan := range invalid {
+		if _, err := admin.ScanOrphanedRows(scan); err == nil {
+			t.Errorf("Expected an error scanning %+v", scan)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// leaseSchema holds the operator's own bookkeeping, apart from the managed
+// databases so that it doesn't show up in their schema
+const leaseSchema = "dba_operator"
+
+// AcquireLease implements DbAdmin
+func (mdba *MySQLDbAdmin) AcquireLease(holder string, duration time.Duration) (dbadmin.Lease, error) {
+	if holder == "" || len(holder) > maxIdentifierLength {
+		return dbadmin.Lease{}, fmt.Errorf("Lease holder must be between 1 and %d characters long", maxIdentifierLength)
+	}
+	if duration < time.Second {
+		return dbadmin.Lease{}, fmt.Errorf("Leases must last at least a second")
+	}
+
+	current, found, err := mdba.currentLease()
+	if isMissingSchemaError(err) {
+		if err := mdba.createLeaseTable(); err != nil {
+			return dbadmin.Lease{}, err
+		}
+	} else if err != nil {
+		return dbadmin.Lease{}, err
+	}
+
+	if found && current.Holder != holder && current.Remaining > 0 {
+		return current, nil
+	}
+
+	// The expiry is computed by the server, so that the clocks of the
+	// clusters don't have to agree
+	expiry := "NOW(6) + INTERVAL " + strconv.FormatInt(int64(duration/time.Microsecond), 10) + " MICROSECOND"
+	upsert := "INSERT INTO %s.leases (database_name, holder, expires_at) VALUES (%s, %s, " + expiry + ") " +
+		"ON DUPLICATE KEY UPDATE holder = %s, expires_at = " + expiry
+	if err := mdba.exec(upsert, identifier(leaseSchema), quoted(mdba.database), quoted(holder), quoted(holder)); err != nil {
+		return dbadmin.Lease{}, fmt.Errorf("Unable to write lease on database: %w", err)
+	}
+
+	lease := dbadmin.Lease{Holder: holder, Remaining: duration}
+	if found && current.Holder != holder {
+		lease.PreviousHolder = current.Holder
+	}
+	return lease, nil
+}
+
+func (mdba *MySQLDbAdmin) currentLease() (dbadmin.Lease, bool, error) {
+	const leaseQuery = "SELECT holder, TIMESTAMPDIFF(MICROSECOND, NOW(6), expires_at) FROM " + leaseSchema + ".leases WHERE database_name = ?"
+
+	var lease dbadmin.Lease
+	var remaining int64
+	if err := mdba.queryRow(leaseQuery, mdba.database).Scan(&lease.Holder, &remaining); err != nil {
+		if err == sql.ErrNoRows {
+			return lease, false, nil
+		}
+		return lease, false, fmt.Errorf("Unable to read lease on database: %w", wrap(err))
+	}
+	lease.Remaining = time.Duration(remaining) * time.Microsecond
+	return lease, true, nil
+}
+
+func (mdba *MySQLDbAdmin) createLeaseTable() error {
+	mdba.log.Info("Creating table for ownership leases", "schema", leaseSchema)
+	if err := mdba.exec("CREATE DATABASE IF NOT EXISTS %s", identifier(leaseSchema)); err != nil {
+		return fmt.Errorf("Unable to create schema for leases: %w", err)
+	}
+
+	const createTable = "CREATE TABLE IF NOT EXISTS %s.leases (" +
+		"database_name VARCHAR(64) NOT NULL PRIMARY KEY, " +
+		"holder VARCHAR(64) NOT NULL, " +
+		"expires_at DATETIME(6) NOT NULL)"
+	if err := mdba.exec(createTable, identifier(leaseSchema)); err != nil {
+		return fmt.Errorf("Unable to create table for leases: %w", err)
+	}
+	return nil
+}
+
+// isMissingSchemaError returns true if the lease table hasn't been created
+// yet
+func isMissingSchemaError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	// ER_BAD_DB_ERROR and ER_NO_SUCH_TABLE
+	return errors.As(err, &mysqlErr) && (mysqlErr.Number == 1049 || mysqlErr.Number == 1146)
+}
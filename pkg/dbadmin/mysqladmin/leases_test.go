@@ -0,0 +1,43 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestAcquireLeaseRejectsInvalid(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	for _, tc := range []struct {
+		holder   string
+		duration time.Duration
+	}{
+		{"", time.Minute},
+		{strings.Repeat("c", maxIdentifierLength+1), time.Minute},
+		{"east", 100 * time.Millisecond},
+	} {
+		if _, err := admin.AcquireLease(tc.holder, tc.duration); err == nil {
+			t.Errorf("Expected an error for holder %q and duration %s", tc.holder, tc.duration)
+		}
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestIsMissingSchemaError(t *testing.T) {
+	for err, expected := range map[error]bool{
+		&mysql.MySQLError{Number: 1049, Message: "Unknown database"}:             true,
+		fmt.Errorf("wrapped: %w", &mysql.MySQLError{Number: 1146, Message: "x"}): true,
+		&mysql.MySQLError{Number: 1045, Message: "Access denied"}:                false,
+		fmt.Errorf("Unable to read lease"):                                       false,
+	} {
+		if isMissingSchemaError(err) != expected {
+			t.Errorf("%v: expected %t", err, expected)
+		}
+	}
+}
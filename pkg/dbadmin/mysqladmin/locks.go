@@ -0,0 +1,110 @@
+package mysqladmin
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// ListMetadataLockWaits implements DbAdmin
+func (mdba *MySQLDbAdmin) ListMetadataLockWaits() ([]dbadmin.LockWait, error) {
+	// The waiting queries themselves are deliberately not selected, they may
+	// contain literal values which shouldn't end up in logs or events
+	const lockWaitsQuery = "SELECT object_name, waiting_pid, waiting_account, waiting_lock_type, " +
+		"IFNULL(waiting_query_secs, 0), blocking_pid, blocking_account " +
+		"FROM sys.schema_table_lock_waits WHERE object_schema = ?"
+	rows, err := mdba.query(lockWaitsQuery, mdba.database)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list metadata lock waits: %w", wrap(err))
+	}
+
+	var waits []dbadmin.LockWait
+	defer rows.Close()
+	for rows.Next() {
+		var wait dbadmin.LockWait
+		var lockType string
+		var waitSeconds int64
+		if err := rows.Scan(
+			&wait.Table,
+			&wait.WaitingSession,
+			&wait.WaitingAccount,
+			&lockType,
+			&waitSeconds,
+			&wait.BlockingSession,
+			&wait.BlockingAccount,
+		); err != nil {
+			return nil, fmt.Errorf("Unable to parse lock wait from result: %w", wrap(err))
+		}
+		wait.Exclusive = lockType == "EXCLUSIVE"
+		wait.Wait = time.Duration(waitSeconds) * time.Second
+		waits = append(waits, wait)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	return waits, nil
+}
+
+// KillSession implements DbAdmin
+func (mdba *MySQLDbAdmin) KillSession(sessionID int64, queryOnly bool) error {
+	// KILL doesn't accept placeholders, the id is an integer so it is safe to
+	// format directly
+	kill := "KILL"
+	if mdba.flavor() == dbadmin.FlavorTiDB {
+		// TiDB only kills sessions on the server that the statement is sent
+		// to unless the TIDB keyword is used
+		kill = "KILL TIDB"
+	}
+	template := kill + " CONNECTION %d"
+	if queryOnly {
+		template = kill + " QUERY %d"
+	}
+	mdba.logStatement(template)
+
+	if _, err := mdba.handle.Exec(fmt.Sprintf(template, sessionID)); err != nil {
+		return fmt.Errorf("Unable to kill session %d: %w", sessionID, wrap(err))
+	}
+	return nil
+}
+
+// KillUserSessions implements DbAdmin
+func (mdba *MySQLDbAdmin) KillUserSessions(username string) (int, error) {
+	const sessionsQuery = "SELECT ID FROM information_schema.PROCESSLIST WHERE USER = ? AND ID <> CONNECTION_ID()"
+	rows, err := mdba.query(sessionsQuery, username)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to list sessions of user %s: %w", username, wrap(err))
+	}
+
+	var sessions []int64
+	defer rows.Close()
+	for rows.Next() {
+		var session int64
+		if err := rows.Scan(&session); err != nil {
+			return 0, fmt.Errorf("Unable to parse session from result: %w", wrap(err))
+		}
+		sessions = append(sessions, session)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	killed := 0
+	for _, session := range sessions {
+		if err := mdba.KillSession(session, false); err != nil {
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == 1094 {
+				// ER_NO_SUCH_THREAD, the session ended on its own
+				continue
+			}
+			return killed, err
+		}
+		killed++
+	}
+	return killed, nil
+}
@@ -0,0 +1,12 @@
+package mysqladmin
+
+import (
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// logStatement records the template of a statement that is about to be sent
+// to the database. Only templates are ever logged, the values substituted
+// into them may contain credentials.
+func (mdba *MySQLDbAdmin) logStatement(template string) {
+	mdba.log.Info("Executing statement", "category", dbadmin.StatementCategory(template), "template", template)
+}
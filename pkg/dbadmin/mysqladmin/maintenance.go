@@ -0,0 +1,69 @@
+package mysqladmin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AnalyzeTables implements DbAdmin
+func (mdba *MySQLDbAdmin) AnalyzeTables(tables []string, optimize bool, timeout time.Duration) error {
+	if len(tables) == 0 {
+		return nil
+	}
+
+	qualified := make([]string, 0, len(tables))
+	for _, table := range tables {
+		if err := validateQuotedIdentifier(table); err != nil {
+			return fmt.Errorf("Unable to analyze table (%s): %w", table, err)
+		}
+		qualified = append(qualified, quoteIdentifier(mdba.database)+"."+quoteIdentifier(table))
+	}
+
+	// For InnoDB, OPTIMIZE TABLE rebuilds the table and then analyzes it
+	template := "ANALYZE TABLE %s"
+	if optimize {
+		template = "OPTIMIZE TABLE %s"
+	}
+	mdba.logStatement(template)
+
+	handle := mdba.handle
+	if mdba.groupReplication {
+		primary, err := mdba.primaryHandle()
+		if err != nil {
+			return err
+		}
+		handle = primary
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	rows, err := handle.QueryContext(ctx, fmt.Sprintf(template, strings.Join(qualified, ", ")))
+	if err != nil {
+		return fmt.Errorf("Unable to analyze tables: %w", wrap(err))
+	}
+	defer rows.Close()
+
+	// Problems with individual tables are reported in the result set rather
+	// than as an error for the statement
+	var failures []string
+	for rows.Next() {
+		var table, op, msgType, msgText string
+		if err := rows.Scan(&table, &op, &msgType, &msgText); err != nil {
+			return fmt.Errorf("Unable to parse analyze result: %w", wrap(err))
+		}
+		if msgType == "error" {
+			failures = append(failures, fmt.Sprintf("%s: %s", table, msgText))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("Unable to analyze tables: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// GetReplicationHealth implements DbAdmin
+func (mdba *MySQLDbAdmin) GetReplicationHealth() (dbadmin.ReplicationHealth, error) {
+	if mdba.flavor() == dbadmin.FlavorTiDB {
+		return dbadmin.ReplicationHealth{}, fmt.Errorf("Replication health is not supported by %s", mdba.flavor())
+	}
+
+	var health dbadmin.ReplicationHealth
+
+	binlogBytes, err := mdba.binlogBytes()
+	if err != nil {
+		return health, err
+	}
+	health.BinlogBytes = binlogBytes
+
+	var lag time.Duration
+	if mdba.flavor() == dbadmin.FlavorAuroraMySQL {
+		// Aurora replicas share storage and don't replicate the binlog, the
+		// cluster reports their lag instead
+		const auroraLagQuery = "SELECT COALESCE(MAX(REPLICA_LAG_IN_MILLISECONDS), 0) " +
+			"FROM information_schema.REPLICA_HOST_STATUS WHERE SESSION_ID <> 'MASTER_SESSION_ID'"
+		var lagMillis float64
+		if err := mdba.queryRow(auroraLagQuery).Scan(&lagMillis); err != nil {
+			return health, fmt.Errorf("Unable to read replica lag: %w", wrap(err))
+		}
+		lag = time.Duration(lagMillis * float64(time.Millisecond))
+	} else {
+		lag, err = mdba.sourceLag()
+		if err != nil {
+			return health, err
+		}
+	}
+	health.ReplicaLag = lag
+
+	return health, nil
+}
+
+// binlogBytes sums the size of every binary log on the server
+func (mdba *MySQLDbAdmin) binlogBytes() (int64, error) {
+	rows, err := mdba.query("SHOW BINARY LOGS")
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1381 {
+			// ER_NO_BINARY_LOGGING
+			return 0, nil
+		}
+		return 0, fmt.Errorf("Unable to list binary logs: %w", wrap(err))
+	}
+	defer rows.Close()
+
+	// Newer servers add columns, only the first two are stable
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("Unable to list binary logs: %w", wrap(err))
+	}
+
+	var total int64
+	for rows.Next() {
+		var name string
+		var size int64
+		values := make([]interface{}, len(columns))
+		values[0], values[1] = &name, &size
+		for i := 2; i < len(values); i++ {
+			values[i] = new(sql.RawBytes)
+		}
+		if err := rows.Scan(values...); err != nil {
+			return 0, fmt.Errorf("Unable to parse binary log from result: %w", wrap(err))
+		}
+		total += size
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	return total, nil
+}
+
+// GetLogPosition implements DbAdmin
+func (mdba *MySQLDbAdmin) GetLogPosition() (dbadmin.LogPosition, error) {
+	rows, err := mdba.query("SHOW MASTER STATUS")
+	if err != nil {
+		return dbadmin.LogPosition{}, fmt.Errorf("Unable to read binary log position: %w", wrap(err))
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return dbadmin.LogPosition{}, fmt.Errorf("Unable to read binary log position: %w", wrap(err))
+	}
+
+	// There are no rows when binary logging is disabled
+	var position dbadmin.LogPosition
+	if rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return position, fmt.Errorf("Unable to parse binary log position from result: %w", wrap(err))
+		}
+		if position, err = parseMasterStatus(columns, values); err != nil {
+			return position, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return position, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	if position.File != "" && mdba.flavor() == dbadmin.FlavorMariaDB {
+		// MariaDB doesn't report its GTIDs with the binary log position
+		const gtidQuery = "SELECT @@GLOBAL.gtid_binlog_pos"
+		if err := mdba.queryRow(gtidQuery).Scan(&position.GTIDSet); err != nil {
+			return position, fmt.Errorf("Unable to read GTID position: %w", wrap(err))
+		}
+	}
+
+	return position, nil
+}
+
+// parseMasterStatus reads the position from a row of SHOW MASTER STATUS,
+// whose columns differ between servers
+func parseMasterStatus(columns []string, values []sql.NullString) (dbadmin.LogPosition, error) {
+	var position dbadmin.LogPosition
+	for i, column := range columns {
+		switch column {
+		case "File":
+			position.File = values[i].String
+		case "Position":
+			parsed, err := strconv.ParseInt(values[i].String, 10, 64)
+			if err != nil {
+				return position, fmt.Errorf("Unable to parse binary log position (%s): %w", values[i].String, err)
+			}
+			position.Position = parsed
+		case "Executed_Gtid_Set":
+			// Long sets are wrapped over several lines
+			position.GTIDSet = strings.Replace(values[i].String, "\n", "", -1)
+		}
+	}
+	return position, nil
+}
+
+// sourceLag returns how far the connected server is behind its source, which
+// is zero when it isn't a replica or replication is stopped
+func (mdba *MySQLDbAdmin) sourceLag() (time.Duration, error) {
+	rows, err := mdba.query("SHOW SLAVE STATUS")
+	if err != nil {
+		return 0, fmt.Errorf("Unable to read replication status: %w", wrap(err))
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("Unable to read replication status: %w", wrap(err))
+	}
+
+	// There is a row per replication channel
+	var lag time.Duration
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return 0, fmt.Errorf("Unable to parse replication status from result: %w", wrap(err))
+		}
+
+		for i, column := range columns {
+			if column != "Seconds_Behind_Master" || !values[i].Valid {
+				continue
+			}
+			seconds, err := strconv.ParseInt(values[i].String, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("Unable to parse replica lag (%s): %w", values[i].String, err)
+			}
+			if channelLag := time.Duration(seconds) * time.Second; channelLag > lag {
+				lag = channelLag
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	return lag, nil
+}
+
+// AbortMigration implements DbAdmin
+func (mdba *MySQLDbAdmin) AbortMigration(username string) error {
+	// Deleting the migration's pod leaves its statements running on the
+	// server, so its sessions are killed as well
+	const sessionsQuery = "SELECT ID FROM information_schema.PROCESSLIST WHERE USER = ?"
+	rows, err := mdba.query(sessionsQuery, username)
+	if err != nil {
+		return fmt.Errorf("Unable to list sessions of migration user: %w", wrap(err))
+	}
+
+	var sessions []int64
+	for rows.Next() {
+		var session int64
+		if err := rows.Scan(&session); err != nil {
+			rows.Close()
+			return fmt.Errorf("Unable to parse session from result: %w", wrap(err))
+		}
+		sessions = append(sessions, session)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	for _, session := range sessions {
+		if err := mdba.KillSession(session, false); err != nil {
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == 1094 {
+				// ER_NO_SUCH_THREAD, the session ended on its own
+				continue
+			}
+			return err
+		}
+	}
+
+	for _, statement := range mdba.engine.GetCleanupStatements() {
+		if err := mdba.exec(statement); err != nil {
+			return fmt.Errorf("Unable to clean up after migration: %w", err)
+		}
+	}
+
+	return nil
+}
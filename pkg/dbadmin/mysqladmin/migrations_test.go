@@ -0,0 +1,37 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestParseMasterStatus(t *testing.T) {
+	columns := []string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB", "Executed_Gtid_Set"}
+	values := []sql.NullString{
+		{String: "mysql-bin.000042", Valid: true},
+		{String: "1337", Valid: true},
+		{String: "", Valid: true},
+		{String: "", Valid: true},
+		{String: "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-77,\n4e11fa47-71ca-11e1-9e33-c80aa9429562:1-5", Valid: true},
+	}
+
+	position, err := parseMasterStatus(columns, values)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if position.File != "mysql-bin.000042" || position.Position != 1337 ||
+		position.GTIDSet != "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-77,4e11fa47-71ca-11e1-9e33-c80aa9429562:1-5" {
+		t.Errorf("Unexpected position: %+v", position)
+	}
+
+	// MariaDB has no GTID column
+	position, err = parseMasterStatus(columns[:4], values[:4])
+	if err != nil || position.GTIDSet != "" || position.Position != 1337 {
+		t.Errorf("Unexpected position: %+v (%v)", position, err)
+	}
+
+	values[1].String = "unknown"
+	if _, err := parseMasterStatus(columns, values); err == nil {
+		t.Errorf("Expected an error for a malformed position")
+	}
+}
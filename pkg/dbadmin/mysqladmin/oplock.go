@@ -0,0 +1,72 @@
+package mysqladmin
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// operatorLockName returns the name of the GET_LOCK lock which excludes
+// other operators from the database. Lock names are limited to 64
+// characters, and database names to 64 themselves, so long names are cut.
+func operatorLockName(database string) string {
+	name := "dba-operator:" + database
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// operatorLockRetryDelay is how long to wait for another operator to finish
+// its reconcile, once the lock timeout has passed
+const operatorLockRetryDelay = 30 * time.Second
+
+// AcquireOperatorLock implements DbAdmin
+func (mdba *MySQLDbAdmin) AcquireOperatorLock(timeout time.Duration) (func() error, error) {
+	// Changes are made on the primary, so that is where the lock is taken
+	handle := mdba.handle
+	if mdba.groupReplication {
+		primary, err := mdba.primaryHandle()
+		if err != nil {
+			return nil, err
+		}
+		handle = primary
+	}
+
+	// The lock belongs to the session, so one connection is kept out of the
+	// pool until it is released
+	ctx := context.Background()
+	conn, err := handle.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open connection for operator lock: %w", wrap(err))
+	}
+
+	name := operatorLockName(mdba.database)
+	const getLock = "SELECT GET_LOCK(?, ?)"
+	mdba.logStatement(getLock)
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, getLock, name, int(timeout.Seconds())).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("Unable to acquire operator lock %s: %w", name, wrap(err))
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		conn.Close()
+		return nil, xerrors.NewTempErrorf("Operator lock %s is held by another operator", name).WithRetryAfter(operatorLockRetryDelay)
+	}
+
+	release := func() error {
+		defer conn.Close()
+
+		const releaseLock = "DO RELEASE_LOCK(?)"
+		mdba.logStatement(releaseLock)
+		if _, err := conn.ExecContext(ctx, releaseLock, name); err != nil {
+			return fmt.Errorf("Unable to release operator lock %s: %w", name, wrap(err))
+		}
+		return nil
+	}
+	return release, nil
+}
@@ -0,0 +1,17 @@
+package mysqladmin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOperatorLockName(t *testing.T) {
+	if name := operatorLockName("quay"); name != "dba-operator:quay" {
+		t.Errorf("Unexpected lock name: %s", name)
+	}
+
+	long := operatorLockName(strings.Repeat("a", 64))
+	if len(long) != 64 || !strings.HasPrefix(long, "dba-operator:") {
+		t.Errorf("Lock names must be cut to 64 characters: %s", long)
+	}
+}
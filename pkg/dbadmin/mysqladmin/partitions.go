@@ -0,0 +1,210 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// boundaryKind is how the upper bounds of a table's partitions encode time
+type boundaryKind int
+
+const (
+	// boundaryColumns is RANGE COLUMNS on a DATE or DATETIME column
+	boundaryColumns boundaryKind = iota
+
+	// boundaryDays is RANGE on TO_DAYS() of a column
+	boundaryDays
+
+	// boundarySeconds is RANGE on UNIX_TIMESTAMP() of a column
+	boundarySeconds
+)
+
+// toDaysEpoch is TO_DAYS('1970-01-01')
+const toDaysEpoch = 719528
+
+const maxValue = "MAXVALUE"
+
+// partitionLayout is what the server reports about the partitions of a table
+type partitionLayout struct {
+	kind       boundaryKind
+	partitions []dbadmin.Partition
+}
+
+// ListPartitions implements DbAdmin
+func (mdba *MySQLDbAdmin) ListPartitions(table string) ([]dbadmin.Partition, error) {
+	layout, err := mdba.partitionLayout(table)
+	if err != nil {
+		return nil, err
+	}
+	return layout.partitions, nil
+}
+
+func (mdba *MySQLDbAdmin) partitionLayout(table string) (*partitionLayout, error) {
+	const partitionsQuery = "SELECT PARTITION_NAME, PARTITION_METHOD, PARTITION_EXPRESSION, PARTITION_DESCRIPTION " +
+		"FROM information_schema.PARTITIONS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY PARTITION_ORDINAL_POSITION"
+	rows, err := mdba.query(partitionsQuery, mdba.database, table)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list partitions of table (%s): %w", table, wrap(err))
+	}
+	defer rows.Close()
+
+	var layout *partitionLayout
+	for rows.Next() {
+		var name, method, expression, description sql.NullString
+		if err := rows.Scan(&name, &method, &expression, &description); err != nil {
+			return nil, fmt.Errorf("Unable to parse partition from result: %w", wrap(err))
+		}
+		if !name.Valid {
+			return nil, fmt.Errorf("Table %s is not partitioned", table)
+		}
+
+		if layout == nil {
+			kind, err := parseBoundaryKind(method.String, expression.String)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to manage partitions of table (%s): %w", table, err)
+			}
+			layout = &partitionLayout{kind: kind}
+		}
+
+		end, err := parseBoundary(layout.kind, description.String)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse bound of partition %s of table %s: %w", name.String, table, err)
+		}
+		layout.partitions = append(layout.partitions, dbadmin.Partition{Name: name.String, End: end})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	if layout == nil {
+		return nil, fmt.Errorf("Table %s does not exist", table)
+	}
+	return layout, nil
+}
+
+// parseBoundaryKind determines how time is encoded from the partitioning
+// method and expression of a table
+func parseBoundaryKind(method, expression string) (boundaryKind, error) {
+	normalized := strings.ToLower(strings.Replace(expression, " ", "", -1))
+	switch {
+	case method == "RANGE COLUMNS" && !strings.Contains(normalized, ","):
+		return boundaryColumns, nil
+	case method == "RANGE" && strings.HasPrefix(normalized, "to_days("):
+		return boundaryDays, nil
+	case method == "RANGE" && strings.HasPrefix(normalized, "unix_timestamp("):
+		return boundarySeconds, nil
+	}
+	return 0, fmt.Errorf("Partitioning method %s on %s does not partition by time", method, expression)
+}
+
+// parseBoundary decodes the upper bound of a partition
+func parseBoundary(kind boundaryKind, description string) (time.Time, error) {
+	if description == maxValue {
+		return time.Time{}, nil
+	}
+
+	switch kind {
+	case boundaryColumns:
+		value := strings.Trim(description, "'")
+		for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				return parsed, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("Not a date: %s", description)
+	case boundaryDays:
+		days, err := strconv.ParseInt(description, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, 0).UTC().AddDate(0, 0, int(days-toDaysEpoch)), nil
+	default:
+		seconds, err := strconv.ParseInt(description, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+}
+
+// partitionDefinition returns the template of a partition which holds
+// values up to end, the date of a RANGE COLUMNS partition is its only value
+func partitionDefinition(kind boundaryKind, end time.Time) (string, []sqlValue) {
+	end = end.UTC()
+	switch kind {
+	case boundaryColumns:
+		return "PARTITION %s VALUES LESS THAN (%s)", []sqlValue{quoted(end.Format("2006-01-02 15:04:05"))}
+	case boundaryDays:
+		days := int64(end.Sub(time.Unix(0, 0)).Hours()/24) + toDaysEpoch
+		return "PARTITION %s VALUES LESS THAN (" + strconv.FormatInt(days, 10) + ")", nil
+	default:
+		return "PARTITION %s VALUES LESS THAN (" + strconv.FormatInt(end.Unix(), 10) + ")", nil
+	}
+}
+
+// AddPartitions implements DbAdmin
+func (mdba *MySQLDbAdmin) AddPartitions(table string, partitions []dbadmin.Partition) error {
+	if len(partitions) == 0 {
+		return nil
+	}
+
+	layout, err := mdba.partitionLayout(table)
+	if err != nil {
+		return err
+	}
+
+	definitions := make([]string, 0, len(partitions)+1)
+	var definitionArgs []sqlValue
+	for _, partition := range partitions {
+		if partition.End.IsZero() {
+			return fmt.Errorf("Partition %s of table %s must have an upper bound", partition.Name, table)
+		}
+		definition, values := partitionDefinition(layout.kind, partition.End)
+		definitions = append(definitions, definition)
+		definitionArgs = append(definitionArgs, identifier(partition.Name))
+		definitionArgs = append(definitionArgs, values...)
+	}
+
+	format := "ALTER TABLE %s.%s ADD PARTITION (" + strings.Join(definitions, ", ") + ")"
+	args := append([]sqlValue{identifier(mdba.database), identifier(table)}, definitionArgs...)
+
+	// Ranges can only be added after the last one, so a partition catching
+	// later values is split instead
+	last := layout.partitions[len(layout.partitions)-1]
+	if last.End.IsZero() {
+		definitions = append(definitions, "PARTITION %s VALUES LESS THAN ("+maxValue+")")
+		format = "ALTER TABLE %s.%s REORGANIZE PARTITION %s INTO (" + strings.Join(definitions, ", ") + ")"
+		args = []sqlValue{identifier(mdba.database), identifier(table), identifier(last.Name)}
+		args = append(args, definitionArgs...)
+		args = append(args, identifier(last.Name))
+	}
+
+	if err := mdba.exec(format, args...); err != nil {
+		return fmt.Errorf("Unable to add partitions to table (%s): %w", table, err)
+	}
+	return nil
+}
+
+// DropPartitions implements DbAdmin
+func (mdba *MySQLDbAdmin) DropPartitions(table string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(names))
+	args := []sqlValue{identifier(mdba.database), identifier(table)}
+	for _, name := range names {
+		placeholders = append(placeholders, "%s")
+		args = append(args, identifier(name))
+	}
+
+	if err := mdba.exec("ALTER TABLE %s.%s DROP PARTITION "+strings.Join(placeholders, ", "), args...); err != nil {
+		return fmt.Errorf("Unable to drop partitions of table (%s): %w", table, err)
+	}
+	return nil
+}
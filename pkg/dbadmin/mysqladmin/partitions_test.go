@@ -0,0 +1,93 @@
+package mysqladmin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBoundaryKind(t *testing.T) {
+	for _, tc := range []struct {
+		method     string
+		expression string
+		expected   boundaryKind
+	}{
+		{"RANGE COLUMNS", "`created`", boundaryColumns},
+		{"RANGE", "to_days(`created`)", boundaryDays},
+		{"RANGE", "TO_DAYS( created )", boundaryDays},
+		{"RANGE", "unix_timestamp(`created`)", boundarySeconds},
+	} {
+		kind, err := parseBoundaryKind(tc.method, tc.expression)
+		if err != nil || kind != tc.expected {
+			t.Errorf("%s %s: expected %d, got %d (%v)", tc.method, tc.expression, tc.expected, kind, err)
+		}
+	}
+
+	for _, method := range [][2]string{
+		{"HASH", "`id`"},
+		{"RANGE", "`id`"},
+		{"RANGE COLUMNS", "`created`,`id`"},
+	} {
+		if _, err := parseBoundaryKind(method[0], method[1]); err == nil {
+			t.Errorf("Expected an error for %s %s", method[0], method[1])
+		}
+	}
+}
+
+func TestParseBoundary(t *testing.T) {
+	expected := time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC)
+	for kind, description := range map[boundaryKind]string{
+		boundaryColumns: "'2020-01-15 00:00:00'",
+		boundaryDays:    "737804",
+		boundarySeconds: "1579046400",
+	} {
+		end, err := parseBoundary(kind, description)
+		if err != nil || !end.Equal(expected) {
+			t.Errorf("%s: expected %s, got %s (%v)", description, expected, end, err)
+		}
+	}
+
+	if end, err := parseBoundary(boundaryColumns, "'2020-01-15'"); err != nil || !end.Equal(expected) {
+		t.Errorf("Expected a date, got %s (%v)", end, err)
+	}
+	if end, err := parseBoundary(boundaryDays, maxValue); err != nil || !end.IsZero() {
+		t.Errorf("Expected MAXVALUE to have no bound, got %s (%v)", end, err)
+	}
+	if _, err := parseBoundary(boundaryColumns, "'yesterday'"); err == nil {
+		t.Errorf("Expected an error for an invalid date")
+	}
+}
+
+func TestPartitionDefinition(t *testing.T) {
+	end := time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	if template, _ := partitionDefinition(boundaryDays, end); template != "PARTITION %s VALUES LESS THAN (737804)" {
+		t.Errorf("Unexpected template: %s", template)
+	}
+	if template, _ := partitionDefinition(boundarySeconds, end); template != "PARTITION %s VALUES LESS THAN (1579046400)" {
+		t.Errorf("Unexpected template: %s", template)
+	}
+
+	template, values := partitionDefinition(boundaryColumns, end)
+	rendered, err := renderStatement(template, append([]sqlValue{identifier("p20200114")}, values...), map[string]bool{"2020-01-15 00:00:00": true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rendered != "PARTITION `p20200114` VALUES LESS THAN ('2020-01-15 00:00:00')" {
+		t.Errorf("Unexpected definition: %s", rendered)
+	}
+}
+
+func TestDropPartitions(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	if err := admin.DropPartitions("logs", nil); err != nil || len(fake.statements) != 0 {
+		t.Errorf("Expected nothing to be dropped: %v %v", err, fake.statements)
+	}
+
+	if err := admin.DropPartitions("logs", []string{"p20200101", "p20200102"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fake.statements) != 1 || fake.statements[0] != "ALTER TABLE %s.%s DROP PARTITION %s, %s" {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
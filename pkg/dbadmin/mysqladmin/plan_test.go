@@ -0,0 +1,95 @@
+package mysqladmin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+type memoryCheckpointer map[string]int
+
+func (mc memoryCheckpointer) Completed(planID string) (int, error) {
+	return mc[planID], nil
+}
+
+func (mc memoryCheckpointer) Complete(planID string, steps int) error {
+	mc[planID] = steps
+	return nil
+}
+
+func TestExecutePlanResumes(t *testing.T) {
+	admin, fake := newFakeAdmin(map[int]error{1: errors.New("connection lost")})
+	checkpoints := memoryCheckpointer{}
+
+	plan := dbadmin.AdminPlan{
+		ID: "test",
+		Steps: []dbadmin.PlanStep{
+			{Kind: dbadmin.StepLockCredentials, Username: "dba_v1"},
+			{Kind: dbadmin.StepLockCredentials, Username: "dba_v2"},
+			{Kind: dbadmin.StepLockCredentials, Username: "dba_v3"},
+		},
+		Checkpoints: checkpoints,
+	}
+
+	if err := admin.ExecutePlan(plan); err == nil {
+		t.Fatalf("Expected the second step to fail")
+	}
+	if checkpoints["test"] != 1 {
+		t.Errorf("Expected one completed step, got %d", checkpoints["test"])
+	}
+
+	fake.failAt = nil
+	if err := admin.ExecutePlan(plan); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if checkpoints["test"] != 3 {
+		t.Errorf("Expected three completed steps, got %d", checkpoints["test"])
+	}
+
+	// The first step must not run again after resuming
+	if len(fake.statements) != 4 {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestExecutePlanRejectsUnknownStep(t *testing.T) {
+	admin, _ := newFakeAdmin(nil)
+
+	if err := admin.ExecutePlan(dbadmin.AdminPlan{ID: "test", Steps: []dbadmin.PlanStep{{Kind: "truncate"}}}); err == nil {
+		t.Errorf("Expected an error for an unknown step")
+	}
+}
+
+func TestRenderPlanRedactsSecrets(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	plan := dbadmin.AdminPlan{
+		ID: "test",
+		Steps: []dbadmin.PlanStep{
+			{Kind: dbadmin.StepCheckSchemaVersion, Version: "v1"},
+			{Kind: dbadmin.StepWriteCredentials, Credentials: []dbadmin.Credentials{{Username: "dba_v1", Password: seededPassword}}},
+			{Kind: dbadmin.StepDeleteCredentials, Username: "dba_v0"},
+		},
+	}
+
+	statements, err := admin.RenderPlan(plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("Rendering must not execute anything: %v", fake.statements)
+	}
+
+	if len(statements) != 5 ||
+		statements[1] != "CREATE USER 'dba_v1'@'%' IDENTIFIED BY '<redacted>'" ||
+		statements[4] != "DROP USER 'dba_v0'" {
+		t.Errorf("Unexpected statements: %q", statements)
+	}
+	for _, statement := range statements {
+		if strings.Contains(statement, seededPassword) {
+			t.Errorf("Password was rendered: %s", statement)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package mysqladmin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// listPrivilegesQuery reads the privileges of the users at every level the
+// server grants them, USAGE only means that a user exists
+const listPrivilegesQuery = `SELECT GRANTEE, '*', '', '', PRIVILEGE_TYPE FROM information_schema.USER_PRIVILEGES WHERE GRANTEE LIKE ? AND PRIVILEGE_TYPE != 'USAGE'
+UNION ALL SELECT GRANTEE, TABLE_SCHEMA, '', '', PRIVILEGE_TYPE FROM information_schema.SCHEMA_PRIVILEGES WHERE GRANTEE LIKE ?
+UNION ALL SELECT GRANTEE, TABLE_SCHEMA, TABLE_NAME, '', PRIVILEGE_TYPE FROM information_schema.TABLE_PRIVILEGES WHERE GRANTEE LIKE ?
+UNION ALL SELECT GRANTEE, TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME, PRIVILEGE_TYPE FROM information_schema.COLUMN_PRIVILEGES WHERE GRANTEE LIKE ?`
+
+// ListUserPrivileges implements DbAdmin
+func (mdba *MySQLDbAdmin) ListUserPrivileges(usernamePrefix string) (map[string][]dbadmin.UserPrivilege, error) {
+	pattern := "'" + usernamePrefix + "%"
+	rows, err := mdba.query(listPrivilegesQuery, pattern, pattern, pattern, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list user privileges: %w", wrap(err))
+	}
+	defer rows.Close()
+
+	privileges := make(map[string][]dbadmin.UserPrivilege)
+	for rows.Next() {
+		var grantee string
+		var privilege dbadmin.UserPrivilege
+		if err := rows.Scan(&grantee, &privilege.Database, &privilege.Table, &privilege.Column, &privilege.Privilege); err != nil {
+			return nil, fmt.Errorf("Unable to parse user privilege from result: %w", wrap(err))
+		}
+
+		username, host, ok := parseGrantee(grantee)
+		if !ok || host != "%" {
+			continue
+		}
+		if privilege.Database == mdba.database {
+			privilege.Database = ""
+		}
+		privileges[username] = append(privileges[username], privilege)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	for _, held := range privileges {
+		sortPrivileges(held)
+	}
+	return privileges, nil
+}
+
+// parseGrantee splits the 'user'@'host' form of the information_schema
+// privilege tables
+func parseGrantee(grantee string) (string, string, bool) {
+	separator := strings.LastIndex(grantee, "@")
+	if separator < 0 {
+		return "", "", false
+	}
+	username, ok := unquoteGrantee(grantee[:separator])
+	if !ok {
+		return "", "", false
+	}
+	host, ok := unquoteGrantee(grantee[separator+1:])
+	if !ok {
+		return "", "", false
+	}
+	return username, host, true
+}
+
+func unquoteGrantee(quoted string) (string, bool) {
+	if len(quoted) < 2 || quoted[0] != '\'' || quoted[len(quoted)-1] != '\'' {
+		return "", false
+	}
+	return strings.Replace(quoted[1:len(quoted)-1], "''", "'", -1), true
+}
+
+func sortPrivileges(privileges []dbadmin.UserPrivilege) {
+	sort.Slice(privileges, func(i, j int) bool {
+		left, right := privileges[i], privileges[j]
+		if left.Database != right.Database {
+			return left.Database < right.Database
+		}
+		if left.Table != right.Table {
+			return left.Table < right.Table
+		}
+		if left.Column != right.Column {
+			return left.Column < right.Column
+		}
+		return left.Privilege < right.Privilege
+	})
+}
+
+// statementPrivileges maps the statement instruments of the performance
+// schema to the privilege which their statements need
+var statementPrivileges = map[string]string{
+	"statement/sql/select":         "SELECT",
+	"statement/sql/insert":         "INSERT",
+	"statement/sql/insert_select":  "INSERT",
+	"statement/sql/replace":        "INSERT",
+	"statement/sql/replace_select": "INSERT",
+	"statement/sql/update":         "UPDATE",
+	"statement/sql/update_multi":   "UPDATE",
+	"statement/sql/delete":         "DELETE",
+	"statement/sql/delete_multi":   "DELETE",
+	"statement/sql/call_procedure": "EXECUTE",
+}
+
+// ListPrivilegeUse implements DbAdmin
+func (mdba *MySQLDbAdmin) ListPrivilegeUse(usernamePrefix string) ([]dbadmin.PrivilegeUse, error) {
+	const statementsQuery = "SELECT USER, EVENT_NAME, COUNT_STAR " +
+		"FROM performance_schema.events_statements_summary_by_user_by_event_name " +
+		"WHERE USER LIKE ? AND EVENT_NAME LIKE 'statement/sql/%' AND COUNT_STAR > 0"
+	rows, err := mdba.query(statementsQuery, usernamePrefix+"%")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list privilege use: %w", wrap(err))
+	}
+	defer rows.Close()
+
+	counted := make(map[dbadmin.PrivilegeUse]int64)
+	for rows.Next() {
+		var username, event string
+		var statements int64
+		if err := rows.Scan(&username, &event, &statements); err != nil {
+			return nil, fmt.Errorf("Unable to parse privilege use from result: %w", wrap(err))
+		}
+		if privilege, ok := statementPrivileges[event]; ok {
+			counted[dbadmin.PrivilegeUse{Username: username, Privilege: privilege}] += statements
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	use := make([]dbadmin.PrivilegeUse, 0, len(counted))
+	for key, statements := range counted {
+		key.Statements = statements
+		use = append(use, key)
+	}
+	sort.Slice(use, func(i, j int) bool {
+		if use[i].Username != use[j].Username {
+			return use[i].Username < use[j].Username
+		}
+		return use[i].Privilege < use[j].Privilege
+	})
+	return use, nil
+}
+
+// revocablePrivileges are the privileges which RevokePrivileges may take
+// away. Privilege names can't be passed as parameters, so only these are
+// ever written into statement templates.
+var revocablePrivileges = map[string]bool{
+	"SELECT":  true,
+	"INSERT":  true,
+	"UPDATE":  true,
+	"DELETE":  true,
+	"EXECUTE": true,
+}
+
+// classPrivileges are the privileges which the grant classes give, only
+// these are revoked by ReapplyGrants
+var classPrivileges = map[string]bool{
+	"SELECT":             true,
+	"INSERT":             true,
+	"UPDATE":             true,
+	"DELETE":             true,
+	"EXECUTE":            true,
+	"REPLICATION SLAVE":  true,
+	"REPLICATION CLIENT": true,
+}
+
+// extraPrivileges returns the held privileges which the grants of the
+// credentials don't give, in the form ListUserPrivileges reports them.
+// Privileges which the operator never grants are left alone.
+func (mdba *MySQLDbAdmin) extraPrivileges(cred dbadmin.Credentials, held []dbadmin.UserPrivilege) ([]dbadmin.UserPrivilege, error) {
+	grants := cred.Grants
+	if len(grants) == 0 {
+		grants = []dbadmin.DatabaseGrant{{Class: dbadmin.GrantClassReadWrite}}
+	}
+
+	granted := make(map[dbadmin.UserPrivilege]bool)
+	for _, grant := range grants {
+		if grant.Database == mdba.database {
+			grant.Database = ""
+		}
+		targets, err := grantTargets(grant)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid grant for user %s: %w", cred.Username, err)
+		}
+		for _, target := range targets {
+			database := target.database
+			if target.class == dbadmin.GrantClassReplication {
+				database = "*"
+			}
+			columns := []string{""}
+			if target.columns != "" {
+				columns = strings.Split(target.columns, columnSeparator)
+			}
+			for _, privilege := range strings.Split(grantClassPrivileges[target.class], ", ") {
+				for _, column := range columns {
+					granted[dbadmin.UserPrivilege{Database: database, Table: target.table, Column: column, Privilege: privilege}] = true
+				}
+			}
+		}
+	}
+
+	var extra []dbadmin.UserPrivilege
+	for _, privilege := range held {
+		if classPrivileges[privilege.Privilege] && !granted[privilege] {
+			extra = append(extra, privilege)
+		}
+	}
+	return extra, nil
+}
+
+// commonPrefix returns the longest prefix which all of the usernames share
+func commonPrefix(usernames []string) string {
+	if len(usernames) == 0 {
+		return ""
+	}
+	prefix := usernames[0]
+	for _, username := range usernames[1:] {
+		for !strings.HasPrefix(username, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// RevokePrivileges implements DbAdmin
+func (mdba *MySQLDbAdmin) RevokePrivileges(username string, privileges []dbadmin.UserPrivilege) error {
+	for _, privilege := range privileges {
+		if !revocablePrivileges[privilege.Privilege] {
+			return fmt.Errorf("Privilege %s of user %s can't be revoked", privilege.Privilege, username)
+		}
+	}
+	return mdba.revokePrivileges(username, privileges)
+}
+
+// revokePrivileges takes away privileges which have been checked against
+// the ones that may be written into statement templates
+func (mdba *MySQLDbAdmin) revokePrivileges(username string, privileges []dbadmin.UserPrivilege) error {
+	statements := make([]grantStatement, 0, len(privileges))
+	for _, privilege := range privileges {
+		statement, err := mdba.revokeStatement(username, privilege)
+		if err != nil {
+			return err
+		}
+		statements = append(statements, statement)
+	}
+
+	for _, statement := range statements {
+		if err := mdba.exec(statement.format, statement.args...); err != nil {
+			// ER_NONEXISTING_GRANT and ER_NONEXISTING_TABLE_GRANT, the
+			// privilege was already revoked
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && (mysqlErr.Number == 1141 || mysqlErr.Number == 1147) {
+				continue
+			}
+			return fmt.Errorf("Unable to revoke privileges of user %s: %w", username, err)
+		}
+	}
+	return nil
+}
+
+func (mdba *MySQLDbAdmin) revokeStatement(username string, privilege dbadmin.UserPrivilege) (grantStatement, error) {
+	if privilege.Column != "" && privilege.Table == "" {
+		return grantStatement{}, fmt.Errorf("Column privilege of user %s has no table", username)
+	}
+
+	var args []sqlValue
+	privileges := privilege.Privilege
+	if privilege.Column != "" {
+		privileges += " (%s)"
+		args = append(args, identifier(privilege.Column))
+	}
+
+	object := "*.*"
+	if privilege.Database != "*" {
+		database := privilege.Database
+		if database == "" {
+			database = mdba.database
+		}
+		object = "%s.*"
+		args = append(args, identifier(database))
+		if privilege.Table != "" {
+			object = "%s.%s"
+			args = append(args, identifier(privilege.Table))
+		}
+	}
+
+	args = append(args, quoted(username))
+	return grantStatement{
+		format: fmt.Sprintf("REVOKE %s ON %s FROM %%s@'%%%%'", privileges, object),
+		args:   args,
+	}, nil
+}
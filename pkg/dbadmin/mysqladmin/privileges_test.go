@@ -0,0 +1,104 @@
+package mysqladmin
+
+import (
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestParseGrantee(t *testing.T) {
+	testCases := []struct {
+		grantee  string
+		username string
+		host     string
+		ok       bool
+	}{
+		{"'dba_v1'@'%'", "dba_v1", "%", true},
+		{"'dba_v1'@'10.0.0.1'", "dba_v1", "10.0.0.1", true},
+		{"'o''brien'@'%'", "o'brien", "%", true},
+		{"'dba@v1'@'%'", "dba@v1", "%", true},
+		{"dba_v1@%", "", "", false},
+		{"'dba_v1'", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		username, host, ok := parseGrantee(tc.grantee)
+		if username != tc.username || host != tc.host || ok != tc.ok {
+			t.Errorf("Unexpected parse of %s: %s %s %v", tc.grantee, username, host, ok)
+		}
+	}
+}
+
+func TestSortPrivileges(t *testing.T) {
+	privileges := []dbadmin.UserPrivilege{
+		{Database: "quay", Table: "user", Privilege: "SELECT"},
+		{Privilege: "UPDATE"},
+		{Database: "*", Privilege: "REPLICATION SLAVE"},
+		{Privilege: "SELECT"},
+	}
+	sortPrivileges(privileges)
+
+	expected := []dbadmin.UserPrivilege{
+		{Privilege: "SELECT"},
+		{Privilege: "UPDATE"},
+		{Database: "*", Privilege: "REPLICATION SLAVE"},
+		{Database: "quay", Table: "user", Privilege: "SELECT"},
+	}
+	for i := range expected {
+		if privileges[i] != expected[i] {
+			t.Errorf("Unexpected order: %v", privileges)
+			break
+		}
+	}
+}
+
+func TestRevokePrivileges(t *testing.T) {
+	admin, fake := newFakeAdmin(map[int]error{1: &mysql.MySQLError{Number: 1147, Message: "There is no such grant defined"}})
+
+	err := admin.RevokePrivileges("dbr_1", []dbadmin.UserPrivilege{
+		{Privilege: "INSERT"},
+		{Database: "audit", Table: "events", Privilege: "DELETE"},
+		{Table: "user", Column: "email", Privilege: "UPDATE"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"REVOKE INSERT ON %s.* FROM %s@'%%'",
+		"REVOKE DELETE ON %s.%s FROM %s@'%%'",
+		"REVOKE UPDATE (%s) ON %s.%s FROM %s@'%%'",
+	}
+	if len(fake.statements) != len(expected) {
+		t.Fatalf("Unexpected statements: %v", fake.statements)
+	}
+	for i := range expected {
+		if fake.statements[i] != expected[i] {
+			t.Errorf("Unexpected statement: %s", fake.statements[i])
+		}
+	}
+
+	statement, _ := admin.revokeStatement("dbr_1", dbadmin.UserPrivilege{Privilege: "INSERT"})
+	if *statement.args[0].value != "quay" {
+		t.Errorf("Expected the connected database to be revoked on: %v", *statement.args[0].value)
+	}
+}
+
+func TestRevokePrivilegesRefusesUnknown(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	for _, privilege := range []dbadmin.UserPrivilege{
+		{Privilege: "SELECT; DROP TABLE user"},
+		{Database: "*", Privilege: "REPLICATION SLAVE"},
+		{Column: "email", Privilege: "SELECT"},
+	} {
+		if err := admin.RevokePrivileges("dbr_1", []dbadmin.UserPrivilege{privilege}); err == nil {
+			t.Errorf("Expected %+v to be refused", privilege)
+		}
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
@@ -0,0 +1,92 @@
+package mysqladmin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+// probeStatements exercise a privilege on a table which doesn't exist, so
+// the server either refuses them or reports the missing table
+var probeStatements = map[string]string{
+	"SELECT": "SELECT 1 FROM %s.%s LIMIT 0",
+	"DROP":   "DROP TABLE %s.%s",
+}
+
+// deniedErrors are returned when the user lacks the privilege
+var deniedErrors = map[uint16]bool{
+	1044: true, // ER_DBACCESS_DENIED_ERROR
+	1142: true, // ER_TABLEACCESS_DENIED_ERROR
+	1143: true, // ER_COLUMNACCESS_DENIED_ERROR
+}
+
+// missingErrors are only returned once the privilege check has passed
+var missingErrors = map[uint16]bool{
+	1051: true, // ER_BAD_TABLE_ERROR
+	1146: true, // ER_NO_SUCH_TABLE
+}
+
+// probeAllowed interprets the outcome of a probe statement, errors which say
+// nothing about the privilege are returned
+func probeAllowed(err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		if deniedErrors[mysqlErr.Number] {
+			return false, nil
+		}
+		if missingErrors[mysqlErr.Number] {
+			return true, nil
+		}
+	}
+	return false, err
+}
+
+// GrantProber probes the privileges of MySQL users issued by the operator
+type GrantProber struct{}
+
+// ProbeGrants implements dbadmin.GrantProber
+func (GrantProber) ProbeGrants(spec dbadmin.ConnectionSpec, probes []dbadmin.GrantProbe) ([]dbadmin.GrantProbeResult, error) {
+	db, dsn, err := openEndpoint(spec)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), endpointCheckTimeout)
+	defer cancel()
+
+	table := "dba_operator_probe_" + randIdentifier(8)
+	results := make([]dbadmin.GrantProbeResult, 0, len(probes))
+	for _, probe := range probes {
+		format, ok := probeStatements[probe.Privilege]
+		if !ok {
+			return nil, fmt.Errorf("Unable to probe privilege %s", probe.Privilege)
+		}
+		database := probe.Database
+		if database == "" {
+			database = spec.Database
+		}
+
+		statement, err := renderStatement(format, []sqlValue{identifier(database), identifier(table)}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to render probe of %s on %s: %w", probe.Privilege, database, err)
+		}
+
+		_, err = db.ExecContext(ctx, statement)
+		allowed, err := probeAllowed(err)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to probe %s on %s: %w", probe.Privilege, database, redact.Error(wrap(err), dsn, spec.Password))
+		}
+		results = append(results, dbadmin.GrantProbeResult{Probe: probe, Allowed: allowed})
+	}
+	return results, nil
+}
@@ -0,0 +1,40 @@
+package mysqladmin
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestProbeAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		err      error
+		allowed  bool
+		failures bool
+	}{
+		{nil, true, false},
+		{&mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"}, true, false},
+		{fmt.Errorf("wrapped: %w", &mysql.MySQLError{Number: 1051, Message: "Unknown table"}), true, false},
+		{&mysql.MySQLError{Number: 1142, Message: "DROP command denied"}, false, false},
+		{&mysql.MySQLError{Number: 1044, Message: "Access denied"}, false, false},
+		{&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout"}, false, true},
+		{errors.New("connection refused"), false, true},
+	} {
+		allowed, err := probeAllowed(tc.err)
+		if allowed != tc.allowed || (err != nil) != tc.failures {
+			t.Errorf("%v: expected allowed %t and error %t, got %t and %v", tc.err, tc.allowed, tc.failures, allowed, err)
+		}
+	}
+}
+
+func TestProbeStatementsQuoteIdentifiers(t *testing.T) {
+	statement, err := renderStatement(probeStatements["DROP"], []sqlValue{identifier("app`db"), identifier("probe")}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if statement != "DROP TABLE `app``db`.`probe`" {
+		t.Errorf("Unexpected statement: %s", statement)
+	}
+}
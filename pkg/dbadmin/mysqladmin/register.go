@@ -0,0 +1,39 @@
+package mysqladmin
+
+import (
+	"fmt"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// Engine is the name which ManagedDatabases use for this backend
+const Engine = "mysql"
+
+func init() {
+	if err := dbadmin.Register(Engine, Factory); err != nil {
+		panic(err)
+	}
+}
+
+// Factory implements dbadmin.Factory, the options must be of type Option
+func Factory(dsn string, settings dbadmin.AdminSettings) (dbadmin.DbAdmin, error) {
+	for name := range settings.Parameters {
+		return nil, fmt.Errorf("The %s backend has no parameter %s", Engine, name)
+	}
+
+	var options []Option
+	if settings.ObserveRetry != nil {
+		options = append(options, WithRetryObserver(settings.ObserveRetry))
+	}
+	if settings.Session != nil {
+		options = append(options, WithSessionSettings(*settings.Session))
+	}
+	for _, option := range settings.Options {
+		mysqlOption, ok := option.(Option)
+		if !ok {
+			return nil, fmt.Errorf("Unsupported option for the %s backend: %T", Engine, option)
+		}
+		options = append(options, mysqlOption)
+	}
+	return CreateMySQLAdmin(dsn, settings.MigrationEngine, settings.Log, options...)
+}
@@ -0,0 +1,28 @@
+package mysqladmin
+
+import (
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/redact/redacttest"
+)
+
+func TestFactoryIsRegistered(t *testing.T) {
+	if _, ok := dbadmin.Lookup(Engine); !ok {
+		t.Errorf("Expected a backend to be registered for %s", Engine)
+	}
+}
+
+func TestFactoryRejectsForeignSettings(t *testing.T) {
+	const dsn = "admin:" + seededPassword + "@tcp(db.example.com:3306)/quay"
+
+	invalid := []dbadmin.AdminSettings{
+		{Log: redacttest.NewRecordingLogger(), Parameters: map[string]string{"region": "us-east-1"}},
+		{Log: redacttest.NewRecordingLogger(), Options: []interface{}{"galera"}},
+	}
+	for _, settings := range invalid {
+		if _, err := Factory(dsn, settings); err == nil {
+			t.Errorf("Expected an error for settings %+v", settings)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// redactedValue replaces the quoted values of rendered statements which
+// aren't known to be safe to show
+const redactedValue = "'<redacted>'"
+
+// RenderPlan implements DbAdmin
+func (mdba *MySQLDbAdmin) RenderPlan(plan dbadmin.AdminPlan) ([]string, error) {
+	var statements []string
+	for i, step := range plan.Steps {
+		// Only the usernames of a step are shown, every other quoted value
+		// could be a password or authentication string
+		public := map[string]bool{step.Username: true}
+		for _, cred := range step.Credentials {
+			public[cred.Username] = true
+		}
+
+		// The shadow shares the detected server, so that it builds the same
+		// statements, but records them instead of executing them
+		shadow := *mdba
+		shadow.exec = func(format string, args ...sqlValue) xerrors.EnhancedError {
+			rendered, err := renderStatement(format, args, public)
+			if err != nil {
+				return wrap(err)
+			}
+			statements = append(statements, rendered)
+			return nil
+		}
+
+		var err error
+		switch step.Kind {
+		case dbadmin.StepCheckSchemaVersion:
+			statements = append(statements, fmt.Sprintf("-- verify that the schema version is %s", step.Version))
+		case dbadmin.StepWriteCredentials:
+			err = shadow.WriteCredentialsBatch(step.Credentials)
+		case dbadmin.StepRotateCredentials:
+			err = shadow.RotateCredentials(step.Credentials)
+		case dbadmin.StepLockCredentials:
+			err = shadow.LockCredentials(step.Username)
+		case dbadmin.StepDeleteCredentials:
+			statements = append(statements, fmt.Sprintf("-- verify that user %s has no sessions", step.Username))
+			err = shadow.dropUser(step.Username)
+		default:
+			err = fmt.Errorf("Unknown plan step: %s", step.Kind)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Unable to render step %d (%s) of plan %s: %w", i, step.Kind, plan.ID, err)
+		}
+	}
+	return statements, nil
+}
+
+// renderStatement substitutes the values into the template the way the
+// server would see them, replacing quoted values which aren't public
+func renderStatement(format string, args []sqlValue, public map[string]bool) (string, error) {
+	segments, err := parseTemplate(format, args)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered strings.Builder
+	for _, seg := range segments {
+		if seg.argIndex < 0 {
+			rendered.WriteString(seg.literal)
+			continue
+		}
+
+		arg := args[seg.argIndex]
+		switch {
+		case arg.kind != quotedValue:
+			rendered.WriteString(substitutedValue(arg))
+		case public[*arg.value]:
+			rendered.WriteString("'" + strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(*arg.value) + "'")
+		default:
+			rendered.WriteString(redactedValue)
+		}
+	}
+	return rendered.String(), nil
+}
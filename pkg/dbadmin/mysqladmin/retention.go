@@ -0,0 +1,57 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+const purgeTemplate = "DELETE FROM %s WHERE %s < NOW() - INTERVAL ? SECOND ORDER BY %s LIMIT %d"
+
+// PurgeRows implements DbAdmin
+func (mdba *MySQLDbAdmin) PurgeRows(table, column string, olderThan time.Duration, limit int) (int64, error) {
+	for _, identifier := range []string{table, column} {
+		if err := validateQuotedIdentifier(identifier); err != nil {
+			return 0, fmt.Errorf("Unable to purge rows of table (%s): %w", table, err)
+		}
+	}
+	if limit < 1 {
+		return 0, fmt.Errorf("Unable to purge rows of table (%s): at least one row must be purged at a time", table)
+	}
+
+	query := buildPurgeStatement(mdba.database, table, column, limit)
+	mdba.logStatement(purgeTemplate)
+
+	var purged int64
+	err := mdba.retryStatement(purgeTemplate, func() xerrors.EnhancedError {
+		handle := mdba.handle
+		if mdba.groupReplication {
+			primary, err := mdba.primaryHandle()
+			if err != nil {
+				return err
+			}
+			handle = primary
+		}
+
+		result, err := handle.Exec(query, int64(olderThan/time.Second))
+		if err != nil {
+			return wrap(err)
+		}
+		purged, err = result.RowsAffected()
+		return wrap(err)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Unable to purge rows of table (%s): %w", table, err)
+	}
+	return purged, nil
+}
+
+// buildPurgeStatement deletes the oldest rows first, so that each batch
+// walks the index on the column instead of scanning the table. The cutoff
+// is taken from the server's clock, in the session time zone which it also
+// compares TIMESTAMP columns in.
+func buildPurgeStatement(database, table, column string, limit int) string {
+	return fmt.Sprintf(purgeTemplate,
+		quoteIdentifier(database)+"."+quoteIdentifier(table), quoteIdentifier(column), quoteIdentifier(column), limit)
+}
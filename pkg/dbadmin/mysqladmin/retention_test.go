@@ -0,0 +1,31 @@
+package mysqladmin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildPurgeStatement(t *testing.T) {
+	query := buildPurgeStatement("quay", "logentry", "datetime", 500)
+	expected := "DELETE FROM `quay`.`logentry` WHERE `datetime` < NOW() - INTERVAL ? SECOND ORDER BY `datetime` LIMIT 500"
+	if query != expected {
+		t.Errorf("Unexpected query: %s", query)
+	}
+}
+
+func TestPurgeRowsRejectsInvalid(t *testing.T) {
+	admin, _ := newFakeAdmin(nil)
+
+	for _, args := range []struct {
+		table, column string
+		limit         int
+	}{
+		{"", "datetime", 500},
+		{"logentry", "date time ", 500},
+		{"logentry", "datetime", 0},
+	} {
+		if _, err := admin.PurgeRows(args.table, args.column, time.Hour, args.limit); err == nil {
+			t.Errorf("Expected an error purging %+v", args)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package mysqladmin
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// statementRetryErrors are the errors of statements which lost out to a
+// concurrent transaction. The server rolled the statement back, so it is
+// retried in place instead of failing the whole reconcile.
+var statementRetryErrors = map[uint16]string{
+	1205: "lock_wait_timeout", // ER_LOCK_WAIT_TIMEOUT
+	1213: "deadlock",          // ER_LOCK_DEADLOCK
+}
+
+const (
+	// maxStatementAttempts bounds how often a statement is run, including
+	// the first attempt
+	maxStatementAttempts = 3
+
+	// statementRetryDelay is the delay before the first retry, it doubles
+	// with every further retry
+	statementRetryDelay = 100 * time.Millisecond
+)
+
+// sleep waits between the attempts of a statement, tests replace it
+var sleep = time.Sleep
+
+// WithRetryObserver calls observe with the reason every time a statement is
+// retried, such as "deadlock"
+func WithRetryObserver(observe func(reason string)) Option {
+	return func(mdba *MySQLDbAdmin) {
+		mdba.observeRetry = observe
+	}
+}
+
+// retryStatement runs the attempt until it succeeds, fails with an error
+// which isn't retried in place, or has been attempted maxStatementAttempts
+// times
+func (mdba *MySQLDbAdmin) retryStatement(format string, attempt func() xerrors.EnhancedError) xerrors.EnhancedError {
+	for made := 1; ; made++ {
+		err := attempt()
+		reason := statementRetryReason(err)
+		if reason == "" || made >= maxStatementAttempts {
+			return err
+		}
+
+		delay := retryBackoff(made, rand.Float64())
+		mdba.log.Info("Retrying statement", "statement", format, "reason", reason, "attempt", made, "delay", delay)
+		if mdba.observeRetry != nil {
+			mdba.observeRetry(reason)
+		}
+		sleep(delay)
+	}
+}
+
+// statementRetryReason returns why the statement should be retried in place,
+// or nothing when it shouldn't be
+func statementRetryReason(err error) string {
+	var mysqle *mysql.MySQLError
+	if err == nil || !errors.As(err, &mysqle) {
+		return ""
+	}
+	return statementRetryErrors[mysqle.Number]
+}
+
+// retryBackoff returns the delay after the given number of attempts, with
+// jitter between 0 and 1 spreading it over the upper half of the exponential
+// delay so that transactions which deadlocked on each other don't retry in
+// lockstep
+func retryBackoff(attempts int, jitter float64) time.Duration {
+	delay := statementRetryDelay << uint(attempts-1)
+	return delay/2 + time.Duration(jitter*float64(delay/2))
+}
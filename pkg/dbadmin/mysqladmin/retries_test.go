@@ -0,0 +1,71 @@
+package mysqladmin
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+func TestRetryStatement(t *testing.T) {
+	var slept []time.Duration
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { sleep = time.Sleep }()
+
+	admin, _ := newFakeAdmin(nil)
+	var observed []string
+	admin.observeRetry = func(reason string) { observed = append(observed, reason) }
+
+	attempts := 0
+	err := admin.retryStatement("GRANT %s", func() xerrors.EnhancedError {
+		attempts++
+		if attempts == 1 {
+			return wrap(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"})
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Errorf("Expected the deadlock to be retried once, got %d attempts (%v)", attempts, err)
+	}
+	if len(observed) != 1 || observed[0] != "deadlock" || len(slept) != 1 {
+		t.Errorf("Expected one observed deadlock, got %v after sleeping %v", observed, slept)
+	}
+
+	attempts = 0
+	err = admin.retryStatement("GRANT %s", func() xerrors.EnhancedError {
+		attempts++
+		return wrap(&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"})
+	})
+	var mysqlErr *mysql.MySQLError
+	if attempts != maxStatementAttempts || !errors.As(err, &mysqlErr) || mysqlErr.Number != 1205 {
+		t.Errorf("Expected the lock wait to fail after %d attempts, got %d (%v)", maxStatementAttempts, attempts, err)
+	}
+
+	attempts = 0
+	_ = admin.retryStatement("GRANT %s", func() xerrors.EnhancedError {
+		attempts++
+		return wrap(&mysql.MySQLError{Number: 1044, Message: "Access denied"})
+	})
+	if attempts != 1 {
+		t.Errorf("Expected other errors not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		attempts int
+		jitter   float64
+		expected time.Duration
+	}{
+		{1, 0, 50 * time.Millisecond},
+		{1, 1, 100 * time.Millisecond},
+		{2, 0.5, 150 * time.Millisecond},
+	} {
+		if delay := retryBackoff(tc.attempts, tc.jitter); delay != tc.expected {
+			t.Errorf("Expected %s after %d attempts with jitter %f, got %s", tc.expected, tc.attempts, tc.jitter, delay)
+		}
+	}
+}
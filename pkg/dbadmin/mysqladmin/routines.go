@@ -0,0 +1,170 @@
+package mysqladmin
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// helperRoutine is a stored routine which the operator can create in managed
+// databases on request
+type helperRoutine struct {
+	// kind is PROCEDURE or FUNCTION
+	kind string
+
+	// signature is the parameter list and characteristics which follow the
+	// routine name
+	signature string
+
+	// body is compared against the definition the server reports in order
+	// to detect drift
+	body string
+}
+
+// softDeleteParams are the parameters shared by the soft delete helpers,
+// which work on any table with an id primary key and a deleted_at column
+const softDeleteParams = "(IN table_name VARCHAR(64), IN row_id BIGINT)\n" +
+	"MODIFIES SQL DATA\n" +
+	"SQL SECURITY INVOKER\n" +
+	"COMMENT 'Managed by dba-operator'"
+
+// helperRoutines contains every routine which may be requested by name. The
+// routines run with the caller's privileges, so granting EXECUTE on them
+// never allows more than the caller could already do.
+var helperRoutines = map[string]helperRoutine{
+	"dba_soft_delete": {
+		kind:      "PROCEDURE",
+		signature: softDeleteParams,
+		body: "BEGIN\n" +
+			"  SET @dba_soft_delete_stmt = CONCAT('UPDATE `', REPLACE(table_name, '`', '``'), '` SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL');\n" +
+			"  SET @dba_soft_delete_id = row_id;\n" +
+			"  PREPARE dba_soft_delete_stmt FROM @dba_soft_delete_stmt;\n" +
+			"  EXECUTE dba_soft_delete_stmt USING @dba_soft_delete_id;\n" +
+			"  DEALLOCATE PREPARE dba_soft_delete_stmt;\n" +
+			"END",
+	},
+	"dba_restore_deleted": {
+		kind:      "PROCEDURE",
+		signature: softDeleteParams,
+		body: "BEGIN\n" +
+			"  SET @dba_restore_stmt = CONCAT('UPDATE `', REPLACE(table_name, '`', '``'), '` SET deleted_at = NULL WHERE id = ?');\n" +
+			"  SET @dba_restore_id = row_id;\n" +
+			"  PREPARE dba_restore_stmt FROM @dba_restore_stmt;\n" +
+			"  EXECUTE dba_restore_stmt USING @dba_restore_id;\n" +
+			"  DEALLOCATE PREPARE dba_restore_stmt;\n" +
+			"END",
+	},
+}
+
+// definitionChecksum hashes a stored definition, ignoring differences in
+// whitespace which the server may introduce when storing it
+func definitionChecksum(body string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(body), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// EnsureHelperRoutines implements DbAdmin
+func (mdba *MySQLDbAdmin) EnsureHelperRoutines(names []string, repair bool) ([]string, error) {
+	if len(names) > 0 && mdba.flavor() == dbadmin.FlavorTiDB {
+		return nil, fmt.Errorf("Stored routines are not supported by %s", mdba.flavor())
+	}
+
+	var drifted []string
+	for _, name := range names {
+		routine, ok := helperRoutines[name]
+		if !ok {
+			return drifted, fmt.Errorf("Unknown helper routine: %s", name)
+		}
+
+		definition, found, err := mdba.routineDefinition(name, routine.kind)
+		if err != nil {
+			return drifted, err
+		}
+		if found && definitionChecksum(definition) == definitionChecksum(routine.body) {
+			continue
+		}
+
+		if found {
+			drifted = append(drifted, name)
+			if !repair {
+				continue
+			}
+			mdba.log.Info("Replacing helper routine whose definition has drifted", "routine", name)
+		}
+
+		if err := mdba.writeRoutine(name, routine, found); err != nil {
+			return drifted, fmt.Errorf("Unable to create helper routine (%s): %w", name, err)
+		}
+	}
+
+	return drifted, nil
+}
+
+// routineDefinition returns the body of the routine as stored by the server.
+// The definition is NULL when the operator isn't allowed to see it, which is
+// treated as a routine that doesn't match.
+func (mdba *MySQLDbAdmin) routineDefinition(name, kind string) (string, bool, error) {
+	const routineQuery = "SELECT ROUTINE_DEFINITION FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = ? AND ROUTINE_NAME = ? AND ROUTINE_TYPE = ?"
+
+	var definition sql.NullString
+	err := mdba.queryRow(routineQuery, mdba.database, name, kind).Scan(&definition)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("Unable to read helper routine (%s): %w", name, wrap(err))
+	}
+	return definition.String, true, nil
+}
+
+// writeRoutine creates the routine, dropping the existing definition first if
+// there is one. Routine definitions can't be prepared, so they are sent
+// directly; the names are quoted and the bodies are constants. MySQL has no
+// way to replace a routine atomically, so it is briefly missing while it is
+// being repaired.
+func (mdba *MySQLDbAdmin) writeRoutine(name string, routine helperRoutine, replace bool) error {
+	handle, err := mdba.directHandle()
+	if err != nil {
+		return err
+	}
+
+	qualified := quoteIdentifier(mdba.database) + "." + quoteIdentifier(name)
+	if replace {
+		dropTemplate := "DROP " + routine.kind + " IF EXISTS %s"
+		mdba.logStatement(dropTemplate)
+		if _, err := handle.Exec(fmt.Sprintf(dropTemplate, qualified)); err != nil {
+			return wrap(err)
+		}
+	}
+
+	createTemplate := "CREATE " + routine.kind + " %s" + routine.signature + "\n" + routine.body
+	mdba.logStatement(createTemplate)
+	if _, err := handle.Exec(strings.Replace(createTemplate, "%s", qualified, 1)); err != nil {
+		return wrap(err)
+	}
+
+	return nil
+}
+
+// directHandle returns the handle which DDL that can't be prepared is sent
+// to directly, once the cluster is ready for it
+func (mdba *MySQLDbAdmin) directHandle() (*sql.DB, error) {
+	if mdba.galera != nil {
+		if err := mdba.checkGaleraReady(); err != nil {
+			return nil, err
+		}
+	}
+
+	if mdba.groupReplication {
+		primary, err := mdba.primaryHandle()
+		if err != nil {
+			return nil, err
+		}
+		return primary, nil
+	}
+	return mdba.handle, nil
+}
@@ -0,0 +1,32 @@
+package mysqladmin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRoutineChecksumIgnoresWhitespace(t *testing.T) {
+	body := helperRoutines["dba_soft_delete"].body
+	reformatted := strings.Replace(body, "\n  ", "\n\t", -1) + "\n"
+
+	if definitionChecksum(body) != definitionChecksum(reformatted) {
+		t.Errorf("Checksum should not depend on indentation")
+	}
+	if definitionChecksum(body) == definitionChecksum(strings.Replace(body, "deleted_at = NOW()", "deleted_at = NULL", 1)) {
+		t.Errorf("Checksum should change when the body does")
+	}
+}
+
+func TestHelperRoutinesAreValid(t *testing.T) {
+	for name, routine := range helperRoutines {
+		if err := validateIdentifier(name); err != nil {
+			t.Errorf("%s: invalid name: %v", name, err)
+		}
+		if routine.kind != "PROCEDURE" && routine.kind != "FUNCTION" {
+			t.Errorf("%s: unknown routine kind %s", name, routine.kind)
+		}
+		if !strings.Contains(routine.signature, "SQL SECURITY INVOKER") {
+			t.Errorf("%s: helper routines must run with the caller's privileges", name)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// autoIncrementCounter matches the table option recording the next
+// AUTO_INCREMENT value, which changes with the data rather than the schema
+var autoIncrementCounter = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+
+type schemaObject struct {
+	name string
+	view bool
+}
+
+// ExportSchema implements DbAdmin
+func (mdba *MySQLDbAdmin) ExportSchema() (string, error) {
+	const listTablesQuery = "SELECT TABLE_NAME, TABLE_TYPE FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME"
+	rows, err := mdba.query(listTablesQuery, mdba.database)
+	if err != nil {
+		return "", fmt.Errorf("Unable to list tables: %w", wrap(err))
+	}
+
+	var objects []schemaObject
+	defer rows.Close()
+	for rows.Next() {
+		var name, tableType string
+		if err := rows.Scan(&name, &tableType); err != nil {
+			return "", fmt.Errorf("Unable to parse table from result: %w", wrap(err))
+		}
+		objects = append(objects, schemaObject{name: name, view: tableType == "VIEW"})
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+
+	statements := make([]string, 0, len(objects))
+	for _, object := range objects {
+		ddl, err := mdba.showCreate(object)
+		if err != nil {
+			return "", err
+		}
+		statements = append(statements, ddl+";\n")
+	}
+
+	return strings.Join(statements, "\n"), nil
+}
+
+// showCreate returns the DDL for a single table or view. SHOW statements don't
+// accept placeholders, so the names are validated and quoted instead.
+func (mdba *MySQLDbAdmin) showCreate(object schemaObject) (string, error) {
+	if err := validateQuotedIdentifier(object.name); err != nil {
+		return "", fmt.Errorf("Unable to export table (%s): %w", object.name, err)
+	}
+
+	qualified := quoteIdentifier(mdba.database) + "." + quoteIdentifier(object.name)
+	if object.view {
+		const showCreateView = "SHOW CREATE VIEW %s"
+		mdba.logStatement(showCreateView)
+
+		var name, ddl, charset, collation string
+		if err := mdba.handle.QueryRow(fmt.Sprintf(showCreateView, qualified)).Scan(&name, &ddl, &charset, &collation); err != nil {
+			return "", fmt.Errorf("Unable to export view (%s): %w", object.name, wrap(err))
+		}
+		return ddl, nil
+	}
+
+	const showCreateTable = "SHOW CREATE TABLE %s"
+	mdba.logStatement(showCreateTable)
+
+	var name, ddl string
+	if err := mdba.handle.QueryRow(fmt.Sprintf(showCreateTable, qualified)).Scan(&name, &ddl); err != nil {
+		return "", fmt.Errorf("Unable to export table (%s): %w", object.name, wrap(err))
+	}
+	return autoIncrementCounter.ReplaceAllString(ddl, ""), nil
+}
@@ -0,0 +1,116 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+const (
+	// seedBatchSize bounds how many rows each upsert of seed data inserts
+	seedBatchSize = 500
+
+	// maxPlaceholders is how many values a prepared statement may bind
+	maxPlaceholders = 65535
+)
+
+// seedNull is how a CSV value of NULL is written, as LOAD DATA reads it
+const seedNull = `\N`
+
+// ApplySeedData implements DbAdmin
+func (mdba *MySQLDbAdmin) ApplySeedData(steps []dbadmin.SeedStep) error {
+	for _, step := range steps {
+		if strings.TrimSpace(step.Statement) != "" && dbadmin.StatementCategory(step.Statement) != dbadmin.CategoryDML {
+			return fmt.Errorf("Seed data may only insert, replace, update or delete rows, not run: %s", strings.Fields(step.Statement)[0])
+		}
+		for _, row := range step.Rows {
+			if len(row) != len(step.Columns) {
+				return fmt.Errorf("Seed data for table %s has rows with %d values for %d columns", step.Table, len(row), len(step.Columns))
+			}
+		}
+	}
+
+	mdba.log.Info("Applying seed data", "steps", len(steps))
+	err := mdba.retryStatement("seed data", func() xerrors.EnhancedError {
+		return mdba.applySeedSteps(steps)
+	})
+	if err != nil {
+		return fmt.Errorf("Unable to apply seed data: %w", err)
+	}
+	return nil
+}
+
+// applySeedSteps makes a single attempt at applying the steps
+func (mdba *MySQLDbAdmin) applySeedSteps(steps []dbadmin.SeedStep) xerrors.EnhancedError {
+	handle := mdba.handle
+	if mdba.groupReplication {
+		primary, err := mdba.primaryHandle()
+		if err != nil {
+			return err
+		}
+		handle = primary
+	}
+
+	tx, err := handle.Begin()
+	if err != nil {
+		return wrap(err)
+	}
+	defer tx.Rollback()
+
+	for _, step := range steps {
+		if step.Statement != "" {
+			if _, err := tx.Exec(step.Statement); err != nil {
+				return wrap(err)
+			}
+			continue
+		}
+
+		batchSize := seedBatchSize
+		if len(step.Columns)*batchSize > maxPlaceholders {
+			batchSize = maxPlaceholders / len(step.Columns)
+		}
+		for start := 0; start < len(step.Rows); start += batchSize {
+			end := start + batchSize
+			if end > len(step.Rows) {
+				end = len(step.Rows)
+			}
+			query, values := buildSeedUpsert(mdba.database, step, step.Rows[start:end])
+			if _, err := tx.Exec(query, values...); err != nil {
+				return wrap(err)
+			}
+		}
+	}
+
+	return wrap(tx.Commit())
+}
+
+// buildSeedUpsert returns the statement which inserts the rows into the
+// step's table of the database, updating the rows whose keys already exist
+func buildSeedUpsert(database string, step dbadmin.SeedStep, rows [][]string) (string, []interface{}) {
+	columns := make([]string, len(step.Columns))
+	updates := make([]string, len(step.Columns))
+	for i, column := range step.Columns {
+		columns[i] = quoteIdentifier(column)
+		updates[i] = fmt.Sprintf("%s = VALUES(%s)", columns[i], columns[i])
+	}
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+	tuples := make([]string, len(rows))
+	values := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		tuples[i] = placeholders
+		for _, value := range row {
+			if value == seedNull {
+				values = append(values, nil)
+			} else {
+				values = append(values, value)
+			}
+		}
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s.%s (%s) VALUES %s ON DUPLICATE KEY UPDATE %s",
+		quoteIdentifier(database), quoteIdentifier(step.Table), strings.Join(columns, ", "), strings.Join(tuples, ", "), strings.Join(updates, ", "))
+	return query, values
+}
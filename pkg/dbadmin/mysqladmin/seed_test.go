@@ -0,0 +1,38 @@
+package mysqladmin
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestBuildSeedUpsert(t *testing.T) {
+	step := dbadmin.SeedStep{Table: "mediatype", Columns: []string{"id", "name"}}
+	query, values := buildSeedUpsert("quay", step, [][]string{{"1", "text/plain"}, {"2", `\N`}})
+
+	expected := "INSERT INTO `quay`.`mediatype` (`id`, `name`) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`)"
+	if query != expected {
+		t.Errorf("Unexpected query: %s", query)
+	}
+	if !reflect.DeepEqual(values, []interface{}{"1", "text/plain", "2", nil}) {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestApplySeedDataRejectsInvalid(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	for _, steps := range [][]dbadmin.SeedStep{
+		{{Statement: "INSERT INTO role (name) VALUES ('admin')"}, {Statement: "DROP TABLE role"}},
+		{{Statement: "GRANT ALL ON *.* TO 'app'"}},
+		{{Table: "role", Columns: []string{"id", "name"}, Rows: [][]string{{"1"}}}},
+	} {
+		if err := admin.ApplySeedData(steps); err == nil {
+			t.Errorf("Expected an error applying %v", steps)
+		}
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
@@ -0,0 +1,201 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// minimumVersions contains the oldest supported version of every flavor
+var minimumVersions = map[dbadmin.ServerFlavor]string{
+	dbadmin.FlavorMySQL:       "5.7.0",
+	dbadmin.FlavorMariaDB:     "10.3.0",
+	dbadmin.FlavorTiDB:        "5.0.0",
+	dbadmin.FlavorAuroraMySQL: "2.0.0",
+}
+
+var (
+	tidbVersion    = regexp.MustCompile(`-TiDB-v(\d+\.\d+\.\d+)`)
+	mariaDBVersion = regexp.MustCompile(`(?:^|-)(\d+\.\d+\.\d+)-MariaDB`)
+	leadingVersion = regexp.MustCompile(`^(\d+\.\d+\.\d+)`)
+)
+
+// DetectServer implements DbAdmin
+func (mdba *MySQLDbAdmin) DetectServer() (dbadmin.ServerInfo, error) {
+	if mdba.server != nil {
+		return *mdba.server, nil
+	}
+
+	const versionQuery = "SELECT VERSION(), @@version_comment, @@hostname, @@port"
+
+	var version, comment, hostname string
+	var port int
+	if err := mdba.queryRow(versionQuery).Scan(&version, &comment, &hostname, &port); err != nil {
+		return dbadmin.ServerInfo{}, fmt.Errorf("Unable to query server version: %w", wrap(err))
+	}
+
+	// Aurora reports the MySQL version it is compatible with, and only
+	// identifies itself through an extra variable
+	const auroraQuery = "SHOW VARIABLES LIKE 'aurora\\_version'"
+
+	var auroraVersion string
+	var name string
+	err := mdba.queryRow(auroraQuery).Scan(&name, &auroraVersion)
+	if err != nil && err != sql.ErrNoRows {
+		return dbadmin.ServerInfo{}, fmt.Errorf("Unable to query Aurora version: %w", wrap(err))
+	}
+
+	info, err := parseServerVersion(version, comment, auroraVersion)
+	if err != nil {
+		return dbadmin.ServerInfo{}, err
+	}
+	if err := checkSupportedVersion(info); err != nil {
+		return dbadmin.ServerInfo{}, err
+	}
+	info.Instance = fmt.Sprintf("%s:%d", hostname, port)
+	info.Database = mdba.database
+
+	mdba.log.Info("Detected server", "flavor", info.Flavor, "version", info.Version, "instance", info.Instance)
+	mdba.server = &info
+
+	if mdba.session != nil && mdba.session.StatementTimeout > 0 {
+		// The statement timeout is named differently by each flavor
+		if err := mdba.reopenWithSessionParams(); err != nil {
+			return dbadmin.ServerInfo{}, err
+		}
+	}
+	return info, nil
+}
+
+// parseServerVersion determines the flavor and version of a server from the
+// output of VERSION() and @@version_comment, and the Aurora version if the
+// server reported one.
+func parseServerVersion(version, comment, auroraVersion string) (dbadmin.ServerInfo, error) {
+	info := dbadmin.ServerInfo{Raw: version}
+
+	var match []string
+	switch {
+	case auroraVersion != "":
+		info.Flavor = dbadmin.FlavorAuroraMySQL
+		match = leadingVersion.FindStringSubmatch(auroraVersion)
+	case strings.Contains(version, "TiDB"):
+		info.Flavor = dbadmin.FlavorTiDB
+		match = tidbVersion.FindStringSubmatch(version)
+	case strings.Contains(version, "MariaDB") || strings.Contains(comment, "MariaDB"):
+		info.Flavor = dbadmin.FlavorMariaDB
+		match = mariaDBVersion.FindStringSubmatch(version)
+	default:
+		info.Flavor = dbadmin.FlavorMySQL
+		match = leadingVersion.FindStringSubmatch(version)
+	}
+
+	if match == nil {
+		return dbadmin.ServerInfo{}, fmt.Errorf("Unable to parse %s server version: %s", info.Flavor, version)
+	}
+	info.Version = match[1]
+	info.Features = serverFeatures(info)
+	info.MaxUsernameLength = maxUsernameLength(info)
+	return info, nil
+}
+
+// featureVersions contains the first version of each flavor which supports
+// each feature, a feature missing from a flavor is never supported
+var featureVersions = map[dbadmin.ServerFlavor]map[dbadmin.ServerFeature]string{
+	dbadmin.FlavorMySQL: {
+		dbadmin.FeatureRoles:              "8.0.0",
+		dbadmin.FeatureInstantDDL:         "8.0.12",
+		dbadmin.FeatureCheckConstraints:   "8.0.16",
+		dbadmin.FeatureUserAttributes:     "8.0.21",
+		dbadmin.FeaturePersistedVariables: "8.0.11",
+		dbadmin.FeatureDualPasswords:      "8.0.14",
+	},
+	dbadmin.FlavorMariaDB: {
+		dbadmin.FeatureRoles:            "10.0.5",
+		dbadmin.FeatureInstantDDL:       "10.3.2",
+		dbadmin.FeatureCheckConstraints: "10.2.1",
+	},
+	dbadmin.FlavorTiDB: {
+		dbadmin.FeatureRoles:            "3.0.0",
+		dbadmin.FeatureInstantDDL:       "2.1.0",
+		dbadmin.FeatureCheckConstraints: "7.2.0",
+		dbadmin.FeatureUserAttributes:   "7.0.0",
+	},
+	dbadmin.FlavorAuroraMySQL: {
+		dbadmin.FeatureRoles:            "3.0.0",
+		dbadmin.FeatureInstantDDL:       "3.0.0",
+		dbadmin.FeatureCheckConstraints: "3.0.0",
+		dbadmin.FeatureUserAttributes:   "3.0.0",
+		dbadmin.FeatureDualPasswords:    "3.0.0",
+	},
+}
+
+func serverFeatures(info dbadmin.ServerInfo) []dbadmin.ServerFeature {
+	var features []dbadmin.ServerFeature
+	for _, feature := range []dbadmin.ServerFeature{
+		dbadmin.FeatureRoles,
+		dbadmin.FeatureInstantDDL,
+		dbadmin.FeatureCheckConstraints,
+		dbadmin.FeatureUserAttributes,
+		dbadmin.FeaturePersistedVariables,
+		dbadmin.FeatureDualPasswords,
+	} {
+		since, ok := featureVersions[info.Flavor][feature]
+		if ok && dbadmin.CompareVersions(info.Version, since) >= 0 {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+// maxUsernameLength returns the username limit of the server, MySQL raised
+// it from 16 characters in 5.7.8
+func maxUsernameLength(info dbadmin.ServerInfo) int {
+	switch {
+	case info.Flavor == dbadmin.FlavorMariaDB:
+		return 80
+	case info.Flavor == dbadmin.FlavorMySQL && dbadmin.CompareVersions(info.Version, "5.7.8") < 0:
+		return 16
+	default:
+		return 32
+	}
+}
+
+// usernameLimit returns the username limit of the server, assuming the
+// limit of current MySQL versions if the server hasn't been detected
+func (mdba *MySQLDbAdmin) usernameLimit() int {
+	if mdba.server == nil {
+		return 32
+	}
+	return maxUsernameLength(*mdba.server)
+}
+
+// checkSupportedVersion returns an error if the server is older than the
+// minimum supported version of its flavor
+func checkSupportedVersion(info dbadmin.ServerInfo) error {
+	minimum, ok := minimumVersions[info.Flavor]
+	if !ok {
+		return fmt.Errorf("Unsupported server flavor: %s", info.Flavor)
+	}
+	if dbadmin.CompareVersions(info.Version, minimum) < 0 {
+		return fmt.Errorf("Unsupported %s version %s, the minimum supported version is %s", info.Flavor, info.Version, minimum)
+	}
+	return nil
+}
+
+// flavor returns the detected flavor of the server, assuming MySQL if the
+// server hasn't been detected
+func (mdba *MySQLDbAdmin) flavor() dbadmin.ServerFlavor {
+	if mdba.server == nil {
+		return dbadmin.FlavorMySQL
+	}
+	return mdba.server.Flavor
+}
+
+// hasFeature returns true if the server has been detected and supports the
+// feature
+func (mdba *MySQLDbAdmin) hasFeature(feature dbadmin.ServerFeature) bool {
+	return mdba.server != nil && mdba.server.HasFeature(feature)
+}
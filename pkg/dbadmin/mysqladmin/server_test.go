@@ -0,0 +1,94 @@
+package mysqladmin
+
+import (
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	testCases := []struct {
+		version       string
+		comment       string
+		auroraVersion string
+		flavor        dbadmin.ServerFlavor
+		expected      string
+	}{
+		{"8.0.32", "MySQL Community Server - GPL", "", dbadmin.FlavorMySQL, "8.0.32"},
+		{"5.7.41-log", "MySQL Community Server (GPL)", "", dbadmin.FlavorMySQL, "5.7.41"},
+		{"8.0.32-24", "Percona Server (GPL), Release 24", "", dbadmin.FlavorMySQL, "8.0.32"},
+		{"10.6.12-MariaDB-log", "MariaDB Server", "", dbadmin.FlavorMariaDB, "10.6.12"},
+		{"5.5.5-10.3.38-MariaDB-0+deb10u1", "Debian 10", "", dbadmin.FlavorMariaDB, "10.3.38"},
+		{"5.7.25-TiDB-v6.5.0", "TiDB Server (Apache License 2.0)", "", dbadmin.FlavorTiDB, "6.5.0"},
+		{"8.0.23", "Source distribution", "3.03.0", dbadmin.FlavorAuroraMySQL, "3.03.0"},
+	}
+
+	for _, tc := range testCases {
+		info, err := parseServerVersion(tc.version, tc.comment, tc.auroraVersion)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.version, err)
+			continue
+		}
+		if info.Flavor != tc.flavor || info.Version != tc.expected || info.Raw != tc.version {
+			t.Errorf("%s: unexpected server info: %+v", tc.version, info)
+		}
+	}
+
+	mysql57, _ := parseServerVersion("5.7.41", "", "")
+	mysql8, _ := parseServerVersion("8.0.32", "", "")
+	if mysql57.HasFeature(dbadmin.FeatureRoles) || !mysql8.HasFeature(dbadmin.FeatureRoles) {
+		t.Errorf("Roles should only be detected for MySQL 8: %v %v", mysql57.Features, mysql8.Features)
+	}
+
+	for version, limit := range map[string]int{"5.7.7": 16, "5.7.8": 32, "8.0.32": 32, "10.6.12-MariaDB": 80} {
+		if info, _ := parseServerVersion(version, "", ""); info.MaxUsernameLength != limit {
+			t.Errorf("%s: expected a username limit of %d, got %d", version, limit, info.MaxUsernameLength)
+		}
+	}
+
+	if _, err := parseServerVersion("unknown", "", ""); err == nil {
+		t.Errorf("Expected an error for an unparseable version")
+	}
+}
+
+func TestCheckSupportedVersion(t *testing.T) {
+	testCases := []struct {
+		info      dbadmin.ServerInfo
+		supported bool
+	}{
+		{dbadmin.ServerInfo{Flavor: dbadmin.FlavorMySQL, Version: "5.7.0"}, true},
+		{dbadmin.ServerInfo{Flavor: dbadmin.FlavorMySQL, Version: "5.6.51"}, false},
+		{dbadmin.ServerInfo{Flavor: dbadmin.FlavorMariaDB, Version: "10.11.2"}, true},
+		{dbadmin.ServerInfo{Flavor: dbadmin.FlavorMariaDB, Version: "10.2.44"}, false},
+		{dbadmin.ServerInfo{Flavor: dbadmin.FlavorTiDB, Version: "4.0.16"}, false},
+		{dbadmin.ServerInfo{Flavor: dbadmin.FlavorAuroraMySQL, Version: "1.23.4"}, false},
+		{dbadmin.ServerInfo{Flavor: "oracle", Version: "19.0.0"}, false},
+	}
+
+	for _, tc := range testCases {
+		err := checkSupportedVersion(tc.info)
+		if (err == nil) != tc.supported {
+			t.Errorf("%+v: expected supported %v, got error %v", tc.info, tc.supported, err)
+		}
+	}
+}
+
+func TestFlavorSpecificBehavior(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+	admin.server = &dbadmin.ServerInfo{Flavor: dbadmin.FlavorMariaDB, Version: "10.6.12"}
+
+	credentials := []dbadmin.Credentials{
+		{Username: "dba_v1", Password: seededPassword, AuthPlugin: dbadmin.AuthPluginCachingSHA2Password},
+	}
+	if err := admin.WriteCredentialsBatch(credentials); err == nil {
+		t.Errorf("Expected MariaDB to reject caching_sha2_password")
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("No statements should run for an unsupported auth plugin: %v", fake.statements)
+	}
+
+	credentials[0].AuthPlugin = dbadmin.AuthPluginNativePassword
+	if err := admin.WriteCredentialsBatch(credentials); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+var sqlModes = regexp.MustCompile(`^[A-Z_]*(,[A-Z_]+)*$`)
+
+// WithSessionSettings sets the session variables of every connection that
+// the operator opens to the database, so that its statements behave the same
+// whatever the server's defaults are.
+func WithSessionSettings(settings dbadmin.SessionSettings) Option {
+	return func(mdba *MySQLDbAdmin) {
+		mdba.session = &settings
+	}
+}
+
+// wholeSeconds rounds a timeout up, so that a short timeout doesn't become
+// no timeout at all
+func wholeSeconds(timeout time.Duration) string {
+	return strconv.FormatInt(int64((timeout+time.Second-1)/time.Second), 10)
+}
+
+// sessionParams returns the session variables as the driver sends them, each
+// value is written into a SET statement as it is. The statement timeout is
+// only included once the flavor of the server is known.
+func sessionParams(settings dbadmin.SessionSettings, server *dbadmin.ServerInfo) (map[string]string, error) {
+	params := make(map[string]string)
+
+	if settings.SQLMode != "" {
+		mode := strings.ToUpper(strings.Replace(settings.SQLMode, " ", "", -1))
+		if !sqlModes.MatchString(mode) {
+			return nil, fmt.Errorf("Invalid SQL mode: %s", settings.SQLMode)
+		}
+		params["sql_mode"] = "'" + mode + "'"
+	}
+
+	if settings.LockWaitTimeout < 0 || settings.InnoDBLockWaitTimeout < 0 || settings.StatementTimeout < 0 {
+		return nil, fmt.Errorf("Session timeouts may not be negative")
+	}
+	if settings.LockWaitTimeout > 0 {
+		params["lock_wait_timeout"] = wholeSeconds(settings.LockWaitTimeout)
+	}
+	if settings.InnoDBLockWaitTimeout > 0 {
+		params["innodb_lock_wait_timeout"] = wholeSeconds(settings.InnoDBLockWaitTimeout)
+	}
+
+	if settings.StatementTimeout > 0 && server != nil {
+		if server.Flavor == dbadmin.FlavorMariaDB {
+			params["max_statement_time"] = strconv.FormatFloat(settings.StatementTimeout.Seconds(), 'f', 3, 64)
+		} else {
+			params["max_execution_time"] = strconv.FormatInt(int64(settings.StatementTimeout/time.Millisecond), 10)
+		}
+	}
+	return params, nil
+}
+
+// setSessionParams adds the session variables to the parameters of the DSN
+func (mdba *MySQLDbAdmin) setSessionParams() error {
+	params, err := sessionParams(*mdba.session, mdba.server)
+	if err != nil {
+		return err
+	}
+	if mdba.connConfig.Params == nil {
+		mdba.connConfig.Params = make(map[string]string, len(params))
+	}
+	for name, value := range params {
+		mdba.connConfig.Params[name] = value
+	}
+	return nil
+}
+
+// reopenWithSessionParams replaces the connection pool with one whose
+// connections also set the variables which depend on the server's flavor
+func (mdba *MySQLDbAdmin) reopenWithSessionParams() error {
+	if err := mdba.setSessionParams(); err != nil {
+		return err
+	}
+
+	dsn := mdba.connConfig.FormatDSN()
+	handle, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return fmt.Errorf("Unable to open connection to db: %w", redact.Error(wrap(err), dsn, mdba.connConfig.Passwd))
+	}
+
+	if mdba.primary == mdba.handle {
+		mdba.primary = nil
+	}
+	if mdba.handle != nil {
+		mdba.handle.Close()
+	}
+	mdba.handle = handle
+	mdba.configurePool(handle)
+	mdba.trackPool()
+	return nil
+}
@@ -0,0 +1,55 @@
+package mysqladmin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestSessionParams(t *testing.T) {
+	settings := dbadmin.SessionSettings{
+		SQLMode:               "strict_trans_tables, no_zero_date",
+		LockWaitTimeout:       1500 * time.Millisecond,
+		InnoDBLockWaitTimeout: 10 * time.Second,
+		StatementTimeout:      30 * time.Second,
+	}
+
+	// The statement timeout waits for the flavor to be known
+	params, err := sessionParams(settings, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"sql_mode":                 "'STRICT_TRANS_TABLES,NO_ZERO_DATE'",
+		"lock_wait_timeout":        "2",
+		"innodb_lock_wait_timeout": "10",
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("Unexpected params: %v", params)
+	}
+
+	for flavor, variable := range map[dbadmin.ServerFlavor][2]string{
+		dbadmin.FlavorMySQL:   {"max_execution_time", "30000"},
+		dbadmin.FlavorTiDB:    {"max_execution_time", "30000"},
+		dbadmin.FlavorMariaDB: {"max_statement_time", "30.000"},
+	} {
+		params, err := sessionParams(settings, &dbadmin.ServerInfo{Flavor: flavor})
+		if err != nil || params[variable[0]] != variable[1] {
+			t.Errorf("%s: unexpected params %v (%v)", flavor, params, err)
+		}
+	}
+}
+
+func TestSessionParamsRejectInvalid(t *testing.T) {
+	for _, settings := range []dbadmin.SessionSettings{
+		{SQLMode: "TRADITIONAL'; DROP DATABASE quay; '"},
+		{SQLMode: "ANSI,,"},
+		{LockWaitTimeout: -time.Second},
+	} {
+		if _, err := sessionParams(settings, nil); err == nil {
+			t.Errorf("Expected an error for %+v", settings)
+		}
+	}
+}
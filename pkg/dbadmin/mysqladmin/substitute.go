@@ -0,0 +1,238 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// maxIdentifierLength is the longest database, table, or column name that
+// MySQL will accept
+const maxIdentifierLength = 64
+
+var unquotedIdentifier = regexp.MustCompile(`^[0-9a-zA-Z$_]+$`)
+var allDigits = regexp.MustCompile(`^[0-9]+$`)
+
+// segment is either a literal piece of a statement template, or a reference
+// to one of the values which should be substituted into it
+type segment struct {
+	literal  string
+	argIndex int
+}
+
+// indirectStatement contains everything required to assemble a statement
+// within the server without any of the substituted values ever being
+// interpreted as SQL by the client.
+type indirectStatement struct {
+	// assign binds every literal and value to a session variable in one
+	// round trip, e.g. SET @a := ?, @b := ?
+	assign     string
+	assignArgs []interface{}
+
+	// concat assembles the final statement from the session variables
+	concat  string
+	stmtVar string
+}
+
+func validateIdentifier(identifier string) error {
+	if len(identifier) == 0 || len(identifier) > maxIdentifierLength {
+		return fmt.Errorf("Identifier must be between 1 and %d characters long", maxIdentifierLength)
+	}
+	if !unquotedIdentifier.MatchString(identifier) || allDigits.MatchString(identifier) {
+		return fmt.Errorf("Identifier %q contains characters which require quoting", identifier)
+	}
+	return nil
+}
+
+// validateQuotedIdentifier checks the rules which MySQL applies to quoted
+// identifiers, which may otherwise contain any character
+func validateQuotedIdentifier(identifier string) error {
+	if len(identifier) == 0 || utf8.RuneCountInString(identifier) > maxIdentifierLength {
+		return fmt.Errorf("Identifier must be between 1 and %d characters long", maxIdentifierLength)
+	}
+	if !utf8.ValidString(identifier) {
+		return fmt.Errorf("Identifier is not valid UTF-8")
+	}
+	for _, r := range identifier {
+		if r == 0 || r > 0xFFFF {
+			return fmt.Errorf("Identifier %q contains characters outside of the range MySQL permits", identifier)
+		}
+	}
+	if strings.HasSuffix(identifier, " ") {
+		return fmt.Errorf("Identifier %q may not end with a space", identifier)
+	}
+	return nil
+}
+
+// quoteIdentifier wraps an identifier in backticks, doubling any backticks
+// which it contains
+func quoteIdentifier(identifier string) string {
+	return "`" + strings.Replace(identifier, "`", "``", -1) + "`"
+}
+
+// substitutedValue returns the text which is bound to the session variable
+// for the specified value
+func substitutedValue(arg sqlValue) string {
+	if arg.kind == identifierValue {
+		return quoteIdentifier(*arg.value)
+	}
+	return *arg.value
+}
+
+func validateValue(value string) error {
+	if !utf8.ValidString(value) {
+		return fmt.Errorf("Value is not valid UTF-8")
+	}
+	return nil
+}
+
+// parseTemplate splits a statement template into literal segments and value
+// references, and validates the values. Only the %s and %% verbs are allowed.
+func parseTemplate(format string, args []sqlValue) ([]segment, error) {
+	var segments []segment
+	var literal strings.Builder
+	nextArg := 0
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal.WriteByte(format[i])
+			continue
+		}
+
+		if i+1 >= len(format) {
+			return nil, fmt.Errorf("Template ends with an incomplete verb")
+		}
+		i++
+
+		switch format[i] {
+		case '%':
+			literal.WriteByte('%')
+		case 's':
+			if nextArg >= len(args) {
+				return nil, fmt.Errorf("Template references more values than were supplied")
+			}
+			if literal.Len() > 0 {
+				segments = append(segments, segment{literal: literal.String(), argIndex: -1})
+				literal.Reset()
+			}
+			segments = append(segments, segment{argIndex: nextArg})
+			nextArg++
+		default:
+			return nil, fmt.Errorf("Template contains unsupported verb %%%c", format[i])
+		}
+	}
+
+	if literal.Len() > 0 {
+		segments = append(segments, segment{literal: literal.String(), argIndex: -1})
+	}
+
+	if nextArg != len(args) {
+		return nil, fmt.Errorf("Template references %d values but %d were supplied", nextArg, len(args))
+	}
+
+	for _, arg := range args {
+		if arg.value == nil {
+			return nil, fmt.Errorf("Values may not be nil")
+		}
+		var err error
+		switch arg.kind {
+		case quotedValue:
+			err = validateValue(*arg.value)
+		case identifierValue:
+			err = validateQuotedIdentifier(*arg.value)
+		default:
+			err = validateIdentifier(*arg.value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return segments, nil
+}
+
+// buildIndirectStatement produces the statements which assemble the
+// template and values into a single string within the server. Literal parts
+// of the template are bound as variables too, so that no quoting or escaping
+// of the template itself is ever required.
+func buildIndirectStatement(format string, args []sqlValue) (*indirectStatement, error) {
+	segments, err := parseTemplate(format, args)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make([]string, 0, len(segments))
+	assignArgs := make([]interface{}, 0, len(segments))
+	concatArgs := make([]string, 0, len(segments))
+	argVars := make(map[int]string, len(args))
+
+	for _, seg := range segments {
+		if seg.argIndex < 0 {
+			varName := randIdentifier(16)
+			assignments = append(assignments, fmt.Sprintf("@%s := ?", varName))
+			assignArgs = append(assignArgs, seg.literal)
+			concatArgs = append(concatArgs, "@"+varName)
+			continue
+		}
+
+		varName, ok := argVars[seg.argIndex]
+		if !ok {
+			varName = randIdentifier(16)
+			argVars[seg.argIndex] = varName
+			assignments = append(assignments, fmt.Sprintf("@%s := ?", varName))
+			assignArgs = append(assignArgs, substitutedValue(args[seg.argIndex]))
+		}
+
+		if args[seg.argIndex].kind == quotedValue {
+			concatArgs = append(concatArgs, fmt.Sprintf("QUOTE(@%s)", varName))
+		} else {
+			concatArgs = append(concatArgs, "@"+varName)
+		}
+	}
+
+	stmtVar := randIdentifier(16)
+	return &indirectStatement{
+		assign:     "SET " + strings.Join(assignments, ", "),
+		assignArgs: assignArgs,
+		concat:     fmt.Sprintf("SET @%s := CONCAT(%s)", stmtVar, strings.Join(concatArgs, ", ")),
+		stmtVar:    stmtVar,
+	}, nil
+}
+
+// buildPreparedStatement renders the template with native placeholders, for
+// statement types where the server supports binding values directly. It
+// returns false when the template can't be expressed that way.
+func buildPreparedStatement(format string, args []sqlValue) (string, []interface{}, bool, error) {
+	switch dbadmin.StatementCategory(format) {
+	case dbadmin.CategoryQuery, dbadmin.CategoryDML:
+	default:
+		return "", nil, false, nil
+	}
+
+	segments, err := parseTemplate(format, args)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	var query strings.Builder
+	values := make([]interface{}, 0, len(args))
+	for _, seg := range segments {
+		if seg.argIndex < 0 {
+			query.WriteString(seg.literal)
+			continue
+		}
+
+		arg := args[seg.argIndex]
+		if arg.kind != quotedValue {
+			// Identifiers can never be bound as placeholders
+			return "", nil, false, nil
+		}
+		query.WriteString("?")
+		values = append(values, *arg.value)
+	}
+
+	return query.String(), values, true, nil
+}
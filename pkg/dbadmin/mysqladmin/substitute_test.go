@@ -0,0 +1,216 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+var hostileValues = []string{
+	``,
+	`"`,
+	`'`,
+	`\`,
+	`\'`,
+	`\"`,
+	`%s`,
+	`%%`,
+	`"); DROP DATABASE quay; --`,
+	`'); DROP USER root; --`,
+	"\x00",
+	"\x1a",
+	"new\nline",
+	`@`,
+	`), QUOTE(@var00`,
+	`ユーザー`,
+}
+
+var assignVar = regexp.MustCompile(`@(var[0-9a-f]+) := \?`)
+var concatExpr = regexp.MustCompile(`^SET @(var[0-9a-f]+) := CONCAT\((.*)\)$`)
+
+// mysqlQuote mirrors the behavior of the QUOTE() function in MySQL
+func mysqlQuote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\x00", `\0`, "\x1a", `\Z`)
+	return "'" + replacer.Replace(value) + "'"
+}
+
+// evaluate simulates the server assembling an indirectStatement
+func evaluate(t *testing.T, stmt *indirectStatement) string {
+	t.Helper()
+
+	vars := make(map[string]string)
+	matches := assignVar.FindAllStringSubmatch(stmt.assign, -1)
+	if len(matches) != len(stmt.assignArgs) {
+		t.Fatalf("Assignment %q binds %d variables but has %d values", stmt.assign, len(matches), len(stmt.assignArgs))
+	}
+	for i, match := range matches {
+		vars[match[1]] = stmt.assignArgs[i].(string)
+	}
+
+	concat := concatExpr.FindStringSubmatch(stmt.concat)
+	if concat == nil || concat[1] != stmt.stmtVar {
+		t.Fatalf("Unexpected concat statement: %q", stmt.concat)
+	}
+
+	var rendered strings.Builder
+	for _, expr := range strings.Split(concat[2], ", ") {
+		if strings.HasPrefix(expr, "QUOTE(@") {
+			rendered.WriteString(mysqlQuote(vars[strings.TrimSuffix(strings.TrimPrefix(expr, "QUOTE(@"), ")")]))
+		} else {
+			rendered.WriteString(vars[strings.TrimPrefix(expr, "@")])
+		}
+	}
+	return rendered.String()
+}
+
+func checkSubstitution(t *testing.T, username, password, database string) bool {
+	t.Helper()
+
+	format := `GRANT SELECT ON %s.* TO %s@'%%' IDENTIFIED BY %s /* "literal" \ quotes */`
+	stmt, err := buildIndirectStatement(format, []sqlValue{noquote(database), quoted(username), quoted(password)})
+	if err != nil {
+		t.Errorf("Unexpected error for username %q, password %q: %v", username, password, err)
+		return false
+	}
+
+	for _, value := range []string{username, password} {
+		if len(value) > 2 && (strings.Contains(stmt.assign, value) || strings.Contains(stmt.concat, value)) {
+			t.Errorf("Value %q was interpolated into client side SQL", value)
+			return false
+		}
+	}
+
+	expected := fmt.Sprintf(`GRANT SELECT ON %s.* TO %s@'%%' IDENTIFIED BY %s /* "literal" \ quotes */`, database, mysqlQuote(username), mysqlQuote(password))
+	if rendered := evaluate(t, stmt); rendered != expected {
+		t.Errorf("Rendered statement %q, expected %q", rendered, expected)
+		return false
+	}
+	return true
+}
+
+func TestIndirectSubstituteHostileValues(t *testing.T) {
+	for _, value := range hostileValues {
+		checkSubstitution(t, value, value, "quay")
+	}
+}
+
+func TestIndirectSubstituteRandomValues(t *testing.T) {
+	property := func(username, password string) bool {
+		if err := validateValue(username); err != nil {
+			return true
+		}
+		if err := validateValue(password); err != nil {
+			return true
+		}
+		return checkSubstitution(t, username, password, "quay")
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIndirectSubstituteRejectsHostileIdentifiers(t *testing.T) {
+	for _, value := range append(hostileValues, "12345", strings.Repeat("a", maxIdentifierLength+1), "my-app", "my.app") {
+		if _, err := buildIndirectStatement("GRANT ALL ON %s.* TO 'user'", []sqlValue{noquote(value)}); err == nil {
+			t.Errorf("Identifier %q should have been rejected", value)
+		}
+	}
+}
+
+func TestIndirectSubstituteRandomIdentifiers(t *testing.T) {
+	property := func(database string) bool {
+		_, err := buildIndirectStatement("GRANT ALL ON %s.* TO 'user'", []sqlValue{noquote(database)})
+		return (err == nil) == (validateIdentifier(database) == nil) && (err != nil || unquotedIdentifier.MatchString(database))
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseTemplateRejectsMalformedTemplates(t *testing.T) {
+	templates := []struct {
+		format string
+		args   []sqlValue
+	}{
+		{"DROP USER %s", nil},
+		{"DROP USER", []sqlValue{quoted("user")}},
+		{"DROP USER %d", []sqlValue{quoted("user")}},
+		{"DROP USER %", nil},
+		{"DROP USER %s", []sqlValue{{}}},
+		{"DROP USER %s", []sqlValue{quoted("\xff\xfe")}},
+	}
+
+	for _, template := range templates {
+		if _, err := parseTemplate(template.format, template.args); err == nil {
+			t.Errorf("Template %q should have been rejected", template.format)
+		}
+	}
+}
+
+func TestBuildPreparedStatement(t *testing.T) {
+	query, values, ok, err := buildPreparedStatement("SELECT COUNT(*) FROM t WHERE user = %s AND host LIKE '%%'", []sqlValue{quoted(`"'; --`)})
+	if err != nil || !ok {
+		t.Fatalf("Expected a prepared statement, got ok=%v err=%v", ok, err)
+	}
+	if query != "SELECT COUNT(*) FROM t WHERE user = ? AND host LIKE '%'" || len(values) != 1 || values[0] != `"'; --` {
+		t.Errorf("Unexpected prepared statement %q %v", query, values)
+	}
+
+	if _, _, ok, _ := buildPreparedStatement("CREATE USER %s", []sqlValue{quoted("user")}); ok {
+		t.Errorf("CREATE USER does not support placeholders")
+	}
+	if _, _, ok, _ := buildPreparedStatement("SELECT * FROM %s", []sqlValue{noquote("t")}); ok {
+		t.Errorf("Identifiers can not be bound as placeholders")
+	}
+}
+
+func TestIndirectSubstituteQuotedIdentifiers(t *testing.T) {
+	identifiers := map[string]string{
+		"quay":         "`quay`",
+		"my-app.prod":  "`my-app.prod`",
+		"select":       "`select`",
+		"12345":        "`12345`",
+		"with`tick":    "`with``tick`",
+		"with space":   "`with space`",
+		`"); DROP --`:  "`\"); DROP --`",
+		"データベース":       "`データベース`",
+		"%s":           "`%s`",
+		"back\\slash'": "`back\\slash'`",
+	}
+
+	for name, expected := range identifiers {
+		stmt, err := buildIndirectStatement("GRANT SELECT ON %s.* TO %s", []sqlValue{identifier(name), quoted("user")})
+		if err != nil {
+			t.Errorf("Unexpected error for identifier %q: %v", name, err)
+			continue
+		}
+		if rendered := evaluate(t, stmt); rendered != "GRANT SELECT ON "+expected+".* TO 'user'" {
+			t.Errorf("Identifier %q rendered as %q", name, rendered)
+		}
+	}
+}
+
+func TestIndirectSubstituteRejectsInvalidQuotedIdentifiers(t *testing.T) {
+	invalid := []string{
+		"",
+		strings.Repeat("a", maxIdentifierLength+1),
+		"trailing ",
+		"nul\x00byte",
+		"\xff\xfe",
+		"emoji\U0001F600",
+	}
+
+	for _, name := range invalid {
+		if _, err := buildIndirectStatement("GRANT SELECT ON %s.* TO 'user'", []sqlValue{identifier(name)}); err == nil {
+			t.Errorf("Identifier %q should have been rejected", name)
+		}
+	}
+
+	if err := validateQuotedIdentifier(strings.Repeat("é", maxIdentifierLength)); err != nil {
+		t.Errorf("Identifier length should be measured in characters: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package mysqladmin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+// Kinds of tunnel which a database can be reached through
+const (
+	TunnelSSH    = "ssh"
+	TunnelSOCKS5 = "socks5"
+)
+
+const tunnelDialTimeout = 10 * time.Second
+
+// TunnelOptions describes a bastion or proxy which the database can only be
+// reached through
+type TunnelOptions struct {
+	// Type is TunnelSSH or TunnelSOCKS5
+	Type string
+
+	// Address is the host:port of the bastion or proxy
+	Address string
+	User    string
+
+	// PrivateKey and KnownHosts authenticate an SSH bastion and the client
+	// to it. KnownHosts is a line of a known_hosts file, or a public key.
+	PrivateKey []byte
+	KnownHosts []byte
+
+	// Password authenticates to a SOCKS proxy
+	Password string
+}
+
+// WithTunnel connects to the database through an SSH bastion or a SOCKS
+// proxy. The tunnel is opened when the admin is created and closed with it.
+func WithTunnel(tunnel TunnelOptions) Option {
+	return func(mdba *MySQLDbAdmin) {
+		mdba.tunnelOptions = &tunnel
+	}
+}
+
+// tunnel is an open tunnel, whose connections the driver dials under name
+type tunnel struct {
+	name   string
+	closer io.Closer
+}
+
+var (
+	tunnelsLock sync.Mutex
+
+	// tunnelDials contains the dial function of each open tunnel. The driver
+	// can't forget a registered network, so the names of closed tunnels are
+	// reused rather than registering a new one for every admin.
+	tunnelDials     = make(map[string]func(addr string) (net.Conn, error))
+	freeTunnelNames []string
+	tunnelNames     int
+)
+
+// openTunnel connects to the bastion or proxy and registers its dial
+// function with the driver
+func openTunnel(options TunnelOptions) (*tunnel, error) {
+	if options.Address == "" {
+		return nil, errors.New("A tunnel requires the address of its bastion or proxy")
+	}
+
+	var dial func(addr string) (net.Conn, error)
+	var closer io.Closer
+	switch options.Type {
+	case TunnelSSH:
+		config, err := sshClientConfig(options)
+		if err != nil {
+			return nil, err
+		}
+		client, err := ssh.Dial(networkTCP, options.Address, config)
+		if err != nil {
+			// The bastion may be restarting, as a database would
+			return nil, xerrors.NewTempErrorf("Unable to connect to SSH bastion (%s): %s", options.Address, err)
+		}
+		dial = func(addr string) (net.Conn, error) {
+			return client.Dial(networkTCP, addr)
+		}
+		closer = client
+	case TunnelSOCKS5:
+		var auth *proxy.Auth
+		if options.User != "" {
+			auth = &proxy.Auth{User: options.User, Password: options.Password}
+		}
+		dialer, err := proxy.SOCKS5(networkTCP, options.Address, auth, &net.Dialer{Timeout: tunnelDialTimeout})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to configure SOCKS proxy (%s): %w", options.Address, err)
+		}
+		dial = func(addr string) (net.Conn, error) {
+			return dialer.Dial(networkTCP, addr)
+		}
+		closer = nopCloser{}
+	default:
+		return nil, fmt.Errorf("Unknown tunnel type: %s", options.Type)
+	}
+
+	tunnelsLock.Lock()
+	defer tunnelsLock.Unlock()
+
+	var name string
+	if count := len(freeTunnelNames); count > 0 {
+		name = freeTunnelNames[count-1]
+		freeTunnelNames = freeTunnelNames[:count-1]
+	} else {
+		tunnelNames++
+		name = fmt.Sprintf("tunnel-%d", tunnelNames)
+		mysql.RegisterDial(name, func(addr string) (net.Conn, error) {
+			return dialTunnel(name, addr)
+		})
+	}
+	tunnelDials[name] = dial
+	return &tunnel{name: name, closer: closer}, nil
+}
+
+func dialTunnel(name, addr string) (net.Conn, error) {
+	tunnelsLock.Lock()
+	dial, ok := tunnelDials[name]
+	tunnelsLock.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("Tunnel %s has been closed", name)
+	}
+	return dial(addr)
+}
+
+// Close closes the tunnel and frees its name for the next tunnel. The
+// connections which were dialed through it are closed as well.
+func (t *tunnel) Close() error {
+	tunnelsLock.Lock()
+	delete(tunnelDials, t.name)
+	freeTunnelNames = append(freeTunnelNames, t.name)
+	tunnelsLock.Unlock()
+
+	return t.closer.Close()
+}
+
+// sshClientConfig authenticates with the private key, and only accepts the
+// bastion's known host key
+func sshClientConfig(options TunnelOptions) (*ssh.ClientConfig, error) {
+	if options.User == "" || len(options.PrivateKey) == 0 {
+		return nil, errors.New("An SSH tunnel requires a user and a private key")
+	}
+	signer, err := ssh.ParsePrivateKey(options.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse SSH private key: %w", err)
+	}
+	hostKey, err := parseHostKey(options.KnownHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ssh.ClientConfig{
+		User:            options.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+		Timeout:         tunnelDialTimeout,
+	}, nil
+}
+
+// parseHostKey accepts a line of a known_hosts file, as printed by
+// ssh-keyscan, or a public key in authorized_keys format
+func parseHostKey(knownHosts []byte) (ssh.PublicKey, error) {
+	if len(strings.TrimSpace(string(knownHosts))) == 0 {
+		return nil, errors.New("An SSH tunnel requires the known host key of the bastion")
+	}
+	if _, _, key, _, _, err := ssh.ParseKnownHosts(knownHosts); err == nil {
+		return key, nil
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey(knownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse the known host key of the bastion: %w", err)
+	}
+	return key, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
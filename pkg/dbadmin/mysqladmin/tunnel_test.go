@@ -0,0 +1,83 @@
+package mysqladmin
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestTunnelNamesAreReused(t *testing.T) {
+	options := TunnelOptions{Type: TunnelSOCKS5, Address: "proxy.example.com:1080"}
+
+	first, err := openTunnel(options)
+	if err != nil {
+		t.Fatalf("Unable to open tunnel: %v", err)
+	}
+	second, err := openTunnel(options)
+	if err != nil {
+		t.Fatalf("Unable to open tunnel: %v", err)
+	}
+	if first.name == second.name {
+		t.Errorf("Open tunnels must have their own names: %s", first.name)
+	}
+
+	first.Close()
+	if _, err := dialTunnel(first.name, "db.example.com:3306"); err == nil {
+		t.Error("Expected dialing through a closed tunnel to fail")
+	}
+
+	third, err := openTunnel(options)
+	if err != nil {
+		t.Fatalf("Unable to open tunnel: %v", err)
+	}
+	if third.name != first.name {
+		t.Errorf("Expected the name of the closed tunnel to be reused, got %s", third.name)
+	}
+	second.Close()
+	third.Close()
+}
+
+func TestOpenTunnelRejectsInvalid(t *testing.T) {
+	invalid := []TunnelOptions{
+		{Type: TunnelSOCKS5},
+		{Type: "vpn", Address: "vpn.example.com:1194"},
+		{Type: TunnelSSH, Address: "bastion.example.com:22", User: "dba"},
+		{Type: TunnelSSH, Address: "bastion.example.com:22", User: "dba", PrivateKey: []byte("not a key"), KnownHosts: []byte("bastion.example.com ssh-rsa AAAA")},
+	}
+	for _, options := range invalid {
+		if _, err := openTunnel(options); err == nil {
+			t.Errorf("Expected an error opening %+v", options)
+		}
+	}
+}
+
+func TestParseHostKey(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := ssh.NewPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorized := ssh.MarshalAuthorizedKey(public)
+
+	for _, line := range [][]byte{authorized, append([]byte("bastion.example.com "), authorized...)} {
+		key, err := parseHostKey(line)
+		if err != nil {
+			t.Errorf("Unable to parse host key %q: %v", line, err)
+			continue
+		}
+		if !bytes.Equal(key.Marshal(), public.Marshal()) {
+			t.Errorf("Parsed the wrong key from %q", line)
+		}
+	}
+
+	if _, err := parseHostKey([]byte("  \n")); err == nil {
+		t.Error("Expected an error without a host key")
+	}
+}
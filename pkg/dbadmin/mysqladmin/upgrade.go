@@ -0,0 +1,240 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// upgradeTarget describes what changes in a major version of MySQL
+type upgradeTarget struct {
+	// from is the version series which upgrades to the target, majors
+	// can't be skipped
+	from string
+
+	// reservedWords became reserved in the target, so identifiers using them
+	// must be quoted
+	reservedWords []string
+
+	// removedSQLModes make the server refuse to start when set globally,
+	// and the routines defined with them fail when they are called
+	removedSQLModes []string
+
+	// nativePartitioningOnly removes partitioning from engines other than
+	// InnoDB, the tables can't be opened after the upgrade
+	nativePartitioningOnly bool
+
+	// nativePasswordDisabled disables the mysql_native_password plugin by
+	// default, its users can no longer authenticate
+	nativePasswordDisabled bool
+}
+
+var upgradeTargets = map[string]upgradeTarget{
+	"8.0": {
+		from: "5.7",
+		reservedWords: []string{
+			"ARRAY", "CUBE", "CUME_DIST", "DENSE_RANK", "EMPTY", "EXCEPT", "FIRST_VALUE", "FUNCTION",
+			"GROUPING", "GROUPS", "JSON_TABLE", "LAG", "LAST_VALUE", "LATERAL", "LEAD", "MEMBER",
+			"NTH_VALUE", "NTILE", "OF", "OVER", "PERCENT_RANK", "RANK", "RECURSIVE", "ROW", "ROWS",
+			"ROW_NUMBER", "SYSTEM", "WINDOW",
+		},
+		removedSQLModes: []string{
+			"DB2", "MAXDB", "MSSQL", "MYSQL323", "MYSQL40", "NO_AUTO_CREATE_USER", "NO_FIELD_OPTIONS",
+			"NO_KEY_OPTIONS", "NO_TABLE_OPTIONS", "ORACLE", "POSTGRESQL",
+		},
+		nativePartitioningOnly: true,
+	},
+	"8.4": {
+		from:                   "8.0",
+		reservedWords:          []string{"MANUAL", "PARALLEL", "QUALIFY", "TABLESAMPLE"},
+		nativePasswordDisabled: true,
+	},
+}
+
+// Checks which upgrade findings are reported under
+const (
+	checkReservedWords    = "reserved-words"
+	checkUTF8MB3          = "utf8mb3"
+	checkSQLModes         = "sql-modes"
+	checkPartitioning     = "partitioning"
+	checkNativePasswords  = "native-passwords"
+	nativePasswordsPlugin = "mysql_native_password"
+)
+
+// CheckUpgradeReadiness implements DbAdmin
+func (mdba *MySQLDbAdmin) CheckUpgradeReadiness(target string, usernamePrefixes []string) ([]dbadmin.UpgradeFinding, error) {
+	upgrade, ok := upgradeTargets[target]
+	if !ok {
+		supported := make([]string, 0, len(upgradeTargets))
+		for version := range upgradeTargets {
+			supported = append(supported, version)
+		}
+		sort.Strings(supported)
+		return nil, fmt.Errorf("Unable to check readiness for MySQL %s, only upgrades to %s are known", target, strings.Join(supported, ", "))
+	}
+
+	info, err := mdba.DetectServer()
+	if err != nil {
+		return nil, err
+	}
+	if info.Flavor != dbadmin.FlavorMySQL {
+		return nil, fmt.Errorf("Upgrade readiness can only be checked for MySQL, not %s", info.Flavor)
+	}
+	if dbadmin.CompareVersions(info.Version, target) >= 0 {
+		return nil, fmt.Errorf("The server already runs MySQL %s", info.Version)
+	}
+	if dbadmin.CompareVersions(info.Version, upgrade.from) < 0 {
+		return nil, fmt.Errorf("MySQL %s must be upgraded to %s before %s", info.Version, upgrade.from, target)
+	}
+
+	var findings []dbadmin.UpgradeFinding
+
+	const identifiersQuery = "SELECT TABLE_NAME, '' FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? " +
+		"UNION ALL SELECT TABLE_NAME, COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ?"
+	identifiers, err := mdba.queryPairs(identifiersQuery, mdba.database, mdba.database)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, reservedWordFindings(identifiers, upgrade.reservedWords)...)
+
+	const utf8mb3Query = "SELECT TABLE_NAME, COLUMN_NAME FROM information_schema.COLUMNS " +
+		"WHERE TABLE_SCHEMA = ? AND CHARACTER_SET_NAME IN ('utf8', 'utf8mb3') ORDER BY TABLE_NAME, ORDINAL_POSITION"
+	columns, err := mdba.queryPairs(utf8mb3Query, mdba.database)
+	if err != nil {
+		return nil, err
+	}
+	for _, column := range columns {
+		findings = append(findings, dbadmin.UpgradeFinding{
+			Check:   checkUTF8MB3,
+			Object:  column[0] + "." + column[1],
+			Message: "utf8mb3 is deprecated and will be removed, convert the column to utf8mb4",
+		})
+	}
+
+	const modesQuery = "SELECT 'global sql_mode', @@GLOBAL.sql_mode " +
+		"UNION ALL SELECT CONCAT('routine ', ROUTINE_NAME), SQL_MODE FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = ? " +
+		"UNION ALL SELECT CONCAT('trigger ', TRIGGER_NAME), SQL_MODE FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA = ? " +
+		"UNION ALL SELECT CONCAT('event ', EVENT_NAME), SQL_MODE FROM information_schema.EVENTS WHERE EVENT_SCHEMA = ?"
+	modes, err := mdba.queryPairs(modesQuery, mdba.database, mdba.database, mdba.database)
+	if err != nil {
+		return nil, err
+	}
+	for _, mode := range modes {
+		findings = append(findings, removedModeFindings(mode[0], mode[1], upgrade.removedSQLModes)...)
+	}
+
+	if upgrade.nativePartitioningOnly {
+		const partitionedQuery = "SELECT TABLE_NAME, ENGINE FROM information_schema.TABLES " +
+			"WHERE TABLE_SCHEMA = ? AND CREATE_OPTIONS LIKE '%partitioned%' AND ENGINE NOT IN ('InnoDB', 'ndbcluster') ORDER BY TABLE_NAME"
+		tables, err := mdba.queryPairs(partitionedQuery, mdba.database)
+		if err != nil {
+			return nil, err
+		}
+		for _, table := range tables {
+			findings = append(findings, dbadmin.UpgradeFinding{
+				Check:    checkPartitioning,
+				Object:   table[0],
+				Message:  fmt.Sprintf("Partitioned %s tables can't be opened after the upgrade, convert the table to InnoDB or remove its partitioning", table[1]),
+				Blocking: true,
+			})
+		}
+	}
+
+	if upgrade.nativePasswordDisabled {
+		for _, prefix := range usernamePrefixes {
+			const pluginsQuery = "SELECT user, plugin FROM mysql.user WHERE user LIKE ? ORDER BY user"
+			users, err := mdba.queryPairs(pluginsQuery, prefix+"%")
+			if err != nil {
+				return nil, err
+			}
+			for _, user := range users {
+				if user[1] != nativePasswordsPlugin {
+					continue
+				}
+				findings = append(findings, dbadmin.UpgradeFinding{
+					Check:    checkNativePasswords,
+					Object:   user[0],
+					Message:  "mysql_native_password is disabled by default, rotate the user to caching_sha2_password or enable the plugin",
+					Blocking: true,
+				})
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// queryPairs returns the rows of a query which selects two text columns
+func (mdba *MySQLDbAdmin) queryPairs(query string, args ...interface{}) ([][2]string, error) {
+	rows, err := mdba.query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to inspect schema: %w", wrap(err))
+	}
+	defer rows.Close()
+
+	var pairs [][2]string
+	for rows.Next() {
+		var pair [2]string
+		if err := rows.Scan(&pair[0], &pair[1]); err != nil {
+			return nil, fmt.Errorf("Unable to parse schema from result: %w", wrap(err))
+		}
+		pairs = append(pairs, pair)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Result set contained an error: %w", wrap(err))
+	}
+	return pairs, nil
+}
+
+// reservedWordFindings reports the tables, and the columns of tables, whose
+// names are reserved words. Queries which quote them keep working.
+func reservedWordFindings(identifiers [][2]string, words []string) []dbadmin.UpgradeFinding {
+	reserved := make(map[string]bool, len(words))
+	for _, word := range words {
+		reserved[word] = true
+	}
+
+	var findings []dbadmin.UpgradeFinding
+	for _, identifier := range identifiers {
+		table, column := identifier[0], identifier[1]
+		name, object := table, table
+		if column != "" {
+			name, object = column, table+"."+column
+		}
+		if !reserved[strings.ToUpper(name)] {
+			continue
+		}
+		findings = append(findings, dbadmin.UpgradeFinding{
+			Check:   checkReservedWords,
+			Object:  object,
+			Message: fmt.Sprintf("%s is a reserved word, statements which don't quote it will fail", strings.ToUpper(name)),
+		})
+	}
+	return findings
+}
+
+// removedModeFindings reports the removed modes in an object's sql_mode
+func removedModeFindings(object, sqlMode string, removed []string) []dbadmin.UpgradeFinding {
+	set := make(map[string]bool)
+	for _, mode := range strings.Split(sqlMode, ",") {
+		set[strings.ToUpper(strings.TrimSpace(mode))] = true
+	}
+
+	var found []string
+	for _, mode := range removed {
+		if set[mode] {
+			found = append(found, mode)
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return []dbadmin.UpgradeFinding{{
+		Check:    checkSQLModes,
+		Object:   object,
+		Message:  fmt.Sprintf("SQL modes %s have been removed, remove them from the sql_mode", strings.Join(found, ", ")),
+		Blocking: true,
+	}}
+}
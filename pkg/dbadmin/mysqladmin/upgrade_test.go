@@ -0,0 +1,65 @@
+package mysqladmin
+
+import (
+	"testing"
+)
+
+func TestReservedWordFindings(t *testing.T) {
+	identifiers := [][2]string{
+		{"rank", ""},
+		{"repository", ""},
+		{"repository", "window"},
+		{"repository", "name"},
+		{"Lateral", ""},
+	}
+
+	findings := reservedWordFindings(identifiers, upgradeTargets["8.0"].reservedWords)
+	objects := make([]string, len(findings))
+	for i, finding := range findings {
+		objects[i] = finding.Object
+		if finding.Blocking {
+			t.Errorf("Expected reserved words to be warnings: %+v", finding)
+		}
+	}
+
+	expected := []string{"rank", "repository.window", "Lateral"}
+	if len(objects) != len(expected) {
+		t.Fatalf("Unexpected findings: %v", objects)
+	}
+	for i := range expected {
+		if objects[i] != expected[i] {
+			t.Errorf("Unexpected findings: %v", objects)
+		}
+	}
+
+	if findings := reservedWordFindings(identifiers, upgradeTargets["8.4"].reservedWords); len(findings) != 0 {
+		t.Errorf("Unexpected findings for 8.4: %+v", findings)
+	}
+}
+
+func TestRemovedModeFindings(t *testing.T) {
+	removed := upgradeTargets["8.0"].removedSQLModes
+
+	findings := removedModeFindings("global sql_mode", "STRICT_TRANS_TABLES,NO_AUTO_CREATE_USER,ONLY_FULL_GROUP_BY", removed)
+	if len(findings) != 1 || !findings[0].Blocking {
+		t.Fatalf("Expected one blocking finding: %+v", findings)
+	}
+	if findings[0].Message != "SQL modes NO_AUTO_CREATE_USER have been removed, remove them from the sql_mode" {
+		t.Errorf("Unexpected message: %s", findings[0].Message)
+	}
+
+	if findings := removedModeFindings("routine cleanup", "STRICT_TRANS_TABLES,ONLY_FULL_GROUP_BY", removed); len(findings) != 0 {
+		t.Errorf("Unexpected findings: %+v", findings)
+	}
+	if findings := removedModeFindings("trigger audit", "", removed); len(findings) != 0 {
+		t.Errorf("Unexpected findings for an empty sql_mode: %+v", findings)
+	}
+}
+
+func TestCheckUpgradeReadinessRejectsUnknownTarget(t *testing.T) {
+	admin, _ := newFakeAdmin(nil)
+
+	if _, err := admin.CheckUpgradeReadiness("9.0", nil); err == nil {
+		t.Error("Expected an error for an unknown target")
+	}
+}
@@ -0,0 +1,90 @@
+package mysqladmin
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+var (
+	variableName    = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	numericVariable = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+)
+
+// checkVariableName refuses names which can't be written into a statement
+// as they are, system variables can't be passed as parameters
+func checkVariableName(name string) error {
+	if len(name) > maxIdentifierLength || !variableName.MatchString(name) {
+		return fmt.Errorf("Invalid server variable name: %s", name)
+	}
+	return nil
+}
+
+// variableAssignment returns the part of a SET statement which assigns the
+// value, numbers and switches are rejected by some variables when they are
+// quoted, so they are written into the template once validated
+func variableAssignment(scope, name, value string) (string, []sqlValue) {
+	assignment := scope + " " + name + " = "
+	if numericVariable.MatchString(value) {
+		return assignment + value, nil
+	}
+	switch upper := strings.ToUpper(value); upper {
+	case "ON", "OFF":
+		return assignment + upper, nil
+	}
+	return assignment + "%s", []sqlValue{quoted(value)}
+}
+
+// GetServerVariables implements DbAdmin
+func (mdba *MySQLDbAdmin) GetServerVariables(names []string) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		if err := checkVariableName(name); err != nil {
+			return nil, err
+		}
+
+		var value string
+		if err := mdba.queryRow("SELECT @@GLOBAL." + name).Scan(&value); err != nil {
+			return nil, fmt.Errorf("Unable to read server variable (%s): %w", name, wrap(err))
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// SetServerVariables implements DbAdmin
+func (mdba *MySQLDbAdmin) SetServerVariables(values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	scope := "GLOBAL"
+	if mdba.hasFeature(dbadmin.FeaturePersistedVariables) {
+		scope = "PERSIST"
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if err := checkVariableName(name); err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// A single statement changes either every variable or none of them
+	assignments := make([]string, 0, len(names))
+	args := make([]sqlValue, 0, len(names))
+	for _, name := range names {
+		assignment, assignmentArgs := variableAssignment(scope, name, values[name])
+		assignments = append(assignments, assignment)
+		args = append(args, assignmentArgs...)
+	}
+	if err := mdba.exec("SET "+strings.Join(assignments, ", "), args...); err != nil {
+		return fmt.Errorf("Unable to set server variables: %w", err)
+	}
+	return nil
+}
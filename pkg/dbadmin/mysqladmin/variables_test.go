@@ -0,0 +1,55 @@
+package mysqladmin
+
+import (
+	"testing"
+)
+
+func TestSetServerVariables(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	values := map[string]string{
+		"max_connections":       "500",
+		"innodb_strict_mode":    "on",
+		"transaction_isolation": "READ-COMMITTED",
+	}
+	if err := admin.SetServerVariables(values); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "SET GLOBAL innodb_strict_mode = ON, GLOBAL max_connections = 500, GLOBAL transaction_isolation = %s"
+	if len(fake.statements) != 1 || fake.statements[0] != expected {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
+
+func TestVariableAssignment(t *testing.T) {
+	for value, expected := range map[string]string{
+		"500":            "GLOBAL x = 500",
+		"0.5":            "GLOBAL x = 0.5",
+		"off":            "GLOBAL x = OFF",
+		"READ-COMMITTED": "GLOBAL x = 'READ-COMMITTED'",
+		"1; DROP":        "GLOBAL x = '1; DROP'",
+	} {
+		format, args := variableAssignment("GLOBAL", "x", value)
+		rendered, err := renderStatement(format, args, map[string]bool{value: true})
+		if err != nil || rendered != expected {
+			t.Errorf("%s: expected %s, got %s (%v)", value, expected, rendered, err)
+		}
+	}
+}
+
+func TestServerVariablesRejectInvalidNames(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	for _, name := range []string{"", "max_connections; DROP DATABASE quay", "GLOBAL.x", "`quoted`"} {
+		if err := admin.SetServerVariables(map[string]string{name: "1"}); err == nil {
+			t.Errorf("Expected an error for %q", name)
+		}
+		if _, err := admin.GetServerVariables([]string{name}); err == nil {
+			t.Errorf("Expected an error for %q", name)
+		}
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+}
@@ -0,0 +1,81 @@
+package mysqladmin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// maskTemplates contains the select expression template for each column
+// mask, every value of which is the column name
+var maskTemplates = map[dbadmin.ColumnMask]string{
+	dbadmin.MaskNone:   "%s AS %s",
+	dbadmin.MaskRedact: "IF(%s IS NULL, NULL, 'REDACTED') AS %s",
+	dbadmin.MaskHash:   "SHA2(%s, 256) AS %s",
+	dbadmin.MaskNull:   "NULL AS %s",
+}
+
+// WriteMaskedView implements DbAdmin
+func (mdba *MySQLDbAdmin) WriteMaskedView(view dbadmin.MaskedView) error {
+	format, args, err := maskedViewStatement(mdba.database, view)
+	if err != nil {
+		return err
+	}
+
+	if err := mdba.exec(format, args...); err != nil {
+		return fmt.Errorf("Unable to write masked view (%s): %w", view.Name, err)
+	}
+	return nil
+}
+
+// maskedViewStatement builds the statement which defines the view
+func maskedViewStatement(database string, view dbadmin.MaskedView) (string, []sqlValue, error) {
+	if len(view.Columns) == 0 {
+		return "", nil, fmt.Errorf("Masked view (%s) must expose at least one column", view.Name)
+	}
+
+	expressions := make([]string, 0, len(view.Columns))
+	args := []sqlValue{identifier(database), identifier(view.Name)}
+	for _, column := range view.Columns {
+		template, ok := maskTemplates[column.Mask]
+		if !ok {
+			return "", nil, fmt.Errorf("Unknown mask (%s) for column %s of masked view %s", column.Mask, column.Name, view.Name)
+		}
+		expressions = append(expressions, template)
+		for i := 0; i < strings.Count(template, "%s"); i++ {
+			args = append(args, identifier(column.Name))
+		}
+	}
+	args = append(args, identifier(database), identifier(view.Table))
+
+	format := fmt.Sprintf(
+		"CREATE OR REPLACE SQL SECURITY DEFINER VIEW %%s.%%s AS SELECT %s FROM %%s.%%s",
+		strings.Join(expressions, ", "),
+	)
+	return format, args, nil
+}
+
+// GetViewChecksum implements DbAdmin
+func (mdba *MySQLDbAdmin) GetViewChecksum(name string) (string, error) {
+	const viewQuery = "SELECT VIEW_DEFINITION FROM information_schema.VIEWS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?"
+
+	var definition sql.NullString
+	err := mdba.queryRow(viewQuery, mdba.database, name).Scan(&definition)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("Unable to read view (%s): %w", name, wrap(err))
+	}
+	return definitionChecksum(definition.String), nil
+}
+
+// DropView implements DbAdmin
+func (mdba *MySQLDbAdmin) DropView(name string) error {
+	if err := mdba.exec("DROP VIEW IF EXISTS %s.%s", identifier(mdba.database), identifier(name)); err != nil {
+		return fmt.Errorf("Unable to drop view (%s): %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package mysqladmin
+
+import (
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestWriteMaskedView(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	view := dbadmin.MaskedView{
+		Name:  "users_masked",
+		Table: "users",
+		Columns: []dbadmin.ViewColumn{
+			{Name: "id"},
+			{Name: "email", Mask: dbadmin.MaskHash},
+			{Name: "phone", Mask: dbadmin.MaskNull},
+		},
+	}
+	if err := admin.WriteMaskedView(view); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "CREATE OR REPLACE SQL SECURITY DEFINER VIEW %s.%s AS SELECT %s AS %s, SHA2(%s, 256) AS %s, NULL AS %s FROM %s.%s"
+	if len(fake.statements) != 1 || fake.statements[0] != expected {
+		t.Errorf("Unexpected statements: %v", fake.statements)
+	}
+
+	_, args, _ := maskedViewStatement("quay", view)
+	if len(args) != 9 {
+		t.Errorf("Expected a value for every placeholder, got %d", len(args))
+	}
+}
+
+func TestWriteMaskedViewRejectsInvalid(t *testing.T) {
+	admin, fake := newFakeAdmin(nil)
+
+	invalid := []dbadmin.MaskedView{
+		{Name: "empty", Table: "users"},
+		{Name: "unknown", Table: "users", Columns: []dbadmin.ViewColumn{{Name: "email", Mask: "scramble"}}},
+	}
+	for _, view := range invalid {
+		if err := admin.WriteMaskedView(view); err == nil {
+			t.Errorf("%s: expected an error", view.Name)
+		}
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("No statements should be run for invalid views: %v", fake.statements)
+	}
+}
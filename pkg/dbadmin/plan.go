@@ -0,0 +1,109 @@
+package dbadmin
+
+import (
+	"fmt"
+)
+
+// StepKind names an operation which can be part of an AdminPlan
+type StepKind string
+
+const (
+	// StepCheckSchemaVersion fails the plan unless the schema is at Version
+	StepCheckSchemaVersion StepKind = "check-schema-version"
+
+	// StepWriteCredentials creates the users in Credentials, with their
+	// grants
+	StepWriteCredentials StepKind = "write-credentials"
+
+	// StepRotateCredentials replaces the passwords of the users in
+	// Credentials
+	StepRotateCredentials StepKind = "rotate-credentials"
+
+	// StepLockCredentials locks the user named Username
+	StepLockCredentials StepKind = "lock-credentials"
+
+	// StepDeleteCredentials drops the user named Username once it is unused
+	StepDeleteCredentials StepKind = "delete-credentials"
+)
+
+// PlanStep is a single operation of an AdminPlan, only the fields used by
+// its kind are read
+type PlanStep struct {
+	Kind        StepKind
+	Version     string
+	Credentials []Credentials
+	Username    string
+}
+
+// Checkpointer durably records how many steps of a plan have completed, so
+// that an interrupted plan can be resumed by another process
+type Checkpointer interface {
+	// Completed returns the number of steps of the plan which have already
+	// completed, zero if the plan has never been started
+	Completed(planID string) (int, error)
+
+	// Complete records that the first steps of the plan have completed
+	Complete(planID string, steps int) error
+}
+
+// AdminPlan is an ordered list of steps which are executed together. Steps
+// are run in order and the plan stops at the first failure. With a
+// Checkpointer, executing a plan with the same ID again resumes after the
+// last completed step, so the ID must change whenever the steps do. A step
+// which was interrupted is run again, and so every step is either atomic or
+// safe to repeat.
+type AdminPlan struct {
+	ID          string
+	Steps       []PlanStep
+	Checkpoints Checkpointer
+}
+
+// RunPlan executes the plan one step at a time using the individual DbAdmin
+// operations, for backends which have no more efficient way of executing it
+func RunPlan(admin DbAdmin, plan AdminPlan) error {
+	start := 0
+	if plan.Checkpoints != nil {
+		completed, err := plan.Checkpoints.Completed(plan.ID)
+		if err != nil {
+			return fmt.Errorf("Unable to load checkpoint for plan %s: %w", plan.ID, err)
+		}
+		start = completed
+	}
+
+	for i := start; i < len(plan.Steps); i++ {
+		step := plan.Steps[i]
+		if err := runStep(admin, step); err != nil {
+			return fmt.Errorf("Plan %s failed at step %d (%s): %w", plan.ID, i, step.Kind, err)
+		}
+
+		if plan.Checkpoints != nil {
+			if err := plan.Checkpoints.Complete(plan.ID, i+1); err != nil {
+				return fmt.Errorf("Unable to checkpoint step %d of plan %s: %w", i, plan.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func runStep(admin DbAdmin, step PlanStep) error {
+	switch step.Kind {
+	case StepCheckSchemaVersion:
+		version, err := admin.GetSchemaVersion()
+		if err != nil {
+			return err
+		}
+		if version != step.Version {
+			return fmt.Errorf("Schema version is %s, expected %s", version, step.Version)
+		}
+		return nil
+	case StepWriteCredentials:
+		return admin.WriteCredentialsBatch(step.Credentials)
+	case StepRotateCredentials:
+		return admin.RotateCredentials(step.Credentials)
+	case StepLockCredentials:
+		return admin.LockCredentials(step.Username)
+	case StepDeleteCredentials:
+		return admin.VerifyUnusedAndDeleteCredentials(step.Username)
+	}
+	return fmt.Errorf("Unknown plan step: %s", step.Kind)
+}
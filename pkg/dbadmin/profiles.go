@@ -0,0 +1,59 @@
+package dbadmin
+
+// PrivilegeProfile is a preset of the privileges held by the operator's admin
+// user, from which the statements to create that user are generated
+type PrivilegeProfile string
+
+// Supported privilege profiles
+const (
+	// ProfileCredentialsOnly may only issue, rotate and revoke credentials
+	ProfileCredentialsOnly PrivilegeProfile = "credentials-only"
+
+	// ProfileCredentialsMigrations may also run migrations and guard them,
+	// which includes killing the sessions that block them
+	ProfileCredentialsMigrations PrivilegeProfile = "credentials+migrations"
+
+	// ProfileFull may also manage helper routines, masked views, scheduled
+	// statements, partitions and server parameters, and convert the default
+	// character set
+	ProfileFull PrivilegeProfile = "full"
+)
+
+// Operation is a group of changes which the operator makes to a managed
+// database that need the same privileges
+type Operation string
+
+// Operations which are enabled by privilege profiles
+const (
+	OperationCredentials    Operation = "credentials"
+	OperationMigrations     Operation = "migrations"
+	OperationHelperRoutines Operation = "helper-routines"
+	OperationMaskedViews    Operation = "masked-views"
+	OperationScheduling     Operation = "scheduled-statements"
+	OperationPartitioning   Operation = "partitioning"
+	OperationParameters     Operation = "parameters"
+	OperationCharset        Operation = "charset-conversion"
+)
+
+var profileOperations = map[PrivilegeProfile][]Operation{
+	ProfileCredentialsOnly:       {OperationCredentials},
+	ProfileCredentialsMigrations: {OperationCredentials, OperationMigrations},
+	ProfileFull:                  {OperationCredentials, OperationMigrations, OperationHelperRoutines, OperationMaskedViews, OperationScheduling, OperationPartitioning, OperationParameters, OperationCharset},
+}
+
+// Known returns true if the profile is one of the presets
+func (p PrivilegeProfile) Known() bool {
+	_, ok := profileOperations[p]
+	return ok
+}
+
+// Allows returns true if the profile holds the privileges that the operation
+// needs
+func (p PrivilegeProfile) Allows(operation Operation) bool {
+	for _, allowed := range profileOperations[p] {
+		if allowed == operation {
+			return true
+		}
+	}
+	return false
+}
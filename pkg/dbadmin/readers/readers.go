@@ -0,0 +1,68 @@
+// Package readers wraps a DbAdmin so that the checks which don't change the
+// database are answered by a read-only endpoint, such as the reader endpoint
+// of a cluster, keeping their load off the primary.
+package readers
+
+import (
+	"github.com/go-logr/logr"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// readerAdmin sends every change to the primary, which it embeds, and the
+// non-mutating checks to the reader. A check which fails on the reader is
+// retried on the primary.
+type readerAdmin struct {
+	dbadmin.DbAdmin
+	reader dbadmin.DbAdmin
+	log    logr.Logger
+}
+
+// Wrap returns a DbAdmin which answers checks from reader when it is able to,
+// and from primary otherwise
+func Wrap(primary, reader dbadmin.DbAdmin, log logr.Logger) dbadmin.DbAdmin {
+	return &readerAdmin{DbAdmin: primary, reader: reader, log: log}
+}
+
+func (ra *readerAdmin) fallback(operation string, err error) {
+	ra.log.Info("Reader unavailable, falling back to the primary", "operation", operation, "error", err.Error())
+}
+
+// GetSchemaVersion implements DbAdmin
+func (ra *readerAdmin) GetSchemaVersion() (string, error) {
+	version, err := ra.reader.GetSchemaVersion()
+	if err != nil {
+		ra.fallback("GetSchemaVersion", err)
+		return ra.DbAdmin.GetSchemaVersion()
+	}
+	return version, nil
+}
+
+// ExportSchema implements DbAdmin
+func (ra *readerAdmin) ExportSchema() (string, error) {
+	ddl, err := ra.reader.ExportSchema()
+	if err != nil {
+		ra.fallback("ExportSchema", err)
+		return ra.DbAdmin.ExportSchema()
+	}
+	return ddl, nil
+}
+
+// GetCapacityUsage implements DbAdmin
+func (ra *readerAdmin) GetCapacityUsage() (dbadmin.CapacityUsage, error) {
+	usage, err := ra.reader.GetCapacityUsage()
+	if err != nil {
+		ra.fallback("GetCapacityUsage", err)
+		return ra.DbAdmin.GetCapacityUsage()
+	}
+	return usage, nil
+}
+
+// Close implements DbAdmin by closing both the reader and the primary
+func (ra *readerAdmin) Close() error {
+	readerErr := ra.reader.Close()
+	if err := ra.DbAdmin.Close(); err != nil {
+		return err
+	}
+	return readerErr
+}
@@ -0,0 +1,64 @@
+package readers
+
+import (
+	"errors"
+	"testing"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// versionAdmin only implements the methods used by the tests
+type versionAdmin struct {
+	dbadmin.DbAdmin
+	version string
+	err     error
+	calls   int
+}
+
+func (va *versionAdmin) GetSchemaVersion() (string, error) {
+	va.calls++
+	return va.version, va.err
+}
+
+func (va *versionAdmin) WriteCredentials(username, password string) error {
+	va.calls++
+	return nil
+}
+
+func TestChecksUseReader(t *testing.T) {
+	primary := &versionAdmin{version: "v2"}
+	reader := &versionAdmin{version: "v1"}
+	admin := Wrap(primary, reader, logf.NullLogger{})
+
+	if version, err := admin.GetSchemaVersion(); err != nil || version != "v1" {
+		t.Errorf("Expected the reader's version, got %s (%v)", version, err)
+	}
+	if primary.calls != 0 {
+		t.Errorf("The primary must not be queried while the reader is available")
+	}
+}
+
+func TestChecksFallBackToPrimary(t *testing.T) {
+	primary := &versionAdmin{version: "v2"}
+	reader := &versionAdmin{err: errors.New("connection refused")}
+	admin := Wrap(primary, reader, logf.NullLogger{})
+
+	if version, err := admin.GetSchemaVersion(); err != nil || version != "v2" {
+		t.Errorf("Expected the primary's version, got %s (%v)", version, err)
+	}
+}
+
+func TestChangesUsePrimary(t *testing.T) {
+	primary := &versionAdmin{}
+	reader := &versionAdmin{}
+	admin := Wrap(primary, reader, logf.NullLogger{})
+
+	if err := admin.WriteCredentials("dba_v1", "secret"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if primary.calls != 1 || reader.calls != 0 {
+		t.Errorf("Changes must only be sent to the primary")
+	}
+}
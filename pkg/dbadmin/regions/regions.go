@@ -0,0 +1,219 @@
+// Package regions wraps a DbAdmin so that every credential operation is also
+// applied to the primaries of other regions, for databases whose regions are
+// independent primaries which don't replicate their users.
+package regions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// Endpoint is the primary of one region. An endpoint which couldn't be
+// connected to has no Admin, and fails every operation with Unavailable.
+type Endpoint struct {
+	Name        string
+	Admin       dbadmin.DbAdmin
+	Unavailable error
+}
+
+// Result is the outcome of an operation on one endpoint
+type Result struct {
+	Endpoint  string
+	Operation string
+	Err       error
+}
+
+// regionalAdmin runs everything on the primary of the local region, which it
+// embeds, and the credential operations on every endpoint as well. New users
+// are removed from every endpoint again if an endpoint can't create them,
+// the other operations are repeated until every endpoint has applied them.
+type regionalAdmin struct {
+	dbadmin.DbAdmin
+	endpoints []Endpoint
+	observe   func(Result)
+	log       logr.Logger
+}
+
+// Wrap returns a DbAdmin which applies the credential operations to primary
+// and then to each of the endpoints, passing the outcome on each endpoint to
+// observe
+func Wrap(primary dbadmin.DbAdmin, endpoints []Endpoint, observe func(Result), log logr.Logger) dbadmin.DbAdmin {
+	return &regionalAdmin{DbAdmin: primary, endpoints: endpoints, observe: observe, log: log}
+}
+
+func (ra *regionalAdmin) apply(endpoint Endpoint, operation string, change func(dbadmin.DbAdmin) error) error {
+	err := endpoint.Unavailable
+	if endpoint.Admin != nil {
+		err = change(endpoint.Admin)
+	}
+	if ra.observe != nil {
+		ra.observe(Result{Endpoint: endpoint.Name, Operation: operation, Err: err})
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to apply %s on regional primary %s: %w", operation, endpoint.Name, err)
+	}
+	return nil
+}
+
+// fanOut applies a change which is safe to repeat. Nothing else is changed
+// if the primary refuses it, otherwise every endpoint is attempted and the
+// first failure is returned, so that a retry brings the rest up to date.
+func (ra *regionalAdmin) fanOut(operation string, change func(dbadmin.DbAdmin) error) error {
+	if err := change(ra.DbAdmin); err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, endpoint := range ra.endpoints {
+		if err := ra.apply(endpoint, operation, change); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteCredentials implements DbAdmin
+func (ra *regionalAdmin) WriteCredentials(username, password string) error {
+	return ra.WriteCredentialsBatch([]dbadmin.Credentials{{Username: username, Password: password}})
+}
+
+// WriteCredentialsBatch implements DbAdmin by creating the users on every
+// endpoint, or on none of them
+func (ra *regionalAdmin) WriteCredentialsBatch(credentials []dbadmin.Credentials) error {
+	if err := ra.DbAdmin.WriteCredentialsBatch(credentials); err != nil {
+		return err
+	}
+
+	written := []dbadmin.DbAdmin{ra.DbAdmin}
+	for _, endpoint := range ra.endpoints {
+		err := ra.apply(endpoint, "WriteCredentials", func(admin dbadmin.DbAdmin) error {
+			return admin.WriteCredentialsBatch(credentials)
+		})
+		if err != nil {
+			ra.rollback(written, credentials)
+			return err
+		}
+		written = append(written, endpoint.Admin)
+	}
+	return nil
+}
+
+// rollback removes the users which were just created, nobody can be using
+// them yet
+func (ra *regionalAdmin) rollback(written []dbadmin.DbAdmin, credentials []dbadmin.Credentials) {
+	for _, admin := range written {
+		for _, cred := range credentials {
+			if err := admin.VerifyUnusedAndDeleteCredentials(cred.Username); err != nil {
+				ra.log.Error(err, "Unable to remove user after a regional primary failed to create it", "username", cred.Username)
+			}
+		}
+	}
+}
+
+// RotateCredentials implements DbAdmin
+func (ra *regionalAdmin) RotateCredentials(credentials []dbadmin.Credentials) error {
+	return ra.fanOut("RotateCredentials", func(admin dbadmin.DbAdmin) error {
+		return admin.RotateCredentials(credentials)
+	})
+}
+
+// DiscardOldPasswords implements DbAdmin
+func (ra *regionalAdmin) DiscardOldPasswords(usernames []string) error {
+	return ra.fanOut("DiscardOldPasswords", func(admin dbadmin.DbAdmin) error {
+		return admin.DiscardOldPasswords(usernames)
+	})
+}
+
+// ReapplyGrants implements DbAdmin
+func (ra *regionalAdmin) ReapplyGrants(credentials []dbadmin.Credentials) error {
+	return ra.fanOut("ReapplyGrants", func(admin dbadmin.DbAdmin) error {
+		return admin.ReapplyGrants(credentials)
+	})
+}
+
+// RevokePrivileges implements DbAdmin
+func (ra *regionalAdmin) RevokePrivileges(username string, privileges []dbadmin.UserPrivilege) error {
+	return ra.fanOut("RevokePrivileges", func(admin dbadmin.DbAdmin) error {
+		return admin.RevokePrivileges(username, privileges)
+	})
+}
+
+// LockCredentials implements DbAdmin
+func (ra *regionalAdmin) LockCredentials(username string) error {
+	return ra.fanOut("LockCredentials", func(admin dbadmin.DbAdmin) error {
+		return admin.LockCredentials(username)
+	})
+}
+
+// UnlockCredentials implements DbAdmin
+func (ra *regionalAdmin) UnlockCredentials(username string) error {
+	return ra.fanOut("UnlockCredentials", func(admin dbadmin.DbAdmin) error {
+		return admin.UnlockCredentials(username)
+	})
+}
+
+// KillUserSessions implements DbAdmin by killing the sessions on every
+// endpoint, and returns how many were killed in total
+func (ra *regionalAdmin) KillUserSessions(username string) (int, error) {
+	killed := 0
+	err := ra.fanOut("KillUserSessions", func(admin dbadmin.DbAdmin) error {
+		count, err := admin.KillUserSessions(username)
+		killed += count
+		return err
+	})
+	return killed, err
+}
+
+// VerifyUnusedAndDeleteCredentials implements DbAdmin. The endpoints are
+// deleted from before the primary and stop at the first one on which the user
+// is still in use, the endpoints which already dropped the user are skipped
+// when it is retried.
+func (ra *regionalAdmin) VerifyUnusedAndDeleteCredentials(username string) error {
+	for _, endpoint := range ra.endpoints {
+		err := ra.apply(endpoint, "DeleteCredentials", func(admin dbadmin.DbAdmin) error {
+			usernames, err := admin.ListUsernames(username)
+			if err != nil {
+				return err
+			}
+			for _, existing := range usernames {
+				if existing == username {
+					return admin.VerifyUnusedAndDeleteCredentials(username)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return ra.DbAdmin.VerifyUnusedAndDeleteCredentials(username)
+}
+
+// ExecutePlan implements DbAdmin by running the steps one at a time, so that
+// each of them is applied to every endpoint
+func (ra *regionalAdmin) ExecutePlan(plan dbadmin.AdminPlan) error {
+	return dbadmin.RunPlan(ra, plan)
+}
+
+// Close implements DbAdmin by closing the primary and every endpoint
+func (ra *regionalAdmin) Close() error {
+	var failed []string
+	for _, endpoint := range ra.endpoints {
+		if endpoint.Admin != nil {
+			if err := endpoint.Admin.Close(); err != nil {
+				failed = append(failed, endpoint.Name)
+			}
+		}
+	}
+	if err := ra.DbAdmin.Close(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("Unable to close regional primaries: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
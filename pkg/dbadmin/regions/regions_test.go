@@ -0,0 +1,169 @@
+package regions
+
+import (
+	"errors"
+	"testing"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// userAdmin only implements the methods used by the tests
+type userAdmin struct {
+	dbadmin.DbAdmin
+	users map[string]string
+	err   error
+}
+
+func newUserAdmin() *userAdmin {
+	return &userAdmin{users: make(map[string]string)}
+}
+
+func (ua *userAdmin) WriteCredentialsBatch(credentials []dbadmin.Credentials) error {
+	if ua.err != nil {
+		return ua.err
+	}
+	for _, cred := range credentials {
+		ua.users[cred.Username] = cred.Password
+	}
+	return nil
+}
+
+func (ua *userAdmin) RotateCredentials(credentials []dbadmin.Credentials) error {
+	if ua.err != nil {
+		return ua.err
+	}
+	for _, cred := range credentials {
+		ua.users[cred.Username] = cred.Password
+	}
+	return nil
+}
+
+func (ua *userAdmin) ListUsernames(usernamePrefix string) ([]string, error) {
+	var usernames []string
+	for username := range ua.users {
+		usernames = append(usernames, username)
+	}
+	return usernames, nil
+}
+
+func (ua *userAdmin) VerifyUnusedAndDeleteCredentials(username string) error {
+	if _, ok := ua.users[username]; !ok {
+		return errors.New("no such user")
+	}
+	if ua.err != nil {
+		return ua.err
+	}
+	delete(ua.users, username)
+	return nil
+}
+
+func wrap(primary *userAdmin, endpoints map[string]*userAdmin) (dbadmin.DbAdmin, *[]Result) {
+	var results []Result
+	var wrapped []Endpoint
+	for _, name := range []string{"eu", "us"} {
+		if admin, ok := endpoints[name]; ok {
+			wrapped = append(wrapped, Endpoint{Name: name, Admin: admin})
+		}
+	}
+	return Wrap(primary, wrapped, func(result Result) { results = append(results, result) }, logf.NullLogger{}), &results
+}
+
+func TestWriteOnEveryEndpoint(t *testing.T) {
+	primary, eu, us := newUserAdmin(), newUserAdmin(), newUserAdmin()
+	admin, results := wrap(primary, map[string]*userAdmin{"eu": eu, "us": us})
+
+	if err := admin.WriteCredentials("dba_v1", "secret"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, endpoint := range map[string]*userAdmin{"primary": primary, "eu": eu, "us": us} {
+		if endpoint.users["dba_v1"] != "secret" {
+			t.Errorf("Expected the user to be written on %s", name)
+		}
+	}
+	if len(*results) != 2 || (*results)[0].Err != nil || (*results)[1].Err != nil {
+		t.Errorf("Unexpected results: %v", *results)
+	}
+}
+
+func TestWriteRolledBack(t *testing.T) {
+	primary, eu, us := newUserAdmin(), newUserAdmin(), newUserAdmin()
+	us.err = errors.New("connection refused")
+	admin, results := wrap(primary, map[string]*userAdmin{"eu": eu, "us": us})
+
+	if err := admin.WriteCredentials("dba_v1", "secret"); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if len(primary.users) != 0 || len(eu.users) != 0 {
+		t.Errorf("Expected the user to be removed again: %v %v", primary.users, eu.users)
+	}
+	if last := (*results)[len(*results)-1]; last.Endpoint != "us" || last.Err == nil {
+		t.Errorf("Expected the failure to be observed: %v", *results)
+	}
+}
+
+func TestUnavailableEndpoint(t *testing.T) {
+	primary := newUserAdmin()
+	unavailable := errors.New("no route to host")
+	admin := Wrap(primary, []Endpoint{{Name: "eu", Unavailable: unavailable}}, nil, logf.NullLogger{})
+
+	if err := admin.WriteCredentials("dba_v1", "secret"); !errors.Is(err, unavailable) {
+		t.Errorf("Expected the endpoint to be unavailable, got %v", err)
+	}
+	if len(primary.users) != 0 {
+		t.Errorf("Expected the user to be removed from the primary")
+	}
+}
+
+func TestRotateContinuesPastFailure(t *testing.T) {
+	primary, eu, us := newUserAdmin(), newUserAdmin(), newUserAdmin()
+	eu.err = errors.New("read only")
+	admin, _ := wrap(primary, map[string]*userAdmin{"eu": eu, "us": us})
+
+	if err := admin.RotateCredentials([]dbadmin.Credentials{{Username: "dba_v1", Password: "rotated"}}); err == nil {
+		t.Errorf("Expected an error")
+	}
+	if primary.users["dba_v1"] != "rotated" || us.users["dba_v1"] != "rotated" {
+		t.Errorf("Expected the other endpoints to be rotated")
+	}
+}
+
+func TestRotateStopsAtPrimary(t *testing.T) {
+	primary, eu := newUserAdmin(), newUserAdmin()
+	primary.err = errors.New("read only")
+	admin, results := wrap(primary, map[string]*userAdmin{"eu": eu})
+
+	if err := admin.RotateCredentials([]dbadmin.Credentials{{Username: "dba_v1", Password: "rotated"}}); err == nil {
+		t.Errorf("Expected an error")
+	}
+	if len(eu.users) != 0 || len(*results) != 0 {
+		t.Errorf("Nothing may change elsewhere when the primary refuses")
+	}
+}
+
+func TestDeleteRetried(t *testing.T) {
+	primary, eu, us := newUserAdmin(), newUserAdmin(), newUserAdmin()
+	for _, endpoint := range []*userAdmin{primary, eu, us} {
+		endpoint.users["dba_v1"] = "secret"
+	}
+	us.err = errors.New("1 active sessions remaining")
+	admin, _ := wrap(primary, map[string]*userAdmin{"eu": eu, "us": us})
+
+	if err := admin.VerifyUnusedAndDeleteCredentials("dba_v1"); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if _, ok := primary.users["dba_v1"]; !ok {
+		t.Errorf("The primary must keep the user until every endpoint dropped it")
+	}
+
+	us.err = nil
+	if err := admin.VerifyUnusedAndDeleteCredentials("dba_v1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, endpoint := range []*userAdmin{primary, eu, us} {
+		if len(endpoint.users) != 0 {
+			t.Errorf("Expected the user to be deleted everywhere")
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package dbadmin
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// Factory connects to a database of one engine, the DSN is in the engine's
+// own syntax
+type Factory func(dsn string, settings AdminSettings) (DbAdmin, error)
+
+// AdminSettings are what a Factory needs besides the DSN. A backend returns
+// an error for the settings which it doesn't support, rather than ignoring
+// them.
+type AdminSettings struct {
+	MigrationEngine MigrationEngine
+
+	// Log receives the templates of the statements which are sent to the
+	// database
+	Log logr.Logger
+
+	// ObserveRetry is told about every statement which is retried in place,
+	// with the reason for the retry
+	ObserveRetry func(reason string)
+
+	// Session is applied to every session of the operator, if set
+	Session *SessionSettings
+
+	// Parameters are the settings of the connection which only the backend
+	// understands, copied from the ManagedDatabase
+	Parameters map[string]string
+
+	// Options are the options of an in-tree backend which the controllers
+	// build from the ManagedDatabase, each of the backend's own option type
+	Options []interface{}
+}
+
+var (
+	factoriesLock sync.RWMutex
+	factories     = make(map[string]Factory)
+)
+
+// Register makes a backend available to ManagedDatabases whose connection
+// names the engine. Backends compiled into a fork of the operator register
+// themselves from an init function, as the in-tree ones do.
+func Register(engine string, factory Factory) error {
+	if engine == "" || factory == nil {
+		return fmt.Errorf("A backend requires an engine name and a factory")
+	}
+
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+
+	if _, ok := factories[engine]; ok {
+		return fmt.Errorf("A backend is already registered for engine %s", engine)
+	}
+	factories[engine] = factory
+	return nil
+}
+
+// Lookup returns the factory registered for the engine
+func Lookup(engine string) (Factory, bool) {
+	factoriesLock.RLock()
+	defer factoriesLock.RUnlock()
+
+	factory, ok := factories[engine]
+	return factory, ok
+}
+
+// Engines lists the engines which have a registered backend, in order
+func Engines() []string {
+	factoriesLock.RLock()
+	defer factoriesLock.RUnlock()
+
+	engines := make([]string, 0, len(factories))
+	for engine := range factories {
+		engines = append(engines, engine)
+	}
+	sort.Strings(engines)
+	return engines
+}
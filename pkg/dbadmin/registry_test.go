@@ -0,0 +1,32 @@
+package dbadmin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	factory := func(dsn string, settings AdminSettings) (DbAdmin, error) {
+		return nil, errors.New("Not connected")
+	}
+
+	if err := Register("dbaas", factory); err != nil {
+		t.Fatalf("Unable to register backend: %v", err)
+	}
+	if err := Register("dbaas", factory); err == nil {
+		t.Error("Expected an error registering a second backend for the engine")
+	}
+	if err := Register("", factory); err == nil {
+		t.Error("Expected an error registering a backend without an engine")
+	}
+
+	if _, ok := Lookup("dbaas"); !ok {
+		t.Error("Expected the registered backend to be found")
+	}
+	if _, ok := Lookup("oracle"); ok {
+		t.Error("Unexpected backend for an engine which wasn't registered")
+	}
+	if engines := Engines(); len(engines) != 1 || engines[0] != "dbaas" {
+		t.Errorf("Unexpected engines: %v", engines)
+	}
+}
@@ -0,0 +1,38 @@
+package dbadmin
+
+import (
+	"strings"
+)
+
+// Statement categories which the logs of statements, and of the reconcile
+// phases which issue them, are tagged with
+const (
+	CategoryDCL   = "dcl"
+	CategoryDDL   = "ddl"
+	CategoryQuery = "query"
+	CategoryDML   = "dml"
+	CategoryOther = "other"
+)
+
+// StatementCategory returns the category of a SQL statement template
+func StatementCategory(template string) string {
+	words := strings.Fields(strings.ToUpper(template))
+	if len(words) == 0 {
+		return CategoryOther
+	}
+
+	switch words[0] {
+	case "GRANT", "REVOKE":
+		return CategoryDCL
+	case "CREATE", "ALTER", "DROP", "RENAME":
+		if len(words) > 1 && (words[1] == "USER" || words[1] == "ROLE") {
+			return CategoryDCL
+		}
+		return CategoryDDL
+	case "SELECT", "SHOW":
+		return CategoryQuery
+	case "INSERT", "UPDATE", "DELETE", "REPLACE":
+		return CategoryDML
+	}
+	return CategoryOther
+}
@@ -0,0 +1,50 @@
+package dbadmin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// usernameDigestLength is the number of hex characters of the digest kept in
+// a derived username
+const usernameDigestLength = 8
+
+// DeriveUsername returns the username of name under prefix which fits in
+// limit characters. Names which fit are used as they are. Longer ones are
+// truncated to make room for an underscore and the first 8 hex characters of
+// the SHA-256 digest of the whole name, so that names which only differ
+// after the cut get their own usernames. A limit of zero doesn't limit the
+// length.
+func DeriveUsername(prefix, name string, limit int) (string, error) {
+	username := prefix + name
+	if limit <= 0 || utf8.RuneCountInString(username) <= limit {
+		return username, nil
+	}
+
+	keep := limit - utf8.RuneCountInString(prefix) - 1 - usernameDigestLength
+	if keep < 1 {
+		return "", fmt.Errorf("Username prefix %s leaves no room for a name within %d characters", prefix, limit)
+	}
+
+	digest := sha256.Sum256([]byte(name))
+	return prefix + string([]rune(name)[:keep]) + "_" + hex.EncodeToString(digest[:])[:usernameDigestLength], nil
+}
+
+// UsernameDerivedFrom returns true if username is the username of name under
+// prefix, as DeriveUsername returns it for any limit
+func UsernameDerivedFrom(username, prefix, name string) bool {
+	if username == prefix+name {
+		return true
+	}
+
+	digest := sha256.Sum256([]byte(name))
+	suffix := "_" + hex.EncodeToString(digest[:])[:usernameDigestLength]
+	if !strings.HasPrefix(username, prefix) || !strings.HasSuffix(username, suffix) {
+		return false
+	}
+	kept := strings.TrimSuffix(strings.TrimPrefix(username, prefix), suffix)
+	return kept != "" && strings.HasPrefix(name, kept)
+}
@@ -0,0 +1,66 @@
+package dbadmin
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDeriveUsername(t *testing.T) {
+	if username, err := DeriveUsername("dba_", "v1", 32); err != nil || username != "dba_v1" {
+		t.Errorf("Names which fit must be used as they are, got %s, %v", username, err)
+	}
+	if username, err := DeriveUsername("dba_", strings.Repeat("a", 28), 32); err != nil || username != "dba_"+strings.Repeat("a", 28) {
+		t.Errorf("Names of exactly the limit must be used as they are, got %s, %v", username, err)
+	}
+	if username, err := DeriveUsername("dba_", strings.Repeat("a", 100), 0); err != nil || len(username) != 104 {
+		t.Errorf("A zero limit must not truncate, got %s, %v", username, err)
+	}
+
+	long := "registry-frontend-schema-2023-11-14-add-index"
+	first, err := DeriveUsername("dba_", long, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != "dba_registry-frontend-s_46bff676" {
+		t.Errorf("The published scheme must not change, got %s", first)
+	}
+	again, _ := DeriveUsername("dba_", long, 32)
+	if again != first {
+		t.Errorf("Derivation must be deterministic, got %s and %s", first, again)
+	}
+
+	sibling, _ := DeriveUsername("dba_", long+"-2", 32)
+	if sibling == first || len(sibling) != 32 {
+		t.Errorf("Names which only differ after the cut must not collide, got %s", sibling)
+	}
+
+	multibyte, err := DeriveUsername("dba_", strings.Repeat("é", 40), 32)
+	if err != nil || utf8.RuneCountInString(multibyte) != 32 || !utf8.ValidString(multibyte) {
+		t.Errorf("Limits are in characters, got %s, %v", multibyte, err)
+	}
+
+	if _, err := DeriveUsername("dba_", long, 12); err == nil {
+		t.Error("Expected an error when the prefix leaves no room for the name")
+	}
+}
+
+func TestUsernameDerivedFrom(t *testing.T) {
+	long := "registry-frontend-schema-2023-11-14-add-index"
+	for _, limit := range []int{16, 32, 80} {
+		username, err := DeriveUsername("dba_", long, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !UsernameDerivedFrom(username, "dba_", long) {
+			t.Errorf("%s should be recognized as derived from %s", username, long)
+		}
+		if UsernameDerivedFrom(username, "dba_", long+"-2") || UsernameDerivedFrom(username, "dbr_", long) {
+			t.Errorf("%s should only be recognized for its own name and prefix", username)
+		}
+	}
+
+	if !UsernameDerivedFrom("dba_v1", "dba_", "v1") || UsernameDerivedFrom("dba_v10", "dba_", "v1") {
+		t.Error("Names which fit must only match exactly")
+	}
+}
@@ -0,0 +1,89 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// Runtime describes the operator process when an archive was collected
+type Runtime struct {
+	Generated  time.Time `json:"generated"`
+	Started    time.Time `json:"started"`
+	GoVersion  string    `json:"goVersion"`
+	Goroutines int       `json:"goroutines"`
+
+	HeapAllocBytes uint64 `json:"heapAllocBytes"`
+	HeapInuseBytes uint64 `json:"heapInuseBytes"`
+	SysBytes       uint64 `json:"sysBytes"`
+	NumGC          uint32 `json:"numGC"`
+}
+
+// WriteArchive writes a zip archive for attaching to bug reports, which
+// contains a dump of every goroutine, the state of the runtime, the recorded
+// reconciles and queries, and the statistics of the connection pools
+func (r *Recorder) WriteArchive(w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"runtime.json", Runtime{
+			Generated:      time.Now().UTC(),
+			Started:        r.startedTime,
+			GoVersion:      runtime.Version(),
+			Goroutines:     runtime.NumGoroutine(),
+			HeapAllocBytes: memStats.HeapAlloc,
+			HeapInuseBytes: memStats.HeapInuse,
+			SysBytes:       memStats.Sys,
+			NumGC:          memStats.NumGC,
+		}},
+		{"reconciles.json", r.Traces()},
+		{"queries.json", r.Bundle()},
+		{"pools.json", r.Pools()},
+	}
+	for _, file := range files {
+		fileWriter, err := archive.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("Unable to add %s to archive: %w", file.name, err)
+		}
+		encoder := json.NewEncoder(fileWriter)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return fmt.Errorf("Unable to write %s: %w", file.name, err)
+		}
+	}
+
+	goroutines, err := archive.Create("goroutines.txt")
+	if err != nil {
+		return fmt.Errorf("Unable to add goroutines.txt to archive: %w", err)
+	}
+	if err := pprof.Lookup("goroutine").WriteTo(goroutines, 2); err != nil {
+		return fmt.Errorf("Unable to dump goroutines: %w", err)
+	}
+
+	return archive.Close()
+}
+
+// ArchiveHandler serves the archive written by WriteArchive
+func (r *Recorder) ArchiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "Only GET is supported", http.StatusMethodNotAllowed)
+			return
+		}
+
+		filename := fmt.Sprintf("dba-operator-%s.zip", time.Now().UTC().Format("20060102-150405"))
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		_ = r.WriteArchive(w)
+	})
+}
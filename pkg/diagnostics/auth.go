@@ -0,0 +1,27 @@
+package diagnostics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// Authenticate only passes requests to next which present token as a bearer
+// token in their Authorization header. Every request is passed when token
+// is empty.
+func Authenticate(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		presented := []byte(strings.TrimSpace(req.Header.Get("Authorization")))
+		if subtle.ConstantTimeCompare(presented, expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dba-operator diagnostics"`)
+			http.Error(w, "A valid bearer token is required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,204 @@
+package diagnostics
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+// Query is the diagnostic record of a single statement sent to a managed
+// database. Only the statement template is recorded, never the values.
+type Query struct {
+	Time     time.Time `json:"time"`
+	Database string    `json:"database"`
+	Template string    `json:"template"`
+
+	// Category is the kind of statement, such as "query" or "ddl"
+	Category string `json:"category,omitempty"`
+
+	// Duration is the time until the first result was returned
+	Duration time.Duration `json:"durationNanos"`
+
+	// Explain contains the output of EXPLAIN FORMAT=JSON for the query, if
+	// the statement can be explained
+	Explain string `json:"explain,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Trace is the diagnostic record of a single reconcile of an object
+type Trace struct {
+	Time       time.Time     `json:"time"`
+	Controller string        `json:"controller"`
+	Object     string        `json:"object"`
+	Duration   time.Duration `json:"durationNanos"`
+
+	// RequeueAfter is when the object is reconciled again, if the reconcile
+	// asked for it
+	RequeueAfter time.Duration `json:"requeueAfterNanos,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// Pool contains the statistics of the connection pool of a database
+type Pool struct {
+	Database string `json:"database"`
+	sql.DBStats
+}
+
+// Bundle is a snapshot of the recorded queries, oldest first
+type Bundle struct {
+	Generated time.Time `json:"generated"`
+	Queries   []Query   `json:"queries"`
+}
+
+// ring tracks the slots of a fixed size ring buffer
+type ring struct {
+	size int
+	next int
+	full bool
+}
+
+// add returns the slot of the newest item, which is the oldest one's slot
+// when the buffer is full
+func (r *ring) add() int {
+	slot := r.next
+	r.next = (r.next + 1) % r.size
+	if r.next == 0 {
+		r.full = true
+	}
+	return slot
+}
+
+// slots returns the slots of the items in the buffer, oldest first
+func (r *ring) slots() []int {
+	var slots []int
+	if r.full {
+		for slot := r.next; slot < r.size; slot++ {
+			slots = append(slots, slot)
+		}
+	}
+	for slot := 0; slot < r.next; slot++ {
+		slots = append(slots, slot)
+	}
+	return slots
+}
+
+// Recorder keeps the most recent query and reconcile diagnostics in fixed
+// size ring buffers, along with the connection pool of each database, and
+// serves them as a JSON bundle. It is safe for concurrent use. Passing a
+// Recorder to the controllers puts the operator in debug mode: the templates
+// of all SQL statements sent to managed databases are logged, and the
+// timings and plans of read queries are recorded in it.
+type Recorder struct {
+	mu sync.Mutex
+
+	queries     []Query
+	queryRing   ring
+	traces      []Trace
+	traceRing   ring
+	pools       map[string]func() sql.DBStats
+	startedTime time.Time
+}
+
+// NewRecorder returns a Recorder which keeps the last capacity queries and
+// the last capacity reconciles
+func NewRecorder(capacity int) *Recorder {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Recorder{
+		queries:     make([]Query, capacity),
+		queryRing:   ring{size: capacity},
+		traces:      make([]Trace, capacity),
+		traceRing:   ring{size: capacity},
+		pools:       make(map[string]func() sql.DBStats),
+		startedTime: time.Now().UTC(),
+	}
+}
+
+// Record adds a query to the buffer, evicting the oldest one when full. The
+// error is redacted of anything resembling a credential.
+func (r *Recorder) Record(query Query) {
+	query.Error = redact.String(query.Error)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.queries[r.queryRing.add()] = query
+}
+
+// RecordTrace adds a reconcile to the buffer, evicting the oldest one when
+// full. The error is redacted of anything resembling a credential.
+func (r *Recorder) RecordTrace(trace Trace) {
+	trace.Error = redact.String(trace.Error)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.traces[r.traceRing.add()] = trace
+}
+
+// TrackPool reports the statistics of a database's connection pool, which
+// replaces the pool previously tracked for the database
+func (r *Recorder) TrackPool(database string, stats func() sql.DBStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pools[database] = stats
+}
+
+// Bundle returns a copy of the recorded queries
+func (r *Recorder) Bundle() Bundle {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var queries []Query
+	for _, slot := range r.queryRing.slots() {
+		queries = append(queries, r.queries[slot])
+	}
+
+	return Bundle{Generated: time.Now().UTC(), Queries: queries}
+}
+
+// Traces returns a copy of the recorded reconciles, oldest first
+func (r *Recorder) Traces() []Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var traces []Trace
+	for _, slot := range r.traceRing.slots() {
+		traces = append(traces, r.traces[slot])
+	}
+	return traces
+}
+
+// Pools returns the current statistics of every tracked connection pool,
+// sorted by database
+func (r *Recorder) Pools() []Pool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pools := make([]Pool, 0, len(r.pools))
+	for database, stats := range r.pools {
+		pools = append(pools, Pool{Database: database, DBStats: stats()})
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Database < pools[j].Database })
+	return pools
+}
+
+// ServeHTTP implements http.Handler by writing the current bundle as JSON
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "Only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(r.Bundle())
+}
@@ -0,0 +1,148 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func templates(bundle Bundle) []string {
+	var result []string
+	for _, query := range bundle.Queries {
+		result = append(result, query.Template)
+	}
+	return result
+}
+
+func TestRecorderKeepsNewestInOrder(t *testing.T) {
+	testCases := []struct {
+		name     string
+		recorded []string
+		expected []string
+	}{
+		{"empty", nil, nil},
+		{"partial", []string{"a", "b"}, []string{"a", "b"}},
+		{"exactly full", []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+		{"wrapped", []string{"a", "b", "c", "d", "e"}, []string{"c", "d", "e"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := NewRecorder(3)
+			for _, template := range tc.recorded {
+				recorder.Record(Query{Template: template})
+			}
+
+			got := templates(recorder.Bundle())
+			if len(got) != len(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, got)
+			}
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					t.Fatalf("expected %v, got %v", tc.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	recorder := NewRecorder(10)
+	recorder.Record(Query{Template: "SELECT 1", Explain: "{}"})
+
+	response := httptest.NewRecorder()
+	recorder.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/debug/sql", nil))
+	if response.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", response.Code)
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(response.Body.Bytes(), &bundle); err != nil {
+		t.Fatalf("unable to parse bundle: %v", err)
+	}
+	if len(bundle.Queries) != 1 || bundle.Queries[0].Template != "SELECT 1" {
+		t.Fatalf("unexpected bundle: %+v", bundle)
+	}
+
+	response = httptest.NewRecorder()
+	recorder.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/debug/sql", nil))
+	if response.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected POST to be rejected, got %d", response.Code)
+	}
+}
+
+func TestRecorderTracesAndPools(t *testing.T) {
+	recorder := NewRecorder(2)
+	for _, object := range []string{"app/a", "app/b", "app/c"} {
+		recorder.RecordTrace(Trace{Object: object})
+	}
+	recorder.RecordTrace(Trace{Object: "app/d", Error: "dial root:hunter2@tcp(db:3306)/app"})
+
+	traces := recorder.Traces()
+	if len(traces) != 2 || traces[0].Object != "app/c" || traces[1].Object != "app/d" {
+		t.Fatalf("expected the newest traces in order, got %+v", traces)
+	}
+	if strings.Contains(traces[1].Error, "hunter2") {
+		t.Errorf("expected the error to be redacted, got %q", traces[1].Error)
+	}
+
+	recorder.TrackPool("orders", func() sql.DBStats { return sql.DBStats{OpenConnections: 1} })
+	recorder.TrackPool("billing", func() sql.DBStats { return sql.DBStats{OpenConnections: 2} })
+	recorder.TrackPool("orders", func() sql.DBStats { return sql.DBStats{OpenConnections: 3} })
+
+	pools := recorder.Pools()
+	if len(pools) != 2 || pools[0].Database != "billing" || pools[1].OpenConnections != 3 {
+		t.Fatalf("expected the latest pool of each database, got %+v", pools)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	recorder := NewRecorder(10)
+	recorder.Record(Query{Template: "SELECT 1"})
+	recorder.RecordTrace(Trace{Controller: "manageddatabase", Object: "app/orders"})
+
+	response := httptest.NewRecorder()
+	recorder.ArchiveHandler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/debug/bundle", nil))
+	if response.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", response.Code)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(response.Body.Bytes()), int64(response.Body.Len()))
+	if err != nil {
+		t.Fatalf("unable to read archive: %v", err)
+	}
+	files := make(map[string]bool)
+	for _, file := range archive.File {
+		files[file.Name] = true
+	}
+	for _, expected := range []string{"runtime.json", "reconciles.json", "queries.json", "pools.json", "goroutines.txt"} {
+		if !files[expected] {
+			t.Errorf("expected %s in the archive, got %v", expected, files)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	handler := Authenticate("s3cret", NewRecorder(1))
+
+	for header, expected := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"Bearer s3cret": http.StatusOK,
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/debug/sql", nil)
+		if header != "" {
+			request.Header.Set("Authorization", header)
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		if response.Code != expected {
+			t.Errorf("%q: expected %d, got %d", header, expected, response.Code)
+		}
+	}
+}
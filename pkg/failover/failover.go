@@ -0,0 +1,153 @@
+// Package failover fails the primary of a database over on purpose, through
+// the RDS API or orchestrator, for failover drills.
+package failover
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/sigv4"
+)
+
+// Methods of triggering a failover
+const (
+	MethodRDSCluster   = "rds-cluster"
+	MethodRDSInstance  = "rds-instance"
+	MethodOrchestrator = "orchestrator"
+)
+
+// Trigger starts a failover of the target, returning once the failover was
+// accepted, usually before it has completed
+type Trigger interface {
+	Failover(ctx context.Context, target string) error
+}
+
+// rdsAPIVersion is the version of the RDS Query API which is requested
+const rdsAPIVersion = "2014-10-31"
+
+// maxResponseBytes limits how much of a response is read for its error
+const maxResponseBytes = 64 * 1024
+
+// RDS fails over Aurora clusters, promoting one of their readers, or reboots
+// Multi-AZ instances onto their standby
+type RDS struct {
+	// Endpoint overrides the regional endpoint of the RDS API
+	Endpoint    string
+	Region      string
+	Credentials sigv4.Credentials
+	Client      *http.Client
+
+	// Cluster is true to fail over a cluster, false for an instance
+	Cluster bool
+}
+
+type rdsErrorResponse struct {
+	Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+}
+
+// Failover implements Trigger
+func (r RDS) Failover(ctx context.Context, target string) error {
+	form := url.Values{"Version": {rdsAPIVersion}}
+	if r.Cluster {
+		form.Set("Action", "FailoverDBCluster")
+		form.Set("DBClusterIdentifier", target)
+	} else {
+		form.Set("Action", "RebootDBInstance")
+		form.Set("DBInstanceIdentifier", target)
+		form.Set("ForceFailover", "true")
+	}
+	body := form.Encode()
+
+	endpoint := r.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://rds.%s.amazonaws.com/", r.Region)
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("Unable to create RDS request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	payloadHash := sha256.Sum256([]byte(body))
+	sigv4.Sign(req, hex.EncodeToString(payloadHash[:]), r.Credentials, r.Region, "rds", time.Now())
+
+	resp, err := client(r.Client).Do(req)
+	if err != nil {
+		return fmt.Errorf("Unable to call %s for %s: %w", form.Get("Action"), target, redact.Error(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
+	}
+
+	responseBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	var rdsErr rdsErrorResponse
+	if xml.Unmarshal(responseBody, &rdsErr) == nil && rdsErr.Error.Code != "" {
+		return fmt.Errorf("RDS refused %s for %s: %s: %s", form.Get("Action"), target, rdsErr.Error.Code, rdsErr.Error.Message)
+	}
+	return fmt.Errorf("RDS responded to %s for %s with status %d", form.Get("Action"), target, resp.StatusCode)
+}
+
+// Orchestrator asks orchestrator for a graceful takeover of the master of a
+// cluster by one of its replicas, which orchestrator picks
+type Orchestrator struct {
+	URL    string
+	Client *http.Client
+}
+
+type orchestratorResponse struct {
+	Code    string
+	Message string
+}
+
+// Failover implements Trigger, target is the alias of the cluster
+func (o Orchestrator) Failover(ctx context.Context, target string) error {
+	endpoint := strings.TrimSuffix(o.URL, "/") + "/api/graceful-master-takeover-auto/" + url.PathEscape(target)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("Unable to create orchestrator request: %w", err)
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := client(o.Client).Do(req)
+	if err != nil {
+		return fmt.Errorf("Unable to request takeover of %s: %w", target, redact.Error(err))
+	}
+	defer resp.Body.Close()
+
+	// Orchestrator reports most failures in the body with a success status
+	responseBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	var response orchestratorResponse
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("Orchestrator responded to the takeover of %s with status %d", target, resp.StatusCode)
+		}
+		return fmt.Errorf("Unable to parse orchestrator response: %w", err)
+	}
+	if response.Code != "OK" || resp.StatusCode >= 300 {
+		return fmt.Errorf("Orchestrator refused the takeover of %s: %s", target, response.Message)
+	}
+	return nil
+}
+
+func client(c *http.Client) *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+	return c
+}
@@ -0,0 +1,149 @@
+// Package inventory describes every database managed by the operator in a
+// report which a CMDB can ingest, and which serves as compliance evidence of
+// who had which access when.
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Kinds of credentials in the inventory
+const (
+	KindAppCredentials    = "AppCredentials"
+	KindCredentialRequest = "DatabaseCredentialRequest"
+	KindReplicaUser       = "ReplicaUser"
+)
+
+// Report is the inventory at one point in time
+type Report struct {
+	GeneratedAt time.Time  `json:"generatedAt"`
+	Databases   []Database `json:"databases"`
+}
+
+// Database is one ManagedDatabase
+type Database struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	Engine          string `json:"engine"`
+	MigrationEngine string `json:"migrationEngine,omitempty"`
+	ServerFlavor    string `json:"serverFlavor,omitempty"`
+	ServerVersion   string `json:"serverVersion,omitempty"`
+	Instance        string `json:"instance,omitempty"`
+
+	SchemaVersion  string     `json:"schemaVersion,omitempty"`
+	LastMigratedAt *time.Time `json:"lastMigratedAt,omitempty"`
+	Paused         bool       `json:"paused,omitempty"`
+
+	// The sizes are left out until the capacity of the database has been
+	// measured
+	DataBytes          *int64     `json:"dataBytes,omitempty"`
+	IndexBytes         *int64     `json:"indexBytes,omitempty"`
+	CapacityMeasuredAt *time.Time `json:"capacityMeasuredAt,omitempty"`
+
+	Credentials []Credential `json:"credentials,omitempty"`
+}
+
+// Credential is one class of access to a database. The app credentials of
+// a database have a Credential per grant, the usernames of their versions
+// come and go with the migrations.
+type Credential struct {
+	Kind string `json:"kind"`
+
+	// Name is the namespace/name of the DatabaseCredentialRequest
+	Name string `json:"name,omitempty"`
+
+	Username string `json:"username,omitempty"`
+	Class    string `json:"class"`
+
+	// Database is the database on the instance which is granted, the
+	// managed database itself when empty
+	Database string `json:"database,omitempty"`
+
+	LastLogin *time.Time `json:"lastLogin,omitempty"`
+}
+
+// Content types of the encoded reports
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeCSV  = "text/csv"
+)
+
+// Encode renders the report in the format, json or csv, and returns its
+// content type
+func (r Report) Encode(format string) ([]byte, string, error) {
+	switch format {
+	case "json":
+		encoded, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return nil, "", fmt.Errorf("Unable to encode inventory: %w", err)
+		}
+		return encoded, ContentTypeJSON, nil
+	case "csv":
+		encoded, err := r.csv()
+		if err != nil {
+			return nil, "", fmt.Errorf("Unable to encode inventory: %w", err)
+		}
+		return encoded, ContentTypeCSV, nil
+	default:
+		return nil, "", fmt.Errorf("Unknown inventory format: %s", format)
+	}
+}
+
+var csvHeader = []string{
+	"namespace", "name", "engine", "migration_engine", "server_flavor", "server_version", "instance",
+	"schema_version", "last_migrated_at", "paused", "data_bytes", "index_bytes", "capacity_measured_at",
+	"credential_kind", "credential_name", "username", "class", "granted_database", "last_login",
+}
+
+// csv flattens the report into a row per credential, a database without
+// credentials has a row of its own
+func (r Report) csv() ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.Write(csvHeader); err != nil {
+		return nil, err
+	}
+
+	for _, db := range r.Databases {
+		columns := []string{
+			db.Namespace, db.Name, db.Engine, db.MigrationEngine, db.ServerFlavor, db.ServerVersion, db.Instance,
+			db.SchemaVersion, formatTime(db.LastMigratedAt), strconv.FormatBool(db.Paused),
+			formatInt(db.DataBytes), formatInt(db.IndexBytes), formatTime(db.CapacityMeasuredAt),
+		}
+
+		credentials := db.Credentials
+		if len(credentials) == 0 {
+			credentials = []Credential{{}}
+		}
+		for _, credential := range credentials {
+			row := append(append([]string{}, columns...),
+				credential.Kind, credential.Name, credential.Username, credential.Class, credential.Database, formatTime(credential.LastLogin))
+			if err := writer.Write(row); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatInt(i *int64) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.FormatInt(*i, 10)
+}
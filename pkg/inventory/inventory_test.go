@@ -0,0 +1,79 @@
+package inventory
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testReport() Report {
+	migrated := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	login := time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)
+	dataBytes, indexBytes := int64(1024), int64(512)
+	return Report{
+		GeneratedAt: time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC),
+		Databases: []Database{
+			{
+				Namespace:      "quay",
+				Name:           "quay",
+				Engine:         "mysql",
+				ServerVersion:  "8.0.32",
+				SchemaVersion:  "v3",
+				LastMigratedAt: &migrated,
+				DataBytes:      &dataBytes,
+				IndexBytes:     &indexBytes,
+				Credentials: []Credential{
+					{Kind: KindAppCredentials, Class: "readwrite"},
+					{Kind: KindCredentialRequest, Name: "reporting/quay", Username: "dbr_1", Class: "readonly", LastLogin: &login},
+				},
+			},
+			{Namespace: "clair", Name: "clair", Engine: "mysql", Paused: true},
+		},
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	encoded, contentType, err := testReport().Encode("json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if contentType != ContentTypeJSON {
+		t.Errorf("Unexpected content type: %s", contentType)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unable to decode inventory: %v", err)
+	}
+	if len(decoded.Databases) != 2 || len(decoded.Databases[0].Credentials) != 2 || *decoded.Databases[0].DataBytes != 1024 {
+		t.Errorf("Unexpected inventory: %s", encoded)
+	}
+	if strings.Contains(string(encoded), `"dataBytes": 0`) {
+		t.Errorf("Expected unmeasured sizes to be left out: %s", encoded)
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	encoded, contentType, err := testReport().Encode("csv")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if contentType != ContentTypeCSV {
+		t.Errorf("Unexpected content type: %s", contentType)
+	}
+
+	expected := strings.Join(csvHeader, ",") + "\n" +
+		"quay,quay,mysql,,,8.0.32,,v3,2020-01-02T03:04:05Z,false,1024,512,,AppCredentials,,,readwrite,,\n" +
+		"quay,quay,mysql,,,8.0.32,,v3,2020-01-02T03:04:05Z,false,1024,512,,DatabaseCredentialRequest,reporting/quay,dbr_1,readonly,,2020-01-03T00:00:00Z\n" +
+		"clair,clair,mysql,,,,,,,true,,,,,,,,,\n"
+	if string(encoded) != expected {
+		t.Errorf("Unexpected CSV:\n%s", encoded)
+	}
+}
+
+func TestEncodeUnknown(t *testing.T) {
+	if _, _, err := testReport().Encode("xml"); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
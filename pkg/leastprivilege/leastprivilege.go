@@ -0,0 +1,156 @@
+// Package leastprivilege works out which privileges of the users issued by
+// the operator went unused, so that standing access can be shrunk to what
+// the applications actually exercise.
+package leastprivilege
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// Tracked are the privileges whose use the backends attribute to users, no
+// other privilege is ever suggested for revocation
+var Tracked = map[string]bool{
+	"SELECT":  true,
+	"INSERT":  true,
+	"UPDATE":  true,
+	"DELETE":  true,
+	"EXECUTE": true,
+}
+
+// Usage is when one privilege of a user was last used
+type Usage struct {
+	Username  string
+	Privilege string
+
+	// Since is when the operator started watching the use of the privilege
+	Since time.Time
+
+	// LastUsed is nil until the privilege is seen being used
+	LastUsed *time.Time
+}
+
+// CounterKey identifies the statement counter of a privilege of a user
+func CounterKey(username, privilege string) string {
+	return username + "/" + privilege
+}
+
+// Observe updates the recorded usage with a sample of the statement
+// counters, keyed by CounterKey. A privilege was used when its counter
+// changed since the previous sample, the counters start from zero again when
+// the server restarts. Only the tracked privileges which are still held are
+// kept, and those which weren't recorded before are watched from now on.
+func Observe(recorded []Usage, held map[string][]dbadmin.UserPrivilege, previous, current map[string]int64, now time.Time) []Usage {
+	known := make(map[string]Usage, len(recorded))
+	for _, usage := range recorded {
+		known[CounterKey(usage.Username, usage.Privilege)] = usage
+	}
+
+	var observed []Usage
+	for username, privileges := range held {
+		seen := make(map[string]bool)
+		for _, privilege := range privileges {
+			if !Tracked[privilege.Privilege] || seen[privilege.Privilege] {
+				continue
+			}
+			seen[privilege.Privilege] = true
+
+			key := CounterKey(username, privilege.Privilege)
+			usage, ok := known[key]
+			if !ok {
+				usage = Usage{Username: username, Privilege: privilege.Privilege, Since: now}
+			}
+
+			count := current[key]
+			before, sampled := previous[key]
+			if sampled && count != before && count > 0 {
+				used := now
+				usage.LastUsed = &used
+			}
+			observed = append(observed, usage)
+		}
+	}
+
+	sort.Slice(observed, func(i, j int) bool {
+		if observed[i].Username != observed[j].Username {
+			return observed[i].Username < observed[j].Username
+		}
+		return observed[i].Privilege < observed[j].Privilege
+	})
+	return observed
+}
+
+// Suggestion is a privilege which went unused for the whole window, with
+// the grants which have to be revoked to take it away
+type Suggestion struct {
+	Username    string
+	Privilege   string
+	UnusedSince time.Time
+	Revoke      []dbadmin.UserPrivilege
+}
+
+// Suggest returns the tracked privileges which weren't used during the
+// window, by users which did use another of their privileges in it. Users
+// which weren't seen using anything are left to login tracking, which also
+// keeps a server that doesn't count statements from having every privilege
+// suggested.
+func Suggest(usage []Usage, held map[string][]dbadmin.UserPrivilege, window time.Duration, now time.Time) []Suggestion {
+	cutoff := now.Add(-window)
+
+	active := make(map[string]bool)
+	for _, one := range usage {
+		if one.LastUsed != nil && one.LastUsed.After(cutoff) {
+			active[one.Username] = true
+		}
+	}
+
+	var suggestions []Suggestion
+	for _, one := range usage {
+		if !active[one.Username] || one.Since.After(cutoff) {
+			continue
+		}
+
+		unusedSince := one.Since
+		if one.LastUsed != nil {
+			if one.LastUsed.After(cutoff) {
+				continue
+			}
+			unusedSince = *one.LastUsed
+		}
+
+		var revoke []dbadmin.UserPrivilege
+		for _, privilege := range held[one.Username] {
+			if privilege.Privilege == one.Privilege {
+				revoke = append(revoke, privilege)
+			}
+		}
+		if len(revoke) == 0 {
+			continue
+		}
+
+		suggestions = append(suggestions, Suggestion{
+			Username:    one.Username,
+			Privilege:   one.Privilege,
+			UnusedSince: unusedSince,
+			Revoke:      revoke,
+		})
+	}
+	return suggestions
+}
+
+// Hash identifies the revocations of a set of suggestions for approval, it
+// doesn't change while the same privileges stay unused
+func Hash(suggestions []Suggestion) string {
+	hash := sha256.New()
+	for _, suggestion := range suggestions {
+		for _, privilege := range suggestion.Revoke {
+			fmt.Fprintf(hash, "%s\x00%s\x00%s\x00%s\x00%s\n", suggestion.Username, privilege.Privilege, privilege.Database, privilege.Table, privilege.Column)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,101 @@
+package leastprivilege
+
+import (
+	"testing"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+var (
+	start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	readWrite = []dbadmin.UserPrivilege{
+		{Privilege: "DELETE"},
+		{Privilege: "INSERT"},
+		{Privilege: "SELECT"},
+		{Privilege: "UPDATE"},
+		{Database: "audit", Table: "events", Privilege: "INSERT"},
+	}
+)
+
+func at(days int) time.Time {
+	return start.Add(time.Duration(days) * 24 * time.Hour)
+}
+
+func TestObserve(t *testing.T) {
+	held := map[string][]dbadmin.UserPrivilege{
+		"dbr_1": readWrite,
+		"dbr_2": {{Database: "*", Privilege: "REPLICATION SLAVE"}},
+	}
+
+	// The first sample only starts watching
+	current := map[string]int64{CounterKey("dbr_1", "SELECT"): 10}
+	usage := Observe(nil, held, map[string]int64{}, current, at(0))
+	if len(usage) != 4 {
+		t.Fatalf("Unexpected usage: %+v", usage)
+	}
+	for _, one := range usage {
+		if one.Username != "dbr_1" || !one.Since.Equal(at(0)) || one.LastUsed != nil {
+			t.Errorf("Unexpected usage: %+v", one)
+		}
+	}
+
+	previous := current
+	current = map[string]int64{CounterKey("dbr_1", "SELECT"): 12, CounterKey("dbr_1", "INSERT"): 1}
+	usage = Observe(usage, held, previous, current, at(1))
+	for _, one := range usage {
+		if !one.Since.Equal(at(0)) {
+			t.Errorf("Expected the start of watching to be kept: %+v", one)
+		}
+		switch one.Privilege {
+		case "SELECT":
+			if one.LastUsed == nil || !one.LastUsed.Equal(at(1)) {
+				t.Errorf("Expected SELECT to be used: %+v", one)
+			}
+		default:
+			if one.LastUsed != nil {
+				t.Errorf("Expected %s to be unused: %+v", one.Privilege, one)
+			}
+		}
+	}
+
+	// Revoked privileges stop being watched
+	held["dbr_1"] = readWrite[2:3]
+	usage = Observe(usage, held, current, current, at(2))
+	if len(usage) != 1 || usage[0].Privilege != "SELECT" || usage[0].LastUsed == nil {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	held := map[string][]dbadmin.UserPrivilege{"dbr_1": readWrite, "dbr_2": readWrite}
+	used := at(25)
+	stale := at(2)
+	usage := []Usage{
+		{Username: "dbr_1", Privilege: "DELETE", Since: at(0)},
+		{Username: "dbr_1", Privilege: "INSERT", Since: at(0), LastUsed: &stale},
+		{Username: "dbr_1", Privilege: "SELECT", Since: at(0), LastUsed: &used},
+		{Username: "dbr_1", Privilege: "UPDATE", Since: at(20)},
+		{Username: "dbr_2", Privilege: "DELETE", Since: at(0)},
+	}
+
+	suggestions := Suggest(usage, held, 14*24*time.Hour, at(30))
+	if len(suggestions) != 2 {
+		t.Fatalf("Unexpected suggestions: %+v", suggestions)
+	}
+	remove, insert := suggestions[0], suggestions[1]
+	if remove.Privilege != "DELETE" || !remove.UnusedSince.Equal(at(0)) || len(remove.Revoke) != 1 {
+		t.Errorf("Unexpected suggestion: %+v", remove)
+	}
+	if insert.Privilege != "INSERT" || !insert.UnusedSince.Equal(stale) || len(insert.Revoke) != 2 {
+		t.Errorf("Unexpected suggestion: %+v", insert)
+	}
+
+	if Hash(suggestions) != Hash(Suggest(usage, held, 14*24*time.Hour, at(31))) {
+		t.Errorf("Expected the hash to stay the same while the privileges stay unused")
+	}
+	if Hash(suggestions) == Hash(suggestions[:1]) {
+		t.Errorf("Expected the hash to change with the suggestions")
+	}
+}
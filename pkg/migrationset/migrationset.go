@@ -0,0 +1,123 @@
+// Package migrationset computes content hashes of migrations, and of the
+// chains of migrations which lead up to a schema version, so that a version
+// can be pinned to exactly the migrations it was tested with.
+package migrationset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DigestPrefix starts every checksum and digest, in the same form as image
+// digests
+const DigestPrefix = "sha256:"
+
+// Migration is the content of a migration which determines what it applies
+// to the database
+type Migration struct {
+	Version  string   `json:"version"`
+	Previous string   `json:"previous"`
+	Image    string   `json:"image"`
+	Command  []string `json:"command"`
+	Args     []string `json:"args"`
+
+	// Env contains NAME=value for each environment variable, or the source
+	// of the value for variables which are read from elsewhere
+	Env []string `json:"env"`
+
+	// ConfigMap names the SQL files of an embedded migration, it is omitted
+	// for the others so that their checksums don't change
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// Bundle is the reference of a bundle, omitted as ConfigMap is
+	Bundle string `json:"bundle,omitempty"`
+}
+
+// IsDigest returns true if the version is written as a digest rather than
+// as the name of a migration
+func IsDigest(version string) bool {
+	if !strings.HasPrefix(version, DigestPrefix) {
+		return false
+	}
+	encoded := version[len(DigestPrefix):]
+	if len(encoded) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(encoded)
+	return err == nil
+}
+
+func hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return DigestPrefix + hex.EncodeToString(sum[:])
+}
+
+// Checksum returns the content hash of a single migration
+func Checksum(migration Migration) (string, error) {
+	encoded, err := json.Marshal(migration)
+	if err != nil {
+		return "", fmt.Errorf("Unable to encode migration (%s): %w", migration.Version, err)
+	}
+	return hash(encoded), nil
+}
+
+// Digest returns the content hash of the chain of migrations which ends at
+// version, each of which is looked up by name
+func Digest(migrations map[string]Migration, version string) (string, error) {
+	var chain []Migration
+	seen := make(map[string]bool)
+	for name := version; name != ""; {
+		if seen[name] {
+			return "", fmt.Errorf("Migration %s is its own predecessor", name)
+		}
+		seen[name] = true
+
+		migration, ok := migrations[name]
+		if !ok {
+			return "", fmt.Errorf("Migration %s is missing from the chain ending at %s", name, version)
+		}
+		chain = append(chain, migration)
+		name = migration.Previous
+	}
+
+	digest := ""
+	for i := len(chain) - 1; i >= 0; i-- {
+		checksum, err := Checksum(chain[i])
+		if err != nil {
+			return "", err
+		}
+		digest = hash([]byte(digest + "\n" + checksum))
+	}
+	return digest, nil
+}
+
+// Resolve returns the version which a digest refers to. The digest is either
+// that of a chain of migrations, or the digest of the one image which a
+// migration is pinned to.
+func Resolve(migrations map[string]Migration, digest string) (string, error) {
+	var matches []string
+	for name, migration := range migrations {
+		if strings.HasSuffix(migration.Image, "@"+digest) {
+			matches = append(matches, name)
+			continue
+		}
+
+		// Chains with a missing or looping migration can't be the one
+		// that is pinned
+		chainDigest, err := Digest(migrations, name)
+		if err == nil && chainDigest == digest {
+			matches = append(matches, name)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("No chain of migrations or migration image matches %s", digest)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("Digest %s matches more than one migration: %s", digest, strings.Join(matches, ", "))
+}
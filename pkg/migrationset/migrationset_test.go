@@ -0,0 +1,100 @@
+package migrationset
+
+import (
+	"strings"
+	"testing"
+)
+
+var testMigrations = map[string]Migration{
+	"v1": {Version: "v1", Image: "quay.io/app/migrations@sha256:" + strings.Repeat("1", 64), Args: []string{"upgrade", "v1"}},
+	"v2": {Version: "v2", Previous: "v1", Image: "quay.io/app/migrations:v2", Args: []string{"upgrade", "v2"}},
+	"v3": {Version: "v3", Previous: "v2", Image: "quay.io/app/migrations:v3", Args: []string{"upgrade", "v3"}},
+}
+
+func copyMigrations() map[string]Migration {
+	copied := make(map[string]Migration, len(testMigrations))
+	for name, migration := range testMigrations {
+		copied[name] = migration
+	}
+	return copied
+}
+
+func TestIsDigest(t *testing.T) {
+	for version, expected := range map[string]bool{
+		"sha256:" + strings.Repeat("a", 64): true,
+		"sha256:" + strings.Repeat("a", 63): false,
+		"sha256:" + strings.Repeat("g", 64): false,
+		"v1":                                false,
+	} {
+		if IsDigest(version) != expected {
+			t.Errorf("%s: expected %t", version, expected)
+		}
+	}
+}
+
+func TestDigestCoversTheChain(t *testing.T) {
+	digest, err := Digest(testMigrations, "v3")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !IsDigest(digest) {
+		t.Errorf("Not a digest: %s", digest)
+	}
+
+	// Mutating an earlier migration under the same name changes the digest
+	mutated := copyMigrations()
+	v1 := mutated["v1"]
+	v1.Args = []string{"upgrade", "v1", "--sql"}
+	mutated["v1"] = v1
+
+	mutatedDigest, err := Digest(mutated, "v3")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if mutatedDigest == digest {
+		t.Errorf("Expected the digest to change when a migration is changed")
+	}
+}
+
+func TestDigestRejectsBrokenChains(t *testing.T) {
+	missing := copyMigrations()
+	delete(missing, "v2")
+	if _, err := Digest(missing, "v3"); err == nil {
+		t.Errorf("Expected an error for a missing migration")
+	}
+
+	looping := copyMigrations()
+	v1 := looping["v1"]
+	v1.Previous = "v3"
+	looping["v1"] = v1
+	if _, err := Digest(looping, "v3"); err == nil {
+		t.Errorf("Expected an error for a loop")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	digest, err := Digest(testMigrations, "v2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if version, err := Resolve(testMigrations, digest); err != nil || version != "v2" {
+		t.Errorf("Expected v2, got %s (%v)", version, err)
+	}
+
+	imageDigest := "sha256:" + strings.Repeat("1", 64)
+	if version, err := Resolve(testMigrations, imageDigest); err != nil || version != "v1" {
+		t.Errorf("Expected v1, got %s (%v)", version, err)
+	}
+
+	if _, err := Resolve(testMigrations, "sha256:"+strings.Repeat("2", 64)); err == nil {
+		t.Errorf("Expected an error for an unknown digest")
+	}
+
+	ambiguous := copyMigrations()
+	v2 := ambiguous["v2"]
+	v2.Image = ambiguous["v1"].Image
+	ambiguous["v2"] = v2
+	if _, err := Resolve(ambiguous, imageDigest); err == nil {
+		t.Errorf("Expected an error for an ambiguous image digest")
+	}
+}
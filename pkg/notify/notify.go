@@ -0,0 +1,81 @@
+// Package notify delivers operator events to the notification sinks listed
+// in the operator configuration.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/redact"
+)
+
+// Event describes something that the operator did, or failed to do, which a
+// human may need to know about
+type Event struct {
+	Time      time.Time `json:"time"`
+	Reason    string    `json:"reason"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// Notifier delivers events
+type Notifier interface {
+	// Notify delivers the event asynchronously, delivery failures are logged
+	// rather than returned
+	Notify(event Event)
+}
+
+// WebhookNotifier posts events as JSON to every configured sink
+type WebhookNotifier struct {
+	provider config.Provider
+	client   *http.Client
+	log      logr.Logger
+}
+
+// NewWebhookNotifier will instantiate a WebhookNotifier which delivers to
+// the sinks in the current config at the time of each notification.
+func NewWebhookNotifier(provider config.Provider, log logr.Logger) *WebhookNotifier {
+	return &WebhookNotifier{
+		provider: provider,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		log:      log,
+	}
+}
+
+// Notify implements Notifier
+func (wn *WebhookNotifier) Notify(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	event.Message = redact.String(event.Message)
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		wn.log.Error(err, "Unable to encode notification")
+		return
+	}
+
+	for _, sink := range wn.provider.Current().NotificationSinks {
+		go wn.deliver(sink, body)
+	}
+}
+
+func (wn *WebhookNotifier) deliver(sink config.NotificationSink, body []byte) {
+	resp, err := wn.client.Post(sink.URL, "application/json", bytes.NewReader(body))
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			err = fmt.Errorf("Sink responded with status %d", resp.StatusCode)
+		}
+	}
+	if err != nil {
+		wn.log.Error(redact.Error(err), "Unable to deliver notification", "sink", sink.Name)
+	}
+}
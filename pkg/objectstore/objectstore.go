@@ -0,0 +1,54 @@
+// Package objectstore uploads objects to S3 compatible storage.
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/redact"
+	"github.com/app-sre/dba-operator/pkg/sigv4"
+)
+
+// Bucket is a bucket which is addressed in the path style, which every S3
+// compatible service supports
+type Bucket struct {
+	Endpoint    string
+	Region      string
+	Name        string
+	Credentials sigv4.Credentials
+	Client      *http.Client
+}
+
+// Put stores body as the object named key, replacing any existing object
+func (b Bucket) Put(ctx context.Context, key, contentType string, body []byte) error {
+	url := strings.TrimSuffix(b.Endpoint, "/") + "/" + b.Name + "/" + strings.TrimPrefix(key, "/")
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("Unable to create object storage request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", contentType)
+
+	payloadHash := sha256.Sum256(body)
+	sigv4.Sign(req, hex.EncodeToString(payloadHash[:]), b.Credentials, b.Region, "s3", time.Now())
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Unable to store object %s: %w", key, redact.Error(err))
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Object storage responded to %s with status %d", key, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Package partitions plans the partitions which are added to and dropped
+// from tables that are partitioned by ranges of time.
+package partitions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// Interval is the length of time covered by each partition
+type Interval string
+
+// Supported partition intervals
+const (
+	Daily   Interval = "daily"
+	Weekly  Interval = "weekly"
+	Monthly Interval = "monthly"
+)
+
+// namePrefix starts the name of every partition, which is followed by the
+// first day it covers
+const namePrefix = "p"
+
+// Policy describes the partitions which a table should have
+type Policy struct {
+	Interval Interval
+
+	// Ahead is how many partitions after the current one are kept ready
+	Ahead int
+
+	// Retention is how long a partition is kept after its range has ended,
+	// zero keeps every partition
+	Retention time.Duration
+}
+
+// Plan is the changes which bring a table in line with its policy
+type Plan struct {
+	Add  []dbadmin.Partition
+	Drop []string
+
+	// Ahead counts the partitions after the current one before the plan is
+	// carried out, and Behind how many partitions are missing or expired
+	Ahead  int
+	Behind int
+}
+
+// Start returns the beginning of the interval which contains t
+func (i Interval) Start(t time.Time) (time.Time, error) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch i {
+	case Daily:
+		return day, nil
+	case Weekly:
+		// Weeks start on Monday
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)), nil
+	case Monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("Unknown partition interval: %s", i)
+}
+
+// Next returns the beginning of the interval after the one which starts at
+// start
+func (i Interval) Next(start time.Time) time.Time {
+	switch i {
+	case Weekly:
+		return start.AddDate(0, 0, 7)
+	case Monthly:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// Name returns the name of the partition which starts at start
+func Name(start time.Time) string {
+	return namePrefix + start.UTC().Format("20060102")
+}
+
+// Compute plans the changes to the existing partitions of a table, which are
+// in order, that satisfy the policy at the time now
+func Compute(existing []dbadmin.Partition, policy Policy, now time.Time) (Plan, error) {
+	var plan Plan
+	if policy.Ahead < 0 {
+		return plan, fmt.Errorf("The number of partitions ahead may not be negative")
+	}
+
+	current, err := policy.Interval.Start(now)
+	if err != nil {
+		return plan, err
+	}
+	currentEnd := policy.Interval.Next(current)
+
+	// Partitions up to the end of this interval, and the ones ahead of it
+	wanted := currentEnd
+	for i := 0; i < policy.Ahead; i++ {
+		wanted = policy.Interval.Next(wanted)
+	}
+
+	var last time.Time
+	for _, partition := range existing {
+		if partition.End.IsZero() {
+			continue
+		}
+		if partition.End.After(last) {
+			last = partition.End
+		}
+		if partition.End.After(currentEnd) {
+			plan.Ahead++
+		}
+	}
+
+	// A table without bounded partitions starts from the current interval,
+	// and ranges which don't line up with the interval are followed by a
+	// short partition which realigns them
+	from := last
+	if from.IsZero() {
+		from = current
+	}
+	for from.Before(wanted) {
+		start, err := policy.Interval.Start(from)
+		if err != nil {
+			return plan, err
+		}
+		end := policy.Interval.Next(start)
+		plan.Add = append(plan.Add, dbadmin.Partition{Name: Name(from), End: end})
+		from = end
+	}
+	plan.Behind = len(plan.Add)
+
+	if policy.Retention > 0 {
+		cutoff := now.Add(-policy.Retention)
+		for i, partition := range existing {
+			// A table always keeps at least one partition
+			if partition.End.IsZero() || partition.End.After(cutoff) || i == len(existing)-1 {
+				continue
+			}
+			plan.Drop = append(plan.Drop, partition.Name)
+		}
+		plan.Behind += len(plan.Drop)
+	}
+
+	return plan, nil
+}
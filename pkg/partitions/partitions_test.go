@@ -0,0 +1,116 @@
+package partitions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestIntervalStart(t *testing.T) {
+	// 2020-01-15 is a Wednesday
+	now := time.Date(2020, 1, 15, 13, 30, 0, 0, time.UTC)
+	for interval, expected := range map[Interval]time.Time{
+		Daily:   day(2020, 1, 15),
+		Weekly:  day(2020, 1, 13),
+		Monthly: day(2020, 1, 1),
+	} {
+		start, err := interval.Start(now)
+		if err != nil || !start.Equal(expected) {
+			t.Errorf("%s: expected %s, got %s (%v)", interval, expected, start, err)
+		}
+	}
+
+	if _, err := Interval("hourly").Start(now); err == nil {
+		t.Errorf("Expected an error for an unknown interval")
+	}
+}
+
+func TestComputeAddsAhead(t *testing.T) {
+	existing := []dbadmin.Partition{
+		{Name: "p20200114", End: day(2020, 1, 15)},
+		{Name: "p20200115", End: day(2020, 1, 16)},
+		{Name: "pmax"},
+	}
+
+	plan, err := Compute(existing, Policy{Interval: Daily, Ahead: 2}, time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []dbadmin.Partition{
+		{Name: "p20200116", End: day(2020, 1, 17)},
+		{Name: "p20200117", End: day(2020, 1, 18)},
+	}
+	if !reflect.DeepEqual(plan.Add, expected) || len(plan.Drop) != 0 {
+		t.Errorf("Unexpected plan: %+v", plan)
+	}
+	if plan.Ahead != 0 || plan.Behind != 2 {
+		t.Errorf("Unexpected schedule, %d ahead and %d behind", plan.Ahead, plan.Behind)
+	}
+
+	// Once carried out there is nothing left to do
+	existing = append(existing[:2], expected...)
+	plan, err = Compute(existing, Policy{Interval: Daily, Ahead: 2}, time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC))
+	if err != nil || len(plan.Add) != 0 || plan.Ahead != 2 || plan.Behind != 0 {
+		t.Errorf("Unexpected plan: %+v (%v)", plan, err)
+	}
+}
+
+func TestComputeRealigns(t *testing.T) {
+	existing := []dbadmin.Partition{{Name: "p0", End: day(2020, 1, 20)}}
+
+	plan, err := Compute(existing, Policy{Interval: Monthly, Ahead: 1}, day(2020, 1, 10))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []dbadmin.Partition{
+		{Name: "p20200120", End: day(2020, 2, 1)},
+		{Name: "p20200201", End: day(2020, 3, 1)},
+	}
+	if !reflect.DeepEqual(plan.Add, expected) {
+		t.Errorf("Unexpected partitions: %+v", plan.Add)
+	}
+}
+
+func TestComputeDropsExpired(t *testing.T) {
+	existing := []dbadmin.Partition{
+		{Name: "p20200101", End: day(2020, 1, 2)},
+		{Name: "p20200102", End: day(2020, 1, 3)},
+		{Name: "p20200103", End: day(2020, 1, 4)},
+	}
+
+	plan, err := Compute(existing, Policy{Interval: Daily, Retention: 24 * time.Hour}, time.Date(2020, 1, 3, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(plan.Drop, []string{"p20200101"}) || len(plan.Add) != 0 {
+		t.Errorf("Unexpected plan: %+v", plan)
+	}
+
+	// The last partition is never dropped
+	plan, err = Compute(existing, Policy{Interval: Daily, Retention: time.Hour}, day(2020, 3, 1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(plan.Drop, []string{"p20200101", "p20200102"}) {
+		t.Errorf("Unexpected partitions dropped: %v", plan.Drop)
+	}
+}
+
+func TestComputeWithoutBoundedPartitions(t *testing.T) {
+	plan, err := Compute([]dbadmin.Partition{{Name: "pmax"}}, Policy{Interval: Weekly}, day(2020, 1, 15))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []dbadmin.Partition{{Name: "p20200113", End: day(2020, 1, 20)}}
+	if !reflect.DeepEqual(plan.Add, expected) {
+		t.Errorf("Unexpected partitions: %+v", plan.Add)
+	}
+}
@@ -0,0 +1,100 @@
+// Package policy evaluates the rules of the operator configuration against
+// the statements of admin plans and the grants of credentials, locally and
+// before anything is changed on a database.
+package policy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// Input is what the rules are evaluated against
+type Input struct {
+	// Annotations of the ManagedDatabase, which may exempt it from rules
+	Annotations map[string]string
+
+	Statements []string
+	Grants     []dbadmin.DatabaseGrant
+}
+
+// Decision is the outcome of evaluating the rules
+type Decision struct {
+	Allowed bool
+
+	// Rule is the name of the rule which denied the input
+	Rule   string
+	Reason string
+
+	// Exempted lists the rules which matched, but which the ManagedDatabase
+	// is exempt from
+	Exempted []string
+}
+
+// Evaluate returns the decision of the first rule which matches the input
+// and which the ManagedDatabase isn't exempt from
+func Evaluate(rules []config.PolicyRule, input Input) (Decision, error) {
+	decision := Decision{Allowed: true}
+	for _, rule := range rules {
+		reason, err := match(rule, input)
+		if err != nil {
+			return Decision{}, err
+		}
+		if reason == "" {
+			continue
+		}
+
+		if rule.ExemptAnnotation != "" && input.Annotations[rule.ExemptAnnotation] == "true" {
+			decision.Exempted = append(decision.Exempted, rule.Name)
+			continue
+		}
+
+		if rule.Message != "" {
+			reason = rule.Message + ": " + reason
+		}
+		return Decision{Allowed: false, Rule: rule.Name, Reason: reason, Exempted: decision.Exempted}, nil
+	}
+	return decision, nil
+}
+
+// match returns why the rule matches the input, or nothing when it doesn't
+func match(rule config.PolicyRule, input Input) (string, error) {
+	for _, expression := range rule.Statements {
+		matcher, err := regexp.Compile(expression)
+		if err != nil {
+			return "", fmt.Errorf("Unable to compile statement pattern of policy rule %s: %w", rule.Name, err)
+		}
+		for _, statement := range input.Statements {
+			if matcher.MatchString(statement) {
+				return fmt.Sprintf("statement matches %s", expression), nil
+			}
+		}
+	}
+
+	for _, pattern := range rule.GrantDatabases {
+		for _, grant := range input.Grants {
+			matched, err := path.Match(pattern, grant.Database)
+			if err != nil {
+				return "", fmt.Errorf("Unable to match grant database pattern of policy rule %s: %w", rule.Name, err)
+			}
+			if matched {
+				return fmt.Sprintf("grant on database %s", grant.Database), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// HasStatementRules returns true if any rule has to see the statements of
+// admin plans, which are otherwise not rendered
+func HasStatementRules(rules []config.PolicyRule) bool {
+	for _, rule := range rules {
+		if len(rule.Statements) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/config"
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+const allowDrop = "dbaoperator.app-sre.redhat.com/allow-drop-table"
+
+var testRules = []config.PolicyRule{
+	{Name: "no-system-grants", GrantDatabases: []string{"mysql", "performance_*"}},
+	{Name: "no-drop-table", Message: "tables are only dropped with approval", Statements: []string{`(?i)^\s*DROP\s+TABLE`}, ExemptAnnotation: allowDrop},
+}
+
+func TestEvaluateStatements(t *testing.T) {
+	input := Input{Statements: []string{"CREATE USER 'dba_v3'", "drop table quay.repository"}}
+
+	decision, err := Evaluate(testRules, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.Allowed || decision.Rule != "no-drop-table" {
+		t.Errorf("Expected the drop to be denied, got %+v", decision)
+	}
+	if expected := `tables are only dropped with approval: statement matches (?i)^\s*DROP\s+TABLE`; decision.Reason != expected {
+		t.Errorf("Expected reason %q, got %q", expected, decision.Reason)
+	}
+
+	input.Annotations = map[string]string{allowDrop: "true"}
+	decision, err = Evaluate(testRules, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decision.Allowed || len(decision.Exempted) != 1 || decision.Exempted[0] != "no-drop-table" {
+		t.Errorf("Expected the annotation to exempt the drop, got %+v", decision)
+	}
+}
+
+func TestEvaluateGrants(t *testing.T) {
+	for database, allowed := range map[string]bool{
+		"quay":               true,
+		"mysql":              false,
+		"performance_schema": false,
+	} {
+		decision, err := Evaluate(testRules, Input{Grants: []dbadmin.DatabaseGrant{
+			{Database: database, Class: dbadmin.GrantClassReadOnly},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision.Allowed != allowed {
+			t.Errorf("%s: expected allowed to be %t, got %+v", database, allowed, decision)
+		}
+	}
+}
+
+func TestHasStatementRules(t *testing.T) {
+	if !HasStatementRules(testRules) {
+		t.Error("Expected the drop rule to need statements")
+	}
+	if HasStatementRules(testRules[:1]) {
+		t.Error("Expected grant rules not to need statements")
+	}
+}
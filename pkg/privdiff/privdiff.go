@@ -0,0 +1,262 @@
+// Package privdiff compares the users, privileges and schema versions of two
+// managed databases, such as the staging and production copies of one
+// application, so that drift is caught before a deploy depends on it.
+package privdiff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// Change describes how a user differs between the environments
+type Change string
+
+const (
+	// ChangeMissing users only exist in the baseline environment
+	ChangeMissing Change = "missing"
+
+	// ChangeExtra users only exist in the compared environment
+	ChangeExtra Change = "extra"
+
+	// ChangeDiffers users exist in both environments, with different
+	// classes or privileges
+	ChangeDiffers Change = "differs"
+)
+
+// User is one user as it was captured from an environment
+type User struct {
+	Username   string                  `json:"username"`
+	Owner      string                  `json:"owner,omitempty"`
+	Class      dbadmin.GrantClass      `json:"class,omitempty"`
+	Privileges []dbadmin.UserPrivilege `json:"privileges,omitempty"`
+}
+
+// Snapshot is what the operator sees of one environment's database
+type Snapshot struct {
+	Environment   string `json:"environment"`
+	SchemaVersion string `json:"schemaVersion"`
+	Users         []User `json:"users"`
+}
+
+// Capture reads the schema version and the users with any of the prefixes
+// from the database
+func Capture(environment string, admin dbadmin.DbAdmin, usernamePrefixes []string) (Snapshot, error) {
+	version, err := admin.GetSchemaVersion()
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("Unable to read the schema version of %s: %w", environment, err)
+	}
+	snapshot := Snapshot{Environment: environment, SchemaVersion: version}
+
+	for _, prefix := range usernamePrefixes {
+		usernames, err := admin.ListUsernames(prefix)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("Unable to list the users of %s: %w", environment, err)
+		}
+		attributes, err := admin.ListUserAttributes(prefix)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("Unable to list the user attributes of %s: %w", environment, err)
+		}
+		privileges, err := admin.ListUserPrivileges(prefix)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("Unable to list the user privileges of %s: %w", environment, err)
+		}
+
+		for _, username := range usernames {
+			user := User{Username: username, Privileges: privileges[username]}
+			if recorded, ok := attributes[username]; ok {
+				user.Owner, user.Class = recorded.Owner, recorded.Class
+			}
+			snapshot.Users = append(snapshot.Users, user)
+		}
+	}
+
+	sort.Slice(snapshot.Users, func(i, j int) bool { return snapshot.Users[i].Username < snapshot.Users[j].Username })
+	return snapshot, nil
+}
+
+// matchKey pairs up the users of two environments. Users which were issued
+// for an object are matched by the object's kind and name, because the
+// usernames of credential requests are derived from their UIDs and the
+// namespaces of the environments usually differ.
+func matchKey(user User) string {
+	parts := strings.Split(user.Owner, "/")
+	if len(parts) == 3 {
+		return parts[0] + "/" + parts[2]
+	}
+	return user.Username
+}
+
+// VersionDiff is set when the environments are at different schema versions
+type VersionDiff struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// UserDiff is one user which differs between the environments
+type UserDiff struct {
+	Key string `json:"key"`
+
+	Change Change `json:"change"`
+
+	FromUsername string             `json:"fromUsername,omitempty"`
+	ToUsername   string             `json:"toUsername,omitempty"`
+	FromClass    dbadmin.GrantClass `json:"fromClass,omitempty"`
+	ToClass      dbadmin.GrantClass `json:"toClass,omitempty"`
+
+	// Missing are the privileges which only the baseline environment holds,
+	// the ones whose absence breaks a deploy
+	Missing []dbadmin.UserPrivilege `json:"missing,omitempty"`
+
+	// Extra are the privileges which only the compared environment holds
+	Extra []dbadmin.UserPrivilege `json:"extra,omitempty"`
+}
+
+// Report is the structured difference between a baseline environment and
+// the environment it is compared to
+type Report struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	SchemaVersion *VersionDiff `json:"schemaVersion,omitempty"`
+
+	Users []UserDiff `json:"users,omitempty"`
+}
+
+// Empty is true when the environments don't differ
+func (r Report) Empty() bool {
+	return r.SchemaVersion == nil && len(r.Users) == 0
+}
+
+// Compare reports how the to environment differs from the from baseline
+func Compare(from, to Snapshot) Report {
+	report := Report{From: from.Environment, To: to.Environment}
+	if from.SchemaVersion != to.SchemaVersion {
+		report.SchemaVersion = &VersionDiff{From: from.SchemaVersion, To: to.SchemaVersion}
+	}
+
+	compared := make(map[string]User, len(to.Users))
+	for _, user := range to.Users {
+		compared[matchKey(user)] = user
+	}
+
+	for _, baseline := range from.Users {
+		key := matchKey(baseline)
+		user, ok := compared[key]
+		if !ok {
+			report.Users = append(report.Users, UserDiff{
+				Key:          key,
+				Change:       ChangeMissing,
+				FromUsername: baseline.Username,
+				FromClass:    baseline.Class,
+				Missing:      baseline.Privileges,
+			})
+			continue
+		}
+		delete(compared, key)
+
+		missing := subtract(baseline.Privileges, user.Privileges)
+		extra := subtract(user.Privileges, baseline.Privileges)
+		if baseline.Class == user.Class && len(missing) == 0 && len(extra) == 0 {
+			continue
+		}
+		report.Users = append(report.Users, UserDiff{
+			Key:          key,
+			Change:       ChangeDiffers,
+			FromUsername: baseline.Username,
+			ToUsername:   user.Username,
+			FromClass:    baseline.Class,
+			ToClass:      user.Class,
+			Missing:      missing,
+			Extra:        extra,
+		})
+	}
+
+	for key, user := range compared {
+		report.Users = append(report.Users, UserDiff{
+			Key:        key,
+			Change:     ChangeExtra,
+			ToUsername: user.Username,
+			ToClass:    user.Class,
+			Extra:      user.Privileges,
+		})
+	}
+
+	sort.Slice(report.Users, func(i, j int) bool { return report.Users[i].Key < report.Users[j].Key })
+	return report
+}
+
+// subtract returns the privileges which are held in left but not in right
+func subtract(left, right []dbadmin.UserPrivilege) []dbadmin.UserPrivilege {
+	held := make(map[dbadmin.UserPrivilege]bool, len(right))
+	for _, privilege := range right {
+		held[privilege] = true
+	}
+
+	var missing []dbadmin.UserPrivilege
+	for _, privilege := range left {
+		if !held[privilege] {
+			missing = append(missing, privilege)
+		}
+	}
+	return missing
+}
+
+// WriteText writes the report in a form meant to be read in a terminal or
+// a CI log
+func (r Report) WriteText(w io.Writer) error {
+	var lines []string
+	if r.Empty() {
+		lines = append(lines, fmt.Sprintf("%s and %s don't differ", r.From, r.To))
+	}
+	if r.SchemaVersion != nil {
+		lines = append(lines, fmt.Sprintf("schema version: %s is at %s, %s is at %s", r.From, r.SchemaVersion.From, r.To, r.SchemaVersion.To))
+	}
+
+	for _, user := range r.Users {
+		switch user.Change {
+		case ChangeMissing:
+			lines = append(lines, fmt.Sprintf("user %s: only in %s", user.Key, r.From))
+		case ChangeExtra:
+			lines = append(lines, fmt.Sprintf("user %s: only in %s", user.Key, r.To))
+		default:
+			lines = append(lines, fmt.Sprintf("user %s: differs", user.Key))
+			if user.FromClass != user.ToClass {
+				lines = append(lines, fmt.Sprintf("  class: %s in %s, %s in %s", user.FromClass, r.From, user.ToClass, r.To))
+			}
+		}
+		for _, privilege := range user.Missing {
+			lines = append(lines, fmt.Sprintf("  - %s", FormatPrivilege(privilege)))
+		}
+		for _, privilege := range user.Extra {
+			lines = append(lines, fmt.Sprintf("  + %s", FormatPrivilege(privilege)))
+		}
+	}
+
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// FormatPrivilege renders a privilege in the syntax of a GRANT statement,
+// with the connected database shown as <database>
+func FormatPrivilege(privilege dbadmin.UserPrivilege) string {
+	database := privilege.Database
+	if database == "" {
+		database = "<database>"
+	}
+	table := privilege.Table
+	if table == "" {
+		table = "*"
+	}
+
+	if privilege.Column != "" {
+		return fmt.Sprintf("%s (%s) ON %s.%s", privilege.Privilege, privilege.Column, database, table)
+	}
+	if database == "*" {
+		return fmt.Sprintf("%s ON *.*", privilege.Privilege)
+	}
+	return fmt.Sprintf("%s ON %s.%s", privilege.Privilege, database, table)
+}
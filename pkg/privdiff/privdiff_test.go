@@ -0,0 +1,168 @@
+package privdiff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+// fakeAdmin serves the reads of Capture, the rest of DbAdmin is left nil
+type fakeAdmin struct {
+	dbadmin.DbAdmin
+
+	version    string
+	usernames  []string
+	attributes map[string]dbadmin.UserAttributes
+	privileges map[string][]dbadmin.UserPrivilege
+}
+
+func (fa *fakeAdmin) GetSchemaVersion() (string, error) {
+	return fa.version, nil
+}
+
+func (fa *fakeAdmin) ListUsernames(usernamePrefix string) ([]string, error) {
+	var matched []string
+	for _, username := range fa.usernames {
+		if len(username) >= len(usernamePrefix) && username[:len(usernamePrefix)] == usernamePrefix {
+			matched = append(matched, username)
+		}
+	}
+	return matched, nil
+}
+
+func (fa *fakeAdmin) ListUserAttributes(usernamePrefix string) (map[string]dbadmin.UserAttributes, error) {
+	return fa.attributes, nil
+}
+
+func (fa *fakeAdmin) ListUserPrivileges(usernamePrefix string) (map[string][]dbadmin.UserPrivilege, error) {
+	return fa.privileges, nil
+}
+
+var (
+	selectAll = dbadmin.UserPrivilege{Privilege: "SELECT"}
+	insertAll = dbadmin.UserPrivilege{Privilege: "INSERT"}
+	execute   = dbadmin.UserPrivilege{Privilege: "EXECUTE"}
+)
+
+func TestCapture(t *testing.T) {
+	admin := &fakeAdmin{
+		version:   "v2",
+		usernames: []string{"dbr_2", "dba_v2", "dbr_1"},
+		attributes: map[string]dbadmin.UserAttributes{
+			"dbr_1": {Owner: "DatabaseCredentialRequest/staging/reporting", Class: dbadmin.GrantClassReadOnly},
+		},
+		privileges: map[string][]dbadmin.UserPrivilege{
+			"dbr_1": {selectAll},
+		},
+	}
+
+	snapshot, err := Capture("staging", admin, []string{"dba_", "dbr_"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if snapshot.SchemaVersion != "v2" || len(snapshot.Users) != 3 {
+		t.Fatalf("Unexpected snapshot: %+v", snapshot)
+	}
+	reporting := snapshot.Users[1]
+	if reporting.Username != "dbr_1" || reporting.Class != dbadmin.GrantClassReadOnly || len(reporting.Privileges) != 1 {
+		t.Errorf("Unexpected user: %+v", reporting)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	staging := Snapshot{
+		Environment:   "staging",
+		SchemaVersion: "v3",
+		Users: []User{
+			{Username: "dba_v2", Privileges: []dbadmin.UserPrivilege{selectAll, insertAll}},
+			{Username: "dba_v3", Privileges: []dbadmin.UserPrivilege{selectAll}},
+			{Username: "dbr_1", Owner: "DatabaseCredentialRequest/staging/reporting", Class: dbadmin.GrantClassReadOnly, Privileges: []dbadmin.UserPrivilege{selectAll}},
+			{Username: "dbr_2", Owner: "DatabaseCredentialRequest/staging/jobs", Class: dbadmin.GrantClassExecute, Privileges: []dbadmin.UserPrivilege{execute}},
+		},
+	}
+	prod := Snapshot{
+		Environment:   "prod",
+		SchemaVersion: "v2",
+		Users: []User{
+			{Username: "dba_v2", Privileges: []dbadmin.UserPrivilege{selectAll}},
+			{Username: "dbr_7", Owner: "DatabaseCredentialRequest/prod/reporting", Class: dbadmin.GrantClassReadOnly, Privileges: []dbadmin.UserPrivilege{selectAll}},
+			{Username: "dbr_8", Owner: "DatabaseCredentialRequest/prod/jobs", Class: dbadmin.GrantClassReadWrite, Privileges: []dbadmin.UserPrivilege{selectAll}},
+			{Username: "dbr_9", Owner: "DatabaseCredentialRequest/prod/debug", Privileges: []dbadmin.UserPrivilege{selectAll}},
+		},
+	}
+
+	report := Compare(staging, prod)
+	if report.Empty() {
+		t.Fatalf("Expected differences")
+	}
+	if report.SchemaVersion == nil || report.SchemaVersion.From != "v3" || report.SchemaVersion.To != "v2" {
+		t.Errorf("Unexpected schema version difference: %+v", report.SchemaVersion)
+	}
+
+	if len(report.Users) != 4 {
+		t.Fatalf("Unexpected user differences: %+v", report.Users)
+	}
+	debug, jobs, v2, v3 := report.Users[0], report.Users[1], report.Users[2], report.Users[3]
+	if jobs.Key != "DatabaseCredentialRequest/jobs" || jobs.Change != ChangeDiffers || jobs.ToClass != dbadmin.GrantClassReadWrite ||
+		len(jobs.Missing) != 1 || jobs.Missing[0] != execute || len(jobs.Extra) != 1 {
+		t.Errorf("Unexpected difference: %+v", jobs)
+	}
+	if debug.Key != "DatabaseCredentialRequest/debug" || debug.Change != ChangeExtra || debug.ToUsername != "dbr_9" {
+		t.Errorf("Unexpected difference: %+v", debug)
+	}
+	if v2.Key != "dba_v2" || v2.Change != ChangeDiffers || len(v2.Missing) != 1 || v2.Missing[0] != insertAll || len(v2.Extra) != 0 {
+		t.Errorf("Unexpected difference: %+v", v2)
+	}
+	if v3.Key != "dba_v3" || v3.Change != ChangeMissing || len(v3.Missing) != 1 {
+		t.Errorf("Unexpected difference: %+v", v3)
+	}
+
+	if !Compare(staging, staging).Empty() {
+		t.Errorf("Expected an environment not to differ from itself")
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	report := Report{
+		From:          "staging",
+		To:            "prod",
+		SchemaVersion: &VersionDiff{From: "v3", To: "v2"},
+		Users: []UserDiff{
+			{Key: "dba_v2", Change: ChangeDiffers, Missing: []dbadmin.UserPrivilege{insertAll}},
+			{Key: "dba_v3", Change: ChangeMissing, Missing: []dbadmin.UserPrivilege{selectAll}},
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := report.WriteText(&buffer); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `schema version: staging is at v3, prod is at v2
+user dba_v2: differs
+  - INSERT ON <database>.*
+user dba_v3: only in staging
+  - SELECT ON <database>.*
+`
+	if buffer.String() != expected {
+		t.Errorf("Unexpected text:\n%s", buffer.String())
+	}
+}
+
+func TestFormatPrivilege(t *testing.T) {
+	testCases := []struct {
+		privilege dbadmin.UserPrivilege
+		expected  string
+	}{
+		{dbadmin.UserPrivilege{Privilege: "SELECT"}, "SELECT ON <database>.*"},
+		{dbadmin.UserPrivilege{Database: "*", Privilege: "REPLICATION SLAVE"}, "REPLICATION SLAVE ON *.*"},
+		{dbadmin.UserPrivilege{Database: "audit", Table: "events", Privilege: "INSERT"}, "INSERT ON audit.events"},
+		{dbadmin.UserPrivilege{Table: "user", Column: "email", Privilege: "SELECT"}, "SELECT (email) ON <database>.user"},
+	}
+
+	for _, tc := range testCases {
+		if formatted := FormatPrivilege(tc.privilege); formatted != tc.expected {
+			t.Errorf("Unexpected format of %+v: %s", tc.privilege, formatted)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package progress parses the progress reports which migration containers
+// write to their output while they run.
+package progress
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Prefix marks a line of container output as a progress report, the rest of
+// the line is a JSON encoded Report
+const Prefix = "DBA_OP_PROGRESS "
+
+// maxLineLength bounds the lines which are read, a report which includes a
+// long statement is still expected to fit
+const maxLineLength = 1024 * 1024
+
+// Report is the progress of a migration at one point in time, all of the
+// fields are optional
+type Report struct {
+	// Step is the number of the step the migration is on, starting from 1
+	Step int `json:"step,omitempty"`
+
+	// Total is the number of steps in the migration
+	Total int `json:"total,omitempty"`
+
+	// Statement is the statement currently being executed
+	Statement string `json:"statement,omitempty"`
+
+	// RowsProcessed is the number of rows backfilled or copied so far
+	RowsProcessed int64 `json:"rowsProcessed,omitempty"`
+}
+
+// Ratio returns how much of the migration has been completed between 0 and
+// 1, and false if the report doesn't say how many steps there are
+func (r Report) Ratio() (float64, bool) {
+	if r.Total <= 0 || r.Step < 0 {
+		return 0, false
+	}
+	if r.Step > r.Total {
+		return 1, true
+	}
+	return float64(r.Step) / float64(r.Total), true
+}
+
+// Parse reads a single progress report line
+func Parse(line string) (Report, error) {
+	if !strings.HasPrefix(line, Prefix) {
+		return Report{}, fmt.Errorf("Progress reports must start with %q", Prefix)
+	}
+
+	var report Report
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, Prefix)), &report); err != nil {
+		return Report{}, fmt.Errorf("Unable to parse progress report: %w", err)
+	}
+	return report, nil
+}
+
+// Latest returns the last valid progress report in the output, and nil if
+// there are none. Other output and malformed reports are skipped, so that a
+// container which logs freely doesn't lose its progress.
+func Latest(output io.Reader) (*Report, error) {
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+
+	var latest *Report
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if !strings.HasPrefix(line, Prefix) {
+			continue
+		}
+		if report, err := Parse(line); err == nil {
+			latest = &report
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return latest, fmt.Errorf("Unable to read migration output: %w", err)
+	}
+
+	return latest, nil
+}
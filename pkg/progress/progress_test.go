@@ -0,0 +1,57 @@
+package progress
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLatest(t *testing.T) {
+	output := strings.Join([]string{
+		"starting migration",
+		`DBA_OP_PROGRESS {"step":1,"total":3,"statement":"ALTER TABLE a ADD COLUMN b INT"}`,
+		`DBA_OP_PROGRESS {"step":2,"total":3,"rowsProcessed":1500}`,
+		`DBA_OP_PROGRESS {"step":3,`,
+		"backfilling",
+	}, "\n")
+
+	report, err := Latest(strings.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report == nil {
+		t.Fatal("expected a progress report")
+	}
+	if report.Step != 2 || report.Total != 3 || report.RowsProcessed != 1500 || report.Statement != "" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestLatestWithoutReports(t *testing.T) {
+	report, err := Latest(strings.NewReader("nothing to see\nDBA_OP_PROGRESSION {}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report != nil {
+		t.Errorf("expected no report, got %+v", report)
+	}
+}
+
+func TestRatio(t *testing.T) {
+	testCases := []struct {
+		report   Report
+		expected float64
+		known    bool
+	}{
+		{Report{Step: 1, Total: 4}, 0.25, true},
+		{Report{Step: 5, Total: 4}, 1, true},
+		{Report{Step: 0, Total: 4}, 0, true},
+		{Report{RowsProcessed: 10}, 0, false},
+	}
+
+	for _, tc := range testCases {
+		ratio, known := tc.report.Ratio()
+		if known != tc.known || ratio != tc.expected {
+			t.Errorf("%+v: expected %v %v, got %v %v", tc.report, tc.expected, tc.known, ratio, known)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/redact/redacttest"
+	"github.com/app-sre/dba-operator/pkg/xerrors"
+)
+
+const seededPassword = "s33ded-Pa55word"
+
+func TestStringScrubsCredentialPatterns(t *testing.T) {
+	inputs := []string{
+		"invalid DSN: admin:" + seededPassword + "@tcp(db.internal:3306)/quay",
+		"mysql://admin:" + seededPassword + "@db.internal/quay",
+		fmt.Sprintf("%+v", struct{ User, Passwd string }{"admin", seededPassword}),
+		"host=db.internal password=" + seededPassword + " sslmode=require",
+		`{"password": "` + seededPassword + `"}`,
+		"CREATE USER 'dba_v1'@'%' IDENTIFIED BY '" + seededPassword + "'",
+		"CREATE USER 'dba_v1'@'%' IDENTIFIED WITH caching_sha2_password BY '" + seededPassword + "'",
+	}
+
+	for _, input := range inputs {
+		redacttest.AssertNoSecrets(t, String(input), seededPassword)
+	}
+}
+
+func TestStringScrubsDSNPasswordsWithDelimiters(t *testing.T) {
+	for _, password := range []string{"pa:ss", "pa/ss", "p@ss", "p@:/s@s", "/pass", "pass@"} {
+		for input, expected := range map[string]string{
+			"invalid DSN: admin:" + password + "@tcp(db.internal:3306)/quay":    "invalid DSN: admin:" + Placeholder + "@tcp(db.internal:3306)/quay",
+			"mysql://admin:" + password + "@db.internal/quay":                   "mysql://admin:" + Placeholder + "@db.internal/quay",
+			"admin:" + password + "@/quay and admin:other@unix(/tmp/sock)/quay": "admin:" + Placeholder + "@/quay and admin:" + Placeholder + "@unix(/tmp/sock)/quay",
+		} {
+			redacted := String(input)
+			redacttest.AssertNoSecrets(t, redacted, password)
+			if redacted != expected {
+				t.Errorf("Expected %s, got %s", expected, redacted)
+			}
+		}
+	}
+}
+
+func TestStringScrubsSeededSecrets(t *testing.T) {
+	redacttest.AssertNoSecrets(t, String("unexpected value "+seededPassword, seededPassword), seededPassword)
+}
+
+func TestStringLeavesOrdinaryMessagesAlone(t *testing.T) {
+	for _, message := range []string{
+		"Unable to create new user dba_v1: Error 1396: Operation CREATE USER failed for 'dba_v1'@'%'",
+		"Back-off pulling image quay.io/app-sre/migrations:v3@sha256:0123456789abcdef",
+		"Migration container image docker://quay.io/app:v1@sha256:0123456789abcdef is not pinned",
+	} {
+		if redacted := String(message); redacted != message {
+			t.Errorf("Message was unexpectedly altered: %s", redacted)
+		}
+	}
+}
+
+func TestErrorPreservesChainAndTemporary(t *testing.T) {
+	cause := xerrors.NewTempErrorf("connection to admin:%s@tcp(db:3306)/quay refused", seededPassword)
+	wrapped := fmt.Errorf("Unable to connect: %w", Error(cause))
+
+	redacttest.AssertNoSecrets(t, wrapped.Error(), seededPassword)
+
+	var enhanced xerrors.EnhancedError
+	if !errors.As(wrapped, &enhanced) || !enhanced.Temporary() {
+		t.Errorf("Redacted error should remain temporary")
+	}
+	if !errors.Is(wrapped, cause) {
+		t.Errorf("Redacted error should still wrap its cause")
+	}
+}
+
+func TestLoggerRedactsEverything(t *testing.T) {
+	recorder := redacttest.NewRecordingLogger()
+	log := Logger(recorder, seededPassword).WithName("test").WithValues("dsn", "admin:"+seededPassword+"@tcp(db)/quay")
+
+	log.Info("rotated to "+seededPassword, "password", seededPassword)
+	log.V(1).Info("debug", "value", seededPassword)
+	log.Error(errors.New("bad password "+seededPassword), "failed", "secret", seededPassword)
+
+	redacttest.AssertNoSecrets(t, recorder.Output(), seededPassword)
+}
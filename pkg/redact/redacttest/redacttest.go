@@ -0,0 +1,90 @@
+// Package redacttest contains helpers for asserting that seeded secret values
+// never appear in the errors or log output of the code under test.
+package redacttest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+// RecordingLogger is a logr.Logger which renders every line it receives,
+// including names, values, and errors, into memory for later inspection.
+type RecordingLogger struct {
+	output *recording
+	name   string
+	values []interface{}
+}
+
+type recording struct {
+	sync.Mutex
+	lines []string
+}
+
+// NewRecordingLogger instantiates an empty RecordingLogger
+func NewRecordingLogger() *RecordingLogger {
+	return &RecordingLogger{output: &recording{}}
+}
+
+// Output returns everything that has been logged, one line per entry
+func (rl *RecordingLogger) Output() string {
+	rl.output.Lock()
+	defer rl.output.Unlock()
+	return strings.Join(rl.output.lines, "\n")
+}
+
+func (rl *RecordingLogger) record(err error, msg string, keysAndValues []interface{}) {
+	line := fmt.Sprintf("%s %s %v %v", rl.name, msg, rl.values, keysAndValues)
+	if err != nil {
+		line = fmt.Sprintf("%s error=%q", line, err.Error())
+	}
+
+	rl.output.Lock()
+	defer rl.output.Unlock()
+	rl.output.lines = append(rl.output.lines, line)
+}
+
+// Info implements logr.Logger
+func (rl *RecordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	rl.record(nil, msg, keysAndValues)
+}
+
+// Enabled implements logr.Logger
+func (rl *RecordingLogger) Enabled() bool {
+	return true
+}
+
+// Error implements logr.Logger
+func (rl *RecordingLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	rl.record(err, msg, keysAndValues)
+}
+
+// V implements logr.Logger, all levels are recorded
+func (rl *RecordingLogger) V(level int) logr.InfoLogger {
+	return rl
+}
+
+// WithValues implements logr.Logger
+func (rl *RecordingLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	values := append(append([]interface{}{}, rl.values...), keysAndValues...)
+	return &RecordingLogger{output: rl.output, name: rl.name, values: values}
+}
+
+// WithName implements logr.Logger
+func (rl *RecordingLogger) WithName(name string) logr.Logger {
+	return &RecordingLogger{output: rl.output, name: rl.name + "." + name, values: rl.values}
+}
+
+// AssertNoSecrets will fail the test if any of the seeded secrets appear
+// anywhere in the haystack.
+func AssertNoSecrets(t testing.TB, haystack string, secrets ...string) {
+	t.Helper()
+	for _, secret := range secrets {
+		if secret != "" && strings.Contains(haystack, secret) {
+			t.Errorf("Secret value %q leaked into output: %s", secret, haystack)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Credentials log in to a registry
+type Credentials struct {
+	Username string
+	Password string
+}
+
+type dockerConfig struct {
+	Auths map[string]struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	} `json:"auths"`
+}
+
+// CredentialsFromDockerConfig reads the credentials of the registry from the
+// contents of a kubernetes.io/dockerconfigjson Secret. Its entries may be
+// written as either a host or a URL.
+func CredentialsFromDockerConfig(data []byte, registry string) (*Credentials, error) {
+	var config dockerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("Unable to read docker config: %w", err)
+	}
+
+	for server, entry := range config.Auths {
+		host := strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+		if slash := strings.IndexByte(host, '/'); slash >= 0 {
+			host = host[:slash]
+		}
+		if host != registry {
+			continue
+		}
+
+		if entry.Username != "" {
+			return &Credentials{Username: entry.Username, Password: entry.Password}, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decode the credentials of registry %s: %w", registry, err)
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Credentials of registry %s aren't written as username:password", registry)
+		}
+		return &Credentials{Username: parts[0], Password: parts[1]}, nil
+	}
+	return nil, fmt.Errorf("Docker config contains no credentials for registry %s", registry)
+}
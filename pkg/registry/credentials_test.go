@@ -0,0 +1,33 @@
+package registry
+
+import (
+	"testing"
+)
+
+func TestCredentialsFromDockerConfig(t *testing.T) {
+	config := []byte(`{"auths": {
+		"https://quay.io/v1/": {"auth": "cm9ib3Q6c2VjcmV0"},
+		"registry.local:5000": {"username": "puller", "password": "hunter2"}
+	}}`)
+
+	for registry, expected := range map[string]Credentials{
+		"quay.io":             {Username: "robot", Password: "secret"},
+		"registry.local:5000": {Username: "puller", Password: "hunter2"},
+	} {
+		creds, err := CredentialsFromDockerConfig(config, registry)
+		if err != nil {
+			t.Errorf("Unable to read credentials of %s: %v", registry, err)
+			continue
+		}
+		if *creds != expected {
+			t.Errorf("Unexpected credentials for %s: %+v", registry, creds)
+		}
+	}
+
+	if _, err := CredentialsFromDockerConfig(config, "docker.io"); err == nil {
+		t.Errorf("Expected an error for a registry without credentials")
+	}
+	if _, err := CredentialsFromDockerConfig([]byte(`{"auths": {"quay.io": {"auth": "bm9jb2xvbg=="}}}`), "quay.io"); err == nil {
+		t.Errorf("Expected an error for credentials without a password")
+	}
+}
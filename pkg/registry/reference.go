@@ -0,0 +1,132 @@
+// Package registry reads manifests and blobs from OCI registries, as
+// described by the distribution spec.
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Scheme prefixes the references of artifacts other than images
+const Scheme = "oci://"
+
+// dockerHub is the registry of images which don't name one
+const dockerHub = "docker.io"
+
+var (
+	repositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagPattern        = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]{0,127}$`)
+	digestPattern     = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// Reference locates an artifact in a registry, by tag or by digest. A
+// reference with both is pulled by digest.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference reads a reference written as
+// oci://registry/repository:tag or oci://registry/repository@sha256:digest
+func ParseReference(ref string) (Reference, error) {
+	if !strings.HasPrefix(ref, Scheme) {
+		return Reference{}, fmt.Errorf("Reference %s doesn't start with %s", ref, Scheme)
+	}
+	remainder := strings.TrimPrefix(ref, Scheme)
+
+	slash := strings.IndexByte(remainder, '/')
+	if slash <= 0 {
+		return Reference{}, fmt.Errorf("Reference %s doesn't name a registry and repository", ref)
+	}
+	parsed, err := parseRepository(ref, remainder[:slash], remainder[slash+1:])
+	if err != nil {
+		return Reference{}, err
+	}
+	if parsed.Tag == "" && parsed.Digest == "" {
+		return Reference{}, fmt.Errorf("Reference %s requires a tag or digest", ref)
+	}
+	return parsed, nil
+}
+
+// ParseImage reads the image of a container as the container runtime does:
+// images which don't name a registry are on Docker Hub, and images without
+// a tag or digest are tagged latest
+func ParseImage(image string) (Reference, error) {
+	registry, remainder := dockerHub, image
+	if slash := strings.IndexByte(image, '/'); slash > 0 {
+		if first := image[:slash]; strings.ContainsAny(first, ".:") || first == "localhost" {
+			registry, remainder = first, image[slash+1:]
+		}
+	}
+	if registry == dockerHub && !strings.Contains(remainder, "/") {
+		remainder = "library/" + remainder
+	}
+
+	parsed, err := parseRepository(image, registry, remainder)
+	if err != nil {
+		return Reference{}, err
+	}
+	if parsed.Tag == "" && parsed.Digest == "" {
+		parsed.Tag = "latest"
+	}
+	return parsed, nil
+}
+
+// parseRepository reads the repository with its tag and digest
+func parseRepository(ref, registry, remainder string) (Reference, error) {
+	parsed := Reference{Registry: registry}
+
+	if at := strings.IndexByte(remainder, '@'); at >= 0 {
+		parsed.Digest = remainder[at+1:]
+		remainder = remainder[:at]
+		if !digestPattern.MatchString(parsed.Digest) {
+			return Reference{}, fmt.Errorf("Reference %s has an invalid digest", ref)
+		}
+	}
+	if colon := strings.LastIndexByte(remainder, ':'); colon >= 0 {
+		parsed.Tag = remainder[colon+1:]
+		remainder = remainder[:colon]
+		if !tagPattern.MatchString(parsed.Tag) {
+			return Reference{}, fmt.Errorf("Reference %s has an invalid tag", ref)
+		}
+	}
+	parsed.Repository = remainder
+
+	if !repositoryPattern.MatchString(parsed.Repository) {
+		return Reference{}, fmt.Errorf("Reference %s has an invalid repository", ref)
+	}
+	return parsed, nil
+}
+
+// String writes the reference as ParseReference reads it
+func (r Reference) String() string {
+	return Scheme + r.Image()
+}
+
+// Image writes the reference as the image of a container
+func (r Reference) Image() string {
+	ref := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		ref += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		ref += "@" + r.Digest
+	}
+	return ref
+}
+
+// Pinned returns the reference to the digest only, which can't be moved
+func (r Reference) Pinned(digest string) Reference {
+	return Reference{Registry: r.Registry, Repository: r.Repository, Digest: digest}
+}
+
+// Target is the tag or digest which the manifest is fetched by
+func (r Reference) Target() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"testing"
+)
+
+const testDigest = "sha256:0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+
+func TestParseReference(t *testing.T) {
+	for ref, expected := range map[string]Reference{
+		"oci://quay.io/app/migrations:v42":                      {Registry: "quay.io", Repository: "app/migrations", Tag: "v42"},
+		"oci://registry.local:5000/migrations:v1.2":             {Registry: "registry.local:5000", Repository: "migrations", Tag: "v1.2"},
+		"oci://quay.io/app/migrations@" + testDigest:            {Registry: "quay.io", Repository: "app/migrations", Digest: testDigest},
+		"oci://quay.io/app/schema-migrations:v42@" + testDigest: {Registry: "quay.io", Repository: "app/schema-migrations", Tag: "v42", Digest: testDigest},
+	} {
+		parsed, err := ParseReference(ref)
+		if err != nil {
+			t.Errorf("Unable to parse %s: %v", ref, err)
+			continue
+		}
+		if parsed != expected {
+			t.Errorf("Unexpected reference for %s: %+v", ref, parsed)
+		}
+		if parsed.String() != ref {
+			t.Errorf("Reference %s is written as %s", ref, parsed.String())
+		}
+	}
+}
+
+func TestParseReferenceInvalid(t *testing.T) {
+	for _, ref := range []string{
+		"quay.io/app/migrations:v42",
+		"https://quay.io/app/migrations:v42",
+		"oci://quay.io",
+		"oci://quay.io/app/migrations",
+		"oci:///migrations:v1",
+		"oci://quay.io/App/migrations:v42",
+		"oci://quay.io/app/migrations:-v42",
+		"oci://quay.io/app/migrations@sha256:1234",
+		"oci://quay.io/app/migrations@md5:0f1e2d3c4b5a69788796a5b4c3d2e1f0",
+	} {
+		if _, err := ParseReference(ref); err == nil {
+			t.Errorf("Expected an error parsing %s", ref)
+		}
+	}
+}
+
+func TestParseImage(t *testing.T) {
+	for image, expected := range map[string]Reference{
+		"quay.io/app/migrate:v1":                    {Registry: "quay.io", Repository: "app/migrate", Tag: "v1"},
+		"localhost/migrate":                         {Registry: "localhost", Repository: "migrate", Tag: "latest"},
+		"registry.local:5000/migrate@" + testDigest: {Registry: "registry.local:5000", Repository: "migrate", Digest: testDigest},
+		"alembic:1.4":                               {Registry: "docker.io", Repository: "library/alembic", Tag: "1.4"},
+		"app/migrate":                               {Registry: "docker.io", Repository: "app/migrate", Tag: "latest"},
+	} {
+		parsed, err := ParseImage(image)
+		if err != nil {
+			t.Errorf("Unable to parse %s: %v", image, err)
+			continue
+		}
+		if parsed != expected {
+			t.Errorf("Unexpected reference for %s: %+v", image, parsed)
+		}
+	}
+
+	for _, image := range []string{"", "Quay.io/App/migrate", "quay.io/app/migrate:v1@sha256:1234"} {
+		if _, err := ParseImage(image); err == nil {
+			t.Errorf("Expected an error parsing %q", image)
+		}
+	}
+}
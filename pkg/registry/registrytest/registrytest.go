@@ -0,0 +1,140 @@
+// Package registrytest contains a registry which serves one repository to
+// the tests of the code which pulls from registries.
+package registrytest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/registry"
+)
+
+// The credentials which the registry issues pull tokens for
+const (
+	Username = "robot"
+	Password = "secret"
+)
+
+const token = "pull-token"
+
+// Server serves the manifests and blobs of one repository over HTTPS, and
+// only to clients which fetched a token first
+type Server struct {
+	*httptest.Server
+	t          *testing.T
+	repository string
+
+	lock      sync.Mutex
+	manifests map[string][]byte
+	blobs     map[string][]byte
+}
+
+// NewServer starts a registry for the repository, it is closed at the end of
+// the test
+func NewServer(t *testing.T, repository string) *Server {
+	server := &Server{
+		t:          t,
+		repository: repository,
+		manifests:  make(map[string][]byte),
+		blobs:      make(map[string][]byte),
+	}
+	server.Server = httptest.NewTLSServer(http.HandlerFunc(server.serve))
+	return server
+}
+
+// Credentials returns the credentials which the registry accepts
+func (s *Server) Credentials() *registry.Credentials {
+	return &registry.Credentials{Username: Username, Password: Password}
+}
+
+// Reference returns the reference of the tag in the repository
+func (s *Server) Reference(tag string) registry.Reference {
+	return registry.Reference{Registry: strings.TrimPrefix(s.URL, "https://"), Repository: s.repository, Tag: tag}
+}
+
+// Blob stores the content, and returns its descriptor
+func (s *Server) Blob(content []byte, annotations map[string]string) registry.Descriptor {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	digest := registry.Digest(content)
+	s.blobs[digest] = content
+	return registry.Descriptor{Digest: digest, Size: int64(len(content)), Annotations: annotations}
+}
+
+// Manifest stores the manifest under its digest, and under the tag unless it
+// is empty, and returns its digest
+func (s *Server) Manifest(tag string, manifest registry.Manifest) string {
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	digest := registry.Digest(body)
+	s.manifests[digest] = body
+	if tag != "" {
+		s.manifests[tag] = body
+	}
+	return digest
+}
+
+// PushFiles stores the files as oras push does, with one layer for each file
+// titled with its name, and returns the digest of the manifest
+func (s *Server) PushFiles(tag string, files map[string]string) string {
+	var manifest registry.Manifest
+	for name, content := range files {
+		manifest.Layers = append(manifest.Layers, s.Blob([]byte(content), map[string]string{"org.opencontainers.image.title": name}))
+	}
+	return s.Manifest(tag, manifest)
+}
+
+// Tag points the tag at the manifest which another tag or digest has
+func (s *Server) Tag(tag, target string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.manifests[tag] = s.manifests[target]
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		if user, password, ok := r.BasicAuth(); !ok || user != Username || password != Password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if scope := r.URL.Query().Get("scope"); scope != "repository:"+s.repository+":pull" {
+			s.t.Errorf("Unexpected token scope: %s", scope)
+		}
+		w.Write([]byte(`{"token": "` + token + `"}`))
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+token {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+s.URL+`/token",service="registrytest"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	prefix := "/v2/" + s.repository + "/"
+	var content []byte
+	switch {
+	case strings.HasPrefix(r.URL.Path, prefix+"manifests/"):
+		content = s.manifests[strings.TrimPrefix(r.URL.Path, prefix+"manifests/")]
+	case strings.HasPrefix(r.URL.Path, prefix+"blobs/"):
+		content = s.blobs[strings.TrimPrefix(r.URL.Path, prefix+"blobs/")]
+	}
+	if content == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Write(content)
+}
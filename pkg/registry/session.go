@@ -0,0 +1,255 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MaxManifestSize bounds the manifests which are read
+const MaxManifestSize = 1 << 20
+
+// ManifestAccept lists the manifest types which are understood, indexes of
+// multi-platform images are only ever read for their digest
+const ManifestAccept = "application/vnd.oci.image.manifest.v1+json, " +
+	"application/vnd.oci.image.index.v1+json, " +
+	"application/vnd.docker.distribution.manifest.v2+json, " +
+	"application/vnd.docker.distribution.manifest.list.v2+json"
+
+// dockerHubAPI is where the API of Docker Hub is served
+const dockerHubAPI = "registry-1.docker.io"
+
+// Descriptor points at a blob of a manifest
+type Descriptor struct {
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest lists the layers of an artifact
+type Manifest struct {
+	Layers []Descriptor `json:"layers"`
+}
+
+// ErrNotFound is returned for manifests and blobs which don't exist
+var ErrNotFound = fmt.Errorf("Not found")
+
+// Session makes the requests of one pull over HTTPS, reusing the token which
+// the registry issued for the first of them
+type Session struct {
+	client *http.Client
+	ref    Reference
+	creds  *Credentials
+
+	token string
+	basic bool
+}
+
+// NewSession reads from the repository of the reference, logging in with the
+// credentials when the registry asks for them. The default client is used
+// when client is nil.
+func NewSession(client *http.Client, ref Reference, creds *Credentials) *Session {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Session{client: client, ref: ref, creds: creds}
+}
+
+// Manifest fetches the manifest with the tag or digest, and returns it with
+// its digest. The digest is checked when the manifest is fetched by digest.
+func (s *Session) Manifest(ctx context.Context, target string) ([]byte, string, error) {
+	body, err := s.get(ctx, "/manifests/"+target, ManifestAccept, MaxManifestSize)
+	if err != nil {
+		return nil, "", err
+	}
+	digest := Digest(body)
+	if digestPattern.MatchString(target) && digest != target {
+		return nil, "", fmt.Errorf("Manifest %s has digest %s", target, digest)
+	}
+	return body, digest, nil
+}
+
+// Blob fetches the blob, and checks that it has its digest and size
+func (s *Session) Blob(ctx context.Context, blob Descriptor) ([]byte, error) {
+	if !digestPattern.MatchString(blob.Digest) {
+		return nil, fmt.Errorf("Blob has an unsupported digest: %s", blob.Digest)
+	}
+	if blob.Size < 0 {
+		return nil, fmt.Errorf("Blob %s has a negative size", blob.Digest)
+	}
+	body, err := s.get(ctx, "/blobs/"+blob.Digest, "", blob.Size)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) != blob.Size || Digest(body) != blob.Digest {
+		return nil, fmt.Errorf("Blob doesn't match its digest %s", blob.Digest)
+	}
+	return body, nil
+}
+
+// get reads at most limit bytes from the path below the repository. A
+// registry which challenges the request is logged in to and asked again.
+func (s *Session) get(ctx context.Context, path, accept string, limit int64) ([]byte, error) {
+	host := s.ref.Registry
+	if host == dockerHub {
+		host = dockerHubAPI
+	}
+	endpoint := "https://" + host + "/v2/" + s.ref.Repository + path
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		s.authorize(req)
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, limit+1))
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			if err := s.login(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		case resp.StatusCode == http.StatusNotFound:
+			return nil, ErrNotFound
+		case resp.StatusCode != http.StatusOK:
+			return nil, fmt.Errorf("Registry responded with %s", resp.Status)
+		case int64(len(body)) > limit:
+			return nil, fmt.Errorf("Registry responded with more than %d bytes", limit)
+		}
+		return body, nil
+	}
+}
+
+func (s *Session) authorize(req *http.Request) {
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	} else if s.basic && s.creds != nil {
+		req.SetBasicAuth(s.creds.Username, s.creds.Password)
+	}
+}
+
+// login answers the challenge of the registry, either by sending the
+// credentials with every request or by exchanging them for a pull token
+func (s *Session) login(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if s.creds == nil {
+			return fmt.Errorf("Registry requires credentials")
+		}
+		s.basic = true
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("Registry requires unsupported authentication: %s", scheme)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme != "https" {
+		return fmt.Errorf("Registry named an invalid token realm: %s", params["realm"])
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", "repository:"+s.ref.Repository+":pull")
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if s.creds != nil {
+		req.SetBasicAuth(s.creds.Username, s.creds.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Unable to fetch registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Registry token service responded with %s", resp.Status)
+	}
+
+	var issued struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, MaxManifestSize)).Decode(&issued); err != nil {
+		return fmt.Errorf("Unable to read registry token: %w", err)
+	}
+	s.token = issued.Token
+	if s.token == "" {
+		s.token = issued.AccessToken
+	}
+	if s.token == "" {
+		return fmt.Errorf("Registry token service issued no token")
+	}
+	return nil
+}
+
+// parseChallenge reads the scheme and the parameters of a WWW-Authenticate
+// header, such as Bearer realm="https://auth.example.com/token",service="x"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := make(map[string]string)
+	challenge = strings.TrimSpace(challenge)
+	space := strings.IndexByte(challenge, ' ')
+	if space < 0 {
+		return challenge, params
+	}
+	scheme, rest := challenge[:space], challenge[space+1:]
+
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		equals := strings.IndexByte(rest, '=')
+		if equals < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:equals]))
+		rest = rest[equals+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				break
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else if comma := strings.IndexByte(rest, ','); comma >= 0 {
+			value, rest = rest[:comma], rest[comma:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+	}
+	return scheme, params
+}
+
+// Digest returns the sha256 digest of the content, as registries write it
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,17 @@
+package registry
+
+import (
+	"testing"
+)
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:app:pull,push"`)
+	if scheme != "Bearer" || params["realm"] != "https://auth.example.com/token" || params["service"] != "registry.example.com" || params["scope"] != "repository:app:pull,push" {
+		t.Errorf("Unexpected challenge: %s %v", scheme, params)
+	}
+
+	scheme, params = parseChallenge(`Basic realm=registry`)
+	if scheme != "Basic" || params["realm"] != "registry" {
+		t.Errorf("Unexpected challenge: %s %v", scheme, params)
+	}
+}
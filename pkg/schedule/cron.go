@@ -0,0 +1,182 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchLimit bounds how far ahead Next looks, expressions such as the
+// 30th of February never match
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// Cron is a standard five field cron expression, evaluated in UTC
+type Cron struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+
+	// Restricting both the day of the month and the day of the week matches
+	// either of them, as in cron
+	anyDay     bool
+	anyWeekday bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = func() map[string]int {
+	names := make(map[string]int, len(weekdays))
+	for name, day := range weekdays {
+		names[name] = int(day)
+	}
+	return names
+}()
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	// 7 is accepted as Sunday
+	{name: "day of week", min: 0, max: 7, names: weekdayNames},
+}
+
+// ParseCron parses an expression of minute, hour, day of month, month and day
+// of week fields. Each field is *, a value, a range or a comma separated list
+// of them, optionally followed by a /step.
+func ParseCron(expression string) (Cron, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return Cron{}, fmt.Errorf("Cron expression must have %d fields: %s", len(cronFields), expression)
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return Cron{}, err
+		}
+		sets[i] = set
+	}
+
+	// Fold Sunday as 7 into 0
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return Cron{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}, nil
+}
+
+func parseCronValue(value string, field cronField) (int, error) {
+	if number, ok := field.names[strings.ToLower(value)]; ok {
+		return number, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < field.min || number > field.max {
+		return 0, fmt.Errorf("Invalid %s in cron expression: %s", field.name, value)
+	}
+	return number, nil
+}
+
+func parseCronField(expression string, field cronField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(expression, ",") {
+		step := 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			parsed, err := strconv.Atoi(part[slash+1:])
+			if err != nil || parsed < 1 {
+				return 0, fmt.Errorf("Invalid step for %s in cron expression: %s", field.name, part)
+			}
+			step = parsed
+			part = part[:slash]
+		}
+
+		first, last := field.min, field.max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if first, err = parseCronValue(bounds[0], field); err != nil {
+				return 0, err
+			}
+			if last, err = parseCronValue(bounds[1], field); err != nil {
+				return 0, err
+			}
+			if first > last {
+				return 0, fmt.Errorf("Invalid range for %s in cron expression: %s", field.name, part)
+			}
+		default:
+			value, err := parseCronValue(part, field)
+			if err != nil {
+				return 0, err
+			}
+			first = value
+			last = value
+			if step > 1 {
+				last = field.max
+			}
+		}
+
+		for value := first; value <= last; value += step {
+			set |= 1 << uint(value)
+		}
+	}
+	return set, nil
+}
+
+func (c Cron) matchesDay(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+// Next returns the first time after the one given which the expression
+// matches, or the zero time if it never matches
+func (c Cron) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+	for t.Before(limit) {
+		switch {
+		case c.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case c.hours&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case c.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,55 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// 2019-11-02 is a Saturday
+	testCases := []struct {
+		expression string
+		after      time.Time
+		next       time.Time
+	}{
+		{"0 3 * * 0", time.Date(2019, 11, 2, 12, 0, 0, 0, time.UTC), time.Date(2019, 11, 3, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * 0", time.Date(2019, 11, 3, 3, 0, 0, 0, time.UTC), time.Date(2019, 11, 10, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * 7", time.Date(2019, 11, 2, 12, 0, 0, 0, time.UTC), time.Date(2019, 11, 3, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2019, 11, 2, 12, 7, 30, 0, time.UTC), time.Date(2019, 11, 2, 12, 15, 0, 0, time.UTC)},
+		{"30 1 1 jan-mar *", time.Date(2019, 11, 2, 12, 0, 0, 0, time.UTC), time.Date(2020, 1, 1, 1, 30, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 13 * fri", time.Date(2019, 11, 2, 12, 0, 0, 0, time.UTC), time.Date(2019, 11, 8, 12, 0, 0, 0, time.UTC)},
+		{"0 22 * * Mon-Fri", time.Date(2019, 11, 2, 23, 0, 0, 0, time.FixedZone("EST", -5*3600)), time.Date(2019, 11, 4, 22, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Date(2019, 11, 2, 12, 0, 0, 0, time.UTC), time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		cron, err := ParseCron(tc.expression)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.expression, err)
+		}
+		if next := cron.Next(tc.after); !next.Equal(tc.next) {
+			t.Errorf("%s after %s: expected %s, got %s", tc.expression, tc.after, tc.next, next)
+		}
+	}
+}
+
+func TestParseCronRejectsInvalid(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"0 3 * *",
+		"0 3 * * * *",
+		"60 3 * * *",
+		"0 24 * * *",
+		"0 3 0 * *",
+		"0 3 * 13 *",
+		"0 3 * * 8",
+		"0 3 * * Someday",
+		"*/0 * * * *",
+		"5-1 * * * *",
+	} {
+		if _, err := ParseCron(expression); err == nil {
+			t.Errorf("Expected an error for %q", expression)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package schedule computes when recurring windows of time are open, and when
+// cron expressions next match.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a span of time which opens at the same UTC time of day on each of
+// its days
+type Window struct {
+	// Days the window opens on, every day when empty
+	Days []time.Weekday
+
+	// Start is the offset from UTC midnight that the window opens at
+	Start    time.Duration
+	Duration time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWindow builds a Window from three letter day names, e.g. Mon, and a
+// start time formatted as HH:MM
+func ParseWindow(days []string, start string, duration time.Duration) (Window, error) {
+	window := Window{Duration: duration}
+	if duration <= 0 || duration > 7*24*time.Hour {
+		return Window{}, fmt.Errorf("Window duration must be positive and at most a week: %s", duration)
+	}
+
+	for _, day := range days {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return Window{}, fmt.Errorf("Unknown day of the week: %s", day)
+		}
+		window.Days = append(window.Days, weekday)
+	}
+
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return Window{}, fmt.Errorf("Window start must be formatted as HH:MM: %w", err)
+	}
+	window.Start = time.Duration(startTime.Hour())*time.Hour + time.Duration(startTime.Minute())*time.Minute
+
+	return window, nil
+}
+
+// opensOn returns true if the window opens on the day
+func (w Window) opensOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, candidate := range w.Days {
+		if candidate == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Open returns true if any of the windows is open at the time, otherwise it
+// returns the time that the next window opens. No windows at all means that
+// there is no restriction, and so is always open.
+func Open(windows []Window, now time.Time) (bool, time.Time) {
+	if len(windows) == 0 {
+		return true, now
+	}
+
+	now = now.UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var next time.Time
+	for _, window := range windows {
+		// Windows may be a week long, so one which opened up to a week ago
+		// could still be open
+		for offset := -7; offset <= 7; offset++ {
+			day := midnight.AddDate(0, 0, offset)
+			if !window.opensOn(day.Weekday()) {
+				continue
+			}
+
+			opens := day.Add(window.Start)
+			if !now.Before(opens) && now.Before(opens.Add(window.Duration)) {
+				return true, now
+			}
+			if opens.After(now) && (next.IsZero() || opens.Before(next)) {
+				next = opens
+			}
+		}
+	}
+
+	return false, next
+}
@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, days []string, start string, duration time.Duration) Window {
+	window, err := ParseWindow(days, start, duration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return window
+}
+
+func TestOpen(t *testing.T) {
+	weekends := mustParse(t, []string{"Sat", "sun"}, "22:00", 4*time.Hour)
+	daily := mustParse(t, nil, "03:30", 30*time.Minute)
+
+	// 2019-11-02 is a Saturday
+	testCases := []struct {
+		name     string
+		windows  []Window
+		now      time.Time
+		expected bool
+		next     time.Time
+	}{
+		{"no windows", nil, time.Date(2019, 11, 4, 12, 0, 0, 0, time.UTC), true, time.Time{}},
+		{"inside", []Window{weekends}, time.Date(2019, 11, 2, 23, 0, 0, 0, time.UTC), true, time.Time{}},
+		{"past midnight", []Window{weekends}, time.Date(2019, 11, 3, 1, 59, 0, 0, time.UTC), true, time.Time{}},
+		{"monday carry over", []Window{weekends}, time.Date(2019, 11, 4, 1, 0, 0, 0, time.UTC), true, time.Time{}},
+		{"closed at end", []Window{weekends}, time.Date(2019, 11, 4, 2, 0, 0, 0, time.UTC), false, time.Date(2019, 11, 9, 22, 0, 0, 0, time.UTC)},
+		{"before start", []Window{weekends}, time.Date(2019, 11, 2, 21, 0, 0, 0, time.UTC), false, time.Date(2019, 11, 2, 22, 0, 0, 0, time.UTC)},
+		{"earliest of several", []Window{weekends, daily}, time.Date(2019, 11, 5, 12, 0, 0, 0, time.UTC), false, time.Date(2019, 11, 6, 3, 30, 0, 0, time.UTC)},
+		{"other timezone", []Window{daily}, time.Date(2019, 11, 5, 22, 45, 0, 0, time.FixedZone("EST", -5*3600)), true, time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		open, next := Open(tc.windows, tc.now)
+		if open != tc.expected {
+			t.Errorf("%s: expected open %v, got %v", tc.name, tc.expected, open)
+		}
+		if !open && !next.Equal(tc.next) {
+			t.Errorf("%s: expected next window at %s, got %s", tc.name, tc.next, next)
+		}
+	}
+}
+
+func TestParseWindowRejectsInvalid(t *testing.T) {
+	testCases := []struct {
+		days     []string
+		start    string
+		duration time.Duration
+	}{
+		{[]string{"Someday"}, "01:00", time.Hour},
+		{nil, "25:00", time.Hour},
+		{nil, "1am", time.Hour},
+		{nil, "01:00", 0},
+		{nil, "01:00", 8 * 24 * time.Hour},
+	}
+
+	for _, tc := range testCases {
+		if _, err := ParseWindow(tc.days, tc.start, tc.duration); err == nil {
+			t.Errorf("expected an error for %v %s %s", tc.days, tc.start, tc.duration)
+		}
+	}
+}
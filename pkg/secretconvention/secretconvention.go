@@ -0,0 +1,178 @@
+// Package secretconvention renders the names, labels and annotations of the
+// Secrets which the operator generates from templates, so that policy engines
+// can rely on every Secret carrying the same metadata.
+package secretconvention
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultNameTemplate names credentials Secrets after the database and the
+// schema version, which is how they were always named
+const DefaultNameTemplate = "{{.Database}}-{{.Version}}"
+
+// Kinds of generated Secrets
+const (
+	KindCredentials       = "credentials"
+	KindCredentialRequest = "credential-request"
+	KindReplica           = "replica"
+	KindConnection        = "connection"
+)
+
+// Target describes the Secret being rendered
+type Target struct {
+	Namespace string
+	Database  string
+
+	// Labels are the labels of the ManagedDatabase
+	Labels map[string]string
+
+	// Version is the schema version of credentials Secrets, and empty for
+	// the other kinds
+	Version string
+
+	// Kind is what the Secret holds
+	Kind string
+}
+
+// Convention is a set of templates, each of which is a Go template of the
+// Target
+type Convention struct {
+	// NameTemplate names the credentials Secret of each schema version
+	NameTemplate string
+
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// Name renders the name of the credentials Secret of the target version
+func (c Convention) Name(target Target) (string, error) {
+	nameTemplate := c.NameTemplate
+	if nameTemplate == "" {
+		nameTemplate = DefaultNameTemplate
+	}
+
+	name, err := render("name", nameTemplate, target)
+	if err != nil {
+		return "", err
+	}
+	if problems := validation.IsDNS1123Subdomain(name); len(problems) > 0 {
+		return "", fmt.Errorf("Secret name %q is invalid: %s", name, strings.Join(problems, ", "))
+	}
+	return name, nil
+}
+
+// Metadata renders the labels and annotations of the target's Secret
+func (c Convention) Metadata(target Target) (map[string]string, map[string]string, error) {
+	labels := make(map[string]string, len(c.Labels))
+	for _, key := range sortedKeys(c.Labels) {
+		if problems := validation.IsQualifiedName(key); len(problems) > 0 {
+			return nil, nil, fmt.Errorf("Label key %q is invalid: %s", key, strings.Join(problems, ", "))
+		}
+		value, err := render("label "+key, c.Labels[key], target)
+		if err != nil {
+			return nil, nil, err
+		}
+		if problems := validation.IsValidLabelValue(value); len(problems) > 0 {
+			return nil, nil, fmt.Errorf("Value %q of label %s is invalid: %s", value, key, strings.Join(problems, ", "))
+		}
+		labels[key] = value
+	}
+
+	annotations := make(map[string]string, len(c.Annotations))
+	for _, key := range sortedKeys(c.Annotations) {
+		if problems := validation.IsQualifiedName(key); len(problems) > 0 {
+			return nil, nil, fmt.Errorf("Annotation key %q is invalid: %s", key, strings.Join(problems, ", "))
+		}
+		value, err := render("annotation "+key, c.Annotations[key], target)
+		if err != nil {
+			return nil, nil, err
+		}
+		annotations[key] = value
+	}
+	return labels, annotations, nil
+}
+
+// Validate checks that every template parses and the keys are valid, without
+// rendering them
+func (c Convention) Validate() error {
+	if _, err := parse("name", c.NameTemplate); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(c.Labels) {
+		if problems := validation.IsQualifiedName(key); len(problems) > 0 {
+			return fmt.Errorf("Label key %q is invalid: %s", key, strings.Join(problems, ", "))
+		}
+		if _, err := parse("label "+key, c.Labels[key]); err != nil {
+			return err
+		}
+	}
+	for _, key := range sortedKeys(c.Annotations) {
+		if problems := validation.IsQualifiedName(key); len(problems) > 0 {
+			return fmt.Errorf("Annotation key %q is invalid: %s", key, strings.Join(problems, ", "))
+		}
+		if _, err := parse("annotation "+key, c.Annotations[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Equal is true when both conventions render the same metadata
+func (c Convention) Equal(other Convention) bool {
+	name, otherName := c.NameTemplate, other.NameTemplate
+	if name == "" {
+		name = DefaultNameTemplate
+	}
+	if otherName == "" {
+		otherName = DefaultNameTemplate
+	}
+	return name == otherName && equalMaps(c.Labels, other.Labels) && equalMaps(c.Annotations, other.Annotations)
+}
+
+func parse(name, text string) (*template.Template, error) {
+	parsed, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse %s template: %w", name, err)
+	}
+	return parsed, nil
+}
+
+func render(name, text string, target Target) (string, error) {
+	parsed, err := parse(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered strings.Builder
+	if err := parsed.Execute(&rendered, target); err != nil {
+		return "", fmt.Errorf("Unable to render %s template: %w", name, err)
+	}
+	return rendered.String(), nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func equalMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
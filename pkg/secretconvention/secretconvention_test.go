@@ -0,0 +1,119 @@
+package secretconvention
+
+import (
+	"testing"
+)
+
+var testTarget = Target{
+	Namespace: "quay",
+	Database:  "quayio",
+	Labels:    map[string]string{"team": "quay", "service": "registry"},
+	Version:   "v3",
+	Kind:      KindCredentials,
+}
+
+func TestName(t *testing.T) {
+	for _, tc := range []struct {
+		template string
+		expected string
+	}{
+		{"", "quayio-v3"},
+		{DefaultNameTemplate, "quayio-v3"},
+		{"{{.Labels.service}}-{{.Database}}-{{.Version}}-credentials", "registry-quayio-v3-credentials"},
+	} {
+		name, err := Convention{NameTemplate: tc.template}.Name(testTarget)
+		if err != nil {
+			t.Errorf("%q: %v", tc.template, err)
+		} else if name != tc.expected {
+			t.Errorf("%q: expected %s, got %s", tc.template, tc.expected, name)
+		}
+	}
+
+	for _, invalid := range []string{
+		"{{.Database}}_{{.Version}}",
+		"{{.Labels.owner}}-{{.Version}}",
+		"{{.Cluster}}",
+		"{{.Database",
+	} {
+		if name, err := (Convention{NameTemplate: invalid}).Name(testTarget); err == nil {
+			t.Errorf("%q: expected an error, got %s", invalid, name)
+		}
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	convention := Convention{
+		Labels: map[string]string{
+			"team":                "{{.Labels.team}}",
+			"example.com/kind":    "{{.Kind}}",
+			"data-classification": "confidential",
+		},
+		Annotations: map[string]string{
+			"example.com/owner": "{{.Namespace}}/{{.Database}}",
+		},
+	}
+	labels, annotations, err := convention.Metadata(testTarget)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedLabels := map[string]string{
+		"team":                "quay",
+		"example.com/kind":    "credentials",
+		"data-classification": "confidential",
+	}
+	if !equalMaps(labels, expectedLabels) {
+		t.Errorf("expected labels %v, got %v", expectedLabels, labels)
+	}
+	if annotations["example.com/owner"] != "quay/quayio" || len(annotations) != 1 {
+		t.Errorf("unexpected annotations %v", annotations)
+	}
+
+	for _, invalid := range []Convention{
+		{Labels: map[string]string{"team": "{{.Labels.owner}}"}},
+		{Labels: map[string]string{"team": "two words"}},
+		{Labels: map[string]string{"not a key": "quay"}},
+		{Annotations: map[string]string{"/owner": "quay"}},
+	} {
+		if _, _, err := invalid.Metadata(testTarget); err == nil {
+			t.Errorf("%v: expected an error", invalid)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Convention{
+		NameTemplate: "{{.Database}}-{{.Version}}-creds",
+		Labels:       map[string]string{"team": "{{index .Labels \"team\"}}"},
+		Annotations:  map[string]string{"example.com/owner": "{{.Namespace}}"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	for _, invalid := range []Convention{
+		{NameTemplate: "{{.Database"},
+		{Labels: map[string]string{"team": "{{end}}"}},
+		{Labels: map[string]string{"-team": "quay"}},
+		{Annotations: map[string]string{"example.com/owner": "{{"}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("%v: expected an error", invalid)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if !(Convention{}).Equal(Convention{NameTemplate: DefaultNameTemplate}) {
+		t.Error("expected the default name template to equal an empty one")
+	}
+	if (Convention{}).Equal(Convention{NameTemplate: "{{.Database}}-{{.Version}}-creds"}) {
+		t.Error("expected a different name template to be unequal")
+	}
+	if (Convention{Labels: map[string]string{"team": "quay"}}).Equal(Convention{Labels: map[string]string{"team": "clair"}}) {
+		t.Error("expected different labels to be unequal")
+	}
+	if !(Convention{Labels: map[string]string{}}).Equal(Convention{}) {
+		t.Error("expected empty labels to equal none")
+	}
+}
@@ -0,0 +1,182 @@
+// Package secretformat renders credentials in the shapes common application
+// frameworks read their database settings in, so that apps can mount the
+// credentials Secrets without any glue code.
+package secretformat
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported formats
+const (
+	Spring     = "spring"
+	Django     = "django"
+	SQLAlchemy = "sqlalchemy"
+	Rails      = "rails"
+)
+
+// Connection contains everything a format may need to connect to a MySQL
+// database
+type Connection struct {
+	Host     string
+	Port     int
+	Database string
+	Username string
+	Password string
+
+	// TLS is one of the TLS modes of connection specs: disabled, preferred,
+	// required or skip-verify
+	TLS string
+}
+
+// keys contains the Secret key each format is written to
+var keys = map[string]string{
+	Spring:     "application.properties",
+	Django:     "DATABASE_URL",
+	SQLAlchemy: "SQLALCHEMY_DATABASE_URI",
+	Rails:      "database.yml",
+}
+
+// sslModes maps the TLS modes to the ssl modes of the MySQL client libraries
+// the frameworks use. Connections which require TLS always verify the server,
+// like the operator's own.
+var sslModes = map[string]string{
+	"disabled":    "DISABLED",
+	"preferred":   "PREFERRED",
+	"required":    "VERIFY_IDENTITY",
+	"skip-verify": "REQUIRED",
+}
+
+// Keys returns the Secret keys which any of the formats is written to,
+// sorted
+func Keys() []string {
+	all := make([]string, 0, len(keys))
+	for _, key := range keys {
+		all = append(all, key)
+	}
+	sort.Strings(all)
+	return all
+}
+
+// Render returns the Secret keys the format adds for the connection
+func Render(format string, conn Connection) (map[string]string, error) {
+	key, ok := keys[format]
+	if !ok {
+		return nil, fmt.Errorf("Unknown secret format: %s", format)
+	}
+	if conn.Host == "" {
+		return nil, fmt.Errorf("Secret format %s requires a host", format)
+	}
+
+	sslMode := ""
+	if conn.TLS != "" {
+		if sslMode, ok = sslModes[conn.TLS]; !ok {
+			return nil, fmt.Errorf("Unknown TLS mode: %s", conn.TLS)
+		}
+	}
+
+	var rendered string
+	switch format {
+	case Spring:
+		rendered = springProperties(conn, sslMode)
+	case Django:
+		rendered = databaseURL("mysql", conn, sslMode)
+	case SQLAlchemy:
+		rendered = databaseURL("mysql+mysqldb", conn, sslMode)
+	case Rails:
+		rendered = railsDatabase(conn, strings.ToLower(sslMode))
+	}
+	return map[string]string{key: rendered}, nil
+}
+
+// address returns host:port, leaving out the port when it is the default
+func address(conn Connection) string {
+	if conn.Port == 0 {
+		if strings.Contains(conn.Host, ":") {
+			return "[" + conn.Host + "]"
+		}
+		return conn.Host
+	}
+	return net.JoinHostPort(conn.Host, strconv.Itoa(conn.Port))
+}
+
+// databaseURL returns the URL form which both dj-database-url and
+// SQLAlchemy parse, whose query parameters are passed on to mysqlclient
+func databaseURL(scheme string, conn Connection, sslMode string) string {
+	query := url.Values{}
+	if sslMode != "" {
+		query.Set("ssl_mode", sslMode)
+	}
+	return (&url.URL{
+		Scheme:   scheme,
+		User:     url.UserPassword(conn.Username, conn.Password),
+		Host:     address(conn),
+		Path:     "/" + conn.Database,
+		RawQuery: query.Encode(),
+	}).String()
+}
+
+// springProperties returns the spring.datasource properties for
+// MySQL Connector/J
+func springProperties(conn Connection, sslMode string) string {
+	jdbcURL := fmt.Sprintf("jdbc:mysql://%s/%s", address(conn), url.PathEscape(conn.Database))
+	if sslMode != "" {
+		jdbcURL += "?sslMode=" + sslMode
+	}
+
+	var properties strings.Builder
+	for _, property := range [][2]string{
+		{"spring.datasource.url", jdbcURL},
+		{"spring.datasource.username", conn.Username},
+		{"spring.datasource.password", conn.Password},
+	} {
+		fmt.Fprintf(&properties, "%s=%s\n", property[0], propertyValue(property[1]))
+	}
+	return properties.String()
+}
+
+// propertyValue escapes the characters which .properties files treat
+// specially in values
+func propertyValue(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`).Replace(value)
+	if strings.HasPrefix(escaped, " ") {
+		escaped = `\` + escaped
+	}
+	return escaped
+}
+
+// railsDatabase returns a database.yml for the mysql2 adapter. Rails runs
+// database.yml through ERB, so the settings apply to whichever environment
+// the app is started in.
+func railsDatabase(conn Connection, sslMode string) string {
+	var database strings.Builder
+	database.WriteString("<%= Rails.env %>:\n")
+	database.WriteString("  adapter: mysql2\n")
+	settings := [][2]string{
+		{"host", conn.Host},
+		{"database", conn.Database},
+		{"username", conn.Username},
+		{"password", conn.Password},
+	}
+	if sslMode != "" {
+		settings = append(settings, [2]string{"ssl_mode", sslMode})
+	}
+	for _, setting := range settings {
+		fmt.Fprintf(&database, "  %s: %s\n", setting[0], yamlValue(setting[1]))
+	}
+	if conn.Port != 0 {
+		fmt.Fprintf(&database, "  port: %d\n", conn.Port)
+	}
+	return database.String()
+}
+
+// yamlValue quotes the value so that YAML never reads it as anything but a
+// string, and ERB leaves it alone
+func yamlValue(value string) string {
+	return strings.Replace(strconv.Quote(value), "<%", "<%%", -1)
+}
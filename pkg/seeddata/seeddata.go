@@ -0,0 +1,177 @@
+// Package seeddata reads the reference tables and bootstrap rows which
+// ManagedDatabases seed from ConfigMaps into the steps that apply them.
+package seeddata
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+	"github.com/app-sre/dba-operator/pkg/sqllint"
+)
+
+// Suffixes of the keys which are read, other keys are ignored
+const (
+	sqlSuffix = ".sql"
+	csvSuffix = ".csv"
+)
+
+// statementRules are the statements which seed data may contain
+var statementRules = sqllint.Rules{Verbs: []string{"INSERT", "REPLACE", "UPDATE", "DELETE"}}
+
+// Parse reads the keys of a ConfigMap in order. Keys ending in .sql contain
+// statements separated by semicolons, and keys ending in .csv contain the
+// rows of the table which the key is named after, below a header naming the
+// columns.
+func Parse(data map[string]string) ([]dbadmin.SeedStep, error) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if strings.HasSuffix(key, sqlSuffix) || strings.HasSuffix(key, csvSuffix) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("Seed data contains no %s or %s keys", sqlSuffix, csvSuffix)
+	}
+	sort.Strings(keys)
+
+	var steps []dbadmin.SeedStep
+	for _, key := range keys {
+		if strings.HasSuffix(key, sqlSuffix) {
+			statements, err := SplitStatements(data[key])
+			if err != nil {
+				return nil, fmt.Errorf("Unable to read seed data (%s): %w", key, err)
+			}
+			for _, statement := range statements {
+				if err := statementRules.Lint(statement); err != nil {
+					return nil, fmt.Errorf("Unable to read seed data (%s): %w", key, err)
+				}
+				steps = append(steps, dbadmin.SeedStep{Statement: statement})
+			}
+			continue
+		}
+
+		rows, err := parseRows(strings.TrimSuffix(key, csvSuffix), data[key])
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read seed data (%s): %w", key, err)
+		}
+		steps = append(steps, rows)
+	}
+	return steps, nil
+}
+
+func parseRows(table, raw string) (dbadmin.SeedStep, error) {
+	if table == "" {
+		return dbadmin.SeedStep{}, fmt.Errorf("CSV keys must be named after their table")
+	}
+
+	reader := csv.NewReader(strings.NewReader(raw))
+	reader.TrimLeadingSpace = true
+
+	columns, err := reader.Read()
+	if err == io.EOF {
+		return dbadmin.SeedStep{}, fmt.Errorf("A header naming the columns is required")
+	} else if err != nil {
+		return dbadmin.SeedStep{}, err
+	}
+	for _, column := range columns {
+		if column == "" {
+			return dbadmin.SeedStep{}, fmt.Errorf("Every column in the header must be named")
+		}
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return dbadmin.SeedStep{}, err
+	}
+	return dbadmin.SeedStep{Table: table, Columns: columns, Rows: rows}, nil
+}
+
+// SplitStatements splits SQL on the semicolons which end its statements,
+// leaving out comments and empty statements. Semicolons inside quotes don't
+// end a statement.
+func SplitStatements(sql string) ([]string, error) {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end, err := quotedEnd(sql, i)
+			if err != nil {
+				return nil, err
+			}
+			current.WriteString(sql[i : end+1])
+			i = end
+		case c == '#' || strings.HasPrefix(sql[i:], "-- "):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end
+				current.WriteByte('\n')
+			}
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("Comment starting at offset %d is never closed", i)
+			}
+			i += end + 3
+			current.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements, nil
+}
+
+// quotedEnd returns the offset of the quote which closes the one at start.
+// Backslashes escape the next character except in backquoted identifiers,
+// and doubled quotes are read as two adjacent quoted strings.
+func quotedEnd(sql string, start int) (int, error) {
+	quote := sql[start]
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("Quote starting at offset %d is never closed", start)
+}
+
+// Checksum returns a digest of the ConfigMap data which changes whenever a
+// key which is read, or its value, does
+func Checksum(data map[string]string) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if strings.HasSuffix(key, sqlSuffix) || strings.HasSuffix(key, csvSuffix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	digest := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(digest, "%d:%s%d:%s", len(key), key, len(data[key]), data[key])
+	}
+	return hex.EncodeToString(digest.Sum(nil))
+}
@@ -0,0 +1,86 @@
+package seeddata
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/app-sre/dba-operator/pkg/dbadmin"
+)
+
+func TestSplitStatements(t *testing.T) {
+	statements, err := SplitStatements(`
+-- Roles which every install needs
+INSERT INTO role (name) VALUES ('admin'), ('read;only');
+# MySQL style comment; with a semicolon
+UPDATE role SET description = "it's \"quoted\"" WHERE name = 'admin' /* keep; */;
+INSERT INTO ` + "`odd;table`" + ` (id) VALUES (1)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"INSERT INTO role (name) VALUES ('admin'), ('read;only')",
+		`UPDATE role SET description = "it's \"quoted\"" WHERE name = 'admin'`,
+		"INSERT INTO `odd;table` (id) VALUES (1)",
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Errorf("Unexpected statements: %q", statements)
+	}
+
+	for _, sql := range []string{"INSERT INTO role VALUES ('admin)", "DELETE FROM role /* never closed"} {
+		if _, err := SplitStatements(sql); err == nil {
+			t.Errorf("Expected an error splitting %q", sql)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	steps, err := Parse(map[string]string{
+		"20-roles.sql":     "DELETE FROM role WHERE name = 'legacy';",
+		"10-mediatype.csv": "id, name\n1, text/plain\n2,\\N\n",
+		"README.md":        "ignored",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []dbadmin.SeedStep{
+		{Table: "10-mediatype", Columns: []string{"id", "name"}, Rows: [][]string{{"1", "text/plain"}, {"2", `\N`}}},
+		{Statement: "DELETE FROM role WHERE name = 'legacy'"},
+	}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("Unexpected steps: %+v", steps)
+	}
+
+	for _, data := range []map[string]string{
+		{"README.md": "nothing to seed"},
+		{"role.csv": ""},
+		{"role.csv": "id,name\n1\n"},
+		{".csv": "id\n1\n"},
+		{"roles.sql": "DELETE FROM role; DROP TABLE role;"},
+		{"roles.sql": "INSERT INTO role VALUES (1, 'admin';"},
+	} {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("Expected an error parsing %v", data)
+		}
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	data := map[string]string{"role.sql": "DELETE FROM role;", "notes.txt": "ignored"}
+	checksum := Checksum(data)
+
+	data["notes.txt"] = "changed"
+	if Checksum(data) != checksum {
+		t.Error("Expected keys which aren't read not to change the checksum")
+	}
+
+	data["role.sql"] = "DELETE FROM role WHERE 1;"
+	if Checksum(data) == checksum {
+		t.Error("Expected a changed statement to change the checksum")
+	}
+	if Checksum(map[string]string{"a.sql": "bc.sql"}) == Checksum(map[string]string{"a.sqlbc.sql": ""}) {
+		t.Error("Expected keys and values not to run together")
+	}
+}
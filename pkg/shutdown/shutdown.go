@@ -0,0 +1,98 @@
+// Package shutdown lets the operator finish the work which changes databases
+// before it exits, so that a pod eviction doesn't interrupt a reconcile
+// halfway through provisioning a user.
+package shutdown
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Drainer tracks the work in flight. Once draining no new work may begin,
+// and the work in flight is expected to stop at its next checkpoint. It is
+// safe for concurrent use.
+type Drainer struct {
+	mu       sync.Mutex
+	draining bool
+	inFlight map[string]int
+	drained  chan struct{}
+}
+
+// NewDrainer returns a Drainer which isn't draining
+func NewDrainer() *Drainer {
+	return &Drainer{inFlight: make(map[string]int), drained: make(chan struct{})}
+}
+
+// Begin records that the named work has started, and returns false without
+// recording it when the Drainer is draining. Every successful Begin must be
+// followed by a Done with the same name.
+func (d *Drainer) Begin(name string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining {
+		return false
+	}
+	d.inFlight[name]++
+	return true
+}
+
+// Done records that the named work has finished
+func (d *Drainer) Done(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.inFlight[name]--
+	if d.inFlight[name] <= 0 {
+		delete(d.inFlight, name)
+	}
+	d.closeIfDrained()
+}
+
+// Draining returns true once Drain has been called, work in flight checks it
+// at its checkpoints
+func (d *Drainer) Draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.draining
+}
+
+// Drain stops new work from beginning and waits up to timeout for the work
+// in flight to be done. It returns the names of the work which is still in
+// flight, sorted.
+func (d *Drainer) Drain(timeout time.Duration) []string {
+	d.mu.Lock()
+	if !d.draining {
+		d.draining = true
+		d.closeIfDrained()
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.drained:
+	case <-time.After(timeout):
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	remaining := make([]string, 0, len(d.inFlight))
+	for name := range d.inFlight {
+		remaining = append(remaining, name)
+	}
+	sort.Strings(remaining)
+	return remaining
+}
+
+// closeIfDrained must be called with mu held
+func (d *Drainer) closeIfDrained() {
+	if d.draining && len(d.inFlight) == 0 {
+		select {
+		case <-d.drained:
+		default:
+			close(d.drained)
+		}
+	}
+}
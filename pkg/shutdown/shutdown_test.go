@@ -0,0 +1,47 @@
+package shutdown
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDrainWaitsForWorkInFlight(t *testing.T) {
+	drainer := NewDrainer()
+	if !drainer.Begin("app/orders") {
+		t.Fatalf("Expected work to begin before draining")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		drainer.Done("app/orders")
+	}()
+
+	if remaining := drainer.Drain(time.Second); len(remaining) != 0 {
+		t.Errorf("Expected all work to be done, got %v", remaining)
+	}
+	if drainer.Begin("app/billing") {
+		t.Errorf("Expected no work to begin while draining")
+	}
+	if !drainer.Draining() {
+		t.Errorf("Expected the drainer to be draining")
+	}
+}
+
+func TestDrainTimesOut(t *testing.T) {
+	drainer := NewDrainer()
+	drainer.Begin("app/orders")
+	drainer.Begin("app/orders")
+	drainer.Begin("app/billing")
+	drainer.Done("app/orders")
+
+	remaining := drainer.Drain(10 * time.Millisecond)
+	if len(remaining) != 2 || remaining[0] != "app/billing" || remaining[1] != "app/orders" {
+		t.Errorf("Expected the work still in flight, got %v", remaining)
+	}
+}
+
+func TestDrainWithoutWork(t *testing.T) {
+	if remaining := NewDrainer().Drain(time.Minute); len(remaining) != 0 {
+		t.Errorf("Expected nothing in flight, got %v", remaining)
+	}
+}
@@ -0,0 +1,167 @@
+// Package silence creates Alertmanager silences which cover planned
+// operations on managed databases, so that expected disruption doesn't page
+// anyone.
+package silence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/app-sre/dba-operator/pkg/config"
+)
+
+// Target identifies the operation which the silence covers
+type Target struct {
+	Namespace string
+	Database  string
+
+	// Operation is the kind of operation, e.g. migration or rotation
+	Operation string
+
+	// Name further identifies the operation, e.g. the migration being run
+	Name string
+}
+
+// Silencer creates and expires silences
+type Silencer interface {
+	// Silence creates a silence for the alerts about the target which ends
+	// after the duration, and returns its ID. It returns an empty ID when
+	// silences aren't configured.
+	Silence(target Target, duration time.Duration) (string, error)
+
+	// Expire ends the silence with the ID early
+	Expire(id string) error
+}
+
+// Matcher is a single label matcher of an Alertmanager silence
+type Matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+}
+
+type silenceRequest struct {
+	Matchers  []Matcher `json:"matchers"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy"`
+	Comment   string    `json:"comment"`
+}
+
+type silenceResponse struct {
+	SilenceID string `json:"silenceID"`
+}
+
+// Render fills in the templated matcher values for the target
+func Render(matchers []config.SilenceMatcher, target Target) ([]Matcher, error) {
+	rendered := make([]Matcher, 0, len(matchers))
+	for _, matcher := range matchers {
+		valueTemplate, err := template.New(matcher.Name).Option("missingkey=error").Parse(matcher.Value)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse value of silence matcher (%s): %w", matcher.Name, err)
+		}
+
+		var value strings.Builder
+		if err := valueTemplate.Execute(&value, target); err != nil {
+			return nil, fmt.Errorf("Unable to render value of silence matcher (%s): %w", matcher.Name, err)
+		}
+
+		rendered = append(rendered, Matcher{Name: matcher.Name, Value: value.String(), IsRegex: matcher.IsRegex})
+	}
+	return rendered, nil
+}
+
+// AlertmanagerSilencer manages silences with the Alertmanager v2 API
+type AlertmanagerSilencer struct {
+	provider config.Provider
+	client   *http.Client
+	log      logr.Logger
+}
+
+// NewAlertmanagerSilencer will instantiate an AlertmanagerSilencer which uses
+// the Alertmanager and matchers in the current config at the time of each
+// silence.
+func NewAlertmanagerSilencer(provider config.Provider, log logr.Logger) *AlertmanagerSilencer {
+	return &AlertmanagerSilencer{
+		provider: provider,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		log:      log,
+	}
+}
+
+// Silence implements Silencer
+func (as *AlertmanagerSilencer) Silence(target Target, duration time.Duration) (string, error) {
+	silences := as.provider.Current().Silences
+	if silences.AlertmanagerURL == "" {
+		return "", nil
+	}
+
+	matchers, err := Render(silences.Matchers, target)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	body, err := json.Marshal(silenceRequest{
+		Matchers:  matchers,
+		StartsAt:  now,
+		EndsAt:    now.Add(duration),
+		CreatedBy: silences.CreatedBy,
+		Comment:   fmt.Sprintf("Planned %s %s of %s/%s", target.Operation, target.Name, target.Namespace, target.Database),
+	})
+	if err != nil {
+		return "", fmt.Errorf("Unable to encode silence: %w", err)
+	}
+
+	resp, err := as.client.Post(strings.TrimSuffix(silences.AlertmanagerURL, "/")+"/api/v2/silences", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("Unable to create silence: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("Alertmanager responded to silence with status %d", resp.StatusCode)
+	}
+
+	var created silenceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("Unable to parse created silence: %w", err)
+	}
+
+	as.log.Info("Created silence", "id", created.SilenceID, "operation", target.Operation, "namespace", target.Namespace, "database", target.Database, "endsAt", now.Add(duration))
+	return created.SilenceID, nil
+}
+
+// Expire implements Silencer
+func (as *AlertmanagerSilencer) Expire(id string) error {
+	alertmanagerURL := as.provider.Current().Silences.AlertmanagerURL
+	if alertmanagerURL == "" {
+		return fmt.Errorf("Unable to expire silence %s, no Alertmanager is configured", id)
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, strings.TrimSuffix(alertmanagerURL, "/")+"/api/v2/silence/"+url.PathEscape(id), nil)
+	if err != nil {
+		return fmt.Errorf("Unable to expire silence %s: %w", id, err)
+	}
+
+	resp, err := as.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Unable to expire silence %s: %w", id, err)
+	}
+	resp.Body.Close()
+
+	// Silences which have already ended can't be found
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("Alertmanager responded to expiring silence %s with status %d", id, resp.StatusCode)
+	}
+
+	as.log.Info("Expired silence", "id", id)
+	return nil
+}
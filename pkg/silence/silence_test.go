@@ -0,0 +1,98 @@
+package silence
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/app-sre/dba-operator/pkg/config"
+)
+
+var testTarget = Target{Namespace: "quay", Database: "quayio", Operation: "migration", Name: "v3"}
+
+func TestRender(t *testing.T) {
+	matchers, err := Render([]config.SilenceMatcher{
+		{Name: "namespace", Value: "{{.Namespace}}"},
+		{Name: "alertname", Value: "MySQL.*", IsRegex: true},
+		{Name: "database", Value: "{{.Database}}-{{.Operation}}"},
+	}, testTarget)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Matcher{
+		{Name: "namespace", Value: "quay"},
+		{Name: "alertname", Value: "MySQL.*", IsRegex: true},
+		{Name: "database", Value: "quayio-migration"},
+	}
+	if len(matchers) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, matchers)
+	}
+	for i := range expected {
+		if matchers[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], matchers[i])
+		}
+	}
+
+	if _, err := Render([]config.SilenceMatcher{{Name: "database", Value: "{{.Cluster}}"}}, testTarget); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestSilenceAndExpire(t *testing.T) {
+	var created silenceRequest
+	var expired string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Error(err)
+			}
+			w.Write([]byte(`{"silenceID":"abc123"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v2/silence/abc123":
+			expired = "abc123"
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.Default()
+	cfg.Silences.AlertmanagerURL = server.URL + "/"
+	cfg.Silences.Matchers = []config.SilenceMatcher{{Name: "database", Value: "{{.Database}}"}}
+	silencer := NewAlertmanagerSilencer(config.Static(cfg), logf.NullLogger{})
+
+	id, err := silencer.Silence(testTarget, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "abc123" {
+		t.Errorf("unexpected silence id: %s", id)
+	}
+	if len(created.Matchers) != 1 || created.Matchers[0].Value != "quayio" || created.CreatedBy != "dba-operator" {
+		t.Errorf("unexpected silence: %+v", created)
+	}
+	if created.EndsAt.Sub(created.StartsAt) != time.Hour {
+		t.Errorf("expected the silence to last an hour, got %s", created.EndsAt.Sub(created.StartsAt))
+	}
+
+	if err := silencer.Expire(id); err != nil {
+		t.Fatal(err)
+	}
+	if expired != id {
+		t.Error("expected the silence to be expired")
+	}
+}
+
+func TestSilenceDisabled(t *testing.T) {
+	silencer := NewAlertmanagerSilencer(config.Static(config.Default()), logf.NullLogger{})
+	id, err := silencer.Silence(testTarget, time.Hour)
+	if err != nil || id != "" {
+		t.Errorf("expected no silence, got %q %v", id, err)
+	}
+}
@@ -0,0 +1,307 @@
+// Package sqllint checks inline SQL before it is sent to a server. It finds
+// the mistakes a lexer can, such as unclosed quotes, comments, parentheses
+// and compound statements, and statements outside the verbs allowed where
+// the SQL is used. It doesn't parse the grammar, so a statement which passes
+// may still be refused by the server.
+package sqllint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules are the statements allowed in a field
+type Rules struct {
+	// Verbs are the keywords which allowed statements start with
+	Verbs []string
+
+	// Blocks allows a BEGIN ... END compound statement, whose statements
+	// may also be the flow control and variable statements of stored
+	// programs
+	Blocks bool
+}
+
+// procedural are the statements which may appear in compound statements,
+// besides the allowed verbs
+var procedural = map[string]bool{
+	"BEGIN": true, "DECLARE": true, "SET": true, "IF": true, "CASE": true,
+	"WHILE": true, "LOOP": true, "REPEAT": true, "LEAVE": true,
+	"ITERATE": true, "SIGNAL": true, "RESIGNAL": true, "OPEN": true,
+	"FETCH": true, "CLOSE": true,
+}
+
+// blockEnds are the keywords which may follow END, naming what it closes
+var blockEnds = map[string]bool{"IF": true, "CASE": true, "WHILE": true, "LOOP": true, "REPEAT": true}
+
+const (
+	tokenWord = iota
+	tokenString
+	tokenIdentifier
+	tokenPunct
+)
+
+type token struct {
+	kind   int
+	text   string
+	offset int
+}
+
+// keyword returns the upper case word, or nothing when the token isn't one
+func (t token) keyword() string {
+	if t.kind != tokenWord {
+		return ""
+	}
+	return strings.ToUpper(t.text)
+}
+
+// frame is an open compound statement, or a CASE expression
+type frame struct {
+	keyword    string
+	expression bool
+	offset     int
+	depth      int
+
+	// until is set once the condition of a REPEAT has started, which is
+	// followed by its END without a semicolon
+	until bool
+}
+
+// Lint returns an error describing the first problem found in the SQL, which
+// must be a single statement
+func (r Rules) Lint(sql string) error {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 || (len(tokens) == 1 && tokens[0].text == ";") {
+		return fmt.Errorf("No statement was found")
+	}
+
+	verbs := make(map[string]bool, len(r.Verbs))
+	for _, verb := range r.Verbs {
+		verbs[strings.ToUpper(verb)] = true
+	}
+
+	var frames []frame
+	var depth int
+	start, ended := true, false
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if ended {
+			if tok.text == ";" && tok.kind == tokenPunct {
+				continue
+			}
+			return fmt.Errorf("Only a single statement is allowed, another starts at offset %d", tok.offset)
+		}
+
+		if tok.kind == tokenPunct {
+			switch tok.text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+				if depth < 0 {
+					return fmt.Errorf("Parenthesis at offset %d is never opened", tok.offset)
+				}
+			case ";":
+				if depth > 0 {
+					return fmt.Errorf("Statement ends at offset %d inside parentheses", tok.offset)
+				}
+				if len(frames) == 0 {
+					ended = true
+				} else if frames[len(frames)-1].expression {
+					return fmt.Errorf("Statement ends at offset %d inside the CASE at offset %d", tok.offset, frames[len(frames)-1].offset)
+				}
+				start = true
+				continue
+			}
+			if start {
+				return fmt.Errorf("Expected a statement at offset %d", tok.offset)
+			}
+			continue
+		}
+
+		keyword := tok.keyword()
+		var top *frame
+		if len(frames) > 0 {
+			top = &frames[len(frames)-1]
+		}
+
+		if start {
+			if keyword == "" {
+				return fmt.Errorf("Expected a statement at offset %d", tok.offset)
+			}
+			// Labels of compound statements
+			if (top != nil || r.Blocks) && i+1 < len(tokens) && tokens[i+1].text == ":" {
+				i++
+				continue
+			}
+			start = false
+
+			switch keyword {
+			case "END":
+				if top == nil || top.expression {
+					return fmt.Errorf("END at offset %d closes nothing", tok.offset)
+				}
+				closes := "BEGIN"
+				if i+1 < len(tokens) && blockEnds[tokens[i+1].keyword()] {
+					i++
+					closes = tokens[i].keyword()
+				}
+				if closes != top.keyword {
+					return fmt.Errorf("END %s at offset %d can't close the %s at offset %d", closes, tok.offset, top.keyword, top.offset)
+				}
+				if depth != top.depth {
+					return fmt.Errorf("Parentheses inside the %s at offset %d are never closed", top.keyword, top.offset)
+				}
+				frames = frames[:len(frames)-1]
+				if len(frames) == 0 {
+					ended = true
+				}
+				continue
+			case "ELSEIF", "WHEN", "ELSE", "UNTIL":
+				expected := map[string]string{"ELSEIF": "IF", "WHEN": "CASE", "UNTIL": "REPEAT"}[keyword]
+				if top == nil || top.expression || (expected != "" && top.keyword != expected) || (keyword == "ELSE" && top.keyword != "IF" && top.keyword != "CASE") {
+					return fmt.Errorf("%s at offset %d is outside of a matching compound statement", keyword, tok.offset)
+				}
+				start = keyword == "ELSE"
+				top.until = keyword == "UNTIL"
+				continue
+			}
+
+			switch {
+			case top == nil && keyword == "BEGIN" && r.Blocks:
+			case top == nil && !verbs[keyword]:
+				return fmt.Errorf("%s statements aren't allowed here, only %s", keyword, strings.Join(r.Verbs, ", "))
+			case top != nil && !verbs[keyword] && !procedural[keyword]:
+				return fmt.Errorf("%s statements at offset %d aren't allowed here, only %s", keyword, tok.offset, strings.Join(r.Verbs, ", "))
+			}
+
+			switch keyword {
+			case "BEGIN", "LOOP", "REPEAT":
+				frames = append(frames, frame{keyword: keyword, offset: tok.offset, depth: depth})
+				start = true
+			case "IF", "CASE", "WHILE":
+				frames = append(frames, frame{keyword: keyword, offset: tok.offset, depth: depth})
+			}
+			continue
+		}
+
+		switch keyword {
+		case "THEN":
+			start = top != nil && !top.expression && depth == top.depth
+		case "DO":
+			start = top != nil && top.keyword == "WHILE" && depth == top.depth
+		case "CASE":
+			frames = append(frames, frame{keyword: keyword, expression: true, offset: tok.offset, depth: depth})
+		case "BEGIN":
+			// The body of a handler declared inside a compound statement
+			if top != nil && !top.expression {
+				frames = append(frames, frame{keyword: keyword, offset: tok.offset, depth: depth})
+				start = true
+			}
+		case "END":
+			if top != nil && top.until {
+				if i+1 == len(tokens) || tokens[i+1].keyword() != "REPEAT" {
+					return fmt.Errorf("END at offset %d can't close the REPEAT at offset %d", tok.offset, top.offset)
+				}
+				i++
+				frames = frames[:len(frames)-1]
+				ended = len(frames) == 0
+				continue
+			}
+			if top == nil || !top.expression {
+				return fmt.Errorf("Expected a semicolon before the END at offset %d", tok.offset)
+			}
+			if depth != top.depth {
+				return fmt.Errorf("Parentheses inside the CASE at offset %d are never closed", top.offset)
+			}
+			frames = frames[:len(frames)-1]
+		}
+	}
+
+	if depth > 0 {
+		return fmt.Errorf("Parentheses are never closed")
+	}
+	if len(frames) > 0 {
+		top := frames[len(frames)-1]
+		return fmt.Errorf("%s at offset %d is never closed with END", top.keyword, top.offset)
+	}
+	return nil
+}
+
+// tokenize splits SQL into words, quoted strings, quoted identifiers and
+// punctuation, leaving out whitespace and comments
+func tokenize(sql string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		case c == '\'' || c == '"' || c == '`':
+			end, err := quotedEnd(sql, i)
+			if err != nil {
+				return nil, err
+			}
+			kind := tokenString
+			if c == '`' {
+				kind = tokenIdentifier
+			}
+			tokens = append(tokens, token{kind: kind, text: sql[i : end+1], offset: i})
+			i = end
+		case c == '#' || isDashComment(sql[i:]):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return tokens, nil
+			}
+			i += end
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("Comment starting at offset %d is never closed", i)
+			}
+			i += end + 3
+		case isWordByte(c):
+			end := i + 1
+			for end < len(sql) && isWordByte(sql[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: sql[i:end], offset: i})
+			i = end - 1
+		default:
+			tokens = append(tokens, token{kind: tokenPunct, text: sql[i : i+1], offset: i})
+		}
+	}
+	return tokens, nil
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isDashComment is true at a -- comment, which MySQL requires to be followed
+// by whitespace
+func isDashComment(sql string) bool {
+	if !strings.HasPrefix(sql, "--") {
+		return false
+	}
+	return len(sql) == 2 || strings.IndexByte(" \t\r\n", sql[2]) >= 0
+}
+
+// quotedEnd returns the offset of the quote which closes the one at start.
+// Backslashes escape the next character except in quoted identifiers, and
+// doubled quotes are read as two adjacent quoted strings.
+func quotedEnd(sql string, start int) (int, error) {
+	quote := sql[start]
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("Quote starting at offset %d is never closed", start)
+}
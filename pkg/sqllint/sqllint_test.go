@@ -0,0 +1,68 @@
+package sqllint
+
+import (
+	"testing"
+)
+
+var scheduled = Rules{Verbs: []string{"DELETE", "UPDATE", "INSERT", "SELECT"}, Blocks: true}
+
+func TestLintAllowed(t *testing.T) {
+	for _, sql := range []string{
+		"DELETE FROM session WHERE expires < NOW()",
+		"delete from `session` where note = 'a;b' -- purge\n;",
+		"UPDATE job SET state = CASE WHEN retries > 3 THEN 'failed' ELSE state END /* ; */",
+		"BEGIN DELETE FROM session LIMIT 1000; UPDATE counters SET n = 0; END",
+		`purge: BEGIN
+			DECLARE done INT DEFAULT 0;
+			DECLARE CONTINUE HANDLER FOR SQLEXCEPTION BEGIN SET done = 1; END;
+			IF (SELECT COUNT(*) FROM session) > 10 THEN
+				DELETE FROM session LIMIT 10;
+			ELSEIF done THEN
+				LEAVE purge;
+			ELSE
+				UPDATE job SET state = 'idle';
+			END IF;
+			REPEAT
+				DELETE FROM session LIMIT 100;
+			UNTIL ROW_COUNT() = 0 END REPEAT;
+			WHILE done = 0 DO
+				SELECT 1 INTO done;
+			END WHILE;
+		END`,
+	} {
+		if err := scheduled.Lint(sql); err != nil {
+			t.Errorf("Unexpected error linting %q: %v", sql, err)
+		}
+	}
+}
+
+func TestLintRejected(t *testing.T) {
+	testCases := []struct {
+		rules Rules
+		sql   string
+	}{
+		{scheduled, ""},
+		{scheduled, "-- only a comment"},
+		{scheduled, "DELETE FROM session WHERE id = 'unclosed"},
+		{scheduled, "DELETE FROM session /* unclosed"},
+		{scheduled, "DELETE FROM session WHERE id IN (1, 2"},
+		{scheduled, "DELETE FROM session WHERE id = 1)"},
+		{scheduled, "DELETE FROM session; DROP TABLE users"},
+		{scheduled, "DROP TABLE session"},
+		{scheduled, "GRANT ALL ON *.* TO 'app'@'%'"},
+		{scheduled, "BEGIN DELETE FROM session; DROP TABLE users; END"},
+		{scheduled, "BEGIN IF 1 THEN DROP TABLE users; END IF; END"},
+		{scheduled, "BEGIN DELETE FROM session END"},
+		{scheduled, "BEGIN DELETE FROM session;"},
+		{scheduled, "BEGIN IF 1 THEN DELETE FROM session; END WHILE; END"},
+		{scheduled, "BEGIN DELETE FROM session; END; DELETE FROM job"},
+		{Rules{Verbs: []string{"DELETE"}}, "BEGIN DELETE FROM session; END"},
+		{Rules{Verbs: []string{"SELECT"}}, "UPDATE job SET state = 'idle'"},
+	}
+
+	for _, testCase := range testCases {
+		if err := testCase.rules.Lint(testCase.sql); err == nil {
+			t.Errorf("Expected an error linting %q", testCase.sql)
+		}
+	}
+}
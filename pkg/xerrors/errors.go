@@ -1,7 +1,10 @@
 package xerrors
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"time"
 )
 
 // EnhancedError contains additional information about whether or not the error
@@ -14,20 +17,82 @@ type EnhancedError interface {
 	Temporary() bool
 }
 
-type temporaryError struct {
-	message string
+// RetryHinter is implemented by temporary errors which know how long the
+// failed operation should wait before it is retried. A zero duration means
+// that there is no hint.
+type RetryHinter interface {
+	RetryAfter() time.Duration
+}
+
+// TemporaryError is always considered temporary
+type TemporaryError struct {
+	message    string
+	retryAfter time.Duration
 }
 
 // NewTempErrorf will create a new base error that is always considered
 // temporary and follows the calling convention of Sprintf.
-func NewTempErrorf(format string, arguments ...interface{}) error {
-	return temporaryError{message: fmt.Sprintf(format, arguments...)}
+func NewTempErrorf(format string, arguments ...interface{}) TemporaryError {
+	return TemporaryError{message: fmt.Sprintf(format, arguments...)}
+}
+
+// WithRetryAfter returns a copy of the error which hints that the operation
+// should be retried after the delay
+func (te TemporaryError) WithRetryAfter(delay time.Duration) TemporaryError {
+	te.retryAfter = delay
+	return te
 }
 
-func (te temporaryError) Error() string {
+func (te TemporaryError) Error() string {
 	return te.message
 }
 
-func (te temporaryError) Temporary() bool {
+// Temporary implements EnhancedError
+func (te TemporaryError) Temporary() bool {
 	return true
 }
+
+// RetryAfter implements RetryHinter
+func (te TemporaryError) RetryAfter() time.Duration {
+	return te.retryAfter
+}
+
+// MaxRetries is where retry counts saturate, the backoff stops growing long
+// before it is reached
+const MaxRetries = 32
+
+// CountRetry returns the retry count after one more retry, which saturates at
+// MaxRetries so that it can be stored without overflowing
+func CountRetry(retries int) int {
+	if retries < 0 {
+		return 1
+	}
+	if retries >= MaxRetries {
+		return MaxRetries
+	}
+	return retries + 1
+}
+
+// Backoff returns how long to wait before retrying an operation which failed
+// with err, after it had already been retried the given number of times. A
+// hint from the error is used as is, otherwise the base delay is doubled on
+// every retry. Neither exceeds limit, unless limit is zero.
+func Backoff(err error, retries int, base, limit time.Duration) time.Duration {
+	var delay time.Duration
+	var hinter RetryHinter
+	if errors.As(err, &hinter) {
+		delay = hinter.RetryAfter()
+	}
+
+	if delay <= 0 {
+		delay = base
+		for i := 0; i < retries && (limit == 0 || delay < limit) && delay < math.MaxInt64/2; i++ {
+			delay *= 2
+		}
+	}
+
+	if limit > 0 && delay > limit {
+		return limit
+	}
+	return delay
+}
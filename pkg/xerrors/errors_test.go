@@ -0,0 +1,44 @@
+package xerrors
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	plain := NewTempErrorf("3 active sessions remaining")
+	hinted := NewTempErrorf("Deadlock found").WithRetryAfter(5 * time.Second)
+
+	testCases := []struct {
+		name     string
+		err      error
+		retries  int
+		limit    time.Duration
+		expected time.Duration
+	}{
+		{"first retry", plain, 0, time.Hour, time.Minute},
+		{"doubles", plain, 3, time.Hour, 8 * time.Minute},
+		{"limited", plain, 10, time.Hour, time.Hour},
+		{"saturated count", plain, MaxRetries, time.Hour, time.Hour},
+		{"no limit", plain, 2, 0, 4 * time.Minute},
+		{"hint", hinted, 5, time.Hour, 5 * time.Second},
+		{"wrapped hint", fmt.Errorf("Unable to remove user: %w", hinted), 0, time.Hour, 5 * time.Second},
+		{"hint over limit", NewTempErrorf("x").WithRetryAfter(2 * time.Hour), 0, time.Hour, time.Hour},
+	}
+
+	for _, tc := range testCases {
+		if delay := Backoff(tc.err, tc.retries, time.Minute, tc.limit); delay != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, delay)
+		}
+	}
+}
+
+func TestCountRetrySaturates(t *testing.T) {
+	if CountRetry(0) != 1 || CountRetry(-4) != 1 {
+		t.Errorf("Expected counting to start at 1")
+	}
+	if CountRetry(MaxRetries) != MaxRetries || CountRetry(MaxRetries-1) != MaxRetries {
+		t.Errorf("Expected the count to saturate at %d", MaxRetries)
+	}
+}